@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// NewHTTPMiddleware guards http handler by ip allowlist and basic auth in config
+func NewHTTPMiddleware(config types.HTTPAuthConfig) (func(http.Handler) http.Handler, error) {
+	nets, err := parseAllowIPs(config.AllowIPs)
+	if err != nil {
+		return nil, err
+	}
+	username, password := config.Auth.Username, config.Auth.Password
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(nets) > 0 && !allowed(nets, r.RemoteAddr) {
+				log.Warnf("[HTTPAuth] Reject %s from %s", r.URL.Path, r.RemoteAddr)
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			if username != "" {
+				u, p, ok := r.BasicAuth()
+				if !ok || subtle.ConstantTimeCompare([]byte(u), []byte(username)) != 1 || subtle.ConstantTimeCompare([]byte(p), []byte(password)) != 1 {
+					w.Header().Set("WWW-Authenticate", `Basic realm="eru"`)
+					http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+					return
+				}
+			}
+			h.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// NewHTTPTLSConfig returns tls config of http listener, nil if cert not set
+func NewHTTPTLSConfig(config types.HTTPAuthConfig) (*tls.Config, error) {
+	if config.Cert == "" || config.Key == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(config.Cert, config.Key)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if config.CA == "" {
+		return tlsConfig, nil
+	}
+	ca, err := ioutil.ReadFile(config.CA)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, types.NewDetailedErr(types.ErrBadCA, config.CA)
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConfig, nil
+}
+
+func parseAllowIPs(ips []string) ([]*net.IPNet, error) {
+	nets := []*net.IPNet{}
+	for _, ip := range ips {
+		if !strings.Contains(ip, "/") {
+			parsed := net.ParseIP(ip)
+			if parsed == nil {
+				return nil, types.NewDetailedErr(types.ErrBadAllowIP, ip)
+			}
+			bits := 8 * net.IPv6len
+			if parsed.To4() != nil {
+				parsed, bits = parsed.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: parsed, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipnet, err := net.ParseCIDR(ip)
+		if err != nil {
+			return nil, types.NewDetailedErr(types.ErrBadAllowIP, ip)
+		}
+		nets = append(nets, ipnet)
+	}
+	return nets, nil
+}
+
+func allowed(nets []*net.IPNet, remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, ipnet := range nets {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	_, err := NewHTTPMiddleware(types.HTTPAuthConfig{AllowIPs: []string{"10.0.0.300"}})
+	assert.True(t, errors.Is(err, types.ErrBadAllowIP))
+
+	// nothing set, all pass
+	middleware, err := NewHTTPMiddleware(types.HTTPAuthConfig{})
+	assert.NoError(t, err)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	serve := func(remoteAddr, username, password string) int {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		r.RemoteAddr = remoteAddr
+		if username != "" {
+			r.SetBasicAuth(username, password)
+		}
+		w := httptest.NewRecorder()
+		middleware(ok).ServeHTTP(w, r)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, serve("1.2.3.4:1234", "", ""))
+
+	middleware, err = NewHTTPMiddleware(types.HTTPAuthConfig{
+		Auth:     types.AuthConfig{Username: "admin", Password: "password"},
+		AllowIPs: []string{"127.0.0.1", "10.0.0.0/8", "::1"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, serve("1.2.3.4:1234", "admin", "password"))
+	assert.Equal(t, http.StatusUnauthorized, serve("127.0.0.1:1234", "", ""))
+	assert.Equal(t, http.StatusUnauthorized, serve("10.1.2.3:1234", "admin", "wrong"))
+	assert.Equal(t, http.StatusOK, serve("10.1.2.3:1234", "admin", "password"))
+	assert.Equal(t, http.StatusOK, serve("[::1]:1234", "admin", "password"))
+}
+
+func TestHTTPTLSConfig(t *testing.T) {
+	config, err := NewHTTPTLSConfig(types.HTTPAuthConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+	_, err = NewHTTPTLSConfig(types.HTTPAuthConfig{Cert: "/tmp/notexists.pem", Key: "/tmp/notexists.key"})
+	assert.Error(t, err)
+}
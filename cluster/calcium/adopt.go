@@ -0,0 +1,113 @@
+package calcium
+
+import (
+	"context"
+
+	"github.com/projecteru2/core/store"
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// AdoptContainer register an existing engine container as eru container, reverse of dissociate
+func (c *Calcium) AdoptContainer(ctx context.Context, opts *types.AdoptOptions) (*types.Container, error) {
+	if opts.ID == "" {
+		return nil, types.ErrNoContainerIDs
+	}
+	if opts.Quota < 0 {
+		return nil, types.ErrBadCPU
+	}
+	if opts.Memory < 0 {
+		return nil, types.ErrBadMemory
+	}
+	if opts.Storage < 0 {
+		return nil, types.ErrBadStorage
+	}
+	var container *types.Container
+	err := c.withNodeLocked(ctx, opts.Nodename, func(node *types.Node) error {
+		info, err := node.Engine.VirtualizationInspect(ctx, opts.ID)
+		if err != nil {
+			return err
+		}
+		// rollback removes container data, so never adopt a known one
+		if _, err := c.store.GetContainer(ctx, info.ID); err == nil {
+			return types.NewDetailedErr(types.ErrKeyExists, info.ID)
+		}
+		name := opts.Name
+		if name == "" {
+			name = info.Name
+		}
+		if _, _, _, err := utils.ParseContainerName(name); err != nil {
+			return err
+		}
+		quota, memory := opts.Quota, opts.Memory
+		if quota == 0 {
+			quota = info.Quota
+		}
+		if memory == 0 {
+			memory = info.Memory
+		}
+		if err := c.checkAdoptResource(node, quota, memory, opts.Storage); err != nil {
+			return err
+		}
+
+		container = &types.Container{
+			ID:       info.ID,
+			Name:     name,
+			Podname:  node.Podname,
+			Nodename: node.Name,
+			Quota:    quota,
+			Memory:   memory,
+			Storage:  opts.Storage,
+			User:     info.User,
+			Env:      info.Env,
+			Image:    info.Image,
+			Labels:   info.Labels,
+			Engine:   node.Engine,
+		}
+		// adopted container counts against quotas of pod and app as a created one, it's refunded if adopting failed
+		usage := containerQuotaUsage(container)
+		if err := c.chargeContainerQuotas(ctx, container, usage); err != nil {
+			return err
+		}
+		err = utils.Txn(
+			ctx,
+			// if
+			func(ctx context.Context) error {
+				return c.store.AddContainer(ctx, container)
+			},
+			// then
+			func(ctx context.Context) error {
+				return c.store.UpdateNodeResource(ctx, node, nil, quota, memory, opts.Storage, nil, nil, nil, store.ActionDecr)
+			},
+			// rollback
+			func(ctx context.Context) error {
+				return c.store.RemoveContainer(ctx, container)
+			},
+			c.config.GlobalTimeout,
+		)
+		if err != nil {
+			c.refundContainerQuotas(ctx, container, usage)
+		}
+		return err
+	})
+	if err != nil {
+		log.Errorf("[AdoptContainer] Adopt container %s on node %s failed %v", opts.ID, opts.Nodename, err)
+		return nil, err
+	}
+	log.Infof("[AdoptContainer] Container %s adopted as %s", container.ID, container.Name)
+	return container, nil
+}
+
+func (c *Calcium) checkAdoptResource(node *types.Node, quota float64, memory, storage int64) error {
+	if quota > float64(node.InitCPU.Total())/float64(c.config.Scheduler.ShareBase) {
+		return types.NewDetailedErr(types.ErrInsufficientCPU, node.Name)
+	}
+	if memory > node.MemCap {
+		return types.NewDetailedErr(types.ErrInsufficientMEM, node.Name)
+	}
+	if storage > node.AvailableStorage() {
+		return types.NewDetailedErr(types.ErrInsufficientStorage, node.Name)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package calcium
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/docker/go-units"
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	lockmocks "github.com/projecteru2/core/lock/mocks"
+	"github.com/projecteru2/core/store"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAdoptContainer(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	st := &storemocks.Store{}
+	c.store = st
+	c.config.Scheduler.ShareBase = 100
+
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	st.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+
+	engine := &enginemocks.API{}
+	node1 := &types.Node{
+		Name:    "node1",
+		Podname: "p1",
+		MemCap:  units.GiB,
+		InitCPU: types.CPUMap{"0": 100, "1": 100},
+		Engine:  engine,
+	}
+	st.On("GetNode", mock.Anything, "node1").Return(node1, nil)
+
+	// bad resource
+	_, err := c.AdoptContainer(ctx, &types.AdoptOptions{Nodename: "node1", ID: "c1", Memory: -1})
+	assert.Error(t, err)
+	// failed by inspect
+	engine.On("VirtualizationInspect", mock.Anything, "c1").Return(nil, types.ErrNilEngine).Once()
+	_, err = c.AdoptContainer(ctx, &types.AdoptOptions{Nodename: "node1", ID: "c1"})
+	assert.Error(t, err)
+
+	info := &enginetypes.VirtualizationInfo{ID: "c1", Name: "handmade", Image: "alpine", Quota: 0.5, Memory: 10 * units.MiB}
+	engine.On("VirtualizationInspect", mock.Anything, "c1").Return(info, nil)
+	// already managed
+	st.On("GetContainer", mock.Anything, "c1").Return(&types.Container{ID: "c1"}, nil).Once()
+	_, err = c.AdoptContainer(ctx, &types.AdoptOptions{Nodename: "node1", ID: "c1"})
+	assert.Error(t, err)
+	st.On("GetContainer", mock.Anything, "c1").Return(nil, types.ErrContainerNotExists)
+	// invalid name
+	_, err = c.AdoptContainer(ctx, &types.AdoptOptions{Nodename: "node1", ID: "c1"})
+	assert.Error(t, err)
+	// insufficient cpu
+	_, err = c.AdoptContainer(ctx, &types.AdoptOptions{Nodename: "node1", ID: "c1", Name: "app_web_abcdef", Quota: 3})
+	assert.Contains(t, err.Error(), types.ErrInsufficientCPU.Error())
+	// quota exceeded
+	usage := types.QuotaUsage{CPU: 0.5, Memory: 10 * units.MiB, Containers: 1}
+	st.On("ChargeQuota", mock.Anything, types.QuotaScopePod, "p1", usage).Return(types.ErrQuotaExceeded).Once()
+	_, err = c.AdoptContainer(ctx, &types.AdoptOptions{Nodename: "node1", ID: "c1", Name: "app_web_abcdef"})
+	assert.True(t, errors.Is(err, types.ErrQuotaExceeded))
+	// app quota exceeded, pod quota is refunded
+	st.On("ChargeQuota", mock.Anything, types.QuotaScopePod, "p1", usage).Return(nil).Once()
+	st.On("ChargeQuota", mock.Anything, types.QuotaScopeApp, "app", usage).Return(types.ErrQuotaExceeded).Once()
+	st.On("ChargeQuota", mock.Anything, types.QuotaScopePod, "p1", usage.Neg()).Return(nil).Once()
+	_, err = c.AdoptContainer(ctx, &types.AdoptOptions{Nodename: "node1", ID: "c1", Name: "app_web_abcdef"})
+	assert.True(t, errors.Is(err, types.ErrQuotaExceeded))
+	st.AssertNotCalled(t, "AddContainer", mock.Anything, mock.Anything)
+	// failed by AddContainer, rolled back and refunded
+	for _, scope := range []string{types.QuotaScopePod, types.QuotaScopeApp} {
+		name := map[string]string{types.QuotaScopePod: "p1", types.QuotaScopeApp: "app"}[scope]
+		st.On("ChargeQuota", mock.Anything, scope, name, usage).Return(nil).Once()
+		st.On("ChargeQuota", mock.Anything, scope, name, usage.Neg()).Return(nil).Once()
+	}
+	st.On("AddContainer", mock.Anything, mock.Anything).Return(types.ErrNoETCD).Once()
+	st.On("RemoveContainer", mock.Anything, mock.Anything).Return(nil)
+	_, err = c.AdoptContainer(ctx, &types.AdoptOptions{Nodename: "node1", ID: "c1", Name: "app_web_abcdef"})
+	assert.Error(t, err)
+	st.AssertNotCalled(t, "UpdateNodeResource", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	st.AssertExpectations(t)
+	// success, resource inspected from engine
+	st.On("ChargeQuota", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	st.On("AddContainer", mock.Anything, mock.Anything).Return(nil)
+	st.On("UpdateNodeResource", mock.Anything, node1, types.CPUMap(nil), 0.5, int64(10*units.MiB), int64(0), types.VolumeMap(nil), types.HugepageMap(nil), types.GPUMap(nil), store.ActionDecr).Return(nil).Once()
+	container, err := c.AdoptContainer(ctx, &types.AdoptOptions{Nodename: "node1", ID: "c1", Name: "app_web_abcdef"})
+	assert.NoError(t, err)
+	assert.Equal(t, "p1", container.Podname)
+	assert.Equal(t, "alpine", container.Image)
+	assert.Equal(t, 0.5, container.Quota)
+	// success, resource given
+	st.On("UpdateNodeResource", mock.Anything, node1, types.CPUMap(nil), 1.0, int64(20*units.MiB), int64(0), types.VolumeMap(nil), types.HugepageMap(nil), types.GPUMap(nil), store.ActionDecr).Return(nil).Once()
+	container, err = c.AdoptContainer(ctx, &types.AdoptOptions{Nodename: "node1", ID: "c1", Name: "app_web_abcdef", Quota: 1, Memory: 20 * units.MiB})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20*units.MiB), container.Memory)
+}
@@ -0,0 +1,67 @@
+package calcium
+
+import (
+	"context"
+
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// filterNodesByAffinity leaves out nodes not running any container matching opts.Affinity,
+// and nodes running any container matching opts.AntiAffinity
+func (c *Calcium) filterNodesByAffinity(ctx context.Context, nodes map[string]*types.Node, opts *types.DeployOptions) (map[string]*types.Node, error) {
+	if len(opts.Affinity) == 0 && len(opts.AntiAffinity) == 0 {
+		return nodes, nil
+	}
+	filtered := map[string]*types.Node{}
+	for nodename, node := range nodes {
+		if len(opts.Affinity) > 0 {
+			running, err := c.nodeRunning(ctx, nodename, opts.Affinity)
+			if err != nil {
+				return nil, err
+			}
+			if !running {
+				log.Debugf("[filterNodesByAffinity] Node %s runs no container matching affinity", nodename)
+				continue
+			}
+		}
+		if len(opts.AntiAffinity) > 0 {
+			running, err := c.nodeRunning(ctx, nodename, opts.AntiAffinity)
+			if err != nil {
+				return nil, err
+			}
+			if running {
+				log.Debugf("[filterNodesByAffinity] Node %s runs containers matching anti affinity", nodename)
+				continue
+			}
+		}
+		filtered[nodename] = node
+	}
+	return filtered, nil
+}
+
+// nodeRunning checks if there is any container with labels on node
+func (c *Calcium) nodeRunning(ctx context.Context, nodename string, labels map[string]string) (bool, error) {
+	containers, err := c.store.ListNodeContainers(ctx, nodename, labels)
+	return len(containers) > 0, err
+}
+
+// selfAntiAffinity tells if containers of the deploy repel each other
+func selfAntiAffinity(opts *types.DeployOptions) bool {
+	return len(opts.AntiAffinity) > 0 && utils.FilterContainer(opts.Labels, opts.AntiAffinity)
+}
+
+// limitOnePerNode makes each node take at most one container, total is the count of nodes able to take one
+func limitOnePerNode(nodesInfo []types.NodeInfo) ([]types.NodeInfo, int) {
+	total := 0
+	for i := range nodesInfo {
+		if nodesInfo[i].Capacity > 1 {
+			nodesInfo[i].Capacity = 1
+		}
+		if nodesInfo[i].Capacity > 0 {
+			total++
+		}
+	}
+	return nodesInfo, total
+}
@@ -0,0 +1,71 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFilterNodesByAffinity(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	st := c.store.(*storemocks.Store)
+	nodes := map[string]*types.Node{"n1": {Name: "n1"}, "n2": {Name: "n2"}, "n3": {Name: "n3"}}
+	opts := &types.DeployOptions{}
+
+	// nothing to filter
+	filtered, err := c.filterNodesByAffinity(ctx, nodes, opts)
+	assert.NoError(t, err)
+	assert.Len(t, filtered, 3)
+
+	cache := map[string]string{"app": "cache"}
+	web := map[string]string{"app": "web"}
+	st.On("ListNodeContainers", mock.Anything, "n1", cache).Return([]*types.Container{{ID: "c1"}}, nil)
+	st.On("ListNodeContainers", mock.Anything, "n2", cache).Return([]*types.Container{{ID: "c2"}}, nil)
+	st.On("ListNodeContainers", mock.Anything, "n3", cache).Return([]*types.Container{}, nil)
+	st.On("ListNodeContainers", mock.Anything, "n1", web).Return([]*types.Container{{ID: "c3"}}, nil)
+	st.On("ListNodeContainers", mock.Anything, "n2", web).Return([]*types.Container{}, nil)
+	st.On("ListNodeContainers", mock.Anything, "n3", web).Return([]*types.Container{}, nil)
+
+	// next to cache
+	opts.Affinity = cache
+	filtered, err = c.filterNodesByAffinity(ctx, nodes, opts)
+	assert.NoError(t, err)
+	assert.Len(t, filtered, 2)
+	assert.NotContains(t, filtered, "n3")
+
+	// next to cache, but away from web
+	opts.AntiAffinity = web
+	filtered, err = c.filterNodesByAffinity(ctx, nodes, opts)
+	assert.NoError(t, err)
+	assert.Len(t, filtered, 1)
+	assert.Contains(t, filtered, "n2")
+
+	// failed by store
+	opts.Affinity = nil
+	opts.AntiAffinity = map[string]string{"app": "db"}
+	st.On("ListNodeContainers", mock.Anything, mock.Anything, opts.AntiAffinity).Return(nil, types.ErrNoETCD)
+	_, err = c.filterNodesByAffinity(ctx, nodes, opts)
+	assert.Error(t, err)
+}
+
+func TestSelfAntiAffinity(t *testing.T) {
+	opts := &types.DeployOptions{Labels: map[string]string{"app": "web", "tier": "front"}}
+	assert.False(t, selfAntiAffinity(opts))
+	opts.AntiAffinity = map[string]string{"app": "web"}
+	assert.True(t, selfAntiAffinity(opts))
+	opts.AntiAffinity = map[string]string{"app": "cache"}
+	assert.False(t, selfAntiAffinity(opts))
+}
+
+func TestLimitOnePerNode(t *testing.T) {
+	nodesInfo := []types.NodeInfo{{Name: "n1", Capacity: 10}, {Name: "n2", Capacity: 0}, {Name: "n3", Capacity: 1}}
+	nodesInfo, total := limitOnePerNode(nodesInfo)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, 1, nodesInfo[0].Capacity)
+	assert.Equal(t, 0, nodesInfo[1].Capacity)
+}
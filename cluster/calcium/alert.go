@@ -0,0 +1,221 @@
+package calcium
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// alertState remembers firing alerts so only crossings are sent, and when processing records are first seen
+// only used by the goroutine checking alerts
+type alertState struct {
+	firing         map[string]*types.Alert
+	processingSeen map[string]time.Time
+}
+
+type deployResult struct {
+	success int64
+	failure int64
+}
+
+func (c *Calcium) alertEnabled() bool {
+	return len(c.config.Alert.Webhooks) > 0
+}
+
+// recordDeployResult counts deployed containers of app for deploy failure rate alert
+func (c *Calcium) recordDeployResult(appname string, success, failure int) {
+	if !c.alertEnabled() || c.config.Alert.DeployFailureRate <= 0 {
+		return
+	}
+	v, _ := c.deployResults.LoadOrStore(appname, &deployResult{})
+	result := v.(*deployResult)
+	atomic.AddInt64(&result.success, int64(success))
+	atomic.AddInt64(&result.failure, int64(failure))
+}
+
+func (c *Calcium) watchAlerts(ctx context.Context) {
+	if !c.alertEnabled() || c.config.Alert.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(c.config.Alert.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cctx, cancel := context.WithTimeout(ctx, c.config.GlobalTimeout)
+			for _, alert := range c.doCheckAlerts(cctx) {
+				c.sendAlert(cctx, alert)
+			}
+			cancel()
+		}
+	}
+}
+
+// doCheckAlerts returns alerts crossing thresholds since last check, either firing or resolved
+func (c *Calcium) doCheckAlerts(ctx context.Context) []*types.Alert {
+	now := time.Now()
+	crossed := map[string]*types.Alert{}
+	if c.config.Alert.NodeAllocation > 0 {
+		c.doCheckNodeAllocation(ctx, now, crossed)
+	}
+	if c.config.Alert.DeployFailureRate > 0 {
+		c.doCheckDeployFailureRate(now, crossed)
+	}
+	if c.config.Alert.ProcessingAge > 0 {
+		c.doCheckProcessing(ctx, now, crossed)
+	}
+
+	if c.alerts.firing == nil {
+		c.alerts.firing = map[string]*types.Alert{}
+	}
+	alerts := []*types.Alert{}
+	for key, alert := range crossed {
+		if _, ok := c.alerts.firing[key]; !ok {
+			alerts = append(alerts, alert)
+		}
+		c.alerts.firing[key] = alert
+	}
+	for key, alert := range c.alerts.firing {
+		if _, ok := crossed[key]; ok {
+			continue
+		}
+		resolved := *alert
+		resolved.Resolved = true
+		resolved.Time = now
+		alerts = append(alerts, &resolved)
+		delete(c.alerts.firing, key)
+	}
+	return alerts
+}
+
+func (c *Calcium) doCheckNodeAllocation(ctx context.Context, now time.Time, crossed map[string]*types.Alert) {
+	pods, err := c.store.GetAllPods(ctx)
+	if err != nil {
+		log.Errorf("[doCheckAlerts] List pods failed %v", err)
+		return
+	}
+	threshold := c.config.Alert.NodeAllocation
+	for _, pod := range pods {
+		nodes, err := c.store.GetNodesByPod(ctx, pod.Name, nil, true)
+		if err != nil {
+			log.Errorf("[doCheckAlerts] List pod %s nodes failed %v", pod.Name, err)
+			continue
+		}
+		for _, node := range nodes {
+			allocations := map[string]float64{}
+			if len(node.InitCPU) > 0 {
+				allocations["cpu"] = node.CPUUsed / float64(len(node.InitCPU))
+			}
+			if node.InitMemCap > 0 {
+				allocations["memory"] = float64(node.InitMemCap-node.MemCap) / float64(node.InitMemCap)
+			}
+			if node.InitStorageCap > 0 {
+				allocations["storage"] = float64(node.InitStorageCap-node.StorageCap) / float64(node.InitStorageCap)
+			}
+			for resource, allocation := range allocations {
+				if allocation <= threshold {
+					continue
+				}
+				crossed[fmt.Sprintf("%s/%s/%s", types.AlertNodeAllocation, node.Name, resource)] = &types.Alert{
+					Kind:      types.AlertNodeAllocation,
+					Target:    node.Name,
+					Value:     allocation,
+					Threshold: threshold,
+					Message:   fmt.Sprintf("%s of node %s is %.0f%% allocated", resource, node.Name, allocation*100),
+					Time:      now,
+				}
+			}
+		}
+	}
+}
+
+func (c *Calcium) doCheckDeployFailureRate(now time.Time, crossed map[string]*types.Alert) {
+	threshold := c.config.Alert.DeployFailureRate
+	c.deployResults.Range(func(k, v interface{}) bool {
+		c.deployResults.Delete(k)
+		appname := k.(string)
+		result := v.(*deployResult)
+		success, failure := atomic.LoadInt64(&result.success), atomic.LoadInt64(&result.failure)
+		if success+failure == 0 {
+			return true
+		}
+		rate := float64(failure) / float64(success+failure)
+		if rate > threshold {
+			crossed[fmt.Sprintf("%s/%s", types.AlertDeployFailureRate, appname)] = &types.Alert{
+				Kind:      types.AlertDeployFailureRate,
+				Target:    appname,
+				Value:     rate,
+				Threshold: threshold,
+				Message:   fmt.Sprintf("%d of %d containers of %s failed to deploy", failure, success+failure, appname),
+				Time:      now,
+			}
+		}
+		return true
+	})
+}
+
+func (c *Calcium) doCheckProcessing(ctx context.Context, now time.Time, crossed map[string]*types.Alert) {
+	records, err := c.store.ListProcessingRecords(ctx)
+	if err != nil {
+		log.Errorf("[doCheckAlerts] List processing records failed %v", err)
+		return
+	}
+	// records keep no time, so age counts from first seen by this core
+	seen := map[string]time.Time{}
+	threshold := c.config.Alert.ProcessingAge
+	for _, record := range records {
+		key := filepath.Join(record.Appname, record.Entrypoint, record.Nodename, record.Ident)
+		first, ok := c.alerts.processingSeen[key]
+		if !ok {
+			first = now
+		}
+		seen[key] = first
+		if age := now.Sub(first); age > threshold {
+			crossed[fmt.Sprintf("%s/%s", types.AlertProcessingStale, key)] = &types.Alert{
+				Kind:      types.AlertProcessingStale,
+				Target:    key,
+				Value:     age.Seconds(),
+				Threshold: threshold.Seconds(),
+				Message:   fmt.Sprintf("processing record %s with %d containers lives for %v", key, record.Count, age),
+				Time:      now,
+			}
+		}
+	}
+	c.alerts.processingSeen = seen
+}
+
+// sendAlert posts alert to all webhooks, failures are only logged
+func (c *Calcium) sendAlert(ctx context.Context, alert *types.Alert) {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		log.Errorf("[sendAlert] Marshal alert failed %v", err)
+		return
+	}
+	for _, url := range c.config.Alert.Webhooks {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			log.Errorf("[sendAlert] Make request to %s failed %v", url, err)
+			continue
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Errorf("[sendAlert] Send alert to %s failed %v", url, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusBadRequest {
+			log.Errorf("[sendAlert] Send alert to %s failed, status code %d", url, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package calcium
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDoCheckAlerts(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := c.store.(*storemocks.Store)
+	c.config.Alert = types.AlertConfig{
+		Webhooks:          []string{"http://alert"},
+		NodeAllocation:    0.9,
+		DeployFailureRate: 0.5,
+		ProcessingAge:     time.Minute,
+	}
+
+	node := &types.Node{Name: "n1", InitCPU: types.CPUMap{"0": 100}, CPUUsed: 0.5, InitMemCap: 100, MemCap: 5}
+	store.On("GetAllPods", mock.Anything).Return([]*types.Pod{{Name: "p1"}}, nil)
+	store.On("GetNodesByPod", mock.Anything, "p1", mock.Anything, true).Return([]*types.Node{node}, nil)
+	records := []*types.ProcessingRecord{{Appname: "app", Entrypoint: "web", Nodename: "n1", Ident: "abc", Count: 1}}
+	store.On("ListProcessingRecords", mock.Anything).Return(func(context.Context) []*types.ProcessingRecord {
+		return records
+	}, nil)
+	c.recordDeployResult("app", 1, 3)
+	c.recordDeployResult("ok", 3, 1)
+
+	alerts := c.doCheckAlerts(ctx)
+	kinds := map[string]string{}
+	for _, alert := range alerts {
+		assert.False(t, alert.Resolved)
+		kinds[alert.Kind] = alert.Target
+	}
+	assert.Equal(t, map[string]string{types.AlertNodeAllocation: "n1", types.AlertDeployFailureRate: "app"}, kinds)
+	assert.Len(t, alerts, 2)
+
+	// firing alerts are not sent again, and deploy failure rate is resolved
+	c.alerts.processingSeen["app/web/n1/abc"] = time.Now().Add(-time.Hour)
+	alerts = c.doCheckAlerts(ctx)
+	assert.Len(t, alerts, 2)
+	for _, alert := range alerts {
+		switch alert.Kind {
+		case types.AlertDeployFailureRate:
+			assert.True(t, alert.Resolved)
+		case types.AlertProcessingStale:
+			assert.False(t, alert.Resolved)
+			assert.Equal(t, "app/web/n1/abc", alert.Target)
+		default:
+			t.Fatalf("unexpected alert %v", alert)
+		}
+	}
+
+	// everything resolved
+	node.MemCap = 100
+	records = nil
+	alerts = c.doCheckAlerts(ctx)
+	assert.Len(t, alerts, 2)
+	for _, alert := range alerts {
+		assert.True(t, alert.Resolved)
+	}
+	assert.Empty(t, c.doCheckAlerts(ctx))
+}
+
+func TestSendAlert(t *testing.T) {
+	c := NewTestCluster()
+	received := make(chan *types.Alert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alert := &types.Alert{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(alert))
+		received <- alert
+	}))
+	defer server.Close()
+	c.config.Alert.Webhooks = []string{server.URL}
+	c.sendAlert(context.Background(), &types.Alert{Kind: types.AlertNodeAllocation, Target: "n1", Value: 0.95})
+	alert := <-received
+	assert.Equal(t, types.AlertNodeAllocation, alert.Kind)
+	assert.Equal(t, "n1", alert.Target)
+}
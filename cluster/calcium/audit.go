@@ -0,0 +1,42 @@
+package calcium
+
+import (
+	"context"
+
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+)
+
+// RecordAuditLog records a mutating call, nothing is recorded if auditing is disabled
+func (c *Calcium) RecordAuditLog(ctx context.Context, log *types.AuditLog) error {
+	if c.config.Audit.TTL <= 0 {
+		return nil
+	}
+	if log.ID == "" {
+		log.ID = utils.RandomString(8)
+	}
+	log.Source = c.config.Bind
+	if max := c.config.Audit.MaxOptionsSize; max > 0 && len(log.Options) > max {
+		log.Options = log.Options[:max]
+	}
+	return c.store.AddAuditLog(ctx, log, c.config.Audit.TTL)
+}
+
+// ListAuditLogs lists audit logs selected by filter, the latest first
+func (c *Calcium) ListAuditLogs(ctx context.Context, filter *types.AuditFilter) ([]*types.AuditLog, error) {
+	logs, err := c.store.ListAuditLogs(ctx, filter.Since, filter.Until)
+	if err != nil {
+		return nil, err
+	}
+	// logs are sorted by start time
+	r := []*types.AuditLog{}
+	for i := len(logs) - 1; i >= 0; i-- {
+		if filter.Match(logs[i]) {
+			r = append(r, logs[i])
+		}
+	}
+	if filter.Limit > 0 && len(r) > filter.Limit {
+		r = r[:filter.Limit]
+	}
+	return r, nil
+}
@@ -0,0 +1,57 @@
+package calcium
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRecordAuditLog(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+
+	// disabled
+	assert.NoError(t, c.RecordAuditLog(ctx, &types.AuditLog{Method: "AddPod"}))
+	store.AssertNotCalled(t, "AddAuditLog", mock.Anything, mock.Anything, mock.Anything)
+
+	c.config.Audit = types.AuditConfig{TTL: time.Hour, MaxOptionsSize: 8}
+	c.config.Bind = "core1:5001"
+	store.On("AddAuditLog", mock.Anything, mock.Anything, time.Hour).Return(nil)
+	log := &types.AuditLog{Method: "AddPod", Options: strings.Repeat("x", 10)}
+	assert.NoError(t, c.RecordAuditLog(ctx, log))
+	assert.NotEmpty(t, log.ID)
+	assert.Equal(t, "core1:5001", log.Source)
+	assert.Equal(t, strings.Repeat("x", 8), log.Options)
+}
+
+func TestListAuditLogs(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+
+	since := time.Now().Add(-time.Hour)
+	store.On("ListAuditLogs", mock.Anything, since, time.Time{}).Return([]*types.AuditLog{
+		{ID: "1", User: "alice", Appname: "app"},
+		{ID: "2", User: "bob", Appname: "app"},
+		{ID: "3", User: "alice"},
+		{ID: "4", User: "alice", Appname: "app"},
+	}, nil)
+	logs, err := c.ListAuditLogs(ctx, &types.AuditFilter{User: "alice", Since: since})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 3)
+	assert.Equal(t, "4", logs[0].ID)
+
+	logs, err = c.ListAuditLogs(ctx, &types.AuditFilter{User: "alice", Appname: "app", Since: since, Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "4", logs[0].ID)
+}
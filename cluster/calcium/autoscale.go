@@ -0,0 +1,200 @@
+package calcium
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/projecteru2/core/cluster"
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// SetScalePolicy saves scale policy of an app entrypoint, time of the last scaling is kept
+func (c *Calcium) SetScalePolicy(ctx context.Context, policy *types.ScalePolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	if old, err := c.store.GetScalePolicy(ctx, policy.Appname, policy.Entrypoint); err == nil {
+		policy.LastScaled = old.LastScaled
+	}
+	return c.store.SetScalePolicy(ctx, policy)
+}
+
+// ListScalePolicies lists all scale policies
+func (c *Calcium) ListScalePolicies(ctx context.Context) ([]*types.ScalePolicy, error) {
+	return c.store.ListScalePolicies(ctx)
+}
+
+// RemoveScalePolicy removes scale policy of an app entrypoint, its containers are kept
+func (c *Calcium) RemoveScalePolicy(ctx context.Context, appname, entrypoint string) error {
+	return c.store.RemoveScalePolicy(ctx, appname, entrypoint)
+}
+
+// doAutoscale checks each policy under its lock, so only one core scales an app entrypoint at a time
+func (c *Calcium) doAutoscale(ctx context.Context, now time.Time) {
+	policies, err := c.store.ListScalePolicies(ctx)
+	if err != nil {
+		log.Errorf("[doAutoscale] List scale policies failed %v", err)
+		return
+	}
+	for _, policy := range policies {
+		if policy.CoolingDown(now) {
+			continue
+		}
+		lock, err := c.doLock(ctx, fmt.Sprintf(cluster.ScalePolicyLock, policy.Appname, policy.Entrypoint), c.config.LockTimeout)
+		if err != nil {
+			log.Errorf("[doAutoscale] Lock policy of %s %s failed %v", policy.Appname, policy.Entrypoint, err)
+			continue
+		}
+		if err := c.doScale(ctx, policy, now); err != nil {
+			log.Errorf("[doAutoscale] Scale %s %s failed %v", policy.Appname, policy.Entrypoint, err)
+		}
+		if err := c.doUnlock(ctx, lock, policy.Appname+"/"+policy.Entrypoint); err != nil {
+			log.Errorf("[doAutoscale] Unlock policy failed %v", err)
+		}
+	}
+}
+
+// doScale deploys or removes containers to make replicas desired by policy, time of scaling is saved to policy
+func (c *Calcium) doScale(ctx context.Context, policy *types.ScalePolicy, now time.Time) error {
+	// policy may be changed or scaled by other cores before locked
+	policy, err := c.store.GetScalePolicy(ctx, policy.Appname, policy.Entrypoint)
+	if err != nil || policy.CoolingDown(now) {
+		return err
+	}
+	all, err := c.store.ListContainers(ctx, policy.Appname, policy.Entrypoint, "", 0, nil)
+	if err != nil {
+		return err
+	}
+	containers := []*types.Container{}
+	for _, container := range all {
+		if container.Podname == policy.Podname {
+			containers = append(containers, container)
+		}
+	}
+	cpuUsage, memoryUsage := c.averageUsage(ctx, containers)
+	desired := policy.DesiredReplicas(len(containers), cpuUsage, memoryUsage, c.config.Autoscale.Tolerance)
+	log.Debugf("[doScale] %s %s replicas %d desired %d, cpu usage %f memory usage %f", policy.Appname, policy.Entrypoint, len(containers), desired, cpuUsage, memoryUsage)
+	switch {
+	case desired > len(containers):
+		err = c.doScaleOut(ctx, policy, containers, desired-len(containers))
+	case desired < len(containers):
+		err = c.doScaleIn(ctx, containers, len(containers)-desired)
+	default:
+		return nil
+	}
+	policy.LastScaled = now
+	if serr := c.store.SetScalePolicy(ctx, policy); serr != nil {
+		log.Errorf("[doScale] Save scale policy of %s %s failed %v", policy.Appname, policy.Entrypoint, serr)
+	}
+	return err
+}
+
+// averageUsage returns average usage ratios of cpu quota and memory limit,
+// containers without limits or stats are skipped, -1 if none counted
+func (c *Calcium) averageUsage(ctx context.Context, containers []*types.Container) (float64, float64) {
+	cpu, memory := 0.0, 0.0
+	cpuCount, memoryCount := 0, 0
+	for _, container := range containers {
+		if container.Engine == nil {
+			continue
+		}
+		stats, err := container.Engine.VirtualizationStats(ctx, container.ID)
+		if err != nil {
+			log.Warnf("[averageUsage] Get stats of container %s failed %v", container.ID, err)
+			continue
+		}
+		if container.Quota > 0 {
+			cpu += stats.CPU / container.Quota
+			cpuCount++
+		}
+		if container.Memory > 0 {
+			memory += float64(stats.Memory) / float64(container.Memory)
+			memoryCount++
+		}
+	}
+	if cpuCount == 0 {
+		cpu, cpuCount = -1, 1
+	}
+	if memoryCount == 0 {
+		memory, memoryCount = -1, 1
+	}
+	return cpu / float64(cpuCount), memory / float64(memoryCount)
+}
+
+// doScaleOut deploys count containers with options of an existing one
+func (c *Calcium) doScaleOut(ctx context.Context, policy *types.ScalePolicy, containers []*types.Container, count int) error {
+	if len(containers) == 0 {
+		return types.NewDetailedErr(types.ErrBadScalePolicy, "no container to deploy like")
+	}
+	template := containers[0]
+	info, err := template.Inspect(ctx)
+	if err != nil {
+		return err
+	}
+	opts, err := makeCloneDeployOptions(template, info.Cmd, info.Dir, info.Labels, info.Networks)
+	if err != nil {
+		return err
+	}
+	opts.Podname = policy.Podname
+	opts.Nodename = ""
+	opts.Count = count
+	log.Infof("[doScaleOut] Deploy %d containers of %s %s like %s", count, policy.Appname, policy.Entrypoint, template.ID)
+	ch, err := c.CreateContainer(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for m := range ch {
+		if m.Error != nil {
+			err = m.Error
+		}
+	}
+	return err
+}
+
+// doScaleIn removes count containers, not running ones first
+func (c *Calcium) doScaleIn(ctx context.Context, containers []*types.Container, count int) error {
+	sorted := append([]*types.Container{}, containers...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return !isRunning(sorted[i]) && isRunning(sorted[j])
+	})
+	IDs := []string{}
+	for _, container := range sorted[:count] {
+		IDs = append(IDs, container.ID)
+	}
+	log.Infof("[doScaleIn] Remove containers %v", IDs)
+	ch, err := c.RemoveContainer(ctx, IDs, true, 1, 0)
+	if err != nil {
+		return err
+	}
+	for m := range ch {
+		if !m.Success {
+			err = m.Error
+		}
+	}
+	return err
+}
+
+func isRunning(container *types.Container) bool {
+	return container.StatusMeta != nil && container.StatusMeta.Running
+}
+
+func (c *Calcium) watchAutoscale(ctx context.Context) {
+	if c.config.Autoscale.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(c.config.Autoscale.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cctx, cancel := context.WithTimeout(ctx, c.config.GlobalTimeout)
+			c.doAutoscale(cctx, time.Now())
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	lockmocks "github.com/projecteru2/core/lock/mocks"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSetScalePolicy(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	st := c.store.(*storemocks.Store)
+
+	assert.Error(t, c.SetScalePolicy(ctx, &types.ScalePolicy{Appname: "app"}))
+	last := time.Now()
+	st.On("GetScalePolicy", mock.Anything, "app", "web").Return(&types.ScalePolicy{LastScaled: last}, nil)
+	st.On("SetScalePolicy", mock.Anything, mock.Anything).Return(nil)
+	policy := &types.ScalePolicy{Appname: "app", Entrypoint: "web", Podname: "pod", Max: 3}
+	assert.NoError(t, c.SetScalePolicy(ctx, policy))
+	assert.Equal(t, last, policy.LastScaled)
+}
+
+func TestAverageUsage(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	engine := &enginemocks.API{}
+	engine.On("VirtualizationStats", mock.Anything, "c1").Return(&enginetypes.VirtualizationStats{CPU: 0.5, Memory: 50}, nil)
+	engine.On("VirtualizationStats", mock.Anything, "c2").Return(&enginetypes.VirtualizationStats{CPU: 0.1, Memory: 80}, nil)
+	engine.On("VirtualizationStats", mock.Anything, "c3").Return(nil, types.ErrEngineNotImplemented)
+	containers := []*types.Container{
+		{ID: "c1", Quota: 1, Memory: 100, Engine: engine},
+		{ID: "c2", Memory: 100, Engine: engine},
+		{ID: "c3", Quota: 1, Memory: 100, Engine: engine},
+		{ID: "c4"},
+	}
+	cpu, memory := c.averageUsage(ctx, containers)
+	assert.InDelta(t, 0.5, cpu, 1e-9)
+	assert.InDelta(t, 0.65, memory, 1e-9)
+	cpu, memory = c.averageUsage(ctx, containers[2:])
+	assert.Equal(t, -1.0, cpu)
+	assert.Equal(t, -1.0, memory)
+}
+
+func TestDoScale(t *testing.T) {
+	c := NewTestCluster()
+	c.config.Autoscale.Tolerance = 0.1
+	ctx := context.Background()
+	st := c.store.(*storemocks.Store)
+	now := time.Now()
+
+	policy := &types.ScalePolicy{Appname: "app", Entrypoint: "web", Podname: "pod", Min: 1, Max: 5, CPUThreshold: 0.5, Cooldown: time.Minute}
+	// cooling down
+	st.On("GetScalePolicy", mock.Anything, "app", "web").Return(&types.ScalePolicy{LastScaled: now, Cooldown: time.Minute}, nil).Once()
+	assert.NoError(t, c.doScale(ctx, policy, now))
+
+	engine := &enginemocks.API{}
+	engine.On("VirtualizationStats", mock.Anything, mock.Anything).Return(&enginetypes.VirtualizationStats{CPU: 0.1}, nil)
+	st.On("GetScalePolicy", mock.Anything, "app", "web").Return(policy, nil)
+	st.On("ListContainers", mock.Anything, "app", "web", "", int64(0), mock.Anything).Return([]*types.Container{
+		{ID: "c1", Podname: "pod", Quota: 1, Engine: engine, StatusMeta: &types.StatusMeta{Running: true}},
+		{ID: "c2", Podname: "pod", Quota: 1, Engine: engine},
+		{ID: "c3", Podname: "pod", Quota: 1, Engine: engine, StatusMeta: &types.StatusMeta{Running: true}},
+		{ID: "c4", Podname: "other", Quota: 1, Engine: engine},
+	}, nil)
+	// usage 0.1 of 0.5, 3 replicas scale in to 1, the not running one first
+	st.On("GetContainers", mock.Anything, []string{"c2", "c1"}).Return(nil, types.ErrNoETCD).Once()
+	st.On("GetContainers", mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD)
+	st.On("SetScalePolicy", mock.Anything, mock.MatchedBy(func(p *types.ScalePolicy) bool {
+		return p.LastScaled.Equal(now)
+	})).Return(nil).Once()
+	assert.Error(t, c.doScale(ctx, policy, now))
+	st.AssertExpectations(t)
+
+	// scale out to min, failed by inspecting container to deploy like
+	policy.LastScaled = time.Time{}
+	policy.Min = 4
+	engine.On("VirtualizationInspect", mock.Anything, "c1").Return(nil, types.ErrNilEngine).Once()
+	st.On("SetScalePolicy", mock.Anything, mock.Anything).Return(nil).Once()
+	assert.Equal(t, types.ErrNilEngine, c.doScale(ctx, policy, now))
+	engine.AssertExpectations(t)
+}
+
+func TestDoAutoscale(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	st := c.store.(*storemocks.Store)
+	now := time.Now()
+
+	// nothing happens if listing failed
+	st.On("ListScalePolicies", mock.Anything).Return(nil, types.ErrNoETCD).Once()
+	c.doAutoscale(ctx, now)
+
+	// cooling down policy is skipped without locking
+	st.On("ListScalePolicies", mock.Anything).Return([]*types.ScalePolicy{
+		{Appname: "app", Entrypoint: "web", LastScaled: now, Cooldown: time.Minute},
+		{Appname: "app", Entrypoint: "worker"},
+	}, nil)
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	st.On("CreateLock", "cscale_app_worker", mock.Anything).Return(lock, nil).Once()
+	st.On("GetScalePolicy", mock.Anything, "app", "worker").Return(nil, types.ErrNoETCD).Once()
+	c.doAutoscale(ctx, now)
+	st.AssertExpectations(t)
+	lock.AssertExpectations(t)
+}
@@ -16,12 +16,15 @@ import (
 
 // BuildImage will build image
 func (c *Calcium) BuildImage(ctx context.Context, opts *types.BuildOptions) (chan *types.BuildImageMessage, error) {
-	// Disable build API if scm not set
-	if c.source == nil {
+	// Disable building from scm if scm not set, tarballs are still built
+	if c.source == nil && (opts.BuildMethod == types.BuildFromSCM || len(opts.Targets) > 0) {
 		return nil, types.ErrSCMNotSet
 	}
+	if len(opts.Targets) > 0 {
+		return c.buildTargets(ctx, opts)
+	}
 	// select nodes
-	node, err := c.selectBuildNode(ctx)
+	node, err := c.selectBuildNode(ctx, opts.Nodename)
 	if err != nil {
 		return nil, err
 	}
@@ -33,7 +36,7 @@ func (c *Calcium) BuildImage(ctx context.Context, opts *types.BuildOptions) (cha
 	case types.BuildFromSCM:
 		return c.buildFromSCM(ctx, node, refs, opts)
 	case types.BuildFromRaw:
-		return c.buildFromContent(ctx, node, refs, opts.Tar)
+		return c.buildFromContent(ctx, node, refs, opts.Tar, opts.Secrets)
 	case types.BuildFromExist:
 		return c.buildFromExist(ctx, refs[0], opts.ExistID)
 	default:
@@ -41,7 +44,19 @@ func (c *Calcium) BuildImage(ctx context.Context, opts *types.BuildOptions) (cha
 	}
 }
 
-func (c *Calcium) selectBuildNode(ctx context.Context) (*types.Node, error) {
+// selectBuildNode returns the designated builder node if nodename is set,
+// or the most idle node of build pod
+func (c *Calcium) selectBuildNode(ctx context.Context, nodename string) (*types.Node, error) {
+	if nodename != "" {
+		node, err := c.GetNode(ctx, nodename)
+		if err != nil {
+			return nil, err
+		}
+		if !node.Available {
+			return nil, types.NewDetailedErr(types.ErrNodeUnavailable, nodename)
+		}
+		return node, nil
+	}
 	// get pod from config
 	// TODO VM BRANCH conside vm build machines.
 	if c.config.Docker.BuildPod == "" {
@@ -61,6 +76,9 @@ func (c *Calcium) selectBuildNode(ctx context.Context) (*types.Node, error) {
 }
 
 func (c *Calcium) buildFromSCM(ctx context.Context, node *types.Node, refs []string, opts *types.BuildOptions) (chan *types.BuildImageMessage, error) {
+	if err := checkBuildSecrets(opts); err != nil {
+		return nil, err
+	}
 	buildContentOpts := &enginetypes.BuildContentOptions{
 		User:   opts.User,
 		UID:    opts.UID,
@@ -71,17 +89,77 @@ func (c *Calcium) buildFromSCM(ctx context.Context, node *types.Node, refs []str
 	if err != nil {
 		return nil, err
 	}
-	return c.buildFromContent(ctx, node, refs, content)
+	return c.buildFromContent(ctx, node, refs, content, opts.Secrets)
 }
 
-func (c *Calcium) buildFromContent(ctx context.Context, node *types.Node, refs []string, content io.Reader) (chan *types.BuildImageMessage, error) {
-	resp, err := node.Engine.ImageBuild(ctx, content, refs)
+func (c *Calcium) buildFromContent(ctx context.Context, node *types.Node, refs []string, content io.Reader, secrets map[string][]byte) (chan *types.BuildImageMessage, error) {
+	resp, err := node.Engine.ImageBuild(ctx, content, refs, secrets)
 	if err != nil {
 		return nil, err
 	}
 	return c.pushImage(ctx, resp, node, refs)
 }
 
+// buildTargets builds targets one by one, the later ones may reuse the cached repository
+func (c *Calcium) buildTargets(ctx context.Context, opts *types.BuildOptions) (chan *types.BuildImageMessage, error) {
+	if opts.BuildMethod != types.BuildFromSCM || opts.Builds == nil {
+		return nil, types.NewDetailedErr(types.ErrNotSupport, "build targets need scm builds")
+	}
+	return withImageBuiltChannel(func(ch chan *types.BuildImageMessage) {
+		for _, target := range opts.Targets {
+			targetCh, err := c.BuildImage(ctx, makeTargetBuildOptions(opts, target))
+			if err != nil {
+				log.Errorf("[buildTargets] Build target %s failed %v", target.Name, err)
+				msg := buildErrMsg(err)
+				msg.Name = target.Name
+				ch <- msg
+				continue
+			}
+			for m := range targetCh {
+				m.Name = target.Name
+				ch <- m
+			}
+		}
+	}), nil
+}
+
+// makeTargetBuildOptions copies builds for target, stages with repo or tarball use subpath of target
+func makeTargetBuildOptions(opts *types.BuildOptions, target *types.BuildTarget) *types.BuildOptions {
+	builds := &types.Builds{Stages: opts.Builds.Stages, Builds: map[string]*types.Build{}}
+	for stage, build := range opts.Builds.Builds {
+		b := *build
+		if (b.Repo != "" || b.Tarball != nil) && target.Subpath != "" {
+			b.Subpath = target.Subpath
+		}
+		builds.Builds[stage] = &b
+	}
+	return &types.BuildOptions{
+		Name:        target.Name,
+		User:        opts.User,
+		UID:         opts.UID,
+		Tags:        target.Tags,
+		BuildMethod: opts.BuildMethod,
+		Builds:      builds,
+		Secrets:     opts.Secrets,
+		Nodename:    opts.Nodename,
+	}
+}
+
+// checkBuildSecrets makes sure secrets used by stages are given
+func checkBuildSecrets(opts *types.BuildOptions) error {
+	if opts.Builds == nil {
+		return nil
+	}
+	for _, build := range opts.Builds.Builds {
+		for _, secret := range build.Secrets {
+			if _, ok := opts.Secrets[secret]; !ok {
+				return types.NewDetailedErr(types.ErrNoBuildSecret, secret)
+			}
+		}
+	}
+	return nil
+}
+
 func (c *Calcium) buildFromExist(ctx context.Context, ref, existID string) (chan *types.BuildImageMessage, error) {
 	return withImageBuiltChannel(func(ch chan *types.BuildImageMessage) {
 		node, err := c.getContainerNode(ctx, existID)
@@ -100,6 +178,48 @@ func (c *Calcium) buildFromExist(ctx context.Context, ref, existID string) (chan
 	}), nil
 }
 
+// CommitContainer commits a running container to image, push it if asked
+func (c *Calcium) CommitContainer(ctx context.Context, opts *types.CommitOptions) (chan *types.BuildImageMessage, error) {
+	if opts.ID == "" {
+		return nil, types.ErrNoContainerIDs
+	}
+	if opts.Name == "" {
+		return nil, types.ErrNoImage
+	}
+	node, err := c.getContainerNode(ctx, opts.ID)
+	if err != nil {
+		return nil, err
+	}
+	refs := node.Engine.BuildRefs(ctx, opts.Name, opts.Tags)
+	log.Infof("[CommitContainer] Commit container %s at node %s to %v", opts.ID, node.Name, refs)
+
+	return withImageBuiltChannel(func(ch chan *types.BuildImageMessage) {
+		imageID, err := node.Engine.ImageCommit(ctx, opts.ID, refs, opts.Comment)
+		if err != nil {
+			log.Errorf("[CommitContainer] Commit container %s failed %v", opts.ID, err)
+			ch <- buildErrMsg(err)
+			return
+		}
+		ch <- &types.BuildImageMessage{ID: imageID, Stream: fmt.Sprintf("committed %s\n", imageID), Status: "committed"}
+		if !opts.Push {
+			return
+		}
+
+		for _, ref := range refs {
+			log.Infof("[CommitContainer] Push image %s", ref)
+			rc, err := node.Engine.ImagePush(ctx, ref)
+			if err != nil {
+				ch <- buildErrMsg(err)
+				continue
+			}
+			for message := range processBuildImageStream(rc) {
+				ch <- message
+			}
+			ch <- &types.BuildImageMessage{Stream: fmt.Sprintf("finished %s\n", ref), Status: "finished", Progress: ref}
+		}
+	}), nil
+}
+
 func (c *Calcium) pushImage(ctx context.Context, resp io.ReadCloser, node *types.Node, tags []string) (chan *types.BuildImageMessage, error) {
 	return withImageBuiltChannel(func(ch chan *types.BuildImageMessage) {
 		defer resp.Close()
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"testing"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
 	schedulermocks "github.com/projecteru2/core/scheduler/mocks"
 	storemocks "github.com/projecteru2/core/store/mocks"
 	"github.com/projecteru2/core/types"
@@ -114,7 +116,7 @@ func TestBuild(t *testing.T) {
 	engine.On("BuildContent", mock.Anything, mock.Anything, mock.Anything).Return("", b, nil)
 	// failed by ImageBuild
 	opts.BuildMethod = types.BuildFromRaw
-	engine.On("ImageBuild", mock.Anything, mock.Anything, mock.Anything).Return(nil, types.ErrNilEngine).Once()
+	engine.On("ImageBuild", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, types.ErrNilEngine).Once()
 	ch, err = c.BuildImage(ctx, opts)
 	assert.Error(t, err)
 	// build from exist not implemented
@@ -129,7 +131,7 @@ func TestBuild(t *testing.T) {
 	ch, err = c.BuildImage(ctx, opts)
 	assert.Error(t, err)
 	// correct
-	engine.On("ImageBuild", mock.Anything, mock.Anything, mock.Anything).Return(buildImageRespReader, nil)
+	engine.On("ImageBuild", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(buildImageRespReader, nil)
 	engine.On("ImagePush", mock.Anything, mock.Anything).Return(buildImageRespReader2, nil)
 	engine.On("ImageRemove", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]string{}, nil)
 	engine.On("ImageBuildCachePrune", mock.Anything, mock.Anything).Return(uint64(1024), nil)
@@ -142,3 +144,169 @@ func TestBuild(t *testing.T) {
 		}
 	}
 }
+
+func TestCommitContainer(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	opts := &types.CommitOptions{Name: "xx", Tags: []string{"debug"}}
+	// no container id
+	_, err := c.CommitContainer(ctx, opts)
+	assert.Error(t, err)
+	opts.ID = "cid"
+	store := c.store.(*storemocks.Store)
+	engine := &enginemocks.API{}
+	// no container
+	store.On("GetContainer", mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD).Once()
+	_, err = c.CommitContainer(ctx, opts)
+	assert.Error(t, err)
+	store.On("GetContainer", mock.Anything, mock.Anything).Return(&types.Container{Nodename: "n1"}, nil)
+	store.On("GetNode", mock.Anything, mock.Anything).Return(&types.Node{Name: "n1", Engine: engine}, nil)
+	engine.On("BuildRefs", mock.Anything, mock.Anything, mock.Anything).Return([]string{"xx:debug"})
+	// commit failed
+	engine.On("ImageCommit", mock.Anything, "cid", []string{"xx:debug"}, "").Return("", types.ErrEngineNotImplemented).Once()
+	ch, err := c.CommitContainer(ctx, opts)
+	assert.NoError(t, err)
+	msgs := []*types.BuildImageMessage{}
+	for m := range ch {
+		msgs = append(msgs, m)
+	}
+	assert.Len(t, msgs, 1)
+	assert.NotEmpty(t, msgs[0].Error)
+	// commit without push
+	engine.On("ImageCommit", mock.Anything, "cid", []string{"xx:debug"}, "").Return("imageid", nil)
+	ch, err = c.CommitContainer(ctx, opts)
+	assert.NoError(t, err)
+	msgs = []*types.BuildImageMessage{}
+	for m := range ch {
+		msgs = append(msgs, m)
+	}
+	assert.Len(t, msgs, 1)
+	assert.Equal(t, "imageid", msgs[0].ID)
+	engine.AssertNotCalled(t, "ImagePush", mock.Anything, mock.Anything)
+	// commit and push
+	opts.Push = true
+	pushResp, err := json.Marshal(&types.BuildImageMessage{Status: "pushed"})
+	assert.NoError(t, err)
+	engine.On("ImagePush", mock.Anything, "xx:debug").Return(ioutil.NopCloser(bytes.NewReader(pushResp)), nil)
+	ch, err = c.CommitContainer(ctx, opts)
+	assert.NoError(t, err)
+	msgs = []*types.BuildImageMessage{}
+	for m := range ch {
+		msgs = append(msgs, m)
+	}
+	assert.Len(t, msgs, 3)
+	assert.Equal(t, "pushed", msgs[1].Status)
+	assert.Equal(t, "finished", msgs[2].Status)
+}
+
+func TestCheckBuildSecrets(t *testing.T) {
+	opts := &types.BuildOptions{}
+	assert.NoError(t, checkBuildSecrets(opts))
+	opts.Builds = &types.Builds{
+		Stages: []string{"compile"},
+		Builds: map[string]*types.Build{
+			"compile": {Secrets: []string{"npm_token"}},
+		},
+	}
+	assert.Error(t, checkBuildSecrets(opts))
+	opts.Secrets = map[string][]byte{"npm_token": []byte("token")}
+	assert.NoError(t, checkBuildSecrets(opts))
+}
+
+func TestBuildTargets(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	c.config.Docker.BuildPod = "test"
+	opts := &types.BuildOptions{
+		BuildMethod: types.BuildFromSCM,
+		Builds: &types.Builds{
+			Stages: []string{"compile", "build"},
+			Builds: map[string]*types.Build{
+				"compile": {Base: base, Repo: repo, Commands: []string{"make"}},
+				"build":   {Base: base},
+			},
+		},
+		Targets: []*types.BuildTarget{
+			{Name: "api", Tags: []string{"v1"}, Subpath: "services/api"},
+			{Name: "worker", Subpath: "services/worker"},
+		},
+	}
+	// raw builds can't have targets
+	opts.BuildMethod = types.BuildFromRaw
+	_, err := c.BuildImage(ctx, opts)
+	assert.Error(t, err)
+	opts.BuildMethod = types.BuildFromSCM
+
+	engine := &enginemocks.API{}
+	node := &types.Node{Name: "test", Podname: "test", Available: true, Engine: engine}
+	store := c.store.(*storemocks.Store)
+	store.On("GetNodesByPod", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*types.Node{node}, nil)
+	scheduler := &schedulermocks.Scheduler{}
+	c.scheduler = scheduler
+	scheduler.On("MaxIdleNode", mock.Anything).Return(node, nil)
+	engine.On("BuildRefs", mock.Anything, "api", []string{"v1"}).Return([]string{"api:v1"})
+	engine.On("BuildRefs", mock.Anything, "worker", mock.Anything).Return([]string{"worker:latest"})
+	// api failed
+	engine.On("BuildContent", mock.Anything, mock.Anything, mock.MatchedBy(func(opts *enginetypes.BuildContentOptions) bool {
+		return opts.Builds.Builds["compile"].Subpath == "services/api" && opts.Builds.Builds["build"].Subpath == ""
+	})).Return("", nil, types.ErrBadSubpath)
+	engine.On("BuildContent", mock.Anything, mock.Anything, mock.MatchedBy(func(opts *enginetypes.BuildContentOptions) bool {
+		return opts.Builds.Builds["compile"].Subpath == "services/worker"
+	})).Return("", ioutil.NopCloser(bytes.NewReader([]byte{})), nil)
+	msg, err := json.Marshal(&types.BuildImageMessage{Stream: "built"})
+	assert.NoError(t, err)
+	engine.On("ImageBuild", mock.Anything, mock.Anything, []string{"worker:latest"}, mock.Anything).Return(ioutil.NopCloser(bytes.NewReader(msg)), nil)
+	engine.On("ImagePush", mock.Anything, "worker:latest").Return(ioutil.NopCloser(bytes.NewReader([]byte{})), nil)
+	engine.On("ImageRemove", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]string{}, nil)
+	engine.On("ImageBuildCachePrune", mock.Anything, mock.Anything).Return(uint64(0), nil)
+
+	ch, err := c.BuildImage(ctx, opts)
+	assert.NoError(t, err)
+	names := map[string][]*types.BuildImageMessage{}
+	for m := range ch {
+		names[m.Name] = append(names[m.Name], m)
+	}
+	assert.Len(t, names["api"], 1)
+	assert.NotEmpty(t, names["api"][0].Error)
+	assert.Equal(t, "built", names["worker"][0].Stream)
+	assert.Equal(t, "finished", names["worker"][len(names["worker"])-1].Status)
+	// spec of request untouched
+	assert.Empty(t, opts.Builds.Builds["compile"].Subpath)
+}
+
+func TestBuildOnNode(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	// tarballs are built without scm
+	c.source = nil
+	opts := &types.BuildOptions{Name: "app", BuildMethod: types.BuildFromRaw, Tar: bytes.NewReader([]byte{}), Nodename: "builder"}
+	store := c.store.(*storemocks.Store)
+	engine := &enginemocks.API{}
+	// unavailable
+	store.On("GetNode", mock.Anything, "builder").Return(&types.Node{Name: "builder", Engine: engine}, nil).Once()
+	_, err := c.BuildImage(ctx, opts)
+	assert.True(t, errors.Is(err, types.ErrNodeUnavailable))
+
+	store.On("GetNode", mock.Anything, "builder").Return(&types.Node{Name: "builder", Available: true, Engine: engine}, nil)
+	msg, err := json.Marshal(&types.BuildImageMessage{Stream: "built"})
+	assert.NoError(t, err)
+	engine.On("BuildRefs", mock.Anything, "app", mock.Anything).Return([]string{"app:latest"})
+	engine.On("ImageBuild", mock.Anything, mock.Anything, []string{"app:latest"}, mock.Anything).Return(ioutil.NopCloser(bytes.NewReader(msg)), nil)
+	engine.On("ImagePush", mock.Anything, "app:latest").Return(ioutil.NopCloser(bytes.NewReader([]byte{})), nil)
+	engine.On("ImageRemove", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]string{}, nil)
+	engine.On("ImageBuildCachePrune", mock.Anything, mock.Anything).Return(uint64(0), nil)
+	// build pod isn't needed
+	ch, err := c.BuildImage(ctx, opts)
+	assert.NoError(t, err)
+	msgs := []*types.BuildImageMessage{}
+	for m := range ch {
+		msgs = append(msgs, m)
+	}
+	assert.Equal(t, "built", msgs[0].Stream)
+	assert.Equal(t, "finished", msgs[len(msgs)-1].Status)
+
+	// scm builds still need scm
+	opts.BuildMethod = types.BuildFromSCM
+	_, err = c.BuildImage(ctx, opts)
+	assert.True(t, errors.Is(err, types.ErrSCMNotSet))
+}
@@ -0,0 +1,59 @@
+package calcium
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// doBulk calls f with each ID, at most concurrency of them at the same time, zero means no limit
+// no more ID is started once ctx is done, so canceling the request stops the rest
+func doBulk(ctx context.Context, IDs []string, concurrency int, f func(ID string)) {
+	if concurrency <= 0 || concurrency > len(IDs) {
+		concurrency = len(IDs)
+	}
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+	for i, ID := range IDs {
+		if ctx.Err() != nil {
+			log.Warnf("[doBulk] Canceled, %d of %d not started: %v", len(IDs)-i, len(IDs), ctx.Err())
+			return
+		}
+		select {
+		case <-ctx.Done():
+			log.Warnf("[doBulk] Canceled, %d of %d not started: %v", len(IDs)-i, len(IDs), ctx.Err())
+			return
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(ID string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			f(ID)
+		}(ID)
+	}
+}
+
+// bulkProgress counts finished items, done is increased and sent together so progress messages are in order
+type bulkProgress struct {
+	sync.Mutex
+	done  int
+	total int
+}
+
+func (p *bulkProgress) send(f func(done, total int)) {
+	p.Lock()
+	defer p.Unlock()
+	p.done++
+	f(p.done, p.total)
+}
+
+// bulkConcurrency limits concurrency by config, zero means no limit
+func (c *Calcium) bulkConcurrency(concurrency int) int {
+	if max := c.config.MaxConcurrency; max > 0 && (concurrency <= 0 || concurrency > max) {
+		return max
+	}
+	return concurrency
+}
@@ -0,0 +1,74 @@
+package calcium
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoBulk(t *testing.T) {
+	IDs := []string{}
+	for i := 0; i < 20; i++ {
+		IDs = append(IDs, fmt.Sprintf("id%d", i))
+	}
+
+	// at most 3 at the same time
+	var running, max, count int64
+	doBulk(context.Background(), IDs, 3, func(ID string) {
+		n := atomic.AddInt64(&running, 1)
+		for {
+			m := atomic.LoadInt64(&max)
+			if n <= m || atomic.CompareAndSwapInt64(&max, m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt64(&running, -1)
+		atomic.AddInt64(&count, 1)
+	})
+	assert.Equal(t, int64(20), count)
+	assert.True(t, max <= 3)
+
+	// canceled, no more started
+	ctx, cancel := context.WithCancel(context.Background())
+	count = 0
+	doBulk(ctx, IDs, 1, func(ID string) {
+		if atomic.AddInt64(&count, 1) == 5 {
+			cancel()
+		}
+	})
+	assert.Equal(t, int64(5), count)
+}
+
+func TestBulkProgress(t *testing.T) {
+	progress := &bulkProgress{total: 10}
+	dones := []int{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			progress.send(func(done, total int) {
+				assert.Equal(t, 10, total)
+				dones = append(dones, done)
+			})
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, dones)
+}
+
+func TestBulkConcurrency(t *testing.T) {
+	c := NewTestCluster()
+	assert.Equal(t, 0, c.bulkConcurrency(0))
+	assert.Equal(t, 5, c.bulkConcurrency(5))
+	c.config.MaxConcurrency = 3
+	assert.Equal(t, 3, c.bulkConcurrency(0))
+	assert.Equal(t, 3, c.bulkConcurrency(5))
+	assert.Equal(t, 2, c.bulkConcurrency(2))
+}
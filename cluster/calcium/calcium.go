@@ -1,17 +1,23 @@
 package calcium
 
 import (
+	"context"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/projecteru2/core/cluster"
 	"github.com/projecteru2/core/scheduler"
 	complexscheduler "github.com/projecteru2/core/scheduler/complex"
 	"github.com/projecteru2/core/source"
+	"github.com/projecteru2/core/source/bitbucket"
+	"github.com/projecteru2/core/source/gitea"
 	"github.com/projecteru2/core/source/github"
 	"github.com/projecteru2/core/source/gitlab"
 	"github.com/projecteru2/core/store"
 	"github.com/projecteru2/core/store/etcdv3"
 	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/wal"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -22,6 +28,18 @@ type Calcium struct {
 	scheduler scheduler.Scheduler
 	source    source.Source
 	watcher   *serviceWatcher
+	puller    *imagePuller
+	queues    *nodeQueues
+	mutators  []deployMutator
+	wal       *wal.WAL
+
+	// podname -> deploys failed by insufficient resources since last capacity sample
+	deployFailures sync.Map
+	// appname -> containers deployed since last alert check
+	deployResults sync.Map
+	alerts        alertState
+	// container ID -> first seen in retention state, only used by the goroutine cleaning them
+	retentionSeen map[string]time.Time
 }
 
 // New returns a new cluster config
@@ -38,6 +56,16 @@ func New(config types.Config, embeddedStorage bool) (*Calcium, error) {
 		return nil, err
 	}
 
+	// set mutators of deploy options
+	mutators, err := newDeployMutators(config.Mutators)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateRetentionRules(config.Retention.Rules); err != nil {
+		return nil, err
+	}
+
 	// set scm
 	var scm source.Source
 	scmtype := strings.ToLower(config.Git.SCMType)
@@ -46,14 +74,44 @@ func New(config types.Config, embeddedStorage bool) (*Calcium, error) {
 		scm, err = gitlab.New(config)
 	case cluster.Github:
 		scm, err = github.New(config)
+	case cluster.Gitea:
+		scm, err = gitea.New(config)
+	case cluster.Bitbucket:
+		scm, err = bitbucket.New(config)
 	default:
 		log.Warn("[Calcium] SCM not set, build API disabled")
 	}
 
-	return &Calcium{store: store, config: config, scheduler: scheduler, source: scm, watcher: &serviceWatcher{}}, err
+	// set wal, nil if disabled
+	var w *wal.WAL
+	if config.WALFile != "" {
+		var walErr error
+		if w, walErr = wal.Open(config.WALFile, config.WALOpenTimeout); walErr != nil {
+			return nil, walErr
+		}
+	}
+
+	cal := &Calcium{store: store, config: config, scheduler: scheduler, source: scm, watcher: &serviceWatcher{}, puller: newImagePuller(config.ImagePull), queues: newNodeQueues(), mutators: mutators, wal: w}
+	cal.puller.onPulled = cal.publishImagePulled
+	go cal.recoverWAL(context.Background())
+	go cal.watchReservations(context.Background())
+	go cal.watchCapacity(context.Background())
+	go cal.watchNodeMetrics(context.Background())
+	go cal.watchAlerts(context.Background())
+	go cal.watchOrphans(context.Background())
+	go cal.watchRetention(context.Background())
+	go cal.watchAutoscale(context.Background())
+	go cal.watchCrons(context.Background())
+	go cal.watchHealthChecks(context.Background())
+	go cal.watchSupervisor(context.Background())
+	go cal.watchNodeLiveness(context.Background())
+	return cal, err
 }
 
 // Finalizer use for defer
 func (c *Calcium) Finalizer() {
 	c.store.TerminateEmbededStorage()
+	if err := c.wal.Close(); err != nil {
+		log.Errorf("[Finalizer] close wal failed %v", err)
+	}
 }
@@ -43,6 +43,8 @@ func NewTestCluster() *Calcium {
 	c.store = &storemocks.Store{}
 	c.scheduler = &schedulermocks.Scheduler{}
 	c.source = &sourcemocks.Source{}
+	c.puller = newImagePuller(c.config.ImagePull)
+	c.queues = newNodeQueues()
 	return c
 }
 
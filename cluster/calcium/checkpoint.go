@@ -0,0 +1,77 @@
+package calcium
+
+import (
+	"context"
+	"path/filepath"
+
+	enginetypes "github.com/projecteru2/core/engine/types"
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// CheckpointContainer checkpoints a running container by CRIU into its sub dir of checkpoint dir,
+// container stopped by checkpoint is left stopped by supervisor until it's restored
+func (c *Calcium) CheckpointContainer(ctx context.Context, opts *types.CheckpointOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if c.config.Checkpoint.Dir == "" {
+		return types.ErrNoCheckpointDir
+	}
+	err := c.withContainerLocked(ctx, opts.ID, func(container *types.Container) error {
+		if err := container.Engine.VirtualizationCheckpoint(ctx, container.ID, &enginetypes.CheckpointOptions{
+			Name: opts.Name,
+			Dir:  c.checkpointDir(container.ID),
+			Exit: opts.Exit,
+		}); err != nil {
+			return err
+		}
+		if opts.Exit {
+			c.doMarkContainerStopped(ctx, container, true)
+			c.publishContainerEvent(types.EventContainerStopped, container)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("[CheckpointContainer] Checkpoint %s of container %s failed %v", opts.Name, opts.ID, err)
+		return err
+	}
+	log.Infof("[CheckpointContainer] Checkpoint %s of container %s done, exit %v", opts.Name, opts.ID, opts.Exit)
+	return nil
+}
+
+// RestoreContainer starts a stopped container from checkpoint, hooks aren't run as processes are restored
+func (c *Calcium) RestoreContainer(ctx context.Context, opts *types.RestoreOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if c.config.Checkpoint.Dir == "" {
+		return types.ErrNoCheckpointDir
+	}
+	from := opts.From
+	if from == "" {
+		from = opts.ID
+	}
+	err := c.withContainerLocked(ctx, opts.ID, func(container *types.Container) error {
+		if err := container.Engine.VirtualizationRestore(ctx, container.ID, &enginetypes.CheckpointOptions{
+			Name: opts.Name,
+			Dir:  c.checkpointDir(from),
+		}); err != nil {
+			return err
+		}
+		c.doMarkContainerStopped(ctx, container, false)
+		c.publishContainerEvent(types.EventContainerStarted, container)
+		return nil
+	})
+	if err != nil {
+		log.Errorf("[RestoreContainer] Restore container %s from checkpoint %s of %s failed %v", opts.ID, opts.Name, from, err)
+		return err
+	}
+	log.Infof("[RestoreContainer] Container %s restored from checkpoint %s of %s", opts.ID, opts.Name, from)
+	return nil
+}
+
+// checkpointDir is where checkpoints of a container are kept
+func (c *Calcium) checkpointDir(ID string) string {
+	return filepath.Join(c.config.Checkpoint.Dir, ID)
+}
@@ -0,0 +1,72 @@
+package calcium
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	lockmocks "github.com/projecteru2/core/lock/mocks"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCheckpointContainer(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+
+	// bad name
+	assert.Error(t, c.CheckpointContainer(ctx, &types.CheckpointOptions{ID: "id1", Name: "../c1"}))
+	// disabled
+	assert.True(t, errors.Is(c.CheckpointContainer(ctx, &types.CheckpointOptions{ID: "id1", Name: "c1"}), types.ErrNoCheckpointDir))
+
+	c.config.Checkpoint.Dir = "/mnt/checkpoints"
+	c.config.Supervisor.Interval = time.Second
+	engine := &enginemocks.API{}
+	container := &types.Container{ID: "id1", Engine: engine}
+	store.On("GetContainers", mock.Anything, []string{"id1"}).Return([]*types.Container{container}, nil)
+	engine.On("VirtualizationCheckpoint", mock.Anything, "id1", &enginetypes.CheckpointOptions{Name: "c1", Dir: "/mnt/checkpoints/id1"}).Return(types.ErrEngineNotImplemented).Once()
+	assert.True(t, errors.Is(c.CheckpointContainer(ctx, &types.CheckpointOptions{ID: "id1", Name: "c1"}), types.ErrEngineNotImplemented))
+
+	// stopped after checkpoint
+	engine.On("VirtualizationCheckpoint", mock.Anything, "id1", &enginetypes.CheckpointOptions{Name: "c1", Dir: "/mnt/checkpoints/id1", Exit: true}).Return(nil)
+	store.On("UpdateContainer", mock.Anything, container).Return(nil)
+	assert.NoError(t, c.CheckpointContainer(ctx, &types.CheckpointOptions{ID: "id1", Name: "c1", Exit: true}))
+	assert.NotEmpty(t, container.Annotations[types.ContainerStoppedAnnotation])
+}
+
+func TestRestoreContainer(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+
+	assert.Error(t, c.RestoreContainer(ctx, &types.RestoreOptions{Name: "c1"}))
+	assert.True(t, errors.Is(c.RestoreContainer(ctx, &types.RestoreOptions{ID: "id2", Name: "c1"}), types.ErrNoCheckpointDir))
+
+	c.config.Checkpoint.Dir = "/mnt/checkpoints"
+	c.config.Supervisor.Interval = time.Second
+	engine := &enginemocks.API{}
+	container := &types.Container{ID: "id2", Engine: engine, Annotations: map[string]string{types.ContainerStoppedAnnotation: "now"}}
+	store.On("GetContainers", mock.Anything, []string{"id2"}).Return([]*types.Container{container}, nil)
+	store.On("UpdateContainer", mock.Anything, container).Return(nil)
+	// checkpoint of the container cloned from
+	engine.On("VirtualizationRestore", mock.Anything, "id2", &enginetypes.CheckpointOptions{Name: "c1", Dir: "/mnt/checkpoints/id1"}).Return(nil)
+	assert.NoError(t, c.RestoreContainer(ctx, &types.RestoreOptions{ID: "id2", Name: "c1", From: "id1"}))
+	_, ok := container.Annotations[types.ContainerStoppedAnnotation]
+	assert.False(t, ok)
+}
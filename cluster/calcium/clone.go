@@ -0,0 +1,92 @@
+package calcium
+
+import (
+	"context"
+	"time"
+
+	"github.com/projecteru2/core/cluster"
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// CloneContainer creates a container with options of an existing one, e.g. a replica for debugging
+// image, command, env, volumes and resources are the same, node planned resources are scheduled again
+func (c *Calcium) CloneContainer(ctx context.Context, opts *types.CloneOptions) (chan *types.CreateContainerMessage, error) {
+	container, err := c.GetContainer(ctx, opts.ID)
+	if err != nil {
+		return nil, err
+	}
+	info, err := container.Inspect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deployOpts, err := makeCloneDeployOptions(container, info.Cmd, info.Dir, info.Labels, info.Networks)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Nodename != "" && opts.Nodename != container.Nodename {
+		node, err := c.GetNode(ctx, opts.Nodename)
+		if err != nil {
+			return nil, err
+		}
+		deployOpts.Podname = node.Podname
+		deployOpts.Nodename = node.Name
+	}
+	deployOpts.Env = append(deployOpts.Env, opts.Env...)
+	deployOpts.IgnoreHook = opts.IgnoreHook
+	log.Infof("[CloneContainer] Clone container %s to node %s", container.ID, deployOpts.Nodename)
+	return c.CreateContainer(ctx, deployOpts)
+}
+
+func makeCloneDeployOptions(container *types.Container, cmd []string, dir string, labels, networks map[string]string) (*types.DeployOptions, error) {
+	appname, entrypoint, _, err := utils.ParseContainerName(container.Name)
+	if err != nil {
+		return nil, err
+	}
+	meta := utils.DecodeMetaInLabel(labels)
+	userLabels := map[string]string{}
+	for key, value := range labels {
+		if !isReservedLabel(key) {
+			userLabels[key] = value
+		}
+	}
+	// ips are not cloned
+	cloneNetworks := map[string]string{}
+	for name := range networks {
+		cloneNetworks[name] = ""
+	}
+	return &types.DeployOptions{
+		Name: appname,
+		Entrypoint: &types.Entrypoint{
+			Name:          entrypoint,
+			Commands:      cmd,
+			Privileged:    container.Privileged,
+			Dir:           dir,
+			Publish:       meta.Publish,
+			HealthCheck:   meta.HealthCheck,
+			Hook:          container.Hook,
+			RestartPolicy: container.Restart,
+			StopTimeout:   int(container.StopTimeout / time.Second),
+		},
+		Podname:        container.Podname,
+		Nodename:       container.Nodename,
+		Image:          container.Image,
+		CPUQuota:       container.Quota,
+		CPUBind:        len(container.CPU) > 0,
+		CPUShares:      container.CPUShares,
+		Memory:         container.Memory,
+		Storage:        container.Storage,
+		Count:          1,
+		Env:            append([]string{}, container.Env...),
+		Volumes:        container.Volumes,
+		Networks:       cloneNetworks,
+		User:           container.User,
+		Labels:         userLabels,
+		Annotations:    container.Annotations,
+		DeployStrategy: cluster.DeployAuto,
+		SoftLimit:      container.SoftLimit,
+		Hugepages:      container.Hugepages,
+		GPURequest:     types.GPURequestOf(container.GPU),
+	}, nil
+}
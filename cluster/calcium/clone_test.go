@@ -0,0 +1,86 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/projecteru2/core/cluster"
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCloneContainer(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := c.store.(*storemocks.Store)
+	engine := &enginemocks.API{}
+
+	// failed by GetContainer
+	store.On("GetContainer", mock.Anything, "c1").Return(nil, types.ErrNoETCD).Once()
+	_, err := c.CloneContainer(ctx, &types.CloneOptions{ID: "c1"})
+	assert.Error(t, err)
+	container := &types.Container{ID: "c1", Name: "app_web_aaaaaa", Podname: "p1", Nodename: "n1", Engine: engine}
+	store.On("GetContainer", mock.Anything, "c1").Return(container, nil)
+	// failed by inspect
+	engine.On("VirtualizationInspect", mock.Anything, "c1").Return(nil, types.ErrNilEngine).Once()
+	_, err = c.CloneContainer(ctx, &types.CloneOptions{ID: "c1"})
+	assert.Error(t, err)
+	engine.On("VirtualizationInspect", mock.Anything, "c1").Return(&enginetypes.VirtualizationInfo{ID: "c1"}, nil)
+	// failed by node
+	store.On("GetNode", mock.Anything, "n2").Return(nil, types.ErrNoETCD).Once()
+	_, err = c.CloneContainer(ctx, &types.CloneOptions{ID: "c1", Nodename: "n2"})
+	assert.Error(t, err)
+	// failed by creating
+	store.On("GetPod", mock.Anything, "p1").Return(nil, types.ErrNoETCD).Once()
+	_, err = c.CloneContainer(ctx, &types.CloneOptions{ID: "c1"})
+	assert.Error(t, err)
+}
+
+func TestMakeCloneDeployOptions(t *testing.T) {
+	container := &types.Container{
+		ID:          "c1",
+		Name:        "app",
+		Podname:     "p1",
+		Nodename:    "n1",
+		Image:       "image",
+		Quota:       1.5,
+		CPU:         types.CPUMap{"0": 100, "1": 50},
+		Memory:      100,
+		Env:         []string{"A=1"},
+		StopTimeout: 10 * time.Second,
+		Restart:     &types.RestartPolicy{Policy: "always"},
+	}
+	_, err := makeCloneDeployOptions(container, nil, "", nil, nil)
+	assert.Error(t, err)
+
+	container.Name = "app_web_aaaaaa"
+	labels := map[string]string{
+		cluster.ERUMark:   "1",
+		cluster.LabelMeta: utils.EncodeMetaInLabel(&types.LabelMeta{Publish: []string{"80"}}),
+		"x":               "y",
+	}
+	opts, err := makeCloneDeployOptions(container, []string{"run", "--debug"}, "/app", labels, map[string]string{"net": "10.0.0.1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "app", opts.Name)
+	assert.Equal(t, "web", opts.Entrypoint.Name)
+	assert.Equal(t, []string{"run", "--debug"}, opts.Entrypoint.Commands)
+	assert.Equal(t, "/app", opts.Entrypoint.Dir)
+	assert.Equal(t, []string{"80"}, opts.Entrypoint.Publish)
+	assert.Equal(t, 10, opts.Entrypoint.StopTimeout)
+	assert.Equal(t, "always", opts.Entrypoint.RestartPolicy.Policy)
+	assert.Equal(t, map[string]string{"x": "y"}, opts.Labels)
+	assert.Equal(t, map[string]string{"net": ""}, opts.Networks)
+	assert.True(t, opts.CPUBind)
+	assert.Equal(t, 1.5, opts.CPUQuota)
+	assert.Equal(t, 1, opts.Count)
+	assert.Equal(t, "n1", opts.Nodename)
+	// env is copied
+	opts.Env = append(opts.Env, "B=2")
+	assert.Equal(t, []string{"A=1"}, container.Env)
+}
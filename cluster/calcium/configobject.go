@@ -0,0 +1,89 @@
+package calcium
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// SetConfigObject saves a config object, the old one of the same name is replaced,
+// containers mounting it get the new one by ReloadConfig
+func (c *Calcium) SetConfigObject(ctx context.Context, object *types.ConfigObject) error {
+	if err := object.Validate(); err != nil {
+		return err
+	}
+	return c.store.SetConfigObject(ctx, object)
+}
+
+// ListConfigObjects lists all config objects
+func (c *Calcium) ListConfigObjects(ctx context.Context) ([]*types.ConfigObject, error) {
+	return c.store.ListConfigObjects(ctx)
+}
+
+// RemoveConfigObject removes a config object by name, files mounted already are kept
+func (c *Calcium) RemoveConfigObject(ctx context.Context, name string) error {
+	return c.store.RemoveConfigObject(ctx, name)
+}
+
+// ReloadConfig renders a config object again for running containers mounting it and sends them the files,
+// containers of all apps are reloaded if appname is empty
+func (c *Calcium) ReloadConfig(ctx context.Context, name, appname string) (chan *types.SendMessage, error) {
+	object, err := c.store.GetConfigObject(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	containers, err := c.store.ListContainers(ctx, appname, "", "", 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan *types.SendMessage)
+	go func() {
+		defer close(ch)
+		for _, container := range containers {
+			if container.StatusMeta != nil && !container.StatusMeta.Running {
+				continue
+			}
+			for _, ref := range container.Configs {
+				if ref.Name != name {
+					continue
+				}
+				err := c.doReloadConfig(ctx, container, object, ref)
+				if err != nil {
+					log.Errorf("[ReloadConfig] Reload %s of container %s failed %v", name, container.ID, err)
+				}
+				ch <- &types.SendMessage{ID: container.ID, Path: ref.Path, Error: err}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (c *Calcium) doReloadConfig(ctx context.Context, container *types.Container, object *types.ConfigObject, ref *types.ConfigRef) error {
+	appname, _, _, err := utils.ParseContainerName(container.Name)
+	if err != nil {
+		return err
+	}
+	content, err := object.Render(types.NewConfigData(appname, container.Podname, container.Nodename, container.Seq, container.Env))
+	if err != nil {
+		return err
+	}
+	return c.doSendFileToContainer(ctx, container.Engine, container.ID, ref.Path, bytes.NewReader(content), true, true)
+}
+
+// renderConfigs renders config objects referred by deploy into files by path in container
+func (c *Calcium) renderConfigs(ctx context.Context, refs []*types.ConfigRef, data *types.ConfigData) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for _, ref := range refs {
+		object, err := c.store.GetConfigObject(ctx, ref.Name)
+		if err != nil {
+			return nil, types.NewDetailedErr(err, "config object "+ref.Name)
+		}
+		if files[ref.Path], err = object.Render(data); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,81 @@
+package calcium
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSetConfigObject(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+
+	assert.True(t, errors.Is(c.SetConfigObject(ctx, &types.ConfigObject{Name: "nginx", Content: "{{"}), types.ErrBadConfigObject))
+	store.On("SetConfigObject", mock.Anything, mock.Anything).Return(nil)
+	assert.NoError(t, c.SetConfigObject(ctx, &types.ConfigObject{Name: "nginx", Content: "{{.Nodename}}"}))
+}
+
+func TestDoMakeContainerOptionsWithConfigs(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	store.On("GetConfigObject", mock.Anything, "nginx").Return(&types.ConfigObject{Name: "nginx", Content: "{{.Appname}}@{{.Nodename}}#{{.ContainerNo}}:{{.Env.PORT}}"}, nil)
+
+	opts := &types.DeployOptions{
+		Name:       "app",
+		Entrypoint: &types.Entrypoint{Name: "web"},
+		Env:        []string{"PORT=80"},
+		ConfigRefs: []*types.ConfigRef{{Name: "nginx", Path: "/etc/nginx.conf"}},
+	}
+	_, files, err := c.doMakeContainerOptions(ctx, 3, nil, nil, nil, opts, &types.Node{Name: "node1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "app@node1#3:80", string(files["/etc/nginx.conf"]))
+}
+
+func TestReloadConfig(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+
+	store.On("GetConfigObject", mock.Anything, "nope").Return(nil, types.ErrBadCount).Once()
+	_, err := c.ReloadConfig(ctx, "nope", "app")
+	assert.Error(t, err)
+
+	engine := &enginemocks.API{}
+	refs := []*types.ConfigRef{{Name: "nginx", Path: "/etc/nginx.conf"}}
+	store.On("GetConfigObject", mock.Anything, "nginx").Return(&types.ConfigObject{Name: "nginx", Content: "{{.Nodename}}-{{.ContainerNo}}"}, nil)
+	store.On("ListContainers", mock.Anything, "app", "", "", int64(0), mock.Anything).Return([]*types.Container{
+		{ID: "c1", Name: "app_web_aaaaaa", Nodename: "node1", Seq: 1, Configs: refs, Engine: engine},
+		// stopped
+		{ID: "c2", Name: "app_web_bbbbbb", Nodename: "node1", Configs: refs, Engine: engine, StatusMeta: &types.StatusMeta{}},
+		// not mounting it
+		{ID: "c3", Name: "app_web_cccccc", Nodename: "node2", Engine: engine},
+	}, nil)
+	sent := ""
+	engine.On("VirtualizationCopyTo", mock.Anything, "c1", "/etc/nginx.conf", mock.Anything, true, true).Return(nil).Run(func(args mock.Arguments) {
+		b, _ := ioutil.ReadAll(args.Get(3).(io.Reader))
+		sent = string(b)
+	})
+	ch, err := c.ReloadConfig(ctx, "nginx", "app")
+	assert.NoError(t, err)
+	messages := []*types.SendMessage{}
+	for m := range ch {
+		messages = append(messages, m)
+	}
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "c1", messages[0].ID)
+	assert.NoError(t, messages[0].Error)
+	assert.Equal(t, "node1-1", sent)
+}
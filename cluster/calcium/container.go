@@ -6,6 +6,7 @@ package calcium
 import (
 	"context"
 
+	"github.com/projecteru2/core/cluster"
 	"github.com/projecteru2/core/types"
 )
 
@@ -36,3 +37,89 @@ func (c *Calcium) GetContainer(ctx context.Context, ID string) (*types.Container
 func (c *Calcium) GetContainers(ctx context.Context, IDs []string) ([]*types.Container, error) {
 	return c.store.GetContainers(ctx, IDs)
 }
+
+// SetContainer update container labels, annotations and selected metadata
+// labels only change in store, engines keep the labels set at creation
+func (c *Calcium) SetContainer(ctx context.Context, opts *types.SetContainerOptions) (*types.Container, error) {
+	var container *types.Container
+	err := c.withContainerLocked(ctx, opts.ID, func(ct *types.Container) error {
+		// changes are made on a copy, the container is untouched if update fails
+		updated := *ct
+		updated.Labels = map[string]string{}
+		for key, value := range ct.Labels {
+			updated.Labels[key] = value
+		}
+		for key, value := range opts.Labels {
+			if isReservedLabel(key) {
+				return types.NewDetailedErr(types.ErrReservedLabel, key)
+			}
+			updated.Labels[key] = value
+		}
+		for _, key := range opts.RemoveLabels {
+			if isReservedLabel(key) {
+				return types.NewDetailedErr(types.ErrReservedLabel, key)
+			}
+			delete(updated.Labels, key)
+		}
+		updated.Annotations = map[string]string{}
+		for key, value := range ct.Annotations {
+			updated.Annotations[key] = value
+		}
+		for key, value := range opts.Annotations {
+			updated.Annotations[key] = value
+		}
+		for _, key := range opts.RemoveAnnotations {
+			delete(updated.Annotations, key)
+		}
+		if err := types.ValidateAnnotations(updated.Annotations); err != nil {
+			return err
+		}
+		if err := setContainerFields(&updated, opts); err != nil {
+			return err
+		}
+		if err := c.store.UpdateContainer(ctx, &updated); err != nil {
+			return err
+		}
+		container = &updated
+		return nil
+	})
+	return container, err
+}
+
+// setContainerFields applies metadata selected by fields of options
+func setContainerFields(container *types.Container, opts *types.SetContainerOptions) error {
+	for _, field := range opts.Fields {
+		switch field {
+		case types.ContainerFieldPriority:
+			container.Priority = opts.Priority
+		case types.ContainerFieldStopTimeout:
+			if opts.StopTimeout < 0 {
+				return types.NewDetailedErr(types.ErrBadStopTimeout, opts.StopTimeout)
+			}
+			container.StopTimeout = opts.StopTimeout
+		default:
+			return types.NewDetailedErr(types.ErrBadContainerField, field)
+		}
+	}
+	return nil
+}
+
+// GetContainerMeta get a meta value bound to container
+func (c *Calcium) GetContainerMeta(ctx context.Context, ID, key string) ([]byte, error) {
+	return c.store.GetContainerMeta(ctx, ID, key)
+}
+
+// SetContainerMeta set a meta value bound to container
+// meta will be removed with container
+func (c *Calcium) SetContainerMeta(ctx context.Context, ID, key string, value []byte) error {
+	return c.store.SetContainerMeta(ctx, ID, key, value)
+}
+
+// DeleteContainerMeta delete a meta value bound to container
+func (c *Calcium) DeleteContainerMeta(ctx context.Context, ID, key string) error {
+	return c.store.DeleteContainerMeta(ctx, ID, key)
+}
+
+func isReservedLabel(key string) bool {
+	return key == cluster.ERUMark || key == cluster.LabelMeta
+}
@@ -2,11 +2,16 @@ package calcium
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/projecteru2/core/cluster"
+	lockmocks "github.com/projecteru2/core/lock/mocks"
 	storemocks "github.com/projecteru2/core/store/mocks"
 	"github.com/projecteru2/core/types"
 )
@@ -54,3 +59,79 @@ func TestGetContainers(t *testing.T) {
 	assert.Equal(t, len(cs), 1)
 	assert.Equal(t, cs[0].ID, ID)
 }
+
+func TestSetContainer(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+
+	container := &types.Container{
+		ID:     "c1",
+		Labels: map[string]string{"a": "1", "b": "2", cluster.ERUMark: "1"},
+	}
+	store.On("GetContainers", mock.Anything, mock.Anything).Return([]*types.Container{container}, nil)
+	// failed by reserved label
+	_, err := c.SetContainer(ctx, &types.SetContainerOptions{ID: "c1", RemoveLabels: []string{cluster.ERUMark}})
+	assert.Error(t, err)
+	_, err = c.SetContainer(ctx, &types.SetContainerOptions{ID: "c1", Labels: map[string]string{cluster.LabelMeta: "x"}})
+	assert.Error(t, err)
+	// failed by update, container is untouched
+	store.On("UpdateContainer", mock.Anything, mock.Anything).Return(types.ErrNoETCD).Once()
+	_, err = c.SetContainer(ctx, &types.SetContainerOptions{ID: "c1", Labels: map[string]string{"a": "3"}})
+	assert.Error(t, err)
+	assert.Equal(t, "1", container.Labels["a"])
+	// success, saved as store does
+	store.On("UpdateContainer", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*container = *args.Get(1).(*types.Container)
+	})
+	ct, err := c.SetContainer(ctx, &types.SetContainerOptions{ID: "c1", Labels: map[string]string{"a": "3", "c": "3"}, RemoveLabels: []string{"b"}})
+	assert.NoError(t, err)
+	assert.Equal(t, ct.Labels["a"], "3")
+	assert.Equal(t, ct.Labels["c"], "3")
+	_, ok := ct.Labels["b"]
+	assert.False(t, ok)
+	// annotations
+	ct, err = c.SetContainer(ctx, &types.SetContainerOptions{ID: "c1", Annotations: map[string]string{"sha": "abc", "ticket": "1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, ct.Annotations["sha"], "abc")
+	ct, err = c.SetContainer(ctx, &types.SetContainerOptions{ID: "c1", RemoveAnnotations: []string{"ticket"}})
+	assert.NoError(t, err)
+	assert.Len(t, ct.Annotations, 1)
+	_, err = c.SetContainer(ctx, &types.SetContainerOptions{ID: "c1", Annotations: map[string]string{"big": strings.Repeat("x", types.MaxAnnotationsSize)}})
+	assert.Error(t, err)
+}
+
+func TestSetContainerFields(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+	store.On("UpdateContainer", mock.Anything, mock.Anything).Return(nil)
+
+	container := &types.Container{ID: "c1", Priority: 5, StopTimeout: time.Minute}
+	store.On("GetContainers", mock.Anything, mock.Anything).Return([]*types.Container{container}, nil)
+	_, err := c.SetContainer(ctx, &types.SetContainerOptions{ID: "c1", Fields: []string{"image"}})
+	assert.True(t, errors.Is(err, types.ErrBadContainerField))
+	_, err = c.SetContainer(ctx, &types.SetContainerOptions{ID: "c1", Fields: []string{types.ContainerFieldStopTimeout}, StopTimeout: -time.Second})
+	assert.True(t, errors.Is(err, types.ErrBadStopTimeout))
+
+	// unselected metadata is kept
+	ct, err := c.SetContainer(ctx, &types.SetContainerOptions{ID: "c1", Fields: []string{types.ContainerFieldPriority}, StopTimeout: time.Second})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, ct.Priority)
+	assert.Equal(t, time.Minute, ct.StopTimeout)
+	assert.Equal(t, 5, container.Priority)
+	ct, err = c.SetContainer(ctx, &types.SetContainerOptions{ID: "c1", Fields: []string{types.ContainerFieldStopTimeout}, StopTimeout: time.Second})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, ct.StopTimeout)
+}
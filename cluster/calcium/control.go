@@ -3,7 +3,8 @@ package calcium
 import (
 	"bytes"
 	"context"
-	"sync"
+	"io"
+	"time"
 
 	"github.com/projecteru2/core/cluster"
 	"github.com/projecteru2/core/types"
@@ -11,91 +12,110 @@ import (
 )
 
 // ControlContainer control containers status
-func (c *Calcium) ControlContainer(ctx context.Context, IDs []string, t string, force bool) (chan *types.ControlContainerMessage, error) {
+// with streamHook, hook stdout is also sent as partial messages as it comes, before the final message of each container
+// stopped containers are killed after stopTimeout, or StopTimeout of container if zero
+// containers are controlled at the same time limited by max concurrency of config, messages carry progress in order
+func (c *Calcium) ControlContainer(ctx context.Context, IDs []string, t string, force, streamHook bool, stopTimeout time.Duration) (chan *types.ControlContainerMessage, error) {
 	ch := make(chan *types.ControlContainerMessage)
+	progress := &bulkProgress{total: len(IDs)}
 
 	go func() {
 		defer close(ch)
-		wg := sync.WaitGroup{}
-		for _, ID := range IDs {
-			wg.Add(1)
-			go func(ID string) {
-				defer wg.Done()
-				var message []*bytes.Buffer
-				err := c.withContainerLocked(ctx, ID, func(container *types.Container) error {
-					var err error
-					switch t {
-					case cluster.ContainerStop:
-						message, err = c.doStopContainer(ctx, container, force)
-						return err
-					case cluster.ContainerStart:
-						message, err = c.doStartContainer(ctx, container, force)
-						return err
-					case cluster.ContainerRestart:
-						message, err = c.doStopContainer(ctx, container, force)
-						if err != nil {
-							return err
-						}
-						startHook, err := c.doStartContainer(ctx, container, force)
-						message = append(message, startHook...)
+		prefetched := c.prefetchContainers(ctx, IDs)
+		doBulk(ctx, IDs, c.bulkConcurrency(0), func(ID string) {
+			var message []*types.HookResult
+			var output io.Writer
+			if streamHook {
+				output = &controlHookWriter{ch: ch, ID: ID}
+			}
+			err := c.withPrefetchedContainerLocked(ctx, ID, prefetched, func(container *types.Container) error {
+				var err error
+				switch t {
+				case cluster.ContainerStop:
+					if message, err = c.doStopContainer(ctx, container, output, force, stopTimeout); err == nil {
+						c.doMarkContainerStopped(ctx, container, true)
+						c.publishContainerEvent(types.EventContainerStopped, container)
+					}
+					return err
+				case cluster.ContainerStart:
+					if message, err = c.doStartContainer(ctx, container, output, force); err == nil {
+						c.doMarkContainerStopped(ctx, container, false)
+						c.publishContainerEvent(types.EventContainerStarted, container)
+					}
+					return err
+				case cluster.ContainerRestart:
+					message, err = c.doStopContainer(ctx, container, output, force, stopTimeout)
+					if err != nil {
 						return err
 					}
-					return types.ErrUnknownControlType
-				})
-				if err == nil {
-					log.Infof("[ControlContainer] Container %s %s", ID, t)
-					log.Info("[ControlContainer] Hook Output:")
-					log.Info(string(types.HookOutput(message)))
+					c.publishContainerEvent(types.EventContainerStopped, container)
+					startHook, err := c.doStartContainer(ctx, container, output, force)
+					message = append(message, startHook...)
+					if err == nil {
+						c.doMarkContainerStopped(ctx, container, false)
+						c.publishContainerEvent(types.EventContainerStarted, container)
+					}
+					return err
 				}
+				return types.ErrUnknownControlType
+			})
+			if err == nil {
+				log.Infof("[ControlContainer] Container %s %s", ID, t)
+				log.Info("[ControlContainer] Hook Output:")
+				log.Info(string(types.HookOutput(types.HookResultsOutput(message))))
+			}
+			progress.send(func(done, total int) {
 				ch <- &types.ControlContainerMessage{
 					ContainerID: ID,
 					Error:       err,
-					Hook:        message,
+					Hook:        types.HookResultsOutput(message),
+					Done:        done,
+					Total:       total,
 				}
-			}(ID)
-		}
-		wg.Wait()
+			})
+		})
 	}()
 
 	return ch, nil
 }
 
-func (c *Calcium) doStartContainer(ctx context.Context, container *types.Container, force bool) (message []*bytes.Buffer, err error) {
+func (c *Calcium) doStartContainer(ctx context.Context, container *types.Container, output io.Writer, force bool) (message []*types.HookResult, err error) {
 	if err = container.Start(ctx); err != nil {
 		return message, err
 	}
 	// TODO healthcheck
-	if container.Hook != nil && len(container.Hook.AfterStart) > 0 {
-		message, err = c.doHook(
-			ctx,
-			container.ID, container.User,
-			container.Hook.AfterStart, container.Env,
-			container.Hook.Force, container.Privileged,
-			force, container.Engine,
-		)
-	}
-	return message, err
+	return c.doAfterStartHook(ctx, container, nil, output, force)
 }
 
-func (c *Calcium) doStopContainer(ctx context.Context, container *types.Container, force bool) (message []*bytes.Buffer, err error) {
-	if container.Hook != nil && len(container.Hook.BeforeStop) > 0 {
-		message, err = c.doHook(
-			ctx,
-			container.ID, container.User,
-			container.Hook.BeforeStop, container.Env,
-			container.Hook.Force, container.Privileged,
-			force, container.Engine,
-		)
-		if err != nil {
-			return message, err
-		}
+func (c *Calcium) doAfterStartHook(ctx context.Context, container *types.Container, publish map[string][]string, output io.Writer, force bool) (message []*types.HookResult, err error) {
+	return c.doHook(ctx, container, types.HookAfterStart, publish, output, force)
+}
+
+func (c *Calcium) doStopContainer(ctx context.Context, container *types.Container, output io.Writer, force bool, stopTimeout time.Duration) (message []*types.HookResult, err error) {
+	if message, err = c.doHook(ctx, container, types.HookBeforeStop, nil, output, force); err != nil {
+		return message, err
 	}
 
 	// 这里 block 的问题很严重，按照目前的配置是 5 分钟一级的 block
 	// 一个简单的处理方法是相信 ctx 不相信 engine 自身的处理
 	// 另外我怀疑 engine 自己的 timeout 实现是完全的等 timeout 而非结束了就退出
-	if err = container.Stop(ctx); err != nil {
-		message = append(message, bytes.NewBufferString(err.Error()))
+	if err = container.Stop(ctx, stopTimeout); err != nil {
+		message = append(message, &types.HookResult{Error: err})
 	}
 	return message, err
 }
+
+// controlHookWriter sends hook output of a container as partial control messages
+type controlHookWriter struct {
+	ch chan *types.ControlContainerMessage
+	ID string
+}
+
+func (w *controlHookWriter) Write(p []byte) (int, error) {
+	w.ch <- &types.ControlContainerMessage{
+		ContainerID: w.ID,
+		Hook:        []*bytes.Buffer{bytes.NewBuffer(append([]byte{}, p...))},
+		Partial:     true,
+	}
+	return len(p), nil
+}
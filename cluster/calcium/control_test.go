@@ -26,7 +26,7 @@ func TestControlStart(t *testing.T) {
 	store.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
 	// failed by GetContainers
 	store.On("GetContainers", mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD).Once()
-	ch, err := c.ControlContainer(ctx, []string{"id1"}, "", true)
+	ch, err := c.ControlContainer(ctx, []string{"id1"}, "", true, false, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.Error(t, r.Error)
@@ -39,14 +39,14 @@ func TestControlStart(t *testing.T) {
 	container.Engine = engine
 	store.On("GetContainers", mock.Anything, mock.Anything).Return([]*types.Container{container}, nil)
 	// failed by type
-	ch, err = c.ControlContainer(ctx, []string{"id1"}, "", true)
+	ch, err = c.ControlContainer(ctx, []string{"id1"}, "", true, false, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.Error(t, r.Error)
 	}
 	// failed by start
 	engine.On("VirtualizationStart", mock.Anything, mock.Anything).Return(types.ErrNilEngine).Once()
-	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStart, false)
+	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStart, false, false, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.Error(t, r.Error)
@@ -59,14 +59,14 @@ func TestControlStart(t *testing.T) {
 	container.Hook = hook
 	container.Hook.Force = false
 	engine.On("ExecCreate", mock.Anything, mock.Anything, mock.Anything).Return("", types.ErrNilEngine).Times(3)
-	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStart, false)
+	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStart, false, false, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.NoError(t, r.Error)
 	}
 	// force false, get no error
 	container.Hook.Force = true
-	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStart, false)
+	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStart, false, false, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.Error(t, r.Error)
@@ -74,32 +74,32 @@ func TestControlStart(t *testing.T) {
 	}
 	engine.On("ExecCreate", mock.Anything, mock.Anything, mock.Anything).Return("eid", nil)
 	// failed by ExecAttach
-	engine.On("ExecAttach", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, nil, types.ErrNilEngine).Once()
-	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStart, false)
+	engine.On("ExecAttachOutput", mock.Anything, mock.Anything).Return(nil, nil, types.ErrNilEngine).Once()
+	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStart, false, false, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.Error(t, r.Error)
 	}
 	data := ioutil.NopCloser(bytes.NewBufferString("output"))
-	engine.On("ExecAttach", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(data, nil, nil).Twice()
+	engine.On("ExecAttachOutput", mock.Anything, mock.Anything).Return(data, ioutil.NopCloser(bytes.NewBuffer(nil)), nil).Twice()
 	// failed by ExecExitCode
 	engine.On("ExecExitCode", mock.Anything, mock.Anything).Return(-1, types.ErrNilEngine).Once()
-	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStart, false)
+	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStart, false, false, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.Error(t, r.Error)
 	}
 	// exitCode is not 0
 	engine.On("ExecExitCode", mock.Anything, mock.Anything).Return(-1, nil).Once()
-	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStart, false)
+	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStart, false, false, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.Error(t, r.Error)
 	}
 	// exitCode is 0
 	engine.On("ExecExitCode", mock.Anything, mock.Anything).Return(0, nil)
-	engine.On("ExecAttach", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(ioutil.NopCloser(bytes.NewBufferString("succ")), nil, nil)
-	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStart, false)
+	engine.On("ExecAttachOutput", mock.Anything, mock.Anything).Return(ioutil.NopCloser(bytes.NewBufferString("succ")), ioutil.NopCloser(bytes.NewBuffer(nil)), nil)
+	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStart, false, false, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.NoError(t, r.Error)
@@ -129,22 +129,22 @@ func TestControlStop(t *testing.T) {
 	container.Hook = hook
 	container.Hook.Force = true
 	engine.On("ExecCreate", mock.Anything, mock.Anything, mock.Anything).Return("", types.ErrNilEngine)
-	ch, err := c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStop, false)
+	ch, err := c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStop, false, false, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.Error(t, r.Error)
 	}
 	// stop failed
 	container.Hook.Force = false
-	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStop, false)
-	engine.On("VirtualizationStop", mock.Anything, mock.Anything).Return(types.ErrNilEngine).Once()
+	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStop, false, false, 0)
+	engine.On("VirtualizationStop", mock.Anything, mock.Anything, mock.Anything).Return(types.ErrNilEngine).Once()
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.Error(t, r.Error)
 	}
-	engine.On("VirtualizationStop", mock.Anything, mock.Anything).Return(nil)
+	engine.On("VirtualizationStop", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	// stop success
-	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStop, false)
+	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStop, false, false, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.NoError(t, r.Error)
@@ -174,18 +174,53 @@ func TestControlRestart(t *testing.T) {
 	container.Hook = hook
 	container.Hook.Force = true
 	engine.On("ExecCreate", mock.Anything, mock.Anything, mock.Anything).Return("", types.ErrNilEngine)
-	ch, err := c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerRestart, false)
+	ch, err := c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerRestart, false, false, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.Error(t, r.Error)
 	}
 	container.Hook = nil
 	// success
-	engine.On("VirtualizationStop", mock.Anything, mock.Anything).Return(nil)
+	engine.On("VirtualizationStop", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	engine.On("VirtualizationStart", mock.Anything, mock.Anything).Return(nil)
-	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerRestart, false)
+	ch, err = c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerRestart, false, false, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.NoError(t, r.Error)
 	}
 }
+
+func TestControlStreamHook(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	c.store = store
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+	engine := &enginemocks.API{}
+	container := &types.Container{
+		ID:     "id1",
+		Engine: engine,
+		Hook:   &types.Hook{BeforeStop: []string{"drain"}},
+	}
+	store.On("GetContainers", mock.Anything, mock.Anything).Return([]*types.Container{container}, nil)
+	engine.On("ExecCreate", mock.Anything, mock.Anything, mock.Anything).Return("eid", nil)
+	engine.On("ExecAttachOutput", mock.Anything, mock.Anything).Return(
+		ioutil.NopCloser(bytes.NewBufferString("draining")), ioutil.NopCloser(bytes.NewBuffer(nil)), nil,
+	)
+	engine.On("ExecExitCode", mock.Anything, mock.Anything).Return(0, nil)
+	engine.On("VirtualizationStop", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	ch, err := c.ControlContainer(ctx, []string{"id1"}, cluster.ContainerStop, false, true, 0)
+	assert.NoError(t, err)
+	messages := []*types.ControlContainerMessage{}
+	for r := range ch {
+		messages = append(messages, r)
+	}
+	assert.Len(t, messages, 2)
+	assert.True(t, messages[0].Partial)
+	assert.Equal(t, "draining", string(types.HookOutput(messages[0].Hook)))
+	assert.False(t, messages[1].Partial)
+	assert.NoError(t, messages[1].Error)
+}
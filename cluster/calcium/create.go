@@ -1,9 +1,12 @@
 package calcium
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/projecteru2/core/cluster"
 	enginetypes "github.com/projecteru2/core/engine/types"
@@ -21,62 +24,136 @@ func (c *Calcium) CreateContainer(ctx context.Context, opts *types.DeployOptions
 	opts.ProcessIdent = utils.RandomString(16)
 	log.Infof("[CreateContainer %s] Creating container with options:", opts.ProcessIdent)
 	litter.Dump(opts)
+	if err := c.mutateDeployOptions(ctx, opts); err != nil {
+		return nil, err
+	}
+	if err := validateResourceOptions(opts); err != nil {
+		return nil, err
+	}
+	if err := types.ValidateAnnotations(opts.Annotations); err != nil {
+		return nil, err
+	}
+	if opts.Entrypoint != nil && opts.Entrypoint.HealthCheck != nil {
+		if err := opts.Entrypoint.HealthCheck.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Entrypoint != nil && opts.Entrypoint.RestartPolicy != nil {
+		if err := opts.Entrypoint.RestartPolicy.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Entrypoint != nil {
+		if err := types.ValidateUlimits(opts.Entrypoint.Ulimits); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Entrypoint != nil && opts.Entrypoint.StopTimeout < 0 {
+		return nil, types.NewDetailedErr(types.ErrBadStopTimeout, opts.Entrypoint.StopTimeout)
+	}
+	if opts.Entrypoint != nil && opts.Entrypoint.Hook != nil {
+		if err := opts.Entrypoint.Hook.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := types.ValidateTimezone(opts.Timezone); err != nil {
+		return nil, err
+	}
+	if err := types.ValidateLocale(opts.Locale); err != nil {
+		return nil, err
+	}
+	if err := types.ValidateSecretRefs(opts.SecretRefs); err != nil {
+		return nil, err
+	}
+	if err := types.ValidateConfigRefs(opts.ConfigRefs); err != nil {
+		return nil, err
+	}
+	if err := c.doFillPodDefaults(ctx, opts); err != nil {
+		return nil, err
+	}
+	return c.doCreateContainer(ctx, opts)
+}
+
+func validateResourceOptions(opts *types.DeployOptions) error {
 	// Count 要大于0
 	if opts.Count <= 0 {
-		return nil, types.NewDetailedErr(types.ErrBadCount, opts.Count)
+		return types.NewDetailedErr(types.ErrBadCount, opts.Count)
 	}
 	// 创建时内存不为 0
 	if opts.Memory < 0 {
-		return nil, types.NewDetailedErr(types.ErrBadMemory, opts.Memory)
+		return types.NewDetailedErr(types.ErrBadMemory, opts.Memory)
 	}
 	// CPUQuota 也需要大于 0
 	if opts.CPUQuota < 0 {
-		return nil, types.NewDetailedErr(types.ErrBadCPU, opts.CPUQuota)
+		return types.NewDetailedErr(types.ErrBadCPU, opts.CPUQuota)
 	}
-	return c.doCreateContainer(ctx, opts)
+	if err := types.ValidateHugepages(opts.Hugepages); err != nil {
+		return err
+	}
+	if err := opts.GPURequest.Validate(); err != nil {
+		return err
+	}
+	if err := types.ValidateCPUShares(opts.CPUShares); err != nil {
+		return err
+	}
+	return nil
 }
 
 func (c *Calcium) doCreateContainer(ctx context.Context, opts *types.DeployOptions) (chan *types.CreateContainerMessage, error) {
-	ch := make(chan *types.CreateContainerMessage)
+	sender := newCreateSender("CreateContainer", c.config.Stream)
+	ch := sender.ch
 	// RFC 计算当前 app 部署情况的时候需要保证同一时间只有这个 app 的这个 entrypoint 在跑
 	// 因此需要在这里加个全局锁，直到部署完毕才释放
 	// 通过 Processing 状态跟踪达成 18 Oct, 2018
-	nodesInfo, err := c.doAllocResource(ctx, opts)
+	var nodesInfo []types.NodeInfo
+	var err error
+	if opts.ReservationID != "" {
+		nodesInfo, err = c.doConsumeReservation(ctx, opts)
+	} else {
+		nodesInfo, err = c.doAllocResource(ctx, opts)
+	}
+	var preempted []string
+	if err != nil && preemptible(opts, err) {
+		log.Warnf("[doCreateContainer] Alloc resource failed %v, preempting containers of lower priority than %d", err, opts.Priority)
+		var preemptErr error
+		if preempted, preemptErr = c.doPreempt(ctx, opts); preemptErr != nil {
+			log.Errorf("[doCreateContainer] Preempt failed %v", preemptErr)
+		}
+		if len(preempted) > 0 {
+			nodesInfo, err = c.doAllocResource(ctx, opts)
+		}
+	}
 	if err != nil {
 		log.Errorf("[doCreateContainer] Error during alloc resource: %v", err)
+		c.recordDeployFailure(opts.Podname, err)
+		go c.sendDeploySchedulingFailure(opts)
 		return ch, err
 	}
 
+	// resources are reserved, containers are dispatched without holding any lock
+	stopTracking := c.trackDispatch(ctx, opts, nodesInfo)
 	go func() {
-		defer close(ch)
+		defer sender.close()
+		defer stopTracking()
 		wg := sync.WaitGroup{}
 		wg.Add(len(nodesInfo))
 		index := 0
+		reportPreempted := sync.Once{}
 
 		// do deployment by each node
 		for _, nodeInfo := range nodesInfo {
-			go metrics.Client.SendDeployCount(nodeInfo.Deploy)
 			go func(nodeInfo types.NodeInfo, index int) {
 				_ = utils.Txn(
 					ctx,
 					func(ctx context.Context) error {
-						for i, m := range c.doCreateContainerOnNode(ctx, nodeInfo, opts, index) {
-							_ = utils.Txn(
-								ctx,
-								func(ctx context.Context) error {
-									ch <- m // nolint
-									return nil
-								},
-								func(ctx context.Context) error {
-									// decr processing count
-									if err := c.store.UpdateProcessing(ctx, opts, nodeInfo.Name, nodeInfo.Deploy-i-1); err != nil { // nolint
-										log.Warnf("[doCreateContainer] Update processing count failed %v", err)
-									}
-									return nil
-								},
-								nil,
-								c.config.GlobalTimeout,
-							)
+						ms := c.doCreateContainerOnNode(ctx, nodeInfo, opts, index)
+						c.refundFailedQuotas(ctx, opts, ms)
+						go c.sendDeployCount(opts, nodeInfo.Name, ms)
+						if len(ms) > 0 && len(preempted) > 0 {
+							reportPreempted.Do(func() { ms[0].Preempted = preempted })
+						}
+						for _, m := range ms {
+							sender.send(m) // nolint
 						}
 						return nil
 					},
@@ -101,6 +178,8 @@ func (c *Calcium) doCreateContainer(ctx context.Context, opts *types.DeployOptio
 
 func (c *Calcium) doCreateContainerOnNode(ctx context.Context, nodeInfo types.NodeInfo, opts *types.DeployOptions, index int) []*types.CreateContainerMessage {
 	ms := make([]*types.CreateContainerMessage, nodeInfo.Deploy)
+	// image is pulled once on node in a deploy
+	pulled := false
 	for i := 0; i < nodeInfo.Deploy; i++ {
 		// createAndStartContainer will auto cleanup
 		cpu := types.CPUMap{}
@@ -111,24 +190,38 @@ func (c *Calcium) doCreateContainerOnNode(ctx context.Context, nodeInfo types.No
 		if len(nodeInfo.VolumePlans) > 0 {
 			volumePlan = nodeInfo.VolumePlans[i]
 		}
+		gpu := types.GPUMap{}
+		if len(nodeInfo.GPUPlan) > 0 {
+			gpu = nodeInfo.GPUPlan[i]
+		}
 
 		node := &types.Node{}
 		if err := utils.Txn(
 			ctx,
 			// if
 			func(ctx context.Context) (err error) {
-				node, err = c.doGetAndPrepareNode(ctx, nodeInfo.Name, opts.Image)
+				var reason string
+				if pulled {
+					if node, err = c.GetNode(ctx, nodeInfo.Name); err != nil {
+						reason = types.DeployFailureNode
+					}
+				} else {
+					node, reason, err = c.doGetAndPrepareNode(ctx, nodeInfo.Name, opts.Image)
+					pulled = err == nil
+				}
 				ms[i] = &types.CreateContainerMessage{ // nolint
-					Error:      err,
-					CPU:        cpu,
-					VolumePlan: volumePlan,
+					Error:         err,
+					FailureReason: reason,
+					CPU:           cpu,
+					VolumePlan:    volumePlan,
+					GPU:           gpu,
 				}
 				return
 			},
 			// then
 			func(ctx context.Context) error {
-				ms[i] = c.doCreateAndStartContainer(ctx, i+index, node, opts, cpu, volumePlan) // nolint
-				return ms[i].Error                                                             // nolint
+				ms[i] = c.doCreateAndStartContainer(ctx, i+index, node, opts, cpu, volumePlan, gpu) // nolint
+				return ms[i].Error                                                                  // nolint
 			},
 			// rollback, will use background context
 			func(ctx context.Context) (err error) {
@@ -138,7 +231,7 @@ func (c *Calcium) doCreateContainerOnNode(ctx context.Context, nodeInfo types.No
 					return
 				}
 				if err = c.withNodeLocked(ctx, nodeInfo.Name, func(node *types.Node) error {
-					return c.store.UpdateNodeResource(ctx, node, cpu, opts.CPUQuota, opts.Memory, opts.Storage, volumePlan.IntoVolumeMap(), store.ActionIncr)
+					return c.store.UpdateNodeResource(ctx, node, cpu, opts.CPUQuota, opts.Memory, opts.Storage, volumePlan.IntoVolumeMap(), opts.Hugepages, gpu, store.ActionIncr)
 				}); err != nil {
 					log.Errorf("[doCreateContainer] Reset node resource %s failed %v", nodeInfo.Name, err)
 				}
@@ -146,21 +239,72 @@ func (c *Calcium) doCreateContainerOnNode(ctx context.Context, nodeInfo types.No
 			},
 			c.config.GlobalTimeout,
 		); err != nil {
+			c.doneProcessing(ctx, opts, nodeInfo, i)
 			continue
 		}
+		c.doneProcessing(ctx, opts, nodeInfo, i)
 		log.Infof("[doCreateContainerOnNode] create container success %s", ms[i].ContainerID)
+		c.publishEvent(&types.Event{
+			Kind:        types.EventContainerCreated,
+			Podname:     opts.Podname,
+			Nodename:    nodeInfo.Name,
+			Appname:     opts.Name,
+			ContainerID: ms[i].ContainerID,
+			Image:       opts.Image,
+		})
 	}
 
 	return ms
 }
 
-func (c *Calcium) doGetAndPrepareNode(ctx context.Context, nodename, image string) (*types.Node, error) {
+// doneProcessing decreases processing count once a container is created or given back,
+// so a crashed deploy only releases resources of containers not dispatched
+func (c *Calcium) doneProcessing(ctx context.Context, opts *types.DeployOptions, nodeInfo types.NodeInfo, i int) {
+	if err := c.store.UpdateProcessing(ctx, opts, nodeInfo.Name, nodeInfo.Deploy-i-1); err != nil {
+		log.Warnf("[doCreateContainer] Update processing count failed %v", err)
+	}
+}
+
+// sendDeployCount counts created and failed containers on node, failures are also counted by reason
+func (c *Calcium) sendDeployCount(opts *types.DeployOptions, nodename string, ms []*types.CreateContainerMessage) {
+	success, failure := 0, 0
+	reasons := map[string]int{}
+	for _, m := range ms {
+		if m.Error != nil {
+			failure++
+			reasons[m.FailureReason]++
+		} else {
+			success++
+		}
+	}
+	metrics.Client.SendDeployCount(opts.Name, opts.Entrypoint.Name, opts.Podname, nodename, success, failure)
+	c.recordDeployResult(opts.Name, success, failure)
+	for reason, n := range reasons {
+		metrics.Client.SendDeployFailure(opts.Name, opts.Entrypoint.Name, opts.Podname, nodename, reason, n)
+	}
+}
+
+// sendDeploySchedulingFailure counts all containers as failed when resource can't be allocated
+func (c *Calcium) sendDeploySchedulingFailure(opts *types.DeployOptions) {
+	metrics.Client.SendDeployCount(opts.Name, opts.Entrypoint.Name, opts.Podname, "", 0, opts.Count)
+	c.recordDeployResult(opts.Name, 0, opts.Count)
+	metrics.Client.SendDeployFailure(opts.Name, opts.Entrypoint.Name, opts.Podname, "", types.DeployFailureScheduling, opts.Count)
+}
+
+// doGetAndPrepareNode gets node and pulls image on it, the failed step is returned as reason
+func (c *Calcium) doGetAndPrepareNode(ctx context.Context, nodename, image string) (*types.Node, string, error) {
 	node, err := c.GetNode(ctx, nodename)
 	if err != nil {
-		return nil, err
+		return nil, types.DeployFailureNode, err
 	}
-
-	return node, pullImage(ctx, node, image)
+	auth, err := c.registryAuth(ctx, node.Podname, image)
+	if err != nil {
+		return node, types.DeployFailureImage, err
+	}
+	if err := c.puller.pull(ctx, node, image, auth); err != nil {
+		return node, types.DeployFailureImage, err
+	}
+	return node, "", nil
 }
 
 func (c *Calcium) doCreateAndStartContainer(
@@ -169,23 +313,34 @@ func (c *Calcium) doCreateAndStartContainer(
 	opts *types.DeployOptions,
 	cpu types.CPUMap,
 	volumePlan types.VolumePlan,
+	gpu types.GPUMap,
 ) *types.CreateContainerMessage {
 	container := &types.Container{
-		Podname:    opts.Podname,
-		Nodename:   node.Name,
-		CPU:        cpu,
-		Quota:      opts.CPUQuota,
-		Memory:     opts.Memory,
-		Storage:    opts.Storage,
-		Hook:       opts.Entrypoint.Hook,
-		Privileged: opts.Entrypoint.Privileged,
-		Engine:     node.Engine,
-		SoftLimit:  opts.SoftLimit,
-		Image:      opts.Image,
-		Env:        opts.Env,
-		User:       opts.User,
-		Volumes:    opts.Volumes,
-		VolumePlan: volumePlan,
+		Podname:     opts.Podname,
+		Nodename:    node.Name,
+		CPU:         cpu,
+		Quota:       opts.CPUQuota,
+		CPUShares:   opts.CPUShares,
+		Memory:      opts.Memory,
+		Storage:     opts.Storage,
+		Hook:        opts.Entrypoint.Hook,
+		PodHook:     opts.PodHook,
+		Privileged:  opts.Entrypoint.Privileged,
+		Engine:      node.Engine,
+		SoftLimit:   opts.SoftLimit,
+		Image:       opts.Image,
+		Env:         opts.Env,
+		User:        opts.User,
+		Volumes:     opts.Volumes,
+		VolumePlan:  volumePlan,
+		Hugepages:   opts.Hugepages,
+		GPU:         gpu,
+		Annotations: opts.Annotations,
+		Restart:     opts.Entrypoint.RestartPolicy,
+		StopTimeout: time.Duration(opts.Entrypoint.StopTimeout) * time.Second,
+		Priority:    opts.Priority,
+		Seq:         no,
+		Configs:     opts.ConfigRefs,
 	}
 	createContainerMessage := &types.CreateContainerMessage{
 		Podname:    container.Podname,
@@ -195,26 +350,50 @@ func (c *Calcium) doCreateAndStartContainer(
 		Memory:     opts.Memory,
 		Storage:    opts.Storage,
 		VolumePlan: volumePlan,
+		Hugepages:  opts.Hugepages,
+		GPU:        gpu,
 		Publish:    map[string][]string{},
 	}
 	var err error
 	var containerCreated *enginetypes.VirtualizationCreated
+	var walID uint64
+	reason := types.DeployFailureCreate
 
 	_ = utils.Txn(
 		ctx,
 		func(ctx context.Context) error {
 			// get config
-			config := c.doMakeContainerOptions(no, cpu, volumePlan, opts, node)
+			var config *enginetypes.VirtualizationCreateOptions
+			var files map[string][]byte
+			if config, files, err = c.doMakeContainerOptions(ctx, no, cpu, volumePlan, gpu, opts, node); err != nil {
+				return err
+			}
 			container.Name = config.Name
 			container.Labels = config.Labels
+			container.Cmd = config.Cmd
+			container.Dir = config.WorkingDir
+			for name := range config.Networks {
+				container.Networks = append(container.Networks, name)
+			}
+			sort.Strings(container.Networks)
 			createContainerMessage.ContainerName = container.Name
 
+			// log intent, container left by a crash is removed on start
+			intent := &createContainerIntent{Nodename: node.Name, Name: container.Name}
+			if walID, err = c.wal.Log(walCreateContainer, intent); err != nil {
+				return err
+			}
+
 			// create container
 			containerCreated, err = node.Engine.VirtualizationCreate(ctx, config)
 			if err != nil {
 				return err
 			}
 			container.ID = containerCreated.ID
+			intent.ID = container.ID
+			if err = c.wal.Update(walID, walCreateContainer, intent); err != nil {
+				return err
+			}
 
 			// Copy data to container
 			if len(opts.Data) > 0 {
@@ -228,18 +407,21 @@ func (c *Calcium) doCreateAndStartContainer(
 					}
 				}
 			}
+			for dst, content := range files {
+				if err = c.doSendFileToContainer(ctx, node.Engine, container.ID, dst, bytes.NewReader(content), true, true); err != nil {
+					return err
+				}
+			}
 
 			// deal with hook
 			if len(opts.AfterCreate) > 0 && container.Hook != nil {
-				container.Hook = &types.Hook{
-					AfterStart: append(opts.AfterCreate, container.Hook.AfterStart...),
-					Force:      container.Hook.Force,
-				}
+				hook := *container.Hook
+				hook.AfterStart = append(append([]string{}, opts.AfterCreate...), hook.AfterStart...)
+				container.Hook = &hook
 			}
 
 			// start first
-			createContainerMessage.Hook, err = c.doStartContainer(ctx, container, opts.IgnoreHook)
-			if err != nil {
+			if err = container.Start(ctx); err != nil {
 				return err
 			}
 
@@ -258,28 +440,47 @@ func (c *Calcium) doCreateAndStartContainer(
 			if containerInfo.User != container.User {
 				container.User = containerInfo.User
 			}
+
+			// hooks know where the container is published
+			reason = types.DeployFailureHook
+			createContainerMessage.HookResults, err = c.doAfterStartHook(ctx, container, createContainerMessage.Publish, nil, opts.IgnoreHook)
+			createContainerMessage.Hook = types.HookResultsOutput(createContainerMessage.HookResults)
+			if err != nil {
+				return err
+			}
 			// reset container.hook
 			container.Hook = opts.Entrypoint.Hook
 			return nil
 		},
 		func(ctx context.Context) error {
 			// store eru container
+			reason = types.DeployFailureStore
 			if err = c.store.AddContainer(ctx, container); err != nil {
 				return err
 			}
 			// non-empty message.ContainerID means "core saves metadata of this container"
 			createContainerMessage.ContainerID = container.ID
+			if err := c.wal.Commit(walID); err != nil {
+				log.Errorf("[doCreateAndStartContainer] commit intent of container %s failed %v", container.ID, err)
+			}
 			return nil
 		},
 		func(ctx context.Context) error {
 			createContainerMessage.Error = err
+			if err != nil {
+				createContainerMessage.FailureReason = reason
+			}
 			if err != nil && container.ID != "" {
-				if err := c.doRemoveContainer(ctx, container, true); err != nil {
+				if _, err := c.doRemoveContainer(ctx, container, true, 0); err != nil {
 					log.Errorf("[doCreateAndStartContainer] create and start container failed, and remove it failed also, %s, %v", container.ID, err)
 					return err
 				}
 				createContainerMessage.ContainerID = ""
 			}
+			// nothing left on engine
+			if err := c.wal.Commit(walID); err != nil {
+				log.Errorf("[doCreateAndStartContainer] commit intent of container %s failed %v", container.Name, err)
+			}
 			return nil
 		},
 		c.config.GlobalTimeout,
@@ -287,14 +488,33 @@ func (c *Calcium) doCreateAndStartContainer(
 	return createContainerMessage
 }
 
-func (c *Calcium) doMakeContainerOptions(index int, cpumap types.CPUMap, volumePlan types.VolumePlan, opts *types.DeployOptions, node *types.Node) *enginetypes.VirtualizationCreateOptions {
+// doMakeContainerOptions makes create options of a container, secrets referred are resolved,
+// values of env ones are set in env of options, file ones and rendered config objects are returned to send after creation
+func (c *Calcium) doMakeContainerOptions(ctx context.Context, index int, cpumap types.CPUMap, volumePlan types.VolumePlan, gpu types.GPUMap, opts *types.DeployOptions, node *types.Node) (*enginetypes.VirtualizationCreateOptions, map[string][]byte, error) {
+	secretEnv, files, err := c.resolveSecrets(ctx, opts.SecretRefs)
+	if err != nil {
+		return nil, nil, err
+	}
+	configFiles, err := c.renderConfigs(ctx, opts.ConfigRefs, types.NewConfigData(opts.Name, opts.Podname, node.Name, index, opts.Env))
+	if err != nil {
+		return nil, nil, err
+	}
+	if files == nil {
+		files = map[string][]byte{}
+	}
+	for dst, content := range configFiles {
+		files[dst] = content
+	}
 	config := &enginetypes.VirtualizationCreateOptions{}
 	// general
 	config.Seq = index
 	config.CPU = cpumap
 	config.Quota = opts.CPUQuota
+	config.CPUShares = opts.CPUShares
 	config.Memory = opts.Memory
 	config.Storage = opts.Storage
+	config.Hugepages = opts.Hugepages
+	config.GPU = gpu
 	config.NUMANode = node.GetNUMANode(cpumap)
 	config.SoftLimit = opts.SoftLimit
 	config.RawArgs = opts.RawArgs
@@ -306,6 +526,10 @@ func (c *Calcium) doMakeContainerOptions(index int, cpumap types.CPUMap, volumeP
 	config.Hosts = opts.ExtraHosts
 	config.Volumes = opts.Volumes.ApplyPlan(volumePlan).ToStringSlice(false, true)
 	config.VolumePlan = volumePlan.ToLiteral()
+	for _, device := range opts.Devices {
+		d := device.Normalized()
+		config.Devices = append(config.Devices, enginetypes.Device{PathOnHost: d.PathOnHost, PathInContainer: d.PathInContainer, Permissions: d.Permissions})
+	}
 	config.Debug = opts.Debug
 	config.Network = opts.NetworkMode
 	config.Networks = opts.Networks
@@ -314,8 +538,18 @@ func (c *Calcium) doMakeContainerOptions(index int, cpumap types.CPUMap, volumeP
 	entry := opts.Entrypoint
 	config.WorkingDir = entry.Dir
 	config.Privileged = entry.Privileged
-	config.RestartPolicy = entry.RestartPolicy
+	if entry.RestartPolicy != nil {
+		config.RestartPolicy = enginetypes.RestartPolicy{
+			Name:       entry.RestartPolicy.Policy,
+			MaxRetries: entry.RestartPolicy.MaxRetries,
+			Backoff:    entry.RestartPolicy.Backoff,
+			Window:     entry.RestartPolicy.Window,
+		}
+	}
 	config.Sysctl = entry.Sysctls
+	for name, ulimit := range entry.Ulimits {
+		config.Ulimits = append(config.Ulimits, enginetypes.Ulimit{Name: name, Soft: ulimit.Soft, Hard: ulimit.Hard})
+	}
 	config.Publish = entry.Publish
 	if entry.Log != nil {
 		config.LogType = entry.Log.Type
@@ -326,8 +560,12 @@ func (c *Calcium) doMakeContainerOptions(index int, cpumap types.CPUMap, volumeP
 	config.Name = utils.MakeContainerName(opts.Name, opts.Entrypoint.Name, suffix)
 	// command and user
 	// extra args is dynamically
-	slices := utils.MakeCommandLineArgs(fmt.Sprintf("%s %s", entry.Command, opts.ExtraArgs))
-	config.Cmd = slices
+	if len(entry.Commands) > 0 {
+		// exec form, keep args as they are
+		config.Cmd = append(append([]string{}, entry.Commands...), utils.MakeCommandLineArgs(opts.ExtraArgs)...)
+	} else {
+		config.Cmd = utils.MakeCommandLineArgs(fmt.Sprintf("%s %s", entry.Command, opts.ExtraArgs))
+	}
 	// env
 	env := append(opts.Env, fmt.Sprintf("APP_NAME=%s", opts.Name))
 	env = append(env, fmt.Sprintf("ERU_POD=%s", opts.Podname))
@@ -335,7 +573,8 @@ func (c *Calcium) doMakeContainerOptions(index int, cpumap types.CPUMap, volumeP
 	env = append(env, fmt.Sprintf("ERU_CONTAINER_NO=%d", index))
 	env = append(env, fmt.Sprintf("ERU_MEMORY=%d", opts.Memory))
 	env = append(env, fmt.Sprintf("ERU_STORAGE=%d", opts.Storage))
-	config.Env = env
+	// secrets are never saved with container, only given to engine
+	config.Env = append(env, secretEnv...)
 	// basic labels, bind to LabelMeta
 	config.Labels = map[string]string{
 		cluster.ERUMark: "1",
@@ -348,5 +587,5 @@ func (c *Calcium) doMakeContainerOptions(index int, cpumap types.CPUMap, volumeP
 		config.Labels[key] = value
 	}
 
-	return config
+	return config, files, nil
 }
@@ -49,11 +49,11 @@ func TestCreateContainerTxn(t *testing.T) {
 	c := NewTestCluster()
 	ctx := context.Background()
 	opts := &types.DeployOptions{
-		Count:        2,
-		DeployMethod: "auto",
-		CPUQuota:     1,
-		Image:        "zc:test",
-		Entrypoint:   &types.Entrypoint{},
+		Count:          2,
+		DeployStrategy: "auto",
+		CPUQuota:       1,
+		Image:          "zc:test",
+		Entrypoint:     &types.Entrypoint{},
 	}
 	store := &storemocks.Store{}
 	scheduler := &schedulermocks.Scheduler{}
@@ -125,6 +125,18 @@ func TestCreateContainerTxn(t *testing.T) {
 		},
 		len(nodes), nil,
 	)
+	scheduler.On("SelectHugepageNodes", mock.AnythingOfType("[]types.NodeInfo"), mock.AnythingOfType("types.ResourceMap")).Return(
+		func(nodesInfo []types.NodeInfo, _ types.HugepageMap) []types.NodeInfo {
+			return nodesInfo
+		},
+		len(nodes), nil,
+	)
+	scheduler.On("SelectGPUNodes", mock.AnythingOfType("[]types.NodeInfo"), mock.AnythingOfType("*types.GPURequest")).Return(
+		func(nodesInfo []types.NodeInfo, _ *types.GPURequest) []types.NodeInfo {
+			return nodesInfo
+		},
+		nil, len(nodes), nil,
+	)
 	scheduler.On("SelectVolumeNodes", mock.AnythingOfType("[]types.NodeInfo"), mock.AnythingOfType("types.VolumeBindings")).Return(
 		func(nodesInfo []types.NodeInfo, _ types.VolumeBindings) []types.NodeInfo {
 			return nodesInfo
@@ -148,8 +160,10 @@ func TestCreateContainerTxn(t *testing.T) {
 		mock.AnythingOfType("int64"),
 		mock.AnythingOfType("int64"),
 		mock.AnythingOfType("types.ResourceMap"),
+		mock.AnythingOfType("types.ResourceMap"),
+		mock.AnythingOfType("types.ResourceMap"),
 		mock.AnythingOfType("string")).Return(
-		func(ctx context.Context, node *types.Node, _ types.CPUMap, quota float64, _, _ int64, _ types.VolumeMap, action string) error {
+		func(ctx context.Context, node *types.Node, _ types.CPUMap, quota float64, _, _ int64, _ types.VolumeMap, _ types.HugepageMap, _ types.GPUMap, action string) error {
 			if action == st.ActionDecr {
 				return errors.Wrap(context.DeadlineExceeded, "UpdateNodeResource")
 			}
@@ -177,8 +191,10 @@ func TestCreateContainerTxn(t *testing.T) {
 		mock.AnythingOfType("int64"),
 		mock.AnythingOfType("int64"),
 		mock.AnythingOfType("types.ResourceMap"),
+		mock.AnythingOfType("types.ResourceMap"),
+		mock.AnythingOfType("types.ResourceMap"),
 		mock.AnythingOfType("string")).Return(
-		func(ctx context.Context, node *types.Node, _ types.CPUMap, quota float64, _, _ int64, _ types.VolumeMap, action string) error {
+		func(ctx context.Context, node *types.Node, _ types.CPUMap, quota float64, _, _ int64, _ types.VolumeMap, _ types.HugepageMap, _ types.GPUMap, action string) error {
 			if action == st.ActionDecr {
 				cnt++
 				if cnt == 2 {
@@ -209,8 +225,10 @@ func TestCreateContainerTxn(t *testing.T) {
 		mock.AnythingOfType("int64"),
 		mock.AnythingOfType("int64"),
 		mock.AnythingOfType("types.ResourceMap"),
+		mock.AnythingOfType("types.ResourceMap"),
+		mock.AnythingOfType("types.ResourceMap"),
 		mock.AnythingOfType("string")).Return(
-		func(ctx context.Context, node *types.Node, _ types.CPUMap, quota float64, _, _ int64, _ types.VolumeMap, action string) error {
+		func(ctx context.Context, node *types.Node, _ types.CPUMap, quota float64, _, _ int64, _ types.VolumeMap, _ types.HugepageMap, _ types.GPUMap, action string) error {
 			if action == st.ActionIncr {
 				quota = -quota
 			}
@@ -251,7 +269,7 @@ func TestCreateContainerTxn(t *testing.T) {
 		}, nil)
 	store.On("SaveProcessing", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	engine.On("ImageLocalDigests", mock.Anything, mock.Anything).Return(nil, errors.Wrap(context.DeadlineExceeded, "ImageLocalDigest")).Twice()
-	engine.On("ImagePull", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.Wrap(context.DeadlineExceeded, "ImagePull")).Twice()
+	engine.On("ImagePull", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.Wrap(context.DeadlineExceeded, "ImagePull")).Twice()
 	store.On("UpdateProcessing", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	store.On("DeleteProcessing", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	ch, err := c.CreateContainer(ctx, opts)
@@ -308,3 +326,24 @@ func TestCreateContainerTxn(t *testing.T) {
 	assert.EqualValues(t, 0, node1.CPUUsed)
 	assert.EqualValues(t, 0, node2.CPUUsed)
 }
+
+func TestDoGetAndPrepareNode(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := c.store.(*storemocks.Store)
+
+	// failed by GetNode
+	store.On("GetNode", mock.Anything, "n0").Return(nil, types.ErrNoETCD)
+	_, reason, err := c.doGetAndPrepareNode(ctx, "n0", "image")
+	assert.Error(t, err)
+	assert.Equal(t, types.DeployFailureNode, reason)
+
+	// failed by pull image
+	engine := &enginemocks.API{}
+	engine.On("ImageLocalDigests", mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD)
+	engine.On("ImagePull", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD)
+	store.On("GetNode", mock.Anything, "n1").Return(&types.Node{Name: "n1", Engine: engine}, nil)
+	_, reason, err = c.doGetAndPrepareNode(ctx, "n1", "image")
+	assert.Error(t, err)
+	assert.Equal(t, types.DeployFailureImage, reason)
+}
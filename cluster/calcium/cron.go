@@ -0,0 +1,161 @@
+package calcium
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const cronLeader = "cron"
+
+// SetCron saves a cron, the old one of the same name is replaced and its schedule goes on
+func (c *Calcium) SetCron(ctx context.Context, cron *types.Cron) error {
+	if err := cron.Validate(); err != nil {
+		return err
+	}
+	if _, err := c.store.GetPod(ctx, cron.DeployOptions.Podname); err != nil {
+		return err
+	}
+	cron.Created = time.Now()
+	cron.LastRun = time.Time{}
+	if old, err := c.store.GetCron(ctx, cron.Name); err == nil {
+		cron.Created = old.Created
+		cron.LastRun = old.LastRun
+	}
+	return c.store.SetCron(ctx, cron)
+}
+
+// ListCrons lists all crons
+func (c *Calcium) ListCrons(ctx context.Context) ([]*types.Cron, error) {
+	return c.store.ListCrons(ctx)
+}
+
+// RemoveCron removes a cron, its running execution goes on
+func (c *Calcium) RemoveCron(ctx context.Context, name string) error {
+	return c.store.RemoveCron(ctx, name)
+}
+
+// WatchCronRuns watches executions of a cron fired by any core, or all crons if name is empty
+func (c *Calcium) WatchCronRuns(ctx context.Context, name string) (<-chan *types.CronRun, error) {
+	return c.store.WatchCronRuns(ctx, name)
+}
+
+// watchCrons campaigns for leader of crons, and fires them while it leads
+func (c *Calcium) watchCrons(ctx context.Context) {
+	if c.config.Cron.Interval <= 0 {
+		return
+	}
+	for {
+		lost, err := c.store.Campaign(ctx, cronLeader, c.config.Cron.LeaderTTL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Errorf("[watchCrons] Campaign failed %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.config.Cron.Interval):
+			}
+			continue
+		}
+		log.Info("[watchCrons] Elected as leader of crons")
+		c.doWatchCrons(ctx, lost)
+	}
+}
+
+func (c *Calcium) doWatchCrons(ctx context.Context, lost <-chan struct{}) {
+	ticker := time.NewTicker(c.config.Cron.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lost:
+			return
+		case <-ticker.C:
+			cctx, cancel := context.WithTimeout(ctx, c.config.GlobalTimeout)
+			c.doTriggerCrons(cctx, time.Now())
+			cancel()
+		}
+	}
+}
+
+// doTriggerCrons fires crons due at now, runs missed when core is down are fired once
+func (c *Calcium) doTriggerCrons(ctx context.Context, now time.Time) {
+	crons, err := c.store.ListCrons(ctx)
+	if err != nil {
+		log.Errorf("[doTriggerCrons] List crons failed %v", err)
+		return
+	}
+	for _, cron := range crons {
+		next, err := cron.Next()
+		if err != nil {
+			log.Errorf("[doTriggerCrons] Bad cron %s %v", cron.Name, err)
+			continue
+		}
+		if next.IsZero() || next.After(now) {
+			continue
+		}
+		// saved before firing, so it's fired at most once even if leader changes
+		cron.LastRun = now
+		if err := c.store.SetCron(ctx, cron); err != nil {
+			log.Errorf("[doTriggerCrons] Save cron %s failed %v", cron.Name, err)
+			continue
+		}
+		go c.doRunCron(context.Background(), cron, next, now)
+	}
+}
+
+// doRunCron deploys by a cron, execution is saved once started and again once finished
+func (c *Calcium) doRunCron(ctx context.Context, cron *types.Cron, scheduled, started time.Time) *types.CronRun {
+	run := &types.CronRun{
+		ID:        strconv.FormatInt(started.UnixNano(), 10),
+		Name:      cron.Name,
+		Scheduled: scheduled,
+		Started:   started,
+	}
+	c.saveCronRun(run)
+	log.Infof("[doRunCron] Cron %s fired, scheduled at %v", cron.Name, scheduled)
+
+	opts := cron.DeployOptions
+	count := opts.Count
+	var err error
+	switch cron.Kind {
+	case types.CronKindJob:
+		var ch <-chan *types.JobMessage
+		if ch, err = c.RunJob(ctx, &types.JobOptions{DeployOptions: opts, Retries: cron.Retries}); err == nil {
+			for m := range ch {
+				if m.Finished && m.ExitCode == 0 {
+					run.Succeeded++
+				}
+			}
+		}
+	default:
+		var ch chan *types.CreateContainerMessage
+		if ch, err = c.CreateContainer(ctx, &opts); err == nil {
+			for m := range ch {
+				if m.Error == nil && m.ContainerID != "" {
+					run.Succeeded++
+				}
+			}
+		}
+	}
+	run.Failed = count - run.Succeeded
+	if err != nil {
+		run.Error = err.Error()
+	}
+	run.Finished = time.Now()
+	c.saveCronRun(run)
+	log.Infof("[doRunCron] Cron %s finished, %d succeeded, %d failed", cron.Name, run.Succeeded, run.Failed)
+	return run
+}
+
+func (c *Calcium) saveCronRun(run *types.CronRun) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GlobalTimeout)
+	defer cancel()
+	if err := c.store.SetCronRun(ctx, run, c.config.Cron.RunTTL); err != nil {
+		log.Errorf("[saveCronRun] Save execution of cron %s failed %v", run.Name, err)
+	}
+}
@@ -0,0 +1,89 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSetCron(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+
+	cron := &types.Cron{Name: "backup", Spec: "@daily", Kind: types.CronKindJob, DeployOptions: types.DeployOptions{Podname: "p1", Count: 1}}
+	assert.Error(t, c.SetCron(ctx, &types.Cron{Name: "backup", Spec: "@often"}))
+	store.On("GetPod", mock.Anything, "p1").Return(&types.Pod{Name: "p1"}, nil)
+
+	// new one
+	store.On("GetCron", mock.Anything, "backup").Return(nil, types.ErrBadCount).Once()
+	store.On("SetCron", mock.Anything, cron).Return(nil)
+	assert.NoError(t, c.SetCron(ctx, cron))
+	assert.False(t, cron.Created.IsZero())
+
+	// replaced one goes on
+	created := time.Now().Add(-time.Hour)
+	lastRun := time.Now().Add(-time.Minute)
+	store.On("GetCron", mock.Anything, "backup").Return(&types.Cron{Created: created, LastRun: lastRun}, nil).Once()
+	assert.NoError(t, c.SetCron(ctx, cron))
+	assert.Equal(t, created, cron.Created)
+	assert.Equal(t, lastRun, cron.LastRun)
+}
+
+func TestDoTriggerCrons(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	c.config.Cron.RunTTL = time.Hour
+
+	now := time.Date(2021, 1, 1, 10, 30, 0, 0, time.UTC)
+	due := &types.Cron{Name: "due", Spec: "*/10 * * * *", Kind: types.CronKindDeploy, Created: now.Add(-time.Hour), LastRun: now.Add(-10 * time.Minute), DeployOptions: types.DeployOptions{Count: 2, CPUQuota: -1}}
+	notDue := &types.Cron{Name: "later", Spec: "0 * * * *", Kind: types.CronKindDeploy, Created: now.Add(-time.Hour), LastRun: now.Add(-30 * time.Minute)}
+	store.On("ListCrons", mock.Anything).Return([]*types.Cron{due, notDue}, nil)
+	store.On("SetCron", mock.Anything, mock.Anything).Return(nil)
+	done := make(chan struct{})
+	store.On("SetCronRun", mock.Anything, mock.Anything, time.Hour).Return(nil).Once()
+	store.On("SetCronRun", mock.Anything, mock.Anything, time.Hour).Return(nil).Once().Run(func(args mock.Arguments) {
+		close(done)
+	})
+
+	c.doTriggerCrons(ctx, now)
+	<-done
+	store.AssertNumberOfCalls(t, "SetCron", 1)
+	assert.Equal(t, now, due.LastRun)
+	assert.Equal(t, now.Add(-30*time.Minute), notDue.LastRun)
+}
+
+func TestDoRunCron(t *testing.T) {
+	c := NewTestCluster()
+	store := &storemocks.Store{}
+	c.store = store
+	store.On("SetCronRun", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	now := time.Now()
+	// bad options fail the whole run
+	cron := &types.Cron{Name: "backup", Kind: types.CronKindDeploy, DeployOptions: types.DeployOptions{Count: 2, CPUQuota: -1}}
+	run := c.doRunCron(context.Background(), cron, now, now)
+	assert.Equal(t, 2, run.Failed)
+	assert.NotEmpty(t, run.Error)
+	assert.False(t, run.Finished.IsZero())
+	store.AssertNumberOfCalls(t, "SetCronRun", 2)
+
+	cron.Kind = types.CronKindJob
+	run = c.doRunCron(context.Background(), cron, now, now)
+	assert.Equal(t, 0, run.Succeeded)
+	assert.Equal(t, 2, run.Failed)
+
+	// leadership lost
+	lost := make(chan struct{})
+	close(lost)
+	c.config.Cron.Interval = time.Hour
+	c.doWatchCrons(context.Background(), lost)
+}
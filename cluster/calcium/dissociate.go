@@ -26,7 +26,9 @@ func (c *Calcium) DissociateContainer(ctx context.Context, IDs []string) (chan *
 						// then
 						func(ctx context.Context) error {
 							log.Infof("[DissociateContainer] Container %s dissociated", container.ID)
-							return c.store.UpdateNodeResource(ctx, node, container.CPU, container.Quota, container.Memory, container.Storage, container.VolumePlan.IntoVolumeMap(), store.ActionIncr)
+							c.refundContainerQuotas(ctx, container, containerQuotaUsage(container))
+							c.publishContainerEvent(types.EventContainerRemoved, container)
+							return c.store.UpdateNodeResource(ctx, node, container.CPU, container.Quota, container.Memory, container.Storage, container.VolumePlan.IntoVolumeMap(), container.Hugepages, container.GPU, store.ActionIncr)
 						},
 						// rollback
 						nil,
@@ -0,0 +1,81 @@
+package calcium
+
+import (
+	"context"
+	"sync"
+
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// DrainNode cordons the node then moves all its containers to other nodes of the pod,
+// or removes them if opts.Remove is set, at most MaxUnavailable at the same time.
+// node is kept cordoned after draining, containers failed to move are kept where they are
+func (c *Calcium) DrainNode(ctx context.Context, opts *types.DrainNodeOptions) (chan *types.MigrateMessage, error) {
+	node, err := c.CordonNode(ctx, opts.Nodename, true)
+	if err != nil {
+		return nil, err
+	}
+	containers, err := c.store.ListNodeContainers(ctx, node.Name, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *types.MigrateMessage)
+	go func() {
+		defer close(ch)
+		step := opts.MaxUnavailable
+		if step <= 0 {
+			step = 1
+		}
+		wg := sync.WaitGroup{}
+		defer wg.Wait()
+		for index, container := range containers {
+			wg.Add(1)
+			go func(container *types.Container) {
+				defer wg.Done()
+				ch <- c.doDrainContainer(ctx, opts, node, container)
+			}(container)
+			if (index+1)%step == 0 {
+				wg.Wait()
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (c *Calcium) doDrainContainer(ctx context.Context, opts *types.DrainNodeOptions, node *types.Node, container *types.Container) *types.MigrateMessage {
+	msg := &types.MigrateMessage{ContainerID: container.ID}
+	if opts.Remove {
+		log.Infof("[DrainNode] Remove container %s from node %s", container.ID, node.Name)
+		removeCh, err := c.RemoveContainer(ctx, []string{container.ID}, true, 1, 0)
+		if err != nil {
+			msg.Error = err
+			return msg
+		}
+		for m := range removeCh {
+			msg.Remove = m
+			msg.Error = m.Error
+		}
+		return msg
+	}
+
+	if volumes := nodeLocalVolumes(container); len(volumes) > 0 && !opts.AllowLocalVolumes {
+		msg.Error = types.NewDetailedErr(types.ErrNodeLocalVolume, volumes)
+		return msg
+	}
+	info, err := container.Inspect(ctx)
+	if err != nil {
+		msg.Error = err
+		return msg
+	}
+	deployOpts, err := makeCloneDeployOptions(container, info.Cmd, info.Dir, info.Labels, info.Networks)
+	if err != nil {
+		msg.Error = err
+		return msg
+	}
+	log.Infof("[DrainNode] Move container %s off node %s", container.ID, node.Name)
+	// node is cordoned, so it won't be scheduled again
+	msg.Create, msg.Remove, msg.Error = c.doMoveContainer(ctx, *deployOpts, container, node.Podname, "")
+	return msg
+}
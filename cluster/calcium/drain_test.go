@@ -0,0 +1,85 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+
+	lockmocks "github.com/projecteru2/core/lock/mocks"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCordonNode(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	st := c.store.(*storemocks.Store)
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	st.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+	node := &types.Node{Name: "n1", Podname: "p1"}
+	st.On("GetNode", mock.Anything, "n1").Return(node, nil)
+
+	st.On("UpdateNode", mock.Anything, node).Return(nil).Once()
+	n, err := c.CordonNode(ctx, "n1", true)
+	assert.NoError(t, err)
+	assert.True(t, n.Unschedulable)
+	// already cordoned
+	n, err = c.CordonNode(ctx, "n1", true)
+	assert.NoError(t, err)
+	assert.True(t, n.Unschedulable)
+	// uncordon
+	st.On("UpdateNode", mock.Anything, node).Return(types.ErrNoETCD).Once()
+	_, err = c.CordonNode(ctx, "n1", false)
+	assert.Error(t, err)
+	st.AssertExpectations(t)
+}
+
+func TestDrainNode(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	st := c.store.(*storemocks.Store)
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	st.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+	node := &types.Node{Name: "n1", Podname: "p1"}
+	st.On("GetNode", mock.Anything, "n1").Return(node, nil)
+	st.On("UpdateNode", mock.Anything, node).Return(nil).Once()
+
+	// failed by listing containers
+	st.On("ListNodeContainers", mock.Anything, "n1", mock.Anything).Return(nil, types.ErrNoETCD).Once()
+	_, err := c.DrainNode(ctx, &types.DrainNodeOptions{Nodename: "n1"})
+	assert.Error(t, err)
+	assert.True(t, node.Unschedulable)
+
+	localVolume, err := types.NewVolumeBinding("AUTO:/data:rw:100")
+	assert.NoError(t, err)
+	st.On("ListNodeContainers", mock.Anything, "n1", mock.Anything).Return([]*types.Container{
+		{ID: "c1", Name: "app_web_aaaaaa", Nodename: "n1", Volumes: types.VolumeBindings{localVolume}},
+		{ID: "c2", Name: "app_web_bbbbbb", Nodename: "n1"},
+	}, nil)
+
+	// c1 refused by local volume, c2 failed by inspecting without engine
+	ch, err := c.DrainNode(ctx, &types.DrainNodeOptions{Nodename: "n1", MaxUnavailable: 2})
+	assert.NoError(t, err)
+	errs := map[string]error{}
+	for m := range ch {
+		errs[m.ContainerID] = m.Error
+		assert.Nil(t, m.Create)
+	}
+	assert.Len(t, errs, 2)
+	assert.Contains(t, errs["c1"].Error(), types.ErrNodeLocalVolume.Error())
+	assert.Equal(t, types.ErrNilEngine, errs["c2"])
+
+	// remove failed by getting container
+	st.On("GetContainers", mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD)
+	ch, err = c.DrainNode(ctx, &types.DrainNodeOptions{Nodename: "n1", Remove: true})
+	assert.NoError(t, err)
+	for m := range ch {
+		assert.Error(t, m.Error)
+		assert.False(t, m.Remove.Success)
+	}
+}
@@ -0,0 +1,56 @@
+package calcium
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// RecordContainerEvents counts oom kills and unexpected exits reported by agents against containers,
+// and sends alerts for them if enabled
+func (c *Calcium) RecordContainerEvents(ctx context.Context, events []*types.ContainerEvent) error {
+	for _, event := range events {
+		if err := event.Validate(); err != nil {
+			return err
+		}
+		if event.Time.IsZero() {
+			event.Time = time.Now()
+		}
+		var exits types.ContainerExits
+		if err := c.withContainerLocked(ctx, event.ID, func(container *types.Container) error {
+			if container.Exits == nil {
+				container.Exits = &types.ContainerExits{}
+			}
+			container.Exits.Record(event)
+			exits = *container.Exits
+			return c.store.UpdateContainer(ctx, container)
+		}); err != nil {
+			log.Errorf("[RecordContainerEvents] Record %s of container %s failed %v", event.Kind, event.ID, err)
+			return err
+		}
+		log.Warnf("[RecordContainerEvents] Container %s %s at %v, exit code %d", event.ID, event.Kind, event.Time, event.ExitCode)
+		if c.alertEnabled() && c.config.Alert.ContainerEvents {
+			go c.sendContainerEventAlert(event, exits)
+		}
+	}
+	return nil
+}
+
+func (c *Calcium) sendContainerEventAlert(event *types.ContainerEvent, exits types.ContainerExits) {
+	alert := &types.Alert{Target: event.ID, Time: event.Time}
+	if event.Kind == types.ContainerEventOOM {
+		alert.Kind = types.AlertContainerOOM
+		alert.Value = float64(exits.OOMKills)
+		alert.Message = fmt.Sprintf("container %s is killed by oom killer, %d times in total", event.ID, exits.OOMKills)
+	} else {
+		alert.Kind = types.AlertContainerExit
+		alert.Value = float64(exits.Exits)
+		alert.Message = fmt.Sprintf("container %s exits with code %d, %d times in total", event.ID, event.ExitCode, exits.Exits)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GlobalTimeout)
+	defer cancel()
+	c.sendAlert(ctx, alert)
+}
@@ -0,0 +1,62 @@
+package calcium
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	lockmocks "github.com/projecteru2/core/lock/mocks"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRecordContainerEvents(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := c.store.(*storemocks.Store)
+
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+	container := &types.Container{ID: "c1"}
+	store.On("GetContainers", mock.Anything, []string{"c1"}).Return([]*types.Container{container}, nil)
+	store.On("UpdateContainer", mock.Anything, mock.Anything).Return(nil)
+
+	alerts := make(chan *types.Alert, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alert := &types.Alert{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(alert))
+		alerts <- alert
+	}))
+	defer server.Close()
+	c.config.Alert = types.AlertConfig{Webhooks: []string{server.URL}, ContainerEvents: true}
+
+	err := c.RecordContainerEvents(ctx, []*types.ContainerEvent{{ID: "c1", Kind: "restart"}})
+	assert.True(t, errors.Is(err, types.ErrBadContainerEvent))
+
+	oomTime := time.Unix(1000, 0)
+	assert.NoError(t, c.RecordContainerEvents(ctx, []*types.ContainerEvent{
+		{ID: "c1", Kind: types.ContainerEventOOM, ExitCode: 137, Time: oomTime},
+		{ID: "c1", Kind: types.ContainerEventExit, ExitCode: 1},
+	}))
+	assert.Equal(t, 1, container.Exits.OOMKills)
+	assert.Equal(t, oomTime, container.Exits.LastOOMKill)
+	assert.Equal(t, 1, container.Exits.Exits)
+	assert.Equal(t, 1, container.Exits.LastExitCode)
+	assert.False(t, container.Exits.LastExit.IsZero())
+
+	kinds := map[string]float64{}
+	for i := 0; i < 2; i++ {
+		alert := <-alerts
+		assert.Equal(t, "c1", alert.Target)
+		kinds[alert.Kind] = alert.Value
+	}
+	assert.Equal(t, map[string]float64{types.AlertContainerOOM: 1, types.AlertContainerExit: 1}, kinds)
+}
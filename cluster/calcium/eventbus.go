@@ -0,0 +1,70 @@
+package calcium
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// WatchEvents streams cluster events published by all cores from now on, selected by filter
+func (c *Calcium) WatchEvents(ctx context.Context, filter *types.EventFilter) (<-chan *types.Event, error) {
+	events, err := c.store.WatchEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan *types.Event)
+	go func() {
+		defer close(ch)
+		for event := range events {
+			if !filter.Match(event) {
+				continue
+			}
+			select {
+			case ch <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// publishEvent saves event to store and notifies webhooks in background, so operations are not slowed down
+func (c *Calcium) publishEvent(event *types.Event) {
+	event.Time = time.Now()
+	event.ID = fmt.Sprintf("%d-%s", event.Time.UnixNano(), utils.RandomString(8))
+	event.Source = c.config.Bind
+	if c.config.Notify.Timeout > 0 {
+		go c.notifyWebhooks(event)
+	}
+	if c.config.Events.TTL <= 0 {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), c.config.GlobalTimeout)
+		defer cancel()
+		if err := c.store.PublishEvent(ctx, event, c.config.Events.TTL); err != nil {
+			log.Errorf("[publishEvent] Publish %s event failed %v", event.Kind, err)
+		}
+	}()
+}
+
+func (c *Calcium) publishContainerEvent(kind string, container *types.Container) {
+	appname, _, _, _ := utils.ParseContainerName(container.Name)
+	c.publishEvent(&types.Event{
+		Kind:        kind,
+		Podname:     container.Podname,
+		Nodename:    container.Nodename,
+		Appname:     appname,
+		ContainerID: container.ID,
+		Image:       container.Image,
+	})
+}
+
+func (c *Calcium) publishImagePulled(node *types.Node, image string) {
+	c.publishEvent(&types.Event{Kind: types.EventImagePulled, Podname: node.Podname, Nodename: node.Name, Image: image})
+}
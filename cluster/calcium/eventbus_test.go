@@ -0,0 +1,93 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWatchEvents(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+
+	events := make(chan *types.Event, 3)
+	events <- &types.Event{ID: "1", Kind: types.EventNodeAdded, Nodename: "n1"}
+	events <- &types.Event{ID: "2", Kind: types.EventContainerCreated, Nodename: "n1"}
+	events <- &types.Event{ID: "3", Kind: types.EventNodeDown, Nodename: "n1"}
+	close(events)
+	store.On("WatchEvents", mock.Anything).Return((<-chan *types.Event)(events), nil)
+
+	ch, err := c.WatchEvents(ctx, &types.EventFilter{Kinds: []string{types.EventNodeAdded, types.EventNodeDown}})
+	assert.NoError(t, err)
+	IDs := []string{}
+	for e := range ch {
+		IDs = append(IDs, e.ID)
+	}
+	assert.Equal(t, []string{"1", "3"}, IDs)
+}
+
+func TestPublishEvent(t *testing.T) {
+	c := NewTestCluster()
+	store := &storemocks.Store{}
+	c.store = store
+	published := make(chan *types.Event, 1)
+	store.On("PublishEvent", mock.Anything, mock.Anything, time.Hour).Return(nil).Run(func(args mock.Arguments) {
+		published <- args.Get(1).(*types.Event)
+	})
+
+	// disabled
+	c.publishContainerEvent(types.EventContainerRemoved, &types.Container{ID: "c1"})
+	store.AssertNotCalled(t, "PublishEvent", mock.Anything, mock.Anything, mock.Anything)
+
+	c.config.Events.TTL = time.Hour
+	c.config.Bind = "core1:5001"
+	c.publishContainerEvent(types.EventContainerRemoved, &types.Container{ID: "c1", Name: "app_web_aaaaaa", Podname: "p1", Nodename: "n1"})
+	event := <-published
+	assert.Equal(t, types.EventContainerRemoved, event.Kind)
+	assert.Equal(t, "app", event.Appname)
+	assert.Equal(t, "core1:5001", event.Source)
+	assert.NotEmpty(t, event.ID)
+	assert.False(t, event.Time.IsZero())
+}
+
+func TestSetContainersStatusUnhealthyEvent(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	c.config.Events.TTL = time.Hour
+	published := make(chan *types.Event, 2)
+	store.On("PublishEvent", mock.Anything, mock.Anything, time.Hour).Return(nil).Run(func(args mock.Arguments) {
+		published <- args.Get(1).(*types.Event)
+	})
+	store.On("SetContainerStatus", mock.Anything, mock.Anything, int64(0)).Return(nil)
+
+	// turning unhealthy
+	container := &types.Container{ID: "c1", Name: "app_web_aaaaaa", StatusMeta: &types.StatusMeta{Running: true, Healthy: true}}
+	store.On("GetContainer", mock.Anything, "c1").Return(container, nil).Once()
+	_, err := c.SetContainersStatus(ctx, []*types.StatusMeta{{ID: "c1", Running: true}}, nil)
+	assert.NoError(t, err)
+	event := <-published
+	assert.Equal(t, types.EventContainerUnhealthy, event.Kind)
+
+	// still unhealthy
+	store.On("GetContainer", mock.Anything, "c1").Return(&types.Container{ID: "c1", StatusMeta: &types.StatusMeta{Running: true}}, nil).Once()
+	_, err = c.SetContainersStatus(ctx, []*types.StatusMeta{{ID: "c1", Running: true}}, nil)
+	assert.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(t, published, 0)
+
+	// healthy again
+	store.On("GetContainer", mock.Anything, "c1").Return(&types.Container{ID: "c1", Name: "app_web_aaaaaa", StatusMeta: &types.StatusMeta{Running: true}}, nil).Once()
+	_, err = c.SetContainersStatus(ctx, []*types.StatusMeta{{ID: "c1", Running: true, Healthy: true}}, nil)
+	assert.NoError(t, err)
+	event = <-published
+	assert.Equal(t, types.EventContainerHealthy, event.Kind)
+}
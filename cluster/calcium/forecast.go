@@ -0,0 +1,153 @@
+package calcium
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	capacitySampleInterval = 10 * time.Minute
+	capacityHistoryKeep    = 30 * 24 * time.Hour
+	defaultForecastWindow  = 7 * 24 * time.Hour
+)
+
+// ForecastCapacity predicts when resources of a pod exhaust by growth in window
+func (c *Calcium) ForecastCapacity(ctx context.Context, podname string, window time.Duration) (*types.CapacityForecast, error) {
+	if window <= 0 {
+		window = defaultForecastWindow
+	}
+	if _, err := c.store.GetPod(ctx, podname); err != nil {
+		return nil, err
+	}
+	samples, err := c.store.ListCapacitySamples(ctx, podname, time.Now().Add(-window))
+	if err != nil {
+		return nil, err
+	}
+
+	forecast := &types.CapacityForecast{Podname: podname, Samples: len(samples), Resources: []*types.ResourceForecast{}}
+	if len(samples) == 0 {
+		return forecast, nil
+	}
+	for _, sample := range samples {
+		forecast.DeployFailures += sample.DeployFailures
+	}
+	// resources are limited by quota of pod if any
+	limit := types.QuotaUsage{}
+	quota, err := c.store.GetQuota(ctx, types.QuotaScopePod, podname)
+	switch {
+	case err == nil:
+		limit = quota.Limit
+	case !errors.Is(err, types.ErrBadCount):
+		return nil, err
+	}
+	latest := samples[len(samples)-1]
+	for _, r := range []struct {
+		name  string
+		total float64
+		limit float64
+		used  func(*types.CapacitySample) float64
+	}{
+		{resourceCPU, latest.CPU, limit.CPU, func(s *types.CapacitySample) float64 { return s.CPUUsed }},
+		{resourceMemory, float64(latest.Memory), float64(limit.Memory), func(s *types.CapacitySample) float64 { return float64(s.MemoryUsed) }},
+		{resourceStorage, float64(latest.Storage), float64(limit.Storage), func(s *types.CapacitySample) float64 { return float64(s.StorageUsed) }},
+		{resourceVolume, float64(latest.Volume), float64(limit.Volume), func(s *types.CapacitySample) float64 { return float64(s.VolumeUsed) }},
+	} {
+		total := r.total
+		if r.limit > 0 && r.limit < total {
+			total = r.limit
+		}
+		rf := &types.ResourceForecast{
+			Resource:     r.name,
+			Total:        total,
+			Used:         r.used(latest),
+			GrowthPerDay: growthPerDay(samples, r.used),
+		}
+		if rf.GrowthPerDay > 0 {
+			days := math.Max(total-rf.Used, 0) / rf.GrowthPerDay
+			rf.ExhaustIn = time.Duration(days * float64(24*time.Hour))
+		}
+		forecast.Resources = append(forecast.Resources, rf)
+	}
+	return forecast, nil
+}
+
+// growthPerDay fits usage of samples by least squares
+func growthPerDay(samples []*types.CapacitySample, used func(*types.CapacitySample) float64) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	start := samples[0].Time
+	n := float64(len(samples))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range samples {
+		x := sample.Time.Sub(start).Hours() / 24
+		y := used(sample)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	d := n*sumXX - sumX*sumX
+	if d == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / d
+}
+
+func (c *Calcium) recordDeployFailure(podname string, err error) {
+	for _, e := range []error{types.ErrInsufficientRes, types.ErrInsufficientCap, types.ErrQuotaExceeded} {
+		if errors.Is(err, e) {
+			c.doRecordDeployFailure(podname)
+			return
+		}
+	}
+	if isInsufficientErr(err) {
+		c.doRecordDeployFailure(podname)
+	}
+}
+
+func (c *Calcium) doRecordDeployFailure(podname string) {
+	v, _ := c.deployFailures.LoadOrStore(podname, new(int64))
+	atomic.AddInt64(v.(*int64), 1)
+}
+
+func (c *Calcium) doSampleCapacity(ctx context.Context) {
+	reports, err := c.CapacityReport(ctx, "")
+	if err != nil {
+		log.Errorf("[doSampleCapacity] Get capacity report failed %v", err)
+		return
+	}
+	now := time.Now()
+	for _, report := range reports {
+		sample := &types.CapacitySample{Time: now, ResourceCapacity: report.ResourceCapacity}
+		if v, ok := c.deployFailures.Load(report.Name); ok {
+			sample.DeployFailures = int(atomic.SwapInt64(v.(*int64), 0))
+		}
+		if err := c.store.AddCapacitySample(ctx, report.Name, sample); err != nil {
+			log.Errorf("[doSampleCapacity] Save capacity sample of %s failed %v", report.Name, err)
+			continue
+		}
+		if err := c.store.RemoveCapacitySamples(ctx, report.Name, now.Add(-capacityHistoryKeep)); err != nil {
+			log.Errorf("[doSampleCapacity] Remove old capacity samples of %s failed %v", report.Name, err)
+		}
+	}
+}
+
+func (c *Calcium) watchCapacity(ctx context.Context) {
+	ticker := time.NewTicker(capacitySampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.doSampleCapacity(ctx)
+		}
+	}
+}
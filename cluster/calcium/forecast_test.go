@@ -0,0 +1,85 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestForecastCapacity(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := c.store.(*storemocks.Store)
+
+	store.On("GetPod", mock.Anything, "p1").Return(&types.Pod{Name: "p1"}, nil)
+	store.On("GetQuota", mock.Anything, types.QuotaScopePod, "p1").Return(&types.Quota{Limit: types.QuotaUsage{Memory: 50}}, nil)
+	store.On("ListCapacitySamples", mock.Anything, "p1", mock.Anything).Return([]*types.CapacitySample{}, nil).Once()
+	f, err := c.ForecastCapacity(ctx, "p1", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, f.Samples, 0)
+	assert.Len(t, f.Resources, 0)
+
+	// cpu grows 1 core per day, memory grows 10 per day, storage doesn't grow
+	now := time.Now()
+	samples := []*types.CapacitySample{}
+	for i := 0; i < 4; i++ {
+		samples = append(samples, &types.CapacitySample{
+			Time: now.Add(time.Duration(i-3) * 24 * time.Hour),
+			ResourceCapacity: types.ResourceCapacity{
+				CPU: 10, CPUUsed: float64(i + 2),
+				Memory: 100, MemoryUsed: int64(i * 10),
+				Storage: 100, StorageUsed: 10,
+			},
+			DeployFailures: 1,
+		})
+	}
+	store.On("ListCapacitySamples", mock.Anything, "p1", mock.Anything).Return(samples, nil)
+	f, err = c.ForecastCapacity(ctx, "p1", time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, f.Samples, 4)
+	assert.Equal(t, f.DeployFailures, 4)
+	assert.Len(t, f.Resources, 4)
+	cpu, memory, storage := f.Resources[0], f.Resources[1], f.Resources[2]
+	assert.Equal(t, cpu.Resource, resourceCPU)
+	assert.InDelta(t, cpu.GrowthPerDay, 1, 0.001)
+	assert.InDelta(t, cpu.ExhaustIn.Hours(), 5*24, 0.1)
+	// limited by quota
+	assert.Equal(t, memory.Total, float64(50))
+	assert.InDelta(t, memory.ExhaustIn.Hours(), 2*24, 0.1)
+	assert.Equal(t, storage.GrowthPerDay, float64(0))
+	assert.Equal(t, storage.ExhaustIn, time.Duration(0))
+}
+
+func TestSampleCapacity(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := c.store.(*storemocks.Store)
+
+	c.recordDeployFailure("p1", types.ErrInsufficientMEM)
+	c.recordDeployFailure("p1", types.NewDetailedErr(types.ErrQuotaExceeded, "cpu"))
+	c.recordDeployFailure("p1", types.ErrBadCount)
+
+	store.On("GetAllPods", mock.Anything).Return([]*types.Pod{{Name: "p1"}}, nil)
+	store.On("ListProcessing", mock.Anything).Return(map[string]int{}, nil)
+	store.On("GetNodesByPod", mock.Anything, "p1", mock.Anything, true).Return([]*types.Node{
+		{Name: "n1", InitCPU: types.CPUMap{"0": 100}, CPUUsed: 0.5, InitMemCap: 100, MemCap: 60},
+	}, nil)
+	store.On("RemoveCapacitySamples", mock.Anything, "p1", mock.Anything).Return(nil)
+	var sample *types.CapacitySample
+	store.On("AddCapacitySample", mock.Anything, "p1", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		sample = args.Get(2).(*types.CapacitySample)
+	})
+	c.doSampleCapacity(ctx)
+	assert.Equal(t, sample.DeployFailures, 2)
+	assert.Equal(t, sample.MemoryUsed, int64(40))
+	assert.Equal(t, sample.CPUUsed, 0.5)
+
+	// failures are reset after sampled
+	c.doSampleCapacity(ctx)
+	assert.Equal(t, sample.DeployFailures, 0)
+}
@@ -0,0 +1,69 @@
+package calcium
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/projecteru2/core/types"
+)
+
+// FragmentationReport finds nodes whose free cpu or volume is fragmented
+// fragmented resources can't be scheduled even if they are enough in total
+func (c *Calcium) FragmentationReport(ctx context.Context, podname string) ([]*types.NodeFragmentation, error) {
+	nodes, err := c.ListPodNodes(ctx, podname, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	r := []*types.NodeFragmentation{}
+	for _, node := range nodes {
+		if f := c.nodeFragmentation(node); len(f.Suggestions) > 0 {
+			r = append(r, f)
+		}
+	}
+	return r, nil
+}
+
+func (c *Calcium) nodeFragmentation(node *types.Node) *types.NodeFragmentation {
+	shareBase := int64(c.config.Scheduler.ShareBase)
+	if shareBase <= 0 {
+		shareBase = 100
+	}
+	f := &types.NodeFragmentation{Name: node.Name, Suggestions: []string{}}
+	var pieces int64
+	for _, free := range node.CPU {
+		switch {
+		case free >= shareBase:
+			f.FreeCores++
+		case free > 0:
+			f.FragmentedCores++
+			pieces += free
+		}
+	}
+	f.FragmentedCPU = float64(pieces) / float64(shareBase)
+	// scattered pieces can hold at least one whole core
+	if f.FragmentedCores > 1 && pieces >= shareBase {
+		f.Suggestions = append(f.Suggestions, fmt.Sprintf(
+			"realloc cpu bind containers on %s to consolidate %.2f cores scattered in %d cores",
+			node.Name, f.FragmentedCPU, f.FragmentedCores,
+		))
+	}
+
+	for _, free := range node.Volume {
+		if free <= 0 {
+			continue
+		}
+		f.VolumeDevices++
+		f.VolumeFree += free
+		if free > f.MaxVolumeDeviceFree {
+			f.MaxVolumeDeviceFree = free
+		}
+	}
+	// largest device holds less than half of free volume
+	if f.VolumeDevices > 1 && f.MaxVolumeDeviceFree*2 < f.VolumeFree {
+		f.Suggestions = append(f.Suggestions, fmt.Sprintf(
+			"migrate volume containers on %s, %d bytes free volume spread across %d devices, largest device only has %d bytes",
+			node.Name, f.VolumeFree, f.VolumeDevices, f.MaxVolumeDeviceFree,
+		))
+	}
+	return f
+}
@@ -0,0 +1,47 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFragmentationReport(t *testing.T) {
+	c := NewTestCluster()
+	c.config.Scheduler.ShareBase = 100
+	ctx := context.Background()
+	store := c.store.(*storemocks.Store)
+	store.On("GetNodesByPod", mock.Anything, "p1", mock.Anything, true).Return(nil, types.ErrNoETCD).Once()
+	_, err := c.FragmentationReport(ctx, "p1")
+	assert.Error(t, err)
+
+	nodes := []*types.Node{
+		{
+			Name:   "n1",
+			CPU:    types.CPUMap{"0": 60, "1": 50, "2": 100, "3": 0},
+			Volume: types.VolumeMap{"/data0": 100, "/data1": 100, "/data2": 100},
+		},
+		{
+			Name:   "n2",
+			CPU:    types.CPUMap{"0": 100, "1": 30},
+			Volume: types.VolumeMap{"/data0": 1000, "/data1": 10},
+		},
+	}
+	store.On("GetNodesByPod", mock.Anything, "p1", mock.Anything, true).Return(nodes, nil)
+	r, err := c.FragmentationReport(ctx, "p1")
+	assert.NoError(t, err)
+	assert.Len(t, r, 1)
+	f := r[0]
+	assert.Equal(t, f.Name, "n1")
+	assert.Equal(t, f.FreeCores, 1)
+	assert.Equal(t, f.FragmentedCores, 2)
+	assert.Equal(t, f.FragmentedCPU, 1.1)
+	assert.Equal(t, f.VolumeDevices, 3)
+	assert.Equal(t, f.VolumeFree, int64(300))
+	assert.Equal(t, f.MaxVolumeDeviceFree, int64(100))
+	assert.Len(t, f.Suggestions, 2)
+}
@@ -0,0 +1,243 @@
+package calcium
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projecteru2/core/cluster"
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const healthCheckLeader = "healthcheck"
+
+// healthState is consecutive results of health checks of a container run by core
+// only used by the goroutine checking health
+type healthState struct {
+	firstSeen time.Time
+	lastCheck time.Time
+	successes int
+	failures  int
+}
+
+// coreHealthCheck returns health check of container if it's run by core
+func (c *Calcium) coreHealthCheck(container *types.Container) *types.HealthCheck {
+	if c.config.HealthCheck.Interval <= 0 {
+		return nil
+	}
+	meta := utils.DecodeMetaInLabel(container.Labels)
+	if meta == nil || meta.HealthCheck == nil {
+		return nil
+	}
+	if p := meta.HealthCheck.Protocol; p != types.HealthCheckTCP && p != types.HealthCheckHTTP {
+		return nil
+	}
+	return meta.HealthCheck
+}
+
+// watchHealthChecks campaigns for leader of health checks, and runs them while it leads
+func (c *Calcium) watchHealthChecks(ctx context.Context) {
+	if c.config.HealthCheck.Interval <= 0 {
+		return
+	}
+	for {
+		lost, err := c.store.Campaign(ctx, healthCheckLeader, c.config.HealthCheck.LeaderTTL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Errorf("[watchHealthChecks] Campaign failed %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.config.HealthCheck.Interval):
+			}
+			continue
+		}
+		log.Info("[watchHealthChecks] Elected as leader of health checks")
+		c.doWatchHealthChecks(ctx, lost)
+	}
+}
+
+func (c *Calcium) doWatchHealthChecks(ctx context.Context, lost <-chan struct{}) {
+	states := map[string]*healthState{}
+	ticker := time.NewTicker(c.config.HealthCheck.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lost:
+			return
+		case <-ticker.C:
+			cctx, cancel := context.WithTimeout(ctx, c.config.GlobalTimeout)
+			c.doHealthChecks(cctx, states, time.Now())
+			cancel()
+		}
+	}
+}
+
+// doHealthChecks runs checks of running containers due at now,
+// status is updated once a container reaches its threshold of turning healthy or unhealthy
+func (c *Calcium) doHealthChecks(ctx context.Context, states map[string]*healthState, now time.Time) {
+	containers, err := c.store.ListContainers(ctx, "", "", "", 0, nil)
+	if err != nil {
+		log.Errorf("[doHealthChecks] List containers failed %v", err)
+		return
+	}
+	seen := map[string]bool{}
+	due := []*types.Container{}
+	checks := []*types.HealthCheck{}
+	for _, container := range containers {
+		// containers without address, e.g. of host network, are left to agents
+		hc := c.coreHealthCheck(container)
+		if hc == nil || !isRunning(container) || containerIP(container) == "" {
+			continue
+		}
+		seen[container.ID] = true
+		state, ok := states[container.ID]
+		if !ok {
+			state = &healthState{firstSeen: now}
+			states[container.ID] = state
+		}
+		interval := c.config.HealthCheck.DefaultInterval
+		if hc.Interval > 0 {
+			interval = time.Duration(hc.Interval) * time.Second
+		}
+		if now.Before(state.firstSeen.Add(time.Duration(hc.InitialDelay)*time.Second)) || now.Before(state.lastCheck.Add(interval)) {
+			continue
+		}
+		state.lastCheck = now
+		due = append(due, container)
+		checks = append(checks, hc)
+	}
+	// containers gone or stopped start over
+	for ID := range states {
+		if !seen[ID] {
+			delete(states, ID)
+		}
+	}
+
+	results := make([]bool, len(due))
+	sem := make(chan struct{}, utils.Max(c.config.HealthCheck.Concurrency, 1))
+	wg := sync.WaitGroup{}
+	for i := range due {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = c.checkHealth(ctx, due[i], checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, container := range due {
+		c.doUpdateHealth(ctx, container, checks[i], states[container.ID], results[i])
+	}
+}
+
+func (c *Calcium) doUpdateHealth(ctx context.Context, container *types.Container, hc *types.HealthCheck, state *healthState, healthy bool) {
+	if healthy {
+		state.successes++
+		state.failures = 0
+	} else {
+		state.failures++
+		state.successes = 0
+	}
+
+	status := *container.StatusMeta
+	switch {
+	case healthy && !status.Healthy && state.successes >= utils.Max(hc.HealthyThreshold, 1):
+		status.Healthy = true
+	case !healthy && status.Healthy && state.failures >= utils.Max(hc.UnhealthyThreshold, 1):
+		status.Healthy = false
+	}
+	if status.Healthy != container.StatusMeta.Healthy {
+		log.Infof("[doUpdateHealth] Container %s turns healthy %v", container.ID, status.Healthy)
+		if err := c.doSetContainerStatus(ctx, container, &status, int64(c.config.HealthCheck.StatusTTL.Seconds())); err != nil {
+			log.Errorf("[doUpdateHealth] Set status of container %s failed %v", container.ID, err)
+		}
+	}
+
+	threshold := c.config.HealthCheck.RestartThreshold
+	if healthy || threshold <= 0 || state.failures < threshold || (container.Restart != nil && container.Restart.Policy == types.RestartNo) {
+		return
+	}
+	log.Warnf("[doUpdateHealth] Container %s failed %d health checks, restarting", container.ID, state.failures)
+	state.failures = 0
+	ch, err := c.ControlContainer(ctx, []string{container.ID}, cluster.ContainerRestart, false, false, 0)
+	if err != nil {
+		log.Errorf("[doUpdateHealth] Restart container %s failed %v", container.ID, err)
+		return
+	}
+	for m := range ch {
+		if m.Error != nil {
+			log.Errorf("[doUpdateHealth] Restart container %s failed %v", container.ID, m.Error)
+		}
+	}
+}
+
+// checkHealth runs tcp or http health check against address of container
+func (c *Calcium) checkHealth(ctx context.Context, container *types.Container, hc *types.HealthCheck) bool {
+	ip := containerIP(container)
+	timeout := c.config.HealthCheck.DefaultTimeout
+	if hc.Timeout > 0 {
+		timeout = time.Duration(hc.Timeout) * time.Second
+	}
+	switch hc.Protocol {
+	case types.HealthCheckTCP:
+		for _, port := range hc.TCPPorts {
+			conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, healthCheckPort(port)), timeout)
+			if err != nil {
+				log.Debugf("[checkHealth] Check tcp port %s of container %s failed %v", port, container.ID, err)
+				return false
+			}
+			conn.Close()
+		}
+		return true
+	case types.HealthCheckHTTP:
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		url := "http://" + net.JoinHostPort(ip, healthCheckPort(hc.HTTPPort)) + hc.HTTPURL
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return false
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Debugf("[checkHealth] Check %s of container %s failed %v", url, container.ID, err)
+			return false
+		}
+		resp.Body.Close()
+		if hc.HTTPCode > 0 {
+			return resp.StatusCode == hc.HTTPCode
+		}
+		return resp.StatusCode < http.StatusBadRequest
+	}
+	return false
+}
+
+// containerIP picks address of container in the first network by name
+func containerIP(container *types.Container) string {
+	names := []string{}
+	for name, ip := range container.StatusMeta.Networks {
+		if ip != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return container.StatusMeta.Networks[names[0]]
+}
+
+// healthCheckPort strips protocol of port, e.g. 8080/tcp
+func healthCheckPort(port string) string {
+	return strings.SplitN(port, "/", 2)[0]
+}
@@ -0,0 +1,87 @@
+package calcium
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/projecteru2/core/cluster"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func healthCheckContainer(ID, ip string, hc *types.HealthCheck) *types.Container {
+	return &types.Container{
+		ID:         ID,
+		Name:       "app_web_" + ID,
+		Labels:     map[string]string{cluster.LabelMeta: utils.EncodeMetaInLabel(&types.LabelMeta{HealthCheck: hc})},
+		StatusMeta: &types.StatusMeta{ID: ID, Running: true, Networks: map[string]string{"bridge": ip}},
+	}
+}
+
+func TestDoHealthChecks(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	c.config.HealthCheck = types.HealthCheckConfig{Interval: time.Second, Concurrency: 2, DefaultInterval: 10 * time.Second, DefaultTimeout: time.Second, StatusTTL: time.Minute}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	ok := healthCheckContainer("ok", host, &types.HealthCheck{Protocol: types.HealthCheckHTTP, HTTPPort: port, HTTPURL: "/healthz"})
+	bad := healthCheckContainer("bad", host, &types.HealthCheck{Protocol: types.HealthCheckHTTP, HTTPPort: port, HTTPURL: "/", UnhealthyThreshold: 2})
+	bad.StatusMeta.Healthy = true
+	tcp := healthCheckContainer("tcp", host, &types.HealthCheck{Protocol: types.HealthCheckTCP, TCPPorts: []string{port + "/tcp"}, InitialDelay: 5})
+	// left to agent
+	cmd := healthCheckContainer("cmd", host, &types.HealthCheck{Protocol: types.HealthCheckCmd, Cmd: []string{"true"}})
+	store.On("ListContainers", mock.Anything, "", "", "", int64(0), mock.Anything).Return([]*types.Container{ok, bad, tcp, cmd}, nil)
+	store.On("SetContainerStatus", mock.Anything, mock.Anything, int64(60)).Return(nil)
+
+	states := map[string]*healthState{}
+	now := time.Now()
+	c.doHealthChecks(ctx, states, now)
+	assert.True(t, ok.StatusMeta.Healthy)
+	// threshold not reached yet
+	assert.True(t, bad.StatusMeta.Healthy)
+	assert.Equal(t, 1, states["bad"].failures)
+	// initial delay
+	assert.True(t, states["tcp"].lastCheck.IsZero())
+	assert.Nil(t, states["cmd"])
+
+	// not due yet
+	c.doHealthChecks(ctx, states, now.Add(time.Second))
+	assert.Equal(t, 1, states["bad"].failures)
+
+	c.doHealthChecks(ctx, states, now.Add(10*time.Second))
+	assert.False(t, bad.StatusMeta.Healthy)
+	assert.True(t, tcp.StatusMeta.Healthy)
+	store.AssertNumberOfCalls(t, "SetContainerStatus", 3)
+}
+
+func TestSetContainersStatusHealthOwnedByCore(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	c.config.HealthCheck.Interval = time.Second
+
+	container := healthCheckContainer("c1", "10.0.0.1", &types.HealthCheck{Protocol: types.HealthCheckTCP, TCPPorts: []string{"80"}})
+	container.StatusMeta.Healthy = true
+	store.On("GetContainer", mock.Anything, "c1").Return(container, nil)
+	store.On("SetContainerStatus", mock.Anything, mock.Anything, int64(0)).Return(nil)
+	r, err := c.SetContainersStatus(ctx, []*types.StatusMeta{{ID: "c1", Running: true}}, nil)
+	assert.NoError(t, err)
+	assert.True(t, r[0].Healthy)
+}
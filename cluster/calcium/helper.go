@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
 
 	"bufio"
 
@@ -23,10 +26,19 @@ type window struct {
 	Width  uint `json:"Col"`
 }
 
-func execuateInside(ctx context.Context, client engine.API, ID, cmd, user string, env []string, privileged bool) ([]byte, error) {
-	cmds := utils.MakeCommandLineArgs(cmd)
+func execuateInside(ctx context.Context, client engine.API, ID, cmd, user, workingDir string, env []string, privileged bool, output io.Writer) *types.HookResult {
+	result := execuateArgsInside(ctx, client, ID, utils.MakeCommandLineArgs(cmd), user, workingDir, env, privileged, output)
+	result.Cmd = cmd
+	return result
+}
+
+// execuateArgsInside runs cmds inside container and collects its output
+// stdout is copied to output as it comes if output is not nil
+func execuateArgsInside(ctx context.Context, client engine.API, ID string, cmds []string, user, workingDir string, env []string, privileged bool, output io.Writer) *types.HookResult {
+	result := &types.HookResult{Cmd: strings.Join(cmds, " "), ExitCode: -1}
 	execConfig := &enginetypes.ExecConfig{
 		User:         user,
+		WorkingDir:   workingDir,
 		Cmd:          cmds,
 		Privileged:   privileged,
 		Env:          env,
@@ -35,27 +47,55 @@ func execuateInside(ctx context.Context, client engine.API, ID, cmd, user string
 	}
 	execID, err := client.ExecCreate(ctx, ID, execConfig)
 	if err != nil {
-		return []byte{}, err
+		result.Error = err
+		return result
 	}
 
-	outStream, _, err := client.ExecAttach(ctx, execID, false)
+	stdout, stderr, err := client.ExecAttachOutput(ctx, execID)
 	if err != nil {
-		return []byte{}, err
+		result.Error = err
+		return result
 	}
+	defer stdout.Close()
+	defer stderr.Close()
+
+	// close the streams when ctx is done, or a hanging command blocks reading forever
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			stdout.Close()
+			stderr.Close()
+		case <-done:
+		}
+	}()
 
-	b := []byte{}
-	for data := range processVirtualizationOutStream(ctx, outStream) {
-		b = append(b, data...)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		result.Stderr, _ = ioutil.ReadAll(stderr)
+	}()
+	var reader io.Reader = stdout
+	if output != nil {
+		reader = io.TeeReader(stdout, output)
+	}
+	result.Stdout, _ = ioutil.ReadAll(reader)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		result.Error = err
+		return result
 	}
 
-	exitCode, err := client.ExecExitCode(ctx, execID)
-	if err != nil {
-		return b, err
+	if result.ExitCode, err = client.ExecExitCode(ctx, execID); err != nil {
+		result.Error = err
+		return result
 	}
-	if exitCode != 0 {
-		return b, fmt.Errorf("%s", b)
+	if result.ExitCode != 0 {
+		result.Error = fmt.Errorf("exit code %d: %s%s", result.ExitCode, result.Stdout, result.Stderr)
 	}
-	return b, nil
+	return result
 }
 
 func distributionInspect(ctx context.Context, node *types.Node, image string, digests []string) bool {
@@ -75,11 +115,11 @@ func distributionInspect(ctx context.Context, node *types.Node, image string, di
 	return false
 }
 
-// Pull an image
-func pullImage(ctx context.Context, node *types.Node, image string) error {
+// Pull an image, auth is credential of registry given by core, nil for credentials in config of engine
+func pullImage(ctx context.Context, node *types.Node, image string, auth *enginetypes.AuthConfig) (pulled bool, err error) {
 	log.Infof("[pullImage] Pulling image %s", image)
 	if image == "" {
-		return types.ErrNoImage
+		return false, types.ErrNoImage
 	}
 
 	// check local
@@ -94,18 +134,19 @@ func pullImage(ctx context.Context, node *types.Node, image string) error {
 
 	if exists && distributionInspect(ctx, node, image, digests) {
 		log.Debug("[pullImage] Image cached, skip pulling")
-		return nil
+		return false, nil
 	}
 
 	log.Info("[pullImage] Image not cached, pulling")
-	rc, err := node.Engine.ImagePull(ctx, image, false)
-	defer utils.EnsureReaderClosed(rc)
+	rc, err := node.Engine.ImagePull(ctx, image, false, auth)
 	if err != nil {
+		utils.EnsureReaderClosed(rc)
 		log.Errorf("[pullImage] Error during pulling image %s: %v", image, err)
-		return err
+		return false, err
 	}
+	logPullProgress(node.Name, image, rc)
 	log.Infof("[pullImage] Done pulling image %s", image)
-	return nil
+	return true, nil
 }
 
 func makeCopyMessage(id, status, name, path string, err error, data io.ReadCloser) *types.CopyMessage {
@@ -119,9 +160,13 @@ func makeCopyMessage(id, status, name, path string, err error, data io.ReadClose
 	}
 }
 
+// getNodesInfo makes scheduling info of nodes, cordoned nodes are left out
 func getNodesInfo(nodes map[string]*types.Node, cpu float64, memory, storage, volumeSize int64) []types.NodeInfo {
 	result := []types.NodeInfo{}
 	for _, node := range nodes {
+		if node.Unschedulable {
+			continue
+		}
 		nodeInfo := types.NodeInfo{
 			Name:          node.Name,
 			CPUMap:        node.CPU,
@@ -129,6 +174,8 @@ func getNodesInfo(nodes map[string]*types.Node, cpu float64, memory, storage, vo
 			InitVolumeMap: node.InitVolume,
 			MemCap:        node.MemCap,
 			StorageCap:    node.AvailableStorage(),
+			Hugepages:     node.Hugepages,
+			GPU:           node.GPU,
 			Rates: map[types.ResourceType]float64{
 				types.ResourceCPU:     cpu / float64(len(node.InitCPU)),
 				types.ResourceMemory:  float64(memory) / float64(node.InitMemCap),
@@ -3,29 +3,167 @@ package calcium
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
 
-	"github.com/projecteru2/core/engine"
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
 )
 
-func (c *Calcium) doHook(
-	ctx context.Context,
-	ID, user string,
-	cmds, env []string,
-	cmdForce, privileged, force bool,
-	engine engine.API,
-) ([]*bytes.Buffer, error) {
-	outputs := []*bytes.Buffer{}
-	for _, cmd := range cmds {
-		output, err := execuateInside(ctx, engine, ID, cmd, user, env, privileged)
-		if err != nil {
-			// 执行 hook 的过程中,如果 cmdForce 为真并且不忽略 hook 就输出错误
-			outputs = append(outputs, bytes.NewBufferString(err.Error()))
-			if cmdForce && !force {
-				return outputs, err
-			}
+// podHookStagePrefix marks results of pod hook stages
+const podHookStagePrefix = "pod/"
+
+// doHook runs stages of event in order, cmds of each stage one by one
+// pod hook wraps container hook: runs after it on after_start, and before it on stop and remove
+// cmds run inside container or against remote urls by hook type
+// error of a failed cmd is returned only if its stage is forced and force (ignore hook) is false
+// stdout of cmds is also copied to output as it comes if output is not nil
+func (c *Calcium) doHook(ctx context.Context, container *types.Container, event string, publish map[string][]string, output io.Writer, force bool) ([]*types.HookResult, error) {
+	var results []*types.HookResult
+	hooks := []*types.Hook{container.Hook, container.PodHook}
+	prefixes := []string{"", podHookStagePrefix}
+	if event != types.HookAfterStart {
+		hooks[0], hooks[1] = hooks[1], hooks[0]
+		prefixes[0], prefixes[1] = prefixes[1], prefixes[0]
+	}
+	for i, hook := range hooks {
+		if hook == nil {
 			continue
 		}
-		outputs = append(outputs, bytes.NewBuffer(output))
+		for _, stage := range hook.StagesOf(event) {
+			for _, cmd := range stage.Commands {
+				result := c.doHookCmdWithRetry(ctx, container, hook, stage, cmd, publish, output)
+				result.Stage = prefixes[i] + stage.Name
+				results = append(results, result)
+				// 执行 hook 的过程中,如果 cmdForce 为真并且不忽略 hook 就输出错误
+				if result.Error != nil && stage.Force && !force {
+					return results, &types.HookError{Stage: result.Stage, Err: result.Error}
+				}
+			}
+		}
+	}
+	return results, nil
+}
+
+// doHookCmdWithRetry retries a failed cmd with exponential backoff
+func (c *Calcium) doHookCmdWithRetry(ctx context.Context, container *types.Container, hook *types.Hook, stage types.HookStage, cmd string, publish map[string][]string, output io.Writer) *types.HookResult {
+	backoff := time.Duration(hook.Backoff) * time.Second
+	result := c.doHookCmd(ctx, container, hook, stage, cmd, publish, output)
+	for i := 0; i < hook.Retries && result.Error != nil; i++ {
+		log.Warnf("[doHook] Hook %s on %s failed %v, retry %d/%d", cmd, container.ID, result.Error, i+1, hook.Retries)
+		select {
+		case <-ctx.Done():
+			return result
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		result = c.doHookCmd(ctx, container, hook, stage, cmd, publish, output)
+	}
+	return result
+}
+
+// doHookCmd runs one hook command, a hanging command is cut off after timeout seconds
+func (c *Calcium) doHookCmd(ctx context.Context, container *types.Container, hook *types.Hook, stage types.HookStage, cmd string, publish map[string][]string, output io.Writer) *types.HookResult {
+	hookCtx := ctx
+	if hook.Timeout > 0 {
+		var cancel context.CancelFunc
+		hookCtx, cancel = context.WithTimeout(ctx, time.Duration(hook.Timeout)*time.Second)
+		defer cancel()
+	}
+	var result *types.HookResult
+	if hook.Type == types.HookTypeHTTP {
+		result = callHTTPHook(hookCtx, container, stage.Name, cmd, publish, hook.HTTPCode, output)
+	} else {
+		user := stage.User
+		if user == "" {
+			user = container.User
+		}
+		result = execuateInside(hookCtx, container.Engine, container.ID, cmd, user, stage.WorkingDir, hookEnv(container, publish), container.Privileged, output)
+	}
+	if hookCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		result.Error = types.NewDetailedErr(types.ErrHookTimeout, cmd)
+	}
+	return result
+}
+
+type hookPayload struct {
+	Stage    string              `json:"stage"`
+	ID       string              `json:"id"`
+	Name     string              `json:"name"`
+	Podname  string              `json:"pod"`
+	Nodename string              `json:"node"`
+	Labels   map[string]string   `json:"labels,omitempty"`
+	Publish  map[string][]string `json:"publish,omitempty"`
+}
+
+// callHTTPHook posts container context to url, for images without shell or curl inside
+// response body goes to stdout and status code to exit code
+func callHTTPHook(ctx context.Context, container *types.Container, stage, url string, publish map[string][]string, code int, output io.Writer) *types.HookResult {
+	result := &types.HookResult{Cmd: url}
+	body, err := json.Marshal(&hookPayload{
+		Stage:    stage,
+		ID:       container.ID,
+		Name:     container.Name,
+		Podname:  container.Podname,
+		Nodename: container.Nodename,
+		Labels:   container.Labels,
+		Publish:  publish,
+	})
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	defer resp.Body.Close()
+	result.ExitCode = resp.StatusCode
+	var reader io.Reader = resp.Body
+	if output != nil {
+		reader = io.TeeReader(reader, output)
+	}
+	if result.Stdout, err = ioutil.ReadAll(reader); err != nil {
+		result.Error = err
+		return result
+	}
+	if code == 0 {
+		code = http.StatusOK
+	}
+	if resp.StatusCode != code {
+		result.Error = fmt.Errorf("status code %d: %s", resp.StatusCode, result.Stdout)
+	}
+	return result
+}
+
+// hookEnv appends deploy context to container env for hook processes
+// publish is only known after container started, nil means unknown
+func hookEnv(container *types.Container, publish map[string][]string) []string {
+	env := append([]string{}, container.Env...)
+	env = append(env,
+		fmt.Sprintf("ERU_CONTAINER_ID=%s", container.ID),
+		fmt.Sprintf("ERU_CONTAINER_NAME=%s", container.Name),
+		fmt.Sprintf("ERU_POD=%s", container.Podname),
+		fmt.Sprintf("ERU_NODE_NAME=%s", container.Nodename),
+	)
+	if labels, err := json.Marshal(container.Labels); err == nil {
+		env = append(env, fmt.Sprintf("ERU_LABELS=%s", labels))
+	}
+	if publish != nil {
+		if b, err := json.Marshal(publish); err == nil {
+			env = append(env, fmt.Sprintf("ERU_PUBLISH=%s", b))
+		}
 	}
-	return outputs, nil
+	return env
 }
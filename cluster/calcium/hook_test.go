@@ -0,0 +1,261 @@
+package calcium
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDoHookTimeout(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	engine := &enginemocks.API{}
+	engine.On("ExecCreate", mock.Anything, mock.Anything, mock.Anything).Return("eid", nil)
+	engine.On("ExecExitCode", mock.Anything, mock.Anything).Return(0, nil)
+
+	// hanging hook is cut off
+	r, w := io.Pipe()
+	defer w.Close()
+	engine.On("ExecAttachOutput", mock.Anything, mock.Anything).Return(r, ioutil.NopCloser(bytes.NewBuffer(nil)), nil).Once()
+	container := &types.Container{ID: "id", Engine: engine, Hook: &types.Hook{AfterStart: []string{"sleep"}, Force: true, Timeout: 1}}
+	results, err := c.doHook(ctx, container, types.HookAfterStart, nil, nil, false)
+	assert.True(t, errors.Is(err, types.ErrHookTimeout))
+	assert.Len(t, results, 1)
+
+	// not forced, go on with next cmd
+	r, w = io.Pipe()
+	defer w.Close()
+	engine.On("ExecAttachOutput", mock.Anything, mock.Anything).Return(r, ioutil.NopCloser(bytes.NewBuffer(nil)), nil).Once()
+	engine.On("ExecAttachOutput", mock.Anything, mock.Anything).Return(ioutil.NopCloser(bytes.NewBufferString("succ")), ioutil.NopCloser(bytes.NewBuffer(nil)), nil).Once()
+	container.Hook.Force = false
+	container.Hook.AfterStart = []string{"sleep", "echo"}
+	results, err = c.doHook(ctx, container, types.HookAfterStart, nil, nil, false)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.True(t, errors.Is(results[0].Error, types.ErrHookTimeout))
+	assert.Equal(t, "succ", string(results[1].Stdout))
+}
+
+func TestDoHookResults(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	engine := &enginemocks.API{}
+	var env []string
+	engine.On("ExecCreate", mock.Anything, mock.Anything, mock.Anything).Return("eid", nil).Run(func(args mock.Arguments) {
+		env = args.Get(2).(*enginetypes.ExecConfig).Env
+	})
+	engine.On("ExecAttachOutput", mock.Anything, mock.Anything).Return(
+		ioutil.NopCloser(bytes.NewBufferString("out")), ioutil.NopCloser(bytes.NewBufferString("err")), nil,
+	)
+	engine.On("ExecExitCode", mock.Anything, mock.Anything).Return(2, nil)
+
+	container := &types.Container{
+		ID:       "id",
+		Nodename: "node",
+		Env:      []string{"A=1"},
+		Labels:   map[string]string{"l": "v"},
+		Engine:   engine,
+		Hook:     &types.Hook{AfterStart: []string{"check"}, Force: true},
+	}
+	results, err := c.doAfterStartHook(ctx, container, map[string][]string{"net": {"1.1.1.1:80"}}, nil, false)
+	assert.Error(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "check", results[0].Cmd)
+	assert.Equal(t, "out", string(results[0].Stdout))
+	assert.Equal(t, "err", string(results[0].Stderr))
+	assert.Equal(t, 2, results[0].ExitCode)
+	assert.Contains(t, env, "A=1")
+	assert.Contains(t, env, "ERU_CONTAINER_ID=id")
+	assert.Contains(t, env, "ERU_NODE_NAME=node")
+	assert.Contains(t, env, `ERU_LABELS={"l":"v"}`)
+	assert.Contains(t, env, `ERU_PUBLISH={"net":["1.1.1.1:80"]}`)
+	assert.True(t, strings.Contains(string(types.HookOutput(types.HookResultsOutput(results))), "exit code 2"))
+}
+
+func TestDoHookRetry(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	engine := &enginemocks.API{}
+	engine.On("ExecCreate", mock.Anything, mock.Anything, mock.Anything).Return("", types.ErrNilEngine)
+	container := &types.Container{ID: "id", Engine: engine, Hook: &types.Hook{AfterStart: []string{"register"}, Force: true, Retries: 2}}
+
+	// out of retries
+	_, err := c.doHook(ctx, container, types.HookAfterStart, nil, nil, false)
+	assert.True(t, errors.Is(err, types.ErrNilEngine))
+	engine.AssertNumberOfCalls(t, "ExecCreate", 3)
+
+	// failed once then succ
+	engine = &enginemocks.API{}
+	engine.On("ExecCreate", mock.Anything, mock.Anything, mock.Anything).Return("", types.ErrNilEngine).Once()
+	engine.On("ExecCreate", mock.Anything, mock.Anything, mock.Anything).Return("eid", nil)
+	engine.On("ExecAttachOutput", mock.Anything, mock.Anything).Return(
+		ioutil.NopCloser(bytes.NewBufferString("ok")), ioutil.NopCloser(bytes.NewBuffer(nil)), nil,
+	)
+	engine.On("ExecExitCode", mock.Anything, mock.Anything).Return(0, nil)
+	container.Engine = engine
+	results, err := c.doHook(ctx, container, types.HookAfterStart, nil, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(results[0].Stdout))
+	engine.AssertNumberOfCalls(t, "ExecCreate", 2)
+}
+
+func TestDoHookHTTP(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	var payload map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write([]byte("done"))
+	}))
+	defer srv.Close()
+
+	container := &types.Container{ID: "id", Podname: "p", Hook: &types.Hook{
+		AfterStart: []string{srv.URL + "/register"},
+		BeforeStop: []string{srv.URL + "/fail"},
+		Type:       types.HookTypeHTTP,
+		Force:      true,
+	}}
+	results, err := c.doHook(ctx, container, types.HookAfterStart, map[string][]string{"net": {"1.1.1.1:80"}}, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "done", string(results[0].Stdout))
+	assert.Equal(t, http.StatusOK, results[0].ExitCode)
+	assert.Equal(t, "id", payload["id"])
+	assert.Equal(t, "p", payload["pod"])
+	assert.Equal(t, types.HookAfterStart, payload["stage"])
+	assert.NotNil(t, payload["publish"])
+
+	// unexpected status
+	results, err = c.doHook(ctx, container, types.HookBeforeStop, nil, nil, false)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, results[0].ExitCode)
+	assert.Equal(t, types.HookBeforeStop, results[0].Stage)
+
+	// expected status
+	container.Hook.HTTPCode = http.StatusServiceUnavailable
+	_, err = c.doHook(ctx, container, types.HookBeforeStop, nil, nil, false)
+	assert.NoError(t, err)
+}
+
+func TestDoHookStages(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	engine := &enginemocks.API{}
+	cmds := []string{}
+	engine.On("ExecCreate", mock.Anything, mock.Anything, mock.Anything).Return("eid", nil).Run(func(args mock.Arguments) {
+		cmds = append(cmds, args.Get(2).(*enginetypes.ExecConfig).Cmd...)
+	})
+	engine.On("ExecAttachOutput", mock.Anything, mock.Anything).Return(
+		ioutil.NopCloser(bytes.NewBuffer(nil)), ioutil.NopCloser(bytes.NewBuffer(nil)), nil,
+	)
+	engine.On("ExecExitCode", mock.Anything, mock.Anything).Return(1, nil)
+	container := &types.Container{ID: "id", Engine: engine, Hook: &types.Hook{
+		AfterStart: []string{"start"},
+		Stages: []types.HookStage{
+			{Name: "warmup", Commands: []string{"warmup"}},
+			{Name: "deregister", On: types.HookBeforeStop, Commands: []string{"deregister"}},
+			{Name: "register", Commands: []string{"register"}, Force: true},
+			{Name: "smoke", Commands: []string{"smoke"}},
+		},
+	}}
+
+	// forced stage stops the sequence
+	results, err := c.doHook(ctx, container, types.HookAfterStart, nil, nil, false)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"start", "warmup", "register"}, cmds)
+	assert.Len(t, results, 3)
+	assert.Equal(t, types.HookAfterStart, results[0].Stage)
+	assert.Equal(t, "warmup", results[1].Stage)
+	assert.Equal(t, "register", results[2].Stage)
+
+	// ignore hook
+	cmds = []string{}
+	results, err = c.doHook(ctx, container, types.HookAfterStart, nil, nil, true)
+	assert.NoError(t, err)
+	assert.Len(t, results, 4)
+
+	results, err = c.doHook(ctx, container, types.HookBeforeRemove, nil, nil, false)
+	assert.NoError(t, err)
+	assert.Nil(t, results)
+}
+
+func TestDoHookUserWorkingDir(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	engine := &enginemocks.API{}
+	configs := []*enginetypes.ExecConfig{}
+	engine.On("ExecCreate", mock.Anything, mock.Anything, mock.Anything).Return("eid", nil).Run(func(args mock.Arguments) {
+		configs = append(configs, args.Get(2).(*enginetypes.ExecConfig))
+	})
+	engine.On("ExecAttachOutput", mock.Anything, mock.Anything).Return(
+		ioutil.NopCloser(bytes.NewBuffer(nil)), ioutil.NopCloser(bytes.NewBuffer(nil)), nil,
+	)
+	engine.On("ExecExitCode", mock.Anything, mock.Anything).Return(0, nil)
+	container := &types.Container{ID: "id", User: "app", Engine: engine, Hook: &types.Hook{
+		AfterStart: []string{"start"},
+		WorkingDir: "/app",
+		Stages:     []types.HookStage{{Name: "migrate", Commands: []string{"migrate"}, User: "root", WorkingDir: "/"}},
+	}}
+	_, err := c.doHook(ctx, container, types.HookAfterStart, nil, nil, false)
+	assert.NoError(t, err)
+	assert.Len(t, configs, 2)
+	assert.Equal(t, "app", configs[0].User)
+	assert.Equal(t, "/app", configs[0].WorkingDir)
+	assert.Equal(t, "root", configs[1].User)
+	assert.Equal(t, "/", configs[1].WorkingDir)
+}
+
+func TestDoHookPodHook(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	engine := &enginemocks.API{}
+	cmds := []string{}
+	engine.On("ExecCreate", mock.Anything, mock.Anything, mock.Anything).Return("eid", nil).Run(func(args mock.Arguments) {
+		cmds = append(cmds, args.Get(2).(*enginetypes.ExecConfig).Cmd...)
+	})
+	engine.On("ExecAttachOutput", mock.Anything, mock.Anything).Return(
+		ioutil.NopCloser(bytes.NewBuffer(nil)), ioutil.NopCloser(bytes.NewBuffer(nil)), nil,
+	)
+	engine.On("ExecExitCode", mock.Anything, mock.Anything).Return(0, nil)
+	container := &types.Container{
+		ID:      "id",
+		Engine:  engine,
+		Hook:    &types.Hook{AfterStart: []string{"warmup"}, BeforeStop: []string{"drain"}},
+		PodHook: &types.Hook{AfterStart: []string{"register"}, BeforeStop: []string{"deregister"}},
+	}
+
+	// pod hook runs after container hook on start
+	results, err := c.doHook(ctx, container, types.HookAfterStart, nil, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"warmup", "register"}, cmds)
+	assert.Equal(t, types.HookAfterStart, results[0].Stage)
+	assert.Equal(t, "pod/"+types.HookAfterStart, results[1].Stage)
+
+	// and before it on stop
+	cmds = []string{}
+	_, err = c.doHook(ctx, container, types.HookBeforeStop, nil, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"deregister", "drain"}, cmds)
+
+	// pod hook only
+	cmds = []string{}
+	container.Hook = nil
+	_, err = c.doHook(ctx, container, types.HookAfterStart, nil, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"register"}, cmds)
+}
@@ -100,7 +100,11 @@ func (c *Calcium) CacheImage(ctx context.Context, podname, nodename string, imag
 						Nodename: node.Name,
 						Message:  "",
 					}
-					if err := pullImage(ctx, node, image); err != nil {
+					auth, err := c.registryAuth(ctx, node.Podname, image)
+					if err == nil {
+						err = c.puller.pull(ctx, node, image, auth)
+					}
+					if err != nil {
 						m.Success = false
 						m.Message = err.Error()
 					}
@@ -78,14 +78,14 @@ func TestCacheImage(t *testing.T) {
 	// fail by ImageRemoteDigest
 	engine.On("ImageRemoteDigest", mock.Anything, mock.Anything).Return("", types.ErrNoETCD).Once()
 	engine.On("ImageLocalDigests", mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD).Once()
-	engine.On("ImagePull", mock.Anything, mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD).Once()
+	engine.On("ImagePull", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD).Once()
 	ch, err := c.CacheImage(ctx, "", "", []string{"xx"}, 0)
 	for c := range ch {
 		assert.False(t, c.Success)
 	}
 	engine.On("ImageRemoteDigest", mock.Anything, mock.Anything).Return("yy", nil)
 	engine.On("ImageLocalDigests", mock.Anything, mock.Anything).Return([]string{"xx"}, nil)
-	engine.On("ImagePull", mock.Anything, mock.Anything, mock.Anything).Return(ioutil.NopCloser(bytes.NewReader([]byte{})), nil)
+	engine.On("ImagePull", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(ioutil.NopCloser(bytes.NewReader([]byte{})), nil)
 	// succ
 	ch, err = c.CacheImage(ctx, "", "", []string{"xx"}, 0)
 	for c := range ch {
@@ -0,0 +1,116 @@
+package calcium
+
+import (
+	"context"
+	"sync"
+
+	enginetypes "github.com/projecteru2/core/engine/types"
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// RunJob runs containers to completion, streams their output and exit codes,
+// containers are removed once exited so resources are released and no metadata is kept,
+// failed ones are deployed again up to opts.Retries times
+func (c *Calcium) RunJob(ctx context.Context, opts *types.JobOptions) (<-chan *types.JobMessage, error) {
+	if opts.Retries < 0 {
+		return nil, types.NewDetailedErr(types.ErrBadJobRetries, opts.Retries)
+	}
+	if opts.Count <= 0 {
+		return nil, types.NewDetailedErr(types.ErrBadCount, opts.Count)
+	}
+	// jobs are not interactive
+	opts.OpenStdin = false
+	opts.Lambda = true
+
+	ch := make(chan *types.JobMessage)
+	go func() {
+		defer close(ch)
+		remaining := opts.Count
+		for attempt := 0; attempt <= opts.Retries && remaining > 0; attempt++ {
+			if attempt > 0 {
+				log.Infof("[RunJob] Retry %d failed containers, attempt %d", remaining, attempt)
+			}
+			remaining = c.doRunJob(ctx, ch, opts.DeployOptions, remaining, attempt)
+		}
+		if remaining > 0 {
+			log.Errorf("[RunJob] %d containers of job %s failed after %d retries", remaining, opts.Name, opts.Retries)
+		}
+	}()
+	return ch, nil
+}
+
+// doRunJob deploys count containers and runs them, returns how many failed
+func (c *Calcium) doRunJob(ctx context.Context, ch chan<- *types.JobMessage, deployOpts types.DeployOptions, count, attempt int) int {
+	// CreateContainer normalizes options in place, so each attempt has its own copy
+	deployOpts.Count = count
+	createCh, err := c.CreateContainer(ctx, &deployOpts)
+	if err != nil {
+		log.Errorf("[doRunJob] Create containers failed %v", err)
+		ch <- &types.JobMessage{Attempt: attempt, Error: err}
+		return count
+	}
+
+	failed := 0
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for message := range createCh {
+		if message.Error != nil || message.ContainerID == "" {
+			log.Errorf("[doRunJob] Create container failed %v", message.Error)
+			ch <- &types.JobMessage{Attempt: attempt, Error: message.Error}
+			failed++
+			continue
+		}
+		wg.Add(1)
+		go func(ID string) {
+			defer wg.Done()
+			if ok := c.doRunJobContainer(ctx, ch, ID, attempt); !ok {
+				mu.Lock()
+				defer mu.Unlock()
+				failed++
+			}
+		}(message.ContainerID)
+	}
+	wg.Wait()
+	return failed
+}
+
+// doRunJobContainer streams output and exit code of a container then removes it, returns whether it exited with 0
+func (c *Calcium) doRunJobContainer(ctx context.Context, ch chan<- *types.JobMessage, ID string, attempt int) (ok bool) {
+	defer func() {
+		if err := c.doRemoveContainerSync(context.Background(), []string{ID}); err != nil {
+			log.Errorf("[doRunJobContainer] Remove job container %s failed %v", ID, err)
+			return
+		}
+		log.Infof("[doRunJobContainer] Container %s finished and removed", utils.ShortID(ID))
+	}()
+
+	container, err := c.GetContainer(ctx, ID)
+	if err != nil {
+		ch <- &types.JobMessage{ContainerID: ID, Attempt: attempt, Error: err}
+		return false
+	}
+	outStream, err := container.Engine.VirtualizationLogs(ctx, &enginetypes.VirtualizationLogStreamOptions{
+		ID: ID, Follow: true, Stdout: true, Stderr: true})
+	if err != nil {
+		log.Errorf("[doRunJobContainer] Can't fetch log of container %s error %v", ID, err)
+		ch <- &types.JobMessage{ContainerID: ID, Attempt: attempt, Error: err}
+		return false
+	}
+	for data := range processVirtualizationOutStream(ctx, outStream) {
+		ch <- &types.JobMessage{ContainerID: ID, Attempt: attempt, Data: append([]byte{}, data...)}
+	}
+
+	r, err := container.Engine.VirtualizationWait(ctx, ID, "")
+	if err != nil {
+		log.Errorf("[doRunJobContainer] %s wait failed %v", utils.ShortID(ID), err)
+		ch <- &types.JobMessage{ContainerID: ID, Attempt: attempt, Error: err}
+		return false
+	}
+	if r.Code != 0 {
+		log.Errorf("[doRunJobContainer] %s run failed %d %s", utils.ShortID(ID), r.Code, r.Message)
+	}
+	ch <- &types.JobMessage{ContainerID: ID, Attempt: attempt, Finished: true, ExitCode: int(r.Code)}
+	return r.Code == 0
+}
@@ -0,0 +1,74 @@
+package calcium
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRunJob(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+
+	_, err := c.RunJob(ctx, &types.JobOptions{DeployOptions: types.DeployOptions{Count: 1}, Retries: -1})
+	assert.True(t, errors.Is(err, types.ErrBadJobRetries))
+	_, err = c.RunJob(ctx, &types.JobOptions{})
+	assert.True(t, errors.Is(err, types.ErrBadCount))
+
+	// failed attempts are retried
+	opts := &types.JobOptions{DeployOptions: types.DeployOptions{Name: "job", Count: 1, CPUQuota: -1}, Retries: 2}
+	ch, err := c.RunJob(ctx, opts)
+	assert.NoError(t, err)
+	attempts := []int{}
+	for m := range ch {
+		assert.Error(t, m.Error)
+		attempts = append(attempts, m.Attempt)
+	}
+	assert.Equal(t, []int{0, 1, 2}, attempts)
+	assert.True(t, opts.Lambda)
+}
+
+func TestDoRunJobContainer(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	engine := &enginemocks.API{}
+	// removing fails, it's only logged
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD)
+	store.On("GetContainers", mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD)
+
+	store.On("GetContainer", mock.Anything, "missing").Return(nil, types.ErrNoETCD).Once()
+	ch := make(chan *types.JobMessage, 10)
+	assert.False(t, c.doRunJobContainer(ctx, ch, "missing", 0))
+	assert.Error(t, (<-ch).Error)
+
+	store.On("GetContainer", mock.Anything, "job").Return(&types.Container{ID: "job", Engine: engine}, nil)
+	engine.On("VirtualizationLogs", mock.Anything, mock.Anything).Return(ioutil.NopCloser(strings.NewReader("ok")), nil)
+	engine.On("VirtualizationWait", mock.Anything, "job", "").Return(&enginetypes.VirtualizationWaitResult{Code: 3}, nil).Once()
+	assert.False(t, c.doRunJobContainer(ctx, ch, "job", 1))
+	close(ch)
+	data := ""
+	var last *types.JobMessage
+	for m := range ch {
+		data += string(m.Data)
+		last = m
+	}
+	assert.Equal(t, "ok", data)
+	assert.True(t, last.Finished)
+	assert.Equal(t, 3, last.ExitCode)
+	assert.Equal(t, 1, last.Attempt)
+
+	engine.On("VirtualizationWait", mock.Anything, "job", "").Return(&enginetypes.VirtualizationWaitResult{}, nil).Once()
+	ch = make(chan *types.JobMessage, 10)
+	assert.True(t, c.doRunJobContainer(ctx, ch, "job", 0))
+}
@@ -19,8 +19,8 @@ const exitDataPrefix = "[exitcode] "
 func (c *Calcium) RunAndWait(ctx context.Context, opts *types.DeployOptions, inCh <-chan []byte) (<-chan *types.AttachContainerMessage, error) {
 	opts.Lambda = true
 	// count = 1 && OpenStdin
-	if opts.OpenStdin && (opts.Count != 1 || opts.DeployMethod != cluster.DeployAuto) {
-		log.Errorf("Count %d method %s", opts.Count, opts.DeployMethod)
+	if opts.OpenStdin && (opts.Count != 1 || opts.DeployStrategy != cluster.DeployAuto) {
+		log.Errorf("Count %d method %s", opts.Count, opts.DeployStrategy)
 		return nil, types.ErrRunAndWaitCountOneWithStdin
 	}
 
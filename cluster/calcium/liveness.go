@@ -0,0 +1,225 @@
+package calcium
+
+import (
+	"context"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const livenessLeader = "liveness"
+
+// NodeHeartbeat keeps node alive for ttl, heartbeat ttl of config is used if ttl is 0
+func (c *Calcium) NodeHeartbeat(ctx context.Context, nodename string, ttl time.Duration) error {
+	if _, err := c.GetNode(ctx, nodename); err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		ttl = c.config.Liveness.HeartbeatTTL
+	}
+	return c.store.SetNodeAlive(ctx, nodename, ttl)
+}
+
+// FailoverNode marks node unavailable and deploys its containers again on other nodes of the pod,
+// containers left on the node are marked lost once deployed again, those failed to deploy are kept as they are
+func (c *Calcium) FailoverNode(ctx context.Context, nodename string) (chan *types.MigrateMessage, error) {
+	node, err := c.SetNode(ctx, &types.SetNodeOptions{Nodename: nodename, Status: types.TriFalse, ContainersDown: true})
+	if err != nil {
+		return nil, err
+	}
+	containers, err := c.store.ListNodeContainers(ctx, node.Name, nil)
+	if err != nil {
+		return nil, err
+	}
+	failovers := map[string]*types.Container{}
+	IDs := []string{}
+	for _, container := range containers {
+		if state := container.RetentionState(); state == types.ContainerStateReplaced || state == types.ContainerStateLost {
+			continue
+		}
+		failovers[container.ID] = container
+		IDs = append(IDs, container.ID)
+	}
+
+	ch := make(chan *types.MigrateMessage)
+	go func() {
+		defer close(ch)
+		log.Infof("[FailoverNode] Fail %d containers of node %s over", len(IDs), node.Name)
+		doBulk(ctx, IDs, c.bulkConcurrency(0), func(ID string) {
+			msg := &types.MigrateMessage{ContainerID: ID}
+			msg.Create, msg.Error = c.doFailoverContainer(ctx, failovers[ID])
+			ch <- msg
+		})
+	}()
+	return ch, nil
+}
+
+// doFailoverContainer deploys a container again in its pod with options it's created by,
+// the old one is marked lost once the new one is created, as its node can't remove it
+func (c *Calcium) doFailoverContainer(ctx context.Context, container *types.Container) (*types.CreateContainerMessage, error) {
+	// containers created before command is kept can't be deployed again
+	if len(container.Cmd) == 0 {
+		return nil, types.NewDetailedErr(types.ErrBadMeta, "command of container is unknown")
+	}
+	networks := map[string]string{}
+	for _, name := range container.Networks {
+		networks[name] = ""
+	}
+	opts, err := makeCloneDeployOptions(container, container.Cmd, container.Dir, container.Labels, networks)
+	if err != nil {
+		return nil, err
+	}
+	// node is scheduled again, the old one may be down or out of resources
+	opts.Nodename = ""
+	opts.ConfigRefs = container.Configs
+
+	createCh, err := c.CreateContainer(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	var create *types.CreateContainerMessage
+	for m := range createCh {
+		create = m
+		err = m.Error
+	}
+	if create == nil || err != nil {
+		log.Errorf("[doFailoverContainer] Deploy container %s again failed %v", container.ID, err)
+		return create, err
+	}
+	log.Infof("[doFailoverContainer] Container %s is deployed again as %s on node %s", container.ID, create.ContainerID, create.Nodename)
+	if err := c.withContainerLocked(ctx, container.ID, func(container *types.Container) error {
+		c.markContainerLost(ctx, container, create.ContainerID)
+		return nil
+	}); err != nil {
+		log.Errorf("[doFailoverContainer] Mark container %s lost failed %v", container.ID, err)
+	}
+	return create, nil
+}
+
+// markContainerLost annotates the container left on a dead node, so retention rules can clean it
+func (c *Calcium) markContainerLost(ctx context.Context, container *types.Container, newID string) {
+	annotations := map[string]string{}
+	for k, v := range container.Annotations {
+		annotations[k] = v
+	}
+	annotations[types.ContainerLostAnnotation] = newID
+	container.Annotations = annotations
+	if err := c.store.UpdateContainer(ctx, container); err != nil {
+		log.Errorf("[markContainerLost] Mark container %s lost failed %v", container.ID, err)
+	}
+}
+
+// watchNodeLiveness campaigns for leader of liveness checks, and runs them while it leads
+func (c *Calcium) watchNodeLiveness(ctx context.Context) {
+	if c.config.Liveness.Interval <= 0 {
+		return
+	}
+	for {
+		lost, err := c.store.Campaign(ctx, livenessLeader, c.config.Liveness.LeaderTTL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Errorf("[watchNodeLiveness] Campaign failed %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.config.Liveness.Interval):
+			}
+			continue
+		}
+		log.Info("[watchNodeLiveness] Elected as leader of liveness checks")
+		c.doWatchNodeLiveness(ctx, lost)
+	}
+}
+
+func (c *Calcium) doWatchNodeLiveness(ctx context.Context, lost <-chan struct{}) {
+	lastSeen := map[string]time.Time{}
+	ticker := time.NewTicker(c.config.Liveness.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lost:
+			return
+		case <-ticker.C:
+			c.doCheckNodeLiveness(ctx, lastSeen, time.Now())
+		}
+	}
+}
+
+// doCheckNodeLiveness fails over available nodes unseen for dead timeout,
+// nodes are seen if they keep heartbeats or their engines reply to pings
+func (c *Calcium) doCheckNodeLiveness(ctx context.Context, lastSeen map[string]time.Time, now time.Time) {
+	cctx, cancel := context.WithTimeout(ctx, c.config.GlobalTimeout)
+	defer cancel()
+	pods, err := c.store.GetAllPods(cctx)
+	if err != nil {
+		log.Errorf("[doCheckNodeLiveness] Get pods failed %v", err)
+		return
+	}
+	nodenames, err := c.store.ListAliveNodes(cctx)
+	if err != nil {
+		log.Errorf("[doCheckNodeLiveness] List alive nodes failed %v", err)
+		return
+	}
+	alive := map[string]bool{}
+	for _, nodename := range nodenames {
+		alive[nodename] = true
+	}
+
+	seen := map[string]bool{}
+	dead := []string{}
+	for _, pod := range pods {
+		nodes, err := c.store.GetNodesByPod(cctx, pod.Name, nil, false)
+		if err != nil {
+			log.Errorf("[doCheckNodeLiveness] Get nodes of pod %s failed %v", pod.Name, err)
+			continue
+		}
+		for _, node := range nodes {
+			seen[node.Name] = true
+			if _, ok := lastSeen[node.Name]; !ok || alive[node.Name] || c.pingNode(cctx, node) {
+				lastSeen[node.Name] = now
+				continue
+			}
+			if now.Sub(lastSeen[node.Name]) >= c.config.Liveness.DeadTimeout {
+				dead = append(dead, node.Name)
+			}
+		}
+	}
+	// unavailable nodes start over once they are available again
+	for nodename := range lastSeen {
+		if !seen[nodename] {
+			delete(lastSeen, nodename)
+		}
+	}
+
+	for _, nodename := range dead {
+		log.Warnf("[doCheckNodeLiveness] Node %s is dead since %v, failing it over", nodename, lastSeen[nodename])
+		delete(lastSeen, nodename)
+		// failover outlives a single round of checks
+		ch, err := c.FailoverNode(ctx, nodename)
+		if err != nil {
+			log.Errorf("[doCheckNodeLiveness] Fail node %s over failed %v", nodename, err)
+			continue
+		}
+		for m := range ch {
+			if m.Error != nil {
+				log.Errorf("[doCheckNodeLiveness] Fail container %s of node %s over failed %v", m.ContainerID, nodename, m.Error)
+			}
+		}
+	}
+}
+
+func (c *Calcium) pingNode(ctx context.Context, node *types.Node) bool {
+	if node.Engine == nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.config.Liveness.PingTimeout)
+	defer cancel()
+	if _, err := node.Engine.Info(ctx); err != nil {
+		log.Debugf("[pingNode] Ping node %s failed %v", node.Name, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	lockmocks "github.com/projecteru2/core/lock/mocks"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNodeHeartbeat(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	c.config.Liveness.HeartbeatTTL = 30 * time.Second
+
+	store.On("GetNode", mock.Anything, "bad").Return(nil, types.ErrNodeNotExists)
+	assert.Error(t, c.NodeHeartbeat(ctx, "bad", time.Minute))
+
+	store.On("GetNode", mock.Anything, "n1").Return(&types.Node{Name: "n1"}, nil)
+	store.On("SetNodeAlive", mock.Anything, "n1", time.Minute).Return(nil)
+	store.On("SetNodeAlive", mock.Anything, "n1", 30*time.Second).Return(nil)
+	assert.NoError(t, c.NodeHeartbeat(ctx, "n1", time.Minute))
+	assert.NoError(t, c.NodeHeartbeat(ctx, "n1", 0))
+	store.AssertNumberOfCalls(t, "SetNodeAlive", 2)
+}
+
+func TestDoCheckNodeLiveness(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	c.config.Liveness = types.LivenessConfig{Interval: time.Second, PingTimeout: time.Second, DeadTimeout: time.Minute}
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+
+	pinged := &enginemocks.API{}
+	pinged.On("Info", mock.Anything).Return(&enginetypes.Info{}, nil)
+	unreachable := &enginemocks.API{}
+	unreachable.On("Info", mock.Anything).Return(nil, types.ErrNilEngine)
+	// keeps heartbeats
+	n1 := &types.Node{Name: "n1", Podname: "p1", Available: true, Engine: unreachable}
+	n2 := &types.Node{Name: "n2", Podname: "p1", Available: true, Engine: pinged}
+	n3 := &types.Node{Name: "n3", Podname: "p1", Available: true, Engine: unreachable}
+	store.On("GetAllPods", mock.Anything).Return([]*types.Pod{{Name: "p1"}}, nil)
+	store.On("ListAliveNodes", mock.Anything).Return([]string{"n1"}, nil)
+	store.On("GetNodesByPod", mock.Anything, "p1", mock.Anything, false).Return([]*types.Node{n1, n2, n3}, nil)
+
+	lastSeen := map[string]time.Time{}
+	now := time.Now()
+	c.doCheckNodeLiveness(ctx, lastSeen, now)
+	assert.Len(t, lastSeen, 3)
+	c.doCheckNodeLiveness(ctx, lastSeen, now.Add(30*time.Second))
+	assert.Equal(t, now.Add(30*time.Second), lastSeen["n1"])
+	assert.Equal(t, now.Add(30*time.Second), lastSeen["n2"])
+	assert.Equal(t, now, lastSeen["n3"])
+
+	// n3 is dead
+	store.On("GetNode", mock.Anything, "n3").Return(n3, nil)
+	store.On("UpdateNode", mock.Anything, n3).Return(nil)
+	store.On("SetContainerStatus", mock.Anything, mock.Anything, int64(0)).Return(nil)
+	// command unknown
+	unknown := &types.Container{ID: "unknown", Name: "app_web_abcdef", Podname: "p1", Nodename: "n3"}
+	lost := &types.Container{ID: "lost", Name: "app_web_ghijkl", Podname: "p1", Nodename: "n3", Annotations: map[string]string{types.ContainerLostAnnotation: "new"}}
+	store.On("ListNodeContainers", mock.Anything, "n3", mock.Anything).Return([]*types.Container{unknown, lost}, nil)
+	c.doCheckNodeLiveness(ctx, lastSeen, now.Add(time.Minute))
+	assert.False(t, n3.Available)
+	assert.False(t, unknown.StatusMeta.Running)
+	assert.Len(t, lastSeen, 2)
+	store.AssertCalled(t, "UpdateNode", mock.Anything, n3)
+}
+
+func TestFailoverNode(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+
+	store.On("GetNode", mock.Anything, "bad").Return(nil, types.ErrNodeNotExists)
+	_, err := c.FailoverNode(ctx, "bad")
+	assert.Error(t, err)
+
+	node := &types.Node{Name: "n1", Podname: "p1", Available: true}
+	store.On("GetNode", mock.Anything, "n1").Return(node, nil)
+	store.On("UpdateNode", mock.Anything, node).Return(nil)
+	store.On("SetContainerStatus", mock.Anything, mock.Anything, int64(0)).Return(nil)
+	unknown := &types.Container{ID: "unknown", Name: "app_web_abcdef", Podname: "p1", Nodename: "n1"}
+	replaced := &types.Container{ID: "replaced", Name: "app_web_ghijkl", Podname: "p1", Nodename: "n1", Annotations: map[string]string{types.ContainerReplacedAnnotation: "new"}}
+	store.On("ListNodeContainers", mock.Anything, "n1", mock.Anything).Return([]*types.Container{unknown, replaced}, nil)
+	ch, err := c.FailoverNode(ctx, "n1")
+	assert.NoError(t, err)
+	messages := []*types.MigrateMessage{}
+	for m := range ch {
+		messages = append(messages, m)
+	}
+	assert.False(t, node.Available)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "unknown", messages[0].ContainerID)
+	assert.Error(t, messages[0].Error)
+}
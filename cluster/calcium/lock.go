@@ -43,12 +43,47 @@ func (c *Calcium) withContainerLocked(ctx context.Context, ID string, f func(con
 	})
 }
 
+// withPrefetchedContainerLocked locks container prefetched in batch, container not prefetched is got by ID
+func (c *Calcium) withPrefetchedContainerLocked(ctx context.Context, ID string, prefetched map[string]*types.Container, f func(container *types.Container) error) error {
+	container, ok := prefetched[ID]
+	if !ok {
+		return c.withContainerLocked(ctx, ID, f)
+	}
+	containerLock, err := c.doLock(ctx, fmt.Sprintf(cluster.ContainerLock, ID), c.config.LockTimeout)
+	if err != nil {
+		return err
+	}
+	defer func() { c.doUnlockAll(ctx, map[string]lock.DistributedLock{ID: containerLock}) }()
+	return f(container)
+}
+
+// prefetchContainers gets containers in batch for bulk operations,
+// nothing is prefetched if any of them can't be got, so errors are reported by ID
+func (c *Calcium) prefetchContainers(ctx context.Context, IDs []string) map[string]*types.Container {
+	prefetched := map[string]*types.Container{}
+	if len(IDs) < 2 {
+		return prefetched
+	}
+	containers, err := c.GetContainers(ctx, IDs)
+	if err != nil {
+		log.Warnf("[prefetchContainers] Get containers in batch failed %v, get them one by one", err)
+		return prefetched
+	}
+	for _, container := range containers {
+		prefetched[container.ID] = container
+	}
+	return prefetched
+}
+
+// withNodeLocked queues f on node, so updates of a node in this core don't contend for its lock
 func (c *Calcium) withNodeLocked(ctx context.Context, nodename string, f func(node *types.Node) error) error {
-	return c.withNodesLocked(ctx, "", nodename, nil, true, func(nodes map[string]*types.Node) error {
-		if n, ok := nodes[nodename]; ok {
-			return f(n)
-		}
-		return types.ErrNodeNotExists
+	return c.queues.do(ctx, nodename, func() error {
+		return c.withNodesLocked(ctx, "", nodename, nil, true, func(nodes map[string]*types.Node) error {
+			if n, ok := nodes[nodename]; ok {
+				return f(n)
+			}
+			return types.ErrNodeNotExists
+		})
 	})
 }
 
@@ -199,3 +199,37 @@ func TestWithNodeLocked(t *testing.T) {
 	})
 	assert.NoError(t, err)
 }
+
+func TestWithPrefetchedContainerLocked(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+
+	// one container is not prefetched
+	assert.Empty(t, c.prefetchContainers(ctx, []string{"c1"}))
+	// batch failed
+	store.On("GetContainers", mock.Anything, []string{"c1", "c2"}).Return(nil, types.ErrBadCount).Once()
+	assert.Empty(t, c.prefetchContainers(ctx, []string{"c1", "c2"}))
+	store.On("GetContainers", mock.Anything, []string{"c1", "c2"}).Return([]*types.Container{{ID: "c1"}, {ID: "c2"}}, nil).Once()
+	prefetched := c.prefetchContainers(ctx, []string{"c1", "c2"})
+	assert.Len(t, prefetched, 2)
+
+	lock := &lockmocks.DistributedLock{}
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	// prefetched container is locked without getting it again
+	assert.NoError(t, c.withPrefetchedContainerLocked(ctx, "c1", prefetched, func(container *types.Container) error {
+		assert.Equal(t, "c1", container.ID)
+		return nil
+	}))
+	store.AssertNumberOfCalls(t, "GetContainers", 2)
+	// others are got by ID
+	store.On("GetContainers", mock.Anything, []string{"c3"}).Return([]*types.Container{{ID: "c3"}}, nil).Once()
+	assert.NoError(t, c.withPrefetchedContainerLocked(ctx, "c3", prefetched, func(container *types.Container) error {
+		assert.Equal(t, "c3", container.ID)
+		return nil
+	}))
+	store.AssertNumberOfCalls(t, "GetContainers", 3)
+}
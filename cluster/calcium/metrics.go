@@ -0,0 +1,46 @@
+package calcium
+
+import (
+	"context"
+	"time"
+
+	"github.com/projecteru2/core/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// doSendNodeMetrics exports resource allocation of all nodes from store, so it's what scheduler sees
+func (c *Calcium) doSendNodeMetrics(ctx context.Context) {
+	pods, err := c.store.GetAllPods(ctx)
+	if err != nil {
+		log.Errorf("[doSendNodeMetrics] List pods failed %v", err)
+		return
+	}
+	for _, pod := range pods {
+		nodes, err := c.store.GetNodesByPod(ctx, pod.Name, nil, true)
+		if err != nil {
+			log.Errorf("[doSendNodeMetrics] List pod %s nodes failed %v", pod.Name, err)
+			continue
+		}
+		for _, node := range nodes {
+			metrics.Client.SendNodeInfo(node)
+		}
+	}
+}
+
+func (c *Calcium) watchNodeMetrics(ctx context.Context) {
+	if c.config.Metrics.NodeInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(c.config.Metrics.NodeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sctx, cancel := context.WithTimeout(ctx, c.config.GlobalTimeout)
+			c.doSendNodeMetrics(sctx)
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDoSendNodeMetrics(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+
+	// failed by GetAllPods
+	store.On("GetAllPods", mock.Anything).Return(nil, types.ErrNoETCD).Once()
+	c.doSendNodeMetrics(ctx)
+	store.AssertNotCalled(t, "GetNodesByPod", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	store.On("GetAllPods", mock.Anything).Return([]*types.Pod{{Name: "p1"}, {Name: "p2"}}, nil)
+	store.On("GetNodesByPod", mock.Anything, "p1", mock.Anything, true).Return(nil, types.ErrNoETCD)
+	store.On("GetNodesByPod", mock.Anything, "p2", mock.Anything, true).Return([]*types.Node{{Name: "n1", Podname: "p2"}}, nil)
+	c.doSendNodeMetrics(ctx)
+	store.AssertNumberOfCalls(t, "GetNodesByPod", 2)
+}
@@ -0,0 +1,182 @@
+package calcium
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// MigrateContainer moves containers of an entrypoint from other pods to opts.Podname
+// each container is recreated with resources of its own then removed, at most MaxUnavailable at the same time
+// volumes managed by drivers are attached as they are, containers with node local volumes are refused unless allowed
+func (c *Calcium) MigrateContainer(ctx context.Context, opts *types.MigrateOptions) (chan *types.MigrateMessage, error) {
+	if opts.Entrypoint == nil {
+		return nil, types.ErrNoEntryInSpec
+	}
+	if _, err := c.GetPod(ctx, opts.Podname); err != nil {
+		return nil, err
+	}
+	var containers []*types.Container
+	var err error
+	if len(opts.IDs) > 0 {
+		containers, err = c.GetContainers(ctx, opts.IDs)
+	} else {
+		containers, err = c.ListContainers(ctx, &types.ListContainersOptions{Appname: opts.Name, Entrypoint: opts.Entrypoint.Name})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *types.MigrateMessage)
+	go func() {
+		defer close(ch)
+		step := opts.MaxUnavailable
+		if step <= 0 {
+			step = 1
+		}
+		wg := sync.WaitGroup{}
+		defer wg.Wait()
+		index := 0
+		for _, container := range containers {
+			if container.Podname == opts.Podname {
+				log.Infof("[MigrateContainer] Container %s is already in pod %s", container.ID, opts.Podname)
+				continue
+			}
+			wg.Add(1)
+			go func(container *types.Container) {
+				defer wg.Done()
+				ch <- c.doMigrateContainer(ctx, opts, container)
+			}(container)
+			if index++; index%step == 0 {
+				wg.Wait()
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (c *Calcium) doMigrateContainer(ctx context.Context, opts *types.MigrateOptions, container *types.Container) *types.MigrateMessage {
+	msg := &types.MigrateMessage{ContainerID: container.ID}
+	appname, entrypoint, _, err := utils.ParseContainerName(container.Name)
+	if err != nil {
+		msg.Error = err
+		return msg
+	}
+	if appname != opts.Name || entrypoint != opts.Entrypoint.Name {
+		msg.Error = types.NewDetailedErr(types.ErrInvalidContainerName, container.Name)
+		return msg
+	}
+	if volumes := nodeLocalVolumes(container); len(volumes) > 0 && !opts.AllowLocalVolumes {
+		msg.Error = types.NewDetailedErr(types.ErrNodeLocalVolume, volumes)
+		return msg
+	}
+	log.Infof("[MigrateContainer] Migrate container %s from %s to pod %s", container.ID, container.Podname, opts.Podname)
+	msg.Create, msg.Remove, msg.Error = c.doMoveContainer(ctx, opts.DeployOptions, container, opts.Podname, "")
+	return msg
+}
+
+// MigrateContainerToNode recreates a container on node with its stored image, env, volumes, labels and resources,
+// the old one is removed only after the new one becomes healthy within timeout, otherwise the new one is removed.
+// GlobalTimeout is used if timeout is zero, containers with node local volumes are refused
+func (c *Calcium) MigrateContainerToNode(ctx context.Context, ID, nodename string, timeout time.Duration) (*types.MigrateMessage, error) {
+	container, err := c.GetContainer(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+	if container.Nodename == nodename {
+		return nil, types.NewDetailedErr(types.ErrSameNode, nodename)
+	}
+	if volumes := nodeLocalVolumes(container); len(volumes) > 0 {
+		return nil, types.NewDetailedErr(types.ErrNodeLocalVolume, volumes)
+	}
+	node, err := c.GetNode(ctx, nodename)
+	if err != nil {
+		return nil, err
+	}
+	info, err := container.Inspect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := makeCloneDeployOptions(container, info.Cmd, info.Dir, info.Labels, info.Networks)
+	if err != nil {
+		return nil, err
+	}
+	opts.Podname = node.Podname
+	opts.Nodename = node.Name
+	if timeout <= 0 {
+		timeout = c.config.GlobalTimeout
+	}
+
+	msg := &types.MigrateMessage{ContainerID: container.ID}
+	log.Infof("[MigrateContainerToNode] Migrate container %s from %s to %s", container.ID, container.Nodename, nodename)
+	msg.Error = utils.Txn(
+		ctx,
+		// if
+		func(ctx context.Context) error {
+			createCh, err := c.CreateContainer(ctx, opts)
+			if err != nil {
+				return err
+			}
+			for m := range createCh {
+				msg.Create = m
+			}
+			if msg.Create == nil {
+				return types.NewDetailedErr(types.ErrContainerNotExists, "no container created")
+			}
+			if msg.Create.Error != nil {
+				return msg.Create.Error
+			}
+			created, err := c.GetContainer(ctx, msg.Create.ContainerID)
+			if err != nil {
+				return err
+			}
+			wctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return c.doWaitContainerHealthy(wctx, created).Error
+		},
+		// then
+		func(ctx context.Context) error {
+			msg.Remove = c.doRemoveMigratedContainer(ctx, container.ID)
+			return msg.Remove.Error
+		},
+		// rollback
+		func(ctx context.Context) error {
+			if msg.Create == nil || msg.Create.Error != nil {
+				return nil
+			}
+			log.Warnf("[MigrateContainerToNode] Migrate container %s failed, remove new one %s", container.ID, msg.Create.ContainerID)
+			return c.doRemoveMigratedContainer(ctx, msg.Create.ContainerID).Error
+		},
+		c.config.GlobalTimeout+timeout,
+	)
+	return msg, nil
+}
+
+func (c *Calcium) doRemoveMigratedContainer(ctx context.Context, ID string) *types.RemoveContainerMessage {
+	remove := &types.RemoveContainerMessage{ContainerID: ID}
+	ch, err := c.RemoveContainer(ctx, []string{ID}, true, 1, 0)
+	if err != nil {
+		remove.Error = err
+		return remove
+	}
+	for m := range ch {
+		remove = m
+	}
+	return remove
+}
+
+// nodeLocalVolumes returns volumes whose data stays on node, host path and scheduled ones
+func nodeLocalVolumes(container *types.Container) []string {
+	volumes := []string{}
+	for _, vb := range container.Volumes {
+		if vb.RequireSchedule() || filepath.IsAbs(vb.Source) {
+			volumes = append(volumes, vb.ToString(false))
+		}
+	}
+	return volumes
+}
@@ -0,0 +1,126 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMigrateContainer(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := c.store.(*storemocks.Store)
+	opts := &types.MigrateOptions{
+		DeployOptions: types.DeployOptions{
+			Name:    "app",
+			Podname: "p2",
+		},
+	}
+	// failed by entrypoint
+	_, err := c.MigrateContainer(ctx, opts)
+	assert.Error(t, err)
+	opts.Entrypoint = &types.Entrypoint{Name: "web"}
+	// failed by pod
+	store.On("GetPod", mock.Anything, "p2").Return(nil, types.ErrNoETCD).Once()
+	_, err = c.MigrateContainer(ctx, opts)
+	assert.Error(t, err)
+	store.On("GetPod", mock.Anything, "p2").Return(&types.Pod{Name: "p2"}, nil)
+
+	localVolume, err := types.NewVolumeBinding("AUTO:/data:rw:100")
+	assert.NoError(t, err)
+	namedVolume, err := types.NewVolumeBinding("shared:/shared")
+	assert.NoError(t, err)
+	store.On("ListContainers", mock.Anything, "app", "web", "", mock.Anything, mock.Anything).Return([]*types.Container{
+		{ID: "c1", Name: "app_web_aaaaaa", Podname: "p2"},
+		{ID: "c2", Name: "app_web_bbbbbb", Podname: "p1", Volumes: types.VolumeBindings{localVolume, namedVolume}},
+		{ID: "c3", Name: "app_web_cccccc", Podname: "p1", Volumes: types.VolumeBindings{namedVolume}},
+	}, nil)
+	store.On("GetNodesByPod", mock.Anything, "p2", mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD)
+	store.On("GetContainers", mock.Anything, []string{"c4"}).Return([]*types.Container{{ID: "c4", Name: "other_web_dddddd", Podname: "p1"}}, nil)
+
+	// c1 is skipped, c2 refused by local volume, c3 failed by creating
+	ch, err := c.MigrateContainer(ctx, opts)
+	assert.NoError(t, err)
+	errs := map[string]error{}
+	for m := range ch {
+		errs[m.ContainerID] = m.Error
+		assert.Nil(t, m.Remove)
+	}
+	assert.Len(t, errs, 2)
+	assert.Contains(t, errs["c2"].Error(), types.ErrNodeLocalVolume.Error())
+	assert.Error(t, errs["c3"])
+
+	// local volumes allowed
+	opts.AllowLocalVolumes = true
+	ch, err = c.MigrateContainer(ctx, opts)
+	assert.NoError(t, err)
+	for m := range ch {
+		if m.ContainerID == "c2" {
+			assert.NotContains(t, m.Error.Error(), types.ErrNodeLocalVolume.Error())
+		}
+	}
+
+	// container of other app
+	opts.IDs = []string{"c4"}
+	ch, err = c.MigrateContainer(ctx, opts)
+	assert.NoError(t, err)
+	for m := range ch {
+		assert.Contains(t, m.Error.Error(), types.ErrInvalidContainerName.Error())
+	}
+}
+
+func TestMigrateContainerToNode(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	st := c.store.(*storemocks.Store)
+	engine := &enginemocks.API{}
+	localVolume, err := types.NewVolumeBinding("/host:/host")
+	assert.NoError(t, err)
+	container := &types.Container{ID: "c1", Name: "app_web_aaaaaa", Podname: "p1", Nodename: "n1", Image: "image", Memory: 10, Engine: engine}
+	st.On("GetContainer", mock.Anything, "c1").Return(container, nil)
+
+	// same node
+	_, err = c.MigrateContainerToNode(ctx, "c1", "n1", 0)
+	assert.Error(t, err)
+	// local volume
+	container.Volumes = types.VolumeBindings{localVolume}
+	_, err = c.MigrateContainerToNode(ctx, "c1", "n2", 0)
+	assert.Contains(t, err.Error(), types.ErrNodeLocalVolume.Error())
+	container.Volumes = nil
+	// failed by node
+	st.On("GetNode", mock.Anything, "n2").Return(nil, types.ErrNoETCD).Once()
+	_, err = c.MigrateContainerToNode(ctx, "c1", "n2", 0)
+	assert.Error(t, err)
+	st.On("GetNode", mock.Anything, "n2").Return(&types.Node{Name: "n2", Podname: "p2"}, nil)
+	// failed by inspecting
+	engine.On("VirtualizationInspect", mock.Anything, "c1").Return(nil, types.ErrNilEngine).Once()
+	_, err = c.MigrateContainerToNode(ctx, "c1", "n2", 0)
+	assert.Error(t, err)
+	engine.On("VirtualizationInspect", mock.Anything, "c1").Return(&enginetypes.VirtualizationInfo{Cmd: []string{"run"}}, nil)
+
+	// failed by creating, old one is kept and nothing to roll back
+	st.On("GetPod", mock.Anything, "p2").Return(nil, types.ErrNoETCD)
+	msg, err := c.MigrateContainerToNode(ctx, "c1", "n2", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "c1", msg.ContainerID)
+	assert.Error(t, msg.Error)
+	assert.Nil(t, msg.Create)
+	assert.Nil(t, msg.Remove)
+	st.AssertNotCalled(t, "GetContainers", mock.Anything, mock.Anything)
+}
+
+func TestNodeLocalVolumes(t *testing.T) {
+	container := &types.Container{}
+	for _, v := range []string{"AUTO:/data:rw:100", "/host:/host", "shared:/shared"} {
+		vb, err := types.NewVolumeBinding(v)
+		assert.NoError(t, err)
+		container.Volumes = append(container.Volumes, vb)
+	}
+	assert.Equal(t, []string{"AUTO:/data:rw:100", "/host:/host"}, nodeLocalVolumes(container))
+}
@@ -0,0 +1,214 @@
+package calcium
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultMutatorTimeout = 5 * time.Second
+
+// deployMutator changes deploy options before scheduling
+type deployMutator interface {
+	name() string
+	mutate(ctx context.Context, opts *types.DeployOptions) error
+}
+
+// newDeployMutators makes mutators in order of config,
+// a mutator with both static changes and webhook becomes two, static one first
+func newDeployMutators(configs []types.MutatorConfig) ([]deployMutator, error) {
+	mutators := []deployMutator{}
+	for _, config := range configs {
+		pods := map[string]bool{}
+		for _, pod := range config.Pods {
+			pods[pod] = true
+		}
+		if len(config.Env) > 0 || len(config.Labels) > 0 || len(config.DNS) > 0 || len(config.Volumes) > 0 {
+			mutation := &types.DeployMutation{Env: config.Env, Labels: config.Labels, DNS: config.DNS, Volumes: config.Volumes}
+			if err := validateDeployMutation(mutation); err != nil {
+				return nil, types.NewDetailedErr(err, config.Name)
+			}
+			mutators = append(mutators, &staticMutator{mutatorName: config.Name, pods: pods, mutation: mutation})
+		}
+		if config.Webhook != "" {
+			timeout := config.Timeout
+			if timeout <= 0 {
+				timeout = defaultMutatorTimeout
+			}
+			mutators = append(mutators, &webhookMutator{mutatorName: config.Name, pods: pods, url: config.Webhook, timeout: timeout, optional: config.Optional})
+		}
+	}
+	return mutators, nil
+}
+
+// mutateDeployOptions applies mutators one by one, the later ones see changes of the former
+func (c *Calcium) mutateDeployOptions(ctx context.Context, opts *types.DeployOptions) error {
+	for _, mutator := range c.mutators {
+		if err := mutator.mutate(ctx, opts); err != nil {
+			log.Errorf("[mutateDeployOptions] Mutator %s failed %v", mutator.name(), err)
+			return types.NewDetailedErr(types.ErrMutatorFailed, fmt.Sprintf("%s: %v", mutator.name(), err))
+		}
+	}
+	return nil
+}
+
+// staticMutator applies changes given in config
+type staticMutator struct {
+	mutatorName string
+	pods        map[string]bool
+	mutation    *types.DeployMutation
+}
+
+func (m *staticMutator) name() string {
+	return m.mutatorName
+}
+
+func (m *staticMutator) mutate(ctx context.Context, opts *types.DeployOptions) error {
+	if len(m.pods) > 0 && !m.pods[opts.Podname] {
+		return nil
+	}
+	return applyDeployMutation(opts, m.mutation)
+}
+
+// webhookMutator posts what is being deployed to an external service and applies changes it returns
+type webhookMutator struct {
+	mutatorName string
+	pods        map[string]bool
+	url         string
+	timeout     time.Duration
+	optional    bool
+}
+
+func (m *webhookMutator) name() string {
+	return m.mutatorName
+}
+
+func (m *webhookMutator) mutate(ctx context.Context, opts *types.DeployOptions) error {
+	if len(m.pods) > 0 && !m.pods[opts.Podname] {
+		return nil
+	}
+	mutation, err := m.call(ctx, opts)
+	if err == nil {
+		err = validateDeployMutation(mutation)
+	}
+	if err != nil {
+		if m.optional {
+			log.Warnf("[webhookMutator] Optional mutator %s failed %v, skip it", m.mutatorName, err)
+			return nil
+		}
+		return err
+	}
+	return applyDeployMutation(opts, mutation)
+}
+
+func (m *webhookMutator) call(ctx context.Context, opts *types.DeployOptions) (*types.DeployMutation, error) {
+	request := &types.DeployMutationRequest{
+		Appname: opts.Name,
+		Podname: opts.Podname,
+		Image:   opts.Image,
+		Env:     opts.Env,
+		Labels:  opts.Labels,
+		DNS:     opts.DNS,
+		Volumes: opts.Volumes.ToStringSlice(false, false),
+	}
+	if opts.Entrypoint != nil {
+		request.Entrypoint = opts.Entrypoint.Name
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code %d: %s", resp.StatusCode, b)
+	}
+	mutation := &types.DeployMutation{}
+	return mutation, json.Unmarshal(b, mutation)
+}
+
+func validateDeployMutation(mutation *types.DeployMutation) error {
+	for _, env := range mutation.Env {
+		if !strings.Contains(env, "=") {
+			return fmt.Errorf("bad env %s", env)
+		}
+	}
+	_, err := types.MakeVolumeBindings(mutation.Volumes)
+	return err
+}
+
+// applyDeployMutation adds changes not set by opts, slices and maps of opts are replaced instead of modified,
+// they may be shared by other deploy options
+func applyDeployMutation(opts *types.DeployOptions, mutation *types.DeployMutation) error {
+	if len(mutation.Env) > 0 {
+		keys := map[string]bool{}
+		for _, env := range opts.Env {
+			keys[strings.SplitN(env, "=", 2)[0]] = true
+		}
+		env := append([]string{}, opts.Env...)
+		for _, e := range mutation.Env {
+			if key := strings.SplitN(e, "=", 2)[0]; !keys[key] {
+				keys[key] = true
+				env = append(env, e)
+			}
+		}
+		opts.Env = env
+	}
+
+	if len(mutation.Labels) > 0 {
+		labels := map[string]string{}
+		for k, v := range mutation.Labels {
+			labels[k] = v
+		}
+		for k, v := range opts.Labels {
+			labels[k] = v
+		}
+		opts.Labels = labels
+	}
+
+	if len(opts.DNS) == 0 && len(mutation.DNS) > 0 {
+		opts.DNS = append([]string{}, mutation.DNS...)
+	}
+
+	if len(mutation.Volumes) > 0 {
+		volumes, err := types.MakeVolumeBindings(mutation.Volumes)
+		if err != nil {
+			return err
+		}
+		mounted := map[string]bool{}
+		for _, vb := range opts.Volumes {
+			mounted[vb.Destination] = true
+		}
+		merged := append(types.VolumeBindings{}, opts.Volumes...)
+		for _, vb := range volumes {
+			if !mounted[vb.Destination] {
+				mounted[vb.Destination] = true
+				merged = append(merged, vb)
+			}
+		}
+		opts.Volumes = merged
+	}
+	return nil
+}
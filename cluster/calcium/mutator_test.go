@@ -0,0 +1,100 @@
+package calcium
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDeployMutators(t *testing.T) {
+	mutators, err := newDeployMutators([]types.MutatorConfig{
+		{Name: "static", Env: []string{"A=1"}},
+		{Name: "both", Labels: map[string]string{"a": "1"}, Webhook: "http://mutator"},
+		{Name: "empty"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, mutators, 3)
+	assert.Equal(t, defaultMutatorTimeout, mutators[2].(*webhookMutator).timeout)
+
+	_, err = newDeployMutators([]types.MutatorConfig{{Name: "bad", Env: []string{"A"}}})
+	assert.Error(t, err)
+	_, err = newDeployMutators([]types.MutatorConfig{{Name: "bad", Volumes: []string{"/tmp"}}})
+	assert.Error(t, err)
+}
+
+func TestApplyDeployMutation(t *testing.T) {
+	volumes, err := types.MakeVolumeBindings([]string{"/data:/data"})
+	assert.NoError(t, err)
+	env := []string{"A=deploy"}
+	opts := &types.DeployOptions{
+		Env:     env,
+		Labels:  map[string]string{"a": "deploy"},
+		Volumes: volumes,
+	}
+	assert.NoError(t, applyDeployMutation(opts, &types.DeployMutation{
+		Env:     []string{"A=mutator", "B=mutator"},
+		Labels:  map[string]string{"a": "mutator", "b": "mutator"},
+		DNS:     []string{"10.0.0.53"},
+		Volumes: []string{"/logs:/data", "/logs:/logs"},
+	}))
+	assert.Equal(t, []string{"A=deploy", "B=mutator"}, opts.Env)
+	assert.Equal(t, map[string]string{"a": "deploy", "b": "mutator"}, opts.Labels)
+	assert.Equal(t, []string{"10.0.0.53"}, opts.DNS)
+	assert.Equal(t, []string{"/data:/data", "/logs:/logs"}, opts.Volumes.ToStringSlice(false, false))
+	// slices given by deploy are not modified
+	assert.Equal(t, []string{"A=deploy"}, env)
+	assert.Len(t, volumes, 1)
+
+	// dns of deploy is kept
+	assert.NoError(t, applyDeployMutation(opts, &types.DeployMutation{DNS: []string{"8.8.8.8"}}))
+	assert.Equal(t, []string{"10.0.0.53"}, opts.DNS)
+}
+
+func TestMutateDeployOptions(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	var request *types.DeployMutationRequest
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = &types.DeployMutationRequest{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(request))
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(&types.DeployMutation{Env: []string{"APP=" + request.Appname}})
+	}))
+	defer server.Close()
+
+	var err error
+	c.mutators, err = newDeployMutators([]types.MutatorConfig{
+		{Name: "static", Pods: []string{"p1"}, Labels: map[string]string{"zone": "c1"}},
+		{Name: "remote", Webhook: server.URL},
+	})
+	assert.NoError(t, err)
+
+	opts := &types.DeployOptions{Name: "app", Podname: "p1", Entrypoint: &types.Entrypoint{Name: "web"}}
+	assert.NoError(t, c.mutateDeployOptions(ctx, opts))
+	assert.Equal(t, map[string]string{"zone": "c1"}, opts.Labels)
+	assert.Equal(t, []string{"APP=app"}, opts.Env)
+	// webhook sees changes of mutators before it
+	assert.Equal(t, "web", request.Entrypoint)
+	assert.Equal(t, map[string]string{"zone": "c1"}, request.Labels)
+
+	// static mutator of other pods is skipped
+	opts = &types.DeployOptions{Name: "app", Podname: "p2"}
+	assert.NoError(t, c.mutateDeployOptions(ctx, opts))
+	assert.Nil(t, opts.Labels)
+
+	// failed webhook fails the deploy unless optional
+	status = http.StatusForbidden
+	err = c.mutateDeployOptions(ctx, &types.DeployOptions{Name: "app"})
+	assert.True(t, errors.Is(err, types.ErrMutatorFailed))
+	c.mutators[1].(*webhookMutator).optional = true
+	opts = &types.DeployOptions{Name: "app"}
+	assert.NoError(t, c.mutateDeployOptions(ctx, opts))
+	assert.Nil(t, opts.Env)
+}
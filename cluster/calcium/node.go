@@ -11,7 +11,14 @@ import (
 // AddNode adds a node
 func (c *Calcium) AddNode(ctx context.Context, opts *types.AddNodeOptions) (*types.Node, error) {
 	opts.Normalize()
-	return c.store.AddNode(ctx, opts)
+	if err := types.ValidateAnnotations(opts.Annotations); err != nil {
+		return nil, err
+	}
+	node, err := c.store.AddNode(ctx, opts)
+	if err == nil {
+		c.publishEvent(&types.Event{Kind: types.EventNodeAdded, Podname: node.Podname, Nodename: node.Name})
+	}
+	return node, err
 }
 
 // RemoveNode remove a node
@@ -38,6 +45,7 @@ func (c *Calcium) SetNode(ctx context.Context, opts *types.SetNodeOptions) (*typ
 		litter.Dump(opts)
 		opts.Normalize(node)
 		n = node
+		wasAvailable := n.Available
 		n.Available = (opts.Status == types.TriTrue) || (opts.Status == types.TriKeep && n.Available)
 		if opts.ContainersDown {
 			containers, err := c.store.ListNodeContainers(ctx, opts.Nodename, nil)
@@ -61,6 +69,13 @@ func (c *Calcium) SetNode(ctx context.Context, opts *types.SetNodeOptions) (*typ
 		if len(opts.Labels) != 0 {
 			n.Labels = opts.Labels
 		}
+		// update annotations
+		if len(opts.Annotations) != 0 {
+			if err := types.ValidateAnnotations(opts.Annotations); err != nil {
+				return err
+			}
+			n.Annotations = opts.Annotations
+		}
 		// update numa
 		if len(opts.NUMA) != 0 {
 			n.NUMA = types.NUMA(opts.NUMA)
@@ -127,6 +142,69 @@ func (c *Calcium) SetNode(ctx context.Context, opts *types.SetNodeOptions) (*typ
 				}
 			}
 		}
+		// update hugepages
+		for size, changeCount := range opts.DeltaHugepages {
+			if types.HugepageSize(size) == 0 {
+				return types.NewDetailedErr(types.ErrBadHugepages, size)
+			}
+			_, ok := n.Hugepages[size]
+			switch {
+			case !ok && changeCount > 0:
+				n.Hugepages[size] = changeCount
+				n.InitHugepages[size] = changeCount
+			case ok && changeCount == 0:
+				delete(n.Hugepages, size)
+				delete(n.InitHugepages, size)
+			case ok:
+				n.Hugepages[size] += changeCount
+				n.InitHugepages[size] += changeCount
+				if n.Hugepages[size] < 0 {
+					return types.ErrBadHugepages
+				}
+			}
+		}
+		// update gpu
+		for device, changeShare := range opts.DeltaGPU {
+			_, ok := n.GPU[device]
+			switch {
+			case !ok && changeShare > 0:
+				n.GPU[device] = changeShare
+				n.InitGPU[device] = changeShare
+			case ok && changeShare == 0:
+				delete(n.GPU, device)
+				delete(n.InitGPU, device)
+			case ok:
+				n.GPU[device] += changeShare
+				n.InitGPU[device] += changeShare
+				if n.GPU[device] < 0 {
+					return types.ErrBadGPU
+				}
+			}
+		}
+		var err error
+		if opts.ChangeEngine() {
+			err = c.store.UpdateNodeEngine(ctx, n, opts.Endpoint, opts.Ca, opts.Cert, opts.Key)
+		} else {
+			err = c.store.UpdateNode(ctx, n)
+		}
+		if err == nil && wasAvailable && !n.Available {
+			c.publishEvent(&types.Event{Kind: types.EventNodeDown, Podname: n.Podname, Nodename: n.Name})
+		}
+		return err
+	})
+}
+
+// CordonNode marks node unschedulable or schedulable again,
+// containers on a cordoned node keep running, but no new ones will be scheduled to it
+func (c *Calcium) CordonNode(ctx context.Context, nodename string, cordon bool) (*types.Node, error) {
+	var n *types.Node
+	return n, c.withNodeLocked(ctx, nodename, func(node *types.Node) error {
+		n = node
+		if n.Unschedulable == cordon {
+			return nil
+		}
+		n.Unschedulable = cordon
+		log.Infof("[CordonNode] Node %s unschedulable %v", nodename, cordon)
 		return c.store.UpdateNode(ctx, n)
 	})
 }
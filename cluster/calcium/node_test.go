@@ -131,6 +131,11 @@ func TestSetNode(t *testing.T) {
 	n, err := c.SetNode(ctx, &types.SetNodeOptions{Nodename: "test", Status: 2})
 	assert.NoError(t, err)
 	assert.Equal(t, n.Name, name)
+	// change endpoint and certs
+	store.On("UpdateNodeEngine", mock.Anything, mock.Anything, "tcp://new:2376", "ca", "cert", "key").Return(nil).Once()
+	_, err = c.SetNode(ctx, &types.SetNodeOptions{Nodename: "test", Status: 2, Endpoint: "tcp://new:2376", Ca: "ca", Cert: "cert", Key: "key"})
+	assert.NoError(t, err)
+	store.AssertCalled(t, "UpdateNodeEngine", mock.Anything, mock.Anything, "tcp://new:2376", "ca", "cert", "key")
 	// not available
 	// failed by list node containers
 	store.On("ListNodeContainers", mock.Anything, mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD).Once()
@@ -230,4 +235,31 @@ func TestSetNode(t *testing.T) {
 	assert.False(t, ok)
 	assert.Equal(t, n.Volume["/sda0"], int64(5))
 	assert.Equal(t, n.Volume["/sda2"], int64(19))
+	// failed by bad hugepage size
+	setOpts.DeltaVolume = nil
+	setOpts.DeltaHugepages = types.HugepageMap{"4Mi": 1}
+	_, err = c.SetNode(ctx, setOpts)
+	assert.Error(t, err)
+	// succ set hugepages
+	setOpts.DeltaHugepages = types.HugepageMap{types.Hugepage2Mi: 512}
+	n, err = c.SetNode(ctx, setOpts)
+	assert.NoError(t, err)
+	assert.Equal(t, n.Hugepages[types.Hugepage2Mi], int64(512))
+	assert.Equal(t, n.InitHugepages[types.Hugepage2Mi], int64(512))
+	setOpts.DeltaHugepages = types.HugepageMap{types.Hugepage2Mi: -1024}
+	_, err = c.SetNode(ctx, setOpts)
+	assert.Error(t, err)
+	// succ set gpu
+	setOpts.DeltaHugepages = nil
+	setOpts.DeltaGPU = types.GPUMap{"0": types.GPUShareBase, "1": types.GPUShareBase}
+	n, err = c.SetNode(ctx, setOpts)
+	assert.NoError(t, err)
+	assert.Equal(t, types.GPUMap{"0": types.GPUShareBase, "1": types.GPUShareBase}, n.InitGPU)
+	setOpts.DeltaGPU = types.GPUMap{"0": 0}
+	n, err = c.SetNode(ctx, setOpts)
+	assert.NoError(t, err)
+	assert.Equal(t, types.GPUMap{"1": types.GPUShareBase}, n.GPU)
+	setOpts.DeltaGPU = types.GPUMap{"1": -200}
+	_, err = c.SetNode(ctx, setOpts)
+	assert.Error(t, err)
 }
@@ -0,0 +1,108 @@
+package calcium
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	webhookEventHeader     = "X-Eru-Event"
+	webhookSignatureHeader = "X-Eru-Signature"
+)
+
+// RegisterWebhook saves a webhook lifecycle events of containers are posted to, the old one of the same name is replaced
+func (c *Calcium) RegisterWebhook(ctx context.Context, webhook *types.EventWebhook) error {
+	if err := webhook.Validate(); err != nil {
+		return err
+	}
+	return c.store.SetEventWebhook(ctx, webhook)
+}
+
+// DeregisterWebhook removes a webhook by name
+func (c *Calcium) DeregisterWebhook(ctx context.Context, name string) error {
+	return c.store.RemoveEventWebhook(ctx, name)
+}
+
+// ListWebhooks lists all webhooks
+func (c *Calcium) ListWebhooks(ctx context.Context) ([]*types.EventWebhook, error) {
+	return c.store.ListEventWebhooks(ctx)
+}
+
+// notifyWebhooks posts event to webhooks registered for it,
+// it's done by the core publishing event so each webhook is notified once
+func (c *Calcium) notifyWebhooks(event *types.Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.GlobalTimeout)
+	defer cancel()
+	webhooks, err := c.store.ListEventWebhooks(ctx)
+	if err != nil {
+		log.Errorf("[notifyWebhooks] List webhooks failed %v", err)
+		return
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Errorf("[notifyWebhooks] Marshal event failed %v", err)
+		return
+	}
+	for _, webhook := range webhooks {
+		if webhook.Match(event) {
+			go c.postWebhook(webhook, event.Kind, body)
+		}
+	}
+}
+
+// postWebhook posts body to webhook, failures and 5xx responses are retried with backoff
+func (c *Calcium) postWebhook(webhook *types.EventWebhook, kind string, body []byte) {
+	backoff := c.config.Notify.Backoff
+	for i := 0; ; i++ {
+		retriable, err := c.doPostWebhook(webhook, kind, body)
+		if err == nil {
+			return
+		}
+		if !retriable || i >= c.config.Notify.Retries {
+			log.Errorf("[postWebhook] Post %s event to webhook %s failed %v", kind, webhook.Name, err)
+			return
+		}
+		log.Warnf("[postWebhook] Post %s event to webhook %s failed %v, retry in %v", kind, webhook.Name, err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (c *Calcium) doPostWebhook(webhook *types.EventWebhook, kind string, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Notify.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, kind)
+	if webhook.Secret != "" {
+		req.Header.Set(webhookSignatureHeader, "sha256="+signWebhook(webhook.Secret, body))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp.StatusCode >= http.StatusInternalServerError, fmt.Errorf("status code %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body) // nolint
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,69 @@
+package calcium
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRegisterWebhook(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+
+	assert.True(t, errors.Is(c.RegisterWebhook(ctx, &types.EventWebhook{Name: "w1"}), types.ErrBadWebhook))
+	store.On("SetEventWebhook", mock.Anything, mock.Anything).Return(nil)
+	assert.NoError(t, c.RegisterWebhook(ctx, &types.EventWebhook{Name: "w1", URL: "http://127.0.0.1/hook"}))
+	store.On("RemoveEventWebhook", mock.Anything, "w1").Return(nil)
+	assert.NoError(t, c.DeregisterWebhook(ctx, "w1"))
+}
+
+func TestNotifyWebhooks(t *testing.T) {
+	c := NewTestCluster()
+	store := &storemocks.Store{}
+	c.store = store
+	c.config.Notify = types.NotifyConfig{Timeout: time.Second, Retries: 2, Backoff: time.Millisecond}
+
+	var calls int32
+	received := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// fails at first and succeeds on retry
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		event := &types.Event{}
+		assert.NoError(t, json.Unmarshal(body, event))
+		assert.Equal(t, "c1", event.ContainerID)
+		assert.Equal(t, "sha256="+signWebhook("secret", body), r.Header.Get(webhookSignatureHeader))
+		received <- r
+	}))
+	defer server.Close()
+
+	store.On("ListEventWebhooks", mock.Anything).Return([]*types.EventWebhook{
+		{Name: "w1", URL: server.URL, Secret: "secret", Appname: "app"},
+		{Name: "w2", URL: server.URL, Appname: "other"},
+	}, nil)
+	c.publishContainerEvent(types.EventContainerCreated, &types.Container{ID: "c1", Name: "app_web_aaaaaa"})
+	select {
+	case r := <-received:
+		assert.Equal(t, types.EventContainerCreated, r.Header.Get(webhookEventHeader))
+	case <-time.After(3 * time.Second):
+		t.Fatal("webhook not notified")
+	}
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
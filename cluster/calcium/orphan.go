@@ -0,0 +1,87 @@
+package calcium
+
+import (
+	"context"
+	"time"
+
+	"github.com/projecteru2/core/cluster"
+	"github.com/projecteru2/core/metrics"
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// doSweepOrphans finds containers labeled by eru on nodes but unknown to store
+// they are left by failed rollbacks, reported and removed if configured
+func (c *Calcium) doSweepOrphans(ctx context.Context) {
+	pods, err := c.store.GetAllPods(ctx)
+	if err != nil {
+		log.Errorf("[doSweepOrphans] List pods failed %v", err)
+		return
+	}
+	for _, pod := range pods {
+		nodes, err := c.store.GetNodesByPod(ctx, pod.Name, nil, false)
+		if err != nil {
+			log.Errorf("[doSweepOrphans] List pod %s nodes failed %v", pod.Name, err)
+			continue
+		}
+		for _, node := range nodes {
+			if _, err := c.doSweepNodeOrphans(ctx, node); err != nil && err != types.ErrEngineNotImplemented {
+				log.Errorf("[doSweepOrphans] Sweep node %s failed %v", node.Name, err)
+			}
+		}
+	}
+}
+
+func (c *Calcium) doSweepNodeOrphans(ctx context.Context, node *types.Node) ([]string, error) {
+	infos, err := node.Engine.VirtualizationList(ctx, map[string]string{cluster.ERUMark: "1"})
+	if err != nil {
+		return nil, err
+	}
+	containers, err := c.store.ListNodeContainers(ctx, node.Name, nil)
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]struct{}{}
+	for _, container := range containers {
+		known[container.ID] = struct{}{}
+	}
+
+	// container is added to store after created by engine
+	createdBefore := time.Now().Add(-c.config.Orphan.Age).Unix()
+	orphans := []string{}
+	for _, info := range infos {
+		if _, ok := known[info.ID]; ok || info.Created > createdBefore {
+			continue
+		}
+		orphans = append(orphans, info.ID)
+		if !c.config.Orphan.Remove {
+			log.Warnf("[doSweepNodeOrphans] Orphan container %s %s found on node %s", info.ID, info.Name, node.Name)
+			continue
+		}
+		if err := node.Engine.VirtualizationRemove(ctx, info.ID, true, true); err != nil {
+			log.Errorf("[doSweepNodeOrphans] Remove orphan container %s on node %s failed %v", info.ID, node.Name, err)
+			continue
+		}
+		log.Infof("[doSweepNodeOrphans] Orphan container %s %s on node %s removed", info.ID, info.Name, node.Name)
+	}
+	metrics.Client.SendOrphanContainers(node.Podname, node.Name, len(orphans))
+	return orphans, nil
+}
+
+func (c *Calcium) watchOrphans(ctx context.Context) {
+	if c.config.Orphan.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(c.config.Orphan.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sctx, cancel := context.WithTimeout(ctx, c.config.GlobalTimeout)
+			c.doSweepOrphans(sctx)
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDoSweepNodeOrphans(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	c.config.Orphan.Age = 10 * time.Minute
+
+	engine := &enginemocks.API{}
+	node := &types.Node{Name: "n1", Podname: "p1", Engine: engine}
+	old := time.Now().Add(-time.Hour).Unix()
+	infos := []*enginetypes.VirtualizationInfo{
+		{ID: "known", Created: old},
+		{ID: "orphan", Created: old},
+		{ID: "creating", Created: time.Now().Unix()},
+	}
+
+	// failed by engine
+	engine.On("VirtualizationList", mock.Anything, mock.Anything).Return(nil, types.ErrEngineNotImplemented).Once()
+	_, err := c.doSweepNodeOrphans(ctx, node)
+	assert.Equal(t, types.ErrEngineNotImplemented, err)
+	engine.On("VirtualizationList", mock.Anything, mock.Anything).Return(infos, nil)
+	// failed by store
+	store.On("ListNodeContainers", mock.Anything, "n1", mock.Anything).Return(nil, types.ErrNoETCD).Once()
+	_, err = c.doSweepNodeOrphans(ctx, node)
+	assert.Error(t, err)
+	store.On("ListNodeContainers", mock.Anything, "n1", mock.Anything).Return([]*types.Container{{ID: "known"}}, nil)
+
+	// report only
+	orphans, err := c.doSweepNodeOrphans(ctx, node)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"orphan"}, orphans)
+	engine.AssertNotCalled(t, "VirtualizationRemove", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// remove
+	c.config.Orphan.Remove = true
+	engine.On("VirtualizationRemove", mock.Anything, "orphan", true, true).Return(nil).Once()
+	orphans, err = c.doSweepNodeOrphans(ctx, node)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"orphan"}, orphans)
+	engine.AssertExpectations(t)
+}
+
+func TestDoSweepOrphans(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+
+	store.On("GetAllPods", mock.Anything).Return([]*types.Pod{{Name: "p1"}}, nil)
+	engine := &enginemocks.API{}
+	engine.On("VirtualizationList", mock.Anything, mock.Anything).Return(nil, types.ErrEngineNotImplemented)
+	store.On("GetNodesByPod", mock.Anything, "p1", mock.Anything, false).Return([]*types.Node{{Name: "n1", Engine: engine}, {Name: "n2", Engine: engine}}, nil)
+	c.doSweepOrphans(ctx)
+	engine.AssertNumberOfCalls(t, "VirtualizationList", 2)
+	store.AssertNotCalled(t, "ListNodeContainers", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -2,6 +2,7 @@ package calcium
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/projecteru2/core/types"
 )
@@ -25,6 +26,113 @@ func (c *Calcium) GetPod(ctx context.Context, podname string) (*types.Pod, error
 	return c.store.GetPod(ctx, podname)
 }
 
+// SetPodPlacement set placement policy of a pod
+// containers will be spread or packed when deploying by DeployAuto or DeployGlobal
+func (c *Calcium) SetPodPlacement(ctx context.Context, podname, placement string) (*types.Pod, error) {
+	if err := types.ValidatePlacement(placement); err != nil {
+		return nil, err
+	}
+	pod, err := c.store.GetPod(ctx, podname)
+	if err != nil {
+		return nil, err
+	}
+	pod.Placement = placement
+	return pod, c.store.UpdatePod(ctx, pod)
+}
+
+// SetPodHook set or clear (when hook is nil) default hooks of a pod
+// containers created afterwards run them along with their own hooks, unless entrypoint skips pod hook
+func (c *Calcium) SetPodHook(ctx context.Context, podname string, hook *types.Hook) (*types.Pod, error) {
+	if hook != nil {
+		if err := hook.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	pod, err := c.store.GetPod(ctx, podname)
+	if err != nil {
+		return nil, err
+	}
+	pod.Hook = hook
+	return pod, c.store.UpdatePod(ctx, pod)
+}
+
+// SetPodLocale set or clear (when empty) default timezone and locale of a pod
+// containers created afterwards use them unless deploy sets its own
+func (c *Calcium) SetPodLocale(ctx context.Context, podname, timezone, locale string) (*types.Pod, error) {
+	if err := types.ValidateTimezone(timezone); err != nil {
+		return nil, err
+	}
+	if err := types.ValidateLocale(locale); err != nil {
+		return nil, err
+	}
+	pod, err := c.store.GetPod(ctx, podname)
+	if err != nil {
+		return nil, err
+	}
+	pod.Timezone = timezone
+	pod.Locale = locale
+	return pod, c.store.UpdatePod(ctx, pod)
+}
+
+// SetPodSysctlAllowlist set or clear (when empty) sysctls containers in a pod may set
+func (c *Calcium) SetPodSysctlAllowlist(ctx context.Context, podname string, allowlist []string) (*types.Pod, error) {
+	if err := types.ValidateSysctlAllowlist(allowlist); err != nil {
+		return nil, err
+	}
+	pod, err := c.store.GetPod(ctx, podname)
+	if err != nil {
+		return nil, err
+	}
+	pod.SysctlAllowlist = allowlist
+	return pod, c.store.UpdatePod(ctx, pod)
+}
+
+// SetPodDeviceAllowlist set or clear (when empty) host devices containers in a pod may be granted
+func (c *Calcium) SetPodDeviceAllowlist(ctx context.Context, podname string, allowlist []string) (*types.Pod, error) {
+	if err := types.ValidateDeviceAllowlist(allowlist); err != nil {
+		return nil, err
+	}
+	pod, err := c.store.GetPod(ctx, podname)
+	if err != nil {
+		return nil, err
+	}
+	pod.DeviceAllowlist = allowlist
+	return pod, c.store.UpdatePod(ctx, pod)
+}
+
+// doFillPodDefaults fills defaults of pod into deploy options,
+// hooks unless entrypoint skips pod hook, timezone and locale unless deploy sets them
+// then applies timezone and locale to env and volumes, and checks sysctls and devices against allowlists of pod
+func (c *Calcium) doFillPodDefaults(ctx context.Context, opts *types.DeployOptions) error {
+	opts.PodHook = nil
+	fillHook := opts.Entrypoint != nil && !opts.Entrypoint.SkipPodHook
+	hasSysctls := opts.Entrypoint != nil && len(opts.Entrypoint.Sysctls) > 0
+	if fillHook || hasSysctls || len(opts.Devices) > 0 || opts.Timezone == "" || opts.Locale == "" {
+		pod, err := c.store.GetPod(ctx, opts.Podname)
+		if err != nil {
+			return err
+		}
+		if fillHook {
+			opts.PodHook = pod.Hook
+		}
+		if opts.Timezone == "" {
+			opts.Timezone = pod.Timezone
+		}
+		if opts.Locale == "" {
+			opts.Locale = pod.Locale
+		}
+		if hasSysctls {
+			if err := types.ValidateSysctls(opts.Entrypoint.Sysctls, pod.SysctlAllowlist); err != nil {
+				return types.NewDetailedErr(err, fmt.Sprintf("pod %s", pod.Name))
+			}
+		}
+		if err := types.ValidateDevices(opts.Devices, pod.DeviceAllowlist); err != nil {
+			return types.NewDetailedErr(err, fmt.Sprintf("pod %s", pod.Name))
+		}
+	}
+	return applyDeployMutation(opts, types.LocaleMutation(opts.Timezone, opts.Locale))
+}
+
 // ListPods show pods
 func (c *Calcium) ListPods(ctx context.Context) ([]*types.Pod, error) {
 	return c.store.GetAllPods(ctx)
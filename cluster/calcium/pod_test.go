@@ -2,6 +2,7 @@ package calcium
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	lockmocks "github.com/projecteru2/core/lock/mocks"
@@ -77,3 +78,126 @@ func TestGetPod(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, p.Name, name)
 }
+
+func TestSetPodPlacement(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+
+	store := &storemocks.Store{}
+	c.store = store
+	_, err := c.SetPodPlacement(ctx, "test", "random")
+	assert.Error(t, err)
+
+	store.On("GetPod", mock.Anything, mock.Anything).Return(&types.Pod{Name: "test"}, nil)
+	store.On("UpdatePod", mock.Anything, mock.Anything).Return(nil)
+	p, err := c.SetPodPlacement(ctx, "test", types.PlacementPack)
+	assert.NoError(t, err)
+	assert.Equal(t, p.Placement, types.PlacementPack)
+}
+
+func TestSetPodHook(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+
+	store := &storemocks.Store{}
+	c.store = store
+	_, err := c.SetPodHook(ctx, "test", &types.Hook{Type: "grpc"})
+	assert.Error(t, err)
+
+	store.On("GetPod", mock.Anything, mock.Anything).Return(&types.Pod{Name: "test"}, nil)
+	store.On("UpdatePod", mock.Anything, mock.Anything).Return(nil)
+	p, err := c.SetPodHook(ctx, "test", &types.Hook{AfterStart: []string{"register"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"register"}, p.Hook.AfterStart)
+	p, err = c.SetPodHook(ctx, "test", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, p.Hook)
+}
+
+func TestSetPodLocale(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+
+	store := &storemocks.Store{}
+	c.store = store
+	_, err := c.SetPodLocale(ctx, "test", "Mars/Olympus", "")
+	assert.True(t, errors.Is(err, types.ErrBadTimezone))
+	_, err = c.SetPodLocale(ctx, "test", "", "english")
+	assert.True(t, errors.Is(err, types.ErrBadLocale))
+
+	store.On("GetPod", mock.Anything, mock.Anything).Return(&types.Pod{Name: "test"}, nil)
+	store.On("UpdatePod", mock.Anything, mock.Anything).Return(nil)
+	p, err := c.SetPodLocale(ctx, "test", "Asia/Shanghai", "en_US.UTF-8")
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Shanghai", p.Timezone)
+	assert.Equal(t, "en_US.UTF-8", p.Locale)
+}
+
+func TestSetPodSysctlAllowlist(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+
+	store := &storemocks.Store{}
+	c.store = store
+	_, err := c.SetPodSysctlAllowlist(ctx, "test", []string{"net"})
+	assert.True(t, errors.Is(err, types.ErrBadSysctl))
+
+	store.On("GetPod", mock.Anything, mock.Anything).Return(&types.Pod{Name: "test"}, nil)
+	store.On("UpdatePod", mock.Anything, mock.Anything).Return(nil)
+	p, err := c.SetPodSysctlAllowlist(ctx, "test", []string{"net.*"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"net.*"}, p.SysctlAllowlist)
+}
+
+func TestSetPodDeviceAllowlist(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+
+	store := &storemocks.Store{}
+	c.store = store
+	_, err := c.SetPodDeviceAllowlist(ctx, "test", []string{"dev/fuse"})
+	assert.True(t, errors.Is(err, types.ErrBadDevice))
+
+	store.On("GetPod", mock.Anything, mock.Anything).Return(&types.Pod{Name: "test"}, nil)
+	store.On("UpdatePod", mock.Anything, mock.Anything).Return(nil)
+	p, err := c.SetPodDeviceAllowlist(ctx, "test", []string{"/dev/fuse"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/dev/fuse"}, p.DeviceAllowlist)
+}
+
+func TestFillPodDefaults(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+
+	store := &storemocks.Store{}
+	c.store = store
+	hook := &types.Hook{AfterStart: []string{"register"}}
+	store.On("GetPod", mock.Anything, mock.Anything).Return(&types.Pod{Name: "test", Hook: hook, Timezone: "Asia/Shanghai"}, nil).Once()
+	opts := &types.DeployOptions{Podname: "test", Entrypoint: &types.Entrypoint{}, Locale: "C.UTF-8"}
+	assert.NoError(t, c.doFillPodDefaults(ctx, opts))
+	assert.Equal(t, hook, opts.PodHook)
+	assert.Equal(t, []string{"TZ=Asia/Shanghai", "LANG=C.UTF-8", "LC_ALL=C.UTF-8"}, opts.Env)
+	assert.Equal(t, []string{"/usr/share/zoneinfo/Asia/Shanghai:/etc/localtime:ro:0"}, opts.Volumes.ToStringSlice(false, false))
+
+	// pod is not needed if hook is skipped and deploy sets both
+	opts.Entrypoint.SkipPodHook = true
+	assert.NoError(t, c.doFillPodDefaults(ctx, opts))
+	assert.Nil(t, opts.PodHook)
+	assert.Len(t, opts.Env, 3)
+	assert.Len(t, opts.Volumes, 1)
+	store.AssertExpectations(t)
+
+	// sysctls are checked against allowlist of pod
+	store.On("GetPod", mock.Anything, mock.Anything).Return(&types.Pod{Name: "test", SysctlAllowlist: []string{"net.*"}}, nil)
+	opts.Entrypoint.Sysctls = map[string]string{"net.core.somaxconn": "1024"}
+	assert.NoError(t, c.doFillPodDefaults(ctx, opts))
+	opts.Entrypoint.Sysctls["kernel.msgmax"] = "65536"
+	err := c.doFillPodDefaults(ctx, opts)
+	assert.True(t, errors.Is(err, types.ErrForbiddenSysctl))
+	assert.Contains(t, err.Error(), "kernel.msgmax")
+
+	// devices are checked against allowlist of pod
+	opts.Entrypoint.Sysctls = nil
+	opts.Devices = []*types.Device{{PathOnHost: "/dev/fuse"}}
+	assert.True(t, errors.Is(c.doFillPodDefaults(ctx, opts), types.ErrForbiddenDevice))
+}
@@ -0,0 +1,131 @@
+package calcium
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// preemptible tells whether a deploy failed by resources may evict containers of lower priority
+func preemptible(opts *types.DeployOptions, err error) bool {
+	if opts.Priority <= 0 || opts.ReservationID != "" {
+		return false
+	}
+	return errors.Is(err, types.ErrInsufficientRes) || errors.Is(err, types.ErrInsufficientCap) || isInsufficientErr(err)
+}
+
+// preemptResource is free cpu, memory and storage of a node
+type preemptResource struct {
+	cpu     float64
+	memory  int64
+	storage int64
+}
+
+// fits counts containers of opts fitting into free resource
+func (r preemptResource) fits(opts *types.DeployOptions) int {
+	n := math.MaxInt32
+	if opts.CPUQuota > 0 {
+		n = int(math.Min(float64(n), math.Floor(r.cpu/opts.CPUQuota+1e-6)))
+	}
+	if opts.Memory > 0 {
+		n = int(math.Min(float64(n), float64(r.memory/opts.Memory)))
+	}
+	if opts.Storage > 0 {
+		n = int(math.Min(float64(n), float64(r.storage/opts.Storage)))
+	}
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// doPreempt evicts containers of lower priority than opts in its pod, so opts can be deployed then,
+// victims are picked from the lowest priority, by estimation of cpu, memory and storage only,
+// nothing is evicted if all candidates are not enough, returns IDs of evicted containers
+func (c *Calcium) doPreempt(ctx context.Context, opts *types.DeployOptions) ([]string, error) {
+	if opts.CPUQuota <= 0 && opts.Memory <= 0 && opts.Storage <= 0 {
+		return nil, nil
+	}
+	nodes, err := c.store.GetNodesByPod(ctx, opts.Podname, opts.NodeLabels, false)
+	if err != nil {
+		return nil, err
+	}
+
+	free := map[string]preemptResource{}
+	candidates := []*types.Container{}
+	total := 0
+	for _, node := range nodes {
+		if node.Unschedulable || (opts.Nodename != "" && node.Name != opts.Nodename) {
+			continue
+		}
+		free[node.Name] = preemptResource{
+			cpu:     float64(len(node.InitCPU)) - node.CPUUsed,
+			memory:  node.MemCap,
+			storage: node.AvailableStorage(),
+		}
+		total += free[node.Name].fits(opts)
+		containers, err := c.store.ListNodeContainers(ctx, node.Name, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, container := range containers {
+			if container.Priority < opts.Priority {
+				candidates = append(candidates, container)
+			}
+		}
+	}
+	if total >= opts.Count {
+		// not short of these resources, evicting won't help
+		return nil, nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Priority < candidates[j].Priority })
+
+	victims := map[string][]string{}
+	freed := map[string]preemptResource{}
+	for name, r := range free {
+		freed[name] = r
+	}
+	for _, container := range candidates {
+		if total >= opts.Count {
+			break
+		}
+		r := freed[container.Nodename]
+		before := r.fits(opts)
+		r.cpu += container.Quota
+		r.memory += container.Memory
+		r.storage += container.Storage
+		freed[container.Nodename] = r
+		victims[container.Nodename] = append(victims[container.Nodename], container.ID)
+		total += r.fits(opts) - before
+	}
+	if total < opts.Count {
+		log.Warnf("[doPreempt] Not enough containers of lower priority than %d in pod %s to preempt", opts.Priority, opts.Podname)
+		return nil, nil
+	}
+
+	IDs := []string{}
+	for nodename, nodeVictims := range victims {
+		// victims not making room on their node are spared
+		if freed[nodename].fits(opts) > free[nodename].fits(opts) {
+			IDs = append(IDs, nodeVictims...)
+		}
+	}
+	ch, err := c.RemoveContainer(ctx, IDs, true, len(IDs), 0)
+	if err != nil {
+		return nil, err
+	}
+	preempted := []string{}
+	for m := range ch {
+		if !m.Success {
+			log.Errorf("[doPreempt] Evict container %s failed %v", m.ContainerID, m.Error)
+			continue
+		}
+		log.Infof("[doPreempt] Container %s preempted by %s of priority %d", m.ContainerID, opts.Name, opts.Priority)
+		preempted = append(preempted, m.ContainerID)
+	}
+	return preempted, nil
+}
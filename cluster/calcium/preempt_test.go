@@ -0,0 +1,66 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	lockmocks "github.com/projecteru2/core/lock/mocks"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPreemptible(t *testing.T) {
+	opts := &types.DeployOptions{}
+	assert.False(t, preemptible(opts, types.ErrInsufficientMEM))
+	opts.Priority = 1
+	assert.True(t, preemptible(opts, types.NewDetailedErr(types.ErrInsufficientMEM, "n1")))
+	assert.True(t, preemptible(opts, types.ErrInsufficientRes))
+	assert.False(t, preemptible(opts, types.ErrNoETCD))
+	opts.ReservationID = "r1"
+	assert.False(t, preemptible(opts, types.ErrInsufficientRes))
+}
+
+func TestDoPreempt(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+	engine := &enginemocks.API{}
+	engine.On("VirtualizationRemove", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// both nodes are full
+	cpu := types.CPUMap{"0": 100, "1": 100}
+	n1 := &types.Node{Name: "n1", InitCPU: cpu, CPUUsed: 2, Engine: engine}
+	n2 := &types.Node{Name: "n2", InitCPU: cpu, CPUUsed: 2, Engine: engine}
+	store.On("GetNodesByPod", mock.Anything, "p1", mock.Anything, false).Return([]*types.Node{n1, n2}, nil)
+	low := &types.Container{ID: "low", Name: "app_web_aaaaaa", Podname: "p1", Nodename: "n1", Quota: 1, Engine: engine}
+	lowest := &types.Container{ID: "lowest", Name: "app_web_bbbbbb", Podname: "p1", Nodename: "n2", Quota: 0.5, Priority: -1, Engine: engine}
+	same := &types.Container{ID: "same", Name: "app_web_cccccc", Podname: "p1", Nodename: "n1", Quota: 1, Priority: 5, Engine: engine}
+	store.On("ListNodeContainers", mock.Anything, "n1", mock.Anything).Return([]*types.Container{low, same}, nil)
+	store.On("ListNodeContainers", mock.Anything, "n2", mock.Anything).Return([]*types.Container{lowest}, nil)
+
+	// nothing is evicted if candidates are not enough
+	opts := &types.DeployOptions{Name: "app", Podname: "p1", Priority: 5, CPUQuota: 1, Count: 2}
+	preempted, err := c.doPreempt(ctx, opts)
+	assert.NoError(t, err)
+	assert.Empty(t, preempted)
+
+	// lowest is picked first but spared since it makes no room
+	opts.Count = 1
+	store.On("GetContainers", mock.Anything, []string{"low"}).Return([]*types.Container{low}, nil)
+	store.On("GetNode", mock.Anything, "n1").Return(n1, nil)
+	store.On("RemoveContainer", mock.Anything, low).Return(nil)
+	store.On("UpdateNodeResource", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	store.On("ChargeQuota", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	preempted, err = c.doPreempt(ctx, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"low"}, preempted)
+	store.AssertNotCalled(t, "RemoveContainer", mock.Anything, lowest)
+}
@@ -0,0 +1,135 @@
+package calcium
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	enginetypes "github.com/projecteru2/core/engine/types"
+	"github.com/projecteru2/core/metrics"
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// imagePuller limits concurrent pulls of core and of each node,
+// identical pulls on a node share the one in flight
+type imagePuller struct {
+	global   chan struct{}
+	perNode  int
+	nodes    sync.Map                             // nodename -> chan struct{}
+	inflight sync.Map                             // nodename/image -> *pullTask
+	onPulled func(node *types.Node, image string) // called once image is pulled, not if it's cached
+}
+
+type pullTask struct {
+	done chan struct{}
+	err  error
+}
+
+// pullProgress is a line of pull stream, unknown lines are ignored
+type pullProgress struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
+func newImagePuller(config types.ImagePullConfig) *imagePuller {
+	p := &imagePuller{perNode: config.NodeConcurrency}
+	if config.MaxConcurrency > 0 {
+		p.global = make(chan struct{}, config.MaxConcurrency)
+	}
+	return p
+}
+
+// pull image on node with auth, waits for the same pull if it's in flight
+func (p *imagePuller) pull(ctx context.Context, node *types.Node, image string, auth *enginetypes.AuthConfig) error {
+	key := node.Name + "/" + image
+	task := &pullTask{done: make(chan struct{})}
+	if v, loaded := p.inflight.LoadOrStore(key, task); loaded {
+		log.Infof("[imagePuller] Image %s is pulling on node %s, wait for it", image, node.Name)
+		task = v.(*pullTask)
+		select {
+		case <-task.done:
+			return task.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	defer func() {
+		p.inflight.Delete(key)
+		close(task.done)
+	}()
+
+	start := time.Now()
+	release, err := p.acquire(ctx, node.Name)
+	if err != nil {
+		task.err = err
+		return err
+	}
+	defer release()
+	wait := time.Since(start)
+	pulled, err := pullImage(ctx, node, image, auth)
+	task.err = err
+	metrics.Client.SendImagePull(node.Name, wait, time.Since(start)-wait, task.err)
+	if pulled && p.onPulled != nil {
+		p.onPulled(node, image)
+	}
+	return task.err
+}
+
+// acquire a slot of core and of node, release must be called after pulling
+func (p *imagePuller) acquire(ctx context.Context, nodename string) (func(), error) {
+	slots := []chan struct{}{}
+	if p.global != nil {
+		slots = append(slots, p.global)
+	}
+	if p.perNode > 0 {
+		v, _ := p.nodes.LoadOrStore(nodename, make(chan struct{}, p.perNode))
+		slots = append(slots, v.(chan struct{}))
+	}
+	release := func(n int) {
+		for _, slot := range slots[:n] {
+			<-slot
+		}
+	}
+	for i, slot := range slots {
+		select {
+		case slot <- struct{}{}:
+		case <-ctx.Done():
+			release(i)
+			return nil, ctx.Err()
+		}
+	}
+	return func() { release(len(slots)) }, nil
+}
+
+// logPullProgress logs status of layers until stream ends,
+// frequent downloading and extracting lines are skipped
+func logPullProgress(nodename, image string, stream io.ReadCloser) {
+	if stream == nil {
+		return
+	}
+	defer utils.EnsureReaderClosed(stream)
+	decoder := json.NewDecoder(stream)
+	for {
+		progress := &pullProgress{}
+		if err := decoder.Decode(progress); err != nil {
+			if err != io.EOF {
+				log.Debugf("[logPullProgress] Stop decoding progress of %s on %s: %v", image, nodename, err)
+			}
+			return
+		}
+		switch {
+		case progress.Error != "":
+			log.Warnf("[logPullProgress] Pull %s on %s: %s", image, nodename, progress.Error)
+		case progress.Status == "Downloading" || progress.Status == "Extracting" || progress.Status == "Waiting":
+		case progress.ID != "":
+			log.Infof("[logPullProgress] Pull %s on %s: %s %s", image, nodename, progress.ID, progress.Status)
+		default:
+			log.Infof("[logPullProgress] Pull %s on %s: %s", image, nodename, progress.Status)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package calcium
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestImagePullerDedup(t *testing.T) {
+	p := newImagePuller(types.ImagePullConfig{MaxConcurrency: 1, NodeConcurrency: 1})
+	engine := &enginemocks.API{}
+	engine.On("ImageLocalDigests", mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD)
+	progress := `{"status":"Pulling from library/alpine","id":"latest"}
+{"status":"Downloading","id":"a1"}
+{"status":"Pull complete","id":"a1"}
+`
+	engine.On("ImagePull", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(ioutil.NopCloser(bytes.NewReader([]byte(progress))), nil).
+		After(100 * time.Millisecond).Once()
+	node := &types.Node{Name: "n1", Engine: engine}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, p.pull(context.Background(), node, "alpine", nil))
+		}()
+	}
+	wg.Wait()
+	engine.AssertNumberOfCalls(t, "ImagePull", 1)
+}
+
+func TestImagePullerLimit(t *testing.T) {
+	p := newImagePuller(types.ImagePullConfig{MaxConcurrency: 2, NodeConcurrency: 1})
+	release, err := p.acquire(context.Background(), "n1")
+	assert.NoError(t, err)
+
+	// node slot is taken
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = p.acquire(ctx, "n1")
+	assert.Error(t, err)
+	// global slot taken by failed acquire is released
+	release2, err := p.acquire(context.Background(), "n2")
+	assert.NoError(t, err)
+
+	release()
+	release2()
+	release, err = p.acquire(context.Background(), "n1")
+	assert.NoError(t, err)
+	release()
+
+	// unlimited
+	p = newImagePuller(types.ImagePullConfig{})
+	release, err = p.acquire(context.Background(), "n1")
+	assert.NoError(t, err)
+	release()
+}
@@ -0,0 +1,75 @@
+package calcium
+
+import (
+	"context"
+	"sync"
+)
+
+// nodeQueues runs updates of each node one by one in order of arrival,
+// updates of different nodes run in parallel.
+// a worker is started for a node with pending jobs and exits when they are done
+type nodeQueues struct {
+	sync.Mutex
+	queues map[string][]*queueJob
+}
+
+type queueJob struct {
+	ctx      context.Context
+	f        func() error
+	done     chan error
+	started  bool
+	canceled bool
+}
+
+func newNodeQueues() *nodeQueues {
+	return &nodeQueues{queues: map[string][]*queueJob{}}
+}
+
+// do queues f on node and waits for its result,
+// f is skipped if ctx is done before it starts, once started it always runs to the end
+func (q *nodeQueues) do(ctx context.Context, nodename string, f func() error) error {
+	job := &queueJob{ctx: ctx, f: f, done: make(chan error, 1)}
+	q.Lock()
+	jobs, working := q.queues[nodename]
+	q.queues[nodename] = append(jobs, job)
+	if !working {
+		go q.work(nodename)
+	}
+	q.Unlock()
+
+	select {
+	case err := <-job.done:
+		return err
+	case <-ctx.Done():
+		q.Lock()
+		if !job.started {
+			job.canceled = true
+			q.Unlock()
+			return ctx.Err()
+		}
+		q.Unlock()
+		return <-job.done
+	}
+}
+
+func (q *nodeQueues) work(nodename string) {
+	for {
+		q.Lock()
+		jobs := q.queues[nodename]
+		if len(jobs) == 0 {
+			delete(q.queues, nodename)
+			q.Unlock()
+			return
+		}
+		job := jobs[0]
+		q.queues[nodename] = jobs[1:]
+		if job.canceled || job.ctx.Err() != nil {
+			q.Unlock()
+			job.done <- job.ctx.Err()
+			continue
+		}
+		job.started = true
+		q.Unlock()
+		job.done <- job.f()
+	}
+}
@@ -0,0 +1,74 @@
+package calcium
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeQueuesOrder(t *testing.T) {
+	q := newNodeQueues()
+	ctx := context.Background()
+	order := []int{}
+	block := make(chan struct{})
+	started := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		q.do(ctx, "n1", func() error { close(started); <-block; return nil })
+	}()
+	<-started
+	for i := 0; i < 10; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.do(ctx, "n1", func() error { order = append(order, i); return nil })
+		}()
+		// queued in order
+		for q.len("n1") != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	// other nodes are not blocked
+	assert.NoError(t, q.do(ctx, "n2", func() error { return nil }))
+	close(block)
+	wg.Wait()
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, order)
+	// workers exit
+	q.Lock()
+	assert.Empty(t, q.queues)
+	q.Unlock()
+}
+
+func TestNodeQueuesCancel(t *testing.T) {
+	q := newNodeQueues()
+	block := make(chan struct{})
+	started := make(chan struct{})
+	go q.do(context.Background(), "n1", func() error { close(started); <-block; return nil })
+	<-started
+
+	// canceled before started, skipped
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	called := false
+	assert.Equal(t, context.DeadlineExceeded, q.do(ctx, "n1", func() error { called = true; return nil }))
+	close(block)
+	assert.NoError(t, q.do(context.Background(), "n1", func() error { return nil }))
+	assert.False(t, called)
+
+	// canceled after started, wait for the result
+	ctx, cancel = context.WithCancel(context.Background())
+	err := q.do(ctx, "n1", func() error { cancel(); time.Sleep(10 * time.Millisecond); return nil })
+	assert.NoError(t, err)
+}
+
+func (q *nodeQueues) len(nodename string) int {
+	q.Lock()
+	defer q.Unlock()
+	return len(q.queues[nodename])
+}
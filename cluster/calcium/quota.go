@@ -0,0 +1,132 @@
+package calcium
+
+import (
+	"context"
+	"errors"
+
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// SetQuota saves a quota, usage is kept if it's replaced, or counted from existing containers if it's new
+func (c *Calcium) SetQuota(ctx context.Context, quota *types.Quota) error {
+	if err := quota.Validate(); err != nil {
+		return err
+	}
+	_, err := c.store.GetQuota(ctx, quota.Scope, quota.Name)
+	switch {
+	case errors.Is(err, types.ErrBadCount):
+		if quota.Used, err = c.countQuotaUsage(ctx, quota.Scope, quota.Name); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	}
+	return c.store.SetQuota(ctx, quota)
+}
+
+// ListQuotas lists all quotas
+func (c *Calcium) ListQuotas(ctx context.Context) ([]*types.Quota, error) {
+	return c.store.ListQuotas(ctx)
+}
+
+// RemoveQuota removes a quota, containers of its scope are unlimited then
+func (c *Calcium) RemoveQuota(ctx context.Context, scope, name string) error {
+	return c.store.RemoveQuota(ctx, scope, name)
+}
+
+func (c *Calcium) countQuotaUsage(ctx context.Context, scope, name string) (types.QuotaUsage, error) {
+	used := types.QuotaUsage{}
+	var containers []*types.Container
+	switch scope {
+	case types.QuotaScopeApp:
+		var err error
+		if containers, err = c.store.ListContainers(ctx, name, "", "", 0, nil); err != nil {
+			return used, err
+		}
+	case types.QuotaScopePod:
+		if _, err := c.store.GetPod(ctx, name); err != nil {
+			return used, err
+		}
+		nodes, err := c.store.GetNodesByPod(ctx, name, nil, true)
+		if err != nil {
+			return used, err
+		}
+		for _, node := range nodes {
+			nodeContainers, err := c.store.ListNodeContainers(ctx, node.Name, nil)
+			if err != nil {
+				return used, err
+			}
+			containers = append(containers, nodeContainers...)
+		}
+	}
+	for _, container := range containers {
+		used = used.Add(containerQuotaUsage(container))
+	}
+	return used, nil
+}
+
+// chargeQuotas charges delta to quotas of pod and app, nothing is charged if either is exceeded
+func (c *Calcium) chargeQuotas(ctx context.Context, podname, appname string, delta types.QuotaUsage) error {
+	if err := c.store.ChargeQuota(ctx, types.QuotaScopePod, podname, delta); err != nil {
+		return err
+	}
+	if err := c.store.ChargeQuota(ctx, types.QuotaScopeApp, appname, delta); err != nil {
+		c.refundQuota(ctx, types.QuotaScopePod, podname, delta)
+		return err
+	}
+	return nil
+}
+
+// refundQuotas gives usage back to quotas of pod and app
+func (c *Calcium) refundQuotas(ctx context.Context, podname, appname string, usage types.QuotaUsage) {
+	c.refundQuota(ctx, types.QuotaScopePod, podname, usage)
+	c.refundQuota(ctx, types.QuotaScopeApp, appname, usage)
+}
+
+func (c *Calcium) refundQuota(ctx context.Context, scope, name string, usage types.QuotaUsage) {
+	if err := c.store.ChargeQuota(ctx, scope, name, usage.Neg()); err != nil {
+		log.Errorf("[refundQuota] Refund %v to quota of %s %s failed %v", usage, scope, name, err)
+	}
+}
+
+// chargeContainerQuotas charges delta of a container to quotas of its pod and app
+func (c *Calcium) chargeContainerQuotas(ctx context.Context, container *types.Container, delta types.QuotaUsage) error {
+	appname, _, _, err := utils.ParseContainerName(container.Name)
+	if err != nil {
+		return err
+	}
+	return c.chargeQuotas(ctx, container.Podname, appname, delta)
+}
+
+// refundContainerQuotas gives usage of a container back to quotas of its pod and app
+func (c *Calcium) refundContainerQuotas(ctx context.Context, container *types.Container, usage types.QuotaUsage) {
+	appname, _, _, err := utils.ParseContainerName(container.Name)
+	if err != nil {
+		log.Errorf("[refundContainerQuotas] Bad container name %s %v", container.Name, err)
+		return
+	}
+	c.refundQuotas(ctx, container.Podname, appname, usage)
+}
+
+// refundFailedQuotas gives usage of containers failed to create on a node back
+func (c *Calcium) refundFailedQuotas(ctx context.Context, opts *types.DeployOptions, ms []*types.CreateContainerMessage) {
+	failed := 0
+	for _, m := range ms {
+		if m.Error != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		c.refundQuotas(ctx, opts.Podname, opts.Name, deployQuotaUsage(opts).Times(failed))
+	}
+}
+
+func containerQuotaUsage(container *types.Container) types.QuotaUsage {
+	return types.QuotaUsage{CPU: container.Quota, Memory: container.Memory, Storage: container.Storage, Volume: container.Volumes.TotalSize(), Containers: 1}
+}
+
+func deployQuotaUsage(opts *types.DeployOptions) types.QuotaUsage {
+	return types.QuotaUsage{CPU: opts.CPUQuota, Memory: opts.Memory, Storage: opts.Storage, Volume: opts.Volumes.TotalSize(), Containers: 1}
+}
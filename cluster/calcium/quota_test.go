@@ -0,0 +1,59 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSetQuota(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+
+	assert.Error(t, c.SetQuota(ctx, &types.Quota{Scope: "node", Name: "n1"}))
+
+	// pod must exist
+	store.On("GetQuota", mock.Anything, types.QuotaScopePod, "p1").Return(nil, types.ErrBadCount).Once()
+	store.On("GetPod", mock.Anything, "p1").Return(nil, types.ErrBadCount).Once()
+	assert.Error(t, c.SetQuota(ctx, &types.Quota{Scope: types.QuotaScopePod, Name: "p1"}))
+
+	// new one counts containers on all nodes of pod, volumes included
+	volume, err := types.NewVolumeBinding("AUTO:/data:rw:100")
+	assert.NoError(t, err)
+	quota := &types.Quota{Scope: types.QuotaScopePod, Name: "p1", Limit: types.QuotaUsage{Volume: 1000}}
+	store.On("GetQuota", mock.Anything, types.QuotaScopePod, "p1").Return(nil, types.ErrBadCount).Once()
+	store.On("GetPod", mock.Anything, "p1").Return(&types.Pod{Name: "p1"}, nil).Once()
+	store.On("GetNodesByPod", mock.Anything, "p1", mock.Anything, true).Return([]*types.Node{{Name: "n1"}, {Name: "n2"}}, nil).Once()
+	store.On("ListNodeContainers", mock.Anything, "n1", mock.Anything).Return([]*types.Container{{Quota: 1, Storage: 100, Volumes: types.VolumeBindings{volume}}}, nil).Once()
+	store.On("ListNodeContainers", mock.Anything, "n2", mock.Anything).Return([]*types.Container{{Memory: 100}}, nil).Once()
+	store.On("SetQuota", mock.Anything, quota).Return(nil)
+	assert.NoError(t, c.SetQuota(ctx, quota))
+	assert.Equal(t, types.QuotaUsage{CPU: 1, Memory: 100, Storage: 100, Volume: 100, Containers: 2}, quota.Used)
+
+	// replaced one is not counted again, store keeps its usage
+	store.On("GetQuota", mock.Anything, types.QuotaScopePod, "p1").Return(&types.Quota{Used: types.QuotaUsage{CPU: 3}}, nil).Once()
+	assert.NoError(t, c.SetQuota(ctx, quota))
+	store.AssertExpectations(t)
+}
+
+func TestRefundFailedQuotas(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	opts := &types.DeployOptions{Name: "app", Podname: "p1", CPUQuota: 1, Memory: 100}
+
+	// nothing failed, nothing refunded
+	c.refundFailedQuotas(ctx, opts, []*types.CreateContainerMessage{{}, {}})
+	refund := types.QuotaUsage{CPU: -2, Memory: -200, Containers: -2}
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopePod, "p1", refund).Return(nil).Once()
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopeApp, "app", refund).Return(nil).Once()
+	c.refundFailedQuotas(ctx, opts, []*types.CreateContainerMessage{{Error: types.ErrNoETCD}, {}, {Error: types.ErrNoETCD}})
+	store.AssertExpectations(t)
+}
@@ -2,6 +2,7 @@ package calcium
 
 import (
 	"context"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -23,6 +24,11 @@ type volCPUMemNodeContainers map[string]map[float64]map[int64]nodeContainers
 
 // ReallocResource allow realloc container resource
 func (c *Calcium) ReallocResource(ctx context.Context, opts *types.ReallocOptions) (chan *types.ReallocResourceMessage, error) {
+	if opts.CPUShares > 0 {
+		if err := types.ValidateCPUShares(opts.CPUShares); err != nil {
+			return nil, err
+		}
+	}
 	ch := make(chan *types.ReallocResourceMessage)
 	go func() {
 		defer close(ch)
@@ -110,6 +116,11 @@ func (c *Calcium) doReallocContainer(ctx context.Context, ch chan *types.Realloc
 							}
 						}
 
+						// 绑定需要 cpu
+						if containerWithCPUBind > 0 && newCPU == 0 && opts.BindCPU == types.TriTrue {
+							return types.NewDetailedErr(types.ErrInvalidRes, "binding cpu needs positive cpu quota")
+						}
+
 						// 检查内存
 						if newMemory != 0 { // nolint
 							if cap := int(node.MemCap / newMemory); cap < len(containers) { // nolint
@@ -138,8 +149,10 @@ func (c *Calcium) doReallocContainer(ctx context.Context, ch chan *types.Realloc
 							}
 							// 得到最终方案
 							cpusets = nodeCPUPlans[node.Name][:containerWithCPUBind]
-						} else if newCPU != 0 { // nolint
-							if cap := float64(node.InitCPU.Total()) / float64(c.config.Scheduler.ShareBase) / newCPU; int(cap) < len(containers) { // nolint
+						}
+						// 剩下的按 quota 共享 cpu
+						if shared := len(containers) - containerWithCPUBind; shared > 0 && newCPU != 0 { // nolint
+							if cap := float64(node.InitCPU.Total()) / float64(c.config.Scheduler.ShareBase) / newCPU; int(cap) < shared { // nolint
 								return types.NewDetailedErr(types.ErrInsufficientCPU, node.Name)
 							}
 						}
@@ -148,23 +161,38 @@ func (c *Calcium) doReallocContainer(ctx context.Context, ch chan *types.Realloc
 							Quota:  newCPU,    // nolint
 							Memory: newMemory, // nolint
 						}
+						// 保存原资源, 节点记账失败时还原
+						originContainers := make([]types.Container, len(containers))
+						for i, container := range containers { // nolint
+							originContainers[i] = *container
+						}
 
+						// 节点记账成功后才发送结果, 回滚时只发送错误
+						var msgs []*types.ReallocResourceMessage
 						return utils.Txn(
 							ctx,
 							// if
-							func(ctx context.Context) error {
-								return c.updateContainersResources(ctx, ch, node, containers, newResource, cpusets, hardVbsForContainer, newAutoVol, opts.BindCPU, opts.MemoryLimit) // nolint
+							func(ctx context.Context) (err error) {
+								msgs, err = c.updateContainersResources(ctx, node, containers, newResource, cpusets, hardVbsForContainer, newAutoVol, opts.CPUShares, opts.BindCPU, opts.MemoryLimit) // nolint
+								return err
 							},
 							// then
 							func(ctx context.Context) (err error) {
 								if err = c.store.UpdateNode(ctx, node); err != nil {
 									log.Errorf("[doReallocContainer] Realloc finish but update node %s failed %s", node.Name, err)
 									litter.Dump(node)
+									return
+								}
+								for _, msg := range msgs {
+									ch <- msg
 								}
 								return
 							},
 							// rollback
-							nil,
+							func(ctx context.Context) error {
+								c.rollbackContainersResources(ctx, node, containers, originContainers) // nolint
+								return nil
+							},
 							c.config.GlobalTimeout,
 						)
 					}); err != nil {
@@ -182,19 +210,21 @@ func (c *Calcium) doReallocContainer(ctx context.Context, ch chan *types.Realloc
 	}
 }
 
-func (c *Calcium) updateContainersResources(ctx context.Context, ch chan *types.ReallocResourceMessage,
+func (c *Calcium) updateContainersResources(ctx context.Context,
 	node *types.Node, containers []*types.Container,
 	newResource *enginetypes.VirtualizationResource,
 	cpusets []types.CPUMap, hardVbsForContainer map[string]types.VolumeBindings, newAutoVol string,
-	bindCPU, memoryLimit types.TriOptions) error {
+	cpuShares int64, bindCPU, memoryLimit types.TriOptions) ([]*types.ReallocResourceMessage, error) {
 
 	autoVbs, _ := types.MakeVolumeBindings(strings.Split(newAutoVol, ","))
 	planForContainers, err := c.reallocVolume(node, containers, autoVbs)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	msgs := []*types.ReallocResourceMessage{}
 	for _, container := range containers {
+		newResource := &enginetypes.VirtualizationResource{Quota: newResource.Quota, Memory: newResource.Memory}
 		// 情况1，原来就有绑定cpu的，保持不变
 		// 情况2，有绑定指令，不管之前有没有cpuMap，都分配
 		if (len(container.CPU) > 0 && bindCPU == types.TriKeep) || bindCPU == types.TriTrue {
@@ -208,6 +238,11 @@ func (c *Calcium) updateContainersResources(ctx context.Context, ch chan *types.
 			newResource.Volumes = append(newResource.Volumes, autoVbs.ToStringSlice(false, false)...)
 		}
 
+		newResource.CPUShares = container.CPUShares
+		if cpuShares > 0 {
+			newResource.CPUShares = cpuShares
+		}
+
 		switch memoryLimit {
 		case types.TriKeep:
 			newResource.SoftLimit = container.SoftLimit
@@ -224,20 +259,34 @@ func (c *Calcium) updateContainersResources(ctx context.Context, ch chan *types.
 			newResource.VolumeChanged = true
 		}
 
-		ch <- &types.ReallocResourceMessage{
+		msgs = append(msgs, &types.ReallocResourceMessage{
 			ContainerID: container.ID,
 			Error:       c.updateResource(ctx, node, container, newResource),
-		}
+		})
 	}
-	return nil
+	return msgs, nil
 }
 
 func (c *Calcium) updateResource(ctx context.Context, node *types.Node, container *types.Container, newResource *enginetypes.VirtualizationResource) error {
-	updateResourceErr := node.Engine.VirtualizationUpdateResource(ctx, container.ID, newResource)
+	// quotas of pod and app are charged by the difference, nothing is updated if it's exceeded
+	newVbs, _ := types.MakeVolumeBindings(newResource.Volumes)
+	delta := types.QuotaUsage{
+		CPU:     newResource.Quota - container.Quota,
+		Memory:  newResource.Memory - container.Memory,
+		Storage: newVbs.TotalSize() - container.Volumes.TotalSize(),
+		Volume:  newVbs.TotalSize() - container.Volumes.TotalSize(),
+	}
+	updateResourceErr := c.chargeContainerQuotas(ctx, container, delta)
+	if updateResourceErr == nil {
+		if updateResourceErr = node.Engine.VirtualizationUpdateResource(ctx, container.ID, newResource); updateResourceErr != nil {
+			c.refundContainerQuotas(ctx, container, delta)
+		}
+	}
 	if updateResourceErr == nil {
 		oldVolumeSize := container.Volumes.TotalSize()
 		container.CPU = newResource.CPU
 		container.Quota = newResource.Quota
+		container.CPUShares = newResource.CPUShares
 		container.Memory = newResource.Memory
 		container.Volumes, _ = types.MakeVolumeBindings(newResource.Volumes)
 		container.VolumePlan = types.MustToVolumePlan(newResource.VolumePlan)
@@ -266,6 +315,42 @@ func (c *Calcium) updateResource(ctx context.Context, node *types.Node, containe
 	return updateResourceErr
 }
 
+// rollbackContainersResources restores engine resources and meta of containers reallocated,
+// node isn't saved so its accounting is untouched
+func (c *Calcium) rollbackContainersResources(ctx context.Context, node *types.Node, containers []*types.Container, originContainers []types.Container) {
+	for i, container := range containers {
+		origin := originContainers[i]
+		oldResource := containerResource(node, &origin)
+		if reflect.DeepEqual(oldResource, containerResource(node, container)) {
+			// not reallocated
+			continue
+		}
+		oldResource.VolumeChanged = !origin.Volumes.IsEqual(container.Volumes)
+		if err := node.Engine.VirtualizationUpdateResource(ctx, container.ID, oldResource); err != nil {
+			log.Errorf("[rollbackContainersResources] Restore resource of container %s failed %v", container.ID, err)
+			continue
+		}
+		c.refundContainerQuotas(ctx, container, containerQuotaUsage(container).Sub(containerQuotaUsage(&origin)))
+		*container = origin
+		if err := c.store.UpdateContainer(ctx, container); err != nil {
+			log.Errorf("[rollbackContainersResources] Restore container %s failed %v", container.ID, err)
+		}
+	}
+}
+
+func containerResource(node *types.Node, container *types.Container) *enginetypes.VirtualizationResource {
+	return &enginetypes.VirtualizationResource{
+		CPU:        container.CPU,
+		Quota:      container.Quota,
+		CPUShares:  container.CPUShares,
+		Memory:     container.Memory,
+		SoftLimit:  container.SoftLimit,
+		NUMANode:   node.GetNUMANode(container.CPU),
+		Volumes:    container.Volumes.ToStringSlice(false, false),
+		VolumePlan: container.VolumePlan.ToLiteral(),
+	}
+}
+
 func (c *Calcium) reallocVolume(node *types.Node, containers []*types.Container, vbs types.VolumeBindings) (plans map[*types.Container]types.VolumePlan, err error) {
 	if len(vbs) == 0 {
 		return
@@ -2,6 +2,7 @@ package calcium
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	complexscheduler "github.com/projecteru2/core/scheduler/complex"
@@ -33,6 +34,7 @@ func TestRealloc(t *testing.T) {
 	c := NewTestCluster()
 	ctx := context.Background()
 	store := &storemocks.Store{}
+	store.On("ChargeQuota", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	c.store = store
 	c.config.Scheduler.ShareBase = 100
 
@@ -61,6 +63,7 @@ func TestRealloc(t *testing.T) {
 
 	c1 := &types.Container{
 		ID:         "c1",
+		Name:       "app_web_c1",
 		Podname:    "p1",
 		Engine:     engine,
 		Memory:     5 * int64(units.MiB),
@@ -73,6 +76,7 @@ func TestRealloc(t *testing.T) {
 
 	c2 := &types.Container{
 		ID:       "c2",
+		Name:     "app_web_c2",
 		Podname:  "p1",
 		Engine:   engine,
 		Memory:   5 * int64(units.MiB),
@@ -221,13 +225,15 @@ func TestRealloc(t *testing.T) {
 		VolumeUsed: int64(300),
 	}
 	c3 := &types.Container{
-		ID:      "c3",
-		Podname: "p1",
-		Engine:  engine,
-		Memory:  5 * int64(units.MiB),
-		Quota:   0.9,
-		CPU:     types.CPUMap{"2": 90},
-		Volumes: types.MustToVolumeBindings([]string{"AUTO:/data0:rw:100", "AUTO:/data1:rw:200"}),
+		ID:        "c3",
+		Name:      "app_web_c3",
+		Podname:   "p1",
+		Engine:    engine,
+		Memory:    5 * int64(units.MiB),
+		Quota:     0.9,
+		CPUShares: 512,
+		CPU:       types.CPUMap{"2": 90},
+		Volumes:   types.MustToVolumeBindings([]string{"AUTO:/data0:rw:100", "AUTO:/data1:rw:200"}),
 		VolumePlan: types.VolumePlan{
 			types.MustToVolumeBinding("AUTO:/data0:rw:100"): types.VolumeMap{"/dir0": 100},
 			types.MustToVolumeBinding("AUTO:/data1:rw:200"): types.VolumeMap{"/dir1": 200},
@@ -236,6 +242,7 @@ func TestRealloc(t *testing.T) {
 	}
 	c4 := &types.Container{
 		ID:       "c4",
+		Name:     "app_web_c4",
 		Podname:  "p1",
 		Engine:   engine,
 		Memory:   5 * int64(units.MiB),
@@ -276,7 +283,15 @@ func TestRealloc(t *testing.T) {
 	assert.Equal(t, node2.MemCap, int64(units.GiB)-4*int64(units.MiB))
 	assert.Equal(t, node2.Volume, types.VolumeMap{"/dir0": 250, "/dir1": 200, "/dir2": 200})
 	assert.Equal(t, node2.VolumeUsed, int64(250))
-
+	// cpu shares kept
+	assert.Equal(t, c3.CPUShares, int64(512))
+	assert.Equal(t, c4.CPUShares, int64(0))
+
+	// bad cpu shares
+	opts := newReallocOptions([]string{"c3"}, 0, 0, nil, types.TriKeep, types.TriKeep)
+	opts.CPUShares = 1
+	_, err = c.ReallocResource(ctx, opts)
+	assert.Error(t, err)
 }
 
 func TestReallocVolume(t *testing.T) {
@@ -294,6 +309,7 @@ func TestReallocVolume(t *testing.T) {
 
 	c1 := &types.Container{
 		ID:       "c1",
+		Name:     "app_web_c1",
 		Engine:   engine,
 		Nodename: "node1",
 		VolumePlan: types.VolumePlan{
@@ -383,6 +399,7 @@ func TestReallocVolume(t *testing.T) {
 
 	c2 := &types.Container{
 		ID:       "c2",
+		Name:     "app_web_c2",
 		Engine:   engine,
 		Nodename: "node1",
 		VolumePlan: types.VolumePlan{
@@ -412,6 +429,7 @@ func TestReallocBindCpu(t *testing.T) {
 	c.config.Scheduler.ShareBase = 100
 	ctx := context.Background()
 	store := &storemocks.Store{}
+	store.On("ChargeQuota", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	c.store = store
 	pod1 := &types.Pod{
 		Name: "p1",
@@ -460,6 +478,7 @@ func TestReallocBindCpu(t *testing.T) {
 	}
 	c5 := &types.Container{
 		ID:       "c5",
+		Name:     "app_web_c5",
 		Podname:  "p1",
 		Engine:   engine,
 		Memory:   5 * int64(units.MiB),
@@ -469,6 +488,7 @@ func TestReallocBindCpu(t *testing.T) {
 	}
 	c6 := &types.Container{
 		ID:       "c6",
+		Name:     "app_web_c6",
 		Podname:  "p1",
 		Engine:   engine,
 		Memory:   5 * int64(units.MiB),
@@ -513,3 +533,75 @@ func TestReallocBindCpu(t *testing.T) {
 	assert.Empty(t, c5.CPU)
 
 }
+
+func TestReallocRebindCPURollback(t *testing.T) {
+	c := NewTestCluster()
+	c.config.Scheduler.ShareBase = 100
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+	store.On("GetPod", mock.Anything, mock.Anything).Return(&types.Pod{Name: "p1"}, nil)
+	simpleMockScheduler, _ := complexscheduler.New(types.Config{Scheduler: types.SchedConfig{MaxShare: -1, ShareBase: 100}})
+	c.scheduler = simpleMockScheduler
+
+	engine := &enginemocks.API{}
+	node := &types.Node{
+		Name:    "node1",
+		MemCap:  int64(units.GiB),
+		CPU:     types.CPUMap{"0": 100, "1": 100},
+		InitCPU: types.CPUMap{"0": 100, "1": 100},
+		Engine:  engine,
+	}
+	bound := &types.Container{ID: "bound", Name: "app_web_bound", Podname: "p1", Nodename: "node1", Engine: engine, Memory: 5 * int64(units.MiB), Quota: 1, CPU: types.CPUMap{"0": 100}}
+	shared := &types.Container{ID: "shared", Name: "app_web_shared", Podname: "p1", Nodename: "node1", Engine: engine, Memory: 5 * int64(units.MiB), Quota: 1}
+	store.On("GetNode", mock.Anything, "node1").Return(node, nil)
+	store.On("GetContainers", mock.Anything, []string{"bound", "shared"}).Return([]*types.Container{bound, shared}, nil)
+	store.On("GetContainers", mock.Anything, []string{"shared"}).Return([]*types.Container{shared}, nil)
+	store.On("UpdateContainer", mock.Anything, mock.Anything).Return(nil)
+
+	// binding needs cpu quota
+	ch, err := c.ReallocResource(ctx, newReallocOptions([]string{"shared"}, -1, 0, nil, types.TriTrue, types.TriKeep))
+	assert.NoError(t, err)
+	for r := range ch {
+		assert.True(t, errors.Is(r.Error, types.ErrInvalidRes))
+	}
+
+	// cpu binding of one container doesn't leak into another
+	engine.On("VirtualizationUpdateResource", mock.Anything, "bound", mock.MatchedBy(func(r *enginetypes.VirtualizationResource) bool {
+		return len(r.CPU) == 1
+	})).Return(nil).Once()
+	engine.On("VirtualizationUpdateResource", mock.Anything, "shared", mock.MatchedBy(func(r *enginetypes.VirtualizationResource) bool {
+		return len(r.CPU) == 0
+	})).Return(nil).Once()
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopePod, "p1", types.QuotaUsage{CPU: -0.5}).Return(nil).Twice()
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopeApp, "app", types.QuotaUsage{CPU: -0.5}).Return(nil).Twice()
+	// node accounting failed, both restored and quota given back
+	store.On("UpdateNode", mock.Anything, mock.Anything).Return(types.ErrNoETCD).Once()
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopePod, "p1", types.QuotaUsage{CPU: 0.5}).Return(nil).Twice()
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopeApp, "app", types.QuotaUsage{CPU: 0.5}).Return(nil).Twice()
+	engine.On("VirtualizationUpdateResource", mock.Anything, "bound", mock.MatchedBy(func(r *enginetypes.VirtualizationResource) bool {
+		return r.Quota == 1 && r.CPU["0"] == 100
+	})).Return(nil).Once()
+	engine.On("VirtualizationUpdateResource", mock.Anything, "shared", mock.MatchedBy(func(r *enginetypes.VirtualizationResource) bool {
+		return r.Quota == 1 && len(r.CPU) == 0
+	})).Return(nil).Once()
+	ch, err = c.ReallocResource(ctx, newReallocOptions([]string{"bound", "shared"}, -0.5, 0, nil, types.TriKeep, types.TriKeep))
+	assert.NoError(t, err)
+	// only errors are sent, successes before rollback aren't
+	n := 0
+	for r := range ch {
+		assert.True(t, errors.Is(r.Error, types.ErrNoETCD))
+		n++
+	}
+	assert.Equal(t, 2, n)
+	engine.AssertExpectations(t)
+	store.AssertNumberOfCalls(t, "ChargeQuota", 8)
+	assert.Equal(t, 1.0, bound.Quota)
+	assert.Equal(t, types.CPUMap{"0": 100}, bound.CPU)
+	assert.Equal(t, 1.0, shared.Quota)
+	assert.Empty(t, shared.CPU)
+}
@@ -0,0 +1,276 @@
+package calcium
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/projecteru2/core/cluster"
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+type rebalanceNode struct {
+	name       string
+	containers []*types.Container
+	cpu        float64
+	memory     int64
+	storage    int64
+}
+
+func (n *rebalanceNode) fits(container *types.Container) bool {
+	return n.cpu >= container.Quota && n.memory >= container.Memory && n.storage >= container.Storage
+}
+
+func (n *rebalanceNode) add(container *types.Container) {
+	n.containers = append(n.containers, container)
+	n.cpu -= container.Quota
+	n.memory -= container.Memory
+	n.storage -= container.Storage
+}
+
+func (n *rebalanceNode) remove(container *types.Container) {
+	for i, c := range n.containers {
+		if c.ID == container.ID {
+			n.containers = append(n.containers[:i], n.containers[i+1:]...)
+			break
+		}
+	}
+	n.cpu += container.Quota
+	n.memory += container.Memory
+	n.storage += container.Storage
+}
+
+// Rebalance moves containers of an entrypoint between nodes of a pod
+// containers on nodes not matching NodeLabels will be moved first
+// moves are only planned unless opts.Execute is set, at most MaxUnavailable containers are moved at the same time
+func (c *Calcium) Rebalance(ctx context.Context, opts *types.RebalanceOptions) (chan *types.RebalanceMessage, error) {
+	if opts.Strategy != cluster.RebalanceSpread && opts.Strategy != cluster.RebalanceConsolidate {
+		return nil, types.NewDetailedErr(types.ErrBadRebalanceStrategy, opts.Strategy)
+	}
+	if opts.Entrypoint == nil {
+		return nil, types.ErrNoEntryInSpec
+	}
+	nodes, err := c.ListPodNodes(ctx, opts.Podname, opts.NodeLabels, false)
+	if err != nil {
+		return nil, err
+	}
+	containers, err := c.ListContainers(ctx, &types.ListContainersOptions{Appname: opts.Name, Entrypoint: opts.Entrypoint.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := map[string]*rebalanceNode{}
+	for _, node := range nodes {
+		candidates[node.Name] = &rebalanceNode{
+			name:    node.Name,
+			cpu:     float64(len(node.InitCPU)) - node.CPUUsed,
+			memory:  node.MemCap,
+			storage: node.AvailableStorage(),
+		}
+	}
+	misplaced := []*types.Container{}
+	for _, container := range containers {
+		if container.Podname != opts.Podname {
+			continue
+		}
+		if n, ok := candidates[container.Nodename]; ok {
+			n.containers = append(n.containers, container)
+			continue
+		}
+		misplaced = append(misplaced, container)
+	}
+
+	var moves []*types.RebalanceMove
+	if opts.Strategy == cluster.RebalanceSpread {
+		moves = planSpread(candidates, misplaced)
+	} else {
+		moves = planConsolidate(candidates, misplaced)
+	}
+	containersByID := map[string]*types.Container{}
+	for _, container := range containers {
+		containersByID[container.ID] = container
+	}
+
+	ch := make(chan *types.RebalanceMessage)
+	go func() {
+		defer close(ch)
+		if !opts.Execute {
+			for _, move := range moves {
+				ch <- &types.RebalanceMessage{Move: move}
+			}
+			return
+		}
+		step := opts.MaxUnavailable
+		if step <= 0 {
+			step = 1
+		}
+		wg := sync.WaitGroup{}
+		defer wg.Wait()
+		for index, move := range moves {
+			wg.Add(1)
+			go func(move *types.RebalanceMove) {
+				defer wg.Done()
+				ch <- c.doRebalanceMove(ctx, opts.DeployOptions, containersByID[move.ContainerID], move)
+			}(move)
+			if (index+1)%step == 0 {
+				wg.Wait()
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (c *Calcium) doRebalanceMove(ctx context.Context, opts types.DeployOptions, container *types.Container, move *types.RebalanceMove) *types.RebalanceMessage {
+	msg := &types.RebalanceMessage{Move: move}
+	msg.Create, msg.Remove, msg.Error = c.doMoveContainer(ctx, opts, container, container.Podname, move.To)
+	return msg
+}
+
+// doMoveContainer creates a container on pod and node with resources of the old one, then removes the old one
+// old one is kept if creating failed, node is scheduled if nodename is empty
+func (c *Calcium) doMoveContainer(ctx context.Context, opts types.DeployOptions, container *types.Container, podname, nodename string) (*types.CreateContainerMessage, *types.RemoveContainerMessage, error) {
+	// 使用老容器的资源配置
+	opts.Podname = podname
+	opts.Nodename = nodename
+	opts.Count = 1
+	opts.DeployStrategy = cluster.DeployAuto
+	opts.Memory = container.Memory
+	opts.Storage = container.Storage
+	opts.CPUQuota = container.Quota
+	opts.CPUShares = container.CPUShares
+	opts.Hugepages = container.Hugepages
+	opts.GPURequest = types.GPURequestOf(container.GPU)
+	opts.SoftLimit = container.SoftLimit
+	opts.Volumes = container.Volumes
+	createCh, err := c.CreateContainer(ctx, &opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	var create *types.CreateContainerMessage
+	for m := range createCh {
+		create = m
+		err = m.Error
+	}
+	if create == nil || err != nil {
+		log.Errorf("[doMoveContainer] Create container on %s %s failed %v, keep %s", podname, nodename, err, container.ID)
+		return create, nil, err
+	}
+	removeCh, err := c.RemoveContainer(ctx, []string{container.ID}, false, 1, 0)
+	if err != nil {
+		return create, nil, err
+	}
+	var remove *types.RemoveContainerMessage
+	for m := range removeCh {
+		remove = m
+	}
+	if remove == nil || !remove.Success {
+		log.Errorf("[doMoveContainer] Remove container %s failed", container.ID)
+	}
+	return create, remove, nil
+}
+
+func sortedRebalanceNodes(candidates map[string]*rebalanceNode) []*rebalanceNode {
+	nodes := []*rebalanceNode{}
+	for _, n := range candidates {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if len(nodes[i].containers) == len(nodes[j].containers) {
+			return nodes[i].name < nodes[j].name
+		}
+		return len(nodes[i].containers) < len(nodes[j].containers)
+	})
+	return nodes
+}
+
+// planSpread moves containers from the most loaded node to the least loaded one
+// until counts differ by at most one
+func planSpread(candidates map[string]*rebalanceNode, misplaced []*types.Container) []*types.RebalanceMove {
+	moves := []*types.RebalanceMove{}
+	for _, container := range misplaced {
+		for _, n := range sortedRebalanceNodes(candidates) {
+			if n.fits(container) {
+				n.add(container)
+				moves = append(moves, &types.RebalanceMove{ContainerID: container.ID, From: container.Nodename, To: n.name})
+				break
+			}
+		}
+	}
+	for {
+		nodes := sortedRebalanceNodes(candidates)
+		if len(nodes) < 2 {
+			return moves
+		}
+		min, max := nodes[0], nodes[len(nodes)-1]
+		if len(max.containers)-len(min.containers) <= 1 {
+			return moves
+		}
+		var moved *types.Container
+		for _, container := range max.containers {
+			if min.fits(container) {
+				moved = container
+				break
+			}
+		}
+		if moved == nil {
+			return moves
+		}
+		max.remove(moved)
+		min.add(moved)
+		moves = append(moves, &types.RebalanceMove{ContainerID: moved.ID, From: max.name, To: min.name})
+	}
+}
+
+// planConsolidate drains the least loaded nodes into more loaded ones
+// a node is drained only if all its containers can be moved
+func planConsolidate(candidates map[string]*rebalanceNode, misplaced []*types.Container) []*types.RebalanceMove {
+	moves := []*types.RebalanceMove{}
+	for _, container := range misplaced {
+		nodes := sortedRebalanceNodes(candidates)
+		for i := len(nodes) - 1; i >= 0; i-- {
+			if nodes[i].fits(container) {
+				nodes[i].add(container)
+				moves = append(moves, &types.RebalanceMove{ContainerID: container.ID, From: container.Nodename, To: nodes[i].name})
+				break
+			}
+		}
+	}
+	drained := map[string]bool{}
+	for _, src := range sortedRebalanceNodes(candidates) {
+		if len(src.containers) == 0 {
+			continue
+		}
+		// 试算，全部能搬走才搬
+		targets := map[string]*rebalanceNode{}
+		for name, n := range candidates {
+			if name == src.name || drained[name] || len(n.containers) < len(src.containers) {
+				continue
+			}
+			copied := *n
+			copied.containers = append([]*types.Container{}, n.containers...)
+			targets[name] = &copied
+		}
+		planned := []*types.RebalanceMove{}
+		for _, container := range src.containers {
+			nodes := sortedRebalanceNodes(targets)
+			for i := len(nodes) - 1; i >= 0; i-- {
+				if nodes[i].fits(container) {
+					nodes[i].add(container)
+					planned = append(planned, &types.RebalanceMove{ContainerID: container.ID, From: src.name, To: nodes[i].name})
+					break
+				}
+			}
+		}
+		if len(planned) != len(src.containers) {
+			continue
+		}
+		for i, move := range planned {
+			candidates[move.To].add(src.containers[i])
+		}
+		drained[src.name] = true
+		src.containers = nil
+		moves = append(moves, planned...)
+	}
+	return moves
+}
@@ -0,0 +1,88 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+
+	"github.com/projecteru2/core/cluster"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRebalance(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := c.store.(*storemocks.Store)
+	opts := &types.RebalanceOptions{
+		DeployOptions: types.DeployOptions{
+			Name:       "app",
+			Podname:    "p1",
+			Entrypoint: &types.Entrypoint{Name: "web"},
+		},
+		Strategy: "unknown",
+	}
+	// failed by strategy
+	_, err := c.Rebalance(ctx, opts)
+	assert.Error(t, err)
+
+	newNode := func(name string) *types.Node {
+		return &types.Node{Name: name, InitCPU: types.CPUMap{"0": 100, "1": 100}, MemCap: 100, InitMemCap: 100}
+	}
+	newContainer := func(ID, nodename string) *types.Container {
+		return &types.Container{ID: ID, Podname: "p1", Nodename: nodename, Memory: 10, Quota: 0.5}
+	}
+	store.On("GetNodesByPod", mock.Anything, "p1", mock.Anything, false).Return([]*types.Node{newNode("n1"), newNode("n2"), newNode("n3")}, nil)
+	store.On("ListContainers", mock.Anything, "app", "web", "", mock.Anything, mock.Anything).Return([]*types.Container{
+		newContainer("c1", "n1"),
+		newContainer("c2", "n1"),
+		newContainer("c3", "n1"),
+		newContainer("c4", "n1"),
+		newContainer("c5", "n2"),
+		newContainer("c6", "n4"), // not in candidates
+	}, nil)
+
+	// spread
+	opts.Strategy = cluster.RebalanceSpread
+	ch, err := c.Rebalance(ctx, opts)
+	assert.NoError(t, err)
+	counts := map[string]int{"n1": 4, "n2": 1, "n4": 1}
+	moves := 0
+	for m := range ch {
+		assert.Nil(t, m.Create)
+		assert.NoError(t, m.Error)
+		counts[m.Move.From]--
+		counts[m.Move.To]++
+		moves++
+	}
+	assert.Equal(t, moves, 3)
+	assert.Equal(t, counts["n1"], 2)
+	assert.Equal(t, counts["n2"], 2)
+	assert.Equal(t, counts["n3"], 2)
+
+	// consolidate
+	opts.Strategy = cluster.RebalanceConsolidate
+	ch, err = c.Rebalance(ctx, opts)
+	assert.NoError(t, err)
+	counts = map[string]int{"n1": 4, "n2": 1, "n4": 1}
+	for m := range ch {
+		counts[m.Move.From]--
+		counts[m.Move.To]++
+	}
+	assert.Equal(t, counts["n1"], 6)
+	assert.Equal(t, counts["n2"], 0)
+	assert.Equal(t, counts["n4"], 0)
+}
+
+func TestPlanConsolidate(t *testing.T) {
+	// n2 can't hold containers of n1, nothing moves
+	candidates := map[string]*rebalanceNode{
+		"n1": {name: "n1", containers: []*types.Container{{ID: "c1", Memory: 50}}, memory: 50},
+		"n2": {name: "n2", containers: []*types.Container{{ID: "c2", Memory: 50}}, memory: 10},
+	}
+	moves := planConsolidate(candidates, nil)
+	assert.Len(t, moves, 1)
+	assert.Equal(t, moves[0].ContainerID, "c2")
+	assert.Equal(t, moves[0].To, "n1")
+}
@@ -0,0 +1,101 @@
+package calcium
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/distribution/reference"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// SetRegistryCredential encrypts password by master key and saves credential of registry,
+// the old one of the same registry and pod is replaced, so credentials are rotated by setting them again
+func (c *Calcium) SetRegistryCredential(ctx context.Context, opts *types.RegistryCredentialOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if opts.Podname != "" {
+		if _, err := c.store.GetPod(ctx, opts.Podname); err != nil {
+			return err
+		}
+	}
+	key, err := c.secretsKey()
+	if err != nil {
+		return err
+	}
+	password, err := utils.Encrypt(key, []byte(opts.Password))
+	if err != nil {
+		return err
+	}
+	return c.store.SetRegistryCredential(ctx, &types.RegistryCredential{
+		Registry: opts.Registry,
+		Podname:  opts.Podname,
+		Username: opts.Username,
+		Password: password,
+	})
+}
+
+// ListRegistryCredentials lists credentials of registries, passwords are never shown
+func (c *Calcium) ListRegistryCredentials(ctx context.Context) ([]*types.RegistryCredential, error) {
+	credentials, err := c.store.ListRegistryCredentials(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, credential := range credentials {
+		credential.Password = nil
+	}
+	return credentials, nil
+}
+
+// RemoveRegistryCredential removes credential of registry for a pod, or the one for all pods if podname is empty
+func (c *Calcium) RemoveRegistryCredential(ctx context.Context, registry, podname string) error {
+	if err := types.ValidateRegistry(registry); err != nil {
+		return err
+	}
+	return c.store.RemoveRegistryCredential(ctx, registry, podname)
+}
+
+// registryAuth looks up credential of registry of image for pod, the one of pod is preferred to the one of all pods,
+// nil is returned if there is none, then engines use credentials in their own config
+func (c *Calcium) registryAuth(ctx context.Context, podname, image string) (*enginetypes.AuthConfig, error) {
+	// credentials are encrypted by master key, there is none without it
+	if c.config.Secrets.MasterKey == "" {
+		return nil, nil
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		// engines tell bad images
+		log.Warnf("[registryAuth] Parse image %s failed %v", image, err)
+		return nil, nil
+	}
+	credentials, err := c.store.ListRegistryCredentials(ctx, reference.Domain(named))
+	if err != nil {
+		return nil, err
+	}
+	var found *types.RegistryCredential
+	for _, credential := range credentials {
+		switch credential.Podname {
+		case podname:
+			found = credential
+		case "":
+			if found == nil {
+				found = credential
+			}
+		}
+	}
+	if found == nil {
+		return nil, nil
+	}
+	key, err := c.secretsKey()
+	if err != nil {
+		return nil, err
+	}
+	password, err := utils.Decrypt(key, found.Password)
+	if err != nil {
+		return nil, types.NewDetailedErr(types.ErrBadRegistryCredential, fmt.Sprintf("decrypt password of %s failed %v", found.Registry, err))
+	}
+	return &enginetypes.AuthConfig{Username: found.Username, Password: string(password)}, nil
+}
@@ -0,0 +1,102 @@
+package calcium
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSetRegistryCredential(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	opts := &types.RegistryCredentialOptions{Registry: "hub.example.com", Podname: "p1", Username: "u", Password: "password"}
+
+	// disabled
+	store.On("GetPod", mock.Anything, "p1").Return(&types.Pod{Name: "p1"}, nil)
+	assert.True(t, errors.Is(c.SetRegistryCredential(ctx, opts), types.ErrNoMasterKey))
+	key := bytes.Repeat([]byte("k"), 32)
+	c.config.Secrets.MasterKey = base64.StdEncoding.EncodeToString(key)
+	assert.True(t, errors.Is(c.SetRegistryCredential(ctx, &types.RegistryCredentialOptions{Registry: "hub.example.com"}), types.ErrBadRegistryCredential))
+	store.On("GetPod", mock.Anything, "nope").Return(nil, types.ErrBadCount)
+	assert.Error(t, c.SetRegistryCredential(ctx, &types.RegistryCredentialOptions{Registry: "hub.example.com", Podname: "nope", Username: "u", Password: "p"}))
+
+	var saved *types.RegistryCredential
+	store.On("SetRegistryCredential", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		saved = args.Get(1).(*types.RegistryCredential)
+	})
+	assert.NoError(t, c.SetRegistryCredential(ctx, opts))
+	assert.Equal(t, "p1", saved.Podname)
+	assert.NotContains(t, string(saved.Password), "password")
+	password, err := utils.Decrypt(key, saved.Password)
+	assert.NoError(t, err)
+	assert.Equal(t, "password", string(password))
+
+	store.On("ListRegistryCredentials", mock.Anything, "").Return([]*types.RegistryCredential{saved}, nil)
+	credentials, err := c.ListRegistryCredentials(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, credentials, 1)
+	assert.Nil(t, credentials[0].Password)
+
+	assert.Error(t, c.RemoveRegistryCredential(ctx, "", "p1"))
+	store.On("RemoveRegistryCredential", mock.Anything, "hub.example.com", "p1").Return(nil)
+	assert.NoError(t, c.RemoveRegistryCredential(ctx, "hub.example.com", "p1"))
+}
+
+func TestRegistryAuth(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+
+	// no credentials without master key
+	auth, err := c.registryAuth(ctx, "p1", "hub.example.com/app:latest")
+	assert.NoError(t, err)
+	assert.Nil(t, auth)
+	store.AssertNotCalled(t, "ListRegistryCredentials", mock.Anything, mock.Anything)
+
+	key := bytes.Repeat([]byte("k"), 32)
+	c.config.Secrets.MasterKey = base64.StdEncoding.EncodeToString(key)
+	all, err := utils.Encrypt(key, []byte("all"))
+	assert.NoError(t, err)
+	pod, err := utils.Encrypt(key, []byte("pod"))
+	assert.NoError(t, err)
+	store.On("ListRegistryCredentials", mock.Anything, "hub.example.com").Return([]*types.RegistryCredential{
+		{Registry: "hub.example.com", Podname: "p1", Username: "u1", Password: pod},
+		{Registry: "hub.example.com", Username: "u", Password: all},
+	}, nil)
+	store.On("ListRegistryCredentials", mock.Anything, "docker.io").Return([]*types.RegistryCredential{}, nil)
+
+	auth, err = c.registryAuth(ctx, "p1", "hub.example.com/app:latest")
+	assert.NoError(t, err)
+	assert.Equal(t, &enginetypes.AuthConfig{Username: "u1", Password: "pod"}, auth)
+	auth, err = c.registryAuth(ctx, "p2", "hub.example.com/app:latest")
+	assert.NoError(t, err)
+	assert.Equal(t, &enginetypes.AuthConfig{Username: "u", Password: "all"}, auth)
+	// falls back to config of engine
+	auth, err = c.registryAuth(ctx, "p1", "alpine")
+	assert.NoError(t, err)
+	assert.Nil(t, auth)
+
+	// pulled with credential
+	engine := &enginemocks.API{}
+	engine.On("ImageLocalDigests", mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD)
+	engine.On("ImagePull", mock.Anything, "hub.example.com/app:latest", false, &enginetypes.AuthConfig{Username: "u1", Password: "pod"}).
+		Return(ioutil.NopCloser(bytes.NewReader([]byte{})), nil)
+	store.On("GetNode", mock.Anything, "n1").Return(&types.Node{Name: "n1", Podname: "p1", Engine: engine}, nil)
+	_, _, err = c.doGetAndPrepareNode(ctx, "n1", "hub.example.com/app:latest")
+	assert.NoError(t, err)
+	engine.AssertNumberOfCalls(t, "ImagePull", 1)
+}
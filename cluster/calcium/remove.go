@@ -3,7 +3,7 @@ package calcium
 import (
 	"bytes"
 	"context"
-	"sync"
+	"time"
 
 	"github.com/projecteru2/core/store"
 	"github.com/projecteru2/core/utils"
@@ -14,61 +14,71 @@ import (
 
 // RemoveContainer remove containers
 // returns a channel that contains removing responses
-func (c *Calcium) RemoveContainer(ctx context.Context, IDs []string, force bool, step int) (chan *types.RemoveContainerMessage, error) {
+// containers are stopped before removed if stopTimeout or StopTimeout of container set, so they get graceful period before killed
+// step containers are removed at the same time, limited by max concurrency of config, messages carry progress in order
+func (c *Calcium) RemoveContainer(ctx context.Context, IDs []string, force bool, step int, stopTimeout time.Duration) (chan *types.RemoveContainerMessage, error) {
 	ch := make(chan *types.RemoveContainerMessage)
 	if step < 1 {
 		step = 1
 	}
+	progress := &bulkProgress{total: len(IDs)}
 
 	go func() {
 		defer close(ch)
-		wg := sync.WaitGroup{}
-		defer wg.Wait()
-		for i, ID := range IDs {
-			wg.Add(1)
-			go func(ID string) {
-				defer wg.Done()
-				ret := &types.RemoveContainerMessage{ContainerID: ID, Success: false, Hook: []*bytes.Buffer{}}
-				if err := c.withContainerLocked(ctx, ID, func(container *types.Container) error {
-					return c.withNodeLocked(ctx, container.Nodename, func(node *types.Node) (err error) {
-						return utils.Txn(
-							ctx,
-							// if
-							func(ctx context.Context) error {
-								return c.doRemoveContainer(ctx, container, force)
-							},
-							// then
-							func(ctx context.Context) error {
-								log.Infof("[RemoveContainer] Container %s removed", container.ID)
-								return c.store.UpdateNodeResource(ctx, node, container.CPU, container.Quota, container.Memory, container.Storage, container.VolumePlan.IntoVolumeMap(), store.ActionIncr)
-							},
-							// rollback
-							nil,
-							c.config.GlobalTimeout,
-						)
-					})
-				}); err != nil {
-					log.Errorf("[RemoveContainer] Remove container %s failed, err: %v", ID, err)
-					ret.Hook = append(ret.Hook, bytes.NewBufferString(err.Error()))
-				} else {
-					ret.Success = true
-				}
-				ch <- ret
-			}(ID)
-			if (i+1)%step == 0 {
-				log.Info("[RemoveContainer] Wait for previous tasks done")
-				wg.Wait()
+		prefetched := c.prefetchContainers(ctx, IDs)
+		doBulk(ctx, IDs, c.bulkConcurrency(step), func(ID string) {
+			ret := &types.RemoveContainerMessage{ContainerID: ID, Success: false, Hook: []*bytes.Buffer{}}
+			if err := c.withPrefetchedContainerLocked(ctx, ID, prefetched, func(container *types.Container) error {
+				return c.withNodeLocked(ctx, container.Nodename, func(node *types.Node) (err error) {
+					return utils.Txn(
+						ctx,
+						// if
+						func(ctx context.Context) error {
+							message, err := c.doRemoveContainer(ctx, container, force, stopTimeout)
+							ret.Hook = append(ret.Hook, types.HookResultsOutput(message)...)
+							return err
+						},
+						// then
+						func(ctx context.Context) error {
+							log.Infof("[RemoveContainer] Container %s removed", container.ID)
+							c.refundContainerQuotas(ctx, container, containerQuotaUsage(container))
+							c.publishContainerEvent(types.EventContainerRemoved, container)
+							return c.store.UpdateNodeResource(ctx, node, container.CPU, container.Quota, container.Memory, container.Storage, container.VolumePlan.IntoVolumeMap(), container.Hugepages, container.GPU, store.ActionIncr)
+						},
+						// rollback
+						nil,
+						c.config.GlobalTimeout,
+					)
+				})
+			}); err != nil {
+				log.Errorf("[RemoveContainer] Remove container %s failed, err: %v", ID, err)
+				ret.Error = err
+				ret.Hook = append(ret.Hook, bytes.NewBufferString(err.Error()))
+			} else {
+				ret.Success = true
 			}
-		}
+			progress.send(func(done, total int) {
+				ret.Done, ret.Total = done, total
+				ch <- ret
+			})
+		})
 	}()
 	return ch, nil
 }
 
-func (c *Calcium) doRemoveContainer(ctx context.Context, container *types.Container, force bool) error {
-	return utils.Txn(
+func (c *Calcium) doRemoveContainer(ctx context.Context, container *types.Container, force bool, stopTimeout time.Duration) (message []*types.HookResult, err error) {
+	err = utils.Txn(
 		ctx,
 		// if
 		func(ctx context.Context) error {
+			if message, err = c.doBeforeRemoveHook(ctx, container, force); err != nil {
+				return err
+			}
+			if stopTimeout > 0 || container.StopTimeout > 0 {
+				if err = container.Stop(ctx, stopTimeout); err != nil && !force {
+					return err
+				}
+			}
 			return container.Remove(ctx, force)
 		},
 		// then
@@ -79,12 +89,16 @@ func (c *Calcium) doRemoveContainer(ctx context.Context, container *types.Contai
 		nil,
 		c.config.GlobalTimeout,
 	)
+	return message, err
+}
 
+func (c *Calcium) doBeforeRemoveHook(ctx context.Context, container *types.Container, force bool) (message []*types.HookResult, err error) {
+	return c.doHook(ctx, container, types.HookBeforeRemove, nil, nil, force)
 }
 
 // 同步地删除容器, 在某些需要等待的场合异常有用!
 func (c *Calcium) doRemoveContainerSync(ctx context.Context, IDs []string) error {
-	ch, err := c.RemoveContainer(ctx, IDs, true, 1)
+	ch, err := c.RemoveContainer(ctx, IDs, true, 1, 0)
 	if err != nil {
 		return err
 	}
@@ -2,7 +2,9 @@ package calcium
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	enginemocks "github.com/projecteru2/core/engine/mocks"
 	lockmocks "github.com/projecteru2/core/lock/mocks"
@@ -23,10 +25,11 @@ func TestRemoveContainer(t *testing.T) {
 
 	// failed by GetContainer
 	store.On("GetContainers", mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD).Once()
-	ch, err := c.RemoveContainer(ctx, []string{"xx"}, false, 0)
+	ch, err := c.RemoveContainer(ctx, []string{"xx"}, false, 0, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.False(t, r.Success)
+		assert.True(t, errors.Is(r.Error, types.ErrNoETCD))
 	}
 	container := &types.Container{
 		ID:       "xx",
@@ -36,7 +39,7 @@ func TestRemoveContainer(t *testing.T) {
 	store.On("GetContainers", mock.Anything, mock.Anything).Return([]*types.Container{container}, nil)
 	// failed by GetNode
 	store.On("GetNode", mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD).Once()
-	ch, err = c.RemoveContainer(ctx, []string{"xx"}, false, 0)
+	ch, err = c.RemoveContainer(ctx, []string{"xx"}, false, 0, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.False(t, r.Success)
@@ -46,7 +49,7 @@ func TestRemoveContainer(t *testing.T) {
 	}
 	store.On("GetNode", mock.Anything, mock.Anything).Return(node, nil)
 	// failed by Remove
-	ch, err = c.RemoveContainer(ctx, []string{"xx"}, false, 0)
+	ch, err = c.RemoveContainer(ctx, []string{"xx"}, false, 0, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.False(t, r.Success)
@@ -57,11 +60,37 @@ func TestRemoveContainer(t *testing.T) {
 	engine.On("VirtualizationRemove", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	store.On("GetContainers", mock.Anything, mock.Anything).Return([]*types.Container{container}, nil)
 	store.On("RemoveContainer", mock.Anything, mock.Anything).Return(nil)
-	store.On("UpdateNodeResource", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	store.On("ChargeQuota", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	store.On("UpdateNodeResource", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	// success
-	ch, err = c.RemoveContainer(ctx, []string{"xx"}, false, 0)
+	ch, err = c.RemoveContainer(ctx, []string{"xx"}, false, 0, 0)
 	assert.NoError(t, err)
 	for r := range ch {
 		assert.True(t, r.Success)
+		assert.NoError(t, r.Error)
 	}
+	// failed by forced before remove hook
+	container.Hook = &types.Hook{BeforeRemove: []string{"deregister"}, Force: true}
+	engine.On("ExecCreate", mock.Anything, mock.Anything, mock.Anything).Return("", types.ErrNilEngine)
+	ch, err = c.RemoveContainer(ctx, []string{"xx"}, false, 0, 0)
+	assert.NoError(t, err)
+	for r := range ch {
+		assert.False(t, r.Success)
+		assert.NotEmpty(t, r.Hook)
+	}
+	// force remove ignores hook failure
+	ch, err = c.RemoveContainer(ctx, []string{"xx"}, true, 0, 0)
+	assert.NoError(t, err)
+	for r := range ch {
+		assert.True(t, r.Success)
+	}
+	// stopped gracefully before removed
+	container.Hook = nil
+	engine.On("VirtualizationStop", mock.Anything, mock.Anything, 30*time.Second).Return(nil).Once()
+	ch, err = c.RemoveContainer(ctx, []string{"xx"}, false, 0, 30*time.Second)
+	assert.NoError(t, err)
+	for r := range ch {
+		assert.True(t, r.Success)
+	}
+	engine.AssertCalled(t, "VirtualizationStop", mock.Anything, mock.Anything, 30*time.Second)
 }
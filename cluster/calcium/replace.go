@@ -54,9 +54,19 @@ func (c *Calcium) ReplaceContainer(ctx context.Context, opts *types.ReplaceOptio
 					replaceOpts.Memory = container.Memory
 					replaceOpts.Storage = container.Storage
 					replaceOpts.CPUQuota = container.Quota
+					replaceOpts.CPUShares = container.CPUShares
+					replaceOpts.Hugepages = container.Hugepages
+					replaceOpts.GPURequest = types.GPURequestOf(container.GPU)
 					replaceOpts.SoftLimit = container.SoftLimit
 					// 覆盖 podname 如果做全量更新的话
 					replaceOpts.Podname = container.Podname
+					// volumes of mutators are already in container volumes
+					if err := c.mutateDeployOptions(ctx, &replaceOpts.DeployOptions); err != nil {
+						return err
+					}
+					if err := c.doFillPodDefaults(ctx, &replaceOpts.DeployOptions); err != nil {
+						return err
+					}
 					// 覆盖 Volumes
 					replaceOpts.Volumes = container.Volumes
 					// 继承网络配置
@@ -110,7 +120,7 @@ func (c *Calcium) doReplaceContainer(
 		return nil, removeMessage, types.ErrNotFitLabels
 	}
 	// prepare node
-	node, err := c.doGetAndPrepareNode(ctx, container.Nodename, opts.Image)
+	node, _, err := c.doGetAndPrepareNode(ctx, container.Nodename, opts.Image)
 	if err != nil {
 		return nil, removeMessage, err
 	}
@@ -130,7 +140,8 @@ func (c *Calcium) doReplaceContainer(
 		ctx,
 		// if
 		func(ctx context.Context) (err error) {
-			removeMessage.Hook, err = c.doStopContainer(ctx, container, opts.IgnoreHook)
+			messages, err := c.doStopContainer(ctx, container, nil, opts.IgnoreHook, 0)
+			removeMessage.Hook = types.HookResultsOutput(messages)
 			return
 		},
 		// then
@@ -142,7 +153,7 @@ func (c *Calcium) doReplaceContainer(
 					return utils.Txn(
 						ctx,
 						func(ctx context.Context) error {
-							createMessage = c.doCreateAndStartContainer(ctx, index, node, &opts.DeployOptions, container.CPU, container.VolumePlan)
+							createMessage = c.doCreateAndStartContainer(ctx, index, node, &opts.DeployOptions, container.CPU, container.VolumePlan, container.GPU)
 							return createMessage.Error
 						},
 						nil,
@@ -153,7 +164,7 @@ func (c *Calcium) doReplaceContainer(
 								return nil
 							}
 							if err = c.withNodeLocked(ctx, node.Name, func(node *types.Node) error {
-								return c.store.UpdateNodeResource(ctx, node, createMessage.CPU, createMessage.Quota, createMessage.Memory, createMessage.Storage, createMessage.VolumePlan.IntoVolumeMap(), store.ActionIncr)
+								return c.store.UpdateNodeResource(ctx, node, createMessage.CPU, createMessage.Quota, createMessage.Memory, createMessage.Storage, createMessage.VolumePlan.IntoVolumeMap(), createMessage.Hugepages, createMessage.GPU, store.ActionIncr)
 							}); err != nil {
 								log.Errorf("[doReplaceContainer] Reset node resource %s failed %v", node.Name, err)
 							}
@@ -164,8 +175,11 @@ func (c *Calcium) doReplaceContainer(
 				},
 				// then
 				func(ctx context.Context) (err error) {
-					if err = c.doRemoveContainer(ctx, container, true); err != nil {
+					messages, err := c.doRemoveContainer(ctx, container, true, 0)
+					removeMessage.Hook = append(removeMessage.Hook, types.HookResultsOutput(messages)...)
+					if err != nil {
 						log.Errorf("[doReplaceContainer] the new started but the old failed to stop")
+						c.markContainerReplaced(ctx, container, createMessage.ContainerID)
 						return
 					}
 					removeMessage.Success = true
@@ -177,15 +191,28 @@ func (c *Calcium) doReplaceContainer(
 		},
 		// rollback
 		func(ctx context.Context) (err error) {
-			messages, err := c.doStartContainer(ctx, container, opts.IgnoreHook)
+			messages, err := c.doStartContainer(ctx, container, nil, opts.IgnoreHook)
 			if err != nil {
 				log.Errorf("[replaceAndRemove] Old container %s restart failed %v", container.ID, err)
 				removeMessage.Hook = append(removeMessage.Hook, bytes.NewBufferString(err.Error()))
 			} else {
-				removeMessage.Hook = append(removeMessage.Hook, messages...)
+				removeMessage.Hook = append(removeMessage.Hook, types.HookResultsOutput(messages)...)
 			}
 			return
 		},
 		c.config.GlobalTimeout,
 	)
 }
+
+// markContainerReplaced annotates the old container left by replace, so retention rules can clean it
+func (c *Calcium) markContainerReplaced(ctx context.Context, container *types.Container, newID string) {
+	annotations := map[string]string{}
+	for k, v := range container.Annotations {
+		annotations[k] = v
+	}
+	annotations[types.ContainerReplacedAnnotation] = newID
+	container.Annotations = annotations
+	if err := c.store.UpdateContainer(ctx, container); err != nil {
+		log.Errorf("[markContainerReplaced] Mark container %s replaced failed %v", container.ID, err)
+	}
+}
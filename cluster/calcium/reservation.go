@@ -0,0 +1,262 @@
+package calcium
+
+import (
+	"context"
+	"time"
+
+	"github.com/projecteru2/core/store"
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const reservationCheckInterval = time.Minute
+
+// Reserve holds resources for a future deploy
+// CreateContainer with the reservation ID consumes it, otherwise resources are released after TTL
+func (c *Calcium) Reserve(ctx context.Context, opts *types.ReserveOptions) (*types.Reservation, error) {
+	if opts.TTL <= 0 {
+		return nil, types.NewDetailedErr(types.ErrBadReservationTTL, opts.TTL)
+	}
+	if opts.Entrypoint == nil {
+		return nil, types.ErrBadEntrypoint
+	}
+	deployOpts := &opts.DeployOptions
+	deployOpts.Normalize()
+	if err := validateResourceOptions(deployOpts); err != nil {
+		return nil, err
+	}
+	// processing status is bound with reservation ID, it will be taken over by CreateContainer
+	deployOpts.ProcessIdent = utils.RandomString(16)
+	nodesInfo, err := c.doAllocResource(ctx, deployOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation := newReservation(deployOpts, nodesInfo, opts.TTL)
+	if err = c.store.AddReservation(ctx, reservation); err != nil {
+		log.Errorf("[Reserve] Save reservation failed %v", err)
+		c.doReleaseReservation(context.Background(), reservation)
+		return nil, err
+	}
+	log.Infof("[Reserve] Reserve %d containers in pod %s as %s", reservation.Count(), reservation.Podname, reservation.ID)
+	return reservation, nil
+}
+
+func newReservation(opts *types.DeployOptions, nodesInfo []types.NodeInfo, ttl time.Duration) *types.Reservation {
+	reservation := &types.Reservation{
+		ID:         opts.ProcessIdent,
+		Podname:    opts.Podname,
+		Appname:    opts.Name,
+		Entrypoint: opts.Entrypoint.Name,
+		CPUQuota:   opts.CPUQuota,
+		CPUBind:    opts.CPUBind,
+		Memory:     opts.Memory,
+		Storage:    opts.Storage,
+		Volumes:    opts.Volumes,
+		Hugepages:  opts.Hugepages,
+		GPURequest: opts.GPURequest,
+		Expire:     time.Now().Add(ttl),
+	}
+	for _, nodeInfo := range nodesInfo {
+		reservation.NodesInfo = append(reservation.NodesInfo, types.NodeInfo{
+			Name:        nodeInfo.Name,
+			Deploy:      nodeInfo.Deploy,
+			CPUPlan:     nodeInfo.CPUPlan,
+			VolumePlans: nodeInfo.VolumePlans,
+			GPUPlan:     nodeInfo.GPUPlan,
+		})
+	}
+	return reservation
+}
+
+// ListReservations list reservations of a pod, list all if podname is empty
+// resources held by running deploys are not listed
+func (c *Calcium) ListReservations(ctx context.Context, podname string) ([]*types.Reservation, error) {
+	reservations, err := c.store.ListReservations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := []*types.Reservation{}
+	for _, reservation := range reservations {
+		if !reservation.Dispatching && (podname == "" || reservation.Podname == podname) {
+			result = append(result, reservation)
+		}
+	}
+	return result, nil
+}
+
+// ReleaseReservation give back resources held by a reservation
+func (c *Calcium) ReleaseReservation(ctx context.Context, id string) error {
+	reservation, err := c.store.GetReservation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if reservation.Dispatching && !reservation.Expired() {
+		return types.NewDetailedErr(types.ErrReservationInUse, id)
+	}
+	// claim it, so it can't be consumed or released twice
+	if err = c.store.RemoveReservation(ctx, id); err != nil {
+		return err
+	}
+	c.doReleaseReservation(ctx, reservation)
+	return nil
+}
+
+// releaseExpiredReservations release all expired reservations
+func (c *Calcium) releaseExpiredReservations(ctx context.Context) {
+	reservations, err := c.store.ListReservations(ctx)
+	if err != nil {
+		log.Errorf("[releaseExpiredReservations] List reservations failed %v", err)
+		return
+	}
+	for _, reservation := range reservations {
+		if !reservation.Expired() {
+			continue
+		}
+		if err := c.ReleaseReservation(ctx, reservation.ID); err != nil {
+			log.Errorf("[releaseExpiredReservations] Release reservation %s failed %v", reservation.ID, err)
+		}
+	}
+}
+
+func (c *Calcium) watchReservations(ctx context.Context) {
+	ticker := time.NewTicker(reservationCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.releaseExpiredReservations(ctx)
+		}
+	}
+}
+
+// doConsumeReservation claims a reservation for deploying
+// deploy will take over processing status of the reservation
+func (c *Calcium) doConsumeReservation(ctx context.Context, opts *types.DeployOptions) ([]types.NodeInfo, error) {
+	reservation, err := c.store.GetReservation(ctx, opts.ReservationID)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.Dispatching {
+		return nil, types.NewDetailedErr(types.ErrReservationInUse, reservation.ID)
+	}
+	if reservation.Expired() {
+		return nil, types.NewDetailedErr(types.ErrReservationExpired, reservation.ID)
+	}
+	if !reservation.Match(opts) {
+		return nil, types.NewDetailedErr(types.ErrReservationMismatch, reservation.ID)
+	}
+	if err = c.store.RemoveReservation(ctx, reservation.ID); err != nil {
+		return nil, err
+	}
+	opts.ProcessIdent = reservation.ID
+	return reservation.NodesInfo, nil
+}
+
+func (c *Calcium) doReleaseReservation(ctx context.Context, reservation *types.Reservation) {
+	opts := &types.DeployOptions{
+		Name:         reservation.Appname,
+		Entrypoint:   &types.Entrypoint{Name: reservation.Entrypoint},
+		ProcessIdent: reservation.ID,
+	}
+	usage := types.QuotaUsage{CPU: reservation.CPUQuota, Memory: reservation.Memory, Storage: reservation.Storage, Volume: reservation.Volumes.TotalSize(), Containers: 1}
+	for _, nodeInfo := range reservation.NodesInfo {
+		// containers already dispatched keep their resources
+		remain, err := c.store.GetProcessing(ctx, opts, nodeInfo.Name)
+		if err != nil {
+			log.Errorf("[doReleaseReservation] Get processing status on %s failed %v", nodeInfo.Name, err)
+			continue
+		}
+		nodeInfo = remainingNodeInfo(nodeInfo, remain)
+		if nodeInfo.Deploy > 0 {
+			nodeCPUPlans := map[string][]types.CPUMap{}
+			if len(nodeInfo.CPUPlan) > 0 {
+				nodeCPUPlans[nodeInfo.Name] = nodeInfo.CPUPlan
+			}
+			nodeVolumePlans := map[string][]types.VolumePlan{}
+			if len(nodeInfo.VolumePlans) > 0 {
+				nodeVolumePlans[nodeInfo.Name] = nodeInfo.VolumePlans
+			}
+			nodeGPUPlans := map[string][]types.GPUMap{}
+			if len(nodeInfo.GPUPlan) > 0 {
+				nodeGPUPlans[nodeInfo.Name] = nodeInfo.GPUPlan
+			}
+			cpuCost, quotaCost, memoryCost, storageCost, volumeCost, hugepagesCost, gpuCost := calcCost(
+				nodeInfo, reservation.Memory, reservation.Storage, reservation.CPUQuota, reservation.Hugepages, nodeCPUPlans, nodeVolumePlans, nodeGPUPlans,
+			)
+			if err := c.withNodeLocked(ctx, nodeInfo.Name, func(node *types.Node) error {
+				if err := c.store.UpdateNodeResource(ctx, node, cpuCost, quotaCost, memoryCost, storageCost, volumeCost, hugepagesCost, gpuCost, store.ActionIncr); err != nil {
+					return err
+				}
+				// quotas of undispatched containers are given back along with resources
+				c.refundQuotas(ctx, reservation.Podname, reservation.Appname, usage.Times(nodeInfo.Deploy))
+				return nil
+			}); err != nil {
+				log.Errorf("[doReleaseReservation] Release resource on %s failed %v", nodeInfo.Name, err)
+			}
+		}
+		if err := c.store.DeleteProcessing(ctx, opts, nodeInfo); err != nil {
+			log.Errorf("[doReleaseReservation] Remove processing status on %s failed %v", nodeInfo.Name, err)
+		}
+	}
+}
+
+// remainingNodeInfo keeps the last remain containers of node, containers are dispatched in order of plans
+func remainingNodeInfo(nodeInfo types.NodeInfo, remain int) types.NodeInfo {
+	if remain > nodeInfo.Deploy {
+		remain = nodeInfo.Deploy
+	}
+	done := nodeInfo.Deploy - remain
+	nodeInfo.Deploy = remain
+	if len(nodeInfo.CPUPlan) >= done {
+		nodeInfo.CPUPlan = nodeInfo.CPUPlan[done:]
+	}
+	if len(nodeInfo.VolumePlans) >= done {
+		nodeInfo.VolumePlans = nodeInfo.VolumePlans[done:]
+	}
+	if len(nodeInfo.GPUPlan) >= done {
+		nodeInfo.GPUPlan = nodeInfo.GPUPlan[done:]
+	}
+	return nodeInfo
+}
+
+// trackDispatch saves resources reserved for a deploy and renews them until it's done,
+// if core crashes during the deploy, they are released by reservation watcher after expiry
+func (c *Calcium) trackDispatch(ctx context.Context, opts *types.DeployOptions, nodesInfo []types.NodeInfo) func() {
+	expire := c.config.DispatchExpire
+	if expire <= 0 {
+		return func() {}
+	}
+	reservation := newReservation(opts, nodesInfo, expire)
+	reservation.Dispatching = true
+	if err := c.store.AddReservation(ctx, reservation); err != nil {
+		log.Warnf("[trackDispatch] Track deploy %s failed %v, resources won't be released if core crashes", opts.ProcessIdent, err)
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(expire / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				reservation.Expire = time.Now().Add(expire)
+				if err := c.store.UpdateReservation(context.Background(), reservation); err != nil {
+					log.Errorf("[trackDispatch] Renew deploy %s failed %v", opts.ProcessIdent, err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		if err := c.store.RemoveReservation(context.Background(), reservation.ID); err != nil {
+			log.Errorf("[trackDispatch] Remove deploy %s failed %v", opts.ProcessIdent, err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package calcium
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/projecteru2/core/cluster"
+	schedulermocks "github.com/projecteru2/core/scheduler/mocks"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReserve(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := c.store.(*storemocks.Store)
+	sched := c.scheduler.(*schedulermocks.Scheduler)
+	opts := &types.ReserveOptions{
+		DeployOptions: types.DeployOptions{
+			Name:           "app",
+			Podname:        "p1",
+			Entrypoint:     &types.Entrypoint{Name: "web"},
+			Memory:         10,
+			Count:          2,
+			DeployStrategy: cluster.DeployAuto,
+		},
+	}
+	// bad ttl
+	_, err := c.Reserve(ctx, opts)
+	assert.Error(t, err)
+	opts.TTL = time.Minute
+
+	node := &types.Node{Name: "n1", MemCap: 100, InitMemCap: 100}
+	nodesInfo := []types.NodeInfo{{Name: "n1", MemCap: 100, Capacity: 10, Deploy: 2}}
+	store.On("GetNodesByPod", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*types.Node{node}, nil)
+	store.On("GetNode", mock.Anything, mock.Anything).Return(node, nil)
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(&dummyLock{}, nil)
+	store.On("MakeDeployStatus", mock.Anything, mock.Anything, mock.Anything).Return(nodesInfo, nil)
+	store.On("GetPod", mock.Anything, mock.Anything).Return(&types.Pod{Name: "p1"}, nil)
+	store.On("UpdateNodeResource",
+		mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+	).Return(nil)
+	store.On("SaveProcessing", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	store.On("DeleteProcessing", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	store.On("GetProcessing", mock.Anything, mock.Anything, mock.Anything).Return(2, nil)
+	sched.On("SelectMemoryNodes", mock.Anything, mock.Anything, mock.Anything).Return(nodesInfo, 10, nil)
+	sched.On("SelectStorageNodes", mock.Anything, mock.Anything).Return(nodesInfo, 10, nil)
+	sched.On("SelectHugepageNodes", mock.Anything, mock.Anything).Return(nodesInfo, 10, nil)
+	sched.On("SelectGPUNodes", mock.Anything, mock.Anything).Return(nodesInfo, nil, 10, nil)
+	sched.On("SelectVolumeNodes", mock.Anything, mock.Anything).Return(nodesInfo, nil, 10, nil)
+	sched.On("CommonDivision", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nodesInfo, nil)
+	// quotas of pod and app are charged by reserving, and refunded by releasing
+	usage := types.QuotaUsage{Memory: 20, Containers: 2}
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopePod, "p1", usage).Return(nil)
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopePod, "p1", usage.Neg()).Return(nil)
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopeApp, "app", usage).Return(nil)
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopeApp, "app", usage.Neg()).Return(nil)
+
+	// failed to save, resources are given back
+	store.On("AddReservation", mock.Anything, mock.Anything).Return(types.ErrNoETCD).Once()
+	_, err = c.Reserve(ctx, opts)
+	assert.Error(t, err)
+	store.AssertNumberOfCalls(t, "DeleteProcessing", 1)
+
+	store.On("AddReservation", mock.Anything, mock.Anything).Return(nil)
+	r, err := c.Reserve(ctx, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, r.Count(), 2)
+	assert.Equal(t, r.Appname, "app")
+	assert.False(t, r.Expired())
+
+	// consume
+	store.On("GetReservation", mock.Anything, r.ID).Return(r, nil)
+	deployOpts := opts.DeployOptions
+	deployOpts.ReservationID = r.ID
+	deployOpts.Count = 3
+	_, err = c.doConsumeReservation(ctx, &deployOpts)
+	assert.True(t, errors.Is(err, types.ErrReservationMismatch))
+	deployOpts.Count = 2
+	store.On("RemoveReservation", mock.Anything, r.ID).Return(nil)
+	ns, err := c.doConsumeReservation(ctx, &deployOpts)
+	assert.NoError(t, err)
+	assert.Equal(t, deployOpts.ProcessIdent, r.ID)
+	assert.Equal(t, ns[0].Deploy, 2)
+
+	// release
+	assert.NoError(t, c.ReleaseReservation(ctx, r.ID))
+	store.AssertNumberOfCalls(t, "DeleteProcessing", 2)
+	store.AssertNumberOfCalls(t, "ChargeQuota", 8)
+
+	// expired reservations are released
+	r.Expire = time.Now().Add(-time.Second)
+	_, err = c.doConsumeReservation(ctx, &deployOpts)
+	assert.True(t, errors.Is(err, types.ErrReservationExpired))
+	store.On("ListReservations", mock.Anything).Return([]*types.Reservation{r, {ID: "r2", Podname: "p2", Expire: time.Now().Add(time.Minute)}}, nil)
+	c.releaseExpiredReservations(ctx)
+	store.AssertNumberOfCalls(t, "RemoveReservation", 3)
+
+	rs, err := c.ListReservations(ctx, "p2")
+	assert.NoError(t, err)
+	assert.Len(t, rs, 1)
+	rs, err = c.ListReservations(ctx, "")
+	assert.NoError(t, err)
+	assert.Len(t, rs, 2)
+}
+
+func TestTrackDispatch(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := c.store.(*storemocks.Store)
+	opts := &types.DeployOptions{
+		Name:         "app",
+		Podname:      "p1",
+		Entrypoint:   &types.Entrypoint{Name: "web"},
+		Memory:       10,
+		ProcessIdent: "ident",
+	}
+	nodesInfo := []types.NodeInfo{{Name: "n1", Deploy: 2}}
+
+	// disabled
+	c.trackDispatch(ctx, opts, nodesInfo)()
+	store.AssertNotCalled(t, "AddReservation", mock.Anything, mock.Anything)
+
+	c.config.DispatchExpire = 30 * time.Millisecond
+	var tracked *types.Reservation
+	store.On("AddReservation", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		tracked = args.Get(1).(*types.Reservation)
+	}).Return(nil)
+	store.On("UpdateReservation", mock.Anything, mock.Anything).Return(nil)
+	store.On("RemoveReservation", mock.Anything, "ident").Return(nil)
+	stop := c.trackDispatch(ctx, opts, nodesInfo)
+	assert.True(t, tracked.Dispatching)
+	assert.Equal(t, "ident", tracked.ID)
+	time.Sleep(50 * time.Millisecond)
+	stop()
+	store.AssertCalled(t, "UpdateReservation", mock.Anything, mock.Anything)
+	store.AssertCalled(t, "RemoveReservation", mock.Anything, "ident")
+
+	// in use, can't be released or consumed
+	store.On("GetReservation", mock.Anything, "ident").Return(tracked, nil)
+	tracked.Expire = time.Now().Add(time.Minute)
+	assert.True(t, errors.Is(c.ReleaseReservation(ctx, "ident"), types.ErrReservationInUse))
+	_, err := c.doConsumeReservation(ctx, &types.DeployOptions{ReservationID: "ident"})
+	assert.True(t, errors.Is(err, types.ErrReservationInUse))
+	store.On("ListReservations", mock.Anything).Return([]*types.Reservation{tracked}, nil)
+	rs, err := c.ListReservations(ctx, "")
+	assert.NoError(t, err)
+	assert.Empty(t, rs)
+
+	// crashed, only containers not dispatched are released
+	tracked.Expire = time.Now().Add(-time.Second)
+	store.On("GetProcessing", mock.Anything, mock.Anything, "n1").Return(1, nil)
+	store.On("DeleteProcessing", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(&dummyLock{}, nil)
+	store.On("GetNode", mock.Anything, "n1").Return(&types.Node{Name: "n1"}, nil)
+	store.On("UpdateNodeResource",
+		mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		int64(10), mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+	).Return(nil).Once()
+	// quotas of the undispatched one are refunded
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopePod, "p1", types.QuotaUsage{Memory: -10, Containers: -1}).Return(nil).Once()
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopeApp, "app", types.QuotaUsage{Memory: -10, Containers: -1}).Return(nil).Once()
+	c.releaseExpiredReservations(ctx)
+	store.AssertExpectations(t)
+}
+
+func TestRemainingNodeInfo(t *testing.T) {
+	nodeInfo := types.NodeInfo{
+		Name:        "n1",
+		Deploy:      3,
+		CPUPlan:     []types.CPUMap{{"0": 100}, {"1": 100}, {"2": 100}},
+		VolumePlans: []types.VolumePlan{{}, {}, {}},
+	}
+	remaining := remainingNodeInfo(nodeInfo, 1)
+	assert.Equal(t, 1, remaining.Deploy)
+	assert.Equal(t, []types.CPUMap{{"2": 100}}, remaining.CPUPlan)
+	assert.Len(t, remaining.VolumePlans, 1)
+	assert.Equal(t, 3, remainingNodeInfo(nodeInfo, 5).Deploy)
+	assert.Equal(t, 0, remainingNodeInfo(nodeInfo, 0).Deploy)
+}
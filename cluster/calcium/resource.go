@@ -2,18 +2,31 @@ package calcium
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
 
-	"github.com/projecteru2/core/cluster"
 	"github.com/projecteru2/core/store"
+	"github.com/projecteru2/core/strategy"
 	"github.com/projecteru2/core/types"
 	"github.com/projecteru2/core/utils"
 )
 
+const (
+	resourceCPU       = "cpu"
+	resourceMemory    = "memory"
+	resourceStorage   = "storage"
+	resourceVolume    = "volume"
+	resourceHugepages = "hugepages"
+	resourceGPU       = "gpu"
+	// limitedByCordon tells a node takes no containers since it's cordoned
+	limitedByCordon = "cordon"
+)
+
 // PodResource show pod resource usage
 func (c *Calcium) PodResource(ctx context.Context, podname string) (*types.PodResource, error) {
 	nodes, err := c.ListPodNodes(ctx, podname, nil, true)
@@ -44,6 +57,57 @@ func (c *Calcium) PodResource(ctx context.Context, podname string) (*types.PodRe
 	return r, nil
 }
 
+// CapacityReport show total and allocated resources of pods and their nodes
+// all pods will be reported if podname is empty
+func (c *Calcium) CapacityReport(ctx context.Context, podname string) ([]*types.PodCapacityReport, error) {
+	var pods []*types.Pod
+	if podname != "" {
+		pod, err := c.store.GetPod(ctx, podname)
+		if err != nil {
+			return nil, err
+		}
+		pods = []*types.Pod{pod}
+	} else {
+		var err error
+		if pods, err = c.store.GetAllPods(ctx); err != nil {
+			return nil, err
+		}
+	}
+	processing, err := c.store.ListProcessing(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []*types.PodCapacityReport{}
+	for _, pod := range pods {
+		nodes, err := c.ListPodNodes(ctx, pod.Name, nil, true)
+		if err != nil {
+			return nil, err
+		}
+		report := &types.PodCapacityReport{Name: pod.Name, Nodes: []*types.NodeCapacityReport{}}
+		for _, node := range nodes {
+			nodeReport := &types.NodeCapacityReport{
+				Name: node.Name,
+				ResourceCapacity: types.ResourceCapacity{
+					CPU:         float64(len(node.InitCPU)),
+					CPUUsed:     node.CPUUsed,
+					Memory:      node.InitMemCap,
+					MemoryUsed:  node.InitMemCap - node.MemCap,
+					Storage:     node.InitStorageCap,
+					StorageUsed: node.StorageUsed(),
+					Volume:      node.InitVolume.Total(),
+					VolumeUsed:  node.VolumeUsed,
+					Processing:  processing[node.Name],
+				},
+			}
+			report.Add(nodeReport.ResourceCapacity)
+			report.Nodes = append(report.Nodes, nodeReport)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
 // NodeResource check node's container and resource
 func (c *Calcium) NodeResource(ctx context.Context, nodename string, fix bool) (*types.NodeResource, error) {
 	nr, err := c.doGetNodeResource(ctx, nodename, fix)
@@ -155,17 +219,127 @@ func (c *Calcium) doFixDiffResource(ctx context.Context, node *types.Node, cpus
 	)
 }
 
+// CalculateCapacity calculates how many containers can be deployed on each candidate node
+// every resource is calculated alone to find out which one limits the node, nothing will be allocated
+func (c *Calcium) CalculateCapacity(ctx context.Context, opts *types.DeployOptions) (*types.CapacityMessage, error) {
+	// capacity is only a report, nodes are read without locks so deploys aren't blocked
+	ns, err := c.getNodes(ctx, opts.Podname, opts.Nodename, opts.NodeLabels, false)
+	if err != nil {
+		return nil, err
+	}
+	nodes := map[string]*types.Node{}
+	for _, node := range ns {
+		nodes[node.Name] = node
+	}
+	if len(nodes) == 0 {
+		return nil, types.ErrInsufficientNodes
+	}
+	msg := &types.CapacityMessage{NodeCapacities: map[string]*types.NodeCapacity{}}
+	selectors := []struct {
+		resource string
+		selector func([]types.NodeInfo) ([]types.NodeInfo, error)
+	}{
+		{resourceCPU, func(nodesInfo []types.NodeInfo) ([]types.NodeInfo, error) {
+			if !opts.CPUBind || opts.CPUQuota == 0 {
+				return nil, nil
+			}
+			nodesInfo, _, _, err := c.scheduler.SelectCPUNodes(nodesInfo, opts.CPUQuota, opts.Memory)
+			return nodesInfo, err
+		}},
+		{resourceMemory, func(nodesInfo []types.NodeInfo) ([]types.NodeInfo, error) {
+			if opts.CPUBind && opts.CPUQuota != 0 {
+				return nil, nil
+			}
+			nodesInfo, _, err := c.scheduler.SelectMemoryNodes(nodesInfo, opts.CPUQuota, opts.Memory)
+			return nodesInfo, err
+		}},
+		{resourceStorage, func(nodesInfo []types.NodeInfo) ([]types.NodeInfo, error) {
+			nodesInfo, _, err := c.scheduler.SelectStorageNodes(nodesInfo, opts.Storage)
+			return nodesInfo, err
+		}},
+		{resourceVolume, func(nodesInfo []types.NodeInfo) ([]types.NodeInfo, error) {
+			nodesInfo, _, _, err := c.scheduler.SelectVolumeNodes(nodesInfo, opts.Volumes)
+			return nodesInfo, err
+		}},
+		{resourceHugepages, func(nodesInfo []types.NodeInfo) ([]types.NodeInfo, error) {
+			if opts.Hugepages.Total() == 0 {
+				return nil, nil
+			}
+			nodesInfo, _, err := c.scheduler.SelectHugepageNodes(nodesInfo, opts.Hugepages)
+			return nodesInfo, err
+		}},
+		{resourceGPU, func(nodesInfo []types.NodeInfo) ([]types.NodeInfo, error) {
+			if opts.GPURequest.DeviceShare() == 0 {
+				return nil, nil
+			}
+			nodesInfo, _, _, err := c.scheduler.SelectGPUNodes(nodesInfo, opts.GPURequest)
+			return nodesInfo, err
+		}},
+	}
+
+	for nodename, node := range nodes {
+		if node.Unschedulable {
+			msg.NodeCapacities[nodename] = &types.NodeCapacity{Nodename: nodename, LimitedBy: limitedByCordon}
+			continue
+		}
+		msg.NodeCapacities[nodename] = &types.NodeCapacity{Nodename: nodename, Capacity: math.MaxInt32}
+	}
+	for _, s := range selectors {
+		nodesInfo := getNodesInfo(nodes, opts.CPUQuota, opts.Memory, opts.Storage, opts.Volumes.TotalSize())
+		for i := range nodesInfo {
+			nodesInfo[i].Capacity = math.MaxInt32
+		}
+		nodesInfo, err := s.selector(nodesInfo)
+		if err != nil && !isInsufficientErr(err) {
+			return nil, err
+		}
+		if nodesInfo == nil && err == nil {
+			continue // resource not required
+		}
+		capacities := map[string]int{}
+		for _, nodeInfo := range nodesInfo {
+			capacities[nodeInfo.Name] = nodeInfo.Capacity
+		}
+		for nodename, nodeCapacity := range msg.NodeCapacities {
+			if capacities[nodename] < nodeCapacity.Capacity {
+				nodeCapacity.Capacity = capacities[nodename]
+				nodeCapacity.LimitedBy = s.resource
+			}
+		}
+	}
+	for _, nodeCapacity := range msg.NodeCapacities {
+		msg.Total += nodeCapacity.Capacity
+	}
+	return msg, nil
+}
+
+func isInsufficientErr(err error) bool {
+	for _, e := range []error{types.ErrInsufficientCPU, types.ErrInsufficientMEM, types.ErrInsufficientStorage, types.ErrInsufficientVolume, types.ErrInsufficientHugepages, types.ErrInsufficientGPU, types.ErrZeroNodes} {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Calcium) doAllocResource(ctx context.Context, opts *types.DeployOptions) ([]types.NodeInfo, error) {
 	var err error
 	var total int
 	var nodesInfo []types.NodeInfo
 	var nodeCPUPlans map[string][]types.CPUMap
 	var nodeVolumePlans map[string][]types.VolumePlan
+	var nodeGPUPlans map[string][]types.GPUMap
 	return nodesInfo, c.withNodesLocked(ctx, opts.Podname, opts.Nodename, opts.NodeLabels, false, func(nodes map[string]*types.Node) error {
 		if len(nodes) == 0 {
 			return types.ErrInsufficientNodes
 		}
+		if nodes, err = c.filterNodesByAffinity(ctx, nodes, opts); err != nil {
+			return err
+		}
 		nodesInfo = getNodesInfo(nodes, opts.CPUQuota, opts.Memory, opts.Storage, opts.Volumes.TotalSize())
+		if len(nodesInfo) == 0 {
+			return types.ErrInsufficientNodes
+		}
 		// 载入之前部署的情况
 		nodesInfo, err = c.store.MakeDeployStatus(ctx, opts, nodesInfo)
 		if err != nil {
@@ -192,7 +366,22 @@ func (c *Calcium) doAllocResource(ctx context.Context, opts *types.DeployOptions
 			return err
 		}
 
-		total = utils.Min(volumeTotal, storTotal, total)
+		var hugepagesTotal int
+		if nodesInfo, hugepagesTotal, err = c.scheduler.SelectHugepageNodes(nodesInfo, opts.Hugepages); err != nil {
+			return err
+		}
+
+		var gpuTotal int
+		if nodesInfo, nodeGPUPlans, gpuTotal, err = c.scheduler.SelectGPUNodes(nodesInfo, opts.GPURequest); err != nil {
+			return err
+		}
+
+		total = utils.Min(volumeTotal, storTotal, hugepagesTotal, gpuTotal, total)
+		if selfAntiAffinity(opts) {
+			var nodesTotal int
+			nodesInfo, nodesTotal = limitOnePerNode(nodesInfo)
+			total = utils.Min(total, nodesTotal)
+		}
 
 		volumeSchedule := false
 		for _, volume := range opts.Volumes {
@@ -203,19 +392,23 @@ func (c *Calcium) doAllocResource(ctx context.Context, opts *types.DeployOptions
 		}
 		resourceType := types.GetResourceType(opts.CPUBind, volumeSchedule)
 
-		switch opts.DeployMethod {
-		case cluster.DeployAuto:
-			nodesInfo, err = c.scheduler.CommonDivision(nodesInfo, opts.Count, total, resourceType)
-		case cluster.DeployEach:
-			nodesInfo, err = c.scheduler.EachDivision(nodesInfo, opts.Count, opts.NodesLimit, resourceType)
-		case cluster.DeployFill:
-			nodesInfo, err = c.scheduler.FillDivision(nodesInfo, opts.Count, opts.NodesLimit, resourceType)
-		case cluster.DeployGlobal:
-			nodesInfo, err = c.scheduler.GlobalDivision(nodesInfo, opts.Count, total, resourceType)
-		default:
-			return types.ErrBadDeployMethod
+		var pod *types.Pod
+		if pod, err = c.store.GetPod(ctx, opts.Podname); err != nil {
+			return err
 		}
-		if err != nil {
+
+		var deploy strategy.Strategy
+		if deploy, err = strategy.Get(opts.DeployStrategy); err != nil {
+			return err
+		}
+		if nodesInfo, err = deploy(c.scheduler, nodesInfo, &strategy.Options{
+			Count:        opts.Count,
+			Total:        total,
+			NodesLimit:   opts.NodesLimit,
+			ResourceType: resourceType,
+			Placement:    pod.Placement,
+			Nodes:        nodes,
+		}); err != nil {
 			return err
 		}
 
@@ -227,13 +420,23 @@ func (c *Calcium) doAllocResource(ctx context.Context, opts *types.DeployOptions
 			return types.ErrInsufficientRes
 		}
 		nodesInfo = nodesInfo[p:]
+
+		// quotas of pod and app are charged by containers deployed actually, under node locks
+		deployed := 0
+		for _, nodeInfo := range nodesInfo {
+			deployed += nodeInfo.Deploy
+		}
+		usage := deployQuotaUsage(opts).Times(deployed)
+		if err = c.chargeQuotas(ctx, opts.Podname, opts.Name, usage); err != nil {
+			return err
+		}
 		track := -1
 		return utils.Txn(
 			ctx,
 			func(ctx context.Context) error {
 				for i, nodeInfo := range nodesInfo {
-					cpuCost, quotaCost, memoryCost, storageCost, volumeCost := calcCost(
-						nodeInfo, opts.Memory, opts.Storage, opts.CPUQuota, nodeCPUPlans, nodeVolumePlans,
+					cpuCost, quotaCost, memoryCost, storageCost, volumeCost, hugepagesCost, gpuCost := calcCost(
+						nodeInfo, opts.Memory, opts.Storage, opts.CPUQuota, opts.Hugepages, nodeCPUPlans, nodeVolumePlans, nodeGPUPlans,
 					)
 					if _, ok := nodeCPUPlans[nodeInfo.Name]; ok {
 						nodesInfo[i].CPUPlan = nodeCPUPlans[nodeInfo.Name][:nodeInfo.Deploy]
@@ -241,7 +444,10 @@ func (c *Calcium) doAllocResource(ctx context.Context, opts *types.DeployOptions
 					if _, ok := nodeVolumePlans[nodeInfo.Name]; ok {
 						nodesInfo[i].VolumePlans = nodeVolumePlans[nodeInfo.Name][:nodeInfo.Deploy]
 					}
-					if err = c.store.UpdateNodeResource(ctx, nodes[nodeInfo.Name], cpuCost, quotaCost, memoryCost, storageCost, volumeCost, store.ActionDecr); err != nil {
+					if _, ok := nodeGPUPlans[nodeInfo.Name]; ok {
+						nodesInfo[i].GPUPlan = nodeGPUPlans[nodeInfo.Name][:nodeInfo.Deploy]
+					}
+					if err = c.store.UpdateNodeResource(ctx, nodes[nodeInfo.Name], cpuCost, quotaCost, memoryCost, storageCost, volumeCost, hugepagesCost, gpuCost, store.ActionDecr); err != nil {
 						return err // due to ctx lifecircle, this will be interrupted by client
 					}
 					track = i
@@ -257,11 +463,12 @@ func (c *Calcium) doAllocResource(ctx context.Context, opts *types.DeployOptions
 				return c.doBindProcessStatus(ctx, opts, nodesInfo)
 			},
 			func(ctx context.Context) error {
+				c.refundQuotas(ctx, opts.Podname, opts.Name, usage)
 				for i := 0; i < track+1; i++ {
-					cpuCost, quotaCost, memoryCost, storageCost, volumeCost := calcCost(
-						nodesInfo[i], opts.Memory, opts.Storage, opts.CPUQuota, nodeCPUPlans, nodeVolumePlans,
+					cpuCost, quotaCost, memoryCost, storageCost, volumeCost, hugepagesCost, gpuCost := calcCost(
+						nodesInfo[i], opts.Memory, opts.Storage, opts.CPUQuota, opts.Hugepages, nodeCPUPlans, nodeVolumePlans, nodeGPUPlans,
 					)
-					if err = c.store.UpdateNodeResource(ctx, nodes[nodesInfo[i].Name], cpuCost, quotaCost, memoryCost, storageCost, volumeCost, store.ActionIncr); err != nil {
+					if err = c.store.UpdateNodeResource(ctx, nodes[nodesInfo[i].Name], cpuCost, quotaCost, memoryCost, storageCost, volumeCost, hugepagesCost, gpuCost, store.ActionIncr); err != nil {
 						return err
 					}
 				}
@@ -283,14 +490,20 @@ func (c *Calcium) doBindProcessStatus(ctx context.Context, opts *types.DeployOpt
 
 func calcCost(
 	nodeInfo types.NodeInfo,
-	memory, storage int64, CPUQuota float64,
+	memory, storage int64, CPUQuota float64, hugepages types.HugepageMap,
 	nodeCPUPlans map[string][]types.CPUMap,
-	nodeVolumePlans map[string][]types.VolumePlan) (types.CPUMap, float64, int64, int64, types.VolumeMap) {
+	nodeVolumePlans map[string][]types.VolumePlan,
+	nodeGPUPlans map[string][]types.GPUMap) (types.CPUMap, float64, int64, int64, types.VolumeMap, types.HugepageMap, types.GPUMap) {
 	cpuCost := types.CPUMap{}
 	memoryCost := memory * int64(nodeInfo.Deploy)
 	storageCost := storage * int64(nodeInfo.Deploy)
 	quotaCost := CPUQuota * float64(nodeInfo.Deploy)
 	volumeCost := types.VolumeMap{}
+	hugepagesCost := types.HugepageMap{}
+	gpuCost := types.GPUMap{}
+	for size, count := range hugepages {
+		hugepagesCost[size] = count * int64(nodeInfo.Deploy)
+	}
 
 	if _, ok := nodeCPUPlans[nodeInfo.Name]; ok {
 		for _, cpu := range nodeCPUPlans[nodeInfo.Name][:nodeInfo.Deploy] {
@@ -304,5 +517,11 @@ func calcCost(
 		}
 	}
 
-	return cpuCost, quotaCost, memoryCost, storageCost, volumeCost
+	if _, ok := nodeGPUPlans[nodeInfo.Name]; ok {
+		for _, gpu := range nodeGPUPlans[nodeInfo.Name][:nodeInfo.Deploy] {
+			gpuCost.Add(gpu)
+		}
+	}
+
+	return cpuCost, quotaCost, memoryCost, storageCost, volumeCost, hugepagesCost, gpuCost
 }
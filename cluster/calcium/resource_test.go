@@ -2,6 +2,7 @@ package calcium
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -15,6 +16,7 @@ import (
 	"github.com/projecteru2/core/cluster"
 	enginemocks "github.com/projecteru2/core/engine/mocks"
 	lockmocks "github.com/projecteru2/core/lock/mocks"
+	complexscheduler "github.com/projecteru2/core/scheduler/complex"
 	schedulermocks "github.com/projecteru2/core/scheduler/mocks"
 	storemocks "github.com/projecteru2/core/store/mocks"
 	"github.com/projecteru2/core/types"
@@ -232,6 +234,16 @@ func TestAllocResource(t *testing.T) {
 	testAllocFailedAsInsufficientVolume(t, c, opts)
 	sched.On("SelectVolumeNodes", mock.Anything, mock.Anything).Return(nodesInfo, nodeVolumePlans, total, nil)
 
+	testAllocFailedAsInsufficientHugepages(t, c, opts)
+	sched.On("SelectHugepageNodes", mock.Anything, mock.Anything).Return(nodesInfo, total, nil)
+
+	testAllocFailedAsInsufficientGPU(t, c, opts)
+	sched.On("SelectGPUNodes", mock.Anything, mock.Anything).Return(nodesInfo, nil, total, nil)
+
+	pod := &types.Pod{Name: podname}
+	testAllocFailedAsGetPodError(t, c, opts)
+	store.On("GetPod", mock.Anything, mock.Anything).Return(pod, nil)
+
 	testAllocFailedAsWrongDeployMethod(t, c, opts)
 
 	testAllocFailedAsCommonDivisionError(t, c, opts)
@@ -240,13 +252,24 @@ func TestAllocResource(t *testing.T) {
 	testAllocFailedAsFillDivisionError(t, c, opts)
 
 	// Mocks for all.
-	opts.DeployMethod = cluster.DeployFill
+	opts.DeployStrategy = cluster.DeployFill
 	sched.On("FillDivision", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nodesInfo, nil)
 
+	testAllocFailedAsQuotaExceeded(t, c, opts)
+	// quota is charged by containers deployed, 3 on n2
+	usage := deployQuotaUsage(opts).Times(3)
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopePod, opts.Podname, usage).Return(nil)
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopePod, opts.Podname, usage.Neg()).Return(nil)
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopeApp, opts.Name, usage).Return(nil)
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopeApp, opts.Name, usage.Neg()).Return(nil)
+	testAllocWithPackPlacement(t, c, pod, opts)
+
 	testAllocFailedAsUpdateNodeResourceError(t, c, opts)
+	store.AssertCalled(t, "ChargeQuota", mock.Anything, types.QuotaScopePod, opts.Podname, usage.Neg())
+	store.AssertCalled(t, "ChargeQuota", mock.Anything, types.QuotaScopeApp, opts.Name, usage.Neg())
 	store.On("UpdateNodeResource",
 		mock.Anything, mock.Anything, mock.Anything, mock.Anything,
-		mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
 	).Return(nil)
 
 	testAllocFailedAsSaveProcessingError(t, c, opts)
@@ -333,23 +356,23 @@ func testAllocFailedAsInsufficientCPU(t *testing.T, c *Calcium, opts *types.Depl
 }
 
 func testAllocFailedAsWrongDeployMethod(t *testing.T, c *Calcium, opts *types.DeployOptions) {
-	ori := opts.DeployMethod
+	ori := opts.DeployStrategy
 	defer func() {
-		opts.DeployMethod = ori
+		opts.DeployStrategy = ori
 	}()
 
-	opts.DeployMethod = "invalid"
+	opts.DeployStrategy = "invalid"
 	_, err := c.doAllocResource(context.Background(), opts)
 	assert.Error(t, err)
 }
 
 func testAllocFailedAsCommonDivisionError(t *testing.T, c *Calcium, opts *types.DeployOptions) {
-	ori := opts.DeployMethod
+	ori := opts.DeployStrategy
 	defer func() {
-		opts.DeployMethod = ori
+		opts.DeployStrategy = ori
 	}()
 
-	opts.DeployMethod = cluster.DeployAuto
+	opts.DeployStrategy = cluster.DeployAuto
 	sched := c.scheduler.(*schedulermocks.Scheduler)
 	sched.On("CommonDivision", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, types.ErrInsufficientRes).Once()
 	_, err := c.doAllocResource(context.Background(), opts)
@@ -357,12 +380,12 @@ func testAllocFailedAsCommonDivisionError(t *testing.T, c *Calcium, opts *types.
 }
 
 func testAllocFailedAsGlobalDivisionError(t *testing.T, c *Calcium, opts *types.DeployOptions) {
-	ori := opts.DeployMethod
+	ori := opts.DeployStrategy
 	defer func() {
-		opts.DeployMethod = ori
+		opts.DeployStrategy = ori
 	}()
 
-	opts.DeployMethod = cluster.DeployGlobal
+	opts.DeployStrategy = cluster.DeployGlobal
 	sched := c.scheduler.(*schedulermocks.Scheduler)
 	sched.On("GlobalDivision", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, types.ErrInsufficientRes)
 	_, err := c.doAllocResource(context.Background(), opts)
@@ -370,12 +393,12 @@ func testAllocFailedAsGlobalDivisionError(t *testing.T, c *Calcium, opts *types.
 }
 
 func testAllocFailedAsEachDivisionError(t *testing.T, c *Calcium, opts *types.DeployOptions) {
-	ori := opts.DeployMethod
+	ori := opts.DeployStrategy
 	defer func() {
-		opts.DeployMethod = ori
+		opts.DeployStrategy = ori
 	}()
 
-	opts.DeployMethod = cluster.DeployEach
+	opts.DeployStrategy = cluster.DeployEach
 	sched := c.scheduler.(*schedulermocks.Scheduler)
 	sched.On("EachDivision", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, types.ErrInsufficientRes)
 	_, err := c.doAllocResource(context.Background(), opts)
@@ -383,23 +406,74 @@ func testAllocFailedAsEachDivisionError(t *testing.T, c *Calcium, opts *types.De
 }
 
 func testAllocFailedAsFillDivisionError(t *testing.T, c *Calcium, opts *types.DeployOptions) {
-	ori := opts.DeployMethod
+	ori := opts.DeployStrategy
 	defer func() {
-		opts.DeployMethod = ori
+		opts.DeployStrategy = ori
 	}()
 
-	opts.DeployMethod = cluster.DeployFill
+	opts.DeployStrategy = cluster.DeployFill
 	sched := c.scheduler.(*schedulermocks.Scheduler)
 	sched.On("FillDivision", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]types.NodeInfo{}, nil).Once()
 	_, err := c.doAllocResource(context.Background(), opts)
 	assert.Error(t, err)
 }
 
+func testAllocFailedAsGetPodError(t *testing.T, c *Calcium, opts *types.DeployOptions) {
+	store := c.store.(*storemocks.Store)
+	store.On("GetPod", mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD).Once()
+	_, err := c.doAllocResource(context.Background(), opts)
+	assert.Error(t, err)
+}
+
+func testAllocWithPackPlacement(t *testing.T, c *Calcium, pod *types.Pod, opts *types.DeployOptions) {
+	ori := opts.DeployStrategy
+	defer func() {
+		opts.DeployStrategy = ori
+		pod.Placement = ""
+	}()
+
+	pod.Placement = types.PlacementPack
+	opts.DeployStrategy = cluster.DeployAuto
+	sched := c.scheduler.(*schedulermocks.Scheduler)
+	sched.On("PackDivision", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, types.ErrInsufficientRes).Once()
+	_, err := c.doAllocResource(context.Background(), opts)
+	assert.Error(t, err)
+	sched.AssertCalled(t, "PackDivision", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func testAllocFailedAsQuotaExceeded(t *testing.T, c *Calcium, opts *types.DeployOptions) {
+	store := c.store.(*storemocks.Store)
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopePod, opts.Podname, mock.Anything).Return(types.ErrQuotaExceeded).Once()
+	_, err := c.doAllocResource(context.Background(), opts)
+	assert.True(t, errors.Is(err, types.ErrQuotaExceeded))
+	// app quota exceeded, pod quota is refunded
+	usage := deployQuotaUsage(opts).Times(3)
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopePod, opts.Podname, usage).Return(nil).Once()
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopeApp, opts.Name, usage).Return(types.ErrQuotaExceeded).Once()
+	store.On("ChargeQuota", mock.Anything, types.QuotaScopePod, opts.Podname, usage.Neg()).Return(nil).Once()
+	_, err = c.doAllocResource(context.Background(), opts)
+	assert.True(t, errors.Is(err, types.ErrQuotaExceeded))
+}
+
+func testAllocFailedAsInsufficientHugepages(t *testing.T, c *Calcium, opts *types.DeployOptions) {
+	sched := c.scheduler.(*schedulermocks.Scheduler)
+	sched.On("SelectHugepageNodes", mock.Anything, mock.Anything).Return(nil, 0, types.ErrInsufficientHugepages).Once()
+	_, err := c.doAllocResource(context.Background(), opts)
+	assert.Error(t, err)
+}
+
+func testAllocFailedAsInsufficientGPU(t *testing.T, c *Calcium, opts *types.DeployOptions) {
+	sched := c.scheduler.(*schedulermocks.Scheduler)
+	sched.On("SelectGPUNodes", mock.Anything, mock.Anything).Return(nil, nil, 0, types.ErrInsufficientGPU).Once()
+	_, err := c.doAllocResource(context.Background(), opts)
+	assert.Error(t, err)
+}
+
 func testAllocFailedAsUpdateNodeResourceError(t *testing.T, c *Calcium, opts *types.DeployOptions) {
 	store := c.store.(*storemocks.Store)
 	store.On("UpdateNodeResource",
 		mock.Anything, mock.Anything, mock.Anything, mock.Anything,
-		mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
 	).Return(types.ErrNoETCD).Once()
 	_, err := c.doAllocResource(context.Background(), opts)
 	assert.Error(t, err)
@@ -418,3 +492,106 @@ func testAllocFailedAsInsufficientVolume(t *testing.T, c *Calcium, opts *types.D
 	_, err := c.doAllocResource(context.Background(), opts)
 	assert.Error(t, err)
 }
+
+func TestCalculateCapacity(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	scheduler, err := complexscheduler.New(types.Config{Scheduler: types.SchedConfig{MaxShare: -1, ShareBase: 100}})
+	assert.NoError(t, err)
+	c.scheduler = scheduler
+	store := c.store.(*storemocks.Store)
+	n1 := &types.Node{
+		Name:           "n1",
+		CPU:            types.CPUMap{"0": 100, "1": 100},
+		InitCPU:        types.CPUMap{"0": 100, "1": 100},
+		MemCap:         100,
+		InitMemCap:     100,
+		StorageCap:     30,
+		InitStorageCap: 30,
+	}
+	n2 := &types.Node{
+		Name:           "n2",
+		CPU:            types.CPUMap{"0": 100, "1": 100},
+		InitCPU:        types.CPUMap{"0": 100, "1": 100},
+		MemCap:         20,
+		InitMemCap:     100,
+		StorageCap:     100,
+		InitStorageCap: 100,
+	}
+	store.On("GetNodesByPod", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*types.Node{n1, n2}, nil)
+	store.On("GetNode", mock.Anything, "n1").Return(n1, nil)
+	store.On("GetNode", mock.Anything, "n2").Return(n2, nil)
+
+	opts := &types.DeployOptions{Podname: "p1", Memory: 10, Storage: 10}
+	msg, err := c.CalculateCapacity(ctx, opts)
+	assert.NoError(t, err)
+	// deploys aren't blocked by reports
+	store.AssertNotCalled(t, "CreateLock", mock.Anything, mock.Anything)
+	assert.Equal(t, msg.Total, 5)
+	assert.Equal(t, msg.NodeCapacities["n1"].Capacity, 3)
+	assert.Equal(t, msg.NodeCapacities["n1"].LimitedBy, "storage")
+	assert.Equal(t, msg.NodeCapacities["n2"].Capacity, 2)
+	assert.Equal(t, msg.NodeCapacities["n2"].LimitedBy, "memory")
+
+	// not enough memory anywhere
+	opts.Memory = 200
+	msg, err = c.CalculateCapacity(ctx, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, msg.Total, 0)
+	assert.Equal(t, msg.NodeCapacities["n1"].LimitedBy, "memory")
+
+	// bad memory
+	opts.Memory = -1
+	_, err = c.CalculateCapacity(ctx, opts)
+	assert.Error(t, err)
+}
+
+func TestCapacityReport(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := c.store.(*storemocks.Store)
+	// failed by GetAllPods
+	store.On("GetAllPods", mock.Anything).Return(nil, types.ErrNoETCD).Once()
+	_, err := c.CapacityReport(ctx, "")
+	assert.Error(t, err)
+	store.On("GetAllPods", mock.Anything).Return([]*types.Pod{{Name: "p1"}}, nil)
+	store.On("ListProcessing", mock.Anything).Return(map[string]int{"n1": 2}, nil)
+	nodes := []*types.Node{
+		{
+			Name:           "n1",
+			InitCPU:        types.CPUMap{"0": 100, "1": 100},
+			CPUUsed:        1.5,
+			MemCap:         60,
+			InitMemCap:     100,
+			StorageCap:     10,
+			InitStorageCap: 100,
+			InitVolume:     types.VolumeMap{"/data": 1000},
+			VolumeUsed:     200,
+		},
+		{
+			Name:       "n2",
+			InitCPU:    types.CPUMap{"0": 100},
+			MemCap:     100,
+			InitMemCap: 100,
+		},
+	}
+	store.On("GetNodesByPod", mock.Anything, "p1", mock.Anything, true).Return(nodes, nil)
+	reports, err := c.CapacityReport(ctx, "")
+	assert.NoError(t, err)
+	assert.Len(t, reports, 1)
+	report := reports[0]
+	assert.Len(t, report.Nodes, 2)
+	assert.Equal(t, report.CPU, 3.0)
+	assert.Equal(t, report.CPUUsed, 1.5)
+	assert.Equal(t, report.Memory, int64(200))
+	assert.Equal(t, report.MemoryUsed, int64(40))
+	assert.Equal(t, report.StorageUsed, int64(90))
+	assert.Equal(t, report.Volume, int64(1000))
+	assert.Equal(t, report.VolumeUsed, int64(200))
+	assert.Equal(t, report.Processing, 2)
+	// by podname
+	store.On("GetPod", mock.Anything, "p1").Return(&types.Pod{Name: "p1"}, nil)
+	reports, err = c.CapacityReport(ctx, "p1")
+	assert.NoError(t, err)
+	assert.Len(t, reports, 1)
+}
@@ -0,0 +1,162 @@
+package calcium
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// retentionReport tells what a round of cleaning did, removed ones are only to remove in dry run
+type retentionReport struct {
+	Kept    []string
+	Removed []string
+	Failed  []string
+}
+
+func validateRetentionRules(rules []types.RetentionRule) error {
+	for _, rule := range rules {
+		if rule.Keep < 0 || rule.Grace < 0 {
+			return types.NewDetailedErr(types.ErrBadRetentionRule, "negative keep or grace")
+		}
+		if err := types.ValidateContainerStates(rule.States); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchRetentionRule returns the first rule for container in state, nil if none matches
+func matchRetentionRule(rules []types.RetentionRule, podname, appname, state string) *types.RetentionRule {
+	for i := range rules {
+		rule := &rules[i]
+		if (rule.Pod != "" && rule.Pod != podname) || (rule.App != "" && rule.App != appname) {
+			continue
+		}
+		if len(rule.States) == 0 {
+			return rule
+		}
+		for _, s := range rule.States {
+			if s == state {
+				return rule
+			}
+		}
+	}
+	return nil
+}
+
+type retentionCandidate struct {
+	container *types.Container
+	since     time.Time
+}
+
+// doCleanRetention removes exited, failed and replaced containers by retention rules,
+// the latest Keep ones of each app in pod are kept, the rest are removed with volumes after grace
+func (c *Calcium) doCleanRetention(ctx context.Context, now time.Time) (*retentionReport, error) {
+	containers, err := c.store.ListContainers(ctx, "", "", "", 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// store keeps no time of state changes, so it counts from first seen by this core
+	seen := map[string]time.Time{}
+	groups := map[*types.RetentionRule]map[string][]*retentionCandidate{}
+	for _, container := range containers {
+		state := container.RetentionState()
+		if state == "" {
+			continue
+		}
+		appname, _, _, err := utils.ParseContainerName(container.Name)
+		if err != nil {
+			log.Warnf("[doCleanRetention] Bad container name %s %v", container.Name, err)
+			continue
+		}
+		rule := matchRetentionRule(c.config.Retention.Rules, container.Podname, appname, state)
+		if rule == nil {
+			continue
+		}
+		since, ok := c.retentionSeen[container.ID]
+		if !ok {
+			since = now
+		}
+		seen[container.ID] = since
+		if groups[rule] == nil {
+			groups[rule] = map[string][]*retentionCandidate{}
+		}
+		key := container.Podname + "/" + appname
+		groups[rule][key] = append(groups[rule][key], &retentionCandidate{container: container, since: since})
+	}
+	c.retentionSeen = seen
+
+	report := &retentionReport{}
+	toRemove := []string{}
+	for rule, apps := range groups {
+		for _, candidates := range apps {
+			// latest first, ties broken by ID to be stable between rounds
+			sort.Slice(candidates, func(i, j int) bool {
+				if !candidates[i].since.Equal(candidates[j].since) {
+					return candidates[i].since.After(candidates[j].since)
+				}
+				return candidates[i].container.ID < candidates[j].container.ID
+			})
+			for i, candidate := range candidates {
+				if i < rule.Keep || now.Sub(candidate.since) < rule.Grace {
+					report.Kept = append(report.Kept, candidate.container.ID)
+					continue
+				}
+				toRemove = append(toRemove, candidate.container.ID)
+			}
+		}
+	}
+
+	if c.config.Retention.DryRun {
+		for _, ID := range toRemove {
+			log.Infof("[doCleanRetention] Container %s would be removed", ID)
+		}
+		report.Removed = toRemove
+		return report, nil
+	}
+	if len(toRemove) == 0 {
+		return report, nil
+	}
+	ch, err := c.RemoveContainer(ctx, toRemove, true, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	for message := range ch {
+		if !message.Success {
+			log.Errorf("[doCleanRetention] Remove container %s failed %v", message.ContainerID, message.Error)
+			report.Failed = append(report.Failed, message.ContainerID)
+			continue
+		}
+		delete(c.retentionSeen, message.ContainerID)
+		report.Removed = append(report.Removed, message.ContainerID)
+	}
+	return report, nil
+}
+
+func (c *Calcium) watchRetention(ctx context.Context) {
+	if c.config.Retention.Interval <= 0 || len(c.config.Retention.Rules) == 0 {
+		return
+	}
+	ticker := time.NewTicker(c.config.Retention.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cctx, cancel := context.WithTimeout(ctx, c.config.GlobalTimeout)
+			report, err := c.doCleanRetention(cctx, time.Now())
+			cancel()
+			if err != nil {
+				log.Errorf("[watchRetention] Clean containers failed %v", err)
+				continue
+			}
+			log.Infof("[watchRetention] Containers kept %d, removed %d, failed %d, dry run %v", len(report.Kept), len(report.Removed), len(report.Failed), c.config.Retention.DryRun)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	lockmocks "github.com/projecteru2/core/lock/mocks"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestValidateRetentionRules(t *testing.T) {
+	assert.NoError(t, validateRetentionRules(nil))
+	assert.NoError(t, validateRetentionRules([]types.RetentionRule{{Keep: 1, States: []string{types.ContainerStateFailed}}}))
+	assert.Error(t, validateRetentionRules([]types.RetentionRule{{Keep: -1}}))
+	assert.Error(t, validateRetentionRules([]types.RetentionRule{{States: []string{"running"}}}))
+}
+
+func TestMatchRetentionRule(t *testing.T) {
+	rules := []types.RetentionRule{
+		{Pod: "p1", App: "app", States: []string{types.ContainerStateFailed}, Keep: 1},
+		{Pod: "p1", Keep: 2},
+	}
+	assert.Equal(t, &rules[0], matchRetentionRule(rules, "p1", "app", types.ContainerStateFailed))
+	assert.Equal(t, &rules[1], matchRetentionRule(rules, "p1", "app", types.ContainerStateExited))
+	assert.Equal(t, &rules[1], matchRetentionRule(rules, "p1", "other", types.ContainerStateFailed))
+	assert.Nil(t, matchRetentionRule(rules, "p2", "app", types.ContainerStateFailed))
+}
+
+func TestDoCleanRetention(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	c.config.Retention.Rules = []types.RetentionRule{{Pod: "p1", Keep: 1, Grace: time.Hour}}
+
+	exited := &types.StatusMeta{Running: false}
+	containers := []*types.Container{
+		{ID: "running", Name: "app_web_aaaaaa", Podname: "p1", Nodename: "n1", StatusMeta: &types.StatusMeta{Running: true}},
+		{ID: "unknown", Name: "app_web_bbbbbb", Podname: "p1", Nodename: "n1"},
+		{ID: "c1", Name: "app_web_cccccc", Podname: "p1", Nodename: "n1", StatusMeta: exited},
+		{ID: "c2", Name: "app_web_dddddd", Podname: "p1", Nodename: "n1", StatusMeta: exited, Exits: &types.ContainerExits{LastExitCode: 1}},
+		{ID: "c3", Name: "app_web_eeeeee", Podname: "p1", Nodename: "n1", StatusMeta: &types.StatusMeta{Running: true}, Annotations: map[string]string{types.ContainerReplacedAnnotation: "new"}},
+		{ID: "other", Name: "app_web_ffffff", Podname: "p2", Nodename: "n2", StatusMeta: exited},
+	}
+
+	// failed by store
+	store.On("ListContainers", mock.Anything, "", "", "", int64(0), mock.Anything).Return(nil, types.ErrNoETCD).Once()
+	_, err := c.doCleanRetention(ctx, time.Now())
+	assert.Error(t, err)
+	store.On("ListContainers", mock.Anything, "", "", "", int64(0), mock.Anything).Return(containers, nil)
+
+	// all kept in grace
+	first := time.Now().Add(-2 * time.Hour)
+	report, err := c.doCleanRetention(ctx, first)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"c1", "c2", "c3"}, report.Kept)
+	assert.Empty(t, report.Removed)
+	assert.Len(t, c.retentionSeen, 3)
+
+	// dry run, the newest one is kept
+	c.config.Retention.DryRun = true
+	c.retentionSeen["c1"] = first.Add(time.Minute)
+	report, err = c.doCleanRetention(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c1"}, report.Kept)
+	assert.ElementsMatch(t, []string{"c2", "c3"}, report.Removed)
+	store.AssertNotCalled(t, "GetContainers", mock.Anything, mock.Anything)
+
+	// remove
+	c.config.Retention.DryRun = false
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+	engine := &enginemocks.API{}
+	engine.On("VirtualizationRemove", mock.Anything, "c2", true, true).Return(nil)
+	engine.On("VirtualizationRemove", mock.Anything, "c3", true, true).Return(types.ErrNilEngine)
+	containers[3].Engine = engine
+	containers[4].Engine = engine
+	store.On("GetContainers", mock.Anything, mock.Anything).Return([]*types.Container{containers[3], containers[4]}, nil)
+	store.On("GetNode", mock.Anything, "n1").Return(&types.Node{Name: "n1"}, nil)
+	store.On("RemoveContainer", mock.Anything, mock.Anything).Return(nil)
+	store.On("ChargeQuota", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	store.On("UpdateNodeResource", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	report, err = c.doCleanRetention(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c2"}, report.Removed)
+	assert.Equal(t, []string{"c3"}, report.Failed)
+	_, ok := c.retentionSeen["c2"]
+	assert.False(t, ok)
+	engine.AssertExpectations(t)
+}
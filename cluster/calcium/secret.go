@@ -0,0 +1,84 @@
+package calcium
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+)
+
+// SetSecret encrypts value by master key and saves it, the old one of the same name is replaced
+func (c *Calcium) SetSecret(ctx context.Context, name string, value []byte) error {
+	if err := types.ValidateSecretName(name); err != nil {
+		return err
+	}
+	key, err := c.secretsKey()
+	if err != nil {
+		return err
+	}
+	data, err := utils.Encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	return c.store.SetSecret(ctx, &types.Secret{Name: name, Data: data})
+}
+
+// ListSecrets lists names of secrets, values are never shown
+func (c *Calcium) ListSecrets(ctx context.Context) ([]string, error) {
+	secrets, err := c.store.ListSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, secret := range secrets {
+		names = append(names, secret.Name)
+	}
+	return names, nil
+}
+
+// RemoveSecret removes a secret by name, containers injected already keep it
+func (c *Calcium) RemoveSecret(ctx context.Context, name string) error {
+	return c.store.RemoveSecret(ctx, name)
+}
+
+func (c *Calcium) secretsKey() ([]byte, error) {
+	if c.config.Secrets.MasterKey == "" {
+		return nil, types.ErrNoMasterKey
+	}
+	key, err := base64.StdEncoding.DecodeString(c.config.Secrets.MasterKey)
+	if err != nil || len(key) != 32 {
+		return nil, types.NewDetailedErr(types.ErrNoMasterKey, "master key must be base64 of 32 bytes")
+	}
+	return key, nil
+}
+
+// resolveSecrets decrypts secrets referred by deploy, into env vars and files by path in container
+func (c *Calcium) resolveSecrets(ctx context.Context, refs []*types.SecretRef) ([]string, map[string][]byte, error) {
+	if len(refs) == 0 {
+		return nil, nil, nil
+	}
+	key, err := c.secretsKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	env := []string{}
+	files := map[string][]byte{}
+	for _, ref := range refs {
+		secret, err := c.store.GetSecret(ctx, ref.Name)
+		if err != nil {
+			return nil, nil, types.NewDetailedErr(err, "secret "+ref.Name)
+		}
+		value, err := utils.Decrypt(key, secret.Data)
+		if err != nil {
+			return nil, nil, types.NewDetailedErr(types.ErrBadSecret, fmt.Sprintf("decrypt %s failed %v", ref.Name, err))
+		}
+		if ref.Env != "" {
+			env = append(env, fmt.Sprintf("%s=%s", ref.Env, value))
+		} else {
+			files[ref.Path] = value
+		}
+	}
+	return env, files, nil
+}
@@ -0,0 +1,80 @@
+package calcium
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSetSecret(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+
+	// disabled
+	assert.True(t, errors.Is(c.SetSecret(ctx, "db", []byte("password")), types.ErrNoMasterKey))
+	c.config.Secrets.MasterKey = base64.StdEncoding.EncodeToString([]byte("short"))
+	assert.True(t, errors.Is(c.SetSecret(ctx, "db", []byte("password")), types.ErrNoMasterKey))
+
+	key := bytes.Repeat([]byte("k"), 32)
+	c.config.Secrets.MasterKey = base64.StdEncoding.EncodeToString(key)
+	assert.True(t, errors.Is(c.SetSecret(ctx, "a/b", []byte("password")), types.ErrBadSecret))
+	var saved *types.Secret
+	store.On("SetSecret", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		saved = args.Get(1).(*types.Secret)
+	})
+	assert.NoError(t, c.SetSecret(ctx, "db", []byte("password")))
+	assert.Equal(t, "db", saved.Name)
+	assert.NotContains(t, string(saved.Data), "password")
+	value, err := utils.Decrypt(key, saved.Data)
+	assert.NoError(t, err)
+	assert.Equal(t, "password", string(value))
+
+	store.On("ListSecrets", mock.Anything).Return([]*types.Secret{saved}, nil)
+	names, err := c.ListSecrets(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"db"}, names)
+}
+
+func TestDoMakeContainerOptionsWithSecrets(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	key := bytes.Repeat([]byte("k"), 32)
+	c.config.Secrets.MasterKey = base64.StdEncoding.EncodeToString(key)
+
+	password, err := utils.Encrypt(key, []byte("password"))
+	assert.NoError(t, err)
+	cert, err := utils.Encrypt(key, []byte("cert"))
+	assert.NoError(t, err)
+	store.On("GetSecret", mock.Anything, "db").Return(&types.Secret{Name: "db", Data: password}, nil)
+	store.On("GetSecret", mock.Anything, "tls").Return(&types.Secret{Name: "tls", Data: cert}, nil)
+	store.On("GetSecret", mock.Anything, "nope").Return(nil, types.ErrBadCount)
+
+	opts := &types.DeployOptions{
+		Name:       "app",
+		Entrypoint: &types.Entrypoint{Name: "web"},
+		Env:        []string{"A=1"},
+		SecretRefs: []*types.SecretRef{{Name: "db", Env: "DB_PASSWORD"}, {Name: "tls", Path: "/etc/tls.crt"}},
+	}
+	config, files, err := c.doMakeContainerOptions(ctx, 0, nil, nil, nil, opts, &types.Node{Name: "node1"})
+	assert.NoError(t, err)
+	assert.Contains(t, config.Env, "DB_PASSWORD=password")
+	assert.Equal(t, map[string][]byte{"/etc/tls.crt": []byte("cert")}, files)
+	// deploy options kept with container have no secrets
+	assert.Equal(t, []string{"A=1"}, opts.Env)
+
+	opts.SecretRefs = []*types.SecretRef{{Name: "nope", Env: "NOPE"}}
+	_, _, err = c.doMakeContainerOptions(ctx, 0, nil, nil, nil, opts, &types.Node{Name: "node1"})
+	assert.True(t, errors.Is(err, types.ErrBadCount))
+}
@@ -2,6 +2,7 @@ package calcium
 
 import (
 	"context"
+	"runtime"
 	"sync"
 	"time"
 
@@ -142,3 +143,21 @@ func (c *Calcium) unregisterService(ctx context.Context, addr string) error {
 	defer cancel()
 	return c.store.UnregisterService(ctx, addr)
 }
+
+// DebugInfo shows goroutines, memory and gc of core process, and state of store connection
+func (c *Calcium) DebugInfo(ctx context.Context) (*types.DebugInfo, error) {
+	stats := &runtime.MemStats{}
+	runtime.ReadMemStats(stats)
+	info := &types.DebugInfo{
+		Goroutines:   runtime.NumGoroutine(),
+		HeapAlloc:    stats.HeapAlloc,
+		HeapSys:      stats.HeapSys,
+		NumGC:        stats.NumGC,
+		GCPauseTotal: time.Duration(stats.PauseTotalNs),
+		StoreState:   c.store.ConnectionState(),
+	}
+	if stats.NumGC > 0 {
+		info.GCPauseLast = time.Duration(stats.PauseNs[(stats.NumGC+255)%256])
+	}
+	return info, nil
+}
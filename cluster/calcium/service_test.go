@@ -99,3 +99,14 @@ func TestWatchServiceStatus(t *testing.T) {
 	}()
 	wg.Wait()
 }
+
+func TestDebugInfo(t *testing.T) {
+	c := NewTestCluster()
+	store := c.store.(*storemocks.Store)
+	store.On("ConnectionState").Return("READY")
+	info, err := c.DebugInfo(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "READY", info.StoreState)
+	assert.True(t, info.Goroutines > 0)
+	assert.True(t, info.HeapAlloc > 0)
+}
@@ -31,8 +31,11 @@ func (c *Calcium) SetContainersStatus(ctx context.Context, status []*types.Statu
 		if !ok {
 			ttl = 0
 		}
-		container.StatusMeta = containerStatus
-		if err = c.store.SetContainerStatus(ctx, container, ttl); err != nil {
+		// health is owned by core if it checks the container
+		if c.coreHealthCheck(container) != nil {
+			containerStatus.Healthy = container.StatusMeta != nil && container.StatusMeta.Healthy
+		}
+		if err = c.doSetContainerStatus(ctx, container, containerStatus, ttl); err != nil {
 			return nil, err
 		}
 		r = append(r, container.StatusMeta)
@@ -40,6 +43,22 @@ func (c *Calcium) SetContainersStatus(ctx context.Context, status []*types.Statu
 	return r, nil
 }
 
+func (c *Calcium) doSetContainerStatus(ctx context.Context, container *types.Container, status *types.StatusMeta, ttl int64) error {
+	// only turning unhealthy or healthy again is an event, not every report of it
+	wasHealthy := container.StatusMeta == nil || container.StatusMeta.Healthy || !container.StatusMeta.Running
+	container.StatusMeta = status
+	if err := c.store.SetContainerStatus(ctx, container, ttl); err != nil {
+		return err
+	}
+	switch {
+	case wasHealthy && status.Running && !status.Healthy:
+		c.publishContainerEvent(types.EventContainerUnhealthy, container)
+	case !wasHealthy && status.Running && status.Healthy:
+		c.publishContainerEvent(types.EventContainerHealthy, container)
+	}
+	return nil
+}
+
 // ContainerStatusStream stream container status
 func (c *Calcium) ContainerStatusStream(ctx context.Context, appname, entrypoint, nodename string, labels map[string]string) chan *types.ContainerStatus {
 	return c.store.ContainerStatusStream(ctx, appname, entrypoint, nodename, labels)
@@ -0,0 +1,105 @@
+package calcium
+
+import (
+	"sync"
+	"time"
+
+	"github.com/projecteru2/core/metrics"
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// createSender sends create messages to a buffered channel,
+// full channel is handled by slow consumer policy of stream config
+type createSender struct {
+	sync.RWMutex
+	ch           chan *types.CreateContainerMessage
+	method       string
+	config       types.StreamConfig
+	dropped      int
+	disconnected bool
+	closed       bool
+}
+
+func newCreateSender(method string, config types.StreamConfig) *createSender {
+	buffer := config.Buffer
+	if buffer < 0 {
+		buffer = 0
+	}
+	return &createSender{
+		ch:     make(chan *types.CreateContainerMessage, buffer),
+		method: method,
+		config: config,
+	}
+}
+
+// send returns false if m is dropped
+func (s *createSender) send(m *types.CreateContainerMessage) bool {
+	start := time.Now()
+	sent := s.doSend(m)
+	metrics.Client.SendStreamWait(s.method, time.Since(start), !sent)
+	return sent
+}
+
+func (s *createSender) doSend(m *types.CreateContainerMessage) bool {
+	if s.config.SlowConsumer == "" || s.config.SlowConsumer == types.SlowConsumerBlock || s.config.Timeout <= 0 {
+		s.ch <- m
+		return true
+	}
+
+	s.RLock()
+	if s.disconnected {
+		s.RUnlock()
+		s.drop()
+		return false
+	}
+	timer := time.NewTimer(s.config.Timeout)
+	defer timer.Stop()
+	select {
+	case s.ch <- m:
+		s.RUnlock()
+		return true
+	case <-timer.C:
+		s.RUnlock()
+	}
+
+	log.Warnf("[createSender] %s consumer is slow, %s message", s.method, s.config.SlowConsumer)
+	s.drop()
+	if s.config.SlowConsumer == types.SlowConsumerDisconnect {
+		s.disconnect()
+	}
+	return false
+}
+
+func (s *createSender) drop() {
+	s.Lock()
+	defer s.Unlock()
+	s.dropped++
+}
+
+// disconnect closes channel to end the stream, pending sends time out before it
+func (s *createSender) disconnect() {
+	s.Lock()
+	defer s.Unlock()
+	if s.disconnected {
+		return
+	}
+	s.disconnected = true
+	s.closed = true
+	close(s.ch)
+}
+
+// close sends summary of dropped messages if any, then closes channel
+func (s *createSender) close() {
+	s.Lock()
+	defer s.Unlock()
+	if s.closed {
+		log.Warnf("[createSender] %s disconnected, %d messages dropped", s.method, s.dropped)
+		return
+	}
+	if s.dropped > 0 {
+		s.ch <- &types.CreateContainerMessage{Error: types.NewDetailedErr(types.ErrMessagesDropped, s.dropped)}
+	}
+	s.closed = true
+	close(s.ch)
+}
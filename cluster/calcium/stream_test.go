@@ -0,0 +1,49 @@
+package calcium
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateSenderBuffer(t *testing.T) {
+	s := newCreateSender("test", types.StreamConfig{Buffer: 2})
+	assert.True(t, s.send(&types.CreateContainerMessage{ContainerID: "1"}))
+	assert.True(t, s.send(&types.CreateContainerMessage{ContainerID: "2"}))
+	s.close()
+	ids := []string{}
+	for m := range s.ch {
+		ids = append(ids, m.ContainerID)
+	}
+	assert.Equal(t, []string{"1", "2"}, ids)
+}
+
+func TestCreateSenderDrop(t *testing.T) {
+	s := newCreateSender("test", types.StreamConfig{Buffer: 1, SlowConsumer: types.SlowConsumerDrop, Timeout: 10 * time.Millisecond})
+	assert.True(t, s.send(&types.CreateContainerMessage{ContainerID: "1"}))
+	assert.False(t, s.send(&types.CreateContainerMessage{ContainerID: "2"}))
+	assert.False(t, s.send(&types.CreateContainerMessage{ContainerID: "3"}))
+	go s.close()
+	ms := []*types.CreateContainerMessage{}
+	for m := range s.ch {
+		ms = append(ms, m)
+	}
+	assert.Len(t, ms, 2)
+	assert.Equal(t, "1", ms[0].ContainerID)
+	// summary of dropped messages
+	assert.True(t, errors.Is(ms[1].Error, types.ErrMessagesDropped))
+}
+
+func TestCreateSenderDisconnect(t *testing.T) {
+	s := newCreateSender("test", types.StreamConfig{SlowConsumer: types.SlowConsumerDisconnect, Timeout: 10 * time.Millisecond})
+	assert.False(t, s.send(&types.CreateContainerMessage{ContainerID: "1"}))
+	// channel is closed to end the stream
+	_, ok := <-s.ch
+	assert.False(t, ok)
+	assert.False(t, s.send(&types.CreateContainerMessage{ContainerID: "2"}))
+	s.close()
+	assert.Equal(t, 2, s.dropped)
+}
@@ -0,0 +1,233 @@
+package calcium
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/projecteru2/core/cluster"
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const supervisorLeader = "supervisor"
+
+// supervisorState is restarts of a container done by supervisor
+// only used by the goroutine supervising containers
+type supervisorState struct {
+	restarts    int
+	lastRestart time.Time
+	lostSince   time.Time
+	abandoned   bool
+}
+
+// supervised tells whether container is restarted by supervisor once it stops unexpectedly
+func supervised(container *types.Container) bool {
+	if container.Restart == nil || container.Restart.Policy == types.RestartNo {
+		return false
+	}
+	if _, ok := container.Annotations[types.ContainerStoppedAnnotation]; ok {
+		return false
+	}
+	state := container.RetentionState()
+	return state != types.ContainerStateReplaced && state != types.ContainerStateLost
+}
+
+// doMarkContainerStopped marks or unmarks container stopped by user, so supervisor leaves it stopped,
+// containers are only marked when supervisor is enabled
+func (c *Calcium) doMarkContainerStopped(ctx context.Context, container *types.Container, stopped bool) {
+	if c.config.Supervisor.Interval <= 0 {
+		return
+	}
+	if _, ok := container.Annotations[types.ContainerStoppedAnnotation]; ok == stopped {
+		return
+	}
+	annotations := map[string]string{}
+	for key, value := range container.Annotations {
+		annotations[key] = value
+	}
+	if stopped {
+		annotations[types.ContainerStoppedAnnotation] = time.Now().Format(time.RFC3339)
+	} else {
+		delete(annotations, types.ContainerStoppedAnnotation)
+	}
+	container.Annotations = annotations
+	if err := c.store.UpdateContainer(ctx, container); err != nil {
+		log.Errorf("[doMarkContainerStopped] Mark container %s stopped %v failed %v", container.ID, stopped, err)
+	}
+}
+
+// watchSupervisor campaigns for leader of supervisor, and supervises containers while it leads
+func (c *Calcium) watchSupervisor(ctx context.Context) {
+	if c.config.Supervisor.Interval <= 0 {
+		return
+	}
+	for {
+		lost, err := c.store.Campaign(ctx, supervisorLeader, c.config.Supervisor.LeaderTTL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Errorf("[watchSupervisor] Campaign failed %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.config.Supervisor.Interval):
+			}
+			continue
+		}
+		log.Info("[watchSupervisor] Elected as leader of supervisor")
+		c.doWatchSupervisor(ctx, lost)
+	}
+}
+
+func (c *Calcium) doWatchSupervisor(ctx context.Context, lost <-chan struct{}) {
+	states := map[string]*supervisorState{}
+	ticker := time.NewTicker(c.config.Supervisor.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lost:
+			return
+		case <-ticker.C:
+			cctx, cancel := context.WithTimeout(ctx, c.config.GlobalTimeout)
+			c.doSupervise(cctx, states, time.Now())
+			cancel()
+		}
+	}
+}
+
+// doSupervise reconciles containers with their restart policies,
+// exited ones are restarted with backoff, lost ones are deployed again in their pods
+func (c *Calcium) doSupervise(ctx context.Context, states map[string]*supervisorState, now time.Time) {
+	containers, err := c.store.ListContainers(ctx, "", "", "", 0, nil)
+	if err != nil {
+		log.Errorf("[doSupervise] List containers failed %v", err)
+		return
+	}
+	seen := map[string]bool{}
+	nodes := map[string]*types.Node{}
+	for _, container := range containers {
+		if !supervised(container) {
+			continue
+		}
+		seen[container.ID] = true
+		state, ok := states[container.ID]
+		if !ok {
+			state = &supervisorState{}
+			states[container.ID] = state
+		}
+		node, ok := nodes[container.Nodename]
+		if !ok {
+			if node, err = c.store.GetNode(ctx, container.Nodename); err != nil {
+				log.Errorf("[doSupervise] Get node %s failed %v", container.Nodename, err)
+				continue
+			}
+			nodes[container.Nodename] = node
+		}
+
+		lost, running := !node.Available, false
+		if !lost {
+			info, err := container.Inspect(ctx)
+			switch {
+			case errors.Is(err, types.ErrContainerNotExists):
+				lost = true
+			case err != nil:
+				log.Warnf("[doSupervise] Inspect container %s failed %v", container.ID, err)
+				continue
+			default:
+				running = info.Running
+			}
+		}
+		switch {
+		case lost:
+			c.doRedeployLostContainer(ctx, container, state, now)
+		case running:
+			state.lostSince = time.Time{}
+			if state.restarts > 0 && now.Sub(state.lastRestart) >= c.config.Supervisor.MaxBackoff {
+				state.restarts = 0
+			}
+		default:
+			state.lostSince = time.Time{}
+			c.doRestartExitedContainer(ctx, container, state, now)
+		}
+	}
+	// containers removed or stopped by user start over
+	for ID := range states {
+		if !seen[ID] {
+			delete(states, ID)
+		}
+	}
+}
+
+func (c *Calcium) doRestartExitedContainer(ctx context.Context, container *types.Container, state *supervisorState, now time.Time) {
+	policy := container.Restart
+	if policy.Policy == types.RestartOnFailure {
+		// exit code is unknown if agent didn't see it, e.g. node rebooted, it's taken as a failure
+		if e := container.Exits; e != nil && e.Exits > 0 && e.LastExitCode == 0 && (e.OOMKills == 0 || e.LastOOMKill.Before(e.LastExit)) {
+			return
+		}
+		if policy.MaxRetries > 0 && state.restarts >= policy.MaxRetries {
+			return
+		}
+	}
+	if state.restarts > 0 && now.Before(state.lastRestart.Add(c.supervisorBackoff(policy, state.restarts))) {
+		return
+	}
+	state.restarts++
+	state.lastRestart = now
+	log.Warnf("[doRestartExitedContainer] Container %s exited, restarting for the %d time", container.ID, state.restarts)
+	ch, err := c.ControlContainer(ctx, []string{container.ID}, cluster.ContainerStart, false, false, 0)
+	if err != nil {
+		log.Errorf("[doRestartExitedContainer] Restart container %s failed %v", container.ID, err)
+		return
+	}
+	for m := range ch {
+		if m.Error != nil {
+			log.Errorf("[doRestartExitedContainer] Restart container %s failed %v", container.ID, m.Error)
+		}
+	}
+}
+
+// doRedeployLostContainer deploys a container lost for lost timeout again in its pod,
+// the lost one is marked lost once the new one is created
+func (c *Calcium) doRedeployLostContainer(ctx context.Context, container *types.Container, state *supervisorState, now time.Time) {
+	if state.lostSince.IsZero() {
+		log.Warnf("[doRedeployLostContainer] Container %s on node %s is lost", container.ID, container.Nodename)
+		state.lostSince = now
+	}
+	if state.abandoned || now.Before(state.lostSince.Add(c.config.Supervisor.LostTimeout)) {
+		return
+	}
+	if state.restarts > 0 && now.Before(state.lastRestart.Add(c.supervisorBackoff(container.Restart, state.restarts))) {
+		return
+	}
+	// containers created before command is kept can't be deployed again
+	if len(container.Cmd) == 0 {
+		log.Errorf("[doRedeployLostContainer] Command of container %s is unknown, it won't be deployed again", container.ID)
+		state.abandoned = true
+		return
+	}
+	state.restarts++
+	state.lastRestart = now
+	log.Warnf("[doRedeployLostContainer] Deploy lost container %s again in pod %s", container.ID, container.Podname)
+	if _, err := c.doFailoverContainer(ctx, container); err != nil {
+		log.Errorf("[doRedeployLostContainer] Deploy lost container %s again failed %v", container.ID, err)
+	}
+}
+
+// supervisorBackoff is waited after restarts, backoff of restart policy is used if it's set
+func (c *Calcium) supervisorBackoff(policy *types.RestartPolicy, restarts int) time.Duration {
+	backoff := c.config.Supervisor.Backoff
+	if policy.Backoff > 0 {
+		backoff = time.Duration(policy.Backoff) * time.Second
+	}
+	maxBackoff := c.config.Supervisor.MaxBackoff
+	for i := 1; i < restarts && (maxBackoff <= 0 || backoff < maxBackoff); i++ {
+		backoff *= 2
+	}
+	if maxBackoff > 0 && backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,128 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	lockmocks "github.com/projecteru2/core/lock/mocks"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDoSuperviseRestartsExited(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	c.config.Supervisor = types.SupervisorConfig{Interval: time.Second, Backoff: 10 * time.Second, MaxBackoff: time.Minute, LostTimeout: time.Minute}
+	lock := &lockmocks.DistributedLock{}
+	lock.On("Lock", mock.Anything).Return(nil)
+	lock.On("Unlock", mock.Anything).Return(nil)
+	store.On("CreateLock", mock.Anything, mock.Anything).Return(lock, nil)
+
+	engine := &enginemocks.API{}
+	engine.On("VirtualizationInspect", mock.Anything, mock.Anything).Return(&enginetypes.VirtualizationInfo{Running: false}, nil)
+	engine.On("VirtualizationStart", mock.Anything, mock.Anything).Return(nil)
+	exited := &types.Container{ID: "exited", Nodename: "n1", Engine: engine, Restart: &types.RestartPolicy{Policy: types.RestartAlways}}
+	// exited cleanly
+	succeeded := &types.Container{ID: "succeeded", Nodename: "n1", Engine: engine, Restart: &types.RestartPolicy{Policy: types.RestartOnFailure},
+		Exits: &types.ContainerExits{Exits: 1, LastExit: time.Now()}}
+	stopped := &types.Container{ID: "stopped", Nodename: "n1", Engine: engine, Restart: &types.RestartPolicy{Policy: types.RestartAlways},
+		Annotations: map[string]string{types.ContainerStoppedAnnotation: "now"}}
+	never := &types.Container{ID: "never", Nodename: "n1", Engine: engine, Restart: &types.RestartPolicy{Policy: types.RestartNo}}
+	store.On("ListContainers", mock.Anything, "", "", "", int64(0), mock.Anything).Return([]*types.Container{exited, succeeded, stopped, never}, nil)
+	store.On("GetNode", mock.Anything, "n1").Return(&types.Node{Name: "n1", Available: true}, nil)
+	store.On("GetContainers", mock.Anything, []string{"exited"}).Return([]*types.Container{exited}, nil)
+
+	states := map[string]*supervisorState{}
+	now := time.Now()
+	c.doSupervise(ctx, states, now)
+	engine.AssertNumberOfCalls(t, "VirtualizationStart", 1)
+	assert.Equal(t, 1, states["exited"].restarts)
+	assert.Nil(t, states["stopped"])
+	assert.Nil(t, states["never"])
+
+	// backoff
+	c.doSupervise(ctx, states, now.Add(5*time.Second))
+	engine.AssertNumberOfCalls(t, "VirtualizationStart", 1)
+	c.doSupervise(ctx, states, now.Add(10*time.Second))
+	engine.AssertNumberOfCalls(t, "VirtualizationStart", 2)
+	// doubled
+	c.doSupervise(ctx, states, now.Add(25*time.Second))
+	engine.AssertNumberOfCalls(t, "VirtualizationStart", 2)
+	c.doSupervise(ctx, states, now.Add(30*time.Second))
+	engine.AssertNumberOfCalls(t, "VirtualizationStart", 3)
+}
+
+func TestDoSuperviseLost(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	c.config.Supervisor = types.SupervisorConfig{Interval: time.Second, Backoff: 10 * time.Second, MaxBackoff: time.Minute, LostTimeout: time.Minute}
+
+	engine := &enginemocks.API{}
+	engine.On("VirtualizationInspect", mock.Anything, "gone").Return(nil, types.NewDetailedErr(types.ErrContainerNotExists, "gone"))
+	engine.On("VirtualizationInspect", mock.Anything, "running").Return(&enginetypes.VirtualizationInfo{Running: true}, nil)
+	gone := &types.Container{ID: "gone", Nodename: "n1", Engine: engine, Restart: &types.RestartPolicy{Policy: types.RestartAlways}}
+	running := &types.Container{ID: "running", Nodename: "n1", Engine: engine, Restart: &types.RestartPolicy{Policy: types.RestartAlways}}
+	down := &types.Container{ID: "down", Nodename: "n2", Restart: &types.RestartPolicy{Policy: types.RestartAlways}}
+	store.On("ListContainers", mock.Anything, "", "", "", int64(0), mock.Anything).Return([]*types.Container{gone, running, down}, nil)
+	store.On("GetNode", mock.Anything, "n1").Return(&types.Node{Name: "n1", Available: true}, nil)
+	store.On("GetNode", mock.Anything, "n2").Return(&types.Node{Name: "n2", Available: false}, nil)
+
+	states := map[string]*supervisorState{}
+	now := time.Now()
+	c.doSupervise(ctx, states, now)
+	assert.Equal(t, now, states["gone"].lostSince)
+	assert.Equal(t, now, states["down"].lostSince)
+	assert.True(t, states["running"].lostSince.IsZero())
+
+	// command is unknown, it can't be deployed again
+	c.doSupervise(ctx, states, now.Add(time.Minute))
+	assert.True(t, states["gone"].abandoned)
+	assert.True(t, states["down"].abandoned)
+	assert.Equal(t, 0, states["gone"].restarts)
+}
+
+func TestSupervisorBackoff(t *testing.T) {
+	c := NewTestCluster()
+	c.config.Supervisor = types.SupervisorConfig{Backoff: 10 * time.Second, MaxBackoff: time.Minute}
+	policy := &types.RestartPolicy{Policy: types.RestartAlways}
+	assert.Equal(t, 10*time.Second, c.supervisorBackoff(policy, 1))
+	assert.Equal(t, 20*time.Second, c.supervisorBackoff(policy, 2))
+	assert.Equal(t, 40*time.Second, c.supervisorBackoff(policy, 3))
+	assert.Equal(t, time.Minute, c.supervisorBackoff(policy, 10))
+	policy.Backoff = 1
+	assert.Equal(t, 2*time.Second, c.supervisorBackoff(policy, 2))
+}
+
+func TestMarkContainerStopped(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	container := &types.Container{ID: "c1", Annotations: map[string]string{"a": "b"}}
+
+	// disabled
+	c.doMarkContainerStopped(ctx, container, true)
+	store.AssertNotCalled(t, "UpdateContainer", mock.Anything, mock.Anything)
+
+	c.config.Supervisor.Interval = time.Second
+	store.On("UpdateContainer", mock.Anything, container).Return(nil)
+	c.doMarkContainerStopped(ctx, container, true)
+	assert.NotEmpty(t, container.Annotations[types.ContainerStoppedAnnotation])
+	assert.Equal(t, "b", container.Annotations["a"])
+	c.doMarkContainerStopped(ctx, container, true)
+	store.AssertNumberOfCalls(t, "UpdateContainer", 1)
+	c.doMarkContainerStopped(ctx, container, false)
+	_, ok := container.Annotations[types.ContainerStoppedAnnotation]
+	assert.False(t, ok)
+	store.AssertNumberOfCalls(t, "UpdateContainer", 2)
+	assert.True(t, supervised(&types.Container{Restart: &types.RestartPolicy{Policy: types.RestartAlways}, Annotations: container.Annotations}))
+}
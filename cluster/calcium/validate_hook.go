@@ -0,0 +1,83 @@
+package calcium
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+)
+
+var hookShells = map[string]bool{"sh": true, "bash": true, "ash": true, "dash": true, "zsh": true}
+
+// ValidateHook checks hook commands against a running container without running them
+// binary of each command must exist, and shell scripts given by -c must parse
+// http hooks are only checked statically, no request is sent
+func (c *Calcium) ValidateHook(ctx context.Context, opts *types.ValidateHookOptions) ([]*types.HookResult, error) {
+	container, err := c.GetContainer(ctx, opts.ID)
+	if err != nil {
+		return nil, err
+	}
+	hook := opts.Hook
+	if hook == nil {
+		hook = container.Hook
+	}
+	if hook == nil {
+		return nil, nil
+	}
+	if err := hook.Validate(); err != nil {
+		return nil, err
+	}
+
+	results := []*types.HookResult{}
+	for _, event := range []string{types.HookAfterStart, types.HookBeforeStop, types.HookBeforeRemove} {
+		for _, stage := range hook.StagesOf(event) {
+			for _, cmd := range stage.Commands {
+				result := &types.HookResult{Stage: stage.Name, Cmd: cmd}
+				if hook.Type != types.HookTypeHTTP {
+					user := stage.User
+					if user == "" {
+						user = container.User
+					}
+					result = validateHookCmd(ctx, container, cmd, user, stage.WorkingDir)
+					result.Stage = stage.Name
+				}
+				results = append(results, result)
+			}
+		}
+	}
+	return results, nil
+}
+
+// validateHookCmd looks up binary of cmd by `command -v` inside container, and checks syntax by `-n` if cmd is a shell script
+func validateHookCmd(ctx context.Context, container *types.Container, cmd, user, workingDir string) *types.HookResult {
+	args := utils.MakeCommandLineArgs(cmd)
+	if len(args) == 0 {
+		return &types.HookResult{Cmd: cmd, Error: types.NewDetailedErr(types.ErrInvalidHook, "empty command")}
+	}
+	// binary name goes as $0 so it needs no quoting
+	check := []string{"sh", "-c", `command -v "$0"`, args[0]}
+	result := execuateArgsInside(ctx, container.Engine, container.ID, check, user, workingDir, container.Env, container.Privileged, nil)
+	if result.Error != nil {
+		result.Cmd = cmd
+		result.Error = types.NewDetailedErr(types.ErrInvalidHook, fmt.Sprintf("%s not found: %v", args[0], result.Error))
+		return result
+	}
+
+	if hookShells[filepath.Base(args[0])] {
+		for i := 1; i < len(args)-1; i++ {
+			if args[i] != "-c" {
+				continue
+			}
+			syntax := []string{args[0], "-n", "-c", args[i+1]}
+			result = execuateArgsInside(ctx, container.Engine, container.ID, syntax, user, workingDir, container.Env, container.Privileged, nil)
+			if result.Error != nil {
+				result.Error = types.NewDetailedErr(types.ErrInvalidHook, fmt.Sprintf("syntax error: %v", result.Error))
+			}
+			break
+		}
+	}
+	result.Cmd = cmd
+	return result
+}
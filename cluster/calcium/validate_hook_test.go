@@ -0,0 +1,69 @@
+package calcium
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestValidateHook(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := c.store.(*storemocks.Store)
+
+	// failed by GetContainer
+	store.On("GetContainer", mock.Anything, "notexists").Return(nil, types.ErrBadContainerID)
+	_, err := c.ValidateHook(ctx, &types.ValidateHookOptions{ID: "notexists"})
+	assert.Error(t, err)
+
+	engine := &enginemocks.API{}
+	cmds := [][]string{}
+	engine.On("ExecCreate", mock.Anything, mock.Anything, mock.Anything).Return("eid", nil).Run(func(args mock.Arguments) {
+		cmds = append(cmds, args.Get(2).(*enginetypes.ExecConfig).Cmd)
+	})
+	engine.On("ExecAttachOutput", mock.Anything, mock.Anything).Return(
+		ioutil.NopCloser(bytes.NewBuffer(nil)), ioutil.NopCloser(bytes.NewBuffer(nil)), nil,
+	)
+	engine.On("ExecExitCode", mock.Anything, mock.Anything).Return(0, nil).Once()
+	engine.On("ExecExitCode", mock.Anything, mock.Anything).Return(0, nil).Once()
+	engine.On("ExecExitCode", mock.Anything, mock.Anything).Return(127, nil).Once()
+	container := &types.Container{ID: "id", Engine: engine, Hook: &types.Hook{
+		AfterStart: []string{"sh -c 'register $ERU_CONTAINER_ID'", "smoke"},
+	}}
+	store.On("GetContainer", mock.Anything, "id").Return(container, nil)
+
+	// hook of container
+	results, err := c.ValidateHook(ctx, &types.ValidateHookOptions{ID: "id"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.NoError(t, results[0].Error)
+	assert.Equal(t, types.HookAfterStart, results[0].Stage)
+	assert.Equal(t, "sh -c 'register $ERU_CONTAINER_ID'", results[0].Cmd)
+	assert.True(t, errors.Is(results[1].Error, types.ErrInvalidHook))
+	assert.Equal(t, []string{"sh", "-c", `command -v "$0"`, "sh"}, cmds[0])
+	assert.Equal(t, []string{"sh", "-n", "-c", "register $ERU_CONTAINER_ID"}, cmds[1])
+	assert.Equal(t, []string{"sh", "-c", `command -v "$0"`, "smoke"}, cmds[2])
+
+	// invalid hook
+	_, err = c.ValidateHook(ctx, &types.ValidateHookOptions{ID: "id", Hook: &types.Hook{Type: "grpc"}})
+	assert.True(t, errors.Is(err, types.ErrInvalidHook))
+
+	// http hook sends nothing
+	results, err = c.ValidateHook(ctx, &types.ValidateHookOptions{ID: "id", Hook: &types.Hook{
+		Type:       types.HookTypeHTTP,
+		BeforeStop: []string{"http://sd/deregister"},
+	}})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, types.HookBeforeStop, results[0].Stage)
+	engine.AssertNumberOfCalls(t, "ExecCreate", 3)
+}
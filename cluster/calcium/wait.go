@@ -0,0 +1,106 @@
+package calcium
+
+import (
+	"context"
+	"sync"
+
+	"github.com/projecteru2/core/cluster"
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// WaitContainer waits until containers exited or healthy, a message is sent for each container once it's done
+func (c *Calcium) WaitContainer(ctx context.Context, opts *types.WaitOptions) (chan *types.WaitContainerMessage, error) {
+	if len(opts.IDs) == 0 {
+		return nil, types.ErrNoContainerIDs
+	}
+	if opts.Condition != cluster.WaitExit && opts.Condition != cluster.WaitHealthy {
+		return nil, types.NewDetailedErr(types.ErrBadWaitCondition, opts.Condition)
+	}
+	containers, err := c.GetContainers(ctx, opts.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *types.WaitContainerMessage)
+	go func() {
+		defer close(ch)
+		wctx := ctx
+		if opts.Timeout > 0 {
+			var cancel context.CancelFunc
+			wctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+			defer cancel()
+		}
+		wg := sync.WaitGroup{}
+		defer wg.Wait()
+		for _, container := range containers {
+			wg.Add(1)
+			go func(container *types.Container) {
+				defer wg.Done()
+				var msg *types.WaitContainerMessage
+				if opts.Condition == cluster.WaitExit {
+					msg = c.doWaitContainerExit(wctx, container)
+				} else {
+					msg = c.doWaitContainerHealthy(wctx, container)
+				}
+				if msg.Error != nil {
+					log.Errorf("[WaitContainer] Wait container %s %s failed %v", container.ID, opts.Condition, msg.Error)
+				}
+				ch <- msg
+			}(container)
+		}
+	}()
+	return ch, nil
+}
+
+func (c *Calcium) doWaitContainerExit(ctx context.Context, container *types.Container) *types.WaitContainerMessage {
+	msg := &types.WaitContainerMessage{ContainerID: container.ID}
+	r, err := container.Engine.VirtualizationWait(ctx, container.ID, "")
+	if r != nil {
+		msg.Code = r.Code
+		msg.Message = r.Message
+	}
+	msg.Error = err
+	return msg
+}
+
+func (c *Calcium) doWaitContainerHealthy(ctx context.Context, container *types.Container) *types.WaitContainerMessage {
+	msg := &types.WaitContainerMessage{ContainerID: container.ID}
+	appname, entrypoint, _, err := utils.ParseContainerName(container.Name)
+	if err != nil {
+		msg.Error = err
+		return msg
+	}
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// watch before checking, so no status change is missed
+	statusCh := c.store.ContainerStatusStream(sctx, appname, entrypoint, container.Nodename, nil)
+	if status, err := c.store.GetContainerStatus(ctx, container.ID); err == nil && status != nil && status.Healthy {
+		msg.Healthy = true
+		return msg
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			msg.Error = ctx.Err()
+			return msg
+		case status, ok := <-statusCh:
+			if !ok {
+				msg.Error = ctx.Err()
+				if msg.Error == nil {
+					msg.Error = types.ErrWatchClosed
+				}
+				return msg
+			}
+			// status deleted when container is down, keep waiting for it back
+			if status.ID != container.ID || status.Delete || status.Container == nil || status.Container.StatusMeta == nil {
+				continue
+			}
+			if status.Container.StatusMeta.Healthy {
+				msg.Healthy = true
+				return msg
+			}
+		}
+	}
+}
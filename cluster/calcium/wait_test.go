@@ -0,0 +1,95 @@
+package calcium
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/projecteru2/core/cluster"
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWaitContainer(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := c.store.(*storemocks.Store)
+
+	// no ids
+	_, err := c.WaitContainer(ctx, &types.WaitOptions{Condition: cluster.WaitExit})
+	assert.Error(t, err)
+	// bad condition
+	_, err = c.WaitContainer(ctx, &types.WaitOptions{IDs: []string{"c1"}, Condition: "xx"})
+	assert.Error(t, err)
+	// failed by store
+	store.On("GetContainers", mock.Anything, mock.Anything).Return(nil, types.ErrNoETCD).Once()
+	_, err = c.WaitContainer(ctx, &types.WaitOptions{IDs: []string{"c1"}, Condition: cluster.WaitExit})
+	assert.Error(t, err)
+
+	engine := &enginemocks.API{}
+	containers := []*types.Container{
+		{ID: "c1", Name: "app_web_aaaaaa", Nodename: "n1", Engine: engine},
+		{ID: "c2", Name: "app_web_bbbbbb", Nodename: "n1", Engine: engine},
+	}
+	store.On("GetContainers", mock.Anything, mock.Anything).Return(containers, nil)
+	engine.On("VirtualizationWait", mock.Anything, "c1", mock.Anything).Return(&enginetypes.VirtualizationWaitResult{Code: 0}, nil)
+	engine.On("VirtualizationWait", mock.Anything, "c2", mock.Anything).Return(&enginetypes.VirtualizationWaitResult{Code: 1, Message: "failed"}, nil)
+	ch, err := c.WaitContainer(ctx, &types.WaitOptions{IDs: []string{"c1", "c2"}, Condition: cluster.WaitExit})
+	assert.NoError(t, err)
+	codes := map[string]int64{}
+	for m := range ch {
+		assert.NoError(t, m.Error)
+		codes[m.ContainerID] = m.Code
+	}
+	assert.Equal(t, map[string]int64{"c1": 0, "c2": 1}, codes)
+}
+
+func TestWaitContainerHealthy(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := c.store.(*storemocks.Store)
+
+	c1 := &types.Container{ID: "c1", Name: "app_web_aaaaaa", Nodename: "n1"}
+	c2 := &types.Container{ID: "c2", Name: "app_web_bbbbbb", Nodename: "n1"}
+	c3 := &types.Container{ID: "c3", Name: "app_web_cccccc", Nodename: "n1"}
+	store.On("GetContainers", mock.Anything, mock.Anything).Return([]*types.Container{c1, c2, c3}, nil)
+	// c1 is healthy already
+	store.On("GetContainerStatus", mock.Anything, "c1").Return(&types.StatusMeta{ID: "c1", Healthy: true}, nil)
+	store.On("GetContainerStatus", mock.Anything, "c2").Return(&types.StatusMeta{ID: "c2"}, nil)
+	store.On("GetContainerStatus", mock.Anything, "c3").Return(nil, types.ErrContainerNotExists)
+	// c2 becomes healthy, c3 never
+	store.On("ContainerStatusStream", mock.Anything, "app", "web", "n1", mock.Anything).Return(func(ctx context.Context, _, _, _ string, _ map[string]string) chan *types.ContainerStatus {
+		statusCh := make(chan *types.ContainerStatus)
+		go func() {
+			defer close(statusCh)
+			for _, status := range []*types.ContainerStatus{
+				{ID: "c3", Container: &types.Container{StatusMeta: &types.StatusMeta{Running: true}}},
+				{ID: "c2", Delete: true},
+				{ID: "c2", Container: &types.Container{StatusMeta: &types.StatusMeta{Healthy: true}}},
+			} {
+				select {
+				case statusCh <- status:
+				case <-ctx.Done():
+					return
+				}
+			}
+			<-ctx.Done()
+		}()
+		return statusCh
+	})
+	ch, err := c.WaitContainer(ctx, &types.WaitOptions{IDs: []string{"c1", "c2", "c3"}, Condition: cluster.WaitHealthy, Timeout: 100 * time.Millisecond})
+	assert.NoError(t, err)
+	results := map[string]*types.WaitContainerMessage{}
+	for m := range ch {
+		results[m.ContainerID] = m
+	}
+	assert.True(t, results["c1"].Healthy)
+	assert.True(t, results["c2"].Healthy)
+	assert.NoError(t, results["c2"].Error)
+	assert.False(t, results["c3"].Healthy)
+	assert.Equal(t, context.DeadlineExceeded, results["c3"].Error)
+}
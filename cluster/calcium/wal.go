@@ -0,0 +1,73 @@
+package calcium
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/wal"
+	log "github.com/sirupsen/logrus"
+)
+
+const walCreateContainer = "create-container"
+
+// createContainerIntent is logged before a container is created by engine,
+// ID is filled once engine returns it
+type createContainerIntent struct {
+	Nodename string `json:"nodename"`
+	Name     string `json:"name"`
+	ID       string `json:"id,omitempty"`
+}
+
+// recoverWAL replays intents left by a crashed core
+func (c *Calcium) recoverWAL(ctx context.Context) {
+	c.wal.Recover(ctx, map[string]wal.Handler{
+		walCreateContainer: c.recoverCreateContainer,
+	})
+}
+
+// recoverCreateContainer removes a container created on engine but never saved in store.
+// it's not adopted, resources of containers not dispatched are given back by reservation watcher
+func (c *Calcium) recoverCreateContainer(ctx context.Context, data []byte) error {
+	intent := &createContainerIntent{}
+	if err := json.Unmarshal(data, intent); err != nil {
+		return err
+	}
+	node, err := c.GetNode(ctx, intent.Nodename)
+	if errors.Is(err, types.ErrBadCount) {
+		// node is gone with its containers
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ID := intent.ID
+	if ID == "" {
+		// crashed before engine returned, it may be created anyway
+		info, err := node.Engine.VirtualizationInspect(ctx, intent.Name)
+		if err != nil {
+			// engine is up, so it's never created
+			if _, infoErr := node.Engine.Info(ctx); infoErr == nil {
+				return nil
+			}
+			return err
+		}
+		ID = info.ID
+	}
+
+	_, err = c.store.GetContainer(ctx, ID)
+	if err == nil {
+		// saved before crash
+		return nil
+	}
+	if !errors.Is(err, types.ErrBadCount) {
+		return err
+	}
+	if err := node.Engine.VirtualizationRemove(ctx, ID, true, true); err != nil {
+		return err
+	}
+	log.Infof("[recoverCreateContainer] Container %s %s left on node %s removed", ID, intent.Name, intent.Nodename)
+	return nil
+}
@@ -0,0 +1,93 @@
+package calcium
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	storemocks "github.com/projecteru2/core/store/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/wal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRecoverCreateContainer(t *testing.T) {
+	c := NewTestCluster()
+	ctx := context.Background()
+	store := &storemocks.Store{}
+	c.store = store
+	engine := &enginemocks.API{}
+	node := &types.Node{Name: "n1", Engine: engine}
+	notFound := types.NewDetailedErr(types.ErrBadCount, "key")
+	data := func(intent *createContainerIntent) []byte {
+		b, _ := json.Marshal(intent)
+		return b
+	}
+
+	// node is gone
+	store.On("GetNode", mock.Anything, "gone").Return(nil, notFound)
+	assert.NoError(t, c.recoverCreateContainer(ctx, data(&createContainerIntent{Nodename: "gone", Name: "a"})))
+	store.On("GetNode", mock.Anything, "n1").Return(node, nil)
+
+	// never created, engine is up
+	engine.On("VirtualizationInspect", mock.Anything, "a").Return(nil, types.ErrNilEngine).Once()
+	engine.On("Info", mock.Anything).Return(&enginetypes.Info{}, nil).Once()
+	assert.NoError(t, c.recoverCreateContainer(ctx, data(&createContainerIntent{Nodename: "n1", Name: "a"})))
+	// engine is down, kept for next time
+	engine.On("VirtualizationInspect", mock.Anything, "a").Return(nil, types.ErrNilEngine).Once()
+	engine.On("Info", mock.Anything).Return(nil, types.ErrNilEngine).Once()
+	assert.Error(t, c.recoverCreateContainer(ctx, data(&createContainerIntent{Nodename: "n1", Name: "a"})))
+
+	// saved before crash
+	engine.On("VirtualizationInspect", mock.Anything, "b").Return(&enginetypes.VirtualizationInfo{ID: "saved"}, nil).Once()
+	store.On("GetContainer", mock.Anything, "saved").Return(&types.Container{ID: "saved"}, nil)
+	assert.NoError(t, c.recoverCreateContainer(ctx, data(&createContainerIntent{Nodename: "n1", Name: "b"})))
+
+	// store failed, kept
+	store.On("GetContainer", mock.Anything, "unknown").Return(nil, types.ErrNoETCD).Once()
+	assert.Error(t, c.recoverCreateContainer(ctx, data(&createContainerIntent{Nodename: "n1", Name: "c", ID: "unknown"})))
+
+	// leaked, removed
+	store.On("GetContainer", mock.Anything, "leaked").Return(nil, notFound)
+	engine.On("VirtualizationRemove", mock.Anything, "leaked", true, true).Return(nil).Once()
+	assert.NoError(t, c.recoverCreateContainer(ctx, data(&createContainerIntent{Nodename: "n1", Name: "d", ID: "leaked"})))
+	engine.AssertExpectations(t)
+}
+
+func TestRecoverWAL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wal")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := NewTestCluster()
+	c.wal, err = wal.Open(filepath.Join(dir, "core.wal"), time.Second)
+	assert.NoError(t, err)
+	defer c.wal.Close()
+	store := &storemocks.Store{}
+	c.store = store
+	engine := &enginemocks.API{}
+	store.On("GetNode", mock.Anything, "n1").Return(&types.Node{Name: "n1", Engine: engine}, nil)
+	store.On("GetContainer", mock.Anything, "leaked").Return(nil, types.NewDetailedErr(types.ErrBadCount, "key"))
+	engine.On("VirtualizationRemove", mock.Anything, "leaked", true, true).Return(types.ErrNilEngine).Once()
+
+	_, err = c.wal.Log(walCreateContainer, &createContainerIntent{Nodename: "n1", Name: "a", ID: "leaked"})
+	assert.NoError(t, err)
+	// failed removal is retried on next start
+	c.recoverWAL(context.Background())
+	entries, err := c.wal.Entries()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	engine.On("VirtualizationRemove", mock.Anything, "leaked", true, true).Return(nil).Once()
+	c.recoverWAL(context.Background())
+	entries, err = c.wal.Entries()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
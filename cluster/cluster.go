@@ -2,6 +2,7 @@ package cluster
 
 import (
 	"context"
+	"time"
 
 	enginetypes "github.com/projecteru2/core/engine/types"
 	"github.com/projecteru2/core/types"
@@ -12,6 +13,10 @@ const (
 	Gitlab = "gitlab"
 	// Github for github
 	Github = "github"
+	// Gitea for gitea
+	Gitea = "gitea"
+	// Bitbucket for bitbucket
+	Bitbucket = "bitbucket"
 	// CopyFailed for copy failed
 	CopyFailed = "failed"
 	// CopyOK for copy ok
@@ -26,6 +31,14 @@ const (
 	DeployFill = "fill"
 	// DeployGlobal for global node resource plan
 	DeployGlobal = "global"
+	// RebalanceSpread for even out containers between nodes
+	RebalanceSpread = "spread"
+	// RebalanceConsolidate for packing containers into fewer nodes
+	RebalanceConsolidate = "consolidate"
+	// WaitExit for waiting containers exited
+	WaitExit = "exit"
+	// WaitHealthy for waiting containers healthy
+	WaitHealthy = "healthy"
 	// ERUMark mark container controlled by eru
 	ERUMark = "ERU"
 	// LabelMeta store publish and health things
@@ -40,12 +53,15 @@ const (
 	ContainerLock = "clock_%s"
 	// NodeLock for lock node
 	NodeLock = "cnode_%s_%s"
+	// ScalePolicyLock for lock scaling of an app entrypoint
+	ScalePolicyLock = "cscale_%s_%s"
 )
 
 // Cluster define all interface
 type Cluster interface {
 	// meta service
 	WatchServiceStatus(context.Context) (<-chan types.ServiceStatus, error)
+	DebugInfo(context.Context) (*types.DebugInfo, error)
 	// meta networks
 	ListNetworks(ctx context.Context, podname string, driver string) ([]*enginetypes.Network, error)
 	ConnectNetwork(ctx context.Context, network, target, ipv4, ipv6 string) ([]string, error)
@@ -54,15 +70,66 @@ type Cluster interface {
 	AddPod(ctx context.Context, podname, desc string) (*types.Pod, error)
 	RemovePod(ctx context.Context, podname string) error
 	GetPod(ctx context.Context, podname string) (*types.Pod, error)
+	SetPodPlacement(ctx context.Context, podname, placement string) (*types.Pod, error)
+	SetPodHook(ctx context.Context, podname string, hook *types.Hook) (*types.Pod, error)
+	SetPodLocale(ctx context.Context, podname, timezone, locale string) (*types.Pod, error)
+	SetPodSysctlAllowlist(ctx context.Context, podname string, allowlist []string) (*types.Pod, error)
+	SetPodDeviceAllowlist(ctx context.Context, podname string, allowlist []string) (*types.Pod, error)
 	ListPods(ctx context.Context) ([]*types.Pod, error)
+	// quota
+	SetQuota(ctx context.Context, quota *types.Quota) error
+	ListQuotas(ctx context.Context) ([]*types.Quota, error)
+	RemoveQuota(ctx context.Context, scope, name string) error
 	// pod resource
 	PodResource(ctx context.Context, podname string) (*types.PodResource, error)
+	Reserve(ctx context.Context, opts *types.ReserveOptions) (*types.Reservation, error)
+	ListReservations(ctx context.Context, podname string) ([]*types.Reservation, error)
+	ReleaseReservation(ctx context.Context, id string) error
+	CalculateCapacity(ctx context.Context, opts *types.DeployOptions) (*types.CapacityMessage, error)
+	CapacityReport(ctx context.Context, podname string) ([]*types.PodCapacityReport, error)
+	FragmentationReport(ctx context.Context, podname string) ([]*types.NodeFragmentation, error)
+	ForecastCapacity(ctx context.Context, podname string, window time.Duration) (*types.CapacityForecast, error)
+	// scale policy
+	SetScalePolicy(ctx context.Context, policy *types.ScalePolicy) error
+	ListScalePolicies(ctx context.Context) ([]*types.ScalePolicy, error)
+	RemoveScalePolicy(ctx context.Context, appname, entrypoint string) error
+	// cron
+	SetCron(ctx context.Context, cron *types.Cron) error
+	ListCrons(ctx context.Context) ([]*types.Cron, error)
+	RemoveCron(ctx context.Context, name string) error
+	WatchCronRuns(ctx context.Context, name string) (<-chan *types.CronRun, error)
+	// cluster event
+	WatchEvents(ctx context.Context, filter *types.EventFilter) (<-chan *types.Event, error)
+	// audit log
+	RecordAuditLog(ctx context.Context, log *types.AuditLog) error
+	ListAuditLogs(ctx context.Context, filter *types.AuditFilter) ([]*types.AuditLog, error)
+	// event webhook
+	RegisterWebhook(ctx context.Context, webhook *types.EventWebhook) error
+	DeregisterWebhook(ctx context.Context, name string) error
+	ListWebhooks(ctx context.Context) ([]*types.EventWebhook, error)
+	// secret
+	SetSecret(ctx context.Context, name string, value []byte) error
+	ListSecrets(ctx context.Context) ([]string, error)
+	RemoveSecret(ctx context.Context, name string) error
+	// registry credential
+	SetRegistryCredential(ctx context.Context, opts *types.RegistryCredentialOptions) error
+	ListRegistryCredentials(ctx context.Context) ([]*types.RegistryCredential, error)
+	RemoveRegistryCredential(ctx context.Context, registry, podname string) error
+	// config object
+	SetConfigObject(ctx context.Context, object *types.ConfigObject) error
+	ListConfigObjects(ctx context.Context) ([]*types.ConfigObject, error)
+	RemoveConfigObject(ctx context.Context, name string) error
+	ReloadConfig(ctx context.Context, name, appname string) (chan *types.SendMessage, error)
 	// meta node
 	AddNode(context.Context, *types.AddNodeOptions) (*types.Node, error)
 	RemoveNode(ctx context.Context, nodename string) error
 	ListPodNodes(ctx context.Context, podname string, labels map[string]string, all bool) ([]*types.Node, error)
 	GetNode(ctx context.Context, nodename string) (*types.Node, error)
 	SetNode(ctx context.Context, opts *types.SetNodeOptions) (*types.Node, error)
+	CordonNode(ctx context.Context, nodename string, cordon bool) (*types.Node, error)
+	DrainNode(ctx context.Context, opts *types.DrainNodeOptions) (chan *types.MigrateMessage, error)
+	FailoverNode(ctx context.Context, nodename string) (chan *types.MigrateMessage, error)
+	NodeHeartbeat(ctx context.Context, nodename string, ttl time.Duration) error
 	// node resource
 	NodeResource(ctx context.Context, nodename string, fix bool) (*types.NodeResource, error)
 	// meta containers
@@ -70,26 +137,42 @@ type Cluster interface {
 	GetContainers(ctx context.Context, IDs []string) ([]*types.Container, error)
 	ListContainers(ctx context.Context, opts *types.ListContainersOptions) ([]*types.Container, error)
 	ListNodeContainers(ctx context.Context, nodename string, labels map[string]string) ([]*types.Container, error)
+	SetContainer(ctx context.Context, opts *types.SetContainerOptions) (*types.Container, error)
+	GetContainerMeta(ctx context.Context, ID, key string) ([]byte, error)
+	SetContainerMeta(ctx context.Context, ID, key string, value []byte) error
+	DeleteContainerMeta(ctx context.Context, ID, key string) error
 	GetContainersStatus(ctx context.Context, IDs []string) ([]*types.StatusMeta, error)
 	SetContainersStatus(ctx context.Context, status []*types.StatusMeta, ttls map[string]int64) ([]*types.StatusMeta, error)
+	RecordContainerEvents(ctx context.Context, events []*types.ContainerEvent) error
 	ContainerStatusStream(ctx context.Context, appname, entrypoint, nodename string, labels map[string]string) chan *types.ContainerStatus
 	// file methods
 	Copy(ctx context.Context, opts *types.CopyOptions) (chan *types.CopyMessage, error)
 	Send(ctx context.Context, opts *types.SendOptions) (chan *types.SendMessage, error)
 	// image methods
 	BuildImage(ctx context.Context, opts *types.BuildOptions) (chan *types.BuildImageMessage, error)
+	CommitContainer(ctx context.Context, opts *types.CommitOptions) (chan *types.BuildImageMessage, error)
 	CacheImage(ctx context.Context, podname, nodenmae string, images []string, step int) (chan *types.CacheImageMessage, error)
 	RemoveImage(ctx context.Context, podname, nodename string, images []string, step int, prune bool) (chan *types.RemoveImageMessage, error)
 	// container methods
 	CreateContainer(ctx context.Context, opts *types.DeployOptions) (chan *types.CreateContainerMessage, error)
+	CloneContainer(ctx context.Context, opts *types.CloneOptions) (chan *types.CreateContainerMessage, error)
+	WaitContainer(ctx context.Context, opts *types.WaitOptions) (chan *types.WaitContainerMessage, error)
 	ReplaceContainer(ctx context.Context, opts *types.ReplaceOptions) (chan *types.ReplaceContainerMessage, error)
-	RemoveContainer(ctx context.Context, IDs []string, force bool, step int) (chan *types.RemoveContainerMessage, error)
+	Rebalance(ctx context.Context, opts *types.RebalanceOptions) (chan *types.RebalanceMessage, error)
+	MigrateContainer(ctx context.Context, opts *types.MigrateOptions) (chan *types.MigrateMessage, error)
+	MigrateContainerToNode(ctx context.Context, ID, nodename string, timeout time.Duration) (*types.MigrateMessage, error)
+	CheckpointContainer(ctx context.Context, opts *types.CheckpointOptions) error
+	RestoreContainer(ctx context.Context, opts *types.RestoreOptions) error
+	RemoveContainer(ctx context.Context, IDs []string, force bool, step int, stopTimeout time.Duration) (chan *types.RemoveContainerMessage, error)
 	DissociateContainer(ctx context.Context, IDs []string) (chan *types.DissociateContainerMessage, error)
-	ControlContainer(ctx context.Context, IDs []string, t string, force bool) (chan *types.ControlContainerMessage, error)
+	AdoptContainer(ctx context.Context, opts *types.AdoptOptions) (*types.Container, error)
+	ControlContainer(ctx context.Context, IDs []string, t string, force, streamHook bool, stopTimeout time.Duration) (chan *types.ControlContainerMessage, error)
+	ValidateHook(ctx context.Context, opts *types.ValidateHookOptions) ([]*types.HookResult, error)
 	ExecuteContainer(ctx context.Context, opts *types.ExecuteContainerOptions, inCh <-chan []byte) chan *types.AttachContainerMessage
 	ReallocResource(ctx context.Context, opts *types.ReallocOptions) (chan *types.ReallocResourceMessage, error)
 	LogStream(ctx context.Context, opts *types.LogStreamOptions) (chan *types.LogStreamMessage, error)
 	RunAndWait(ctx context.Context, opts *types.DeployOptions, inCh <-chan []byte) (<-chan *types.AttachContainerMessage, error)
+	RunJob(ctx context.Context, opts *types.JobOptions) (<-chan *types.JobMessage, error)
 	// finalizer
 	Finalizer()
 }
@@ -8,6 +8,8 @@ import (
 	enginetypes "github.com/projecteru2/core/engine/types"
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	types "github.com/projecteru2/core/types"
 )
 
@@ -62,6 +64,29 @@ func (_m *Cluster) AddPod(ctx context.Context, podname string, desc string) (*ty
 	return r0, r1
 }
 
+// AdoptContainer provides a mock function with given fields: ctx, opts
+func (_m *Cluster) AdoptContainer(ctx context.Context, opts *types.AdoptOptions) (*types.Container, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 *types.Container
+	if rf, ok := ret.Get(0).(func(context.Context, *types.AdoptOptions) *types.Container); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Container)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.AdoptOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // BuildImage provides a mock function with given fields: ctx, opts
 func (_m *Cluster) BuildImage(ctx context.Context, opts *types.BuildOptions) (chan *types.BuildImageMessage, error) {
 	ret := _m.Called(ctx, opts)
@@ -108,6 +133,112 @@ func (_m *Cluster) CacheImage(ctx context.Context, podname string, nodenmae stri
 	return r0, r1
 }
 
+// CalculateCapacity provides a mock function with given fields: ctx, opts
+func (_m *Cluster) CalculateCapacity(ctx context.Context, opts *types.DeployOptions) (*types.CapacityMessage, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 *types.CapacityMessage
+	if rf, ok := ret.Get(0).(func(context.Context, *types.DeployOptions) *types.CapacityMessage); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.CapacityMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.DeployOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CapacityReport provides a mock function with given fields: ctx, podname
+func (_m *Cluster) CapacityReport(ctx context.Context, podname string) ([]*types.PodCapacityReport, error) {
+	ret := _m.Called(ctx, podname)
+
+	var r0 []*types.PodCapacityReport
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*types.PodCapacityReport); ok {
+		r0 = rf(ctx, podname)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.PodCapacityReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, podname)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CheckpointContainer provides a mock function with given fields: ctx, opts
+func (_m *Cluster) CheckpointContainer(ctx context.Context, opts *types.CheckpointOptions) error {
+	ret := _m.Called(ctx, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.CheckpointOptions) error); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CloneContainer provides a mock function with given fields: ctx, opts
+func (_m *Cluster) CloneContainer(ctx context.Context, opts *types.CloneOptions) (chan *types.CreateContainerMessage, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 chan *types.CreateContainerMessage
+	if rf, ok := ret.Get(0).(func(context.Context, *types.CloneOptions) chan *types.CreateContainerMessage); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan *types.CreateContainerMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.CloneOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CommitContainer provides a mock function with given fields: ctx, opts
+func (_m *Cluster) CommitContainer(ctx context.Context, opts *types.CommitOptions) (chan *types.BuildImageMessage, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 chan *types.BuildImageMessage
+	if rf, ok := ret.Get(0).(func(context.Context, *types.CommitOptions) chan *types.BuildImageMessage); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan *types.BuildImageMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.CommitOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ConnectNetwork provides a mock function with given fields: ctx, network, target, ipv4, ipv6
 func (_m *Cluster) ConnectNetwork(ctx context.Context, network string, target string, ipv4 string, ipv6 string) ([]string, error) {
 	ret := _m.Called(ctx, network, target, ipv4, ipv6)
@@ -147,13 +278,13 @@ func (_m *Cluster) ContainerStatusStream(ctx context.Context, appname string, en
 	return r0
 }
 
-// ControlContainer provides a mock function with given fields: ctx, IDs, t, force
-func (_m *Cluster) ControlContainer(ctx context.Context, IDs []string, t string, force bool) (chan *types.ControlContainerMessage, error) {
-	ret := _m.Called(ctx, IDs, t, force)
+// ControlContainer provides a mock function with given fields: ctx, IDs, t, force, streamHook, stopTimeout
+func (_m *Cluster) ControlContainer(ctx context.Context, IDs []string, t string, force bool, streamHook bool, stopTimeout time.Duration) (chan *types.ControlContainerMessage, error) {
+	ret := _m.Called(ctx, IDs, t, force, streamHook, stopTimeout)
 
 	var r0 chan *types.ControlContainerMessage
-	if rf, ok := ret.Get(0).(func(context.Context, []string, string, bool) chan *types.ControlContainerMessage); ok {
-		r0 = rf(ctx, IDs, t, force)
+	if rf, ok := ret.Get(0).(func(context.Context, []string, string, bool, bool, time.Duration) chan *types.ControlContainerMessage); ok {
+		r0 = rf(ctx, IDs, t, force, streamHook, stopTimeout)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(chan *types.ControlContainerMessage)
@@ -161,8 +292,8 @@ func (_m *Cluster) ControlContainer(ctx context.Context, IDs []string, t string,
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, []string, string, bool) error); ok {
-		r1 = rf(ctx, IDs, t, force)
+	if rf, ok := ret.Get(1).(func(context.Context, []string, string, bool, bool, time.Duration) error); ok {
+		r1 = rf(ctx, IDs, t, force, streamHook, stopTimeout)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -193,6 +324,29 @@ func (_m *Cluster) Copy(ctx context.Context, opts *types.CopyOptions) (chan *typ
 	return r0, r1
 }
 
+// CordonNode provides a mock function with given fields: ctx, nodename, cordon
+func (_m *Cluster) CordonNode(ctx context.Context, nodename string, cordon bool) (*types.Node, error) {
+	ret := _m.Called(ctx, nodename, cordon)
+
+	var r0 *types.Node
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) *types.Node); ok {
+		r0 = rf(ctx, nodename, cordon)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Node)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, nodename, cordon)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateContainer provides a mock function with given fields: ctx, opts
 func (_m *Cluster) CreateContainer(ctx context.Context, opts *types.DeployOptions) (chan *types.CreateContainerMessage, error) {
 	ret := _m.Called(ctx, opts)
@@ -216,6 +370,57 @@ func (_m *Cluster) CreateContainer(ctx context.Context, opts *types.DeployOption
 	return r0, r1
 }
 
+// DebugInfo provides a mock function with given fields: _a0
+func (_m *Cluster) DebugInfo(_a0 context.Context) (*types.DebugInfo, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *types.DebugInfo
+	if rf, ok := ret.Get(0).(func(context.Context) *types.DebugInfo); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.DebugInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteContainerMeta provides a mock function with given fields: ctx, ID, key
+func (_m *Cluster) DeleteContainerMeta(ctx context.Context, ID string, key string) error {
+	ret := _m.Called(ctx, ID, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, ID, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeregisterWebhook provides a mock function with given fields: ctx, name
+func (_m *Cluster) DeregisterWebhook(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DisconnectNetwork provides a mock function with given fields: ctx, network, target, force
 func (_m *Cluster) DisconnectNetwork(ctx context.Context, network string, target string, force bool) error {
 	ret := _m.Called(ctx, network, target, force)
@@ -253,6 +458,29 @@ func (_m *Cluster) DissociateContainer(ctx context.Context, IDs []string) (chan
 	return r0, r1
 }
 
+// DrainNode provides a mock function with given fields: ctx, opts
+func (_m *Cluster) DrainNode(ctx context.Context, opts *types.DrainNodeOptions) (chan *types.MigrateMessage, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 chan *types.MigrateMessage
+	if rf, ok := ret.Get(0).(func(context.Context, *types.DrainNodeOptions) chan *types.MigrateMessage); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan *types.MigrateMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.DrainNodeOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ExecuteContainer provides a mock function with given fields: ctx, opts, inCh
 func (_m *Cluster) ExecuteContainer(ctx context.Context, opts *types.ExecuteContainerOptions, inCh <-chan []byte) chan *types.AttachContainerMessage {
 	ret := _m.Called(ctx, opts, inCh)
@@ -269,11 +497,80 @@ func (_m *Cluster) ExecuteContainer(ctx context.Context, opts *types.ExecuteCont
 	return r0
 }
 
+// FailoverNode provides a mock function with given fields: ctx, nodename
+func (_m *Cluster) FailoverNode(ctx context.Context, nodename string) (chan *types.MigrateMessage, error) {
+	ret := _m.Called(ctx, nodename)
+
+	var r0 chan *types.MigrateMessage
+	if rf, ok := ret.Get(0).(func(context.Context, string) chan *types.MigrateMessage); ok {
+		r0 = rf(ctx, nodename)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan *types.MigrateMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, nodename)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Finalizer provides a mock function with given fields:
 func (_m *Cluster) Finalizer() {
 	_m.Called()
 }
 
+// ForecastCapacity provides a mock function with given fields: ctx, podname, window
+func (_m *Cluster) ForecastCapacity(ctx context.Context, podname string, window time.Duration) (*types.CapacityForecast, error) {
+	ret := _m.Called(ctx, podname, window)
+
+	var r0 *types.CapacityForecast
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) *types.CapacityForecast); ok {
+		r0 = rf(ctx, podname, window)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.CapacityForecast)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration) error); ok {
+		r1 = rf(ctx, podname, window)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FragmentationReport provides a mock function with given fields: ctx, podname
+func (_m *Cluster) FragmentationReport(ctx context.Context, podname string) ([]*types.NodeFragmentation, error) {
+	ret := _m.Called(ctx, podname)
+
+	var r0 []*types.NodeFragmentation
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*types.NodeFragmentation); ok {
+		r0 = rf(ctx, podname)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.NodeFragmentation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, podname)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetContainer provides a mock function with given fields: ctx, ID
 func (_m *Cluster) GetContainer(ctx context.Context, ID string) (*types.Container, error) {
 	ret := _m.Called(ctx, ID)
@@ -297,6 +594,29 @@ func (_m *Cluster) GetContainer(ctx context.Context, ID string) (*types.Containe
 	return r0, r1
 }
 
+// GetContainerMeta provides a mock function with given fields: ctx, ID, key
+func (_m *Cluster) GetContainerMeta(ctx context.Context, ID string, key string) ([]byte, error) {
+	ret := _m.Called(ctx, ID, key)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []byte); ok {
+		r0 = rf(ctx, ID, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ID, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetContainers provides a mock function with given fields: ctx, IDs
 func (_m *Cluster) GetContainers(ctx context.Context, IDs []string) ([]*types.Container, error) {
 	ret := _m.Called(ctx, IDs)
@@ -389,22 +709,22 @@ func (_m *Cluster) GetPod(ctx context.Context, podname string) (*types.Pod, erro
 	return r0, r1
 }
 
-// ListContainers provides a mock function with given fields: ctx, opts
-func (_m *Cluster) ListContainers(ctx context.Context, opts *types.ListContainersOptions) ([]*types.Container, error) {
-	ret := _m.Called(ctx, opts)
+// ListAuditLogs provides a mock function with given fields: ctx, filter
+func (_m *Cluster) ListAuditLogs(ctx context.Context, filter *types.AuditFilter) ([]*types.AuditLog, error) {
+	ret := _m.Called(ctx, filter)
 
-	var r0 []*types.Container
-	if rf, ok := ret.Get(0).(func(context.Context, *types.ListContainersOptions) []*types.Container); ok {
-		r0 = rf(ctx, opts)
+	var r0 []*types.AuditLog
+	if rf, ok := ret.Get(0).(func(context.Context, *types.AuditFilter) []*types.AuditLog); ok {
+		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*types.Container)
+			r0 = ret.Get(0).([]*types.AuditLog)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *types.ListContainersOptions) error); ok {
-		r1 = rf(ctx, opts)
+	if rf, ok := ret.Get(1).(func(context.Context, *types.AuditFilter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -412,12 +732,81 @@ func (_m *Cluster) ListContainers(ctx context.Context, opts *types.ListContainer
 	return r0, r1
 }
 
-// ListNetworks provides a mock function with given fields: ctx, podname, driver
-func (_m *Cluster) ListNetworks(ctx context.Context, podname string, driver string) ([]*enginetypes.Network, error) {
-	ret := _m.Called(ctx, podname, driver)
+// ListConfigObjects provides a mock function with given fields: ctx
+func (_m *Cluster) ListConfigObjects(ctx context.Context) ([]*types.ConfigObject, error) {
+	ret := _m.Called(ctx)
 
-	var r0 []*enginetypes.Network
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) []*enginetypes.Network); ok {
+	var r0 []*types.ConfigObject
+	if rf, ok := ret.Get(0).(func(context.Context) []*types.ConfigObject); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.ConfigObject)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListContainers provides a mock function with given fields: ctx, opts
+func (_m *Cluster) ListContainers(ctx context.Context, opts *types.ListContainersOptions) ([]*types.Container, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 []*types.Container
+	if rf, ok := ret.Get(0).(func(context.Context, *types.ListContainersOptions) []*types.Container); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Container)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.ListContainersOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListCrons provides a mock function with given fields: ctx
+func (_m *Cluster) ListCrons(ctx context.Context) ([]*types.Cron, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*types.Cron
+	if rf, ok := ret.Get(0).(func(context.Context) []*types.Cron); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Cron)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListNetworks provides a mock function with given fields: ctx, podname, driver
+func (_m *Cluster) ListNetworks(ctx context.Context, podname string, driver string) ([]*enginetypes.Network, error) {
+	ret := _m.Called(ctx, podname, driver)
+
+	var r0 []*enginetypes.Network
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []*enginetypes.Network); ok {
 		r0 = rf(ctx, podname, driver)
 	} else {
 		if ret.Get(0) != nil {
@@ -504,22 +893,22 @@ func (_m *Cluster) ListPods(ctx context.Context) ([]*types.Pod, error) {
 	return r0, r1
 }
 
-// LogStream provides a mock function with given fields: ctx, opts
-func (_m *Cluster) LogStream(ctx context.Context, opts *types.LogStreamOptions) (chan *types.LogStreamMessage, error) {
-	ret := _m.Called(ctx, opts)
+// ListQuotas provides a mock function with given fields: ctx
+func (_m *Cluster) ListQuotas(ctx context.Context) ([]*types.Quota, error) {
+	ret := _m.Called(ctx)
 
-	var r0 chan *types.LogStreamMessage
-	if rf, ok := ret.Get(0).(func(context.Context, *types.LogStreamOptions) chan *types.LogStreamMessage); ok {
-		r0 = rf(ctx, opts)
+	var r0 []*types.Quota
+	if rf, ok := ret.Get(0).(func(context.Context) []*types.Quota); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(chan *types.LogStreamMessage)
+			r0 = ret.Get(0).([]*types.Quota)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *types.LogStreamOptions) error); ok {
-		r1 = rf(ctx, opts)
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -527,22 +916,22 @@ func (_m *Cluster) LogStream(ctx context.Context, opts *types.LogStreamOptions)
 	return r0, r1
 }
 
-// NodeResource provides a mock function with given fields: ctx, nodename, fix
-func (_m *Cluster) NodeResource(ctx context.Context, nodename string, fix bool) (*types.NodeResource, error) {
-	ret := _m.Called(ctx, nodename, fix)
+// ListRegistryCredentials provides a mock function with given fields: ctx
+func (_m *Cluster) ListRegistryCredentials(ctx context.Context) ([]*types.RegistryCredential, error) {
+	ret := _m.Called(ctx)
 
-	var r0 *types.NodeResource
-	if rf, ok := ret.Get(0).(func(context.Context, string, bool) *types.NodeResource); ok {
-		r0 = rf(ctx, nodename, fix)
+	var r0 []*types.RegistryCredential
+	if rf, ok := ret.Get(0).(func(context.Context) []*types.RegistryCredential); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*types.NodeResource)
+			r0 = ret.Get(0).([]*types.RegistryCredential)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
-		r1 = rf(ctx, nodename, fix)
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -550,16 +939,16 @@ func (_m *Cluster) NodeResource(ctx context.Context, nodename string, fix bool)
 	return r0, r1
 }
 
-// PodResource provides a mock function with given fields: ctx, podname
-func (_m *Cluster) PodResource(ctx context.Context, podname string) (*types.PodResource, error) {
+// ListReservations provides a mock function with given fields: ctx, podname
+func (_m *Cluster) ListReservations(ctx context.Context, podname string) ([]*types.Reservation, error) {
 	ret := _m.Called(ctx, podname)
 
-	var r0 *types.PodResource
-	if rf, ok := ret.Get(0).(func(context.Context, string) *types.PodResource); ok {
+	var r0 []*types.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*types.Reservation); ok {
 		r0 = rf(ctx, podname)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*types.PodResource)
+			r0 = ret.Get(0).([]*types.Reservation)
 		}
 	}
 
@@ -573,22 +962,22 @@ func (_m *Cluster) PodResource(ctx context.Context, podname string) (*types.PodR
 	return r0, r1
 }
 
-// ReallocResource provides a mock function with given fields: ctx, opts
-func (_m *Cluster) ReallocResource(ctx context.Context, opts *types.ReallocOptions) (chan *types.ReallocResourceMessage, error) {
-	ret := _m.Called(ctx, opts)
+// ListScalePolicies provides a mock function with given fields: ctx
+func (_m *Cluster) ListScalePolicies(ctx context.Context) ([]*types.ScalePolicy, error) {
+	ret := _m.Called(ctx)
 
-	var r0 chan *types.ReallocResourceMessage
-	if rf, ok := ret.Get(0).(func(context.Context, *types.ReallocOptions) chan *types.ReallocResourceMessage); ok {
-		r0 = rf(ctx, opts)
+	var r0 []*types.ScalePolicy
+	if rf, ok := ret.Get(0).(func(context.Context) []*types.ScalePolicy); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(chan *types.ReallocResourceMessage)
+			r0 = ret.Get(0).([]*types.ScalePolicy)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *types.ReallocOptions) error); ok {
-		r1 = rf(ctx, opts)
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -596,22 +985,22 @@ func (_m *Cluster) ReallocResource(ctx context.Context, opts *types.ReallocOptio
 	return r0, r1
 }
 
-// RemoveContainer provides a mock function with given fields: ctx, IDs, force, step
-func (_m *Cluster) RemoveContainer(ctx context.Context, IDs []string, force bool, step int) (chan *types.RemoveContainerMessage, error) {
-	ret := _m.Called(ctx, IDs, force, step)
+// ListSecrets provides a mock function with given fields: ctx
+func (_m *Cluster) ListSecrets(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
 
-	var r0 chan *types.RemoveContainerMessage
-	if rf, ok := ret.Get(0).(func(context.Context, []string, bool, int) chan *types.RemoveContainerMessage); ok {
-		r0 = rf(ctx, IDs, force, step)
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(chan *types.RemoveContainerMessage)
+			r0 = ret.Get(0).([]string)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, []string, bool, int) error); ok {
-		r1 = rf(ctx, IDs, force, step)
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -619,22 +1008,22 @@ func (_m *Cluster) RemoveContainer(ctx context.Context, IDs []string, force bool
 	return r0, r1
 }
 
-// RemoveImage provides a mock function with given fields: ctx, podname, nodename, images, step, prune
-func (_m *Cluster) RemoveImage(ctx context.Context, podname string, nodename string, images []string, step int, prune bool) (chan *types.RemoveImageMessage, error) {
-	ret := _m.Called(ctx, podname, nodename, images, step, prune)
+// ListWebhooks provides a mock function with given fields: ctx
+func (_m *Cluster) ListWebhooks(ctx context.Context) ([]*types.EventWebhook, error) {
+	ret := _m.Called(ctx)
 
-	var r0 chan *types.RemoveImageMessage
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, int, bool) chan *types.RemoveImageMessage); ok {
-		r0 = rf(ctx, podname, nodename, images, step, prune)
+	var r0 []*types.EventWebhook
+	if rf, ok := ret.Get(0).(func(context.Context) []*types.EventWebhook); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(chan *types.RemoveImageMessage)
+			r0 = ret.Get(0).([]*types.EventWebhook)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, []string, int, bool) error); ok {
-		r1 = rf(ctx, podname, nodename, images, step, prune)
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -642,49 +1031,44 @@ func (_m *Cluster) RemoveImage(ctx context.Context, podname string, nodename str
 	return r0, r1
 }
 
-// RemoveNode provides a mock function with given fields: ctx, nodename
-func (_m *Cluster) RemoveNode(ctx context.Context, nodename string) error {
-	ret := _m.Called(ctx, nodename)
+// LogStream provides a mock function with given fields: ctx, opts
+func (_m *Cluster) LogStream(ctx context.Context, opts *types.LogStreamOptions) (chan *types.LogStreamMessage, error) {
+	ret := _m.Called(ctx, opts)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, nodename)
+	var r0 chan *types.LogStreamMessage
+	if rf, ok := ret.Get(0).(func(context.Context, *types.LogStreamOptions) chan *types.LogStreamMessage); ok {
+		r0 = rf(ctx, opts)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan *types.LogStreamMessage)
+		}
 	}
 
-	return r0
-}
-
-// RemovePod provides a mock function with given fields: ctx, podname
-func (_m *Cluster) RemovePod(ctx context.Context, podname string) error {
-	ret := _m.Called(ctx, podname)
-
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, podname)
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.LogStreamOptions) error); ok {
+		r1 = rf(ctx, opts)
 	} else {
-		r0 = ret.Error(0)
+		r1 = ret.Error(1)
 	}
 
-	return r0
+	return r0, r1
 }
 
-// ReplaceContainer provides a mock function with given fields: ctx, opts
-func (_m *Cluster) ReplaceContainer(ctx context.Context, opts *types.ReplaceOptions) (chan *types.ReplaceContainerMessage, error) {
+// MigrateContainer provides a mock function with given fields: ctx, opts
+func (_m *Cluster) MigrateContainer(ctx context.Context, opts *types.MigrateOptions) (chan *types.MigrateMessage, error) {
 	ret := _m.Called(ctx, opts)
 
-	var r0 chan *types.ReplaceContainerMessage
-	if rf, ok := ret.Get(0).(func(context.Context, *types.ReplaceOptions) chan *types.ReplaceContainerMessage); ok {
+	var r0 chan *types.MigrateMessage
+	if rf, ok := ret.Get(0).(func(context.Context, *types.MigrateOptions) chan *types.MigrateMessage); ok {
 		r0 = rf(ctx, opts)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(chan *types.ReplaceContainerMessage)
+			r0 = ret.Get(0).(chan *types.MigrateMessage)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *types.ReplaceOptions) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *types.MigrateOptions) error); ok {
 		r1 = rf(ctx, opts)
 	} else {
 		r1 = ret.Error(1)
@@ -693,22 +1077,22 @@ func (_m *Cluster) ReplaceContainer(ctx context.Context, opts *types.ReplaceOpti
 	return r0, r1
 }
 
-// RunAndWait provides a mock function with given fields: ctx, opts, inCh
-func (_m *Cluster) RunAndWait(ctx context.Context, opts *types.DeployOptions, inCh <-chan []byte) (<-chan *types.AttachContainerMessage, error) {
-	ret := _m.Called(ctx, opts, inCh)
+// MigrateContainerToNode provides a mock function with given fields: ctx, ID, nodename, timeout
+func (_m *Cluster) MigrateContainerToNode(ctx context.Context, ID string, nodename string, timeout time.Duration) (*types.MigrateMessage, error) {
+	ret := _m.Called(ctx, ID, nodename, timeout)
 
-	var r0 <-chan *types.AttachContainerMessage
-	if rf, ok := ret.Get(0).(func(context.Context, *types.DeployOptions, <-chan []byte) <-chan *types.AttachContainerMessage); ok {
-		r0 = rf(ctx, opts, inCh)
+	var r0 *types.MigrateMessage
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration) *types.MigrateMessage); ok {
+		r0 = rf(ctx, ID, nodename, timeout)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(<-chan *types.AttachContainerMessage)
+			r0 = ret.Get(0).(*types.MigrateMessage)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *types.DeployOptions, <-chan []byte) error); ok {
-		r1 = rf(ctx, opts, inCh)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Duration) error); ok {
+		r1 = rf(ctx, ID, nodename, timeout)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -716,22 +1100,36 @@ func (_m *Cluster) RunAndWait(ctx context.Context, opts *types.DeployOptions, in
 	return r0, r1
 }
 
-// Send provides a mock function with given fields: ctx, opts
-func (_m *Cluster) Send(ctx context.Context, opts *types.SendOptions) (chan *types.SendMessage, error) {
-	ret := _m.Called(ctx, opts)
+// NodeHeartbeat provides a mock function with given fields: ctx, nodename, ttl
+func (_m *Cluster) NodeHeartbeat(ctx context.Context, nodename string, ttl time.Duration) error {
+	ret := _m.Called(ctx, nodename, ttl)
 
-	var r0 chan *types.SendMessage
-	if rf, ok := ret.Get(0).(func(context.Context, *types.SendOptions) chan *types.SendMessage); ok {
-		r0 = rf(ctx, opts)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) error); ok {
+		r0 = rf(ctx, nodename, ttl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NodeResource provides a mock function with given fields: ctx, nodename, fix
+func (_m *Cluster) NodeResource(ctx context.Context, nodename string, fix bool) (*types.NodeResource, error) {
+	ret := _m.Called(ctx, nodename, fix)
+
+	var r0 *types.NodeResource
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) *types.NodeResource); ok {
+		r0 = rf(ctx, nodename, fix)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(chan *types.SendMessage)
+			r0 = ret.Get(0).(*types.NodeResource)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *types.SendOptions) error); ok {
-		r1 = rf(ctx, opts)
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, nodename, fix)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -739,22 +1137,22 @@ func (_m *Cluster) Send(ctx context.Context, opts *types.SendOptions) (chan *typ
 	return r0, r1
 }
 
-// SetContainersStatus provides a mock function with given fields: ctx, status, ttls
-func (_m *Cluster) SetContainersStatus(ctx context.Context, status []*types.StatusMeta, ttls map[string]int64) ([]*types.StatusMeta, error) {
-	ret := _m.Called(ctx, status, ttls)
+// PodResource provides a mock function with given fields: ctx, podname
+func (_m *Cluster) PodResource(ctx context.Context, podname string) (*types.PodResource, error) {
+	ret := _m.Called(ctx, podname)
 
-	var r0 []*types.StatusMeta
-	if rf, ok := ret.Get(0).(func(context.Context, []*types.StatusMeta, map[string]int64) []*types.StatusMeta); ok {
-		r0 = rf(ctx, status, ttls)
+	var r0 *types.PodResource
+	if rf, ok := ret.Get(0).(func(context.Context, string) *types.PodResource); ok {
+		r0 = rf(ctx, podname)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*types.StatusMeta)
+			r0 = ret.Get(0).(*types.PodResource)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, []*types.StatusMeta, map[string]int64) error); ok {
-		r1 = rf(ctx, status, ttls)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, podname)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -762,21 +1160,44 @@ func (_m *Cluster) SetContainersStatus(ctx context.Context, status []*types.Stat
 	return r0, r1
 }
 
-// SetNode provides a mock function with given fields: ctx, opts
-func (_m *Cluster) SetNode(ctx context.Context, opts *types.SetNodeOptions) (*types.Node, error) {
+// ReallocResource provides a mock function with given fields: ctx, opts
+func (_m *Cluster) ReallocResource(ctx context.Context, opts *types.ReallocOptions) (chan *types.ReallocResourceMessage, error) {
 	ret := _m.Called(ctx, opts)
 
-	var r0 *types.Node
-	if rf, ok := ret.Get(0).(func(context.Context, *types.SetNodeOptions) *types.Node); ok {
+	var r0 chan *types.ReallocResourceMessage
+	if rf, ok := ret.Get(0).(func(context.Context, *types.ReallocOptions) chan *types.ReallocResourceMessage); ok {
 		r0 = rf(ctx, opts)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*types.Node)
+			r0 = ret.Get(0).(chan *types.ReallocResourceMessage)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *types.SetNodeOptions) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *types.ReallocOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Rebalance provides a mock function with given fields: ctx, opts
+func (_m *Cluster) Rebalance(ctx context.Context, opts *types.RebalanceOptions) (chan *types.RebalanceMessage, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 chan *types.RebalanceMessage
+	if rf, ok := ret.Get(0).(func(context.Context, *types.RebalanceOptions) chan *types.RebalanceMessage); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan *types.RebalanceMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.RebalanceOptions) error); ok {
 		r1 = rf(ctx, opts)
 	} else {
 		r1 = ret.Error(1)
@@ -785,6 +1206,746 @@ func (_m *Cluster) SetNode(ctx context.Context, opts *types.SetNodeOptions) (*ty
 	return r0, r1
 }
 
+// RecordAuditLog provides a mock function with given fields: ctx, log
+func (_m *Cluster) RecordAuditLog(ctx context.Context, log *types.AuditLog) error {
+	ret := _m.Called(ctx, log)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.AuditLog) error); ok {
+		r0 = rf(ctx, log)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordContainerEvents provides a mock function with given fields: ctx, events
+func (_m *Cluster) RecordContainerEvents(ctx context.Context, events []*types.ContainerEvent) error {
+	ret := _m.Called(ctx, events)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*types.ContainerEvent) error); ok {
+		r0 = rf(ctx, events)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RegisterWebhook provides a mock function with given fields: ctx, webhook
+func (_m *Cluster) RegisterWebhook(ctx context.Context, webhook *types.EventWebhook) error {
+	ret := _m.Called(ctx, webhook)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.EventWebhook) error); ok {
+		r0 = rf(ctx, webhook)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReleaseReservation provides a mock function with given fields: ctx, id
+func (_m *Cluster) ReleaseReservation(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReloadConfig provides a mock function with given fields: ctx, name, appname
+func (_m *Cluster) ReloadConfig(ctx context.Context, name string, appname string) (chan *types.SendMessage, error) {
+	ret := _m.Called(ctx, name, appname)
+
+	var r0 chan *types.SendMessage
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) chan *types.SendMessage); ok {
+		r0 = rf(ctx, name, appname)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan *types.SendMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, name, appname)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveConfigObject provides a mock function with given fields: ctx, name
+func (_m *Cluster) RemoveConfigObject(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveContainer provides a mock function with given fields: ctx, IDs, force, step, stopTimeout
+func (_m *Cluster) RemoveContainer(ctx context.Context, IDs []string, force bool, step int, stopTimeout time.Duration) (chan *types.RemoveContainerMessage, error) {
+	ret := _m.Called(ctx, IDs, force, step, stopTimeout)
+
+	var r0 chan *types.RemoveContainerMessage
+	if rf, ok := ret.Get(0).(func(context.Context, []string, bool, int, time.Duration) chan *types.RemoveContainerMessage); ok {
+		r0 = rf(ctx, IDs, force, step, stopTimeout)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan *types.RemoveContainerMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string, bool, int, time.Duration) error); ok {
+		r1 = rf(ctx, IDs, force, step, stopTimeout)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveCron provides a mock function with given fields: ctx, name
+func (_m *Cluster) RemoveCron(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveImage provides a mock function with given fields: ctx, podname, nodename, images, step, prune
+func (_m *Cluster) RemoveImage(ctx context.Context, podname string, nodename string, images []string, step int, prune bool) (chan *types.RemoveImageMessage, error) {
+	ret := _m.Called(ctx, podname, nodename, images, step, prune)
+
+	var r0 chan *types.RemoveImageMessage
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []string, int, bool) chan *types.RemoveImageMessage); ok {
+		r0 = rf(ctx, podname, nodename, images, step, prune)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan *types.RemoveImageMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, []string, int, bool) error); ok {
+		r1 = rf(ctx, podname, nodename, images, step, prune)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveNode provides a mock function with given fields: ctx, nodename
+func (_m *Cluster) RemoveNode(ctx context.Context, nodename string) error {
+	ret := _m.Called(ctx, nodename)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, nodename)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemovePod provides a mock function with given fields: ctx, podname
+func (_m *Cluster) RemovePod(ctx context.Context, podname string) error {
+	ret := _m.Called(ctx, podname)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, podname)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveQuota provides a mock function with given fields: ctx, scope, name
+func (_m *Cluster) RemoveQuota(ctx context.Context, scope string, name string) error {
+	ret := _m.Called(ctx, scope, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, scope, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveRegistryCredential provides a mock function with given fields: ctx, registry, podname
+func (_m *Cluster) RemoveRegistryCredential(ctx context.Context, registry string, podname string) error {
+	ret := _m.Called(ctx, registry, podname)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, registry, podname)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveScalePolicy provides a mock function with given fields: ctx, appname, entrypoint
+func (_m *Cluster) RemoveScalePolicy(ctx context.Context, appname string, entrypoint string) error {
+	ret := _m.Called(ctx, appname, entrypoint)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, appname, entrypoint)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveSecret provides a mock function with given fields: ctx, name
+func (_m *Cluster) RemoveSecret(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplaceContainer provides a mock function with given fields: ctx, opts
+func (_m *Cluster) ReplaceContainer(ctx context.Context, opts *types.ReplaceOptions) (chan *types.ReplaceContainerMessage, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 chan *types.ReplaceContainerMessage
+	if rf, ok := ret.Get(0).(func(context.Context, *types.ReplaceOptions) chan *types.ReplaceContainerMessage); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan *types.ReplaceContainerMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.ReplaceOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reserve provides a mock function with given fields: ctx, opts
+func (_m *Cluster) Reserve(ctx context.Context, opts *types.ReserveOptions) (*types.Reservation, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 *types.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, *types.ReserveOptions) *types.Reservation); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.ReserveOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreContainer provides a mock function with given fields: ctx, opts
+func (_m *Cluster) RestoreContainer(ctx context.Context, opts *types.RestoreOptions) error {
+	ret := _m.Called(ctx, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.RestoreOptions) error); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RunAndWait provides a mock function with given fields: ctx, opts, inCh
+func (_m *Cluster) RunAndWait(ctx context.Context, opts *types.DeployOptions, inCh <-chan []byte) (<-chan *types.AttachContainerMessage, error) {
+	ret := _m.Called(ctx, opts, inCh)
+
+	var r0 <-chan *types.AttachContainerMessage
+	if rf, ok := ret.Get(0).(func(context.Context, *types.DeployOptions, <-chan []byte) <-chan *types.AttachContainerMessage); ok {
+		r0 = rf(ctx, opts, inCh)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan *types.AttachContainerMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.DeployOptions, <-chan []byte) error); ok {
+		r1 = rf(ctx, opts, inCh)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RunJob provides a mock function with given fields: ctx, opts
+func (_m *Cluster) RunJob(ctx context.Context, opts *types.JobOptions) (<-chan *types.JobMessage, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 <-chan *types.JobMessage
+	if rf, ok := ret.Get(0).(func(context.Context, *types.JobOptions) <-chan *types.JobMessage); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan *types.JobMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.JobOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Send provides a mock function with given fields: ctx, opts
+func (_m *Cluster) Send(ctx context.Context, opts *types.SendOptions) (chan *types.SendMessage, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 chan *types.SendMessage
+	if rf, ok := ret.Get(0).(func(context.Context, *types.SendOptions) chan *types.SendMessage); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan *types.SendMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.SendOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetConfigObject provides a mock function with given fields: ctx, object
+func (_m *Cluster) SetConfigObject(ctx context.Context, object *types.ConfigObject) error {
+	ret := _m.Called(ctx, object)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.ConfigObject) error); ok {
+		r0 = rf(ctx, object)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetContainer provides a mock function with given fields: ctx, opts
+func (_m *Cluster) SetContainer(ctx context.Context, opts *types.SetContainerOptions) (*types.Container, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 *types.Container
+	if rf, ok := ret.Get(0).(func(context.Context, *types.SetContainerOptions) *types.Container); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Container)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.SetContainerOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetContainerMeta provides a mock function with given fields: ctx, ID, key, value
+func (_m *Cluster) SetContainerMeta(ctx context.Context, ID string, key string, value []byte) error {
+	ret := _m.Called(ctx, ID, key, value)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []byte) error); ok {
+		r0 = rf(ctx, ID, key, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetContainersStatus provides a mock function with given fields: ctx, status, ttls
+func (_m *Cluster) SetContainersStatus(ctx context.Context, status []*types.StatusMeta, ttls map[string]int64) ([]*types.StatusMeta, error) {
+	ret := _m.Called(ctx, status, ttls)
+
+	var r0 []*types.StatusMeta
+	if rf, ok := ret.Get(0).(func(context.Context, []*types.StatusMeta, map[string]int64) []*types.StatusMeta); ok {
+		r0 = rf(ctx, status, ttls)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.StatusMeta)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []*types.StatusMeta, map[string]int64) error); ok {
+		r1 = rf(ctx, status, ttls)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetCron provides a mock function with given fields: ctx, cron
+func (_m *Cluster) SetCron(ctx context.Context, cron *types.Cron) error {
+	ret := _m.Called(ctx, cron)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Cron) error); ok {
+		r0 = rf(ctx, cron)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetNode provides a mock function with given fields: ctx, opts
+func (_m *Cluster) SetNode(ctx context.Context, opts *types.SetNodeOptions) (*types.Node, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 *types.Node
+	if rf, ok := ret.Get(0).(func(context.Context, *types.SetNodeOptions) *types.Node); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Node)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.SetNodeOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPodDeviceAllowlist provides a mock function with given fields: ctx, podname, allowlist
+func (_m *Cluster) SetPodDeviceAllowlist(ctx context.Context, podname string, allowlist []string) (*types.Pod, error) {
+	ret := _m.Called(ctx, podname, allowlist)
+
+	var r0 *types.Pod
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) *types.Pod); ok {
+		r0 = rf(ctx, podname, allowlist)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Pod)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(ctx, podname, allowlist)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPodHook provides a mock function with given fields: ctx, podname, hook
+func (_m *Cluster) SetPodHook(ctx context.Context, podname string, hook *types.Hook) (*types.Pod, error) {
+	ret := _m.Called(ctx, podname, hook)
+
+	var r0 *types.Pod
+	if rf, ok := ret.Get(0).(func(context.Context, string, *types.Hook) *types.Pod); ok {
+		r0 = rf(ctx, podname, hook)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Pod)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *types.Hook) error); ok {
+		r1 = rf(ctx, podname, hook)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPodLocale provides a mock function with given fields: ctx, podname, timezone, locale
+func (_m *Cluster) SetPodLocale(ctx context.Context, podname string, timezone string, locale string) (*types.Pod, error) {
+	ret := _m.Called(ctx, podname, timezone, locale)
+
+	var r0 *types.Pod
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *types.Pod); ok {
+		r0 = rf(ctx, podname, timezone, locale)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Pod)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, podname, timezone, locale)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPodPlacement provides a mock function with given fields: ctx, podname, placement
+func (_m *Cluster) SetPodPlacement(ctx context.Context, podname string, placement string) (*types.Pod, error) {
+	ret := _m.Called(ctx, podname, placement)
+
+	var r0 *types.Pod
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *types.Pod); ok {
+		r0 = rf(ctx, podname, placement)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Pod)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, podname, placement)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPodSysctlAllowlist provides a mock function with given fields: ctx, podname, allowlist
+func (_m *Cluster) SetPodSysctlAllowlist(ctx context.Context, podname string, allowlist []string) (*types.Pod, error) {
+	ret := _m.Called(ctx, podname, allowlist)
+
+	var r0 *types.Pod
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) *types.Pod); ok {
+		r0 = rf(ctx, podname, allowlist)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Pod)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(ctx, podname, allowlist)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetQuota provides a mock function with given fields: ctx, quota
+func (_m *Cluster) SetQuota(ctx context.Context, quota *types.Quota) error {
+	ret := _m.Called(ctx, quota)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Quota) error); ok {
+		r0 = rf(ctx, quota)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetRegistryCredential provides a mock function with given fields: ctx, opts
+func (_m *Cluster) SetRegistryCredential(ctx context.Context, opts *types.RegistryCredentialOptions) error {
+	ret := _m.Called(ctx, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.RegistryCredentialOptions) error); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetScalePolicy provides a mock function with given fields: ctx, policy
+func (_m *Cluster) SetScalePolicy(ctx context.Context, policy *types.ScalePolicy) error {
+	ret := _m.Called(ctx, policy)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.ScalePolicy) error); ok {
+		r0 = rf(ctx, policy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetSecret provides a mock function with given fields: ctx, name, value
+func (_m *Cluster) SetSecret(ctx context.Context, name string, value []byte) error {
+	ret := _m.Called(ctx, name, value)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) error); ok {
+		r0 = rf(ctx, name, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ValidateHook provides a mock function with given fields: ctx, opts
+func (_m *Cluster) ValidateHook(ctx context.Context, opts *types.ValidateHookOptions) ([]*types.HookResult, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 []*types.HookResult
+	if rf, ok := ret.Get(0).(func(context.Context, *types.ValidateHookOptions) []*types.HookResult); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.HookResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.ValidateHookOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WaitContainer provides a mock function with given fields: ctx, opts
+func (_m *Cluster) WaitContainer(ctx context.Context, opts *types.WaitOptions) (chan *types.WaitContainerMessage, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 chan *types.WaitContainerMessage
+	if rf, ok := ret.Get(0).(func(context.Context, *types.WaitOptions) chan *types.WaitContainerMessage); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan *types.WaitContainerMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.WaitOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WatchCronRuns provides a mock function with given fields: ctx, name
+func (_m *Cluster) WatchCronRuns(ctx context.Context, name string) (<-chan *types.CronRun, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 <-chan *types.CronRun
+	if rf, ok := ret.Get(0).(func(context.Context, string) <-chan *types.CronRun); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan *types.CronRun)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WatchEvents provides a mock function with given fields: ctx, filter
+func (_m *Cluster) WatchEvents(ctx context.Context, filter *types.EventFilter) (<-chan *types.Event, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 <-chan *types.Event
+	if rf, ok := ret.Get(0).(func(context.Context, *types.EventFilter) <-chan *types.Event); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan *types.Event)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.EventFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // WatchServiceStatus provides a mock function with given fields: _a0
 func (_m *Cluster) WatchServiceStatus(_a0 context.Context) (<-chan types.ServiceStatus, error) {
 	ret := _m.Called(_a0)
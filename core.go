@@ -17,6 +17,7 @@ import (
 	pb "github.com/projecteru2/core/rpc/gen"
 	"github.com/projecteru2/core/utils"
 	"github.com/projecteru2/core/versioninfo"
+	"github.com/projecteru2/core/webhook"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 	cli "github.com/urfave/cli/v2"
@@ -78,13 +79,21 @@ func serve() {
 		grpc.MaxRecvMsgSize(config.GRPCConfig.MaxRecvMsgSize),
 	}
 
+	streamInterceptors := []grpc.StreamServerInterceptor{vibranium.StreamCounter}
+	unaryInterceptors := []grpc.UnaryServerInterceptor{}
 	if config.Auth.Username != "" {
 		log.Info("[main] Cluster auth enable.")
 		auth := auth.NewAuth(config.Auth)
-		opts = append(opts, grpc.StreamInterceptor(auth.StreamInterceptor))
-		opts = append(opts, grpc.UnaryInterceptor(auth.UnaryInterceptor))
+		streamInterceptors = append(streamInterceptors, auth.StreamInterceptor)
+		unaryInterceptors = append(unaryInterceptors, auth.UnaryInterceptor)
 		log.Infof("[main] Username %s Password %s", config.Auth.Username, config.Auth.Password)
 	}
+	// mutating calls are audited whether auth is enabled or not, with an empty user if it is not,
+	// audit goes after auth so rejected calls are not recorded
+	streamInterceptors = append(streamInterceptors, vibranium.AuditStreamInterceptor)
+	unaryInterceptors = append(unaryInterceptors, vibranium.AuditUnaryInterceptor)
+
+	opts = append(opts, grpc.ChainStreamInterceptor(streamInterceptors...), grpc.ChainUnaryInterceptor(unaryInterceptors...))
 
 	grpcServer := grpc.NewServer(opts...)
 	pb.RegisterCoreRPCServer(grpcServer, vibranium)
@@ -94,14 +103,38 @@ func serve() {
 		}
 	}()
 	if config.Profile != "" {
-		http.Handle("/metrics", metrics.Client.ResourceMiddleware(cluster)(promhttp.Handler()))
+		http.Handle("/metrics", metrics.Client.ResourceMiddleware(cluster)(metrics.Client.DebugMiddleware(vibranium.Debug)(promhttp.Handler())))
+		middleware, err := auth.NewHTTPMiddleware(config.ProfileAuth)
+		if err != nil {
+			log.Fatalf("[main] %v", err)
+		}
+		tlsConfig, err := auth.NewHTTPTLSConfig(config.ProfileAuth)
+		if err != nil {
+			log.Fatalf("[main] %v", err)
+		}
+		server := &http.Server{Addr: config.Profile, Handler: middleware(http.DefaultServeMux), TLSConfig: tlsConfig}
 		go func() {
-			if err := http.ListenAndServe(config.Profile, nil); err != nil {
+			var err error
+			if tlsConfig != nil {
+				err = server.ListenAndServeTLS("", "")
+			} else {
+				err = server.ListenAndServe()
+			}
+			if err != nil {
 				log.Errorf("[main] start http failed %v", err)
 			}
 		}()
 	}
 
+	if config.Webhook.Bind != "" {
+		server := &http.Server{Addr: config.Webhook.Bind, Handler: webhook.New(cluster, config.Webhook)}
+		go func() {
+			if err := server.ListenAndServe(); err != nil {
+				log.Errorf("[main] start webhook failed %v", err)
+			}
+		}()
+	}
+
 	unregisterService, err := cluster.RegisterService(context.Background())
 	if err != nil {
 		log.Errorf("[main] failed to register service: %v", err)
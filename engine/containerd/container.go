@@ -0,0 +1,737 @@
+package containerd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/mount"
+	"github.com/containerd/containerd/oci"
+	"github.com/containerd/containerd/runtime/restart"
+	"github.com/containerd/continuity/fs"
+	"github.com/containerd/typeurl"
+	"github.com/docker/go-units"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	coretypes "github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	minMemory          = units.MiB * 4
+	root               = "root"
+	hostNetwork        = "host"
+	noneNetwork        = "none"
+	noneLog            = "none"
+	defaultStopTimeout = 10 * time.Second
+)
+
+type rawArgs struct {
+	PidMode string   `json:"pid_mod"`
+	CapAdd  []string `json:"cap_add"`
+	CapDrop []string `json:"cap_drop"`
+}
+
+// VirtualizationCreate create a container, its task is created when started
+func (e *Engine) VirtualizationCreate(ctx context.Context, opts *enginetypes.VirtualizationCreateOptions) (*enginetypes.VirtualizationCreated, error) {
+	ctx = e.withNamespace(ctx)
+	r := &enginetypes.VirtualizationCreated{}
+	// memory should more than 4MiB
+	if opts.Memory > 0 && opts.Memory < minMemory || opts.Memory < 0 {
+		return r, coretypes.ErrBadMemory
+	}
+	restartPolicy, err := makeRestartPolicy(opts.RestartPolicy)
+	if err != nil {
+		return r, err
+	}
+	// stdio of tasks goes to log file, nothing to attach to
+	if opts.Stdin {
+		return r, coretypes.NewDetailedErr(coretypes.ErrEngineNotImplemented, "stdin")
+	}
+	// runc started by containerd runs no oci hooks of nvidia
+	if len(opts.GPU) > 0 {
+		return r, coretypes.NewDetailedErr(coretypes.ErrEngineNotImplemented, "gpu")
+	}
+	rArgs := &rawArgs{}
+	if len(opts.RawArgs) > 0 {
+		if err := json.Unmarshal(opts.RawArgs, rArgs); err != nil {
+			return r, err
+		}
+	}
+	// networks win over network mode, the same as docker engine
+	networkMode := opts.Network
+	for name := range opts.Networks {
+		networkMode = name
+	}
+	if networkMode == "" {
+		networkMode = e.config.NetworkMode
+	}
+	if networkMode != hostNetwork && networkMode != noneNetwork {
+		return r, coretypes.NewDetailedErr(coretypes.ErrEngineNotImplemented, fmt.Sprintf("network %s", networkMode))
+	}
+	if len(opts.DNS) > 0 || len(opts.Hosts) > 0 || len(opts.Publish) > 0 {
+		log.Warnf("[VirtualizationCreate] containerd engine not support dns, hosts and publish, %s will use those of host", opts.Name)
+	}
+	if opts.Storage > 0 {
+		log.Warnf("[VirtualizationCreate] containerd engine not support storage limit, %s will use rootfs without limit", opts.Name)
+	}
+	resources := makeResources(opts.Quota, opts.CPUShares, opts.Memory, opts.CPU, opts.NUMANode, opts.SoftLimit)
+	if resources.HugepageLimits, err = makeHugepageLimits(opts.Hugepages); err != nil {
+		return r, err
+	}
+	image, err := e.getImage(ctx, opts.Image)
+	if err != nil {
+		return r, err
+	}
+
+	ID := strings.ToLower(utils.RandomString(64))
+	specOpts := []oci.SpecOpts{oci.WithImageConfig(image)}
+	if len(opts.Cmd) > 0 {
+		specOpts = []oci.SpecOpts{oci.WithImageConfigArgs(image, opts.Cmd)}
+	}
+	specOpts = append(specOpts,
+		oci.WithEnv(opts.Env),
+		oci.WithMounts(makeMounts(opts.Volumes, makeEnv(opts.Env))),
+		withRlimits(makeRlimits(opts.Ulimits)),
+		withResources(resources),
+	)
+	if opts.WorkingDir != "" {
+		specOpts = append(specOpts, oci.WithProcessCwd(opts.WorkingDir))
+	}
+	user := opts.User
+	if opts.Privileged {
+		user = root
+		specOpts = append(specOpts, oci.WithPrivileged, oci.WithAllDevicesAllowed, oci.WithHostDevices)
+	}
+	// user in image config is resolved by rootfs of snapshot as well
+	if user != "" {
+		specOpts = append(specOpts, oci.WithUser(user))
+	}
+	if len(rArgs.CapAdd) > 0 {
+		specOpts = append(specOpts, oci.WithAddedCapabilities(makeCapabilities(rArgs.CapAdd)))
+	}
+	if len(rArgs.CapDrop) > 0 {
+		specOpts = append(specOpts, oci.WithDroppedCapabilities(makeCapabilities(rArgs.CapDrop)))
+	}
+	for _, device := range opts.Devices {
+		specOpts = append(specOpts, withDevice(device))
+	}
+	if rArgs.PidMode == hostNetwork {
+		specOpts = append(specOpts, oci.WithHostNamespace(specs.PIDNamespace))
+	}
+	if networkMode == hostNetwork {
+		// dns, hosts and sysctls of net are of host, the same as docker engine
+		specOpts = append(specOpts, oci.WithHostNamespace(specs.NetworkNamespace), oci.WithHostHostsFile, oci.WithHostResolvconf)
+	} else {
+		specOpts = append(specOpts, oci.WithHostname(utils.ShortID(ID)), withSysctl(opts.Sysctl))
+	}
+
+	labels := map[string]string{nameLabel: opts.Name, userLabel: opts.User}
+	for k, v := range opts.Labels {
+		labels[k] = v
+	}
+	if restartPolicy != "" {
+		labels[restartLabel] = restartPolicy
+	}
+	containerOpts := []containerd.NewContainerOpts{
+		containerd.WithContainerLabels(labels),
+		containerd.WithImage(image),
+		containerd.WithSnapshotter(e.config.Snapshotter),
+		containerd.WithNewSnapshot(ID, image),
+		containerd.WithRuntime(e.config.Runtime, nil),
+		containerd.WithNewSpec(specOpts...),
+	}
+	// lambda output is read from logs
+	if opts.LogType != noneLog || opts.Lambda {
+		logPath := filepath.Join(e.config.LogDir, e.config.Namespace, ID+".log")
+		if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+			return r, err
+		}
+		// tasks restarted by restart monitor of containerd write to the same file
+		containerOpts = append(containerOpts, restart.WithFileLogURI(logPath))
+	}
+	container, err := e.client.NewContainer(ctx, ID, containerOpts...)
+	if err != nil {
+		return r, err
+	}
+	r.Name = opts.Name
+	r.ID = container.ID()
+	return r, nil
+}
+
+// VirtualizationCopyTo copy things to virtualization
+func (e *Engine) VirtualizationCopyTo(ctx context.Context, ID, target string, content io.Reader, AllowOverwriteDirWithFile, CopyUIDGID bool) error {
+	ctx = e.withNamespace(ctx)
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	container, err := e.client.LoadContainer(ctx, ID)
+	if err != nil {
+		return err
+	}
+	spec, err := container.Spec(ctx)
+	if err != nil {
+		return err
+	}
+	return e.withRootfs(ctx, container, func(root string) error {
+		path, err := fs.RootPath(root, target)
+		if err != nil {
+			return err
+		}
+		if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+			if !AllowOverwriteDirWithFile {
+				return errors.Errorf("can't overwrite directory %s with file", target)
+			}
+			if err := os.RemoveAll(path); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, data, 0755); err != nil {
+			return err
+		}
+		if !CopyUIDGID {
+			return nil
+		}
+		return os.Chown(path, int(spec.Process.User.UID), int(spec.Process.User.GID))
+	})
+}
+
+// VirtualizationStart start virtualization, a stopped task is replaced by a new one
+func (e *Engine) VirtualizationStart(ctx context.Context, ID string) error {
+	ctx = e.withNamespace(ctx)
+	container, err := e.client.LoadContainer(ctx, ID)
+	if err != nil {
+		return err
+	}
+	labels, err := container.Labels(ctx)
+	if err != nil {
+		return err
+	}
+	task, err := container.Task(ctx, nil)
+	switch {
+	case err == nil:
+		status, err := task.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if status.Status == containerd.Running {
+			return nil
+		}
+		if _, err := task.Delete(ctx); err != nil {
+			return err
+		}
+	case !errdefs.IsNotFound(err):
+		return err
+	}
+
+	creator := cio.NullIO
+	if uri, ok := labels[restart.LogURILabel]; ok {
+		u, err := url.Parse(uri)
+		if err != nil {
+			return err
+		}
+		creator = cio.LogURI(u)
+	}
+	if task, err = container.NewTask(ctx, creator); err != nil {
+		return err
+	}
+	if err := task.Start(ctx); err != nil {
+		if _, derr := task.Delete(ctx); derr != nil {
+			log.Errorf("[VirtualizationStart] Delete task of %s failed %v", ID, derr)
+		}
+		return err
+	}
+	if labels[restartLabel] == "" {
+		return nil
+	}
+	// restart monitor of containerd keeps task running from now on
+	return container.Update(ctx, restart.WithStatus(containerd.Running))
+}
+
+// VirtualizationStop stop virtualization, killed after gracefulTimeout, zero means 10s
+func (e *Engine) VirtualizationStop(ctx context.Context, ID string, gracefulTimeout time.Duration) error {
+	ctx = e.withNamespace(ctx)
+	container, err := e.client.LoadContainer(ctx, ID)
+	if err != nil {
+		return err
+	}
+	if err := e.stopRestarting(ctx, container); err != nil {
+		return err
+	}
+	task, err := container.Task(ctx, nil)
+	if errdefs.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if gracefulTimeout <= 0 {
+		gracefulTimeout = defaultStopTimeout
+	}
+	status, err := task.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Status != containerd.Stopped {
+		exitCh, err := task.Wait(ctx)
+		if err != nil {
+			return err
+		}
+		if err := task.Kill(ctx, syscall.SIGTERM); err != nil && !errdefs.IsNotFound(err) {
+			return err
+		}
+		select {
+		case <-exitCh:
+		case <-time.After(gracefulTimeout):
+			if err := task.Kill(ctx, syscall.SIGKILL, containerd.WithKillAll); err != nil && !errdefs.IsNotFound(err) {
+				return err
+			}
+			select {
+			case <-exitCh:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	_, err = task.Delete(ctx)
+	return err
+}
+
+// VirtualizationRemove remove virtualization, volumes are binds only and kept
+func (e *Engine) VirtualizationRemove(ctx context.Context, ID string, removeVolumes, force bool) error {
+	ctx = e.withNamespace(ctx)
+	container, err := e.client.LoadContainer(ctx, ID)
+	if err != nil {
+		return err
+	}
+	labels, err := container.Labels(ctx)
+	if err != nil {
+		return err
+	}
+	task, err := container.Task(ctx, nil)
+	switch {
+	case err == nil:
+		status, err := task.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if status.Status == containerd.Running && !force {
+			return errors.Errorf("container %s is running, stop it or remove it by force", ID)
+		}
+		if err := e.stopRestarting(ctx, container); err != nil {
+			return err
+		}
+		if _, err := task.Delete(ctx, containerd.WithProcessKill); err != nil && !errdefs.IsNotFound(err) {
+			return err
+		}
+	case !errdefs.IsNotFound(err):
+		return err
+	}
+	if err := container.Delete(ctx, containerd.WithSnapshotCleanup); err != nil {
+		return err
+	}
+	if path := logPath(labels); path != "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Warnf("[VirtualizationRemove] Remove log of %s failed %v", ID, err)
+		}
+	}
+	return nil
+}
+
+// VirtualizationInspect get virtualization info
+func (e *Engine) VirtualizationInspect(ctx context.Context, ID string) (*enginetypes.VirtualizationInfo, error) {
+	if e.client == nil {
+		return nil, coretypes.ErrNilEngine
+	}
+	ctx = e.withNamespace(ctx)
+	container, err := e.client.LoadContainer(ctx, ID)
+	if errdefs.IsNotFound(err) {
+		return nil, coretypes.NewDetailedErr(coretypes.ErrContainerNotExists, ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e.inspect(ctx, container)
+}
+
+// VirtualizationLogs show virtualization logs, stdout and stderr are in the same file
+func (e *Engine) VirtualizationLogs(ctx context.Context, opts *enginetypes.VirtualizationLogStreamOptions) (io.ReadCloser, error) {
+	if opts.Since != "" || opts.Until != "" {
+		return nil, coretypes.NewDetailedErr(coretypes.ErrEngineNotImplemented, "since and until of logs without timestamps")
+	}
+	ctx = e.withNamespace(ctx)
+	container, err := e.client.LoadContainer(ctx, opts.ID)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := container.Labels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	path := logPath(labels)
+	if path == "" {
+		return nil, coretypes.NewDetailedErr(coretypes.ErrEngineNotImplemented, "logs of container without log file")
+	}
+	tail := -1
+	if opts.Tail != "" && opts.Tail != "all" {
+		if tail, err = strconv.Atoi(opts.Tail); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		// never started
+		return ioutil.NopCloser(bytes.NewReader(nil)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	offset, err := tailOffset(f, tail)
+	if err == nil {
+		_, err = f.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !opts.Follow {
+		return f, nil
+	}
+	return &followReader{ctx: ctx, file: f, running: func() bool {
+		running, err := e.running(ctx, container)
+		return err == nil && running
+	}}, nil
+}
+
+// VirtualizationAttach not implemented, stdio of tasks goes to log file
+func (e *Engine) VirtualizationAttach(ctx context.Context, ID string, stream, stdin bool) (io.ReadCloser, io.WriteCloser, error) {
+	return nil, nil, coretypes.ErrEngineNotImplemented
+}
+
+// VirtualizationResize not implemented, tasks have no tty
+func (e *Engine) VirtualizationResize(ctx context.Context, ID string, height, width uint) error {
+	return coretypes.ErrEngineNotImplemented
+}
+
+// VirtualizationWait wait virtualization exit, returns at once if it has no task
+func (e *Engine) VirtualizationWait(ctx context.Context, ID, state string) (*enginetypes.VirtualizationWaitResult, error) {
+	ctx = e.withNamespace(ctx)
+	r := &enginetypes.VirtualizationWaitResult{}
+	fail := func(err error) (*enginetypes.VirtualizationWaitResult, error) {
+		r.Message = err.Error()
+		r.Code = -1
+		return r, err
+	}
+	container, err := e.client.LoadContainer(ctx, ID)
+	if err != nil {
+		return fail(err)
+	}
+	task, err := container.Task(ctx, nil)
+	if errdefs.IsNotFound(err) {
+		return r, nil
+	}
+	if err != nil {
+		return fail(err)
+	}
+	exitCh, err := task.Wait(ctx)
+	if err != nil {
+		return fail(err)
+	}
+	select {
+	case status := <-exitCh:
+		code, _, err := status.Result()
+		if err != nil {
+			return fail(err)
+		}
+		r.Code = int64(code)
+		return r, nil
+	case <-ctx.Done():
+		return fail(ctx.Err())
+	}
+}
+
+// VirtualizationUpdateResource update virtualization resource, of both the running task and spec of next start
+func (e *Engine) VirtualizationUpdateResource(ctx context.Context, ID string, opts *enginetypes.VirtualizationResource) error {
+	if opts.Memory > 0 && opts.Memory < minMemory || opts.Memory < 0 {
+		return coretypes.ErrBadMemory
+	}
+	if opts.VolumeChanged {
+		log.Errorf("[VirtualizationUpdateResource] containerd engine not support rebinding volume resource: %v", opts.Volumes)
+		return coretypes.ErrNotSupport
+	}
+	quota := opts.Quota
+	numaNode := opts.NUMANode
+	// unlimited cpu
+	if quota == 0 {
+		quota = -1
+		numaNode = ""
+	}
+	resources := makeResources(quota, opts.CPUShares, opts.Memory, opts.CPU, numaNode, opts.SoftLimit)
+	// unlimited memory
+	if opts.Memory == 0 {
+		unlimited := int64(-1)
+		resources.Memory = &specs.LinuxMemory{Limit: &unlimited, Swap: &unlimited}
+	}
+	hugepageLimits, err := makeHugepageLimits(opts.Hugepages)
+	if err != nil {
+		return err
+	}
+	resources.HugepageLimits = hugepageLimits
+
+	ctx = e.withNamespace(ctx)
+	container, err := e.client.LoadContainer(ctx, ID)
+	if err != nil {
+		return err
+	}
+	if err := container.Update(ctx, withSpecResources(resources)); err != nil {
+		return err
+	}
+	task, err := container.Task(ctx, nil)
+	if errdefs.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return task.Update(ctx, containerd.WithResources(resources))
+}
+
+// VirtualizationList list virtualizations with all labels matched, stopped ones included
+func (e *Engine) VirtualizationList(ctx context.Context, labels map[string]string) ([]*enginetypes.VirtualizationInfo, error) {
+	ctx = e.withNamespace(ctx)
+	filters := []string{}
+	if len(labels) > 0 {
+		conditions := []string{}
+		for k, v := range labels {
+			conditions = append(conditions, fmt.Sprintf("labels.%q==%q", k, v))
+		}
+		// conditions of one filter are all matched
+		filters = append(filters, strings.Join(conditions, ","))
+	}
+	containers, err := e.client.Containers(ctx, filters...)
+	if err != nil {
+		return nil, err
+	}
+	r := []*enginetypes.VirtualizationInfo{}
+	for _, container := range containers {
+		info, err := e.inspect(ctx, container)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, info)
+	}
+	return r, nil
+}
+
+// VirtualizationStats not implemented, metrics of tasks are protobuf of cgroups which core doesn't vendor
+func (e *Engine) VirtualizationStats(ctx context.Context, ID string) (*enginetypes.VirtualizationStats, error) {
+	return nil, coretypes.ErrEngineNotImplemented
+}
+
+// VirtualizationCheckpoint not implemented
+func (e *Engine) VirtualizationCheckpoint(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) error {
+	return coretypes.ErrEngineNotImplemented
+}
+
+// VirtualizationRestore not implemented
+func (e *Engine) VirtualizationRestore(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) error {
+	return coretypes.ErrEngineNotImplemented
+}
+
+// VirtualizationCopyFrom copy a file from a virtualization
+func (e *Engine) VirtualizationCopyFrom(ctx context.Context, ID, path string) (io.ReadCloser, string, error) {
+	ctx = e.withNamespace(ctx)
+	container, err := e.client.LoadContainer(ctx, ID)
+	if err != nil {
+		return nil, "", err
+	}
+	var data []byte
+	if err := e.withRootfs(ctx, container, func(root string) error {
+		p, err := fs.RootPath(root, path)
+		if err != nil {
+			return err
+		}
+		data, err = ioutil.ReadFile(p)
+		return err
+	}); err != nil {
+		return nil, "", err
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), filepath.Base(path), nil
+}
+
+// inspect makes info of container from its record and spec
+func (e *Engine) inspect(ctx context.Context, container containerd.Container) (*enginetypes.VirtualizationInfo, error) {
+	info, err := container.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := container.Spec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r := &enginetypes.VirtualizationInfo{
+		ID:       info.ID,
+		Name:     info.Labels[nameLabel],
+		User:     info.Labels[userLabel],
+		Image:    info.Image,
+		Labels:   map[string]string{},
+		Networks: map[string]string{},
+		Created:  info.CreatedAt.Unix(),
+	}
+	for k, v := range info.Labels {
+		if !internalLabel(k) {
+			r.Labels[k] = v
+		}
+	}
+	if spec.Process != nil {
+		r.Cmd = spec.Process.Args
+		r.Dir = spec.Process.Cwd
+		r.Env = spec.Process.Env
+	}
+	if spec.Linux != nil {
+		if resources := spec.Linux.Resources; resources != nil {
+			if cpu := resources.CPU; cpu != nil && cpu.Quota != nil && *cpu.Quota > 0 && cpu.Period != nil && *cpu.Period > 0 {
+				r.Quota = float64(*cpu.Quota) / float64(*cpu.Period)
+			}
+			if memory := resources.Memory; memory != nil {
+				switch {
+				case memory.Limit != nil && *memory.Limit > 0:
+					r.Memory = *memory.Limit
+				case memory.Reservation != nil && *memory.Reservation > 0:
+					r.Memory = *memory.Reservation
+				}
+			}
+		}
+		hostNetworkNS := true
+		for _, ns := range spec.Linux.Namespaces {
+			hostNetworkNS = hostNetworkNS && ns.Type != specs.NetworkNamespace
+		}
+		if hostNetworkNS {
+			r.Networks[hostNetwork] = ""
+		}
+	}
+	if r.Running, err = e.running(ctx, container); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// running tells if task of container is running
+func (e *Engine) running(ctx context.Context, container containerd.Container) (bool, error) {
+	task, err := container.Task(ctx, nil)
+	if errdefs.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	status, err := task.Status(ctx)
+	if err != nil {
+		return false, err
+	}
+	return status.Status == containerd.Running, nil
+}
+
+// stopRestarting tells restart monitor of containerd not to start task again
+func (e *Engine) stopRestarting(ctx context.Context, container containerd.Container) error {
+	labels, err := container.Labels(ctx)
+	if err != nil || labels[restartLabel] == "" {
+		return err
+	}
+	return container.Update(ctx, restart.WithStatus(containerd.Stopped))
+}
+
+// withRootfs calls f with rootfs of running task, or with rootfs of snapshot mounted temporarily
+func (e *Engine) withRootfs(ctx context.Context, container containerd.Container, f func(root string) error) error {
+	task, err := container.Task(ctx, nil)
+	switch {
+	case err == nil:
+		status, err := task.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if status.Status == containerd.Running {
+			return f(fmt.Sprintf("/proc/%d/root", task.Pid()))
+		}
+	case !errdefs.IsNotFound(err):
+		return err
+	}
+	info, err := container.Info(ctx)
+	if err != nil {
+		return err
+	}
+	mounts, err := e.client.SnapshotService(info.Snapshotter).Mounts(ctx, info.SnapshotKey)
+	if err != nil {
+		return err
+	}
+	return mount.WithTempMount(ctx, mounts, f)
+}
+
+// withSpecResources replaces resources in spec of container record
+func withSpecResources(resources *specs.LinuxResources) containerd.UpdateContainerOpts {
+	return func(ctx context.Context, client *containerd.Client, c *containers.Container) error {
+		v, err := typeurl.UnmarshalAny(c.Spec)
+		if err != nil {
+			return err
+		}
+		spec, ok := v.(*oci.Spec)
+		if !ok {
+			return errors.Errorf("unknown spec %T of container %s", v, c.ID)
+		}
+		if err := withResources(resources)(ctx, client, c, spec); err != nil {
+			return err
+		}
+		c.Spec, err = typeurl.MarshalAny(spec)
+		return err
+	}
+}
+
+// makeRestartPolicy returns policy kept by restart monitor of containerd, empty if never restarted
+func makeRestartPolicy(policy enginetypes.RestartPolicy) (string, error) {
+	if policy.Backoff > 0 {
+		return "", coretypes.NewDetailedErr(coretypes.ErrInvalidRestartPolicy, "containerd engine doesn't support backoff")
+	}
+	if policy.Window > 0 {
+		return "", coretypes.NewDetailedErr(coretypes.ErrInvalidRestartPolicy, "containerd engine doesn't support window")
+	}
+	switch policy.Name {
+	case "", coretypes.RestartNo:
+		return "", nil
+	case coretypes.RestartAlways, coretypes.RestartUnlessStopped:
+		return policy.Name, nil
+	default:
+		// restart monitor restarts whatever the exit code is
+		return "", coretypes.NewDetailedErr(coretypes.ErrInvalidRestartPolicy, fmt.Sprintf("containerd engine doesn't support %s", policy.Name))
+	}
+}
+
+// logPath returns path of log file of container, empty if it has none
+func logPath(labels map[string]string) string {
+	u, err := url.Parse(labels[restart.LogURILabel])
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return u.Path
+}
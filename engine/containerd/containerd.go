@@ -0,0 +1,123 @@
+package containerd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/projecteru2/core/engine"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	coretypes "github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// PrefixKey indicate containerd, followed by path of its socket,
+	// e.g. containerd:///run/containerd/containerd.sock
+	// containerd only listens on unix socket, so core runs on the node,
+	// log files and fifos of tasks are opened by core on the same host
+	PrefixKey = "containerd://"
+
+	defaultNamespace   = "eru"
+	defaultSnapshotter = "overlayfs"
+	defaultRuntime     = "io.containerd.runc.v2"
+	defaultLogDir      = "/var/log/eru/containerd"
+	procMeminfo        = "/proc/meminfo"
+)
+
+// Engine is engine for containerd, containers run as tasks of runc shims without dockerd
+type Engine struct {
+	client *containerd.Client
+	config coretypes.ContainerdConfig
+	docker coretypes.DockerConfig // registry credentials and hub of refs
+
+	sync.Mutex
+	execs map[string]*execution
+}
+
+// MakeClient make containerd client
+func MakeClient(ctx context.Context, config coretypes.Config, nodename, endpoint, ca, cert, key string) (engine.API, error) {
+	address := strings.TrimPrefix(endpoint, PrefixKey)
+	if !strings.HasPrefix(address, "/") {
+		return nil, coretypes.NewDetailedErr(coretypes.ErrNodeFormat, fmt.Sprintf("endpoint invalid %v", endpoint))
+	}
+	c := config.Containerd
+	if c.Namespace == "" {
+		c.Namespace = defaultNamespace
+	}
+	if c.Snapshotter == "" {
+		c.Snapshotter = defaultSnapshotter
+	}
+	if c.Runtime == "" {
+		c.Runtime = defaultRuntime
+	}
+	if c.LogDir == "" {
+		c.LogDir = defaultLogDir
+	}
+	cli, err := containerd.New(address, containerd.WithDefaultNamespace(c.Namespace), containerd.WithDefaultRuntime(c.Runtime))
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("[MakeContainerdEngine] Create new client for %s, namespace %s", endpoint, c.Namespace)
+	return &Engine{client: cli, config: c, docker: config.Docker, execs: map[string]*execution{}}, nil
+}
+
+// Close closes connection to containerd
+func (e *Engine) Close() error {
+	return e.client.Close()
+}
+
+// withNamespace puts namespace of engine into ctx, some helpers of containerd client read it from ctx
+func (e *Engine) withNamespace(ctx context.Context) context.Context {
+	return namespaces.WithNamespace(ctx, e.config.Namespace)
+}
+
+// Info show node info, cpu and memory are of the host core runs on, which is the node
+func (e *Engine) Info(ctx context.Context) (*enginetypes.Info, error) {
+	server, err := e.client.Server(e.withNamespace(ctx))
+	if err != nil {
+		return nil, err
+	}
+	memTotal, err := memoryTotal(procMeminfo)
+	if err != nil {
+		return nil, err
+	}
+	return &enginetypes.Info{ID: server.UUID, NCPU: runtime.NumCPU(), MemTotal: memTotal}, nil
+}
+
+// ResourceValidate validate resource usage
+func (e *Engine) ResourceValidate(ctx context.Context, cpu float64, cpumap map[string]int64, memory, storage int64) error {
+	return nil
+}
+
+// memoryTotal reads MemTotal of meminfo in bytes
+func memoryTotal(meminfo string) (int64, error) {
+	f, err := os.Open(meminfo)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return kb << 10, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no MemTotal in %s", meminfo)
+}
@@ -0,0 +1,200 @@
+package containerd
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/oci"
+	"github.com/containerd/containerd/runtime/restart"
+	"github.com/docker/go-units"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	corecluster "github.com/projecteru2/core/cluster"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	coretypes "github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeClient(t *testing.T) {
+	_, err := MakeClient(context.Background(), coretypes.Config{}, "node", PrefixKey+"127.0.0.1:2375", "", "", "")
+	assert.Error(t, err)
+}
+
+func TestMakeResources(t *testing.T) {
+	r := makeResources(1.5, 0, units.GiB, map[string]int64{"2": 100, "0": 50}, "0", false)
+	assert.Equal(t, int64(1.5*corecluster.CPUPeriodBase), *r.CPU.Quota)
+	assert.Equal(t, uint64(corecluster.CPUPeriodBase), *r.CPU.Period)
+	assert.Nil(t, r.CPU.Shares)
+	assert.Equal(t, "0,2", r.CPU.Cpus)
+	assert.Equal(t, "0", r.CPU.Mems)
+	assert.Equal(t, int64(units.GiB), *r.Memory.Limit)
+	assert.Equal(t, int64(units.GiB), *r.Memory.Swap)
+	assert.Equal(t, int64(units.GiB/2), *r.Memory.Reservation)
+
+	r = makeResources(-1, 512, units.GiB, nil, "", true)
+	assert.Equal(t, int64(-1), *r.CPU.Quota)
+	assert.Equal(t, uint64(512), *r.CPU.Shares)
+	assert.Empty(t, r.CPU.Cpus)
+	assert.Nil(t, r.Memory.Limit)
+	assert.Equal(t, int64(units.GiB), *r.Memory.Reservation)
+
+	r = makeResources(0, 0, 0, nil, "", false)
+	assert.Nil(t, r.CPU.Quota)
+	assert.Nil(t, r.Memory.Limit)
+	assert.Nil(t, r.Memory.Reservation)
+}
+
+func TestMakeMounts(t *testing.T) {
+	mounts := makeMounts([]string{"/data/app:$APPDIR/data:ro", "/tmp:/tmp", "/plan:/plan:rw:1024", "invalid"}, makeEnv([]string{"APPDIR=/home/app", "EMPTY"}))
+	assert.Equal(t, []specs.Mount{
+		{Destination: "/home/app/data", Type: "bind", Source: "/data/app", Options: []string{"ro", "rbind"}},
+		{Destination: "/tmp", Type: "bind", Source: "/tmp", Options: []string{"rw", "rbind"}},
+		{Destination: "/plan", Type: "bind", Source: "/plan", Options: []string{"rw", "rbind"}},
+	}, mounts)
+}
+
+func TestMakeRlimits(t *testing.T) {
+	assert.Equal(t, []specs.POSIXRlimit{{Type: "RLIMIT_NOFILE", Soft: 65535, Hard: 65535}}, makeRlimits(nil))
+	assert.Equal(t, []specs.POSIXRlimit{{Type: "RLIMIT_NOFILE", Soft: 1024, Hard: 2048}, {Type: "RLIMIT_CORE", Soft: 0, Hard: 0}},
+		makeRlimits([]enginetypes.Ulimit{{Name: "nofile", Soft: 1024, Hard: 2048}, {Name: "core"}}))
+}
+
+func TestMakeHugepageLimits(t *testing.T) {
+	limits, err := makeHugepageLimits(map[string]int64{"2Mi": 512, "1Gi": 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []specs.LinuxHugepageLimit{
+		{Pagesize: "1GB", Limit: 2 * units.GiB},
+		{Pagesize: "2MB", Limit: 512 * 2 * units.MiB},
+	}, limits)
+
+	_, err = makeHugepageLimits(map[string]int64{"3Mi": 1})
+	assert.True(t, errors.Is(err, coretypes.ErrBadHugepages))
+}
+
+func TestMakeCapabilities(t *testing.T) {
+	assert.Equal(t, []string{"CAP_SYS_ADMIN", "CAP_NET_RAW"}, makeCapabilities([]string{"sys_admin", "CAP_NET_RAW"}))
+}
+
+func TestMakeRestartPolicy(t *testing.T) {
+	policy, err := makeRestartPolicy(enginetypes.RestartPolicy{})
+	assert.NoError(t, err)
+	assert.Empty(t, policy)
+	policy, err = makeRestartPolicy(enginetypes.RestartPolicy{Name: coretypes.RestartAlways})
+	assert.NoError(t, err)
+	assert.Equal(t, coretypes.RestartAlways, policy)
+
+	for _, p := range []enginetypes.RestartPolicy{
+		{Name: coretypes.RestartOnFailure, MaxRetries: 3},
+		{Name: coretypes.RestartAlways, Backoff: 10},
+		{Name: coretypes.RestartAlways, Window: 60},
+	} {
+		_, err = makeRestartPolicy(p)
+		assert.True(t, errors.Is(err, coretypes.ErrInvalidRestartPolicy))
+	}
+}
+
+func TestLogPath(t *testing.T) {
+	c := &containers.Container{}
+	assert.Empty(t, logPath(c.Labels))
+	assert.NoError(t, restart.WithFileLogURI("/var/log/eru/containerd/eru/abc.log")(context.Background(), nil, c))
+	assert.Equal(t, "/var/log/eru/containerd/eru/abc.log", logPath(c.Labels))
+	assert.Empty(t, logPath(map[string]string{restart.LogURILabel: "binary:///usr/bin/logger"}))
+}
+
+func TestMakeProcessSpec(t *testing.T) {
+	root, err := ioutil.TempDir("", "rootfs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "etc"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "etc", "passwd"), []byte("root:x:0:0:root:/root:/bin/sh\napp:x:1000:1000::/home/app:/bin/sh\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "etc", "group"), []byte("root:x:0:\napp:x:1000:\nwheel:x:10:app\n"), 0644))
+
+	spec := &oci.Spec{Process: &specs.Process{Args: []string{"app"}, Env: []string{"A=1"}, Cwd: "/", User: specs.User{UID: 1000, GID: 1000}}}
+	p, err := makeProcessSpec(spec, &enginetypes.ExecConfig{Cmd: []string{"sh"}, Env: []string{"B=2"}, WorkingDir: "/home/app"}, true, root)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sh"}, p.Args)
+	assert.Equal(t, []string{"A=1", "B=2"}, p.Env)
+	assert.Equal(t, "/home/app", p.Cwd)
+	assert.True(t, p.Terminal)
+	assert.Equal(t, specs.User{UID: 1000, GID: 1000}, p.User)
+	assert.Nil(t, p.Capabilities)
+	// process of container is untouched
+	assert.Equal(t, []string{"A=1"}, spec.Process.Env)
+	assert.Equal(t, []string{"app"}, spec.Process.Args)
+
+	p, err = makeProcessSpec(spec, &enginetypes.ExecConfig{Cmd: []string{"sh"}, User: "root", Privileged: true}, false, root)
+	assert.NoError(t, err)
+	assert.Equal(t, specs.User{AdditionalGids: []uint32{}}, p.User)
+	assert.Equal(t, "/", p.Cwd)
+	assert.Contains(t, p.Capabilities.Effective, "CAP_SYS_ADMIN")
+
+	p, err = makeProcessSpec(spec, &enginetypes.ExecConfig{User: "app"}, false, root)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{10}, p.User.AdditionalGids)
+
+	_, err = makeProcessSpec(spec, &enginetypes.ExecConfig{User: "nobody"}, false, root)
+	assert.Error(t, err)
+}
+
+func TestTailOffset(t *testing.T) {
+	f := strings.NewReader("a\nbb\nccc\n")
+	for n, expected := range map[int]int64{-1: 0, 0: 9, 1: 5, 2: 2, 3: 0, 10: 0} {
+		offset, err := tailOffset(f, n)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, offset, "tail %d", n)
+	}
+	offset, err := tailOffset(strings.NewReader("a\nbb"), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), offset)
+}
+
+func TestFollowReader(t *testing.T) {
+	f, err := ioutil.TempFile("", "log")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("hello\n")
+	assert.NoError(t, err)
+
+	r, err := os.Open(f.Name())
+	assert.NoError(t, err)
+	running := true
+	reader := &followReader{ctx: context.Background(), file: r, running: func() bool {
+		// write more and stop after the first poll
+		if running {
+			_, _ = f.WriteString("world\n")
+			running = false
+			return true
+		}
+		return false
+	}}
+	defer reader.Close()
+	b, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello\nworld\n", string(b))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	reader = &followReader{ctx: ctx, file: r, running: func() bool { return true }}
+	_, err = reader.Read(make([]byte, 10))
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestMemoryTotal(t *testing.T) {
+	f, err := ioutil.TempFile("", "meminfo")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("MemFree:         1024 kB\nMemTotal:        2048 kB\n")
+	assert.NoError(t, err)
+	memTotal, err := memoryTotal(f.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2048*units.KiB), memTotal)
+
+	_, err = memoryTotal(filepath.Join(os.TempDir(), "not-exists"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,241 @@
+package containerd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/oci"
+	"github.com/containerd/continuity/fs"
+	"github.com/opencontainers/runc/libcontainer/user"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/pkg/errors"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// execution is an exec created but not finished, processes start when attached
+type execution struct {
+	ID     string
+	target string
+	config *enginetypes.ExecConfig
+
+	started bool
+	process containerd.Process
+	done    chan struct{}
+	code    int
+	err     error
+}
+
+// ExecCreate create a exec, the process is started by attaching it
+func (e *Engine) ExecCreate(ctx context.Context, target string, config *enginetypes.ExecConfig) (string, error) {
+	if _, err := e.client.LoadContainer(e.withNamespace(ctx), target); err != nil {
+		return "", err
+	}
+	execID := strings.ToLower(utils.RandomString(64))
+	e.Lock()
+	defer e.Unlock()
+	e.execs[execID] = &execution{ID: execID, target: target, config: config, done: make(chan struct{})}
+	return execID, nil
+}
+
+// ExecAttach attach a exec, stdout and stderr are merged into one stream
+func (e *Engine) ExecAttach(ctx context.Context, execID string, tty bool) (io.ReadCloser, io.WriteCloser, error) {
+	exec, err := e.execution(execID)
+	if err != nil {
+		return nil, nil, err
+	}
+	stdinReader, stdinWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	var stdin io.Reader = stdinReader
+	if !exec.config.AttachStdin {
+		stdin = nil
+		stdinReader.Close()
+	}
+	if err := e.start(ctx, exec, tty, stdin, outWriter, outWriter); err != nil {
+		stdinReader.Close()
+		outReader.Close()
+		return nil, nil, err
+	}
+	return outReader, stdinWriter, nil
+}
+
+// ExecAttachOutput attach a non-tty exec, stdout and stderr are split
+// both streams must be consumed, the process blocks on writing either one
+func (e *Engine) ExecAttachOutput(ctx context.Context, execID string) (io.ReadCloser, io.ReadCloser, error) {
+	exec, err := e.execution(execID)
+	if err != nil {
+		return nil, nil, err
+	}
+	stdoutReader, stdoutWriter := io.Pipe()
+	stderrReader, stderrWriter := io.Pipe()
+	if err := e.start(ctx, exec, false, nil, stdoutWriter, stderrWriter); err != nil {
+		stdoutReader.Close()
+		stderrReader.Close()
+		return nil, nil, err
+	}
+	return stdoutReader, stderrReader, nil
+}
+
+// Execute executes a container
+func (e *Engine) Execute(ctx context.Context, target string, config *enginetypes.ExecConfig) (string, io.ReadCloser, io.WriteCloser, error) {
+	execID, err := e.ExecCreate(ctx, target, config)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	reader, writer, err := e.ExecAttach(ctx, execID, config.Tty)
+	return execID, reader, writer, err
+}
+
+// ExecExitCode get exec return code, waiting for the process to exit
+func (e *Engine) ExecExitCode(ctx context.Context, execID string) (int, error) {
+	exec, err := e.execution(execID)
+	if err != nil {
+		return -1, err
+	}
+	select {
+	case <-exec.done:
+	case <-ctx.Done():
+		return -1, ctx.Err()
+	}
+	e.Lock()
+	delete(e.execs, execID)
+	e.Unlock()
+	return exec.code, exec.err
+}
+
+// ExecResize resize exec tty
+func (e *Engine) ExecResize(ctx context.Context, execID string, height, width uint) error {
+	exec, err := e.execution(execID)
+	if err != nil {
+		return err
+	}
+	e.Lock()
+	process := exec.process
+	e.Unlock()
+	if process == nil {
+		return errors.Errorf("exec %s not started", execID)
+	}
+	return process.Resize(e.withNamespace(ctx), uint32(width), uint32(height))
+}
+
+func (e *Engine) execution(execID string) (*execution, error) {
+	e.Lock()
+	defer e.Unlock()
+	exec, ok := e.execs[execID]
+	if !ok {
+		return nil, errors.Errorf("exec %s not found", execID)
+	}
+	return exec, nil
+}
+
+// start starts process of exec in task of target, writers are closed after the process exits
+func (e *Engine) start(ctx context.Context, exec *execution, tty bool, stdin io.Reader, stdout, stderr io.WriteCloser) error {
+	ctx = e.withNamespace(ctx)
+	container, err := e.client.LoadContainer(ctx, exec.target)
+	if err != nil {
+		return err
+	}
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		return err
+	}
+	spec, err := container.Spec(ctx)
+	if err != nil {
+		return err
+	}
+	pspec, err := makeProcessSpec(spec, exec.config, tty, fmt.Sprintf("/proc/%d/root", task.Pid()))
+	if err != nil {
+		return err
+	}
+	ioOpts := []cio.Opt{cio.WithStreams(stdin, stdout, stderr)}
+	if tty {
+		ioOpts = append(ioOpts, cio.WithTerminal)
+	}
+
+	e.Lock()
+	if exec.started {
+		e.Unlock()
+		return errors.Errorf("exec %s already started", exec.ID)
+	}
+	exec.started = true
+	e.Unlock()
+	execID := exec.ID
+
+	process, err := task.Exec(ctx, execID, pspec, cio.NewCreator(ioOpts...))
+	if err != nil {
+		return err
+	}
+	// exit of process outlives ctx of attaching
+	bgCtx := e.withNamespace(context.Background())
+	exitCh, err := process.Wait(bgCtx)
+	if err == nil {
+		err = process.Start(ctx)
+	}
+	if err != nil {
+		if _, derr := process.Delete(bgCtx, containerd.WithProcessKill); derr != nil {
+			log.Errorf("[start] Delete exec %s failed %v", execID, derr)
+		}
+		return err
+	}
+	e.Lock()
+	exec.process = process
+	e.Unlock()
+
+	go func() {
+		defer close(exec.done)
+		code, _, err := (<-exitCh).Result()
+		// all output is copied before closing writers
+		process.IO().Wait()
+		stdout.Close()
+		stderr.Close()
+		if _, derr := process.Delete(bgCtx); derr != nil {
+			log.Errorf("[start] Delete exec %s failed %v", execID, derr)
+		}
+		exec.code, exec.err = int(code), err
+		if err != nil {
+			exec.code = -1
+		}
+	}()
+	return nil
+}
+
+// makeProcessSpec makes process of exec from process of container, user is resolved in rootfs of task
+func makeProcessSpec(spec *oci.Spec, config *enginetypes.ExecConfig, tty bool, root string) (*specs.Process, error) {
+	pspec := *spec.Process
+	pspec.Args = config.Cmd
+	pspec.Terminal = tty
+	pspec.Env = append(append([]string{}, spec.Process.Env...), config.Env...)
+	if config.WorkingDir != "" {
+		pspec.Cwd = config.WorkingDir
+	}
+	if config.User != "" {
+		passwdPath, err := fs.RootPath(root, "/etc/passwd")
+		if err != nil {
+			return nil, err
+		}
+		groupPath, err := fs.RootPath(root, "/etc/group")
+		if err != nil {
+			return nil, err
+		}
+		u, err := user.GetExecUserPath(config.User, nil, passwdPath, groupPath)
+		if err != nil {
+			return nil, err
+		}
+		gids := []uint32{}
+		for _, gid := range u.Sgids {
+			gids = append(gids, uint32(gid))
+		}
+		pspec.User = specs.User{UID: uint32(u.Uid), GID: uint32(u.Gid), AdditionalGids: gids}
+	}
+	if config.Privileged {
+		caps := oci.GetAllCapabilities()
+		pspec.Capabilities = &specs.LinuxCapabilities{Bounding: caps, Effective: caps, Inheritable: caps, Permitted: caps, Ambient: caps}
+	}
+	return &pspec, nil
+}
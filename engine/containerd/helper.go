@@ -0,0 +1,315 @@
+package containerd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/oci"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/go-units"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	corecluster "github.com/projecteru2/core/cluster"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	coretypes "github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// labels of containers kept by engine, the rest are labels of core
+	labelPrefix  = "eru.containerd."
+	nameLabel    = labelPrefix + "name"
+	userLabel    = labelPrefix + "user"
+	restartLabel = labelPrefix + "restart"
+
+	followInterval = 500 * time.Millisecond
+)
+
+// makeEnv makes env map to expand volumes, later ones win like docker
+func makeEnv(env []string) map[string]string {
+	r := map[string]string{}
+	for _, e := range env {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) == 1 {
+			r[parts[0]] = ""
+			continue
+		}
+		r[parts[0]] = parts[1]
+	}
+	return r
+}
+
+// makeMounts makes bind mounts from volumes in the same format of docker engine,
+// e.g. "/foo-data:$SOMEENV/foodata:rw", sources of scheduled volumes are already given by volume plan
+func makeMounts(volumes []string, env map[string]string) []specs.Mount {
+	mounts := []specs.Mount{}
+	for _, volume := range volumes {
+		expanded := os.Expand(volume, func(key string) string { return env[key] })
+		parts := strings.Split(expanded, ":")
+		if len(parts) < 2 {
+			continue
+		}
+		options := []string{"rw"}
+		if len(parts) >= 3 && parts[2] != "" {
+			options = strings.Split(parts[2], ",")
+		}
+		if len(parts) == 4 && parts[3] != "0" {
+			log.Warn("[makeMounts] containerd engine not support volume with size limit")
+		}
+		mounts = append(mounts, specs.Mount{Destination: parts[1], Type: "bind", Source: parts[0], Options: append(options, "rbind")})
+	}
+	return mounts
+}
+
+// makeRlimits returns ulimits of entrypoint, with nofile of 65535 if not set
+func makeRlimits(ulimits []enginetypes.Ulimit) []specs.POSIXRlimit {
+	r := []specs.POSIXRlimit{}
+	nofile := false
+	for _, ulimit := range ulimits {
+		nofile = nofile || ulimit.Name == "nofile"
+		r = append(r, specs.POSIXRlimit{Type: "RLIMIT_" + strings.ToUpper(ulimit.Name), Soft: uint64(ulimit.Soft), Hard: uint64(ulimit.Hard)})
+	}
+	if !nofile {
+		r = append(r, specs.POSIXRlimit{Type: "RLIMIT_NOFILE", Soft: 65535, Hard: 65535})
+	}
+	return r
+}
+
+// makeResources is the same as docker engine, quota -1 means unlimited
+func makeResources(cpu float64, cpuShares, memory int64, cpuMap map[string]int64, numaNode string, softlimit bool) *specs.LinuxResources {
+	r := &specs.LinuxResources{CPU: &specs.LinuxCPU{}, Memory: &specs.LinuxMemory{}}
+	if cpuShares > 0 {
+		shares := uint64(cpuShares)
+		r.CPU.Shares = &shares
+	}
+	period := uint64(corecluster.CPUPeriodBase)
+	r.CPU.Period = &period
+	if cpu > 0 {
+		quota := int64(cpu * float64(corecluster.CPUPeriodBase))
+		r.CPU.Quota = &quota
+	} else if cpu == -1 {
+		quota := int64(-1)
+		r.CPU.Quota = &quota
+	}
+	if len(cpuMap) > 0 {
+		cpuIDs := []string{}
+		for cpuID := range cpuMap {
+			cpuIDs = append(cpuIDs, cpuID)
+		}
+		sort.Strings(cpuIDs)
+		r.CPU.Cpus = strings.Join(cpuIDs, ",")
+		r.CPU.Mems = numaNode
+	}
+	if memory <= 0 {
+		return r
+	}
+	if softlimit {
+		r.Memory.Reservation = &memory
+		return r
+	}
+	reservation := memory / 2
+	if reservation < int64(units.MiB*4) {
+		reservation = int64(units.MiB * 4)
+	}
+	r.Memory.Limit = &memory
+	r.Memory.Swap = &memory
+	r.Memory.Reservation = &reservation
+	return r
+}
+
+// makeHugepageLimits makes hugetlb limits in bytes, oci names page sizes by 2MB / 1GB instead of 2Mi / 1Gi
+func makeHugepageLimits(hugepages map[string]int64) ([]specs.LinuxHugepageLimit, error) {
+	sizes := []string{}
+	for size := range hugepages {
+		sizes = append(sizes, size)
+	}
+	sort.Strings(sizes)
+	r := []specs.LinuxHugepageLimit{}
+	for _, size := range sizes {
+		pageSize := coretypes.HugepageSize(size)
+		if pageSize == 0 || hugepages[size] < 0 {
+			return nil, coretypes.NewDetailedErr(coretypes.ErrBadHugepages, fmt.Sprintf("%s: %d", size, hugepages[size]))
+		}
+		r = append(r, specs.LinuxHugepageLimit{Pagesize: strings.TrimSuffix(size, "i") + "B", Limit: uint64(pageSize * hugepages[size])})
+	}
+	return r, nil
+}
+
+// makeCapabilities prefixes capabilities in docker format with CAP_
+func makeCapabilities(caps []string) []string {
+	r := []string{}
+	for _, c := range caps {
+		c = strings.ToUpper(c)
+		if !strings.HasPrefix(c, "CAP_") {
+			c = "CAP_" + c
+		}
+		r = append(r, c)
+	}
+	return r
+}
+
+// withResources replaces cpu, memory and hugetlb limits of spec
+func withResources(resources *specs.LinuxResources) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
+		if s.Linux == nil {
+			s.Linux = &specs.Linux{}
+		}
+		if s.Linux.Resources == nil {
+			s.Linux.Resources = &specs.LinuxResources{}
+		}
+		s.Linux.Resources.CPU = resources.CPU
+		s.Linux.Resources.Memory = resources.Memory
+		s.Linux.Resources.HugepageLimits = resources.HugepageLimits
+		return nil
+	}
+}
+
+// withRlimits replaces rlimits of process
+func withRlimits(rlimits []specs.POSIXRlimit) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
+		s.Process.Rlimits = rlimits
+		return nil
+	}
+}
+
+// withSysctl sets sysctls of container
+func withSysctl(sysctl map[string]string) oci.SpecOpts {
+	return func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
+		if len(sysctl) == 0 {
+			return nil
+		}
+		if s.Linux == nil {
+			s.Linux = &specs.Linux{}
+		}
+		if s.Linux.Sysctl == nil {
+			s.Linux.Sysctl = map[string]string{}
+		}
+		for k, v := range sysctl {
+			s.Linux.Sysctl[k] = v
+		}
+		return nil
+	}
+}
+
+// withDevice grants a host device, mapped to another path in container if given
+func withDevice(device enginetypes.Device) oci.SpecOpts {
+	return func(ctx context.Context, client oci.Client, c *containers.Container, s *oci.Spec) error {
+		permissions := device.Permissions
+		if permissions == "" {
+			permissions = "rwm"
+		}
+		if err := oci.WithLinuxDevice(device.PathOnHost, permissions)(ctx, client, c, s); err != nil {
+			return err
+		}
+		if device.PathInContainer != "" {
+			s.Linux.Devices[len(s.Linux.Devices)-1].Path = device.PathInContainer
+		}
+		return nil
+	}
+}
+
+// normalizeRef makes full name of image in containerd, e.g. docker.io/library/redis:latest
+func normalizeRef(ref string) (reference.Named, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return nil, err
+	}
+	return reference.TagNameOnly(named), nil
+}
+
+// internalLabel tells labels kept by engine and restart monitor of containerd from labels of core
+func internalLabel(key string) bool {
+	return strings.HasPrefix(key, labelPrefix) || strings.HasPrefix(key, "containerd.io/")
+}
+
+// tailOffset returns offset of the last n lines of f, n < 0 means all lines
+func tailOffset(f io.ReadSeeker, n int) (int64, error) {
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil || n < 0 {
+		return 0, err
+	}
+	if n == 0 {
+		return size, nil
+	}
+	buf := make([]byte, 4096)
+	offset := size
+	lines := 0
+	// a trailing newline ends the last line instead of starting a new one
+	skip := true
+	for offset > 0 {
+		chunk := int64(len(buf))
+		if offset < chunk {
+			chunk = offset
+		}
+		offset -= chunk
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+		if _, err := io.ReadFull(f, buf[:chunk]); err != nil {
+			return 0, err
+		}
+		for i := chunk - 1; i >= 0; i-- {
+			if buf[i] != '\n' {
+				skip = false
+				continue
+			}
+			if skip {
+				skip = false
+				continue
+			}
+			lines++
+			if lines == n {
+				return offset + i + 1, nil
+			}
+		}
+	}
+	return 0, nil
+}
+
+// followReader reads file until task stops, waiting for more at EOF like tail -f
+type followReader struct {
+	ctx     context.Context
+	file    *os.File
+	running func() bool
+}
+
+// Read .
+func (r *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.file.Read(p)
+		if err != io.EOF || n > 0 {
+			return n, err
+		}
+		if !r.running() {
+			// logs written right before exit
+			return r.file.Read(p)
+		}
+		select {
+		case <-r.ctx.Done():
+			return 0, r.ctx.Err()
+		case <-time.After(followInterval):
+		}
+	}
+}
+
+// Close .
+func (r *followReader) Close() error {
+	return r.file.Close()
+}
+
+// jsonMessage makes a message of json stream the same as docker pulling and pushing
+func jsonMessage(id, status string) (io.ReadCloser, error) {
+	b, err := json.Marshal(map[string]string{"id": id, "status": status})
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
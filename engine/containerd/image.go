@@ -0,0 +1,209 @@
+package containerd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
+	"github.com/docker/distribution/reference"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	coresource "github.com/projecteru2/core/source"
+	coretypes "github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+)
+
+const (
+	dockerHubDomain = "docker.io"
+	dockerHubHost   = "registry-1.docker.io"
+)
+
+// ImageList list images of the same repo
+func (e *Engine) ImageList(ctx context.Context, image string) ([]*enginetypes.Image, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil, err
+	}
+	prefix := named.Name() + ":"
+	imgs, err := e.client.ListImages(e.withNamespace(ctx))
+	if err != nil {
+		return nil, err
+	}
+	// images are names of targets in containerd, the same target is one image of docker
+	byDigest := map[string]*enginetypes.Image{}
+	r := []*enginetypes.Image{}
+	for _, img := range imgs {
+		if !strings.HasPrefix(img.Name(), prefix) {
+			continue
+		}
+		digest := img.Target().Digest.String()
+		if _, ok := byDigest[digest]; !ok {
+			byDigest[digest] = &enginetypes.Image{ID: digest}
+			r = append(r, byDigest[digest])
+		}
+		byDigest[digest].Tags = append(byDigest[digest].Tags, img.Name())
+	}
+	return r, nil
+}
+
+// ImageRemove remove a image, content is collected by gc of containerd
+func (e *Engine) ImageRemove(ctx context.Context, image string, force, prune bool) ([]string, error) {
+	named, err := normalizeRef(image)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.client.ImageService().Delete(e.withNamespace(ctx), named.String(), images.SynchronousDelete()); err != nil {
+		return nil, err
+	}
+	return []string{named.String()}, nil
+}
+
+// ImagesPrune does nothing, unreferenced content is collected by gc of containerd
+func (e *Engine) ImagesPrune(ctx context.Context) error {
+	return nil
+}
+
+// ImagePull pull and unpack image, returns after pulled with one message of json stream
+// auth of registry in config is used if auth is nil
+func (e *Engine) ImagePull(ctx context.Context, ref string, all bool, auth *enginetypes.AuthConfig) (io.ReadCloser, error) {
+	if all {
+		return nil, coretypes.NewDetailedErr(coretypes.ErrEngineNotImplemented, "pull all tags")
+	}
+	named, err := normalizeRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	img, err := e.client.Pull(e.withNamespace(ctx), named.String(),
+		containerd.WithPullUnpack,
+		containerd.WithPullSnapshotter(e.config.Snapshotter),
+		containerd.WithResolver(e.resolver(auth)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return jsonMessage(img.Target().Digest.String(), fmt.Sprintf("Pulled %s", named.String()))
+}
+
+// ImagePush push image, returns after pushed with one message of json stream
+func (e *Engine) ImagePush(ctx context.Context, ref string) (io.ReadCloser, error) {
+	named, err := normalizeRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	ctx = e.withNamespace(ctx)
+	img, err := e.client.GetImage(ctx, named.String())
+	if err != nil {
+		return nil, err
+	}
+	if err := e.client.Push(ctx, named.String(), img.Target(), containerd.WithResolver(e.resolver(nil))); err != nil {
+		return nil, err
+	}
+	return jsonMessage(img.Target().Digest.String(), fmt.Sprintf("Pushed %s", named.String()))
+}
+
+// ImageBuild is not supported, containerd has no builder
+func (e *Engine) ImageBuild(ctx context.Context, input io.Reader, refs []string, secrets map[string][]byte) (io.ReadCloser, error) {
+	return nil, coretypes.ErrEngineNotImplemented
+}
+
+// ImageBuildFromExist is not supported, containerd can't commit snapshot of container
+func (e *Engine) ImageBuildFromExist(ctx context.Context, ID, name string) (string, error) {
+	return "", coretypes.ErrEngineNotImplemented
+}
+
+// ImageCommit is not supported, containerd can't commit snapshot of container
+func (e *Engine) ImageCommit(ctx context.Context, ID string, refs []string, comment string) (string, error) {
+	return "", coretypes.ErrEngineNotImplemented
+}
+
+// ImageBuildCachePrune is not supported, containerd has no build cache
+func (e *Engine) ImageBuildCachePrune(ctx context.Context, all bool) (uint64, error) {
+	return 0, coretypes.ErrEngineNotImplemented
+}
+
+// ImageLocalDigests return image digests
+func (e *Engine) ImageLocalDigests(ctx context.Context, image string) ([]string, error) {
+	named, err := normalizeRef(image)
+	if err != nil {
+		return nil, err
+	}
+	img, err := e.client.GetImage(e.withNamespace(ctx), named.String())
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("%s@%s", named.Name(), img.Target().Digest.String())}, nil
+}
+
+// ImageRemoteDigest return image digest at remote
+func (e *Engine) ImageRemoteDigest(ctx context.Context, image string) (string, error) {
+	named, err := normalizeRef(image)
+	if err != nil {
+		return "", err
+	}
+	_, desc, err := e.resolver(nil).Resolve(e.withNamespace(ctx), named.String())
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s@%s", named.Name(), desc.Digest.String()), nil
+}
+
+// BuildRefs output refs, the same as docker engine
+func (e *Engine) BuildRefs(ctx context.Context, name string, tags []string) []string {
+	if len(tags) == 0 {
+		tags = []string{utils.DefaultVersion}
+	}
+	refs := []string{}
+	prefix := strings.Trim(e.docker.Namespace, "/")
+	for _, tag := range tags {
+		if prefix == "" {
+			refs = append(refs, fmt.Sprintf("%s/%s:%s", e.docker.Hub, name, tag))
+			continue
+		}
+		refs = append(refs, fmt.Sprintf("%s/%s/%s:%s", e.docker.Hub, prefix, name, tag))
+	}
+	return refs
+}
+
+// BuildContent is not supported, images are built from scm by docker nodes of build pod
+func (e *Engine) BuildContent(ctx context.Context, scm coresource.Source, opts *enginetypes.BuildContentOptions) (string, io.Reader, error) {
+	return "", nil, coretypes.ErrEngineNotImplemented
+}
+
+// getImage returns image of ref, unpacked into snapshotter of engine
+func (e *Engine) getImage(ctx context.Context, ref string) (containerd.Image, error) {
+	named, err := normalizeRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	img, err := e.client.GetImage(ctx, named.String())
+	if err != nil {
+		return nil, err
+	}
+	unpacked, err := img.IsUnpacked(ctx, e.config.Snapshotter)
+	if err != nil || unpacked {
+		return img, err
+	}
+	return img, img.Unpack(ctx, e.config.Snapshotter)
+}
+
+// resolver resolves refs with auth given by core, or auth of registry in config if it's nil
+func (e *Engine) resolver(auth *enginetypes.AuthConfig) remotes.Resolver {
+	creds := func(host string) (string, string, error) {
+		if auth != nil {
+			return auth.Username, auth.Password, nil
+		}
+		// registries in config are named by domain of refs
+		if host == dockerHubHost {
+			host = dockerHubDomain
+		}
+		authConfig := e.docker.AuthConfigs[host]
+		return authConfig.Username, authConfig.Password, nil
+	}
+	return docker.NewResolver(docker.ResolverOptions{
+		Hosts: docker.ConfigureDefaultRegistries(docker.WithAuthorizer(docker.NewDockerAuthorizer(docker.WithAuthCreds(creds)))),
+	})
+}
@@ -0,0 +1,23 @@
+package containerd
+
+import (
+	"context"
+
+	enginetypes "github.com/projecteru2/core/engine/types"
+	coretypes "github.com/projecteru2/core/types"
+)
+
+// NetworkConnect is not supported, containers are in host network or none
+func (e *Engine) NetworkConnect(ctx context.Context, network, target, ipv4, ipv6 string) ([]string, error) {
+	return nil, coretypes.ErrEngineNotImplemented
+}
+
+// NetworkDisconnect is not supported, containers are in host network or none
+func (e *Engine) NetworkDisconnect(ctx context.Context, network, target string, force bool) error {
+	return coretypes.ErrEngineNotImplemented
+}
+
+// NetworkList is not supported, containerd has no networks
+func (e *Engine) NetworkList(ctx context.Context, drivers []string) ([]*enginetypes.Network, error) {
+	return nil, coretypes.ErrEngineNotImplemented
+}
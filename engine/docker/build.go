@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -32,8 +34,11 @@ const (
 WORKDIR {{.Dir}}{{ end }}
 {{ if .Repo }}ADD {{.Repo}} .{{ end }}
 {{ if .StopSignal }}STOPSIGNAL {{.StopSignal}} {{ end }}`
-	copyTmpl = "COPY --from=%s %s %s"
-	runTmpl  = "RUN %s"
+	copyTmpl   = "COPY --from=%s %s %s"
+	runTmpl    = "RUN %s"
+	secretTmpl = "--mount=type=secret,id=%s "
+	// label of verified source ref of stage
+	verifiedLabel = "eru.source.%s.verified"
 	//TODO consider work dir privilege
 	//Add user manually
 	userTmpl = `RUN echo "{{.User}}::{{.UID}}:{{.UID}}:{{.User}}:/dev/null:/sbin/nologin" >> /etc/passwd && \
@@ -62,8 +67,8 @@ func (e *Engine) BuildRefs(ctx context.Context, name string, tags []string) []st
 //
 // build directory is like:
 //
-//    buildDir ├─ :appname ├─ code
-//             ├─ Dockerfile
+//	buildDir ├─ :appname ├─ code
+//	         ├─ Dockerfile
 func (e *Engine) BuildContent(ctx context.Context, scm coresource.Source, opts *enginetypes.BuildContentOptions) (string, io.Reader, error) {
 	if opts.Builds == nil {
 		return "", nil, coretypes.ErrNoBuildsInSpec
@@ -87,8 +92,10 @@ func (e *Engine) makeDockerFile(ctx context.Context, opts *types.BuildContentOpt
 	var preCache map[string]string
 	var preStage string
 	var buildTmpl []string
+	// verified refs of every stage are labeled on the final image
+	verified := map[string]string{}
 
-	for _, stage := range opts.Builds.Stages {
+	for i, stage := range opts.Builds.Stages {
 		build, ok := opts.Builds.Builds[stage]
 		if !ok {
 			log.Warnf("[makeDockerFile] Builds stage %s not defined", stage)
@@ -96,11 +103,22 @@ func (e *Engine) makeDockerFile(ctx context.Context, opts *types.BuildContentOpt
 		}
 
 		// get source or artifacts
-		reponame, err := e.preparedSource(ctx, build, scm, buildDir)
+		reponame, ref, err := e.preparedSource(ctx, build, scm, buildDir)
 		if err != nil {
 			return err
 		}
 		build.Repo = reponame
+		if build.Verify != nil || (build.Tarball != nil && build.Tarball.Checksum != "") {
+			verified[fmt.Sprintf(verifiedLabel, stage)] = ref
+		}
+		if i == len(opts.Builds.Stages)-1 && len(verified) > 0 {
+			if build.Labels == nil {
+				build.Labels = map[string]string{}
+			}
+			for k, v := range verified {
+				build.Labels[k] = v
+			}
+		}
 
 		// get header
 		from := fmt.Sprintf(fromAsTmpl, build.Base, stage)
@@ -111,10 +129,14 @@ func (e *Engine) makeDockerFile(ctx context.Context, opts *types.BuildContentOpt
 			copys = append(copys, fmt.Sprintf(copyTmpl, preStage, src, dst))
 		}
 
-		// get commands
+		// get commands, secrets are mounted only while running commands
+		mounts := ""
+		for _, secret := range build.Secrets {
+			mounts += fmt.Sprintf(secretTmpl, secret)
+		}
 		commands := []string{}
 		for _, command := range build.Commands {
-			commands = append(commands, fmt.Sprintf(runTmpl, command))
+			commands = append(commands, fmt.Sprintf(runTmpl, mounts+command))
 		}
 
 		// decide add source or not
@@ -138,12 +160,23 @@ func (e *Engine) makeDockerFile(ctx context.Context, opts *types.BuildContentOpt
 	return createDockerfile(dockerfile, buildDir)
 }
 
-func (e *Engine) preparedSource(ctx context.Context, build *types.Build, scm coresource.Source, buildDir string) (string, error) {
+func (e *Engine) preparedSource(ctx context.Context, build *types.Build, scm coresource.Source, buildDir string) (string, string, error) {
 	// parse repository name
 	// code locates under /:repositoryname
 	var cloneDir string
 	var err error
-	reponame := ""
+	reponame, ref := "", ""
+	if build.Repo != "" && build.Tarball != nil {
+		return "", "", coretypes.ErrRepoTarballExclusive
+	}
+	if build.Tarball != nil {
+		// tarball locates under /:tarballname
+		reponame = tarballName(build.Tarball.URL)
+		cloneDir = filepath.Join(buildDir, reponame)
+		if ref, err = scm.Tarball(ctx, build.Tarball, cloneDir); err != nil {
+			return "", "", err
+		}
+	}
 	if build.Repo != "" { // nolint
 		version := build.Version
 		if version == "" {
@@ -151,25 +184,41 @@ func (e *Engine) preparedSource(ctx context.Context, build *types.Build, scm cor
 		}
 		reponame, err = utils.GetGitRepoName(build.Repo)
 		if err != nil {
-			return "", err
+			return "", "", err
 		}
 
 		// clone code into cloneDir
 		// which is under buildDir and named as repository name
 		cloneDir = filepath.Join(buildDir, reponame)
-		if err := scm.SourceCode(ctx, build.Repo, cloneDir, version, build.Submodule); err != nil {
-			return "", err
+		sourceOpts := &types.SourceOptions{
+			Revision:   version,
+			Branch:     build.Branch,
+			Tag:        build.Tag,
+			Commit:     build.Commit,
+			Depth:      build.Depth,
+			Submodule:  build.Submodule,
+			Credential: build.Credential,
+			Verify:     build.Verify,
+		}
+		if ref, err = scm.SourceCode(ctx, build.Repo, cloneDir, sourceOpts); err != nil {
+			return "", "", err
 		}
 
 		if build.Security {
 			// ensure source code is safe
 			// we don't want any history files to be retrieved
 			if err := scm.Security(cloneDir); err != nil {
-				return "", err
+				return "", "", err
 			}
 		}
 	}
 
+	if cloneDir != "" && build.Subpath != "" {
+		if err := keepSubpath(cloneDir, build.Subpath); err != nil {
+			return "", "", err
+		}
+	}
+
 	// if artifact download url is provided, remove all source code to
 	// improve security
 	if len(build.Artifacts) > 0 {
@@ -177,16 +226,52 @@ func (e *Engine) preparedSource(ctx context.Context, build *types.Build, scm cor
 		if cloneDir != "" {
 			os.RemoveAll(cloneDir)
 			if err := os.MkdirAll(cloneDir, os.ModeDir); err != nil {
-				return "", err
+				return "", "", err
 			}
 			artifactsDir = cloneDir
 		}
 		for _, artifact := range build.Artifacts {
 			if err := scm.Artifact(artifact, artifactsDir); err != nil {
-				return "", err
+				return "", "", err
 			}
 		}
 	}
 
-	return reponame, nil
+	return reponame, ref, nil
+}
+
+// tarballName is the url file name without archive extensions
+func tarballName(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "source"
+	}
+	name := path.Base(u.Path)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if name == "" || name == "." || name == "/" {
+		return "source"
+	}
+	return name
+}
+
+// keepSubpath replaces cloneDir with its subpath, so monorepo builds only send the subpath in context
+func keepSubpath(cloneDir, subpath string) error {
+	subpath = filepath.Clean(subpath)
+	if filepath.IsAbs(subpath) || subpath == "." || strings.HasPrefix(subpath, "..") {
+		return coretypes.NewDetailedErr(coretypes.ErrBadSubpath, subpath)
+	}
+	src := filepath.Join(cloneDir, subpath)
+	if info, err := os.Stat(src); err != nil || !info.IsDir() {
+		return coretypes.NewDetailedErr(coretypes.ErrBadSubpath, subpath)
+	}
+	tmp := cloneDir + ".subpath"
+	if err := os.Rename(src, tmp); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(cloneDir); err != nil {
+		return err
+	}
+	return os.Rename(tmp, cloneDir)
 }
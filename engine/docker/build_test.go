@@ -0,0 +1,67 @@
+package docker
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	enginetypes "github.com/projecteru2/core/engine/types"
+	sourcemocks "github.com/projecteru2/core/source/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestKeepSubpath(t *testing.T) {
+	buildDir, err := ioutil.TempDir("", "build")
+	assert.NoError(t, err)
+	defer os.RemoveAll(buildDir)
+	cloneDir := filepath.Join(buildDir, "repo")
+	assert.NoError(t, os.MkdirAll(filepath.Join(cloneDir, "services", "api"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(cloneDir, "services", "api", "main.go"), []byte("api"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(cloneDir, "README"), []byte("readme"), 0644))
+
+	for _, bad := range []string{"/etc", "../repo", ".", "services/nothing", "README"} {
+		assert.Error(t, keepSubpath(cloneDir, bad))
+	}
+	assert.NoError(t, keepSubpath(cloneDir, "services/api/"))
+	b, err := ioutil.ReadFile(filepath.Join(cloneDir, "main.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "api", string(b))
+	_, err = os.Stat(filepath.Join(cloneDir, "README"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestVerifiedSourceLabel(t *testing.T) {
+	buildDir, err := ioutil.TempDir("", "build")
+	assert.NoError(t, err)
+	defer os.RemoveAll(buildDir)
+
+	scm := &sourcemocks.Source{}
+	scm.On("SourceCode", mock.Anything, "git@github.com:a/b.git", mock.Anything, mock.Anything).Return("sha", nil)
+	opts := &enginetypes.BuildContentOptions{
+		Builds: &enginetypes.Builds{
+			Stages: []string{"build", "run"},
+			Builds: map[string]*enginetypes.Build{
+				"build": {Base: "golang", Repo: "git@github.com:a/b.git", Verify: &enginetypes.SourceVerify{Signature: true}},
+				"run":   {Base: "alpine"},
+			},
+		},
+	}
+	e := &Engine{}
+	assert.NoError(t, e.makeDockerFile(context.Background(), opts, scm, buildDir))
+	b, err := ioutil.ReadFile(filepath.Join(buildDir, "Dockerfile"))
+	assert.NoError(t, err)
+	// label on the final stage
+	parts := strings.Split(string(b), "FROM alpine")
+	assert.Len(t, parts, 2)
+	assert.Contains(t, parts[1], "LABEL eru.source.build.verified=sha")
+}
+
+func TestTarballName(t *testing.T) {
+	assert.Equal(t, "vendor-1.0", tarballName("https://example.com/dl/vendor-1.0.tar.gz?token=x"))
+	assert.Equal(t, "vendor", tarballName("https://example.com/vendor.zip"))
+	assert.Equal(t, "source", tarballName("https://example.com/"))
+}
@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/go-connections/nat"
 	"github.com/docker/go-units"
@@ -20,8 +21,10 @@ import (
 
 	dockertypes "github.com/docker/docker/api/types"
 	dockercontainer "github.com/docker/docker/api/types/container"
+	dockerfilters "github.com/docker/docker/api/types/filters"
 	dockernetwork "github.com/docker/docker/api/types/network"
 	dockerslice "github.com/docker/docker/api/types/strslice"
+	dockerapi "github.com/docker/docker/client"
 
 	"encoding/json"
 
@@ -34,6 +37,7 @@ const (
 	maxMemory     = math.MaxInt64
 	restartAlways = "always"
 	root          = "root"
+	hugetlbfs     = "/dev/hugepages"
 )
 
 type rawArgs struct {
@@ -55,10 +59,22 @@ func (e *Engine) VirtualizationCreate(ctx context.Context, opts *enginetypes.Vir
 		opts.LogType = "json-file"
 	}
 	// set restart always
+	restartPolicy := opts.RestartPolicy.Name
 	restartRetryCount := 3
-	if opts.RestartPolicy == restartAlways {
+	if restartPolicy == restartAlways {
 		restartRetryCount = 0
 	}
+	if opts.RestartPolicy.MaxRetries > 0 {
+		restartRetryCount = opts.RestartPolicy.MaxRetries
+	}
+	if opts.RestartPolicy.Backoff > 0 {
+		// docker uses its own exponential backoff
+		return r, coretypes.NewDetailedErr(coretypes.ErrInvalidRestartPolicy, "docker engine doesn't support backoff")
+	}
+	if opts.RestartPolicy.Window > 0 {
+		// docker counts retries in the whole lifetime
+		return r, coretypes.NewDetailedErr(coretypes.ErrInvalidRestartPolicy, "docker engine doesn't support window")
+	}
 	// network mode 和 networks 互斥
 	// 没有 networks 的时候用 networkmode 的值
 	// 有 networks 的时候一律用用 networks 的值作为 mode
@@ -86,6 +102,10 @@ func (e *Engine) VirtualizationCreate(ctx context.Context, opts *enginetypes.Vir
 			opts.LogConfig[k] = v
 		}
 	}
+	// gpu devices are exposed by runtime of nvidia, docker api we use has no device requests
+	if len(opts.GPU) > 0 {
+		opts.Env = append(opts.Env, fmt.Sprintf("NVIDIA_VISIBLE_DEVICES=%s", strings.Join(coretypes.GPUDevices(opts.GPU), ",")))
+	}
 	// add node IP
 	hostIP := GetIP(e.client.DaemonHost())
 	opts.Env = append(opts.Env, fmt.Sprintf("ERU_NODE_IP=%s", hostIP))
@@ -98,6 +118,15 @@ func (e *Engine) VirtualizationCreate(ctx context.Context, opts *enginetypes.Vir
 	}
 	// mount paths
 	binds, volumes := makeMountPaths(opts)
+	// docker api has no hugetlb limits, hugepages are only guaranteed by scheduling,
+	// hugetlbfs mounted by systemd on host is bound to use them
+	if len(opts.Hugepages) > 0 {
+		if err := coretypes.ValidateHugepages(opts.Hugepages); err != nil {
+			return r, err
+		}
+		log.Warnf("[VirtualizationCreate] docker engine not support hugetlb limits, %s will use hugepages %v without limits", opts.Name, opts.Hugepages)
+		binds = append(binds, fmt.Sprintf("%s:%s:rw", hugetlbfs, hugetlbfs))
+	}
 	log.Debugf("[VirtualizationCreate] App %s will bind %v", opts.Name, binds)
 
 	config := &dockercontainer.Config{
@@ -113,11 +142,9 @@ func (e *Engine) VirtualizationCreate(ctx context.Context, opts *enginetypes.Vir
 		Tty:             opts.Stdin,
 	}
 
-	resource := makeResourceSetting(opts.Quota, opts.Memory, opts.CPU, opts.NUMANode, opts.SoftLimit)
-	// set ulimits
-	resource.Ulimits = []*units.Ulimit{
-		{Name: "nofile", Soft: 65535, Hard: 65535},
-	}
+	resource := makeResourceSetting(opts.Quota, opts.CPUShares, opts.Memory, opts.CPU, opts.NUMANode, opts.SoftLimit)
+	// set ulimits, nofile is raised by default unless entrypoint sets it
+	resource.Ulimits = makeUlimits(opts.Ulimits)
 	if networkMode.IsHost() {
 		opts.DNS = []string{}
 		opts.Sysctl = map[string]string{}
@@ -162,7 +189,7 @@ func (e *Engine) VirtualizationCreate(ctx context.Context, opts *enginetypes.Vir
 		},
 		NetworkMode: networkMode,
 		RestartPolicy: dockercontainer.RestartPolicy{
-			Name:              opts.RestartPolicy,
+			Name:              restartPolicy,
 			MaximumRetryCount: restartRetryCount,
 		},
 		CapAdd:     capAdds,
@@ -173,6 +200,16 @@ func (e *Engine) VirtualizationCreate(ctx context.Context, opts *enginetypes.Vir
 		PidMode:    rArgs.PidMode,
 		StorageOpt: rArgs.StorageOpt,
 	}
+	if len(opts.GPU) > 0 {
+		hostConfig.Runtime = e.config.Docker.GPURuntime
+	}
+	for _, device := range opts.Devices {
+		hostConfig.Devices = append(hostConfig.Devices, dockercontainer.DeviceMapping{
+			PathOnHost:        device.PathOnHost,
+			PathInContainer:   device.PathInContainer,
+			CgroupPermissions: device.Permissions,
+		})
+	}
 
 	if hostConfig.NetworkMode.IsBridge() {
 		portMapping := nat.PortMap{}
@@ -228,9 +265,13 @@ func (e *Engine) VirtualizationStart(ctx context.Context, ID string) error {
 	return e.client.ContainerStart(ctx, ID, dockertypes.ContainerStartOptions{})
 }
 
-// VirtualizationStop stop virtualization
-func (e *Engine) VirtualizationStop(ctx context.Context, ID string) error {
-	return e.client.ContainerStop(ctx, ID, nil)
+// VirtualizationStop stop virtualization, killed after gracefulTimeout, zero means docker default
+func (e *Engine) VirtualizationStop(ctx context.Context, ID string, gracefulTimeout time.Duration) error {
+	var timeout *time.Duration
+	if gracefulTimeout > 0 {
+		timeout = &gracefulTimeout
+	}
+	return e.client.ContainerStop(ctx, ID, timeout)
 }
 
 // VirtualizationRemove remove virtualization
@@ -246,15 +287,33 @@ func (e *Engine) VirtualizationInspect(ctx context.Context, ID string) (*enginet
 
 	containerJSON, err := e.client.ContainerInspect(ctx, ID)
 	r := &enginetypes.VirtualizationInfo{}
+	if dockerapi.IsErrNotFound(err) {
+		return r, coretypes.NewDetailedErr(coretypes.ErrContainerNotExists, ID)
+	}
 	if err != nil {
 		return r, err
 	}
 	r.ID = containerJSON.ID
+	r.Name = strings.TrimLeft(containerJSON.Name, "/")
 	r.User = containerJSON.Config.User
 	r.Image = containerJSON.Config.Image
+	r.Cmd = containerJSON.Config.Cmd
+	r.Dir = containerJSON.Config.WorkingDir
 	r.Env = containerJSON.Config.Env
 	r.Labels = containerJSON.Config.Labels
 	r.Running = containerJSON.State.Running
+	if containerJSON.HostConfig != nil {
+		resource := containerJSON.HostConfig.Resources
+		switch {
+		case resource.NanoCPUs > 0:
+			r.Quota = float64(resource.NanoCPUs) / 1e9
+		case resource.CPUQuota > 0 && resource.CPUPeriod > 0:
+			r.Quota = float64(resource.CPUQuota) / float64(resource.CPUPeriod)
+		}
+		if resource.Memory > 0 && resource.Memory != maxMemory {
+			r.Memory = resource.Memory
+		}
+	}
 	r.Networks = map[string]string{}
 	for networkName, networkSetting := range containerJSON.NetworkSettings.Networks {
 		ip := networkSetting.IPAddress
@@ -357,12 +416,86 @@ func (e *Engine) VirtualizationUpdateResource(ctx context.Context, ID string, op
 		}
 	}
 
-	newResource := makeResourceSetting(quota, memory, cpuMap, numaNode, softLimit)
+	newResource := makeResourceSetting(quota, opts.CPUShares, memory, cpuMap, numaNode, softLimit)
 	updateConfig := dockercontainer.UpdateConfig{Resources: newResource}
 	_, err := e.client.ContainerUpdate(ctx, ID, updateConfig)
 	return err
 }
 
+// VirtualizationList list virtualizations with all labels matched, stopped ones included
+func (e *Engine) VirtualizationList(ctx context.Context, labels map[string]string) ([]*enginetypes.VirtualizationInfo, error) {
+	f := dockerfilters.NewArgs()
+	for k, v := range labels {
+		f.Add("label", fmt.Sprintf("%s=%s", k, v))
+	}
+	containers, err := e.client.ContainerList(ctx, dockertypes.ContainerListOptions{All: true, Filters: f})
+	if err != nil {
+		return nil, err
+	}
+	r := []*enginetypes.VirtualizationInfo{}
+	for _, container := range containers {
+		info := &enginetypes.VirtualizationInfo{
+			ID:      container.ID,
+			Image:   container.Image,
+			Labels:  container.Labels,
+			Running: container.State == "running",
+			Created: container.Created,
+		}
+		if len(container.Names) > 0 {
+			info.Name = strings.TrimLeft(container.Names[0], "/")
+		}
+		r = append(r, info)
+	}
+	return r, nil
+}
+
+// VirtualizationStats gets cpu and memory in use of a container from one shot of docker stats
+func (e *Engine) VirtualizationStats(ctx context.Context, ID string) (*enginetypes.VirtualizationStats, error) {
+	if e.client == nil {
+		return nil, coretypes.ErrNilEngine
+	}
+	resp, err := e.client.ContainerStats(ctx, ID, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	stats := &dockertypes.StatsJSON{}
+	if err := json.NewDecoder(resp.Body).Decode(stats); err != nil {
+		return nil, err
+	}
+	return toVirtualizationStats(stats), nil
+}
+
+// toVirtualizationStats calculates cpu in use between the two samples of stats, the same as docker cli does
+func toVirtualizationStats(stats *dockertypes.StatsJSON) *enginetypes.VirtualizationStats {
+	r := &enginetypes.VirtualizationStats{}
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(stats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		r.CPU = cpuDelta / systemDelta * onlineCPUs
+	}
+	memory := stats.MemoryStats.Usage
+	if cache := stats.MemoryStats.Stats["cache"]; cache < memory {
+		memory -= cache
+	}
+	r.Memory = int64(memory)
+	return r
+}
+
+// VirtualizationCheckpoint checkpoints a running virtualization by CRIU, docker runs in experimental mode is required
+func (e *Engine) VirtualizationCheckpoint(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) error {
+	return e.client.CheckpointCreate(ctx, ID, dockertypes.CheckpointCreateOptions{CheckpointID: opts.Name, CheckpointDir: opts.Dir, Exit: opts.Exit})
+}
+
+// VirtualizationRestore starts a stopped virtualization from checkpoint
+func (e *Engine) VirtualizationRestore(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) error {
+	return e.client.ContainerStart(ctx, ID, dockertypes.ContainerStartOptions{CheckpointID: opts.Name, CheckpointDir: opts.Dir})
+}
+
 // VirtualizationCopyFrom copy thing from a virtualization
 func (e *Engine) VirtualizationCopyFrom(ctx context.Context, ID, path string) (io.ReadCloser, string, error) {
 	resp, stat, err := e.client.CopyFromContainer(ctx, ID, path)
@@ -0,0 +1,43 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	dockercontainer "github.com/docker/docker/api/types/container"
+	"github.com/projecteru2/core/engine/docker/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	coretypes "github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestVirtualizationCreateHugepages(t *testing.T) {
+	client := &mocks.APIClient{}
+	client.On("DaemonHost").Return("tcp://10.0.0.1:2376")
+	hostConfig := &dockercontainer.HostConfig{}
+	client.On("ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, "app_web_abcdef").Return(dockercontainer.ContainerCreateCreatedBody{ID: "abc"}, nil).Run(func(args mock.Arguments) {
+		*hostConfig = *args.Get(2).(*dockercontainer.HostConfig)
+	})
+	e := &Engine{client: client, config: coretypes.Config{Docker: coretypes.DockerConfig{NetworkMode: "host"}}}
+
+	opts := &enginetypes.VirtualizationCreateOptions{
+		Name:    "app_web_abcdef",
+		Image:   "hub/app:latest",
+		Volumes: []string{"/host:/data"},
+		VirtualizationResource: enginetypes.VirtualizationResource{
+			Memory:    1 << 30,
+			Hugepages: map[string]int64{"2Mi": 512},
+		},
+	}
+	created, err := e.VirtualizationCreate(context.Background(), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", created.ID)
+	assert.Equal(t, []string{"/host:/data:rw", "/dev/hugepages:/dev/hugepages:rw"}, hostConfig.Binds)
+	assert.Equal(t, int64(1<<30), hostConfig.Memory)
+
+	opts.Hugepages = map[string]int64{"4Ki": 1}
+	_, err = e.VirtualizationCreate(context.Background(), opts)
+	assert.True(t, errors.Is(err, coretypes.ErrBadHugepages))
+}
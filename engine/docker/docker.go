@@ -71,6 +71,11 @@ func MakeClient(ctx context.Context, config coretypes.Config, nodename, endpoint
 	return makeRawClient(ctx, config, client, endpoint)
 }
 
+// Close closes idle connections of client
+func (e *Engine) Close() error {
+	return e.client.Close()
+}
+
 // Info show node info
 // 2 seconds timeout
 // used to be 5, but client won't wait that long
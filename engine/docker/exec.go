@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 
 	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
 	enginetypes "github.com/projecteru2/core/engine/types"
 )
 
@@ -44,6 +45,24 @@ func (e *Engine) ExecAttach(ctx context.Context, execID string, tty bool) (io.Re
 	return ioutil.NopCloser(resp.Reader), resp.Conn, nil
 }
 
+// ExecAttachOutput attach a non-tty exec, stdout and stderr are split
+// both streams must be consumed, closing either one closes the connection
+func (e *Engine) ExecAttachOutput(ctx context.Context, execID string) (io.ReadCloser, io.ReadCloser, error) {
+	resp, err := e.client.ContainerExecAttach(ctx, execID, dockertypes.ExecStartCheck{})
+	if err != nil {
+		return nil, nil, err
+	}
+	outr, outw := io.Pipe()
+	errr, errw := io.Pipe()
+	go func() {
+		defer resp.Close()
+		_, err := stdcopy.StdCopy(outw, errw, resp.Reader)
+		_ = outw.CloseWithError(err)
+		_ = errw.CloseWithError(err)
+	}()
+	return fuckDockerStream{resp.Conn, outr}, fuckDockerStream{resp.Conn, errr}, nil
+}
+
 // Execute executes a container
 func (e *Engine) Execute(ctx context.Context, target string, config *enginetypes.ExecConfig) (string, io.ReadCloser, io.WriteCloser, error) {
 	execID, err := e.ExecCreate(ctx, target, config)
@@ -90,7 +90,7 @@ func makeMountPaths(opts *enginetypes.VirtualizationCreateOptions) ([]string, ma
 		} else if len(parts) >= 3 {
 			binds = append(binds, fmt.Sprintf("%s:%s:%s", parts[0], parts[1], parts[2]))
 			volumes[parts[1]] = struct{}{}
-			if len(parts) == 4 {
+			if len(parts) == 4 && parts[3] != "0" {
 				log.Warn("[makeMountPaths] docker engine not support volume with size limit")
 			}
 		}
@@ -99,8 +99,24 @@ func makeMountPaths(opts *enginetypes.VirtualizationCreateOptions) ([]string, ma
 	return binds, volumes
 }
 
-func makeResourceSetting(cpu float64, memory int64, cpuMap map[string]int64, numaNode string, softlimit bool) dockercontainer.Resources {
+// makeUlimits returns ulimits of entrypoint, with nofile of 65535 if not set
+func makeUlimits(ulimits []enginetypes.Ulimit) []*units.Ulimit {
+	r := []*units.Ulimit{}
+	nofile := false
+	for _, ulimit := range ulimits {
+		nofile = nofile || ulimit.Name == "nofile"
+		r = append(r, &units.Ulimit{Name: ulimit.Name, Soft: ulimit.Soft, Hard: ulimit.Hard})
+	}
+	if !nofile {
+		r = append(r, &units.Ulimit{Name: "nofile", Soft: 65535, Hard: 65535})
+	}
+	return r
+}
+
+func makeResourceSetting(cpu float64, cpuShares, memory int64, cpuMap map[string]int64, numaNode string, softlimit bool) dockercontainer.Resources {
 	resource := dockercontainer.Resources{}
+	// docker converts it to cpu.weight on cgroup v2
+	resource.CPUShares = cpuShares
 
 	resource.CPUQuota = 0
 	resource.CPUPeriod = corecluster.CPUPeriodBase
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 
+	dockertypes "github.com/docker/docker/api/types"
+	enginetypes "github.com/projecteru2/core/engine/types"
 	coreutils "github.com/projecteru2/core/utils"
 	"github.com/stretchr/testify/assert"
 )
@@ -40,3 +42,35 @@ func TestWithDumpFiles(t *testing.T) {
 		assert.True(t, os.IsNotExist(err))
 	}
 }
+
+func TestMakeUlimits(t *testing.T) {
+	ulimits := makeUlimits(nil)
+	assert.Len(t, ulimits, 1)
+	assert.Equal(t, "nofile", ulimits[0].Name)
+	assert.Equal(t, int64(65535), ulimits[0].Hard)
+
+	ulimits = makeUlimits([]enginetypes.Ulimit{{Name: "nofile", Soft: 1024, Hard: 4096}, {Name: "core", Soft: -1, Hard: -1}})
+	assert.Len(t, ulimits, 2)
+	assert.Equal(t, int64(1024), ulimits[0].Soft)
+	assert.Equal(t, "core", ulimits[1].Name)
+}
+
+func TestToVirtualizationStats(t *testing.T) {
+	stats := &dockertypes.StatsJSON{}
+	stats.PreCPUStats.CPUUsage.TotalUsage = 100
+	stats.PreCPUStats.SystemUsage = 1000
+	stats.CPUStats.CPUUsage.TotalUsage = 300
+	stats.CPUStats.SystemUsage = 2000
+	stats.CPUStats.CPUUsage.PercpuUsage = []uint64{150, 150, 0, 0}
+	stats.MemoryStats.Usage = 1000
+	stats.MemoryStats.Stats = map[string]uint64{"cache": 200}
+	r := toVirtualizationStats(stats)
+	assert.InDelta(t, 0.8, r.CPU, 1e-9)
+	assert.Equal(t, int64(800), r.Memory)
+
+	// first sample has nothing to compare with
+	stats.PreCPUStats = dockertypes.CPUStats{}
+	stats.CPUStats.SystemUsage = 0
+	stats.CPUStats.OnlineCPUs = 2
+	assert.Zero(t, toVirtualizationStats(stats).CPU)
+}
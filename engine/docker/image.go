@@ -8,7 +8,9 @@ import (
 	dockertypes "github.com/docker/docker/api/types"
 	dockerfilters "github.com/docker/docker/api/types/filters"
 
+	buildsecrets "github.com/projecteru2/core/engine/docker/secrets"
 	enginetypes "github.com/projecteru2/core/engine/types"
+	coretypes "github.com/projecteru2/core/types"
 	"github.com/projecteru2/core/utils"
 )
 
@@ -65,13 +67,19 @@ func (e *Engine) ImagesPrune(ctx context.Context) error {
 	return err
 }
 
-// ImagePull pull Image
-func (e *Engine) ImagePull(ctx context.Context, ref string, all bool) (io.ReadCloser, error) {
-	auth, err := makeEncodedAuthConfigFromRemote(e.config.Docker.AuthConfigs, ref)
+// ImagePull pull Image, auth of registry in config is used if auth is nil
+func (e *Engine) ImagePull(ctx context.Context, ref string, all bool, auth *enginetypes.AuthConfig) (io.ReadCloser, error) {
+	var encodedAuth string
+	var err error
+	if auth != nil {
+		encodedAuth, err = encodeAuthToBase64(coretypes.AuthConfig{Username: auth.Username, Password: auth.Password})
+	} else {
+		encodedAuth, err = makeEncodedAuthConfigFromRemote(e.config.Docker.AuthConfigs, ref)
+	}
 	if err != nil {
 		return nil, err
 	}
-	pullOptions := dockertypes.ImagePullOptions{All: all, RegistryAuth: auth}
+	pullOptions := dockertypes.ImagePullOptions{All: all, RegistryAuth: encodedAuth}
 	return e.client.ImagePull(ctx, ref, pullOptions)
 }
 
@@ -86,7 +94,8 @@ func (e *Engine) ImagePush(ctx context.Context, ref string) (io.ReadCloser, erro
 }
 
 // ImageBuild build image
-func (e *Engine) ImageBuild(ctx context.Context, input io.Reader, refs []string) (io.ReadCloser, error) {
+// secrets are served by a buildkit session of core, so they never go into build context or layers
+func (e *Engine) ImageBuild(ctx context.Context, input io.Reader, refs []string, secrets map[string][]byte) (io.ReadCloser, error) {
 	authConfigs := map[string]dockertypes.AuthConfig{}
 	for domain, conf := range e.config.Docker.AuthConfigs {
 		b64auth, err := encodeAuthToBase64(conf)
@@ -110,11 +119,41 @@ func (e *Engine) ImageBuild(ctx context.Context, input io.Reader, refs []string)
 		PullParent:     true,
 		AuthConfigs:    authConfigs,
 	}
+	if len(secrets) == 0 {
+		resp, err := e.client.ImageBuild(ctx, input, buildOptions)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+
+	// session lives until build output closed
+	sessionCtx, cancel := context.WithCancel(ctx)
+	sessionID, err := buildsecrets.Serve(sessionCtx, e.client.DialSession, secrets)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	buildOptions.Version = dockertypes.BuilderBuildKit
+	buildOptions.SessionID = sessionID
 	resp, err := e.client.ImageBuild(ctx, input, buildOptions)
 	if err != nil {
+		cancel()
 		return nil, err
 	}
-	return resp.Body, nil
+	return &sessionReadCloser{ReadCloser: resp.Body, cancel: cancel}, nil
+}
+
+// sessionReadCloser stops build session when build output closed
+type sessionReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close .
+func (s *sessionReadCloser) Close() error {
+	defer s.cancel()
+	return s.ReadCloser.Close()
 }
 
 // ImageBuildFromExist commits image from running container
@@ -132,6 +171,29 @@ func (e *Engine) ImageBuildFromExist(ctx context.Context, ID, name string) (imag
 	return resp.ID, err
 }
 
+// ImageCommit commits container filesystem to the first ref and tags it with the rest
+func (e *Engine) ImageCommit(ctx context.Context, ID string, refs []string, comment string) (string, error) {
+	if len(refs) == 0 {
+		return "", coretypes.ErrNoImage
+	}
+	opts := dockertypes.ContainerCommitOptions{
+		Reference: refs[0],
+		Comment:   comment,
+		Author:    "eru-core",
+		Pause:     true,
+	}
+	resp, err := e.client.ContainerCommit(ctx, ID, opts)
+	if err != nil {
+		return "", err
+	}
+	for _, ref := range refs[1:] {
+		if err := e.client.ImageTag(ctx, resp.ID, ref); err != nil {
+			return resp.ID, err
+		}
+	}
+	return resp.ID, nil
+}
+
 // ImageBuildCachePrune prune build cache
 func (e *Engine) ImageBuildCachePrune(ctx context.Context, all bool) (uint64, error) {
 	r, err := e.client.BuildCachePrune(ctx, dockertypes.BuildCachePruneOptions{All: all})
@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	headerSessionID        = "X-Docker-Expose-Session-Uuid"
+	headerSessionName      = "X-Docker-Expose-Session-Name"
+	headerSessionSharedKey = "X-Docker-Expose-Session-Sharedkey"
+	headerSessionMethod    = "X-Docker-Expose-Session-Grpc-Method"
+	sessionName            = "eru-core"
+)
+
+// Dialer hijacks a session connection to docker daemon
+type Dialer func(ctx context.Context, proto string, meta map[string][]string) (net.Conn, error)
+
+// Store serves secrets by ID, secrets only live in memory of core
+type Store map[string][]byte
+
+// GetSecret returns secret by ID
+func (s Store) GetSecret(ctx context.Context, req *GetSecretRequest) (*GetSecretResponse, error) {
+	data, ok := s[req.GetID()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "secret %s not found", req.GetID())
+	}
+	return &GetSecretResponse{Data: data}, nil
+}
+
+// Serve starts a buildkit session which serves secrets to builds,
+// session is alive until ctx done, returns session ID for build options
+func Serve(ctx context.Context, dial Dialer, secrets map[string][]byte) (string, error) {
+	server := grpc.NewServer()
+	RegisterSecretsServer(server, Store(secrets))
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+
+	ID := uuid.New().String()
+	meta := map[string][]string{
+		headerSessionID:        {ID},
+		headerSessionName:      {sessionName},
+		headerSessionSharedKey: {""},
+		headerSessionMethod:    methods(server),
+	}
+	conn, err := dial(ctx, "h2c", meta)
+	if err != nil {
+		return "", err
+	}
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+		server.Stop()
+	}()
+	// daemon is the grpc client over the hijacked connection
+	go (&http2.Server{}).ServeConn(conn, &http2.ServeConnOpts{Handler: server})
+	return ID, nil
+}
+
+func methods(server *grpc.Server) []string {
+	ms := []string{}
+	for name, info := range server.GetServiceInfo() {
+		for _, method := range info.Methods {
+			ms = append(ms, fmt.Sprintf("/%s/%s", name, method.Name))
+		}
+	}
+	sort.Strings(ms)
+	return ms
+}
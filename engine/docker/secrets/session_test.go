@@ -0,0 +1,52 @@
+package secrets
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server, client := net.Pipe()
+	var meta map[string][]string
+	dial := func(ctx context.Context, proto string, m map[string][]string) (net.Conn, error) {
+		assert.Equal(t, "h2c", proto)
+		meta = m
+		return server, nil
+	}
+	ID, err := Serve(ctx, dial, map[string][]byte{"token": []byte("secret")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ID}, meta[headerSessionID])
+	assert.Equal(t, []string{
+		"/grpc.health.v1.Health/Check",
+		"/grpc.health.v1.Health/Watch",
+		"/moby.buildkit.secrets.v1.Secrets/GetSecret",
+	}, meta[headerSessionMethod])
+
+	// act as daemon
+	conn, err := grpc.DialContext(ctx, "session", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return client, nil
+	}))
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+
+	secrets := NewSecretsClient(conn)
+	secret, err := secrets.GetSecret(ctx, &GetSecretRequest{ID: "token"})
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(secret.Data))
+	_, err = secrets.GetSecret(ctx, &GetSecretRequest{ID: "nothing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -3,6 +3,7 @@ package engine
 import (
 	"context"
 	"io"
+	"time"
 
 	enginetypes "github.com/projecteru2/core/engine/types"
 	coresource "github.com/projecteru2/core/source"
@@ -14,6 +15,7 @@ type API interface {
 
 	ExecCreate(ctx context.Context, target string, config *enginetypes.ExecConfig) (string, error)
 	ExecAttach(ctx context.Context, execID string, tty bool) (io.ReadCloser, io.WriteCloser, error)
+	ExecAttachOutput(ctx context.Context, execID string) (stdout, stderr io.ReadCloser, err error)
 	Execute(ctx context.Context, target string, config *enginetypes.ExecConfig) (string, io.ReadCloser, io.WriteCloser, error)
 	ExecResize(ctx context.Context, execID string, height, width uint) (err error)
 	ExecExitCode(ctx context.Context, execID string) (int, error)
@@ -25,13 +27,14 @@ type API interface {
 	ImageList(ctx context.Context, image string) ([]*enginetypes.Image, error)
 	ImageRemove(ctx context.Context, image string, force, prune bool) ([]string, error)
 	ImagesPrune(ctx context.Context) error
-	ImagePull(ctx context.Context, ref string, all bool) (io.ReadCloser, error)
+	ImagePull(ctx context.Context, ref string, all bool, auth *enginetypes.AuthConfig) (io.ReadCloser, error)
 	ImagePush(ctx context.Context, ref string) (io.ReadCloser, error)
-	ImageBuild(ctx context.Context, input io.Reader, refs []string) (io.ReadCloser, error)
+	ImageBuild(ctx context.Context, input io.Reader, refs []string, secrets map[string][]byte) (io.ReadCloser, error)
 	ImageBuildCachePrune(ctx context.Context, all bool) (uint64, error)
 	ImageLocalDigests(ctx context.Context, image string) ([]string, error)
 	ImageRemoteDigest(ctx context.Context, image string) (string, error)
 	ImageBuildFromExist(ctx context.Context, ID, name string) (string, error)
+	ImageCommit(ctx context.Context, ID string, refs []string, comment string) (string, error)
 
 	BuildRefs(ctx context.Context, name string, tags []string) []string
 	BuildContent(ctx context.Context, scm coresource.Source, opts *enginetypes.BuildContentOptions) (string, io.Reader, error)
@@ -39,15 +42,19 @@ type API interface {
 	VirtualizationCreate(ctx context.Context, opts *enginetypes.VirtualizationCreateOptions) (*enginetypes.VirtualizationCreated, error)
 	VirtualizationCopyTo(ctx context.Context, ID, target string, content io.Reader, AllowOverwriteDirWithFile, CopyUIDGID bool) error
 	VirtualizationStart(ctx context.Context, ID string) error
-	VirtualizationStop(ctx context.Context, ID string) error
+	VirtualizationStop(ctx context.Context, ID string, gracefulTimeout time.Duration) error
 	VirtualizationRemove(ctx context.Context, ID string, volumes, force bool) error
 	VirtualizationInspect(ctx context.Context, ID string) (*enginetypes.VirtualizationInfo, error)
+	VirtualizationStats(ctx context.Context, ID string) (*enginetypes.VirtualizationStats, error)
 	VirtualizationLogs(ctx context.Context, opts *enginetypes.VirtualizationLogStreamOptions) (io.ReadCloser, error)
 	VirtualizationAttach(ctx context.Context, ID string, stream, stdin bool) (io.ReadCloser, io.WriteCloser, error)
 	VirtualizationResize(ctx context.Context, ID string, height, width uint) error
 	VirtualizationWait(ctx context.Context, ID, state string) (*enginetypes.VirtualizationWaitResult, error)
 	VirtualizationUpdateResource(ctx context.Context, ID string, opts *enginetypes.VirtualizationResource) error
 	VirtualizationCopyFrom(ctx context.Context, ID, path string) (io.ReadCloser, string, error)
+	VirtualizationList(ctx context.Context, labels map[string]string) ([]*enginetypes.VirtualizationInfo, error)
+	VirtualizationCheckpoint(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) error
+	VirtualizationRestore(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) error
 
 	ResourceValidate(ctx context.Context, cpu float64, cpumap map[string]int64, memory, storage int64) error
 }
@@ -6,8 +6,11 @@ import (
 	"strings"
 
 	"github.com/projecteru2/core/engine"
+	"github.com/projecteru2/core/engine/containerd"
 	"github.com/projecteru2/core/engine/docker"
+	"github.com/projecteru2/core/engine/instrumented"
 	"github.com/projecteru2/core/engine/mocks/fakeengine"
+	"github.com/projecteru2/core/engine/podman"
 	"github.com/projecteru2/core/engine/systemd"
 	"github.com/projecteru2/core/engine/virt"
 	"github.com/projecteru2/core/types"
@@ -21,6 +24,8 @@ var engines = map[string]factory{
 	virt.HTTPPrefixKey:   virt.MakeClient,
 	virt.GRPCPrefixKey:   virt.MakeClient,
 	systemd.SSHPrefixKey: systemd.MakeClient,
+	podman.PrefixKey:     podman.MakeClient,
+	containerd.PrefixKey: containerd.MakeClient,
 	fakeengine.PrefixKey: fakeengine.MakeClient,
 }
 
@@ -34,7 +39,11 @@ func GetEngine(ctx context.Context, config types.Config, nodename, endpoint, ca,
 	if !ok {
 		return nil, types.ErrNotSupport
 	}
-	return e(ctx, config, nodename, endpoint, ca, cert, key)
+	api, err := e(ctx, config, nodename, endpoint, ca, cert, key)
+	if err != nil {
+		return nil, err
+	}
+	return instrumented.New(api, nodename), nil
 }
 
 func getEnginePrefix(endpoint string) (string, error) {
@@ -0,0 +1,298 @@
+package instrumented
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/projecteru2/core/engine"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	"github.com/projecteru2/core/metrics"
+	coresource "github.com/projecteru2/core/source"
+)
+
+// Engine wraps an engine, records latency and errors of each call on node
+// methods can not fail are not recorded
+type Engine struct {
+	engine.API
+	nodename string
+}
+
+// New wraps api of node
+func New(api engine.API, nodename string) engine.API {
+	return &Engine{API: api, nodename: nodename}
+}
+
+func (e *Engine) observe(method string, start time.Time, err error) error {
+	metrics.Client.SendEngineCall(e.nodename, method, time.Since(start), err)
+	return err
+}
+
+// Close closes wrapped engine if it can be closed
+func (e *Engine) Close() error {
+	if closer, ok := e.API.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Info .
+func (e *Engine) Info(ctx context.Context) (*enginetypes.Info, error) {
+	start := time.Now()
+	info, err := e.API.Info(ctx)
+	return info, e.observe("Info", start, err)
+}
+
+// ExecCreate .
+func (e *Engine) ExecCreate(ctx context.Context, target string, config *enginetypes.ExecConfig) (string, error) {
+	start := time.Now()
+	execID, err := e.API.ExecCreate(ctx, target, config)
+	return execID, e.observe("ExecCreate", start, err)
+}
+
+// ExecAttach .
+func (e *Engine) ExecAttach(ctx context.Context, execID string, tty bool) (io.ReadCloser, io.WriteCloser, error) {
+	start := time.Now()
+	reader, writer, err := e.API.ExecAttach(ctx, execID, tty)
+	return reader, writer, e.observe("ExecAttach", start, err)
+}
+
+// ExecAttachOutput .
+func (e *Engine) ExecAttachOutput(ctx context.Context, execID string) (io.ReadCloser, io.ReadCloser, error) {
+	start := time.Now()
+	stdout, stderr, err := e.API.ExecAttachOutput(ctx, execID)
+	return stdout, stderr, e.observe("ExecAttachOutput", start, err)
+}
+
+// Execute .
+func (e *Engine) Execute(ctx context.Context, target string, config *enginetypes.ExecConfig) (string, io.ReadCloser, io.WriteCloser, error) {
+	start := time.Now()
+	execID, reader, writer, err := e.API.Execute(ctx, target, config)
+	return execID, reader, writer, e.observe("Execute", start, err)
+}
+
+// ExecResize .
+func (e *Engine) ExecResize(ctx context.Context, execID string, height, width uint) error {
+	start := time.Now()
+	return e.observe("ExecResize", start, e.API.ExecResize(ctx, execID, height, width))
+}
+
+// ExecExitCode .
+func (e *Engine) ExecExitCode(ctx context.Context, execID string) (int, error) {
+	start := time.Now()
+	code, err := e.API.ExecExitCode(ctx, execID)
+	return code, e.observe("ExecExitCode", start, err)
+}
+
+// NetworkConnect .
+func (e *Engine) NetworkConnect(ctx context.Context, network, target, ipv4, ipv6 string) ([]string, error) {
+	start := time.Now()
+	networks, err := e.API.NetworkConnect(ctx, network, target, ipv4, ipv6)
+	return networks, e.observe("NetworkConnect", start, err)
+}
+
+// NetworkDisconnect .
+func (e *Engine) NetworkDisconnect(ctx context.Context, network, target string, force bool) error {
+	start := time.Now()
+	return e.observe("NetworkDisconnect", start, e.API.NetworkDisconnect(ctx, network, target, force))
+}
+
+// NetworkList .
+func (e *Engine) NetworkList(ctx context.Context, drivers []string) ([]*enginetypes.Network, error) {
+	start := time.Now()
+	networks, err := e.API.NetworkList(ctx, drivers)
+	return networks, e.observe("NetworkList", start, err)
+}
+
+// ImageList .
+func (e *Engine) ImageList(ctx context.Context, image string) ([]*enginetypes.Image, error) {
+	start := time.Now()
+	images, err := e.API.ImageList(ctx, image)
+	return images, e.observe("ImageList", start, err)
+}
+
+// ImageRemove .
+func (e *Engine) ImageRemove(ctx context.Context, image string, force, prune bool) ([]string, error) {
+	start := time.Now()
+	removed, err := e.API.ImageRemove(ctx, image, force, prune)
+	return removed, e.observe("ImageRemove", start, err)
+}
+
+// ImagesPrune .
+func (e *Engine) ImagesPrune(ctx context.Context) error {
+	start := time.Now()
+	return e.observe("ImagesPrune", start, e.API.ImagesPrune(ctx))
+}
+
+// ImagePull .
+func (e *Engine) ImagePull(ctx context.Context, ref string, all bool, auth *enginetypes.AuthConfig) (io.ReadCloser, error) {
+	start := time.Now()
+	reader, err := e.API.ImagePull(ctx, ref, all, auth)
+	return reader, e.observe("ImagePull", start, err)
+}
+
+// ImagePush .
+func (e *Engine) ImagePush(ctx context.Context, ref string) (io.ReadCloser, error) {
+	start := time.Now()
+	reader, err := e.API.ImagePush(ctx, ref)
+	return reader, e.observe("ImagePush", start, err)
+}
+
+// ImageBuild .
+func (e *Engine) ImageBuild(ctx context.Context, input io.Reader, refs []string, secrets map[string][]byte) (io.ReadCloser, error) {
+	start := time.Now()
+	reader, err := e.API.ImageBuild(ctx, input, refs, secrets)
+	return reader, e.observe("ImageBuild", start, err)
+}
+
+// ImageBuildCachePrune .
+func (e *Engine) ImageBuildCachePrune(ctx context.Context, all bool) (uint64, error) {
+	start := time.Now()
+	reclaimed, err := e.API.ImageBuildCachePrune(ctx, all)
+	return reclaimed, e.observe("ImageBuildCachePrune", start, err)
+}
+
+// ImageLocalDigests .
+func (e *Engine) ImageLocalDigests(ctx context.Context, image string) ([]string, error) {
+	start := time.Now()
+	digests, err := e.API.ImageLocalDigests(ctx, image)
+	return digests, e.observe("ImageLocalDigests", start, err)
+}
+
+// ImageRemoteDigest .
+func (e *Engine) ImageRemoteDigest(ctx context.Context, image string) (string, error) {
+	start := time.Now()
+	digest, err := e.API.ImageRemoteDigest(ctx, image)
+	return digest, e.observe("ImageRemoteDigest", start, err)
+}
+
+// ImageBuildFromExist .
+func (e *Engine) ImageBuildFromExist(ctx context.Context, ID, name string) (string, error) {
+	start := time.Now()
+	imageID, err := e.API.ImageBuildFromExist(ctx, ID, name)
+	return imageID, e.observe("ImageBuildFromExist", start, err)
+}
+
+// ImageCommit .
+func (e *Engine) ImageCommit(ctx context.Context, ID string, refs []string, comment string) (string, error) {
+	start := time.Now()
+	imageID, err := e.API.ImageCommit(ctx, ID, refs, comment)
+	return imageID, e.observe("ImageCommit", start, err)
+}
+
+// BuildContent .
+func (e *Engine) BuildContent(ctx context.Context, scm coresource.Source, opts *enginetypes.BuildContentOptions) (string, io.Reader, error) {
+	start := time.Now()
+	dir, reader, err := e.API.BuildContent(ctx, scm, opts)
+	return dir, reader, e.observe("BuildContent", start, err)
+}
+
+// VirtualizationCreate .
+func (e *Engine) VirtualizationCreate(ctx context.Context, opts *enginetypes.VirtualizationCreateOptions) (*enginetypes.VirtualizationCreated, error) {
+	start := time.Now()
+	created, err := e.API.VirtualizationCreate(ctx, opts)
+	return created, e.observe("VirtualizationCreate", start, err)
+}
+
+// VirtualizationCopyTo .
+func (e *Engine) VirtualizationCopyTo(ctx context.Context, ID, target string, content io.Reader, AllowOverwriteDirWithFile, CopyUIDGID bool) error {
+	start := time.Now()
+	return e.observe("VirtualizationCopyTo", start, e.API.VirtualizationCopyTo(ctx, ID, target, content, AllowOverwriteDirWithFile, CopyUIDGID))
+}
+
+// VirtualizationStart .
+func (e *Engine) VirtualizationStart(ctx context.Context, ID string) error {
+	start := time.Now()
+	return e.observe("VirtualizationStart", start, e.API.VirtualizationStart(ctx, ID))
+}
+
+// VirtualizationStop .
+func (e *Engine) VirtualizationStop(ctx context.Context, ID string, gracefulTimeout time.Duration) error {
+	start := time.Now()
+	return e.observe("VirtualizationStop", start, e.API.VirtualizationStop(ctx, ID, gracefulTimeout))
+}
+
+// VirtualizationRemove .
+func (e *Engine) VirtualizationRemove(ctx context.Context, ID string, volumes, force bool) error {
+	start := time.Now()
+	return e.observe("VirtualizationRemove", start, e.API.VirtualizationRemove(ctx, ID, volumes, force))
+}
+
+// VirtualizationInspect .
+func (e *Engine) VirtualizationInspect(ctx context.Context, ID string) (*enginetypes.VirtualizationInfo, error) {
+	start := time.Now()
+	info, err := e.API.VirtualizationInspect(ctx, ID)
+	return info, e.observe("VirtualizationInspect", start, err)
+}
+
+// VirtualizationStats .
+func (e *Engine) VirtualizationStats(ctx context.Context, ID string) (*enginetypes.VirtualizationStats, error) {
+	start := time.Now()
+	stats, err := e.API.VirtualizationStats(ctx, ID)
+	return stats, e.observe("VirtualizationStats", start, err)
+}
+
+// VirtualizationLogs .
+func (e *Engine) VirtualizationLogs(ctx context.Context, opts *enginetypes.VirtualizationLogStreamOptions) (io.ReadCloser, error) {
+	start := time.Now()
+	reader, err := e.API.VirtualizationLogs(ctx, opts)
+	return reader, e.observe("VirtualizationLogs", start, err)
+}
+
+// VirtualizationAttach .
+func (e *Engine) VirtualizationAttach(ctx context.Context, ID string, stream, stdin bool) (io.ReadCloser, io.WriteCloser, error) {
+	start := time.Now()
+	reader, writer, err := e.API.VirtualizationAttach(ctx, ID, stream, stdin)
+	return reader, writer, e.observe("VirtualizationAttach", start, err)
+}
+
+// VirtualizationResize .
+func (e *Engine) VirtualizationResize(ctx context.Context, ID string, height, width uint) error {
+	start := time.Now()
+	return e.observe("VirtualizationResize", start, e.API.VirtualizationResize(ctx, ID, height, width))
+}
+
+// VirtualizationWait .
+func (e *Engine) VirtualizationWait(ctx context.Context, ID, state string) (*enginetypes.VirtualizationWaitResult, error) {
+	start := time.Now()
+	result, err := e.API.VirtualizationWait(ctx, ID, state)
+	return result, e.observe("VirtualizationWait", start, err)
+}
+
+// VirtualizationUpdateResource .
+func (e *Engine) VirtualizationUpdateResource(ctx context.Context, ID string, opts *enginetypes.VirtualizationResource) error {
+	start := time.Now()
+	return e.observe("VirtualizationUpdateResource", start, e.API.VirtualizationUpdateResource(ctx, ID, opts))
+}
+
+// VirtualizationCopyFrom .
+func (e *Engine) VirtualizationCopyFrom(ctx context.Context, ID, path string) (io.ReadCloser, string, error) {
+	start := time.Now()
+	reader, filename, err := e.API.VirtualizationCopyFrom(ctx, ID, path)
+	return reader, filename, e.observe("VirtualizationCopyFrom", start, err)
+}
+
+// VirtualizationList .
+func (e *Engine) VirtualizationList(ctx context.Context, labels map[string]string) ([]*enginetypes.VirtualizationInfo, error) {
+	start := time.Now()
+	infos, err := e.API.VirtualizationList(ctx, labels)
+	return infos, e.observe("VirtualizationList", start, err)
+}
+
+// VirtualizationCheckpoint .
+func (e *Engine) VirtualizationCheckpoint(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) error {
+	start := time.Now()
+	return e.observe("VirtualizationCheckpoint", start, e.API.VirtualizationCheckpoint(ctx, ID, opts))
+}
+
+// VirtualizationRestore .
+func (e *Engine) VirtualizationRestore(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) error {
+	start := time.Now()
+	return e.observe("VirtualizationRestore", start, e.API.VirtualizationRestore(ctx, ID, opts))
+}
+
+// ResourceValidate .
+func (e *Engine) ResourceValidate(ctx context.Context, cpu float64, cpumap map[string]int64, memory, storage int64) error {
+	start := time.Now()
+	return e.observe("ResourceValidate", start, e.API.ResourceValidate(ctx, cpu, cpumap, memory, storage))
+}
@@ -0,0 +1,27 @@
+package instrumented
+
+import (
+	"context"
+	"testing"
+
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEngine(t *testing.T) {
+	ctx := context.Background()
+	api := &enginemocks.API{}
+	api.On("Info", mock.Anything).Return(&enginetypes.Info{ID: "id"}, nil)
+	api.On("VirtualizationStart", mock.Anything, mock.Anything).Return(types.ErrNilEngine)
+	api.On("BuildRefs", mock.Anything, mock.Anything, mock.Anything).Return([]string{"ref"})
+	e := New(api, "n1")
+
+	info, err := e.Info(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "id", info.ID)
+	assert.Equal(t, types.ErrNilEngine, e.VirtualizationStart(ctx, "id"))
+	assert.Equal(t, []string{"ref"}, e.BuildRefs(ctx, "name", nil))
+}
@@ -0,0 +1,401 @@
+package lazy
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/projecteru2/core/engine"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	coresource "github.com/projecteru2/core/source"
+	log "github.com/sirupsen/logrus"
+)
+
+// Engine builds the wrapped engine on first call, so listing nodes won't dial every daemon
+// a failed build is not kept, the next call will try again
+type Engine struct {
+	sync.Mutex
+	api   engine.API
+	build func(context.Context) (engine.API, error)
+}
+
+// New wraps build of an engine
+func New(build func(context.Context) (engine.API, error)) engine.API {
+	return &Engine{build: build}
+}
+
+func (e *Engine) get(ctx context.Context) (engine.API, error) {
+	e.Lock()
+	defer e.Unlock()
+	if e.api != nil {
+		return e.api, nil
+	}
+	api, err := e.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e.api = api
+	return api, nil
+}
+
+// Info .
+func (e *Engine) Info(ctx context.Context) (*enginetypes.Info, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.Info(ctx)
+}
+
+// ExecCreate .
+func (e *Engine) ExecCreate(ctx context.Context, target string, config *enginetypes.ExecConfig) (string, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return "", err
+	}
+	return api.ExecCreate(ctx, target, config)
+}
+
+// ExecAttach .
+func (e *Engine) ExecAttach(ctx context.Context, execID string, tty bool) (io.ReadCloser, io.WriteCloser, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return api.ExecAttach(ctx, execID, tty)
+}
+
+// ExecAttachOutput .
+func (e *Engine) ExecAttachOutput(ctx context.Context, execID string) (stdout, stderr io.ReadCloser, err error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return api.ExecAttachOutput(ctx, execID)
+}
+
+// Execute .
+func (e *Engine) Execute(ctx context.Context, target string, config *enginetypes.ExecConfig) (string, io.ReadCloser, io.WriteCloser, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return api.Execute(ctx, target, config)
+}
+
+// ExecResize .
+func (e *Engine) ExecResize(ctx context.Context, execID string, height, width uint) (err error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return err
+	}
+	return api.ExecResize(ctx, execID, height, width)
+}
+
+// ExecExitCode .
+func (e *Engine) ExecExitCode(ctx context.Context, execID string) (int, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return api.ExecExitCode(ctx, execID)
+}
+
+// NetworkConnect .
+func (e *Engine) NetworkConnect(ctx context.Context, network, target, ipv4, ipv6 string) ([]string, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.NetworkConnect(ctx, network, target, ipv4, ipv6)
+}
+
+// NetworkDisconnect .
+func (e *Engine) NetworkDisconnect(ctx context.Context, network, target string, force bool) error {
+	api, err := e.get(ctx)
+	if err != nil {
+		return err
+	}
+	return api.NetworkDisconnect(ctx, network, target, force)
+}
+
+// NetworkList .
+func (e *Engine) NetworkList(ctx context.Context, drivers []string) ([]*enginetypes.Network, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.NetworkList(ctx, drivers)
+}
+
+// ImageList .
+func (e *Engine) ImageList(ctx context.Context, image string) ([]*enginetypes.Image, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.ImageList(ctx, image)
+}
+
+// ImageRemove .
+func (e *Engine) ImageRemove(ctx context.Context, image string, force, prune bool) ([]string, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.ImageRemove(ctx, image, force, prune)
+}
+
+// ImagesPrune .
+func (e *Engine) ImagesPrune(ctx context.Context) error {
+	api, err := e.get(ctx)
+	if err != nil {
+		return err
+	}
+	return api.ImagesPrune(ctx)
+}
+
+// ImagePull .
+func (e *Engine) ImagePull(ctx context.Context, ref string, all bool, auth *enginetypes.AuthConfig) (io.ReadCloser, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.ImagePull(ctx, ref, all, auth)
+}
+
+// ImagePush .
+func (e *Engine) ImagePush(ctx context.Context, ref string) (io.ReadCloser, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.ImagePush(ctx, ref)
+}
+
+// ImageBuild .
+func (e *Engine) ImageBuild(ctx context.Context, input io.Reader, refs []string, secrets map[string][]byte) (io.ReadCloser, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.ImageBuild(ctx, input, refs, secrets)
+}
+
+// ImageBuildCachePrune .
+func (e *Engine) ImageBuildCachePrune(ctx context.Context, all bool) (uint64, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return api.ImageBuildCachePrune(ctx, all)
+}
+
+// ImageLocalDigests .
+func (e *Engine) ImageLocalDigests(ctx context.Context, image string) ([]string, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.ImageLocalDigests(ctx, image)
+}
+
+// ImageRemoteDigest .
+func (e *Engine) ImageRemoteDigest(ctx context.Context, image string) (string, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return "", err
+	}
+	return api.ImageRemoteDigest(ctx, image)
+}
+
+// ImageBuildFromExist .
+func (e *Engine) ImageBuildFromExist(ctx context.Context, ID, name string) (string, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return "", err
+	}
+	return api.ImageBuildFromExist(ctx, ID, name)
+}
+
+// ImageCommit .
+func (e *Engine) ImageCommit(ctx context.Context, ID string, refs []string, comment string) (string, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return "", err
+	}
+	return api.ImageCommit(ctx, ID, refs, comment)
+}
+
+// BuildRefs .
+func (e *Engine) BuildRefs(ctx context.Context, name string, tags []string) []string {
+	api, err := e.get(ctx)
+	if err != nil {
+		log.Errorf("[BuildRefs] Get engine failed %v", err)
+		return nil
+	}
+	return api.BuildRefs(ctx, name, tags)
+}
+
+// BuildContent .
+func (e *Engine) BuildContent(ctx context.Context, scm coresource.Source, opts *enginetypes.BuildContentOptions) (string, io.Reader, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	return api.BuildContent(ctx, scm, opts)
+}
+
+// VirtualizationCreate .
+func (e *Engine) VirtualizationCreate(ctx context.Context, opts *enginetypes.VirtualizationCreateOptions) (*enginetypes.VirtualizationCreated, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.VirtualizationCreate(ctx, opts)
+}
+
+// VirtualizationCopyTo .
+func (e *Engine) VirtualizationCopyTo(ctx context.Context, ID, target string, content io.Reader, AllowOverwriteDirWithFile, CopyUIDGID bool) error {
+	api, err := e.get(ctx)
+	if err != nil {
+		return err
+	}
+	return api.VirtualizationCopyTo(ctx, ID, target, content, AllowOverwriteDirWithFile, CopyUIDGID)
+}
+
+// VirtualizationStart .
+func (e *Engine) VirtualizationStart(ctx context.Context, ID string) error {
+	api, err := e.get(ctx)
+	if err != nil {
+		return err
+	}
+	return api.VirtualizationStart(ctx, ID)
+}
+
+// VirtualizationStop .
+func (e *Engine) VirtualizationStop(ctx context.Context, ID string, gracefulTimeout time.Duration) error {
+	api, err := e.get(ctx)
+	if err != nil {
+		return err
+	}
+	return api.VirtualizationStop(ctx, ID, gracefulTimeout)
+}
+
+// VirtualizationRemove .
+func (e *Engine) VirtualizationRemove(ctx context.Context, ID string, volumes, force bool) error {
+	api, err := e.get(ctx)
+	if err != nil {
+		return err
+	}
+	return api.VirtualizationRemove(ctx, ID, volumes, force)
+}
+
+// VirtualizationInspect .
+func (e *Engine) VirtualizationInspect(ctx context.Context, ID string) (*enginetypes.VirtualizationInfo, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.VirtualizationInspect(ctx, ID)
+}
+
+// VirtualizationStats .
+func (e *Engine) VirtualizationStats(ctx context.Context, ID string) (*enginetypes.VirtualizationStats, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.VirtualizationStats(ctx, ID)
+}
+
+// VirtualizationLogs .
+func (e *Engine) VirtualizationLogs(ctx context.Context, opts *enginetypes.VirtualizationLogStreamOptions) (io.ReadCloser, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.VirtualizationLogs(ctx, opts)
+}
+
+// VirtualizationAttach .
+func (e *Engine) VirtualizationAttach(ctx context.Context, ID string, stream, stdin bool) (io.ReadCloser, io.WriteCloser, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return api.VirtualizationAttach(ctx, ID, stream, stdin)
+}
+
+// VirtualizationResize .
+func (e *Engine) VirtualizationResize(ctx context.Context, ID string, height, width uint) error {
+	api, err := e.get(ctx)
+	if err != nil {
+		return err
+	}
+	return api.VirtualizationResize(ctx, ID, height, width)
+}
+
+// VirtualizationWait .
+func (e *Engine) VirtualizationWait(ctx context.Context, ID, state string) (*enginetypes.VirtualizationWaitResult, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.VirtualizationWait(ctx, ID, state)
+}
+
+// VirtualizationUpdateResource .
+func (e *Engine) VirtualizationUpdateResource(ctx context.Context, ID string, opts *enginetypes.VirtualizationResource) error {
+	api, err := e.get(ctx)
+	if err != nil {
+		return err
+	}
+	return api.VirtualizationUpdateResource(ctx, ID, opts)
+}
+
+// VirtualizationCopyFrom .
+func (e *Engine) VirtualizationCopyFrom(ctx context.Context, ID, path string) (io.ReadCloser, string, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return api.VirtualizationCopyFrom(ctx, ID, path)
+}
+
+// VirtualizationList .
+func (e *Engine) VirtualizationList(ctx context.Context, labels map[string]string) ([]*enginetypes.VirtualizationInfo, error) {
+	api, err := e.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.VirtualizationList(ctx, labels)
+}
+
+// VirtualizationCheckpoint .
+func (e *Engine) VirtualizationCheckpoint(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) error {
+	api, err := e.get(ctx)
+	if err != nil {
+		return err
+	}
+	return api.VirtualizationCheckpoint(ctx, ID, opts)
+}
+
+// VirtualizationRestore .
+func (e *Engine) VirtualizationRestore(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) error {
+	api, err := e.get(ctx)
+	if err != nil {
+		return err
+	}
+	return api.VirtualizationRestore(ctx, ID, opts)
+}
+
+// ResourceValidate .
+func (e *Engine) ResourceValidate(ctx context.Context, cpu float64, cpumap map[string]int64, memory, storage int64) error {
+	api, err := e.get(ctx)
+	if err != nil {
+		return err
+	}
+	return api.ResourceValidate(ctx, cpu, cpumap, memory, storage)
+}
@@ -0,0 +1,44 @@
+package lazy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/projecteru2/core/engine"
+	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEngine(t *testing.T) {
+	ctx := context.Background()
+	api := &enginemocks.API{}
+	api.On("Info", mock.Anything).Return(&enginetypes.Info{ID: "id"}, nil)
+	api.On("BuildRefs", mock.Anything, mock.Anything, mock.Anything).Return([]string{"ref"})
+	built := 0
+	var buildErr error = types.ErrCannotGetEngine
+	e := New(func(context.Context) (engine.API, error) {
+		built++
+		if buildErr != nil {
+			return nil, buildErr
+		}
+		return api, nil
+	})
+	assert.Equal(t, 0, built)
+
+	// failed by build
+	_, err := e.Info(ctx)
+	assert.Equal(t, types.ErrCannotGetEngine, err)
+	assert.Nil(t, e.BuildRefs(ctx, "name", nil))
+	assert.Equal(t, 2, built)
+
+	// built once
+	buildErr = nil
+	info, err := e.Info(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "id", info.ID)
+	assert.Equal(t, []string{"ref"}, e.BuildRefs(ctx, "name", nil))
+	assert.Equal(t, 3, built)
+}
@@ -7,6 +7,7 @@ import context "context"
 import io "io"
 import mock "github.com/stretchr/testify/mock"
 import source "github.com/projecteru2/core/source"
+import time "time"
 import types "github.com/projecteru2/core/engine/types"
 
 // API is an autogenerated mock type for the API type
@@ -92,6 +93,38 @@ func (_m *API) ExecAttach(ctx context.Context, execID string, tty bool) (io.Read
 	return r0, r1, r2
 }
 
+// ExecAttachOutput provides a mock function with given fields: ctx, execID
+func (_m *API) ExecAttachOutput(ctx context.Context, execID string) (io.ReadCloser, io.ReadCloser, error) {
+	ret := _m.Called(ctx, execID)
+
+	var r0 io.ReadCloser
+	if rf, ok := ret.Get(0).(func(context.Context, string) io.ReadCloser); ok {
+		r0 = rf(ctx, execID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	var r1 io.ReadCloser
+	if rf, ok := ret.Get(1).(func(context.Context, string) io.ReadCloser); ok {
+		r1 = rf(ctx, execID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(io.ReadCloser)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, execID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // ExecCreate provides a mock function with given fields: ctx, target, config
 func (_m *API) ExecCreate(ctx context.Context, target string, config *types.ExecConfig) (string, error) {
 	ret := _m.Called(ctx, target, config)
@@ -187,13 +220,13 @@ func (_m *API) Execute(ctx context.Context, target string, config *types.ExecCon
 	return r0, r1, r2, r3
 }
 
-// ImageBuild provides a mock function with given fields: ctx, input, refs
-func (_m *API) ImageBuild(ctx context.Context, input io.Reader, refs []string) (io.ReadCloser, error) {
-	ret := _m.Called(ctx, input, refs)
+// ImageBuild provides a mock function with given fields: ctx, input, refs, secrets
+func (_m *API) ImageBuild(ctx context.Context, input io.Reader, refs []string, secrets map[string][]byte) (io.ReadCloser, error) {
+	ret := _m.Called(ctx, input, refs, secrets)
 
 	var r0 io.ReadCloser
-	if rf, ok := ret.Get(0).(func(context.Context, io.Reader, []string) io.ReadCloser); ok {
-		r0 = rf(ctx, input, refs)
+	if rf, ok := ret.Get(0).(func(context.Context, io.Reader, []string, map[string][]byte) io.ReadCloser); ok {
+		r0 = rf(ctx, input, refs, secrets)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(io.ReadCloser)
@@ -201,8 +234,8 @@ func (_m *API) ImageBuild(ctx context.Context, input io.Reader, refs []string) (
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, io.Reader, []string) error); ok {
-		r1 = rf(ctx, input, refs)
+	if rf, ok := ret.Get(1).(func(context.Context, io.Reader, []string, map[string][]byte) error); ok {
+		r1 = rf(ctx, input, refs, secrets)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -252,6 +285,27 @@ func (_m *API) ImageBuildFromExist(ctx context.Context, ID string, name string)
 	return r0, r1
 }
 
+// ImageCommit provides a mock function with given fields: ctx, ID, refs, comment
+func (_m *API) ImageCommit(ctx context.Context, ID string, refs []string, comment string) (string, error) {
+	ret := _m.Called(ctx, ID, refs, comment)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, string) string); ok {
+		r0 = rf(ctx, ID, refs, comment)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string, string) error); ok {
+		r1 = rf(ctx, ID, refs, comment)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ImageList provides a mock function with given fields: ctx, image
 func (_m *API) ImageList(ctx context.Context, image string) ([]*types.Image, error) {
 	ret := _m.Called(ctx, image)
@@ -298,13 +352,13 @@ func (_m *API) ImageLocalDigests(ctx context.Context, image string) ([]string, e
 	return r0, r1
 }
 
-// ImagePull provides a mock function with given fields: ctx, ref, all
-func (_m *API) ImagePull(ctx context.Context, ref string, all bool) (io.ReadCloser, error) {
-	ret := _m.Called(ctx, ref, all)
+// ImagePull provides a mock function with given fields: ctx, ref, all, auth
+func (_m *API) ImagePull(ctx context.Context, ref string, all bool, auth *types.AuthConfig) (io.ReadCloser, error) {
+	ret := _m.Called(ctx, ref, all, auth)
 
 	var r0 io.ReadCloser
-	if rf, ok := ret.Get(0).(func(context.Context, string, bool) io.ReadCloser); ok {
-		r0 = rf(ctx, ref, all)
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool, *types.AuthConfig) io.ReadCloser); ok {
+		r0 = rf(ctx, ref, all, auth)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(io.ReadCloser)
@@ -312,8 +366,8 @@ func (_m *API) ImagePull(ctx context.Context, ref string, all bool) (io.ReadClos
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
-		r1 = rf(ctx, ref, all)
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool, *types.AuthConfig) error); ok {
+		r1 = rf(ctx, ref, all, auth)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -531,6 +585,20 @@ func (_m *API) VirtualizationAttach(ctx context.Context, ID string, stream bool,
 	return r0, r1, r2
 }
 
+// VirtualizationCheckpoint provides a mock function with given fields: ctx, ID, opts
+func (_m *API) VirtualizationCheckpoint(ctx context.Context, ID string, opts *types.CheckpointOptions) error {
+	ret := _m.Called(ctx, ID, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *types.CheckpointOptions) error); ok {
+		r0 = rf(ctx, ID, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // VirtualizationCopyFrom provides a mock function with given fields: ctx, ID, path
 func (_m *API) VirtualizationCopyFrom(ctx context.Context, ID string, path string) (io.ReadCloser, string, error) {
 	ret := _m.Called(ctx, ID, path)
@@ -621,6 +689,29 @@ func (_m *API) VirtualizationInspect(ctx context.Context, ID string) (*types.Vir
 	return r0, r1
 }
 
+// VirtualizationList provides a mock function with given fields: ctx, labels
+func (_m *API) VirtualizationList(ctx context.Context, labels map[string]string) ([]*types.VirtualizationInfo, error) {
+	ret := _m.Called(ctx, labels)
+
+	var r0 []*types.VirtualizationInfo
+	if rf, ok := ret.Get(0).(func(context.Context, map[string]string) []*types.VirtualizationInfo); ok {
+		r0 = rf(ctx, labels)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.VirtualizationInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, map[string]string) error); ok {
+		r1 = rf(ctx, labels)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // VirtualizationLogs provides a mock function with given fields: ctx, opts
 func (_m *API) VirtualizationLogs(ctx context.Context, opts *types.VirtualizationLogStreamOptions) (io.ReadCloser, error) {
 	ret := _m.Called(ctx, opts)
@@ -672,6 +763,20 @@ func (_m *API) VirtualizationResize(ctx context.Context, ID string, height uint,
 	return r0
 }
 
+// VirtualizationRestore provides a mock function with given fields: ctx, ID, opts
+func (_m *API) VirtualizationRestore(ctx context.Context, ID string, opts *types.CheckpointOptions) error {
+	ret := _m.Called(ctx, ID, opts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *types.CheckpointOptions) error); ok {
+		r0 = rf(ctx, ID, opts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // VirtualizationStart provides a mock function with given fields: ctx, ID
 func (_m *API) VirtualizationStart(ctx context.Context, ID string) error {
 	ret := _m.Called(ctx, ID)
@@ -686,13 +791,36 @@ func (_m *API) VirtualizationStart(ctx context.Context, ID string) error {
 	return r0
 }
 
-// VirtualizationStop provides a mock function with given fields: ctx, ID
-func (_m *API) VirtualizationStop(ctx context.Context, ID string) error {
+// VirtualizationStats provides a mock function with given fields: ctx, ID
+func (_m *API) VirtualizationStats(ctx context.Context, ID string) (*types.VirtualizationStats, error) {
 	ret := _m.Called(ctx, ID)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+	var r0 *types.VirtualizationStats
+	if rf, ok := ret.Get(0).(func(context.Context, string) *types.VirtualizationStats); ok {
 		r0 = rf(ctx, ID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.VirtualizationStats)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, ID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VirtualizationStop provides a mock function with given fields: ctx, ID, gracefulTimeout
+func (_m *API) VirtualizationStop(ctx context.Context, ID string, gracefulTimeout time.Duration) error {
+	ret := _m.Called(ctx, ID, gracefulTimeout)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) error); ok {
+		r0 = rf(ctx, ID, gracefulTimeout)
 	} else {
 		r0 = ret.Error(0)
 	}
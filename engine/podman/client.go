@@ -0,0 +1,232 @@
+package podman
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// client speaks libpod REST API over unix socket or tcp
+type client struct {
+	network string // unix or tcp
+	address string
+	version string
+	tls     *tls.Config
+	http    *http.Client
+}
+
+// apiError is error body of libpod API
+type apiError struct {
+	Cause    string `json:"cause"`
+	Message  string `json:"message"`
+	Response int    `json:"response"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("podman API error %d: %s", e.Response, e.Message)
+}
+
+func newClient(address, version, ca, cert, key string) (*client, error) {
+	c := &client{network: "tcp", address: address, version: version}
+	if strings.HasPrefix(address, "/") {
+		c.network = "unix"
+	} else if ca != "" && cert != "" && key != "" {
+		tlsc, err := makeTLSConfig(ca, cert, key)
+		if err != nil {
+			return nil, err
+		}
+		c.tls = tlsc
+	}
+	c.http = &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return c.dial(ctx)
+	}}}
+	return c, nil
+}
+
+func makeTLSConfig(ca, cert, key string) (*tls.Config, error) {
+	pair, err := tls.X509KeyPair([]byte(cert), []byte(key))
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(ca)) {
+		return nil, fmt.Errorf("bad ca of podman endpoint")
+	}
+	// same as docker engine, ca is trusted but hostname is not verified
+	return &tls.Config{Certificates: []tls.Certificate{pair}, RootCAs: pool, InsecureSkipVerify: true}, nil // nolint
+}
+
+func (c *client) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil || c.tls == nil {
+		return conn, err
+	}
+	tlsConn := tls.Client(conn, c.tls)
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// host is IP of the node, empty for unix socket
+func (c *client) host() string {
+	if c.network != "tcp" {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(c.address)
+	if err != nil {
+		return c.address
+	}
+	return host
+}
+
+func (c *client) newRequest(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Request, error) {
+	u := fmt.Sprintf("http://d/%s/libpod%s", c.version, path)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return http.NewRequestWithContext(ctx, method, u, body)
+}
+
+// do sends request, body is encoded to json unless it's a reader,
+// response body must be closed by caller when err is nil
+func (c *client) do(ctx context.Context, method, path string, query url.Values, body interface{}, header http.Header) (*http.Response, error) {
+	reader, contentType, err := encodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, method, path, query, reader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+	return resp, nil
+}
+
+// call sends request and decodes json response into result if it's not nil
+func (c *client) call(ctx context.Context, method, path string, query url.Values, body, result interface{}) error {
+	resp, err := c.do(ctx, method, path, query, body, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if result == nil {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// hijack takes over the connection after response header for attach and exec,
+// stdin goes to the connection and output comes from it
+func (c *client) hijack(ctx context.Context, path string, query url.Values, body interface{}) (*hijackedStream, error) {
+	reader, contentType, err := encodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, path, query, reader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer conn.Close()
+		return nil, decodeError(resp)
+	}
+	return &hijackedStream{conn: conn, reader: br}, nil
+}
+
+func encodeBody(body interface{}) (io.Reader, string, error) {
+	switch b := body.(type) {
+	case nil:
+		return nil, "", nil
+	case io.Reader:
+		return b, "application/x-tar", nil
+	default:
+		buf, err := json.Marshal(b)
+		if err != nil {
+			return nil, "", err
+		}
+		return bytes.NewReader(buf), "application/json", nil
+	}
+}
+
+func decodeError(resp *http.Response) error {
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	e := &apiError{}
+	if err := json.Unmarshal(b, e); err != nil || e.Message == "" {
+		return &apiError{Message: strings.TrimSpace(string(b)), Response: resp.StatusCode}
+	}
+	if e.Response == 0 {
+		e.Response = resp.StatusCode
+	}
+	return e
+}
+
+// hijackedStream is a connection taken over from http, buffered output read with response header comes first
+type hijackedStream struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// Read .
+func (s *hijackedStream) Read(p []byte) (int, error) {
+	return s.reader.Read(p)
+}
+
+// Write .
+func (s *hijackedStream) Write(p []byte) (int, error) {
+	return s.conn.Write(p)
+}
+
+// Close .
+func (s *hijackedStream) Close() error {
+	return s.conn.Close()
+}
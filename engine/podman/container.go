@@ -0,0 +1,504 @@
+package podman
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pkg/errors"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	coretypes "github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	minMemory     = units.MiB * 4
+	restartAlways = "always"
+	root          = "root"
+	hostNetwork   = "host"
+	bridgeNetwork = "bridge"
+	noneNetwork   = "none"
+)
+
+type rawArgs struct {
+	PidMode    string            `json:"pid_mod"`
+	StorageOpt map[string]string `json:"storage_opt"`
+	CapAdd     []string          `json:"cap_add"`
+	CapDrop    []string          `json:"cap_drop"`
+}
+
+// inspect is the subset of libpod container inspect used by core
+type inspect struct {
+	ID      string    `json:"Id"`
+	Name    string    `json:"Name"`
+	Created time.Time `json:"Created"`
+	Config  struct {
+		User       string            `json:"User"`
+		Image      string            `json:"Image"`
+		Cmd        []string          `json:"Cmd"`
+		WorkingDir string            `json:"WorkingDir"`
+		Env        []string          `json:"Env"`
+		Labels     map[string]string `json:"Labels"`
+	} `json:"Config"`
+	State struct {
+		Running bool `json:"Running"`
+	} `json:"State"`
+	HostConfig struct {
+		NetworkMode string `json:"NetworkMode"`
+		NanoCpus    int64  `json:"NanoCpus"`
+		CPUQuota    int64  `json:"CpuQuota"`
+		CPUPeriod   int64  `json:"CpuPeriod"`
+		Memory      int64  `json:"Memory"`
+	} `json:"HostConfig"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			IPAddress string `json:"IPAddress"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// VirtualizationCreate create a container
+func (e *Engine) VirtualizationCreate(ctx context.Context, opts *enginetypes.VirtualizationCreateOptions) (*enginetypes.VirtualizationCreated, error) {
+	r := &enginetypes.VirtualizationCreated{}
+	// memory should more than 4MiB
+	if opts.Memory > 0 && opts.Memory < minMemory || opts.Memory < 0 {
+		return r, coretypes.ErrBadMemory
+	}
+	if opts.RestartPolicy.Backoff > 0 {
+		return r, coretypes.NewDetailedErr(coretypes.ErrInvalidRestartPolicy, "podman engine doesn't support backoff")
+	}
+	if opts.RestartPolicy.Window > 0 {
+		return r, coretypes.NewDetailedErr(coretypes.ErrInvalidRestartPolicy, "podman engine doesn't support window")
+	}
+	rArgs := &rawArgs{StorageOpt: map[string]string{}}
+	if len(opts.RawArgs) > 0 {
+		if err := json.Unmarshal(opts.RawArgs, rArgs); err != nil {
+			return r, err
+		}
+	}
+
+	// gpu devices are exposed by nvidia hook, same as runtime of docker
+	if len(opts.GPU) > 0 {
+		opts.Env = append(opts.Env, fmt.Sprintf("NVIDIA_VISIBLE_DEVICES=%s", strings.Join(coretypes.GPUDevices(opts.GPU), ",")))
+	}
+	hostIP := e.client.host()
+	opts.Env = append(opts.Env, fmt.Sprintf("ERU_NODE_IP=%s", hostIP))
+	env := makeEnv(opts.Env)
+
+	s := &spec{
+		Name:          opts.Name,
+		Image:         opts.Image,
+		Command:       opts.Cmd,
+		Env:           env,
+		Labels:        opts.Labels,
+		WorkDir:       opts.WorkingDir,
+		User:          opts.User,
+		Stdin:         opts.Stdin,
+		Terminal:      opts.Stdin,
+		Privileged:    opts.Privileged,
+		CapAdd:        rArgs.CapAdd,
+		CapDrop:       rArgs.CapDrop,
+		DNSServers:    opts.DNS,
+		HostAdd:       opts.Hosts,
+		Sysctl:        opts.Sysctl,
+		Rlimits:       makeRlimits(opts.Ulimits),
+		Devices:       makeDevices(opts.Devices),
+		Mounts:        makeMounts(opts.Volumes, env),
+		RestartPolicy: opts.RestartPolicy.Name,
+	}
+	if opts.Privileged {
+		s.User = root
+		s.CapAdd = append(s.CapAdd, "SYS_ADMIN")
+	}
+	if opts.RestartPolicy.Name != "" && opts.RestartPolicy.Name != restartAlways {
+		tries := uint(3)
+		if opts.RestartPolicy.MaxRetries > 0 {
+			tries = uint(opts.RestartPolicy.MaxRetries)
+		}
+		s.RestartTries = &tries
+	}
+	if rArgs.PidMode != "" {
+		s.PidNS = &namespace{NSMode: rArgs.PidMode}
+	}
+	if opts.Storage > 0 {
+		volumeTotal := int64(0)
+		for _, v := range opts.Volumes {
+			parts := strings.Split(v, ":")
+			if len(parts) < 4 {
+				continue
+			}
+			size, err := strconv.ParseInt(parts[3], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			volumeTotal += size
+		}
+		if opts.Storage-volumeTotal > 0 {
+			rArgs.StorageOpt["size"] = fmt.Sprintf("%v", opts.Storage-volumeTotal)
+		}
+	}
+	if len(rArgs.StorageOpt) > 0 {
+		s.StorageOpts = rArgs.StorageOpt
+	}
+	// lambda output is read from logs, k8s-file is the file log driver of podman
+	if opts.Lambda {
+		opts.LogType = "k8s-file"
+	}
+	if opts.LogType != "" {
+		s.LogConfiguration = &logConfig{Driver: opts.LogType, Options: opts.LogConfig}
+	}
+	s.ResourceLimits = makeResources(opts.Quota, opts.CPUShares, opts.Memory, opts.CPU, opts.NUMANode, opts.SoftLimit)
+	hugepageLimits, err := makeHugepageLimits(opts.Hugepages)
+	if err != nil {
+		return r, err
+	}
+	s.ResourceLimits.HugepageLimits = hugepageLimits
+
+	// networks win over network mode, the same as docker engine
+	networkMode := opts.Network
+	if len(opts.Networks) > 0 {
+		networkMode = bridgeNetwork
+		s.Networks = map[string]networkOption{}
+		for name, ipv4 := range opts.Networks {
+			if name == hostNetwork {
+				networkMode = hostNetwork
+				break
+			}
+			option := networkOption{}
+			if ipv4 != "" {
+				if net.ParseIP(ipv4) == nil {
+					return r, coretypes.NewDetailedErr(coretypes.ErrBadIPAddress, ipv4)
+				}
+				option.StaticIPs = []string{ipv4}
+			}
+			s.Networks[name] = option
+		}
+	}
+	if networkMode == "" {
+		networkMode = e.config.Podman.NetworkMode
+	}
+	switch networkMode {
+	case hostNetwork:
+		s.Networks = nil
+		s.DNSServers = nil
+		s.Sysctl = nil
+		s.NetNS = namespace{NSMode: hostNetwork}
+	case noneNetwork:
+		s.NetNS = namespace{NSMode: noneNetwork}
+	case bridgeNetwork, "":
+		s.NetNS = namespace{NSMode: bridgeNetwork}
+	default:
+		// a named network
+		s.NetNS = namespace{NSMode: bridgeNetwork}
+		if s.Networks == nil {
+			s.Networks = map[string]networkOption{networkMode: {}}
+		}
+	}
+	if s.NetNS.NSMode == bridgeNetwork {
+		for _, p := range opts.Publish {
+			port, err := strconv.ParseUint(p, 10, 16)
+			if err != nil {
+				return r, err
+			}
+			s.PortMappings = append(s.PortMappings, portMapping{ContainerPort: uint16(port), HostPort: uint16(port), Protocol: "tcp"})
+		}
+	}
+
+	created := &struct {
+		ID       string   `json:"Id"`
+		Warnings []string `json:"Warnings"`
+	}{}
+	if err := e.client.call(ctx, http.MethodPost, "/containers/create", nil, s, created); err != nil {
+		return r, err
+	}
+	for _, warning := range created.Warnings {
+		log.Warnf("[VirtualizationCreate] Create %s warning: %s", opts.Name, warning)
+	}
+	r.Name = opts.Name
+	r.ID = created.ID
+	return r, nil
+}
+
+// VirtualizationCopyTo copy things to virtualization
+func (e *Engine) VirtualizationCopyTo(ctx context.Context, ID, target string, content io.Reader, AllowOverwriteDirWithFile, CopyUIDGID bool) error {
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := tw.WriteHeader(&tar.Header{Name: filepath.Base(target), Mode: 0755, Size: int64(len(data))})
+		if err == nil {
+			_, err = tw.Write(data)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	query := url.Values{"path": {filepath.Dir(target)}}
+	if !AllowOverwriteDirWithFile {
+		query.Set("noOverwriteDirNonDir", "true")
+	}
+	if CopyUIDGID {
+		query.Set("copyUIDGID", "true")
+	}
+	err = e.client.call(ctx, http.MethodPut, fmt.Sprintf("/containers/%s/archive", ID), query, pr, nil)
+	_ = pr.Close()
+	return err
+}
+
+// VirtualizationStart start virtualization
+func (e *Engine) VirtualizationStart(ctx context.Context, ID string) error {
+	return e.client.call(ctx, http.MethodPost, fmt.Sprintf("/containers/%s/start", ID), nil, nil, nil)
+}
+
+// VirtualizationStop stop virtualization, killed after gracefulTimeout, zero means podman default
+func (e *Engine) VirtualizationStop(ctx context.Context, ID string, gracefulTimeout time.Duration) error {
+	query := url.Values{}
+	if gracefulTimeout > 0 {
+		query.Set("timeout", strconv.Itoa(int(gracefulTimeout.Seconds())))
+	}
+	return e.client.call(ctx, http.MethodPost, fmt.Sprintf("/containers/%s/stop", ID), query, nil, nil)
+}
+
+// VirtualizationRemove remove virtualization
+func (e *Engine) VirtualizationRemove(ctx context.Context, ID string, removeVolumes, force bool) error {
+	query := url.Values{"v": {strconv.FormatBool(removeVolumes)}, "force": {strconv.FormatBool(force)}}
+	return e.client.call(ctx, http.MethodDelete, fmt.Sprintf("/containers/%s", ID), query, nil, nil)
+}
+
+// VirtualizationInspect get virtualization info
+func (e *Engine) VirtualizationInspect(ctx context.Context, ID string) (*enginetypes.VirtualizationInfo, error) {
+	if e.client == nil {
+		return nil, coretypes.ErrNilEngine
+	}
+	c := &inspect{}
+	r := &enginetypes.VirtualizationInfo{}
+	if err := e.client.call(ctx, http.MethodGet, fmt.Sprintf("/containers/%s/json", ID), nil, nil, c); err != nil {
+		return r, err
+	}
+	r.ID = c.ID
+	r.Name = strings.TrimLeft(c.Name, "/")
+	r.User = c.Config.User
+	r.Image = c.Config.Image
+	r.Cmd = c.Config.Cmd
+	r.Dir = c.Config.WorkingDir
+	r.Env = c.Config.Env
+	r.Labels = c.Config.Labels
+	r.Running = c.State.Running
+	r.Created = c.Created.Unix()
+	switch {
+	case c.HostConfig.NanoCpus > 0:
+		r.Quota = float64(c.HostConfig.NanoCpus) / 1e9
+	case c.HostConfig.CPUQuota > 0 && c.HostConfig.CPUPeriod > 0:
+		r.Quota = float64(c.HostConfig.CPUQuota) / float64(c.HostConfig.CPUPeriod)
+	}
+	if c.HostConfig.Memory > 0 {
+		r.Memory = c.HostConfig.Memory
+	}
+	r.Networks = map[string]string{}
+	if c.HostConfig.NetworkMode == hostNetwork {
+		r.Networks[hostNetwork] = e.client.host()
+	}
+	for name, network := range c.NetworkSettings.Networks {
+		r.Networks[name] = network.IPAddress
+	}
+	return r, nil
+}
+
+// VirtualizationLogs show virtualization logs
+func (e *Engine) VirtualizationLogs(ctx context.Context, opts *enginetypes.VirtualizationLogStreamOptions) (io.ReadCloser, error) {
+	query := url.Values{
+		"follow": {strconv.FormatBool(opts.Follow)},
+		"stdout": {strconv.FormatBool(opts.Stdout)},
+		"stderr": {strconv.FormatBool(opts.Stderr)},
+	}
+	for k, v := range map[string]string{"tail": opts.Tail, "since": opts.Since, "until": opts.Until} {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+	resp, err := e.client.do(ctx, http.MethodGet, fmt.Sprintf("/containers/%s/logs", opts.ID), query, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return mergeStream(resp.Body), nil
+}
+
+// VirtualizationAttach attach to a virtualization
+func (e *Engine) VirtualizationAttach(ctx context.Context, ID string, stream, stdin bool) (io.ReadCloser, io.WriteCloser, error) {
+	query := url.Values{
+		"stream": {strconv.FormatBool(stream)},
+		"stdin":  {strconv.FormatBool(stdin)},
+		"logs":   {"true"},
+		"stdout": {"true"},
+		"stderr": {"true"},
+	}
+	s, err := e.client.hijack(ctx, fmt.Sprintf("/containers/%s/attach", ID), query, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ioutil.NopCloser(s), s, nil
+}
+
+// VirtualizationResize resizes remote terminal
+func (e *Engine) VirtualizationResize(ctx context.Context, ID string, height, width uint) error {
+	query := url.Values{"h": {strconv.Itoa(int(height))}, "w": {strconv.Itoa(int(width))}}
+	return e.client.call(ctx, http.MethodPost, fmt.Sprintf("/containers/%s/resize", ID), query, nil, nil)
+}
+
+// VirtualizationWait wait virtualization exit
+func (e *Engine) VirtualizationWait(ctx context.Context, ID, state string) (*enginetypes.VirtualizationWaitResult, error) {
+	r := &enginetypes.VirtualizationWaitResult{}
+	query := url.Values{"condition": {"stopped"}}
+	if err := e.client.call(ctx, http.MethodPost, fmt.Sprintf("/containers/%s/wait", ID), query, nil, &r.Code); err != nil {
+		r.Message = err.Error()
+		r.Code = -1
+		return r, err
+	}
+	return r, nil
+}
+
+// VirtualizationUpdateResource update virtualization resource
+func (e *Engine) VirtualizationUpdateResource(ctx context.Context, ID string, opts *enginetypes.VirtualizationResource) error {
+	if opts.Memory > 0 && opts.Memory < minMemory || opts.Memory < 0 {
+		return coretypes.ErrBadMemory
+	}
+	if opts.VolumeChanged {
+		log.Errorf("[VirtualizationUpdateResource] podman engine not support rebinding volume resource: %v", opts.Volumes)
+		return coretypes.ErrNotSupport
+	}
+	quota := opts.Quota
+	numaNode := opts.NUMANode
+	// unlimited cpu
+	if quota == 0 {
+		quota = -1
+		numaNode = ""
+	}
+	resource := makeResources(quota, opts.CPUShares, opts.Memory, opts.CPU, numaNode, opts.SoftLimit)
+	// unlimited memory
+	if opts.Memory == 0 {
+		unlimited := int64(-1)
+		resource.Memory = &memoryResources{Limit: &unlimited, Swap: &unlimited}
+	}
+	hugepageLimits, err := makeHugepageLimits(opts.Hugepages)
+	if err != nil {
+		return err
+	}
+	resource.HugepageLimits = hugepageLimits
+	return e.client.call(ctx, http.MethodPost, fmt.Sprintf("/containers/%s/update", ID), nil, resource, nil)
+}
+
+// VirtualizationList list virtualizations with all labels matched, stopped ones included
+func (e *Engine) VirtualizationList(ctx context.Context, labels map[string]string) ([]*enginetypes.VirtualizationInfo, error) {
+	query := url.Values{"all": {"true"}}
+	if len(labels) > 0 {
+		filters := []string{}
+		for k, v := range labels {
+			filters = append(filters, fmt.Sprintf("%s=%s", k, v))
+		}
+		b, err := json.Marshal(map[string][]string{"label": filters})
+		if err != nil {
+			return nil, err
+		}
+		query.Set("filters", string(b))
+	}
+	containers := []struct {
+		ID      string            `json:"Id"`
+		Names   []string          `json:"Names"`
+		Image   string            `json:"Image"`
+		Labels  map[string]string `json:"Labels"`
+		State   string            `json:"State"`
+		Created time.Time         `json:"Created"`
+	}{}
+	if err := e.client.call(ctx, http.MethodGet, "/containers/json", query, nil, &containers); err != nil {
+		return nil, err
+	}
+	r := []*enginetypes.VirtualizationInfo{}
+	for _, container := range containers {
+		info := &enginetypes.VirtualizationInfo{
+			ID:      container.ID,
+			Image:   container.Image,
+			Labels:  container.Labels,
+			Running: container.State == "running",
+			Created: container.Created.Unix(),
+		}
+		if len(container.Names) > 0 {
+			info.Name = strings.TrimLeft(container.Names[0], "/")
+		}
+		r = append(r, info)
+	}
+	return r, nil
+}
+
+// VirtualizationStats gets cpu and memory in use of a container from one shot of podman stats
+func (e *Engine) VirtualizationStats(ctx context.Context, ID string) (*enginetypes.VirtualizationStats, error) {
+	if e.client == nil {
+		return nil, coretypes.ErrNilEngine
+	}
+	resp := &struct {
+		Error interface{} `json:"Error"`
+		Stats []struct {
+			CPU      float64 `json:"CPU"` // percent of one core
+			MemUsage uint64  `json:"MemUsage"`
+		} `json:"Stats"`
+	}{}
+	query := url.Values{"containers": {ID}, "stream": {"false"}}
+	if err := e.client.call(ctx, http.MethodGet, "/containers/stats", query, nil, resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("%v", resp.Error)
+	}
+	if len(resp.Stats) == 0 {
+		return nil, coretypes.NewDetailedErr(coretypes.ErrContainerNotExists, ID)
+	}
+	return &enginetypes.VirtualizationStats{CPU: resp.Stats[0].CPU / 100, Memory: int64(resp.Stats[0].MemUsage)}, nil
+}
+
+// VirtualizationCheckpoint not implemented, checkpoints of podman can't be put in a shared dir
+func (e *Engine) VirtualizationCheckpoint(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) error {
+	return coretypes.ErrEngineNotImplemented
+}
+
+// VirtualizationRestore not implemented
+func (e *Engine) VirtualizationRestore(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) error {
+	return coretypes.ErrEngineNotImplemented
+}
+
+// VirtualizationCopyFrom copy thing from a virtualization
+func (e *Engine) VirtualizationCopyFrom(ctx context.Context, ID, path string) (io.ReadCloser, string, error) {
+	resp, err := e.client.do(ctx, http.MethodGet, fmt.Sprintf("/containers/%s/archive", ID), url.Values{"path": {path}}, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	name := filepath.Base(path)
+	if encoded := resp.Header.Get("X-Docker-Container-Path-Stat"); encoded != "" {
+		stat := &struct {
+			Name string `json:"name"`
+		}{}
+		if b, err := base64.StdEncoding.DecodeString(encoded); err == nil && json.Unmarshal(b, stat) == nil && stat.Name != "" {
+			name = stat.Name
+		}
+	}
+	tarReader := tar.NewReader(resp.Body)
+	if _, err = tarReader.Next(); err != nil {
+		resp.Body.Close()
+		return nil, "", errors.Wrapf(err, "read tarball from podman API failed: %s", path)
+	}
+	return &closeWith{tarReader, resp.Body}, name, nil
+}
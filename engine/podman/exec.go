@@ -0,0 +1,83 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	enginetypes "github.com/projecteru2/core/engine/types"
+)
+
+// ExecCreate create a exec
+func (e *Engine) ExecCreate(ctx context.Context, target string, config *enginetypes.ExecConfig) (string, error) {
+	execConfig := map[string]interface{}{
+		"User":         config.User,
+		"Privileged":   config.Privileged,
+		"Cmd":          config.Cmd,
+		"WorkingDir":   config.WorkingDir,
+		"Env":          config.Env,
+		"AttachStderr": config.AttachStderr,
+		"AttachStdout": config.AttachStdout,
+		"AttachStdin":  config.AttachStdin,
+		"Tty":          config.Tty,
+	}
+	r := &struct {
+		ID string `json:"Id"`
+	}{}
+	if err := e.client.call(ctx, http.MethodPost, fmt.Sprintf("/containers/%s/exec", target), nil, execConfig, r); err != nil {
+		return "", err
+	}
+	return r.ID, nil
+}
+
+// ExecAttach attach a exec, output is multiplexed unless tty, the same as docker
+func (e *Engine) ExecAttach(ctx context.Context, execID string, tty bool) (io.ReadCloser, io.WriteCloser, error) {
+	s, err := e.client.hijack(ctx, fmt.Sprintf("/exec/%s/start", execID), nil, map[string]bool{"Detach": false, "Tty": tty})
+	if err != nil {
+		return nil, nil, err
+	}
+	return ioutil.NopCloser(s), s, nil
+}
+
+// ExecAttachOutput attach a non-tty exec, stdout and stderr are split
+// both streams must be consumed, closing either one closes the connection
+func (e *Engine) ExecAttachOutput(ctx context.Context, execID string) (io.ReadCloser, io.ReadCloser, error) {
+	s, err := e.client.hijack(ctx, fmt.Sprintf("/exec/%s/start", execID), nil, map[string]bool{"Detach": false, "Tty": false})
+	if err != nil {
+		return nil, nil, err
+	}
+	stdout, stderr := splitStream(s)
+	return stdout, stderr, nil
+}
+
+// Execute executes a container
+func (e *Engine) Execute(ctx context.Context, target string, config *enginetypes.ExecConfig) (string, io.ReadCloser, io.WriteCloser, error) {
+	execID, err := e.ExecCreate(ctx, target, config)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	reader, writer, err := e.ExecAttach(ctx, execID, config.Tty)
+	return execID, reader, writer, err
+}
+
+// ExecExitCode get exec return code
+func (e *Engine) ExecExitCode(ctx context.Context, execID string) (int, error) {
+	r := &struct {
+		ExitCode int `json:"ExitCode"`
+	}{}
+	if err := e.client.call(ctx, http.MethodGet, fmt.Sprintf("/exec/%s/json", execID), nil, nil, r); err != nil {
+		return -1, err
+	}
+	return r.ExitCode, nil
+}
+
+// ExecResize resize exec tty
+func (e *Engine) ExecResize(ctx context.Context, execID string, height, width uint) error {
+	query := url.Values{"h": {strconv.Itoa(int(height))}, "w": {strconv.Itoa(int(width))}}
+	return e.client.call(ctx, http.MethodPost, fmt.Sprintf("/exec/%s/resize", execID), query, nil, nil)
+}
@@ -0,0 +1,292 @@
+package podman
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-units"
+	corecluster "github.com/projecteru2/core/cluster"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	coretypes "github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// spec is the subset of libpod SpecGenerator used by core
+type spec struct {
+	Name             string                   `json:"name,omitempty"`
+	Image            string                   `json:"image"`
+	Command          []string                 `json:"command,omitempty"`
+	Env              map[string]string        `json:"env,omitempty"`
+	Labels           map[string]string        `json:"labels,omitempty"`
+	WorkDir          string                   `json:"work_dir,omitempty"`
+	User             string                   `json:"user,omitempty"`
+	Stdin            bool                     `json:"stdin,omitempty"`
+	Terminal         bool                     `json:"terminal,omitempty"`
+	Privileged       bool                     `json:"privileged,omitempty"`
+	CapAdd           []string                 `json:"cap_add,omitempty"`
+	CapDrop          []string                 `json:"cap_drop,omitempty"`
+	DNSServers       []string                 `json:"dns_server,omitempty"`
+	HostAdd          []string                 `json:"hostadd,omitempty"`
+	Sysctl           map[string]string        `json:"sysctl,omitempty"`
+	Rlimits          []rlimit                 `json:"r_limits,omitempty"`
+	Devices          []device                 `json:"devices,omitempty"`
+	Mounts           []mount                  `json:"mounts,omitempty"`
+	RestartPolicy    string                   `json:"restart_policy,omitempty"`
+	RestartTries     *uint                    `json:"restart_tries,omitempty"`
+	NetNS            namespace                `json:"netns"`
+	PidNS            *namespace               `json:"pidns,omitempty"`
+	Networks         map[string]networkOption `json:"Networks,omitempty"`
+	PortMappings     []portMapping            `json:"portmappings,omitempty"`
+	ResourceLimits   *resources               `json:"resource_limits,omitempty"`
+	LogConfiguration *logConfig               `json:"log_configuration,omitempty"`
+	StorageOpts      map[string]string        `json:"storage_opts,omitempty"`
+}
+
+type rlimit struct {
+	Type string `json:"type"`
+	Hard int64  `json:"hard"`
+	Soft int64  `json:"soft"`
+}
+
+type device struct {
+	Path string `json:"path"`
+}
+
+type mount struct {
+	Destination string   `json:"destination"`
+	Type        string   `json:"type"`
+	Source      string   `json:"source"`
+	Options     []string `json:"options,omitempty"`
+}
+
+type namespace struct {
+	NSMode string `json:"nsmode"`
+	Value  string `json:"value,omitempty"`
+}
+
+type networkOption struct {
+	StaticIPs []string `json:"static_ips,omitempty"`
+}
+
+type portMapping struct {
+	ContainerPort uint16 `json:"container_port"`
+	HostPort      uint16 `json:"host_port"`
+	Protocol      string `json:"protocol"`
+}
+
+// resources is the same as LinuxResources of oci runtime spec
+type resources struct {
+	CPU            *cpuResources    `json:"cpu,omitempty"`
+	Memory         *memoryResources `json:"memory,omitempty"`
+	HugepageLimits []hugepageLimit  `json:"hugepageLimits,omitempty"`
+}
+
+type cpuResources struct {
+	Shares *uint64 `json:"shares,omitempty"`
+	Quota  *int64  `json:"quota,omitempty"`
+	Period *uint64 `json:"period,omitempty"`
+	Cpus   string  `json:"cpus,omitempty"`
+	Mems   string  `json:"mems,omitempty"`
+}
+
+type memoryResources struct {
+	Limit       *int64 `json:"limit,omitempty"`
+	Reservation *int64 `json:"reservation,omitempty"`
+	Swap        *int64 `json:"swap,omitempty"`
+}
+
+type hugepageLimit struct {
+	PageSize string `json:"pageSize"`
+	Limit    uint64 `json:"limit"`
+}
+
+type logConfig struct {
+	Driver  string            `json:"driver,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// makeEnv makes env map, later ones win like docker
+func makeEnv(env []string) map[string]string {
+	r := map[string]string{}
+	for _, e := range env {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) == 1 {
+			r[parts[0]] = ""
+			continue
+		}
+		r[parts[0]] = parts[1]
+	}
+	return r
+}
+
+// makeMounts makes bind mounts from volumes in the same format of docker engine,
+// e.g. "/foo-data:$SOMEENV/foodata:rw", size of volume is not supported either
+func makeMounts(volumes []string, env map[string]string) []mount {
+	mounts := []mount{}
+	for _, volume := range volumes {
+		expanded := os.Expand(volume, func(key string) string { return env[key] })
+		parts := strings.Split(expanded, ":")
+		if len(parts) < 2 {
+			continue
+		}
+		options := []string{"rw"}
+		if len(parts) >= 3 && parts[2] != "" {
+			options = strings.Split(parts[2], ",")
+		}
+		if len(parts) == 4 && parts[3] != "0" {
+			log.Warn("[makeMounts] podman engine not support volume with size limit")
+		}
+		mounts = append(mounts, mount{Destination: parts[1], Type: "bind", Source: parts[0], Options: append(options, "rbind")})
+	}
+	return mounts
+}
+
+// makeRlimits returns ulimits of entrypoint, with nofile of 65535 if not set
+func makeRlimits(ulimits []enginetypes.Ulimit) []rlimit {
+	r := []rlimit{}
+	nofile := false
+	for _, ulimit := range ulimits {
+		nofile = nofile || ulimit.Name == "nofile"
+		r = append(r, rlimit{Type: ulimit.Name, Soft: ulimit.Soft, Hard: ulimit.Hard})
+	}
+	if !nofile {
+		r = append(r, rlimit{Type: "nofile", Soft: 65535, Hard: 65535})
+	}
+	return r
+}
+
+// makeResources is the same as docker engine, quota -1 means unlimited
+func makeResources(cpu float64, cpuShares, memory int64, cpuMap map[string]int64, numaNode string, softlimit bool) *resources {
+	r := &resources{CPU: &cpuResources{}, Memory: &memoryResources{}}
+	if cpuShares > 0 {
+		shares := uint64(cpuShares)
+		r.CPU.Shares = &shares
+	}
+	period := uint64(corecluster.CPUPeriodBase)
+	r.CPU.Period = &period
+	if cpu > 0 {
+		quota := int64(cpu * float64(corecluster.CPUPeriodBase))
+		r.CPU.Quota = &quota
+	} else if cpu == -1 {
+		quota := int64(-1)
+		r.CPU.Quota = &quota
+	}
+	if len(cpuMap) > 0 {
+		cpuIDs := []string{}
+		for cpuID := range cpuMap {
+			cpuIDs = append(cpuIDs, cpuID)
+		}
+		sort.Strings(cpuIDs)
+		r.CPU.Cpus = strings.Join(cpuIDs, ",")
+		r.CPU.Mems = numaNode
+	}
+	if memory <= 0 {
+		return r
+	}
+	if softlimit {
+		r.Memory.Reservation = &memory
+		return r
+	}
+	reservation := memory / 2
+	if reservation < int64(units.MiB*4) {
+		reservation = int64(units.MiB * 4)
+	}
+	r.Memory.Limit = &memory
+	r.Memory.Swap = &memory
+	r.Memory.Reservation = &reservation
+	return r
+}
+
+// makeDevices makes devices in format of host:container:permissions
+func makeDevices(devices []enginetypes.Device) []device {
+	r := []device{}
+	for _, d := range devices {
+		path := d.PathOnHost
+		if d.PathInContainer != "" {
+			path = fmt.Sprintf("%s:%s", path, d.PathInContainer)
+			if d.Permissions != "" {
+				path = fmt.Sprintf("%s:%s", path, d.Permissions)
+			}
+		}
+		r = append(r, device{Path: path})
+	}
+	return r
+}
+
+// makeEncodedAuth encodes credential of registry of ref in docker config, empty if there is none
+func makeEncodedAuth(authConfigs map[string]coretypes.AuthConfig, ref string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", err
+	}
+	authConfig, ok := authConfigs[reference.Domain(named)]
+	if !ok {
+		return "", nil
+	}
+	buf, err := json.Marshal(authConfig)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(buf), nil
+}
+
+// mergeStream merges multiplexed stdout and stderr into one
+func mergeStream(stream io.ReadCloser) io.ReadCloser {
+	outr, outw := io.Pipe()
+	go func() {
+		defer stream.Close()
+		_, err := stdcopy.StdCopy(outw, outw, stream)
+		_ = outw.CloseWithError(err)
+	}()
+	return outr
+}
+
+// splitStream splits multiplexed stdout and stderr,
+// both streams must be consumed, closing either one closes the connection
+func splitStream(stream io.ReadCloser) (io.ReadCloser, io.ReadCloser) {
+	outr, outw := io.Pipe()
+	errr, errw := io.Pipe()
+	go func() {
+		defer stream.Close()
+		_, err := stdcopy.StdCopy(outw, errw, stream)
+		_ = outw.CloseWithError(err)
+		_ = errw.CloseWithError(err)
+	}()
+	return &closeWith{outr, stream}, &closeWith{errr, stream}
+}
+
+// closeWith closes the underlying stream as well
+type closeWith struct {
+	io.Reader
+	stream io.Closer
+}
+
+// Close .
+func (c *closeWith) Close() error {
+	return c.stream.Close()
+}
+
+// makeHugepageLimits makes hugetlb limits in bytes, oci names page sizes by 2MB / 1GB instead of 2Mi / 1Gi
+func makeHugepageLimits(hugepages map[string]int64) ([]hugepageLimit, error) {
+	sizes := []string{}
+	for size := range hugepages {
+		sizes = append(sizes, size)
+	}
+	sort.Strings(sizes)
+	r := []hugepageLimit{}
+	for _, size := range sizes {
+		pageSize := coretypes.HugepageSize(size)
+		if pageSize == 0 || hugepages[size] < 0 {
+			return nil, coretypes.NewDetailedErr(coretypes.ErrBadHugepages, fmt.Sprintf("%s: %d", size, hugepages[size]))
+		}
+		r = append(r, hugepageLimit{PageSize: strings.TrimSuffix(size, "i") + "B", Limit: uint64(pageSize * hugepages[size])})
+	}
+	return r, nil
+}
@@ -0,0 +1,232 @@
+package podman
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	coresource "github.com/projecteru2/core/source"
+	coretypes "github.com/projecteru2/core/types"
+	"github.com/projecteru2/core/utils"
+)
+
+// ImageList list image
+func (e *Engine) ImageList(ctx context.Context, image string) ([]*enginetypes.Image, error) {
+	// images of the same repo
+	if strings.Contains(image, ":") {
+		image = strings.Split(image, ":")[0]
+	}
+	b, err := json.Marshal(map[string][]string{"reference": {image}})
+	if err != nil {
+		return nil, err
+	}
+	images := []struct {
+		ID       string   `json:"Id"`
+		RepoTags []string `json:"RepoTags"`
+	}{}
+	if err := e.client.call(ctx, http.MethodGet, "/images/json", url.Values{"filters": {string(b)}}, nil, &images); err != nil {
+		return nil, err
+	}
+	r := []*enginetypes.Image{}
+	for _, image := range images {
+		r = append(r, &enginetypes.Image{ID: image.ID, Tags: image.RepoTags})
+	}
+	return r, nil
+}
+
+// ImageRemove remove a image, podman removes dangling parents anyway
+func (e *Engine) ImageRemove(ctx context.Context, image string, force, prune bool) ([]string, error) {
+	removed := &struct {
+		Deleted  []string `json:"Deleted"`
+		Untagged []string `json:"Untagged"`
+		Errors   []string `json:"Errors"`
+	}{}
+	query := url.Values{"images": {image}, "force": {strconv.FormatBool(force)}}
+	r := []string{}
+	if err := e.client.call(ctx, http.MethodDelete, "/images/remove", query, nil, removed); err != nil {
+		return r, err
+	}
+	if len(removed.Errors) > 0 {
+		return r, fmt.Errorf("%s", strings.Join(removed.Errors, "; "))
+	}
+	r = append(r, removed.Untagged...)
+	return append(r, removed.Deleted...), nil
+}
+
+// ImagesPrune prune dangling images
+func (e *Engine) ImagesPrune(ctx context.Context) error {
+	return e.client.call(ctx, http.MethodPost, "/images/prune", nil, nil, nil)
+}
+
+// ImagePull pull Image, output is json stream of progress
+// auth of registry in config is used if auth is nil
+func (e *Engine) ImagePull(ctx context.Context, ref string, all bool, auth *enginetypes.AuthConfig) (io.ReadCloser, error) {
+	header, err := e.authHeader(ref, auth)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{"reference": {ref}, "allTags": {strconv.FormatBool(all)}}
+	resp, err := e.client.do(ctx, http.MethodPost, "/images/pull", query, nil, header)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ImagePush push image
+func (e *Engine) ImagePush(ctx context.Context, ref string) (io.ReadCloser, error) {
+	header, err := e.authHeader(ref, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.client.do(ctx, http.MethodPost, fmt.Sprintf("/images/%s/push", url.PathEscape(ref)), url.Values{"destination": {ref}}, nil, header)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ImageBuild build image from dockerfile in input tarball, build secrets are not supported
+func (e *Engine) ImageBuild(ctx context.Context, input io.Reader, refs []string, secrets map[string][]byte) (io.ReadCloser, error) {
+	if len(secrets) > 0 {
+		return nil, coretypes.NewDetailedErr(coretypes.ErrEngineNotImplemented, "build secrets")
+	}
+	query := url.Values{"t": refs, "nocache": {"true"}, "rm": {"true"}, "forcerm": {"true"}, "pull": {"true"}}
+	resp, err := e.client.do(ctx, http.MethodPost, "/build", query, input, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ImageBuildFromExist commits image from running container
+func (e *Engine) ImageBuildFromExist(ctx context.Context, ID, name string) (string, error) {
+	imageID, err := e.commit(ctx, ID, name, "")
+	if err != nil {
+		return "", err
+	}
+	stream, err := e.ImagePush(ctx, name)
+	defer utils.EnsureReaderClosed(stream)
+	return imageID, err
+}
+
+// ImageCommit commits container filesystem to the first ref and tags it with the rest
+func (e *Engine) ImageCommit(ctx context.Context, ID string, refs []string, comment string) (string, error) {
+	if len(refs) == 0 {
+		return "", coretypes.ErrNoImage
+	}
+	imageID, err := e.commit(ctx, ID, refs[0], comment)
+	if err != nil {
+		return "", err
+	}
+	for _, ref := range refs[1:] {
+		repo, tag, err := splitRef(ref)
+		if err != nil {
+			return imageID, err
+		}
+		query := url.Values{"repo": {repo}, "tag": {tag}}
+		if err := e.client.call(ctx, http.MethodPost, fmt.Sprintf("/images/%s/tag", imageID), query, nil, nil); err != nil {
+			return imageID, err
+		}
+	}
+	return imageID, nil
+}
+
+func (e *Engine) commit(ctx context.Context, ID, ref, comment string) (string, error) {
+	repo, tag, err := splitRef(ref)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{"container": {ID}, "repo": {repo}, "tag": {tag}, "author": {"eru-core"}, "pause": {"true"}}
+	if comment != "" {
+		query.Set("comment", comment)
+	}
+	r := &struct {
+		ID string `json:"Id"`
+	}{}
+	if err := e.client.call(ctx, http.MethodPost, "/commit", query, nil, r); err != nil {
+		return "", err
+	}
+	return r.ID, nil
+}
+
+// ImageBuildCachePrune is not supported, podman has no build cache apart from images
+func (e *Engine) ImageBuildCachePrune(ctx context.Context, all bool) (uint64, error) {
+	return 0, coretypes.ErrEngineNotImplemented
+}
+
+// ImageLocalDigests return image digests
+func (e *Engine) ImageLocalDigests(ctx context.Context, image string) ([]string, error) {
+	r := &struct {
+		RepoDigests []string `json:"RepoDigests"`
+	}{}
+	if err := e.client.call(ctx, http.MethodGet, fmt.Sprintf("/images/%s/json", url.PathEscape(image)), nil, nil, r); err != nil {
+		return nil, err
+	}
+	return r.RepoDigests, nil
+}
+
+// ImageRemoteDigest is not supported, libpod API can't inspect manifest of registry,
+// so images are always pulled
+func (e *Engine) ImageRemoteDigest(ctx context.Context, image string) (string, error) {
+	return "", coretypes.ErrEngineNotImplemented
+}
+
+// BuildRefs output refs, the same as docker engine
+func (e *Engine) BuildRefs(ctx context.Context, name string, tags []string) []string {
+	if len(tags) == 0 {
+		tags = []string{utils.DefaultVersion}
+	}
+	refs := []string{}
+	prefix := strings.Trim(e.config.Docker.Namespace, "/")
+	for _, tag := range tags {
+		if prefix == "" {
+			refs = append(refs, fmt.Sprintf("%s/%s:%s", e.config.Docker.Hub, name, tag))
+			continue
+		}
+		refs = append(refs, fmt.Sprintf("%s/%s/%s:%s", e.config.Docker.Hub, prefix, name, tag))
+	}
+	return refs
+}
+
+// BuildContent is not supported, images are built from scm by docker nodes of build pod
+func (e *Engine) BuildContent(ctx context.Context, scm coresource.Source, opts *enginetypes.BuildContentOptions) (string, io.Reader, error) {
+	return "", nil, coretypes.ErrEngineNotImplemented
+}
+
+// authHeader encodes auth given by core, or auth of registry of ref in config if it's nil
+func (e *Engine) authHeader(ref string, auth *enginetypes.AuthConfig) (http.Header, error) {
+	if auth != nil {
+		buf, err := json.Marshal(coretypes.AuthConfig{Username: auth.Username, Password: auth.Password})
+		if err != nil {
+			return nil, err
+		}
+		return http.Header{"X-Registry-Auth": {base64.URLEncoding.EncodeToString(buf)}}, nil
+	}
+	encoded, err := makeEncodedAuth(e.config.Docker.AuthConfigs, ref)
+	if err != nil || encoded == "" {
+		return nil, err
+	}
+	return http.Header{"X-Registry-Auth": {encoded}}, nil
+}
+
+// splitRef splits ref into repo and tag, latest if no tag
+func splitRef(ref string) (string, string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", "", err
+	}
+	tag := "latest"
+	if tagged, ok := named.(reference.Tagged); ok {
+		tag = tagged.Tag()
+	}
+	return named.Name(), tag, nil
+}
@@ -0,0 +1,73 @@
+package podman
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	enginetypes "github.com/projecteru2/core/engine/types"
+	coretypes "github.com/projecteru2/core/types"
+)
+
+// NetworkConnect connect to a network
+func (e *Engine) NetworkConnect(ctx context.Context, network, target, ipv4, ipv6 string) ([]string, error) {
+	body := map[string]interface{}{"container": target}
+	if ipv4 != "" {
+		ip := net.ParseIP(ipv4)
+		if ip == nil {
+			return nil, coretypes.NewDetailedErr(coretypes.ErrBadIPAddress, ipv4)
+		}
+		body["static_ips"] = []string{ip.String()}
+	}
+	if err := e.client.call(ctx, http.MethodPost, fmt.Sprintf("/networks/%s/connect", network), nil, body, nil); err != nil {
+		return nil, err
+	}
+	container := &inspect{}
+	if err := e.client.call(ctx, http.MethodGet, fmt.Sprintf("/containers/%s/json", target), nil, nil, container); err != nil {
+		return nil, err
+	}
+	ns, ok := container.NetworkSettings.Networks[network]
+	if !ok {
+		return []string{}, nil
+	}
+	return []string{ns.IPAddress}, nil
+}
+
+// NetworkDisconnect disconnect from a network
+func (e *Engine) NetworkDisconnect(ctx context.Context, network, target string, force bool) error {
+	body := map[string]interface{}{"Container": target, "Force": force}
+	return e.client.call(ctx, http.MethodPost, fmt.Sprintf("/networks/%s/disconnect", network), nil, body, nil)
+}
+
+// NetworkList show all networks
+func (e *Engine) NetworkList(ctx context.Context, drivers []string) ([]*enginetypes.Network, error) {
+	networks := []*enginetypes.Network{}
+	query := url.Values{}
+	if len(drivers) > 0 {
+		b, err := json.Marshal(map[string][]string{"driver": drivers})
+		if err != nil {
+			return networks, err
+		}
+		query.Set("filters", string(b))
+	}
+	ns := []struct {
+		Name    string `json:"name"`
+		Subnets []struct {
+			Subnet string `json:"subnet"`
+		} `json:"subnets"`
+	}{}
+	if err := e.client.call(ctx, http.MethodGet, "/networks/json", query, nil, &ns); err != nil {
+		return networks, err
+	}
+	for _, n := range ns {
+		subnets := []string{}
+		for _, subnet := range n.Subnets {
+			subnets = append(subnets, subnet.Subnet)
+		}
+		networks = append(networks, &enginetypes.Network{Name: n.Name, Subnets: subnets})
+	}
+	return networks, nil
+}
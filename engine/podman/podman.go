@@ -0,0 +1,70 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/projecteru2/core/engine"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	coretypes "github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// PrefixKey indicate podman, followed by socket path or host:port,
+	// e.g. podman:///run/user/1000/podman/podman.sock or podman://10.0.0.1:8888
+	PrefixKey = "podman://"
+
+	defaultAPIVersion = "v4.0.0"
+)
+
+// Engine is engine for podman, it runs rootless as well
+type Engine struct {
+	client *client
+	config coretypes.Config
+}
+
+// MakeClient make podman client
+func MakeClient(ctx context.Context, config coretypes.Config, nodename, endpoint, ca, cert, key string) (engine.API, error) {
+	address := strings.TrimPrefix(endpoint, PrefixKey)
+	if address == "" {
+		return nil, coretypes.NewDetailedErr(coretypes.ErrNodeFormat, fmt.Sprintf("endpoint invalid %v", endpoint))
+	}
+	version := config.Podman.APIVersion
+	if version == "" {
+		version = defaultAPIVersion
+	}
+	cli, err := newClient(address, version, ca, cert, key)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("[MakePodmanEngine] Create new client for %s, %s", endpoint, version)
+	return &Engine{client: cli, config: config}, nil
+}
+
+// Close closes idle connections of client
+func (e *Engine) Close() error {
+	e.client.http.CloseIdleConnections()
+	return nil
+}
+
+// Info show node info
+func (e *Engine) Info(ctx context.Context) (*enginetypes.Info, error) {
+	r := &struct {
+		Host struct {
+			Hostname string `json:"hostname"`
+			CPUs     int    `json:"cpus"`
+			MemTotal int64  `json:"memTotal"`
+		} `json:"host"`
+	}{}
+	if err := e.client.call(ctx, "GET", "/info", nil, nil, r); err != nil {
+		return nil, err
+	}
+	return &enginetypes.Info{ID: r.Host.Hostname, NCPU: r.Host.CPUs, MemTotal: r.Host.MemTotal}, nil
+}
+
+// ResourceValidate validate resource usage
+func (e *Engine) ResourceValidate(ctx context.Context, cpu float64, cpumap map[string]int64, memory, storage int64) error {
+	return nil
+}
@@ -0,0 +1,221 @@
+package podman
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	coretypes "github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEngine(t *testing.T, handler http.Handler) *Engine {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	config := coretypes.Config{Podman: coretypes.PodmanConfig{APIVersion: "v4.0.0", NetworkMode: "bridge"}}
+	api, err := MakeClient(context.Background(), config, "node", PrefixKey+strings.TrimPrefix(server.URL, "http://"), "", "", "")
+	assert.NoError(t, err)
+	return api.(*Engine)
+}
+
+func writeFrames(w http.ResponseWriter, stdout, stderr string) {
+	_, _ = stdcopy.NewStdWriter(w, stdcopy.Stdout).Write([]byte(stdout))
+	_, _ = stdcopy.NewStdWriter(w, stdcopy.Stderr).Write([]byte(stderr))
+}
+
+func TestVirtualizationCreate(t *testing.T) {
+	s := &spec{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v4.0.0/libpod/containers/create", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		*s = spec{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(s))
+		_, _ = w.Write([]byte(`{"Id":"abc","Warnings":[]}`))
+	})
+	e := newTestEngine(t, mux)
+
+	opts := &enginetypes.VirtualizationCreateOptions{
+		Name:    "app_web_abcdef",
+		Image:   "hub/app:latest",
+		Cmd:     []string{"run"},
+		Env:     []string{"A=1", "DATA=/data"},
+		Volumes: []string{"/host:$DATA:ro"},
+		Publish: []string{"8080"},
+		VirtualizationResource: enginetypes.VirtualizationResource{
+			Quota:     1.5,
+			Memory:    1 << 30,
+			CPU:       map[string]int64{"1": 100, "0": 100},
+			Hugepages: map[string]int64{"2Mi": 512, "1Gi": 1},
+		},
+		RestartPolicy: enginetypes.RestartPolicy{Name: "on-failure", MaxRetries: 5},
+	}
+	created, err := e.VirtualizationCreate(context.Background(), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", created.ID)
+	assert.Equal(t, "app_web_abcdef", created.Name)
+
+	assert.Equal(t, "1", s.Env["A"])
+	assert.Equal(t, "127.0.0.1", s.Env["ERU_NODE_IP"])
+	assert.Equal(t, []mount{{Destination: "/data", Type: "bind", Source: "/host", Options: []string{"ro", "rbind"}}}, s.Mounts)
+	assert.Equal(t, bridgeNetwork, s.NetNS.NSMode)
+	assert.Equal(t, []portMapping{{ContainerPort: 8080, HostPort: 8080, Protocol: "tcp"}}, s.PortMappings)
+	assert.Equal(t, uint(5), *s.RestartTries)
+	assert.Equal(t, int64(150000), *s.ResourceLimits.CPU.Quota)
+	assert.Equal(t, "0,1", s.ResourceLimits.CPU.Cpus)
+	assert.Equal(t, int64(1<<30), *s.ResourceLimits.Memory.Limit)
+	assert.Equal(t, []rlimit{{Type: "nofile", Soft: 65535, Hard: 65535}}, s.Rlimits)
+	assert.Equal(t, []hugepageLimit{{PageSize: "1GB", Limit: 1 << 30}, {PageSize: "2MB", Limit: 1 << 30}}, s.ResourceLimits.HugepageLimits)
+
+	// host network leaves dns and ports out
+	opts = &enginetypes.VirtualizationCreateOptions{Name: "app", Image: "app", DNS: []string{"8.8.8.8"}, Publish: []string{"80"}, Networks: map[string]string{"host": ""}}
+	_, err = e.VirtualizationCreate(context.Background(), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, hostNetwork, s.NetNS.NSMode)
+	assert.Empty(t, s.DNSServers)
+	assert.Empty(t, s.PortMappings)
+
+	opts = &enginetypes.VirtualizationCreateOptions{Name: "app", Image: "app", Networks: map[string]string{"calico": "bad"}}
+	_, err = e.VirtualizationCreate(context.Background(), opts)
+	assert.Error(t, err)
+
+	opts = &enginetypes.VirtualizationCreateOptions{Name: "app", Image: "app", VirtualizationResource: enginetypes.VirtualizationResource{Hugepages: map[string]int64{"4Ki": 1}}}
+	_, err = e.VirtualizationCreate(context.Background(), opts)
+	assert.True(t, errors.Is(err, coretypes.ErrBadHugepages))
+
+	opts = &enginetypes.VirtualizationCreateOptions{Name: "app", Image: "app", VirtualizationResource: enginetypes.VirtualizationResource{Memory: 1}}
+	_, err = e.VirtualizationCreate(context.Background(), opts)
+	assert.Equal(t, coretypes.ErrBadMemory, err)
+}
+
+func TestVirtualizationInspect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v4.0.0/libpod/containers/abc/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Id":"abc","Name":"app_web_abcdef","Created":"2021-01-02T03:04:05Z",
+			"Config":{"User":"app","Image":"hub/app:latest","Env":["A=1"],"Labels":{"ERU":"1"}},
+			"State":{"Running":true},
+			"HostConfig":{"NetworkMode":"host","CpuQuota":150000,"CpuPeriod":100000,"Memory":1073741824},
+			"NetworkSettings":{"Networks":{}}}`))
+	})
+	mux.HandleFunc("/v4.0.0/libpod/containers/missing/json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"cause":"no such container","message":"no container with name or ID \"missing\" found","response":404}`))
+	})
+	e := newTestEngine(t, mux)
+
+	info, err := e.VirtualizationInspect(context.Background(), "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "app_web_abcdef", info.Name)
+	assert.True(t, info.Running)
+	assert.Equal(t, 1.5, info.Quota)
+	assert.Equal(t, int64(1<<30), info.Memory)
+	assert.Equal(t, "127.0.0.1", info.Networks["host"])
+	assert.Equal(t, int64(1609556645), info.Created)
+
+	_, err = e.VirtualizationInspect(context.Background(), "missing")
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, err.(*apiError).Response)
+	assert.Contains(t, err.Error(), "missing")
+}
+
+func TestExec(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v4.0.0/libpod/containers/abc/exec", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Id":"e1"}`))
+	})
+	mux.HandleFunc("/v4.0.0/libpod/exec/e1/start", func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		assert.NoError(t, err)
+		defer conn.Close()
+		_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Type: application/vnd.docker.multiplexed-stream\r\n\r\n")
+		_ = buf.Flush()
+		_, _ = stdcopy.NewStdWriter(conn, stdcopy.Stdout).Write([]byte("out"))
+		_, _ = stdcopy.NewStdWriter(conn, stdcopy.Stderr).Write([]byte("err"))
+	})
+	mux.HandleFunc("/v4.0.0/libpod/exec/e1/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ExitCode":3}`))
+	})
+	e := newTestEngine(t, mux)
+
+	execID, err := e.ExecCreate(context.Background(), "abc", &enginetypes.ExecConfig{Cmd: []string{"ls"}, AttachStdout: true, AttachStderr: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "e1", execID)
+
+	stdout, stderr, err := e.ExecAttachOutput(context.Background(), execID)
+	assert.NoError(t, err)
+	outCh := make(chan []byte)
+	go func() {
+		b, _ := ioutil.ReadAll(stdout)
+		outCh <- b
+	}()
+	errb, err := ioutil.ReadAll(stderr)
+	assert.NoError(t, err)
+	assert.Equal(t, "err", string(errb))
+	assert.Equal(t, "out", string(<-outCh))
+
+	code, err := e.ExecExitCode(context.Background(), execID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, code)
+}
+
+func TestVirtualizationLogs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v4.0.0/libpod/containers/abc/logs", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "10", r.URL.Query().Get("tail"))
+		assert.Empty(t, r.URL.Query().Get("since"))
+		writeFrames(w, "a\n", "b\n")
+	})
+	e := newTestEngine(t, mux)
+
+	rc, err := e.VirtualizationLogs(context.Background(), &enginetypes.VirtualizationLogStreamOptions{ID: "abc", Tail: "10", Stdout: true, Stderr: true})
+	assert.NoError(t, err)
+	b, err := ioutil.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, "a\nb\n", string(b))
+}
+
+func TestMakeEncodedAuth(t *testing.T) {
+	auths := map[string]coretypes.AuthConfig{"hub.example.com": {Username: "u", Password: "p"}}
+	auth, err := makeEncodedAuth(auths, "hub.example.com/app:latest")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, auth)
+	auth, err = makeEncodedAuth(auths, "app:latest")
+	assert.NoError(t, err)
+	assert.Empty(t, auth)
+
+	repo, tag, err := splitRef("hub.example.com/app")
+	assert.NoError(t, err)
+	assert.Equal(t, "hub.example.com/app", repo)
+	assert.Equal(t, "latest", tag)
+}
+
+func TestImagePull(t *testing.T) {
+	auth := ""
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v4.0.0/libpod/images/pull", func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("X-Registry-Auth")
+		_, _ = w.Write([]byte(`{"status":"done"}`))
+	})
+	e := newTestEngine(t, mux)
+
+	rc, err := e.ImagePull(context.Background(), "hub.example.com/app:latest", false, nil)
+	assert.NoError(t, err)
+	rc.Close()
+	assert.Empty(t, auth)
+
+	rc, err = e.ImagePull(context.Background(), "hub.example.com/app:latest", false, &enginetypes.AuthConfig{Username: "u", Password: "p"})
+	assert.NoError(t, err)
+	rc.Close()
+	b, err := base64.URLEncoding.DecodeString(auth)
+	assert.NoError(t, err)
+	config := coretypes.AuthConfig{}
+	assert.NoError(t, json.Unmarshal(b, &config))
+	assert.Equal(t, coretypes.AuthConfig{Username: "u", Password: "p"}, config)
+}
@@ -20,6 +20,12 @@ func (s *SSHClient) ExecAttach(ctx context.Context, execID string, tty bool) (re
 	return
 }
 
+// ExecAttachOutput attaches stdout and stderr
+func (s *SSHClient) ExecAttachOutput(ctx context.Context, execID string) (stdout, stderr io.ReadCloser, err error) {
+	err = types.ErrEngineNotImplemented
+	return
+}
+
 // Execute executes a cmd and attaches stdio
 func (s *SSHClient) Execute(ctx context.Context, target string, config *enginetypes.ExecConfig) (execID string, reader io.ReadCloser, writer io.WriteCloser, err error) {
 	err = types.ErrEngineNotImplemented
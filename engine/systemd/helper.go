@@ -6,7 +6,8 @@ import (
 )
 
 const (
-	eruSystemdUnitPath = `/usr/local/lib/systemd/system/`
+	// units of runtime are transient, they are gone with reboot of node
+	eruSystemdUnitPath = `/run/systemd/system/`
 )
 
 func getUnitFilename(ID string) string {
@@ -28,7 +28,7 @@ func (s *SSHClient) ImagesPrune(ctx context.Context) (err error) {
 }
 
 // ImagePull pulls image
-func (s *SSHClient) ImagePull(ctx context.Context, ref string, all bool) (rc io.ReadCloser, err error) {
+func (s *SSHClient) ImagePull(ctx context.Context, ref string, all bool, auth *enginetypes.AuthConfig) (rc io.ReadCloser, err error) {
 	return
 }
 
@@ -39,7 +39,7 @@ func (s *SSHClient) ImagePush(ctx context.Context, ref string) (rc io.ReadCloser
 }
 
 // ImageBuild builds image
-func (s *SSHClient) ImageBuild(ctx context.Context, input io.Reader, refs []string) (reader io.ReadCloser, err error) {
+func (s *SSHClient) ImageBuild(ctx context.Context, input io.Reader, refs []string, secrets map[string][]byte) (reader io.ReadCloser, err error) {
 	err = types.ErrEngineNotImplemented
 	return
 }
@@ -50,6 +50,12 @@ func (s *SSHClient) ImageBuildFromExist(ctx context.Context, ID, name string) (i
 	return
 }
 
+// ImageCommit won't work for systemd engine
+func (s *SSHClient) ImageCommit(ctx context.Context, ID string, refs []string, comment string) (imageID string, err error) {
+	err = types.ErrEngineNotImplemented
+	return
+}
+
 // ImageBuildCachePrune prunes cache
 func (s *SSHClient) ImageBuildCachePrune(ctx context.Context, all bool) (reclaimedInBytes uint64, err error) {
 	err = types.ErrEngineNotImplemented
@@ -29,15 +29,17 @@ const (
 // SSHClient contains a connection to sshd
 type SSHClient struct {
 	hostIP string
+	slice  string
 	client *ssh.Client
 }
 
-// NewSSHClient creates a SSHClient pointer
-func NewSSHClient(endpoint string, config *ssh.ClientConfig) (*SSHClient, error) {
+// NewSSHClient creates a SSHClient pointer, services are run in slice
+func NewSSHClient(endpoint string, config *ssh.ClientConfig, slice string) (*SSHClient, error) {
 	parts := strings.Split(endpoint, ":")
 	client, err := ssh.Dial("tcp", endpoint, config)
 	return &SSHClient{
 		hostIP: parts[0],
+		slice:  slice,
 		client: client,
 	}, err
 }
@@ -58,6 +60,7 @@ func MakeClient(ctx context.Context, config coretypes.Config, nodename, endpoint
 	return NewSSHClient(
 		strings.TrimPrefix(endpoint, SSHPrefixKey),
 		sshConfig,
+		config.Systemd.Slice,
 	)
 }
 
@@ -70,6 +73,11 @@ func (s *SSHClient) withSession(f func(*ssh.Session) error) (err error) {
 	return f(session)
 }
 
+// Close closes ssh connection
+func (s *SSHClient) Close() error {
+	return s.client.Close()
+}
+
 // Info fetches cpu info of remote
 func (s *SSHClient) Info(ctx context.Context) (info *enginetypes.Info, err error) {
 	cpu, err := s.cpuInfo(ctx)
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -16,6 +18,7 @@ import (
 )
 
 const (
+	cgroupRoot   = "/sys/fs/cgroup"
 	unitTemplate = `
 [Unit]
 %s
@@ -46,10 +49,6 @@ func (s *SSHClient) newUnitBuilder(ID string, opts *enginetypes.VirtualizationCr
 	}
 }
 
-func (b *unitBuilder) cgroupPath() string {
-	return b.ID
-}
-
 func (b *unitBuilder) buildUnit() *unitBuilder {
 	if b.err != nil {
 		return b
@@ -69,16 +68,41 @@ func (b *unitBuilder) buildUnit() *unitBuilder {
 	return b
 }
 
-func (b *unitBuilder) buildPreExec(cpuAmount int) *unitBuilder {
+func (b *unitBuilder) buildResource(slice string) *unitBuilder {
 	if b.err != nil {
 		return b
 	}
 
-	b.serviceBuffer = append(b.serviceBuffer,
-		fmt.Sprintf("ExecStartPre=/usr/bin/cgcreate -g memory,cpuset:%s", b.cgroupPath()),
-	)
+	properties, err := resourceProperties(&b.opts.VirtualizationResource)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.serviceBuffer = append(b.serviceBuffer, fmt.Sprintf("Slice=%s", slice))
+	b.serviceBuffer = append(b.serviceBuffer, properties...)
+	return b.buildHugepageLimit().buildNetworkLimit()
+}
+
+func (b *unitBuilder) buildHugepageLimit() *unitBuilder {
+	if b.err != nil || len(b.opts.Hugepages) == 0 {
+		return b
+	}
 
-	return b.buildNetworkLimit().buildCPULimit(cpuAmount).buildMemoryLimit()
+	sizes := []string{}
+	for size := range b.opts.Hugepages {
+		sizes = append(sizes, size)
+	}
+	sort.Strings(sizes)
+	for _, size := range sizes {
+		cmd, err := hugetlbCommand(b.ID, size, b.opts.Hugepages[size])
+		if err != nil {
+			b.err = err
+			return b
+		}
+		// runs as root before the service, `$` is escaped as `$$` in unit files
+		b.serviceBuffer = append(b.serviceBuffer, fmt.Sprintf("ExecStartPre=+/bin/sh -c '%s'", strings.ReplaceAll(cmd, "$", "$$")))
+	}
+	return b
 }
 
 func (b *unitBuilder) buildNetworkLimit() *unitBuilder {
@@ -101,58 +125,56 @@ func (b *unitBuilder) buildNetworkLimit() *unitBuilder {
 	return b
 }
 
-func (b *unitBuilder) buildCPULimit(cpuAmount int) *unitBuilder {
-	if b.err != nil {
-		return b
+// resourceProperties maps resource to resource control properties of systemd on cgroup v2,
+// unlimited ones are set to default explicitly, so that they are also used to update a running unit
+// hugepages are not included since systemd has no control of hugetlb, see hugetlbCommand
+func resourceProperties(resource *enginetypes.VirtualizationResource) ([]string, error) {
+	properties := []string{"CPUQuota=", "CPUWeight=", "AllowedCPUs=", "AllowedMemoryNodes="}
+	if resource.Quota > 0 {
+		properties[0] = fmt.Sprintf("CPUQuota=%.2f%%", resource.Quota*100)
 	}
-
-	cpusetCPUs := fmt.Sprintf("0-%d", cpuAmount-1)
-	if len(b.opts.CPU) > 0 {
+	if resource.CPUShares > 0 {
+		properties[1] = fmt.Sprintf("CPUWeight=%d", cpuSharesToWeight(resource.CPUShares))
+	}
+	if len(resource.CPU) > 0 {
 		allowedCPUs := []string{}
-		for CPU := range b.opts.CPU {
+		for CPU := range resource.CPU {
 			allowedCPUs = append(allowedCPUs, CPU)
 		}
-		cpusetCPUs = strings.Join(allowedCPUs, ",")
+		sort.Strings(allowedCPUs)
+		properties[2] = fmt.Sprintf("AllowedCPUs=%s", strings.Join(allowedCPUs, ","))
+		properties[3] = fmt.Sprintf("AllowedMemoryNodes=%s", resource.NUMANode)
 	}
 
-	if b.opts.Quota > 0 {
-		b.serviceBuffer = append(b.serviceBuffer,
-			fmt.Sprintf("CPUQuota=%.2f%%", b.opts.Quota*100),
+	// the same as docker engine, soft limit is protected but not limited, hard limit has no swap
+	switch {
+	case resource.Memory == 0:
+		properties = append(properties, "MemoryMax=infinity", "MemoryLow=0", "MemorySwapMax=infinity")
+	case resource.SoftLimit:
+		properties = append(properties, "MemoryMax=infinity", fmt.Sprintf("MemoryLow=%d", resource.Memory), "MemorySwapMax=infinity")
+	default:
+		properties = append(properties,
+			fmt.Sprintf("MemoryMax=%d", resource.Memory),
+			fmt.Sprintf("MemoryLow=%d", utils.Max(int(resource.Memory/2), units.MiB*4)),
+			"MemorySwapMax=0",
 		)
 	}
-
-	numaNode := b.opts.NUMANode
-	if numaNode == "" {
-		numaNode = "0"
-	}
-	b.serviceBuffer = append(b.serviceBuffer,
-		fmt.Sprintf("ExecStartPre=/usr/bin/cgset -r cpuset.cpus=%s %s", cpusetCPUs, b.cgroupPath()),
-		fmt.Sprintf("ExecStartPre=/usr/bin/cgset -r cpuset.mems=%s %s", numaNode, b.cgroupPath()),
-	)
-
-	return b
+	return properties, nil
 }
-func (b *unitBuilder) buildMemoryLimit() *unitBuilder {
-	if b.err != nil {
-		return b
-	}
 
-	if b.opts.Memory == 0 {
-		return b
+// hugetlbCommand makes a shell command writing hugetlb limit of cgroup v2 into the control group of unit,
+// 0 pages resets the limit to max, which is skipped if the hugetlb controller is not enabled for the unit
+func hugetlbCommand(ID, size string, count int64) (string, error) {
+	pageSize := types.HugepageSize(size)
+	if pageSize == 0 || count < 0 {
+		return "", types.NewDetailedErr(types.ErrBadHugepages, fmt.Sprintf("%s: %d", size, count))
 	}
-
-	if b.opts.SoftLimit {
-		b.serviceBuffer = append(b.serviceBuffer,
-			fmt.Sprintf("ExecStartPre=/usr/bin/cgset -r memory.soft_limit_in_bytes=%d %s", b.opts.Memory, b.cgroupPath()),
-		)
-
-	} else {
-		b.serviceBuffer = append(b.serviceBuffer,
-			fmt.Sprintf("ExecStartPre=/usr/bin/cgset -r memory.limit_in_bytes=%d %s", b.opts.Memory, b.cgroupPath()),
-			fmt.Sprintf("ExecStartPre=/usr/bin/cgset -r memory.soft_limit_in_bytes=%d %s", utils.Max(int(b.opts.Memory/2), units.MiB*4), b.cgroupPath()),
-		)
+	// cgroup names hugetlb files by 2MB / 1GB instead of 2Mi / 1Gi
+	file := fmt.Sprintf("%s$(/bin/systemctl show %s --property ControlGroup --value)/hugetlb.%s.max", cgroupRoot, ID, strings.TrimSuffix(size, "i")+"B")
+	if count == 0 {
+		return fmt.Sprintf("f=%s; [ ! -e $f ] || echo max > $f", file), nil
 	}
-	return b
+	return fmt.Sprintf("echo %d > %s", pageSize*count, file), nil
 }
 
 func (b *unitBuilder) buildExec() *unitBuilder {
@@ -176,7 +198,7 @@ func (b *unitBuilder) buildExec() *unitBuilder {
 		return b
 	}
 
-	restartPolicy, err := b.convertToSystemdRestartPolicy(b.opts.RestartPolicy)
+	restartPolicy, err := b.convertToSystemdRestartPolicy(b.opts.RestartPolicy.Name)
 	if err != nil {
 		b.err = err
 		return b
@@ -191,25 +213,39 @@ func (b *unitBuilder) buildExec() *unitBuilder {
 	}
 
 	b.serviceBuffer = append(b.serviceBuffer, []string{
-		fmt.Sprintf("ExecStart=/usr/bin/cgexec -g memory,cpuset:%s %s", b.cgroupPath(), strings.Join(cmds, " ")),
+		fmt.Sprintf("ExecStart=%s", strings.Join(cmds, " ")),
 		fmt.Sprintf("User=%s", user),
 		fmt.Sprintf("Environment=%s", strings.Join(env, " ")),
 		fmt.Sprintf("StandardOutput=%s", stdioType),
 		fmt.Sprintf("StandardError=%s", stdioType),
 		fmt.Sprintf("Restart=%s", restartPolicy),
 	}...)
+	if b.opts.RestartPolicy.Backoff > 0 {
+		b.serviceBuffer = append(b.serviceBuffer, fmt.Sprintf("RestartSec=%d", b.opts.RestartPolicy.Backoff))
+	}
+	if b.opts.RestartPolicy.MaxRetries > 0 {
+		// start limit counts the first start too, it's unlimited in time if window is not set, the same as docker
+		window := "infinity"
+		if b.opts.RestartPolicy.Window > 0 {
+			window = strconv.Itoa(b.opts.RestartPolicy.Window)
+		}
+		b.unitBuffer = append(b.unitBuffer,
+			fmt.Sprintf("StartLimitIntervalSec=%s", window),
+			fmt.Sprintf("StartLimitBurst=%d", b.opts.RestartPolicy.MaxRetries+1),
+		)
+	}
+	for _, ulimit := range b.opts.Ulimits {
+		b.serviceBuffer = append(b.serviceBuffer, fmt.Sprintf("Limit%s=%s:%s", strings.ToUpper(ulimit.Name), systemdLimit(ulimit.Soft), systemdLimit(ulimit.Hard)))
+	}
 	return b
 }
 
-func (b *unitBuilder) buildPostExec() *unitBuilder {
-	if b.err != nil {
-		return b
+// systemdLimit converts -1 to infinity of systemd resource limits
+func systemdLimit(limit int64) string {
+	if limit < 0 {
+		return "infinity"
 	}
-
-	b.serviceBuffer = append(b.serviceBuffer,
-		fmt.Sprintf("ExecStopPost=/usr/bin/cgdelete -g cpuset,memory:%s", b.cgroupPath()),
-	)
-	return b
+	return strconv.FormatInt(limit, 10)
 }
 
 func (b *unitBuilder) buffer() (*bytes.Buffer, error) {
@@ -246,3 +282,8 @@ func (b *unitBuilder) convertToSystemdStdio(logType string) (stdioType string, e
 	}
 	return
 }
+
+// cpuSharesToWeight maps cpu.shares [2, 262144] to cgroup v2 cpu.weight [1, 10000]
+func cpuSharesToWeight(shares int64) int64 {
+	return 1 + ((shares-2)*9999)/262142
+}
@@ -0,0 +1,94 @@
+package systemd
+
+import (
+	"strings"
+	"testing"
+
+	enginetypes "github.com/projecteru2/core/engine/types"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceProperties(t *testing.T) {
+	properties, err := resourceProperties(&enginetypes.VirtualizationResource{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CPUQuota=", "CPUWeight=", "AllowedCPUs=", "AllowedMemoryNodes=", "MemoryMax=infinity", "MemoryLow=0", "MemorySwapMax=infinity"}, properties)
+
+	properties, err = resourceProperties(&enginetypes.VirtualizationResource{
+		Quota:     1.5,
+		CPUShares: 1024,
+		CPU:       map[string]int64{"3": 100, "1": 50},
+		NUMANode:  "0",
+		Memory:    1 << 30,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CPUQuota=150.00%", "CPUWeight=39", "AllowedCPUs=1,3", "AllowedMemoryNodes=0", "MemoryMax=1073741824", "MemoryLow=536870912", "MemorySwapMax=0"}, properties)
+
+	properties, err = resourceProperties(&enginetypes.VirtualizationResource{Memory: 1 << 20, SoftLimit: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"MemoryMax=infinity", "MemoryLow=1048576", "MemorySwapMax=infinity"}, properties[4:])
+
+	properties, err = resourceProperties(&enginetypes.VirtualizationResource{Hugepages: map[string]int64{"2Mi": 1}})
+	assert.NoError(t, err)
+	assert.Len(t, properties, 7)
+}
+
+func TestHugetlbCommand(t *testing.T) {
+	cmd, err := hugetlbCommand("SYSTEMD-abc", "2Mi", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "echo 4194304 > /sys/fs/cgroup$(/bin/systemctl show SYSTEMD-abc --property ControlGroup --value)/hugetlb.2MB.max", cmd)
+
+	cmd, err = hugetlbCommand("SYSTEMD-abc", "1Gi", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "f=/sys/fs/cgroup$(/bin/systemctl show SYSTEMD-abc --property ControlGroup --value)/hugetlb.1GB.max; [ ! -e $f ] || echo max > $f", cmd)
+
+	_, err = hugetlbCommand("SYSTEMD-abc", "4Ki", 1)
+	assert.True(t, strings.Contains(err.Error(), types.ErrBadHugepages.Error()))
+}
+
+func TestUnitBuilder(t *testing.T) {
+	opts := &enginetypes.VirtualizationCreateOptions{
+		Name:    "app_web_abcdef",
+		Cmd:     []string{"/bin/app", "-c config"},
+		Env:     []string{"A=1"},
+		Network: "host",
+		VirtualizationResource: enginetypes.VirtualizationResource{
+			Quota:  1,
+			Memory: 1 << 30,
+		},
+	}
+	buffer, err := (&SSHClient{}).newUnitBuilder("SYSTEMD-abc", opts).buildUnit().buildResource("eru.slice").buildExec().buffer()
+	assert.NoError(t, err)
+	unit := buffer.String()
+	assert.Contains(t, unit, "Slice=eru.slice\n")
+	assert.Contains(t, unit, "CPUQuota=100.00%\n")
+	assert.Contains(t, unit, "MemoryMax=1073741824\n")
+	assert.Contains(t, unit, "ExecStart=/bin/app '-c config'\n")
+	assert.NotContains(t, unit, "cgexec")
+	assert.NotContains(t, unit, "StartLimit")
+
+	opts.RestartPolicy = enginetypes.RestartPolicy{Name: "on-failure", MaxRetries: 3, Window: 60}
+	buffer, err = (&SSHClient{}).newUnitBuilder("SYSTEMD-abc", opts).buildUnit().buildResource("eru.slice").buildExec().buffer()
+	assert.NoError(t, err)
+	unit = buffer.String()
+	assert.Contains(t, unit, "StartLimitIntervalSec=60\nStartLimitBurst=4\n")
+	opts.RestartPolicy.Window = 0
+	buffer, err = (&SSHClient{}).newUnitBuilder("SYSTEMD-abc", opts).buildUnit().buildResource("eru.slice").buildExec().buffer()
+	assert.NoError(t, err)
+	assert.Contains(t, buffer.String(), "StartLimitIntervalSec=infinity\n")
+	opts.RestartPolicy = enginetypes.RestartPolicy{}
+
+	opts.Hugepages = map[string]int64{"1Gi": 1, "2Mi": 512}
+	buffer, err = (&SSHClient{}).newUnitBuilder("SYSTEMD-abc", opts).buildUnit().buildResource("eru.slice").buildExec().buffer()
+	assert.NoError(t, err)
+	assert.Contains(t, buffer.String(), "ExecStartPre=+/bin/sh -c 'echo 1073741824 > /sys/fs/cgroup$$(/bin/systemctl show SYSTEMD-abc --property ControlGroup --value)/hugetlb.1GB.max'\n"+
+		"ExecStartPre=+/bin/sh -c 'echo 1073741824 > /sys/fs/cgroup$$(/bin/systemctl show SYSTEMD-abc --property ControlGroup --value)/hugetlb.2MB.max'\n")
+	opts.Hugepages = map[string]int64{"4Ki": 1}
+	_, err = (&SSHClient{}).newUnitBuilder("SYSTEMD-abc", opts).buildUnit().buildResource("eru.slice").buildExec().buffer()
+	assert.Error(t, err)
+	opts.Hugepages = nil
+
+	opts.Network = "calico"
+	_, err = (&SSHClient{}).newUnitBuilder("SYSTEMD-abc", opts).buildUnit().buildResource("eru.slice").buildExec().buffer()
+	assert.Error(t, err)
+}
@@ -8,6 +8,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	enginetypes "github.com/projecteru2/core/engine/types"
@@ -16,31 +17,38 @@ import (
 )
 
 const (
-	cmdFileExist      = `/usr/bin/test -f '%s'`
-	cmdCopyFromStdin  = `/bin/cp -f /dev/stdin '%s'`
-	cmdMkdir          = `/bin/mkdir -p %s`
-	cmdRemove         = `/bin/rm -f %s`
-	cmdSystemdReload  = `/bin/systemctl daemon-reload`
-	cmdSystemdRestart = `/bin/systemctl restart %s`
-	cmdSystemdStop    = `/bin/systemctl stop %s`
-	cmdSystemdStatus  = `/bin/systemctl show %s --property SubState,ActiveState,Environment,Description --no-pager`
-	cmdCopyToStdout   = `/bin/cp -f '%s' /dev/stdout`
+	cmdFileExist          = `/usr/bin/test -f '%s'`
+	cmdCopyFromStdin      = `/bin/cp -f /dev/stdin '%s'`
+	cmdMkdir              = `/bin/mkdir -p %s`
+	cmdRemove             = `/bin/rm -f %s`
+	cmdSystemdReload      = `/bin/systemctl daemon-reload`
+	cmdSystemdRestart     = `/bin/systemctl restart %s`
+	cmdSystemdStop        = `/bin/systemctl stop %s`
+	cmdSystemdSetProperty = `/bin/systemctl set-property --runtime %s %s`
+	cmdSystemdStatus      = `/bin/systemctl show %s --property SubState,ActiveState,Environment,Description --no-pager`
+	cmdCopyToStdout       = `/bin/cp -f '%s' /dev/stdout`
 )
 
 // VirtualizationCreate creates systemd service
 func (s *SSHClient) VirtualizationCreate(ctx context.Context, opts *enginetypes.VirtualizationCreateOptions) (created *enginetypes.VirtualizationCreated, err error) {
 	ID := "SYSTEMD-" + strings.ToLower(utils.RandomString(46))
-
-	cpuAmount, err := s.cpuInfo(ctx)
-	if err != nil {
-		return
+	// processes run on host and see its devices, they can't be mapped to other paths
+	for _, device := range opts.Devices {
+		if device.PathInContainer != device.PathOnHost {
+			return nil, types.NewDetailedErr(types.ErrEngineNotImplemented, "device mapping")
+		}
 	}
-	buffer, err := s.newUnitBuilder(ID, opts).buildUnit().buildPreExec(cpuAmount).buildExec().buildPostExec().buffer()
+	// processes see all gpu devices of host, cuda only uses the planned ones
+	if len(opts.GPU) > 0 {
+		opts.Env = append(opts.Env, fmt.Sprintf("CUDA_VISIBLE_DEVICES=%s", strings.Join(types.GPUDevices(opts.GPU), ",")))
+	}
+
+	buffer, err := s.newUnitBuilder(ID, opts).buildUnit().buildResource(s.slice).buildExec().buffer()
 	if err != nil {
 		return
 	}
 
-	// cp - /usr/local/lib/systemd/system/
+	// cp - /run/systemd/system/
 	if err = s.VirtualizationCopyTo(ctx, "", getUnitFilename(ID), buffer, true, true); err != nil {
 		return
 	}
@@ -79,8 +87,8 @@ func (s *SSHClient) VirtualizationStart(ctx context.Context, ID string) (err err
 	return errors.Wrap(err, stderr.String())
 }
 
-// VirtualizationStop stops a systemd service
-func (s *SSHClient) VirtualizationStop(ctx context.Context, ID string) (err error) {
+// VirtualizationStop stops a systemd service, gracefulTimeout is decided by TimeoutStopSec of unit
+func (s *SSHClient) VirtualizationStop(ctx context.Context, ID string, gracefulTimeout time.Duration) (err error) {
 	// systemctl stop $ID
 	_, stderr, err := s.runSingleCommand(ctx, fmt.Sprintf(cmdSystemdStop, ID), nil)
 	return errors.Wrap(err, stderr.String())
@@ -89,7 +97,7 @@ func (s *SSHClient) VirtualizationStop(ctx context.Context, ID string) (err erro
 // VirtualizationRemove removes a systemd service
 func (s *SSHClient) VirtualizationRemove(ctx context.Context, ID string, volumes, force bool) (err error) {
 	if force {
-		_ = s.VirtualizationStop(ctx, ID)
+		_ = s.VirtualizationStop(ctx, ID, 0)
 	}
 
 	// rm -f $FILE
@@ -155,8 +163,57 @@ func (s *SSHClient) VirtualizationWait(ctx context.Context, ID, state string) (r
 	return
 }
 
-// VirtualizationUpdateResource updates service resource limits
+// VirtualizationUpdateResource updates resource limits of a running service, till it's stopped
 func (s *SSHClient) VirtualizationUpdateResource(ctx context.Context, ID string, opts *enginetypes.VirtualizationResource) (err error) {
+	// processes see file system of host, there is nothing to bind
+	if opts.VolumeChanged {
+		return types.ErrNotSupport
+	}
+	properties, err := resourceProperties(opts)
+	if err != nil {
+		return
+	}
+	for i, property := range properties {
+		properties[i] = fmt.Sprintf("'%s'", property)
+	}
+	// systemctl set-property --runtime $ID CPUQuota=...
+	_, stderr, err := s.runSingleCommand(ctx, fmt.Sprintf(cmdSystemdSetProperty, ID, strings.Join(properties, " ")), nil)
+	if err != nil {
+		return errors.Wrap(err, stderr.String())
+	}
+	// hugetlb limits are written into cgroup directly, page sizes not requested are reset
+	for _, size := range []string{types.Hugepage2Mi, types.Hugepage1Gi} {
+		cmd, err := hugetlbCommand(ID, size, opts.Hugepages[size])
+		if err != nil {
+			return err
+		}
+		if _, stderr, err = s.runSingleCommand(ctx, cmd, nil); err != nil {
+			return errors.Wrap(err, stderr.String())
+		}
+	}
+	return nil
+}
+
+// VirtualizationList won't work for systemd engine
+func (s *SSHClient) VirtualizationList(ctx context.Context, labels map[string]string) (infos []*enginetypes.VirtualizationInfo, err error) {
+	err = types.ErrEngineNotImplemented
+	return
+}
+
+// VirtualizationCheckpoint won't work for systemd engine
+func (s *SSHClient) VirtualizationCheckpoint(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) (err error) {
+	err = types.ErrEngineNotImplemented
+	return
+}
+
+// VirtualizationRestore won't work for systemd engine
+func (s *SSHClient) VirtualizationRestore(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) (err error) {
+	err = types.ErrEngineNotImplemented
+	return
+}
+
+// VirtualizationStats won't work for systemd engine
+func (s *SSHClient) VirtualizationStats(ctx context.Context, ID string) (stats *enginetypes.VirtualizationStats, err error) {
 	err = types.ErrEngineNotImplemented
 	return
 }
@@ -26,9 +26,18 @@ type Builds struct {
 type Build struct {
 	Base       string            `yaml:"base,omitempty"`
 	Repo       string            `yaml:"repo,omitempty"`
+	Tarball    *Tarball          `yaml:"tarball,omitempty"` // https archive used as source instead of repo
 	Version    string            `yaml:"version,omitempty"`
 	Dir        string            `yaml:"dir,omitempty"`
+	Subpath    string            `yaml:"subpath,omitempty"` // only this path of repo is used as source
+	Branch     string            `yaml:"branch,omitempty"`
+	Tag        string            `yaml:"tag,omitempty"`
+	Commit     string            `yaml:"commit,omitempty"`
+	Depth      int               `yaml:"depth,omitempty"`
 	Submodule  bool              `yaml:"submodule,omitempty"`
+	Credential *SourceCredential `yaml:"credential,omitempty"`
+	Verify     *SourceVerify     `yaml:"verify,omitempty"`       // verified ref is labeled on image
+	Secrets    []string          `yaml:"secrets,omitempty,flow"` // IDs of build secrets mounted at /run/secrets/:ID for commands
 	Security   bool              `yaml:"security,omitempty"`
 	Commands   []string          `yaml:"commands,omitempty,flow"`
 	Envs       map[string]string `yaml:"envs,omitempty,flow"`
@@ -38,3 +47,43 @@ type Build struct {
 	Cache      map[string]string `yaml:"cache,omitempty,flow"`
 	StopSignal string            `yaml:"stop_signal,omitempty,flow"`
 }
+
+// SourceOptions is options of fetching source code
+type SourceOptions struct {
+	Revision   string // resolved when branch, tag and commit are all empty
+	Branch     string // clone this branch only
+	Tag        string // clone this tag only, exclusive with branch
+	Commit     string // exact sha to checkout
+	Depth      int    // shallow clone depth, 0 for full history
+	Submodule  bool
+	Credential *SourceCredential
+	Verify     *SourceVerify
+}
+
+// SourceVerify is integrity verification of source before building
+type SourceVerify struct {
+	Signature bool   `yaml:"signature,omitempty"` // verify gpg signature of tag if given, otherwise of commit
+	KeyRing   string `yaml:"keyring,omitempty"`   // armored public keys, trusted keyring in core config is used if empty
+	Checksum  string `yaml:"checksum,omitempty"`  // sha256:hex of source archive
+}
+
+// Tarball is plain https archive of source, tar, tar.gz or zip
+type Tarball struct {
+	URL      string            `yaml:"url,omitempty"`
+	Checksum string            `yaml:"checksum,omitempty"`    // sha256:hex of tarball
+	Header   map[string]string `yaml:"header,omitempty,flow"` // e.g. Authorization of vendor
+}
+
+// SourceCredential is scoped credential for fetching private repository and its submodules,
+// overrides the credential in core config
+type SourceCredential struct {
+	Username   string `yaml:"username,omitempty"`
+	Token      string `yaml:"token,omitempty"`       // token for https repository
+	PrivateKey string `yaml:"private_key,omitempty"` // content of deploy key for ssh repository
+}
+
+// AuthConfig is credential of registry given by core, engines use their own config if it's nil
+type AuthConfig struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
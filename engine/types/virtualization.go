@@ -4,15 +4,26 @@ package types
 type VirtualizationResource struct {
 	CPU           map[string]int64 // for cpu binding
 	Quota         float64          // for cpu quota
+	CPUShares     int64            // relative cpu weight as cpu.shares, 0 means default
 	Memory        int64            // for memory binding
 	Storage       int64
-	SoftLimit     bool   // soft limit or not
-	NUMANode      string // numa node
+	Hugepages     map[string]int64 // hugepage size to page count, e.g. {"2Mi": 512}
+	GPU           map[string]int64 // gpu device id to share, e.g. {"0": 100}
+	SoftLimit     bool             // soft limit or not
+	NUMANode      string           // numa node
 	Volumes       []string
 	VolumePlan    map[string]map[string]int64 // literal VolumePlan
 	VolumeChanged bool                        // indicate whether new volumes contained in realloc request
 }
 
+// RestartPolicy define restart policy, Backoff and Window in seconds
+type RestartPolicy struct {
+	Name       string
+	MaxRetries int
+	Backoff    int
+	Window     int // max retries are counted in, zero for the whole lifetime
+}
+
 // VirtualizationCreateOptions use for create virtualization target
 type VirtualizationCreateOptions struct {
 	VirtualizationResource
@@ -29,16 +40,18 @@ type VirtualizationCreateOptions struct {
 	Hosts      []string
 	Publish    []string
 	Sysctl     map[string]string
+	Ulimits    []Ulimit
 	Labels     map[string]string
 
 	Debug bool
 
-	RestartPolicy string
+	RestartPolicy RestartPolicy
 
 	Network  string
 	Networks map[string]string
 
 	Volumes []string
+	Devices []Device
 
 	LogType   string
 	LogConfig map[string]string
@@ -47,6 +60,20 @@ type VirtualizationCreateOptions struct {
 	Lambda  bool
 }
 
+// Ulimit is soft and hard limits of a resource, -1 means unlimited
+type Ulimit struct {
+	Name string
+	Soft int64
+	Hard int64
+}
+
+// Device is a host device granted to container
+type Device struct {
+	PathOnHost      string
+	PathInContainer string
+	Permissions     string
+}
+
 // VirtualizationCreated use for store name and ID
 type VirtualizationCreated struct {
 	ID   string
@@ -56,13 +83,34 @@ type VirtualizationCreated struct {
 // VirtualizationInfo store virtualization info
 type VirtualizationInfo struct {
 	ID       string
+	Name     string
 	User     string
 	Image    string
+	Cmd      []string
+	Dir      string
 	Running  bool
 	Env      []string
 	Labels   map[string]string
 	Networks map[string]string
-	// TODO other information like cpu memory
+	// resource limits, 0 means unlimited or unknown
+	Quota  float64
+	Memory int64
+	// unix seconds
+	Created int64
+}
+
+// VirtualizationStats is resource in use of a virtualization
+type VirtualizationStats struct {
+	CPU    float64 // cores
+	Memory int64   // bytes, page cache excluded
+}
+
+// CheckpointOptions locates checkpoint of virtualization by CRIU,
+// Dir is on storage shared by nodes so checkpoint can be restored elsewhere, Exit only works for checkpoint
+type CheckpointOptions struct {
+	Name string
+	Dir  string
+	Exit bool // stop virtualization after checkpoint
 }
 
 // VirtualizationWaitResult store exit result
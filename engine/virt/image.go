@@ -9,6 +9,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	enginetypes "github.com/projecteru2/core/engine/types"
+	coretypes "github.com/projecteru2/core/types"
 
 	virttypes "github.com/projecteru2/libyavirt/types"
 )
@@ -32,7 +33,7 @@ func (v *Virt) ImagesPrune(ctx context.Context) (err error) {
 }
 
 // ImagePull pulls an image to local virt-node.
-func (v *Virt) ImagePull(ctx context.Context, ref string, all bool) (rc io.ReadCloser, err error) {
+func (v *Virt) ImagePull(ctx context.Context, ref string, all bool, auth *enginetypes.AuthConfig) (rc io.ReadCloser, err error) {
 	return
 }
 
@@ -43,7 +44,7 @@ func (v *Virt) ImagePush(ctx context.Context, ref string) (rc io.ReadCloser, err
 }
 
 // ImageBuild captures from a guest.
-func (v *Virt) ImageBuild(ctx context.Context, input io.Reader, refs []string) (rc io.ReadCloser, err error) {
+func (v *Virt) ImageBuild(ctx context.Context, input io.Reader, refs []string, secrets map[string][]byte) (rc io.ReadCloser, err error) {
 	log.Warnf("does not implement")
 	return
 }
@@ -69,6 +70,11 @@ func (v *Virt) ImageBuildFromExist(ctx context.Context, ID, name string) (string
 	return uimg.Name, nil
 }
 
+// ImageCommit is not supported, use ImageBuildFromExist to capture a guest.
+func (v *Virt) ImageCommit(ctx context.Context, ID string, refs []string, comment string) (string, error) {
+	return "", coretypes.ErrEngineNotImplemented
+}
+
 // ImageBuildCachePrune prunes cached one.
 func (v *Virt) ImageBuildCachePrune(ctx context.Context, all bool) (reclaimed uint64, err error) {
 	log.Warnf("does not implement")
@@ -8,6 +8,7 @@ import (
 	"io"
 	"io/ioutil"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -80,6 +81,11 @@ func (v *Virt) ExecAttach(ctx context.Context, execID string, tty bool) (io.Read
 	return nil, nil, fmt.Errorf("ExecAttach does not implement")
 }
 
+// ExecAttachOutput attaches an execution with split output.
+func (v *Virt) ExecAttachOutput(ctx context.Context, execID string) (io.ReadCloser, io.ReadCloser, error) {
+	return nil, nil, fmt.Errorf("ExecAttachOutput does not implement")
+}
+
 // Execute executes a command in vm
 func (v *Virt) Execute(ctx context.Context, target string, config *enginetypes.ExecConfig) (execID string, outputStream io.ReadCloser, inputStream io.WriteCloser, err error) {
 	if config.Tty {
@@ -141,6 +147,20 @@ func (v *Virt) BuildContent(ctx context.Context, scm coresource.Source, opts *en
 
 // VirtualizationCreate creates a guest.
 func (v *Virt) VirtualizationCreate(ctx context.Context, opts *enginetypes.VirtualizationCreateOptions) (guest *enginetypes.VirtualizationCreated, err error) {
+	// yavirt has no hugepages backing of guest memory
+	if len(opts.Hugepages) > 0 {
+		return nil, coretypes.NewDetailedErr(coretypes.ErrEngineNotImplemented, "hugepages")
+	}
+	if len(opts.Devices) > 0 {
+		return nil, coretypes.NewDetailedErr(coretypes.ErrEngineNotImplemented, "devices")
+	}
+	if len(opts.GPU) > 0 {
+		return nil, coretypes.NewDetailedErr(coretypes.ErrEngineNotImplemented, "gpu")
+	}
+	if len(opts.Ulimits) > 0 {
+		return nil, coretypes.NewDetailedErr(coretypes.ErrEngineNotImplemented, "ulimits")
+	}
+
 	vols, err := v.parseVolumes(opts.Volumes)
 	if err != nil {
 		return nil, err
@@ -187,8 +207,8 @@ func (v *Virt) VirtualizationStart(ctx context.Context, ID string) (err error) {
 	return
 }
 
-// VirtualizationStop stops it.
-func (v *Virt) VirtualizationStop(ctx context.Context, ID string) (err error) {
+// VirtualizationStop stops it, gracefulTimeout isn't supported by yavirt.
+func (v *Virt) VirtualizationStop(ctx context.Context, ID string, gracefulTimeout time.Duration) (err error) {
 	_, err = v.client.StopGuest(ctx, ID)
 	return
 }
@@ -263,6 +283,27 @@ func (v *Virt) VirtualizationCopyFrom(ctx context.Context, ID, path string) (io.
 	return nil, "", fmt.Errorf("VirtualizationCopyFrom does not implement")
 }
 
+// VirtualizationList lists virtual units, not implemented.
+func (v *Virt) VirtualizationList(ctx context.Context, labels map[string]string) ([]*enginetypes.VirtualizationInfo, error) {
+	return nil, coretypes.ErrEngineNotImplemented
+}
+
+// VirtualizationCheckpoint not implemented.
+func (v *Virt) VirtualizationCheckpoint(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) error {
+	return coretypes.ErrEngineNotImplemented
+}
+
+// VirtualizationRestore not implemented.
+func (v *Virt) VirtualizationRestore(ctx context.Context, ID string, opts *enginetypes.CheckpointOptions) error {
+	return coretypes.ErrEngineNotImplemented
+}
+
+// VirtualizationStats gets resource in use of a guest.
+// TODO: yavirtd client doesn't expose stats yet.
+func (v *Virt) VirtualizationStats(ctx context.Context, ID string) (*enginetypes.VirtualizationStats, error) {
+	return nil, coretypes.ErrEngineNotImplemented
+}
+
 // VirtualizationExecute executes commands in running virtual unit
 func (v *Virt) VirtualizationExecute(ctx context.Context, ID string, commands, env []string, workdir string) (io.WriteCloser, io.ReadCloser, error) {
 	return nil, nil, fmt.Errorf("VirtualizationExecute not implemented")
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// dogstatsdBackend sends metrics in dogstatsd format, labels go as tags
+// to datadog agent, or to statsd supporting tags
+type dogstatsdBackend struct {
+	sync.Mutex
+	addr  string
+	conn  net.Conn
+	rates map[string]float32
+}
+
+func newDogstatsdBackend(addr string, rates map[string]float32) *dogstatsdBackend {
+	return &dogstatsdBackend{addr: addr, rates: rates}
+}
+
+// tagValueReplacer replaces separators of dogstatsd in tag values
+var tagValueReplacer = strings.NewReplacer(",", "_", "|", "_", ":", "_")
+
+// dogstatsdLine names metric as prometheus does, so they can be queried alike
+func dogstatsdLine(metric *Metric, value, kind string, rate float32, labels []string) []byte {
+	buf := bytes.NewBufferString(metric.Name)
+	buf.WriteByte(':')
+	buf.WriteString(value)
+	buf.WriteByte('|')
+	buf.WriteString(kind)
+	if rate < 1 {
+		buf.WriteString("|@")
+		buf.WriteString(strconv.FormatFloat(float64(rate), 'f', -1, 32))
+	}
+	for i, label := range labels {
+		if i >= len(metric.Labels) {
+			break
+		}
+		if i == 0 {
+			buf.WriteString("|#")
+		} else {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(metric.Labels[i])
+		buf.WriteByte(':')
+		buf.WriteString(tagValueReplacer.Replace(label))
+	}
+	return buf.Bytes()
+}
+
+func (d *dogstatsdBackend) send(line []byte) {
+	d.Lock()
+	defer d.Unlock()
+	if d.conn == nil {
+		conn, err := net.Dial("udp", d.addr)
+		if err != nil {
+			log.Errorf("[dogstatsd] Connect datadog agent failed: %v", err)
+			return
+		}
+		d.conn = conn
+	}
+	if _, err := d.conn.Write(line); err != nil {
+		log.Errorf("[dogstatsd] Sending dogstatsd failed: %v", err)
+	}
+}
+
+// Gauge .
+func (d *dogstatsdBackend) Gauge(metric *Metric, value float64, labels ...string) {
+	d.send(dogstatsdLine(metric, strconv.FormatFloat(value, 'f', -1, 64), "g", 1, labels))
+}
+
+// Count .
+func (d *dogstatsdBackend) Count(metric *Metric, n int, labels ...string) {
+	rate := sampleRate(d.rates, metric)
+	if sampledOut(rate) {
+		return
+	}
+	d.send(dogstatsdLine(metric, strconv.Itoa(n), "c", rate, labels))
+}
+
+// Timing .
+func (d *dogstatsdBackend) Timing(metric *Metric, duration time.Duration, labels ...string) {
+	rate := sampleRate(d.rates, metric)
+	if sampledOut(rate) {
+		return
+	}
+	d.send(dogstatsdLine(metric, strconv.FormatInt(int64(duration/time.Millisecond), 10), "ms", rate, labels))
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/projecteru2/core/cluster"
+	"github.com/projecteru2/core/types"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -33,3 +34,20 @@ func (m *Metrics) ResourceMiddleware(cluster cluster.Cluster) func(http.Handler)
 		})
 	}
 }
+
+// DebugMiddleware to update resource usage of core itself
+func (m *Metrics) DebugMiddleware(debugInfo func(context.Context) (*types.DebugInfo, error)) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(context.Background(), m.Config.GlobalTimeout)
+			defer cancel()
+			info, err := debugInfo(ctx)
+			if err != nil {
+				log.Errorf("[DebugMiddleware] Get debug info err %v", err)
+			} else {
+				m.SendDebugInfo(info)
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
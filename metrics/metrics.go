@@ -3,8 +3,8 @@ package metrics
 import (
 	"fmt"
 	"os"
+	"time"
 
-	statsdlib "github.com/CMGS/statsd"
 	"github.com/projecteru2/core/types"
 	"github.com/projecteru2/core/utils"
 	"github.com/prometheus/client_golang/prometheus"
@@ -12,208 +12,263 @@ import (
 )
 
 const (
-	cpuMap           = "core.node.%s.cpu.%s"
-	memStats         = "core.node.%s.memory"
-	storageStats     = "core.node.%s.storage"
-	memUsedStats     = "core.node.%s.memory.used"
-	storageUsedStats = "core.node.%s.storage.used"
-	cpuUsedStats     = "core.node.%s.cpu.used"
-	deployCount      = "core.%s.deploy.count"
+	// BackendStatsd pushes metrics to statsd
+	BackendStatsd = "statsd"
+	// BackendPrometheus exposes metrics to be pulled by prometheus
+	BackendPrometheus = "prometheus"
+	// BackendPushgateway pushes metrics to prometheus pushgateway
+	BackendPushgateway = "pushgateway"
+	// BackendDatadog pushes tagged metrics to datadog agent by dogstatsd
+	BackendDatadog = "datadog"
 )
 
+// Metric describes a metric and how it is named in each backend
+// Statsd is the bucket format, label values are given in order, e.g. core.node.%[2]s.memory
+// StatsdLegacy is the old bucket format still sent by statsd backend, for dashboards built on it
+type Metric struct {
+	Name         string
+	Help         string
+	Labels       []string
+	Statsd       string
+	StatsdLegacy string
+}
+
+var (
+	memoryCapacity  = &Metric{Name: "memory_capacity", Help: "node available memory.", Labels: []string{"podname", "nodename"}, Statsd: "core.node.%[2]s.memory"}
+	memoryUsed      = &Metric{Name: "memory_used", Help: "node used memory.", Labels: []string{"podname", "nodename"}, Statsd: "core.node.%[2]s.memory.used"}
+	storageCapacity = &Metric{Name: "storage_capacity", Help: "node available storage.", Labels: []string{"podname", "nodename"}, Statsd: "core.node.%[2]s.storage"}
+	storageUsed     = &Metric{Name: "storage_used", Help: "node used storage.", Labels: []string{"podname", "nodename"}, Statsd: "core.node.%[2]s.storage.used"}
+	cpuMap          = &Metric{Name: "cpu_map", Help: "node available cpu.", Labels: []string{"podname", "nodename", "cpuid"}, Statsd: "core.node.%[2]s.cpu.%[3]s"}
+	cpuUsed         = &Metric{Name: "cpu_used", Help: "node used cpu.", Labels: []string{"podname", "nodename"}, Statsd: "core.node.%[2]s.cpu.used"}
+	cpuTotal        = &Metric{Name: "cpu_total", Help: "node total cpu.", Labels: []string{"podname", "nodename"}, Statsd: "core.node.%[2]s.cpu.total"}
+	memoryTotal     = &Metric{Name: "memory_total", Help: "node total memory.", Labels: []string{"podname", "nodename"}, Statsd: "core.node.%[2]s.memory.total"}
+	storageTotal    = &Metric{Name: "storage_total", Help: "node total storage.", Labels: []string{"podname", "nodename"}, Statsd: "core.node.%[2]s.storage.total"}
+	volumeCapacity  = &Metric{Name: "volume_capacity", Help: "node available volume.", Labels: []string{"podname", "nodename"}, Statsd: "core.node.%[2]s.volume"}
+	volumeUsed      = &Metric{Name: "volume_used", Help: "node used volume.", Labels: []string{"podname", "nodename"}, Statsd: "core.node.%[2]s.volume.used"}
+	volumeTotal     = &Metric{Name: "volume_total", Help: "node total volume.", Labels: []string{"podname", "nodename"}, Statsd: "core.node.%[2]s.volume.total"}
+	orphans         = &Metric{Name: "orphan_containers", Help: "eru containers on node unknown to store.", Labels: []string{"podname", "nodename"}, Statsd: "core.node.%[2]s.orphans"}
+	engineCall      = &Metric{Name: "core_engine_call_seconds", Help: "latency of engine calls.", Labels: []string{"nodename", "method"}, Statsd: "core.engine.%[1]s.%[2]s"}
+	engineError     = &Metric{Name: "core_engine_errors", Help: "failed engine calls.", Labels: []string{"nodename", "method"}, Statsd: "core.engine.%[1]s.%[2]s.error"}
+	storeOp         = &Metric{Name: "core_store_op_seconds", Help: "latency of store operations.", Labels: []string{"op"}, Statsd: "core.store.%[1]s"}
+	storeError      = &Metric{Name: "core_store_errors", Help: "failed store operations.", Labels: []string{"op"}, Statsd: "core.store.%[1]s.error"}
+	storeConflict   = &Metric{Name: "core_store_txn_conflicts", Help: "store txns failed by compare.", Labels: []string{"op"}, Statsd: "core.store.%[1]s.conflict"}
+	storeWatch      = &Metric{Name: "core_store_watch_events", Help: "events received by store watches.", Labels: []string{}, Statsd: "core.store.watch"}
+	storeWatchError = &Metric{Name: "core_store_watch_errors", Help: "failed store watches.", Labels: []string{}, Statsd: "core.store.watch.error"}
+	goroutines      = &Metric{Name: "core_goroutines", Help: "goroutines of core.", Labels: []string{"hostname"}, Statsd: "core.%[1]s.goroutines"}
+	heapAlloc       = &Metric{Name: "core_heap_alloc_bytes", Help: "allocated heap of core.", Labels: []string{"hostname"}, Statsd: "core.%[1]s.heap.alloc"}
+	heapSys         = &Metric{Name: "core_heap_sys_bytes", Help: "heap of core obtained from os.", Labels: []string{"hostname"}, Statsd: "core.%[1]s.heap.sys"}
+	gcPauseTotal    = &Metric{Name: "core_gc_pause_total_seconds", Help: "total gc pause of core.", Labels: []string{"hostname"}, Statsd: "core.%[1]s.gc.pause.total"}
+	gcPauseLast     = &Metric{Name: "core_gc_pause_last_seconds", Help: "last gc pause of core.", Labels: []string{"hostname"}, Statsd: "core.%[1]s.gc.pause.last"}
+	grpcStreams     = &Metric{Name: "core_grpc_streams", Help: "open grpc streams of core.", Labels: []string{"hostname"}, Statsd: "core.%[1]s.grpc.streams"}
+	storeUp         = &Metric{Name: "core_store_up", Help: "1 if connection to store is ready.", Labels: []string{"hostname"}, Statsd: "core.%[1]s.store.up"}
+	deployCount     = &Metric{Name: "core_deploy", Help: "core deploy counter", Labels: []string{"hostname", "appname", "entrypoint", "podname", "nodename", "result"}, Statsd: "core.%[1]s.deploy.%[4]s.%[5]s.%[2]s.%[3]s.%[6]s", StatsdLegacy: "core.%[1]s.deploy.count"}
+	imagePullWait   = &Metric{Name: "core_image_pull_wait_seconds", Help: "time of image pulls waiting for concurrency limits.", Labels: []string{"nodename"}, Statsd: "core.image.%[1]s.pull.wait"}
+	imagePull       = &Metric{Name: "core_image_pull_seconds", Help: "latency of image pulls.", Labels: []string{"nodename"}, Statsd: "core.image.%[1]s.pull"}
+	imagePullError  = &Metric{Name: "core_image_pull_errors", Help: "failed image pulls.", Labels: []string{"nodename"}, Statsd: "core.image.%[1]s.pull.error"}
+	streamWait      = &Metric{Name: "core_stream_wait_seconds", Help: "time of sending messages to result channels.", Labels: []string{"method"}, Statsd: "core.stream.%[1]s.wait"}
+	streamDropped   = &Metric{Name: "core_stream_dropped", Help: "messages dropped for slow consumers.", Labels: []string{"method"}, Statsd: "core.stream.%[1]s.dropped"}
+	deployFailures  = &Metric{Name: "core_deploy_failures", Help: "core deploy failures by reason", Labels: []string{"hostname", "appname", "entrypoint", "podname", "nodename", "reason"}, Statsd: "core.%[1]s.deploy.%[4]s.%[5]s.%[2]s.%[3]s.failure.%[6]s"}
+)
+
+// Backend is where metrics go
+type Backend interface {
+	Gauge(metric *Metric, value float64, labels ...string)
+	Count(metric *Metric, n int, labels ...string)
+	Timing(metric *Metric, d time.Duration, labels ...string)
+}
+
 // Metrics define metrics
 type Metrics struct {
 	Config types.Config
 
-	StatsdAddr   string
-	Hostname     string
-	statsdClient *statsdlib.Client
-
-	MemoryCapacity  *prometheus.GaugeVec
-	MemoryUsed      *prometheus.GaugeVec
-	StorageCapacity *prometheus.GaugeVec
-	StorageUsed     *prometheus.GaugeVec
-	CPUMap          *prometheus.GaugeVec
-	CPUUsed         *prometheus.GaugeVec
-	DeployCount     *prometheus.CounterVec
-}
-
-// Lazy connect
-func (m *Metrics) checkConn() error {
-	if m.statsdClient != nil {
-		return nil
-	}
-	var err error
-	// We needn't try to renew/reconnect because of only supporting UDP protocol now
-	// We should add an `errorCount` to reconnect when implementing TCP protocol
-	if m.statsdClient, err = statsdlib.New(m.StatsdAddr, statsdlib.WithErrorHandler(func(err error) {
-		log.Errorf("[statsd] Sending statsd failed: %v", err)
-	})); err != nil {
-		log.Errorf("[statsd] Connect statsd failed: %v", err)
-		return err
+	Hostname string
+	backends []Backend
+}
+
+func (m *Metrics) gauge(metric *Metric, value float64, labels ...string) {
+	for _, backend := range m.backends {
+		backend.Gauge(metric, value, labels...)
 	}
-	return nil
 }
 
-func (m *Metrics) gauge(key string, value float64) error {
-	if err := m.checkConn(); err != nil {
-		return err
+func (m *Metrics) count(metric *Metric, n int, labels ...string) {
+	for _, backend := range m.backends {
+		backend.Count(metric, n, labels...)
 	}
-	m.statsdClient.Gauge(key, value)
-	return nil
 }
 
-func (m *Metrics) count(key string, n int, rate float32) error {
-	if err := m.checkConn(); err != nil {
-		return err
+func (m *Metrics) timing(metric *Metric, d time.Duration, labels ...string) {
+	for _, backend := range m.backends {
+		backend.Timing(metric, d, labels...)
 	}
-	m.statsdClient.Count(key, n, rate)
-	return nil
 }
 
-// SendNodeInfo update node resource capacity
+// SendNodeInfo update node resource capacity, allocation and total
 func (m *Metrics) SendNodeInfo(node *types.Node) {
 	nodename := node.Name
 	podname := node.Podname
 
-	memory := float64(node.MemCap)
-	memoryUsed := float64(node.InitMemCap - node.MemCap)
-	storage := float64(node.StorageCap)
-	storageUsed := float64(node.InitStorageCap - node.StorageCap)
-	cpuUsed := node.CPUUsed
-
-	if m.MemoryCapacity != nil {
-		m.MemoryCapacity.WithLabelValues(podname, nodename).Set(memory)
+	m.gauge(memoryCapacity, float64(node.MemCap), podname, nodename)
+	m.gauge(memoryUsed, float64(node.InitMemCap-node.MemCap), podname, nodename)
+	m.gauge(memoryTotal, float64(node.InitMemCap), podname, nodename)
+	m.gauge(storageCapacity, float64(node.StorageCap), podname, nodename)
+	m.gauge(storageUsed, float64(node.InitStorageCap-node.StorageCap), podname, nodename)
+	m.gauge(storageTotal, float64(node.InitStorageCap), podname, nodename)
+	m.gauge(volumeCapacity, float64(node.Volume.Total()), podname, nodename)
+	m.gauge(volumeUsed, float64(node.VolumeUsed), podname, nodename)
+	m.gauge(volumeTotal, float64(node.InitVolume.Total()), podname, nodename)
+	m.gauge(cpuUsed, node.CPUUsed, podname, nodename)
+	m.gauge(cpuTotal, float64(len(node.InitCPU)), podname, nodename)
+	for cpuid, value := range node.CPU {
+		m.gauge(cpuMap, float64(value), podname, nodename, cpuid)
 	}
+}
 
-	if m.MemoryUsed != nil {
-		m.MemoryUsed.WithLabelValues(podname, nodename).Set(memoryUsed)
-	}
+// SendOrphanContainers update count of orphan containers found on node
+func (m *Metrics) SendOrphanContainers(podname, nodename string, n int) {
+	m.gauge(orphans, float64(n), podname, nodename)
+}
 
-	if m.StorageCapacity != nil {
-		m.StorageCapacity.WithLabelValues(podname, nodename).Set(storage)
-	}
+const (
+	deploySuccess = "success"
+	deployFailure = "failure"
+)
 
-	if m.StorageUsed != nil {
-		m.StorageUsed.WithLabelValues(podname, nodename).Set(storageUsed)
+// SendDeployCount update deploy counter of an app entrypoint on node, by result
+func (m *Metrics) SendDeployCount(appname, entrypoint, podname, nodename string, success, failure int) {
+	log.Info("[Metrics] Update deploy counter")
+	if success > 0 {
+		m.count(deployCount, success, m.Hostname, appname, entrypoint, podname, nodename, deploySuccess)
 	}
-
-	if m.CPUUsed != nil {
-		m.CPUUsed.WithLabelValues(podname, nodename).Set(cpuUsed)
+	if failure > 0 {
+		m.count(deployCount, failure, m.Hostname, appname, entrypoint, podname, nodename, deployFailure)
 	}
+}
 
-	cleanedNodeName := utils.CleanStatsdMetrics(nodename)
-	for cpuid, value := range node.CPU {
-		val := float64(value)
-
-		if m.CPUMap != nil {
-			m.CPUMap.WithLabelValues(podname, nodename, cpuid).Set(val)
-		}
-
-		if m.StatsdAddr == "" {
-			continue
-		}
+// SendDeployFailure update deploy failure counter of an app entrypoint by reason
+// nodename is empty if containers failed before being scheduled to nodes
+func (m *Metrics) SendDeployFailure(appname, entrypoint, podname, nodename, reason string, n int) {
+	m.count(deployFailures, n, m.Hostname, appname, entrypoint, podname, nodename, reason)
+}
 
-		if err := m.gauge(fmt.Sprintf(cpuMap, cleanedNodeName, cpuid), val); err != nil {
-			log.Errorf("[SendNodeInfo] Error occurred while sending cpu data to statsd: %v", err)
-		}
+// SendEngineCall records latency of an engine call on node, and counts it if failed
+func (m *Metrics) SendEngineCall(nodename, method string, d time.Duration, err error) {
+	m.timing(engineCall, d, nodename, method)
+	if err != nil {
+		m.count(engineError, 1, nodename, method)
 	}
+}
 
-	if m.StatsdAddr == "" {
-		return
+// SendImagePull records waiting and pulling time of an image pull on node, and counts it if failed
+func (m *Metrics) SendImagePull(nodename string, wait, d time.Duration, err error) {
+	m.timing(imagePullWait, wait, nodename)
+	m.timing(imagePull, d, nodename)
+	if err != nil {
+		m.count(imagePullError, 1, nodename)
 	}
+}
 
-	if err := m.gauge(fmt.Sprintf(memStats, cleanedNodeName), memory); err != nil {
-		log.Errorf("[SendNodeInfo] Error occurred while sending memory data to statsd: %v", err)
+// SendStreamWait records time of sending a message to result channel of method, and counts it if dropped
+func (m *Metrics) SendStreamWait(method string, d time.Duration, dropped bool) {
+	m.timing(streamWait, d, method)
+	if dropped {
+		m.count(streamDropped, 1, method)
 	}
+}
 
-	if err := m.gauge(fmt.Sprintf(storageStats, cleanedNodeName), storage); err != nil {
-		log.Errorf("[SendNodeInfo] Error occurred while sending storage data to statsd: %v", err)
+// SendStoreOp records latency of a store operation, and counts it if failed
+func (m *Metrics) SendStoreOp(op string, d time.Duration, err error) {
+	m.timing(storeOp, d, op)
+	if err != nil {
+		m.count(storeError, 1, op)
 	}
+}
 
-	if err := m.gauge(fmt.Sprintf(memUsedStats, cleanedNodeName), memoryUsed); err != nil {
-		log.Errorf("[SendNodeInfo] Error occurred while sending memory used data to statsd: %v", err)
-	}
+// SendStoreConflict counts a store txn whose compares failed
+func (m *Metrics) SendStoreConflict(op string) {
+	m.count(storeConflict, 1, op)
+}
 
-	if err := m.gauge(fmt.Sprintf(storageUsedStats, cleanedNodeName), storageUsed); err != nil {
-		log.Errorf("[SendNodeInfo] Error occurred while sending storage used data to statsd: %v", err)
+// SendStoreWatch counts events of a watch response, and the response if failed
+func (m *Metrics) SendStoreWatch(events int, err error) {
+	if events > 0 {
+		m.count(storeWatch, events)
 	}
-
-	if err := m.gauge(fmt.Sprintf(cpuUsedStats, cleanedNodeName), cpuUsed); err != nil {
-		log.Errorf("[SendNodeInfo] Error occurred while sending cpu used data to statsd: %v", err)
+	if err != nil {
+		m.count(storeWatchError, 1)
 	}
 }
 
-// SendDeployCount update deploy counter
-func (m *Metrics) SendDeployCount(n int) {
-	log.Info("[Metrics] Update deploy counter")
-	if m.DeployCount != nil {
-		m.DeployCount.WithLabelValues(m.Hostname).Add(float64(n))
-	}
-
-	if m.StatsdAddr == "" {
-		return
-	}
-	key := fmt.Sprintf(deployCount, m.Hostname)
-	if err := m.count(key, n, 1.0); err != nil {
-		log.Errorf("[SendDeployCount] Error occurred while counting: %v", err)
+// SendDebugInfo update resource usage of core itself
+func (m *Metrics) SendDebugInfo(info *types.DebugInfo) {
+	m.gauge(goroutines, float64(info.Goroutines), m.Hostname)
+	m.gauge(heapAlloc, float64(info.HeapAlloc), m.Hostname)
+	m.gauge(heapSys, float64(info.HeapSys), m.Hostname)
+	m.gauge(gcPauseTotal, info.GCPauseTotal.Seconds(), m.Hostname)
+	m.gauge(gcPauseLast, info.GCPauseLast.Seconds(), m.Hostname)
+	m.gauge(grpcStreams, float64(info.Streams), m.Hostname)
+	up := 0.
+	if info.StoreState == "READY" {
+		up = 1
 	}
+	m.gauge(storeUp, up, m.Hostname)
 }
 
 // Client is a metrics obj
 var Client = Metrics{}
 
 // InitMetrics new a metrics obj
+// backends are selected by config, statsd (if statsd address set) and prometheus by default
 func InitMetrics(config types.Config) error {
 	hostname, err := os.Hostname()
 	if err != nil {
 		return err
 	}
 	Client = Metrics{
-		Config:     config,
-		StatsdAddr: config.Statsd,
-		Hostname:   utils.CleanStatsdMetrics(hostname),
-	}
-
-	Client.MemoryCapacity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "memory_capacity",
-		Help: "node available memory.",
-	}, []string{"podname", "nodename"})
-
-	Client.MemoryUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "memory_used",
-		Help: "node used memory.",
-	}, []string{"podname", "nodename"})
-
-	Client.StorageCapacity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "storage_capacity",
-		Help: "node available storage.",
-	}, []string{"podname", "nodename"})
-
-	Client.StorageUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "storage_used",
-		Help: "node used storage.",
-	}, []string{"podname", "nodename"})
-
-	Client.CPUMap = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "cpu_map",
-		Help: "node available cpu.",
-	}, []string{"podname", "nodename", "cpuid"})
-
-	Client.CPUUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "cpu_used",
-		Help: "node used cpu.",
-	}, []string{"podname", "nodename"})
-
-	Client.DeployCount = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: "core_deploy",
-		Help: "core deploy counter",
-	}, []string{"hostname"})
-
-	prometheus.MustRegister(
-		Client.DeployCount, Client.MemoryCapacity,
-		Client.StorageCapacity, Client.CPUMap,
-		Client.MemoryUsed, Client.StorageUsed, Client.CPUUsed,
-	)
+		Config:   config,
+		Hostname: utils.CleanStatsdMetrics(hostname),
+	}
+
+	backends := config.Metrics.Backends
+	if len(backends) == 0 {
+		backends = []string{BackendPrometheus}
+		if config.Statsd != "" {
+			backends = append(backends, BackendStatsd)
+		}
+	}
+	for _, name := range backends {
+		backend, err := newBackend(name, config)
+		if err != nil {
+			return err
+		}
+		Client.backends = append(Client.backends, backend)
+	}
 	return nil
 }
+
+func newBackend(name string, config types.Config) (Backend, error) {
+	switch name {
+	case BackendStatsd:
+		if config.Statsd == "" {
+			return nil, types.NewDetailedErr(types.ErrBadMetricsBackend, "statsd address not set")
+		}
+		if config.Metrics.StatsdTags {
+			return newDogstatsdBackend(config.Statsd, config.Metrics.SampleRates), nil
+		}
+		return newStatsdBackend(config.Statsd, config.Metrics.SampleRates), nil
+	case BackendPrometheus:
+		return newPrometheusBackend(prometheus.DefaultRegisterer), nil
+	case BackendPushgateway:
+		if config.Metrics.Pushgateway == "" {
+			return nil, types.NewDetailedErr(types.ErrBadMetricsBackend, "pushgateway url not set")
+		}
+		return newPushgatewayBackend(config.Metrics.Pushgateway, Client.Hostname, config.Metrics.PushInterval), nil
+	case BackendDatadog:
+		if config.Metrics.Datadog == "" {
+			return nil, types.NewDetailedErr(types.ErrBadMetricsBackend, "datadog agent address not set")
+		}
+		return newDogstatsdBackend(config.Metrics.Datadog, config.Metrics.SampleRates), nil
+	default:
+		return nil, types.NewDetailedErr(types.ErrBadMetricsBackend, fmt.Sprintf("unknown backend %s", name))
+	}
+}
@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsdBucket(t *testing.T) {
+	assert.Equal(t, []string{"core.node.n-1.cpu.0"}, statsdBuckets(cpuMap, []string{"p", "n.1", "0"}))
+	// legacy bucket of deploy count is kept for dashboards built on it
+	assert.Equal(t, []string{"core.host.deploy.p.n1.app.web.success", "core.host.deploy.count"}, statsdBuckets(deployCount, []string{"host", "app", "web", "p", "n1", "success"}))
+}
+
+func TestDogstatsdLine(t *testing.T) {
+	assert.Equal(t, "cpu_map:0.5|g|#podname:p,nodename:n1,cpuid:0", string(dogstatsdLine(cpuMap, "0.5", "g", 1, []string{"p", "n1", "0"})))
+	assert.Equal(t, "core_deploy:1|c|#hostname:h,appname:app,entrypoint:web,podname:p,nodename:n1,result:failure", string(dogstatsdLine(deployCount, "1", "c", 1, []string{"h", "app", "web", "p", "n1", "failure"})))
+	assert.Equal(t, "core_engine_call_seconds:12|ms|@0.1|#nodename:n1,method:Info", string(dogstatsdLine(engineCall, "12", "ms", 0.1, []string{"n1", "Info"})))
+	// separators in tag values are replaced
+	assert.Equal(t, "core_engine_call_seconds:12|ms|#nodename:10.0.0.1_2376,method:a_b_c", string(dogstatsdLine(engineCall, "12", "ms", 1, []string{"10.0.0.1:2376", "a,b|c"})))
+}
+
+func TestSampleRate(t *testing.T) {
+	rates := map[string]float32{engineCall.Name: 0.1, engineError.Name: 2}
+	assert.Equal(t, float32(0.1), sampleRate(rates, engineCall))
+	assert.Equal(t, float32(1), sampleRate(rates, engineError))
+	assert.Equal(t, float32(1), sampleRate(nil, deployCount))
+	assert.False(t, sampledOut(1))
+}
+
+func TestPrometheusBackend(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	backend := newPrometheusBackend(registry)
+	backend.Gauge(memoryCapacity, 100, "p", "n1")
+	backend.Gauge(memoryCapacity, 50, "p", "n1")
+	backend.Count(deployCount, 2, "h", "app", "web", "p", "n1", "success")
+	backend.Count(deployCount, 3, "h", "app", "web", "p", "n1", "success")
+	backend.Timing(engineCall, time.Second, "n1", "Info")
+	assert.Equal(t, float64(50), testutil.ToFloat64(backend.gauges[memoryCapacity.Name].WithLabelValues("p", "n1")))
+	assert.Equal(t, float64(5), testutil.ToFloat64(backend.counters[deployCount.Name].WithLabelValues("h", "app", "web", "p", "n1", "success")))
+	assert.Equal(t, 1, testutil.CollectAndCount(backend.histograms[engineCall.Name]))
+}
+
+func TestNewBackend(t *testing.T) {
+	config := types.Config{}
+	_, err := newBackend(BackendStatsd, config)
+	assert.Error(t, err)
+	_, err = newBackend(BackendDatadog, config)
+	assert.Error(t, err)
+	_, err = newBackend("graphite", config)
+	assert.Error(t, err)
+	config.Metrics.Datadog = "127.0.0.1:8125"
+	backend, err := newBackend(BackendDatadog, config)
+	assert.NoError(t, err)
+	assert.IsType(t, &dogstatsdBackend{}, backend)
+	config.Statsd = "127.0.0.1:8125"
+	backend, err = newBackend(BackendStatsd, config)
+	assert.NoError(t, err)
+	assert.IsType(t, &statsdBackend{}, backend)
+	config.Metrics.StatsdTags = true
+	backend, err = newBackend(BackendStatsd, config)
+	assert.NoError(t, err)
+	assert.IsType(t, &dogstatsdBackend{}, backend)
+}
+
+type countBackend struct {
+	counts map[string]int
+}
+
+func (b *countBackend) Gauge(metric *Metric, value float64, labels ...string) {
+	b.counts[metric.Name] = int(value)
+}
+
+func (b *countBackend) Count(metric *Metric, n int, labels ...string) {
+	if len(labels) == 0 {
+		b.counts[metric.Name] += n
+		return
+	}
+	b.counts[labels[len(labels)-1]] += n
+}
+
+func (b *countBackend) Timing(metric *Metric, d time.Duration, labels ...string) {
+	b.counts[metric.Name]++
+}
+
+func TestSendDeployCount(t *testing.T) {
+	backend := &countBackend{counts: map[string]int{}}
+	m := &Metrics{backends: []Backend{backend}}
+	m.SendDeployCount("app", "web", "p", "n1", 2, 0)
+	m.SendDeployCount("app", "web", "p", "n1", 1, 1)
+	assert.Equal(t, map[string]int{deploySuccess: 3, deployFailure: 1}, backend.counts)
+}
+
+func TestSendEngineCall(t *testing.T) {
+	backend := &countBackend{counts: map[string]int{}}
+	m := &Metrics{backends: []Backend{backend}}
+	m.SendEngineCall("n1", "VirtualizationCreate", time.Second, nil)
+	m.SendEngineCall("n1", "VirtualizationCreate", time.Second, types.ErrNilEngine)
+	assert.Equal(t, map[string]int{engineCall.Name: 2, "VirtualizationCreate": 1}, backend.counts)
+}
+
+func TestSendStoreOp(t *testing.T) {
+	backend := &countBackend{counts: map[string]int{}}
+	m := &Metrics{backends: []Backend{backend}}
+	m.SendStoreOp("get", time.Millisecond, nil)
+	m.SendStoreOp("txn", time.Millisecond, types.ErrNoOps)
+	m.SendStoreConflict("txn")
+	m.SendStoreWatch(3, nil)
+	m.SendStoreWatch(0, types.ErrNoOps)
+	assert.Equal(t, map[string]int{storeOp.Name: 2, "txn": 2, storeWatch.Name: 3, storeWatchError.Name: 1}, backend.counts)
+}
+
+func TestSendDebugInfo(t *testing.T) {
+	backend := &countBackend{counts: map[string]int{}}
+	m := &Metrics{backends: []Backend{backend}}
+	m.SendDebugInfo(&types.DebugInfo{Goroutines: 10, Streams: 2, GCPauseTotal: 3 * time.Second, StoreState: "READY"})
+	assert.Equal(t, 10, backend.counts[goroutines.Name])
+	assert.Equal(t, 2, backend.counts[grpcStreams.Name])
+	assert.Equal(t, 3, backend.counts[gcPauseTotal.Name])
+	assert.Equal(t, 1, backend.counts[storeUp.Name])
+	m.SendDebugInfo(&types.DebugInfo{StoreState: "TRANSIENT_FAILURE"})
+	assert.Equal(t, 0, backend.counts[storeUp.Name])
+}
+
+func TestSendDeployFailure(t *testing.T) {
+	backend := &countBackend{counts: map[string]int{}}
+	m := &Metrics{backends: []Backend{backend}}
+	m.SendDeployFailure("app", "web", "p", "", types.DeployFailureScheduling, 3)
+	m.SendDeployFailure("app", "web", "p", "n1", types.DeployFailureImage, 1)
+	assert.Equal(t, map[string]int{types.DeployFailureScheduling: 3, types.DeployFailureImage: 1}, backend.counts)
+	assert.Equal(t, "core.h.deploy.p.none.app.web.failure.scheduling", statsdBucket(deployFailures.Statsd, []string{"h", "app", "web", "p", "", "scheduling"}))
+}
+
+func TestSendNodeInfo(t *testing.T) {
+	backend := &countBackend{counts: map[string]int{}}
+	m := &Metrics{backends: []Backend{backend}}
+	m.SendNodeInfo(&types.Node{
+		Name:       "n1",
+		Podname:    "p",
+		InitCPU:    types.CPUMap{"0": 100, "1": 100},
+		MemCap:     1,
+		InitMemCap: 3,
+		Volume:     types.VolumeMap{"/data": 10},
+		InitVolume: types.VolumeMap{"/data": 30},
+		VolumeUsed: 20,
+	})
+	assert.Equal(t, 2, backend.counts[cpuTotal.Name])
+	assert.Equal(t, 2, backend.counts[memoryUsed.Name])
+	assert.Equal(t, 3, backend.counts[memoryTotal.Name])
+	assert.Equal(t, 10, backend.counts[volumeCapacity.Name])
+	assert.Equal(t, 20, backend.counts[volumeUsed.Name])
+	assert.Equal(t, 30, backend.counts[volumeTotal.Name])
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	log "github.com/sirupsen/logrus"
+)
+
+// prometheusBackend keeps metrics in collectors registered lazily on first use
+type prometheusBackend struct {
+	sync.Mutex
+	registerer prometheus.Registerer
+	gauges     map[string]*prometheus.GaugeVec
+	counters   map[string]*prometheus.CounterVec
+	histograms map[string]*prometheus.HistogramVec
+}
+
+func newPrometheusBackend(registerer prometheus.Registerer) *prometheusBackend {
+	return &prometheusBackend{
+		registerer: registerer,
+		gauges:     map[string]*prometheus.GaugeVec{},
+		counters:   map[string]*prometheus.CounterVec{},
+		histograms: map[string]*prometheus.HistogramVec{},
+	}
+}
+
+func (p *prometheusBackend) gauge(metric *Metric) *prometheus.GaugeVec {
+	p.Lock()
+	defer p.Unlock()
+	if g, ok := p.gauges[metric.Name]; ok {
+		return g
+	}
+	g := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: metric.Name, Help: metric.Help}, metric.Labels)
+	if err := p.registerer.Register(g); err != nil {
+		log.Errorf("[prometheus] Register %s failed %v", metric.Name, err)
+	}
+	p.gauges[metric.Name] = g
+	return g
+}
+
+func (p *prometheusBackend) counter(metric *Metric) *prometheus.CounterVec {
+	p.Lock()
+	defer p.Unlock()
+	if c, ok := p.counters[metric.Name]; ok {
+		return c
+	}
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{Name: metric.Name, Help: metric.Help}, metric.Labels)
+	if err := p.registerer.Register(c); err != nil {
+		log.Errorf("[prometheus] Register %s failed %v", metric.Name, err)
+	}
+	p.counters[metric.Name] = c
+	return c
+}
+
+func (p *prometheusBackend) histogram(metric *Metric) *prometheus.HistogramVec {
+	p.Lock()
+	defer p.Unlock()
+	if h, ok := p.histograms[metric.Name]; ok {
+		return h
+	}
+	h := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: metric.Name, Help: metric.Help}, metric.Labels)
+	if err := p.registerer.Register(h); err != nil {
+		log.Errorf("[prometheus] Register %s failed %v", metric.Name, err)
+	}
+	p.histograms[metric.Name] = h
+	return h
+}
+
+// Gauge .
+func (p *prometheusBackend) Gauge(metric *Metric, value float64, labels ...string) {
+	p.gauge(metric).WithLabelValues(labels...).Set(value)
+}
+
+// Count .
+func (p *prometheusBackend) Count(metric *Metric, n int, labels ...string) {
+	p.counter(metric).WithLabelValues(labels...).Add(float64(n))
+}
+
+// Timing .
+func (p *prometheusBackend) Timing(metric *Metric, d time.Duration, labels ...string) {
+	p.histogram(metric).WithLabelValues(labels...).Observe(d.Seconds())
+}
+
+// newPushgatewayBackend keeps metrics in its own registry and pushes them to pushgateway every interval
+func newPushgatewayBackend(url, instance string, interval time.Duration) *prometheusBackend {
+	registry := prometheus.NewRegistry()
+	pusher := push.New(url, "eru_core").Grouping("instance", instance).Gatherer(registry)
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			if err := pusher.Push(); err != nil {
+				log.Errorf("[pushgateway] Push metrics failed %v", err)
+			}
+		}
+	}()
+	return newPrometheusBackend(registry)
+}
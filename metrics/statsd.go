@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	statsdlib "github.com/CMGS/statsd"
+	"github.com/projecteru2/core/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// statsdBackend sends metrics to statsd with label values in bucket names
+type statsdBackend struct {
+	sync.Mutex
+	addr   string
+	client *statsdlib.Client
+	rates  map[string]float32
+}
+
+func newStatsdBackend(addr string, rates map[string]float32) *statsdBackend {
+	return &statsdBackend{addr: addr, rates: rates}
+}
+
+// sampleRate of counters and timings, 1 if not set
+func sampleRate(rates map[string]float32, metric *Metric) float32 {
+	if rate, ok := rates[metric.Name]; ok && rate > 0 && rate < 1 {
+		return rate
+	}
+	return 1
+}
+
+func sampledOut(rate float32) bool {
+	return rate < 1 && rand.Float32() > rate // nolint
+}
+
+// Lazy connect
+func (s *statsdBackend) checkConn() error {
+	s.Lock()
+	defer s.Unlock()
+	if s.client != nil {
+		return nil
+	}
+	var err error
+	// We needn't try to renew/reconnect because of only supporting UDP protocol now
+	// We should add an `errorCount` to reconnect when implementing TCP protocol
+	if s.client, err = statsdlib.New(s.addr, statsdlib.WithErrorHandler(func(err error) {
+		log.Errorf("[statsd] Sending statsd failed: %v", err)
+	})); err != nil {
+		log.Errorf("[statsd] Connect statsd failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func statsdBucket(format string, labels []string) string {
+	values := make([]interface{}, len(labels))
+	for i, label := range labels {
+		if label == "" {
+			label = "none"
+		}
+		values[i] = utils.CleanStatsdMetrics(label)
+	}
+	return fmt.Sprintf(format, values...)
+}
+
+// statsdBuckets returns the bucket of metric, along with its legacy one if any
+func statsdBuckets(metric *Metric, labels []string) []string {
+	buckets := []string{statsdBucket(metric.Statsd, labels)}
+	if metric.StatsdLegacy != "" {
+		buckets = append(buckets, statsdBucket(metric.StatsdLegacy, labels))
+	}
+	return buckets
+}
+
+// Gauge .
+func (s *statsdBackend) Gauge(metric *Metric, value float64, labels ...string) {
+	if err := s.checkConn(); err != nil {
+		return
+	}
+	for _, bucket := range statsdBuckets(metric, labels) {
+		s.client.Gauge(bucket, value)
+	}
+}
+
+// Count .
+func (s *statsdBackend) Count(metric *Metric, n int, labels ...string) {
+	if err := s.checkConn(); err != nil {
+		return
+	}
+	for _, bucket := range statsdBuckets(metric, labels) {
+		s.client.Count(bucket, n, sampleRate(s.rates, metric))
+	}
+}
+
+// Timing .
+func (s *statsdBackend) Timing(metric *Metric, d time.Duration, labels ...string) {
+	if err := s.checkConn(); err != nil {
+		return
+	}
+	for _, bucket := range statsdBuckets(metric, labels) {
+		s.client.Timing(bucket, int(d/time.Millisecond), sampleRate(s.rates, metric))
+	}
+}
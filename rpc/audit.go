@@ -0,0 +1,211 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/projecteru2/core/rpc/gen"
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// auditedMethods are mutating methods recorded to audit logs,
+// status reported by agents continuously isn't recorded
+var auditedMethods = map[string]bool{
+	"ConnectNetwork":           true,
+	"DisconnectNetwork":        true,
+	"AddPod":                   true,
+	"RemovePod":                true,
+	"SetPodPlacement":          true,
+	"SetPodHook":               true,
+	"SetPodLocale":             true,
+	"SetPodSysctlAllowlist":    true,
+	"SetPodDeviceAllowlist":    true,
+	"AddNode":                  true,
+	"RemoveNode":               true,
+	"SetNode":                  true,
+	"CordonNode":               true,
+	"DrainNode":                true,
+	"FailoverNode":             true,
+	"Reserve":                  true,
+	"ReleaseReservation":       true,
+	"SetScalePolicy":           true,
+	"RemoveScalePolicy":        true,
+	"SetCron":                  true,
+	"RemoveCron":               true,
+	"SetQuota":                 true,
+	"RemoveQuota":              true,
+	"RegisterWebhook":          true,
+	"DeregisterWebhook":        true,
+	"SetSecret":                true,
+	"RemoveSecret":             true,
+	"SetRegistryCredential":    true,
+	"RemoveRegistryCredential": true,
+	"SetConfigObject":          true,
+	"RemoveConfigObject":       true,
+	"ReloadConfig":             true,
+	"SetContainer":             true,
+	"AdoptContainer":           true,
+	"SetContainerMeta":         true,
+	"DeleteContainerMeta":      true,
+	"Send":                     true,
+	"BuildImage":               true,
+	"CommitContainer":          true,
+	"CacheImage":               true,
+	"RemoveImage":              true,
+	"CreateContainer":          true,
+	"CloneContainer":           true,
+	"ReplaceContainer":         true,
+	"Rebalance":                true,
+	"MigrateContainer":         true,
+	"MigrateContainerToNode":   true,
+	"CheckpointContainer":      true,
+	"RestoreContainer":         true,
+	"RemoveContainer":          true,
+	"DissociateContainer":      true,
+	"ControlContainer":         true,
+	"ExecuteContainer":         true,
+	"ReallocResource":          true,
+	"RunAndWait":               true,
+	"RunJob":                   true,
+}
+
+// AuditUnaryInterceptor records mutating unary calls to audit logs
+func (v *Vibranium) AuditUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	method := path.Base(info.FullMethod)
+	if !auditedMethods[method] {
+		return handler(ctx, req)
+	}
+	started := time.Now()
+	resp, err := handler(ctx, req)
+	v.recordAuditLog(ctx, method, req, started, err)
+	return resp, err
+}
+
+// AuditStreamInterceptor records mutating streaming calls to audit logs, with their first request
+func (v *Vibranium) AuditStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	method := path.Base(info.FullMethod)
+	if !auditedMethods[method] {
+		return handler(srv, stream)
+	}
+	started := time.Now()
+	s := &auditedStream{ServerStream: stream}
+	err := handler(srv, s)
+	v.recordAuditLog(stream.Context(), method, s.req, started, err)
+	return err
+}
+
+// auditedStream keeps the first request received
+type auditedStream struct {
+	grpc.ServerStream
+	req interface{}
+}
+
+func (s *auditedStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil && s.req == nil {
+		s.req = m
+	}
+	return err
+}
+
+func (v *Vibranium) recordAuditLog(ctx context.Context, method string, req interface{}, started time.Time, err error) {
+	auditLog := &types.AuditLog{
+		Method:   method,
+		User:     v.auditUser(ctx),
+		Appname:  auditAppname(req),
+		Options:  auditOptions(req),
+		Started:  started,
+		Finished: time.Now(),
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		auditLog.Peer = p.Addr.String()
+	}
+	if err != nil {
+		auditLog.Error = err.Error()
+	}
+	// caller may be gone, log is recorded anyway
+	rctx, cancel := context.WithTimeout(context.Background(), v.config.GlobalTimeout)
+	defer cancel()
+	if err := v.cluster.RecordAuditLog(rctx, auditLog); err != nil {
+		log.Errorf("[recordAuditLog] Record %s called by %s failed %v", method, auditLog.Peer, err)
+	}
+}
+
+// auditUser is the username authenticated by metadata, empty if auth is disabled
+func (v *Vibranium) auditUser(ctx context.Context) string {
+	username := v.config.Auth.Username
+	if username == "" {
+		return ""
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(username)) > 0 {
+		return username
+	}
+	return ""
+}
+
+func auditAppname(req interface{}) string {
+	var opts *pb.DeployOptions
+	switch r := req.(type) {
+	case *pb.DeployOptions:
+		opts = r
+	case *pb.ReplaceOptions:
+		opts = r.DeployOpt
+	case *pb.RunAndWaitOptions:
+		opts = r.DeployOptions
+	case *pb.JobOptions:
+		opts = r.DeployOptions
+	}
+	if opts == nil {
+		return ""
+	}
+	return opts.Name
+}
+
+// auditOptions encodes request in json, credentials of nodes, secrets of webhooks, values of secrets and passwords of registries are redacted
+func auditOptions(req interface{}) string {
+	if req == nil {
+		return ""
+	}
+	switch r := req.(type) {
+	case *pb.AddNodeOptions:
+		c := proto.Clone(r).(*pb.AddNodeOptions)
+		c.Ca, c.Cert, c.Key = redact(c.Ca), redact(c.Cert), redact(c.Key)
+		req = c
+	case *pb.SetNodeOptions:
+		c := proto.Clone(r).(*pb.SetNodeOptions)
+		c.Ca, c.Cert, c.Key = redact(c.Ca), redact(c.Cert), redact(c.Key)
+		req = c
+	case *pb.EventWebhook:
+		c := proto.Clone(r).(*pb.EventWebhook)
+		c.Secret = redact(c.Secret)
+		req = c
+	case *pb.SetSecretOptions:
+		c := proto.Clone(r).(*pb.SetSecretOptions)
+		c.Value = []byte(redact(string(c.Value)))
+		req = c
+	case *pb.SetRegistryCredentialOptions:
+		c := proto.Clone(r).(*pb.SetRegistryCredentialOptions)
+		c.Password = redact(c.Password)
+		req = c
+	}
+	b, err := json.Marshal(req)
+	if err != nil {
+		log.Errorf("[auditOptions] Encode %T failed %v", req, err)
+		return ""
+	}
+	return string(b)
+}
+
+func redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	clustermock "github.com/projecteru2/core/cluster/mocks"
+	pb "github.com/projecteru2/core/rpc/gen"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAuditUnaryInterceptor(t *testing.T) {
+	v := newVibranium()
+	v.config.Auth.Username = "admin"
+	cluster := v.cluster.(*clustermock.Cluster)
+	var recorded *types.AuditLog
+	cluster.On("RecordAuditLog", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(1).(*types.AuditLog)
+	}).Return(nil)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("admin", "password"))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, errors.New("node exists") }
+
+	// reads are not audited
+	_, err := v.AuditUnaryInterceptor(ctx, &pb.GetNodeOptions{}, &grpc.UnaryServerInfo{FullMethod: "/pb.CoreRPC/GetNode"}, handler)
+	assert.Error(t, err)
+	assert.Nil(t, recorded)
+
+	req := &pb.AddNodeOptions{Nodename: "node1", Key: "secret"}
+	_, err = v.AuditUnaryInterceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/pb.CoreRPC/AddNode"}, handler)
+	assert.Error(t, err)
+	assert.Equal(t, "AddNode", recorded.Method)
+	assert.Equal(t, "admin", recorded.User)
+	assert.Equal(t, "node exists", recorded.Error)
+	assert.Contains(t, recorded.Options, "node1")
+	assert.NotContains(t, recorded.Options, "secret")
+	assert.Equal(t, "secret", req.Key)
+	assert.False(t, recorded.Finished.Before(recorded.Started))
+
+	_, err = v.AuditUnaryInterceptor(ctx, &pb.SetSecretOptions{Name: "db", Value: []byte("password")}, &grpc.UnaryServerInfo{FullMethod: "/pb.CoreRPC/SetSecret"}, handler)
+	assert.Error(t, err)
+	assert.Equal(t, "SetSecret", recorded.Method)
+	assert.NotContains(t, recorded.Options, "password")
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *fakeServerStream) Context() context.Context { return context.Background() }
+
+func (s *fakeServerStream) RecvMsg(m interface{}) error { return nil }
+
+func TestAuditStreamInterceptor(t *testing.T) {
+	v := newVibranium()
+	cluster := v.cluster.(*clustermock.Cluster)
+	var recorded *types.AuditLog
+	cluster.On("RecordAuditLog", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(1).(*types.AuditLog)
+	}).Return(nil)
+
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return stream.RecvMsg(&pb.DeployOptions{Name: "app"})
+	}
+	err := v.AuditStreamInterceptor(nil, &fakeServerStream{}, &grpc.StreamServerInfo{FullMethod: "/pb.CoreRPC/CreateContainer"}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "CreateContainer", recorded.Method)
+	assert.Equal(t, "app", recorded.Appname)
+	assert.Empty(t, recorded.User)
+	assert.Empty(t, recorded.Error)
+}
@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/projecteru2/core/rpc/gen"
+	"github.com/projecteru2/core/types"
+)
+
+// messageSchemaVersion is schema_version of streamed container messages,
+// bump it when meaning of their fields changes
+const messageSchemaVersion = 1
+
+// errorCodes maps known errors to codes, the first matched one wins
+var errorCodes = []struct {
+	code pb.ErrorCode
+	errs []error
+}{
+	{pb.ErrorCode_CANCELED, []error{context.Canceled}},
+	{pb.ErrorCode_TIMEOUT, []error{context.DeadlineExceeded}},
+	{pb.ErrorCode_HOOK_FAILED, []error{types.ErrHookTimeout}},
+	{pb.ErrorCode_NOT_FOUND, []error{
+		types.ErrNodeNotExists, types.ErrContainerNotExists, types.ErrKeyNotExists, types.ErrNoImage,
+	}},
+	{pb.ErrorCode_ALREADY_EXISTS, []error{types.ErrNodeExist, types.ErrKeyExists}},
+	{pb.ErrorCode_INSUFFICIENT_RESOURCES, []error{
+		types.ErrInsufficientCPU, types.ErrInsufficientMEM, types.ErrInsufficientStorage,
+		types.ErrInsufficientVolume, types.ErrInsufficientHugepages, types.ErrInsufficientGPU, types.ErrInsufficientCap,
+		types.ErrInsufficientRes, types.ErrInsufficientNodes, types.ErrAlreadyFilled,
+		types.ErrZeroNodes, types.ErrPodNoNodes,
+	}},
+	{pb.ErrorCode_QUOTA_EXCEEDED, []error{types.ErrContainerMetaQuota, types.ErrQuotaExceeded}},
+	{pb.ErrorCode_NOT_SUPPORTED, []error{types.ErrNotSupport, types.ErrEngineNotImplemented, types.ErrBadDeployMethod, types.ErrNoMasterKey, types.ErrNoCheckpointDir}},
+	{pb.ErrorCode_INVALID_ARGUMENT, []error{
+		types.ErrInvalidRes, types.ErrNegativeMemory, types.ErrNegativeStorage, types.ErrNegativeQuota,
+		types.ErrBadContainerID, types.ErrBadMemory, types.ErrBadCPU, types.ErrBadCPUShares,
+		types.ErrBadStorage, types.ErrBadVolume, types.ErrBadHugepages, types.ErrBadGPU, types.ErrBadCount,
+		types.ErrBadEntrypoint, types.ErrBadPlacement, types.ErrBadQuota, types.ErrNoEntryInSpec, types.ErrNoDeployOpts,
+		types.ErrNoContainerIDs, types.ErrRunAndWaitCountOneWithStdin, types.ErrUnknownControlType,
+		types.ErrInvalidBind, types.ErrInvalidContainerName, types.ErrReservedLabel,
+		types.ErrAnnotationsTooLarge, types.ErrInvalidHealthCheck, types.ErrInvalidRestartPolicy, types.ErrInvalidUlimit,
+		types.ErrInvalidHook, types.ErrBadStopTimeout, types.ErrBadWaitCondition, types.ErrBadTimezone, types.ErrBadLocale,
+		types.ErrBadSysctl, types.ErrForbiddenSysctl, types.ErrBadDevice, types.ErrForbiddenDevice,
+		types.ErrBadContainerEvent, types.ErrBadContainerField,
+		types.ErrReservationExpired, types.ErrReservationMismatch, types.ErrReservationInUse,
+		types.ErrBadScalePolicy, types.ErrBadWebhook, types.ErrBadSecret,
+		types.ErrBadConfigObject, types.ErrBadCheckpoint, types.ErrNodeUnavailable, types.ErrBadRegistryCredential,
+	}},
+}
+
+// toRPCErrorCode tells kind of err for clients to branch on
+func toRPCErrorCode(err error) pb.ErrorCode {
+	if err == nil {
+		return pb.ErrorCode_OK
+	}
+	var hookErr *types.HookError
+	if errors.As(err, &hookErr) {
+		return pb.ErrorCode_HOOK_FAILED
+	}
+	for _, c := range errorCodes {
+		for _, e := range c.errs {
+			if errors.Is(err, e) {
+				return c.code
+			}
+		}
+	}
+	return pb.ErrorCode_UNKNOWN_ERROR
+}
@@ -0,0 +1,44 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/projecteru2/core/rpc/gen"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToRPCErrorCode(t *testing.T) {
+	assert.Equal(t, pb.ErrorCode_OK, toRPCErrorCode(nil))
+	assert.Equal(t, pb.ErrorCode_UNKNOWN_ERROR, toRPCErrorCode(errors.New("docker daemon is gone")))
+	assert.Equal(t, pb.ErrorCode_CANCELED, toRPCErrorCode(context.Canceled))
+	assert.Equal(t, pb.ErrorCode_NOT_FOUND, toRPCErrorCode(types.NewDetailedErr(types.ErrContainerNotExists, "id")))
+	assert.Equal(t, pb.ErrorCode_INSUFFICIENT_RESOURCES, toRPCErrorCode(types.NewDetailedErr(types.ErrInsufficientMEM, "node")))
+	assert.Equal(t, pb.ErrorCode_INVALID_ARGUMENT, toRPCErrorCode(types.ErrBadCount))
+	// a failed forced hook is a hook failure whatever its cmd returns
+	assert.Equal(t, pb.ErrorCode_HOOK_FAILED, toRPCErrorCode(&types.HookError{Stage: "s", Err: context.DeadlineExceeded}))
+}
+
+func TestStreamedMessageSchema(t *testing.T) {
+	err := types.NewDetailedErr(types.ErrInsufficientCap, "pod")
+	create := toRPCCreateContainerMessage(&types.CreateContainerMessage{Error: err})
+	assert.Equal(t, int32(messageSchemaVersion), create.SchemaVersion)
+	assert.Equal(t, pb.ErrorCode_INSUFFICIENT_RESOURCES, create.ErrorCode)
+	assert.Equal(t, err.Error(), create.Error)
+
+	control := toRPCControlContainerMessage(&types.ControlContainerMessage{})
+	assert.Equal(t, int32(messageSchemaVersion), control.SchemaVersion)
+	assert.Equal(t, pb.ErrorCode_OK, control.ErrorCode)
+
+	remove := toRPCRemoveContainerMessage(&types.RemoveContainerMessage{Error: types.ErrContainerNotExists})
+	assert.Equal(t, int32(messageSchemaVersion), remove.SchemaVersion)
+	assert.Equal(t, pb.ErrorCode_NOT_FOUND, remove.ErrorCode)
+	assert.Equal(t, types.ErrContainerNotExists.Error(), remove.Error)
+
+	job := toRPCJobMessage(&types.JobMessage{Finished: true, ExitCode: 3})
+	assert.Equal(t, int32(messageSchemaVersion), job.SchemaVersion)
+	assert.Equal(t, pb.ErrorCode_OK, job.ErrorCode)
+	assert.Equal(t, int32(3), job.ExitCode)
+}
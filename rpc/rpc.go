@@ -1,13 +1,12 @@
 package rpc
 
 import (
-	"archive/tar"
 	"bufio"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/projecteru2/core/cluster"
@@ -16,6 +15,7 @@ import (
 	"github.com/projecteru2/core/versioninfo"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
 )
 
 // Vibranium is implementations for grpc server interface
@@ -26,6 +26,10 @@ type Vibranium struct {
 	counter sync.WaitGroup
 	rpcch   chan struct{}
 	TaskNum int
+	streams int64
+
+	chunks    *chunkPool
+	copySlots chan struct{}
 }
 
 // Info show core info
@@ -39,6 +43,32 @@ func (v *Vibranium) Info(ctx context.Context, opts *pb.Empty) (*pb.CoreInfo, err
 	}, nil
 }
 
+// DebugInfo show resource usage of core
+func (v *Vibranium) DebugInfo(ctx context.Context, opts *pb.Empty) (*pb.CoreDebugInfo, error) {
+	info, err := v.Debug(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toRPCDebugInfo(info), nil
+}
+
+// Debug gets resource usage of core from cluster, with open streams counted by StreamCounter
+func (v *Vibranium) Debug(ctx context.Context) (*types.DebugInfo, error) {
+	info, err := v.cluster.DebugInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info.Streams = atomic.LoadInt64(&v.streams)
+	return info, nil
+}
+
+// StreamCounter counts open streams
+func (v *Vibranium) StreamCounter(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	atomic.AddInt64(&v.streams, 1)
+	defer atomic.AddInt64(&v.streams, -1)
+	return handler(srv, stream)
+}
+
 // WatchServiceStatus pushes sibling services
 func (v *Vibranium) WatchServiceStatus(_ *pb.Empty, stream pb.CoreRPC_WatchServiceStatusServer) (err error) {
 	ch, err := v.cluster.WatchServiceStatus(stream.Context())
@@ -109,6 +139,51 @@ func (v *Vibranium) GetPod(ctx context.Context, opts *pb.GetPodOptions) (*pb.Pod
 	return toRPCPod(p), nil
 }
 
+// SetPodPlacement set placement policy of a pod
+func (v *Vibranium) SetPodPlacement(ctx context.Context, opts *pb.SetPodPlacementOptions) (*pb.Pod, error) {
+	p, err := v.cluster.SetPodPlacement(ctx, opts.Name, opts.Placement)
+	if err != nil {
+		return nil, err
+	}
+	return toRPCPod(p), nil
+}
+
+// SetPodHook set default hooks of a pod
+func (v *Vibranium) SetPodHook(ctx context.Context, opts *pb.SetPodHookOptions) (*pb.Pod, error) {
+	p, err := v.cluster.SetPodHook(ctx, opts.Name, toCoreHook(opts.Hook))
+	if err != nil {
+		return nil, err
+	}
+	return toRPCPod(p), nil
+}
+
+// SetPodLocale set default timezone and locale of a pod
+func (v *Vibranium) SetPodLocale(ctx context.Context, opts *pb.SetPodLocaleOptions) (*pb.Pod, error) {
+	p, err := v.cluster.SetPodLocale(ctx, opts.Name, opts.Timezone, opts.Locale)
+	if err != nil {
+		return nil, err
+	}
+	return toRPCPod(p), nil
+}
+
+// SetPodSysctlAllowlist set sysctls containers in a pod may set
+func (v *Vibranium) SetPodSysctlAllowlist(ctx context.Context, opts *pb.SetPodSysctlAllowlistOptions) (*pb.Pod, error) {
+	p, err := v.cluster.SetPodSysctlAllowlist(ctx, opts.Name, opts.Allowlist)
+	if err != nil {
+		return nil, err
+	}
+	return toRPCPod(p), nil
+}
+
+// SetPodDeviceAllowlist set host devices containers in a pod may be granted
+func (v *Vibranium) SetPodDeviceAllowlist(ctx context.Context, opts *pb.SetPodDeviceAllowlistOptions) (*pb.Pod, error) {
+	p, err := v.cluster.SetPodDeviceAllowlist(ctx, opts.Name, opts.Allowlist)
+	if err != nil {
+		return nil, err
+	}
+	return toRPCPod(p), nil
+}
+
 // ListPods returns a list of pods
 func (v *Vibranium) ListPods(ctx context.Context, _ *pb.Empty) (*pb.Pods, error) {
 	ps, err := v.cluster.ListPods(ctx)
@@ -124,6 +199,29 @@ func (v *Vibranium) ListPods(ctx context.Context, _ *pb.Empty) (*pb.Pods, error)
 	return &pb.Pods{Pods: pods}, nil
 }
 
+// SetQuota save a quota of pod or app, deploys beyond it are rejected
+func (v *Vibranium) SetQuota(ctx context.Context, opts *pb.Quota) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.SetQuota(ctx, toCoreQuota(opts))
+}
+
+// ListQuotas list all quotas with their usage
+func (v *Vibranium) ListQuotas(ctx context.Context, _ *pb.Empty) (*pb.Quotas, error) {
+	qs, err := v.cluster.ListQuotas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	quotas := []*pb.Quota{}
+	for _, q := range qs {
+		quotas = append(quotas, toRPCQuota(q))
+	}
+	return &pb.Quotas{Quotas: quotas}, nil
+}
+
+// RemoveQuota remove a quota
+func (v *Vibranium) RemoveQuota(ctx context.Context, opts *pb.RemoveQuotaOptions) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.RemoveQuota(ctx, opts.Scope, opts.Name)
+}
+
 // GetPodResource get pod nodes resource usage
 func (v *Vibranium) GetPodResource(ctx context.Context, opts *pb.GetPodOptions) (*pb.PodResource, error) {
 	r, err := v.cluster.PodResource(ctx, opts.Name)
@@ -187,6 +285,56 @@ func (v *Vibranium) SetNode(ctx context.Context, opts *pb.SetNodeOptions) (*pb.N
 	return toRPCNode(ctx, n), nil
 }
 
+// CordonNode marks node unschedulable or not
+func (v *Vibranium) CordonNode(ctx context.Context, opts *pb.CordonNodeOptions) (*pb.Node, error) {
+	n, err := v.cluster.CordonNode(ctx, opts.Nodename, opts.Cordon)
+	if err != nil {
+		return nil, err
+	}
+	return toRPCNode(ctx, n), nil
+}
+
+// DrainNode moves or removes all containers of a node
+func (v *Vibranium) DrainNode(opts *pb.DrainNodeOptions, stream pb.CoreRPC_DrainNodeServer) error {
+	v.taskAdd("DrainNode", true)
+	defer v.taskDone("DrainNode", true)
+
+	ch, err := v.cluster.DrainNode(stream.Context(), toCoreDrainNodeOptions(opts))
+	if err != nil {
+		return err
+	}
+
+	for m := range ch {
+		if err = stream.Send(toRPCMigrateMessage(m)); err != nil {
+			v.logUnsentMessages("DrainNode", m)
+		}
+	}
+	return nil
+}
+
+// FailoverNode deploys containers of a dead node again on other nodes
+func (v *Vibranium) FailoverNode(opts *pb.FailoverNodeOptions, stream pb.CoreRPC_FailoverNodeServer) error {
+	v.taskAdd("FailoverNode", true)
+	defer v.taskDone("FailoverNode", true)
+
+	ch, err := v.cluster.FailoverNode(stream.Context(), opts.Nodename)
+	if err != nil {
+		return err
+	}
+
+	for m := range ch {
+		if err = stream.Send(toRPCMigrateMessage(m)); err != nil {
+			v.logUnsentMessages("FailoverNode", m)
+		}
+	}
+	return nil
+}
+
+// NodeHeartbeat keeps node alive
+func (v *Vibranium) NodeHeartbeat(ctx context.Context, opts *pb.NodeHeartbeatOptions) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.NodeHeartbeat(ctx, opts.Nodename, time.Duration(opts.Ttl)*time.Second)
+}
+
 // GetNodeResource check node resource
 func (v *Vibranium) GetNodeResource(ctx context.Context, opts *pb.GetNodeResourceOptions) (*pb.NodeResource, error) {
 	nr, err := v.cluster.NodeResource(ctx, opts.GetOpts().Nodename, opts.Fix)
@@ -197,6 +345,275 @@ func (v *Vibranium) GetNodeResource(ctx context.Context, opts *pb.GetNodeResourc
 	return toRPCNodeResource(nr), nil
 }
 
+// CalculateCapacity calculates capacity of each candidate node
+func (v *Vibranium) CalculateCapacity(ctx context.Context, opts *pb.DeployOptions) (*pb.CapacityMessage, error) {
+	deployOpts, err := toCoreDeployOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := v.cluster.CalculateCapacity(ctx, deployOpts)
+	if err != nil {
+		return nil, err
+	}
+	return toRPCCapacityMessage(msg), nil
+}
+
+// Reserve holds resources for a future deploy
+func (v *Vibranium) Reserve(ctx context.Context, opts *pb.ReserveOptions) (*pb.Reservation, error) {
+	reserveOpts, err := toCoreReserveOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	r, err := v.cluster.Reserve(ctx, reserveOpts)
+	if err != nil {
+		return nil, err
+	}
+	return toRPCReservation(r), nil
+}
+
+// ListReservations list reservations of a pod
+func (v *Vibranium) ListReservations(ctx context.Context, opts *pb.GetPodOptions) (*pb.Reservations, error) {
+	rs, err := v.cluster.ListReservations(ctx, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+	reservations := []*pb.Reservation{}
+	for _, r := range rs {
+		reservations = append(reservations, toRPCReservation(r))
+	}
+	return &pb.Reservations{Reservations: reservations}, nil
+}
+
+// ReleaseReservation release resources held by a reservation
+func (v *Vibranium) ReleaseReservation(ctx context.Context, opts *pb.ReleaseReservationOptions) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.ReleaseReservation(ctx, opts.Id)
+}
+
+// SetScalePolicy save scale policy of an app entrypoint
+func (v *Vibranium) SetScalePolicy(ctx context.Context, opts *pb.ScalePolicy) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.SetScalePolicy(ctx, toCoreScalePolicy(opts))
+}
+
+// ListScalePolicies list all scale policies
+func (v *Vibranium) ListScalePolicies(ctx context.Context, _ *pb.Empty) (*pb.ScalePolicies, error) {
+	ps, err := v.cluster.ListScalePolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policies := []*pb.ScalePolicy{}
+	for _, p := range ps {
+		policies = append(policies, toRPCScalePolicy(p))
+	}
+	return &pb.ScalePolicies{Policies: policies}, nil
+}
+
+// RemoveScalePolicy remove scale policy of an app entrypoint
+func (v *Vibranium) RemoveScalePolicy(ctx context.Context, opts *pb.RemoveScalePolicyOptions) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.RemoveScalePolicy(ctx, opts.Appname, opts.Entrypoint)
+}
+
+// SetCron save a cron, it's fired by the leader core
+func (v *Vibranium) SetCron(ctx context.Context, opts *pb.Cron) (*pb.Empty, error) {
+	cron, err := toCoreCron(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Empty{}, v.cluster.SetCron(ctx, cron)
+}
+
+// ListCrons list all crons
+func (v *Vibranium) ListCrons(ctx context.Context, _ *pb.Empty) (*pb.Crons, error) {
+	cs, err := v.cluster.ListCrons(ctx)
+	if err != nil {
+		return nil, err
+	}
+	crons := []*pb.Cron{}
+	for _, c := range cs {
+		crons = append(crons, toRPCCron(c))
+	}
+	return &pb.Crons{Crons: crons}, nil
+}
+
+// RemoveCron remove a cron
+func (v *Vibranium) RemoveCron(ctx context.Context, opts *pb.RemoveCronOptions) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.RemoveCron(ctx, opts.Name)
+}
+
+// WatchCronRuns watch executions of crons
+func (v *Vibranium) WatchCronRuns(opts *pb.WatchCronRunsOptions, stream pb.CoreRPC_WatchCronRunsServer) error {
+	ch, err := v.cluster.WatchCronRuns(stream.Context(), opts.Name)
+	if err != nil {
+		return err
+	}
+	for r := range ch {
+		if err = stream.Send(toRPCCronRun(r)); err != nil {
+			v.logUnsentMessages("WatchCronRuns", r)
+			return err
+		}
+	}
+	return nil
+}
+
+// WatchEvents watch cluster events published by all cores
+func (v *Vibranium) WatchEvents(opts *pb.WatchEventsOptions, stream pb.CoreRPC_WatchEventsServer) error {
+	ch, err := v.cluster.WatchEvents(stream.Context(), toCoreEventFilter(opts))
+	if err != nil {
+		return err
+	}
+	for e := range ch {
+		if err = stream.Send(toRPCEvent(e)); err != nil {
+			v.logUnsentMessages("WatchEvents", e)
+			return err
+		}
+	}
+	return nil
+}
+
+// ListAuditLogs lists audit logs of mutating calls, latest first
+func (v *Vibranium) ListAuditLogs(ctx context.Context, opts *pb.ListAuditLogsOptions) (*pb.AuditLogs, error) {
+	logs, err := v.cluster.ListAuditLogs(ctx, toCoreAuditFilter(opts))
+	if err != nil {
+		return nil, err
+	}
+	r := &pb.AuditLogs{}
+	for _, l := range logs {
+		r.Logs = append(r.Logs, toRPCAuditLog(l))
+	}
+	return r, nil
+}
+
+// RegisterWebhook register a webhook lifecycle events of containers are posted to
+func (v *Vibranium) RegisterWebhook(ctx context.Context, opts *pb.EventWebhook) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.RegisterWebhook(ctx, toCoreEventWebhook(opts))
+}
+
+// DeregisterWebhook deregister a webhook by name
+func (v *Vibranium) DeregisterWebhook(ctx context.Context, opts *pb.DeregisterWebhookOptions) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.DeregisterWebhook(ctx, opts.Name)
+}
+
+// ListWebhooks list all webhooks, secrets are not shown
+func (v *Vibranium) ListWebhooks(ctx context.Context, _ *pb.Empty) (*pb.EventWebhooks, error) {
+	webhooks, err := v.cluster.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r := &pb.EventWebhooks{}
+	for _, w := range webhooks {
+		r.Webhooks = append(r.Webhooks, toRPCEventWebhook(w))
+	}
+	return r, nil
+}
+
+// SetSecret encrypt and save a secret
+func (v *Vibranium) SetSecret(ctx context.Context, opts *pb.SetSecretOptions) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.SetSecret(ctx, opts.Name, opts.Value)
+}
+
+// ListSecrets list names of secrets
+func (v *Vibranium) ListSecrets(ctx context.Context, _ *pb.Empty) (*pb.Secrets, error) {
+	names, err := v.cluster.ListSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Secrets{Names: names}, nil
+}
+
+// RemoveSecret remove a secret by name
+func (v *Vibranium) RemoveSecret(ctx context.Context, opts *pb.RemoveSecretOptions) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.RemoveSecret(ctx, opts.Name)
+}
+
+// SetRegistryCredential add or rotate credential of a registry
+func (v *Vibranium) SetRegistryCredential(ctx context.Context, opts *pb.SetRegistryCredentialOptions) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.SetRegistryCredential(ctx, toCoreRegistryCredentialOptions(opts))
+}
+
+// ListRegistryCredentials list credentials of registries without passwords
+func (v *Vibranium) ListRegistryCredentials(ctx context.Context, _ *pb.Empty) (*pb.RegistryCredentials, error) {
+	credentials, err := v.cluster.ListRegistryCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toRPCRegistryCredentials(credentials), nil
+}
+
+// RemoveRegistryCredential remove credential of a registry
+func (v *Vibranium) RemoveRegistryCredential(ctx context.Context, opts *pb.RemoveRegistryCredentialOptions) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.RemoveRegistryCredential(ctx, opts.Registry, opts.Podname)
+}
+
+// SetConfigObject save a config object
+func (v *Vibranium) SetConfigObject(ctx context.Context, opts *pb.ConfigObject) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.SetConfigObject(ctx, &types.ConfigObject{Name: opts.Name, Content: opts.Content})
+}
+
+// ListConfigObjects list all config objects
+func (v *Vibranium) ListConfigObjects(ctx context.Context, _ *pb.Empty) (*pb.ConfigObjects, error) {
+	objects, err := v.cluster.ListConfigObjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r := &pb.ConfigObjects{}
+	for _, o := range objects {
+		r.Objects = append(r.Objects, &pb.ConfigObject{Name: o.Name, Content: o.Content})
+	}
+	return r, nil
+}
+
+// RemoveConfigObject remove a config object by name
+func (v *Vibranium) RemoveConfigObject(ctx context.Context, opts *pb.RemoveConfigObjectOptions) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.RemoveConfigObject(ctx, opts.Name)
+}
+
+// ReloadConfig render a config object again and send it into running containers mounting it
+func (v *Vibranium) ReloadConfig(opts *pb.ReloadConfigOptions, stream pb.CoreRPC_ReloadConfigServer) error {
+	v.taskAdd("ReloadConfig", true)
+	defer v.taskDone("ReloadConfig", true)
+
+	ch, err := v.cluster.ReloadConfig(stream.Context(), opts.Name, opts.Appname)
+	if err != nil {
+		return err
+	}
+	for m := range ch {
+		msg := &pb.SendMessage{Id: m.ID, Path: m.Path}
+		if m.Error != nil {
+			msg.Error = m.Error.Error()
+		}
+		if err := stream.Send(msg); err != nil {
+			v.logUnsentMessages("ReloadConfig", m)
+		}
+	}
+	return nil
+}
+
+// CapacityReport show total and allocated resources of pods
+func (v *Vibranium) CapacityReport(ctx context.Context, opts *pb.CapacityReportOptions) (*pb.PodCapacityReports, error) {
+	reports, err := v.cluster.CapacityReport(ctx, opts.Podname)
+	if err != nil {
+		return nil, err
+	}
+	return toRPCCapacityReport(reports), nil
+}
+
+// FragmentationReport show nodes with fragmented resources
+func (v *Vibranium) FragmentationReport(ctx context.Context, opts *pb.GetPodOptions) (*pb.NodeFragmentations, error) {
+	fs, err := v.cluster.FragmentationReport(ctx, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+	return toRPCNodeFragmentations(fs), nil
+}
+
+// ForecastCapacity predicts resources exhaustion of a pod
+func (v *Vibranium) ForecastCapacity(ctx context.Context, opts *pb.ForecastCapacityOptions) (*pb.CapacityForecast, error) {
+	f, err := v.cluster.ForecastCapacity(ctx, opts.Podname, time.Duration(opts.Window)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return toRPCCapacityForecast(f), nil
+}
+
 // GetContainer get a container
 // More information will be shown
 func (v *Vibranium) GetContainer(ctx context.Context, id *pb.ContainerID) (*pb.Container, error) {
@@ -251,6 +668,49 @@ func (v *Vibranium) ListNodeContainers(ctx context.Context, opts *pb.GetNodeOpti
 	return toRPCContainers(ctx, containers, nil), nil
 }
 
+// SetContainer update container labels
+func (v *Vibranium) SetContainer(ctx context.Context, opts *pb.SetContainerOptions) (*pb.Container, error) {
+	v.taskAdd("SetContainer", true)
+	defer v.taskDone("SetContainer", true)
+
+	container, err := v.cluster.SetContainer(ctx, toCoreSetContainerOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	return toRPCContainer(ctx, container)
+}
+
+// AdoptContainer register an existing engine container into eru
+func (v *Vibranium) AdoptContainer(ctx context.Context, opts *pb.AdoptContainerOptions) (*pb.Container, error) {
+	v.taskAdd("AdoptContainer", true)
+	defer v.taskDone("AdoptContainer", true)
+
+	container, err := v.cluster.AdoptContainer(ctx, toCoreAdoptOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	return toRPCContainer(ctx, container)
+}
+
+// GetContainerMeta get a meta value bound to container
+func (v *Vibranium) GetContainerMeta(ctx context.Context, opts *pb.ContainerMetaOptions) (*pb.ContainerMeta, error) {
+	value, err := v.cluster.GetContainerMeta(ctx, opts.Id, opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ContainerMeta{Id: opts.Id, Key: opts.Key, Value: value}, nil
+}
+
+// SetContainerMeta set a meta value bound to container
+func (v *Vibranium) SetContainerMeta(ctx context.Context, opts *pb.ContainerMeta) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.SetContainerMeta(ctx, opts.Id, opts.Key, opts.Value)
+}
+
+// DeleteContainerMeta delete a meta value bound to container
+func (v *Vibranium) DeleteContainerMeta(ctx context.Context, opts *pb.ContainerMetaOptions) (*pb.Empty, error) {
+	return &pb.Empty{}, v.cluster.DeleteContainerMeta(ctx, opts.Id, opts.Key)
+}
+
 // GetContainersStatus get containers status
 func (v *Vibranium) GetContainersStatus(ctx context.Context, opts *pb.ContainerIDs) (*pb.ContainersStatus, error) {
 	v.taskAdd("GetContainersStatus", false)
@@ -284,6 +744,14 @@ func (v *Vibranium) SetContainersStatus(ctx context.Context, opts *pb.SetContain
 	return toRPCContainersStatus(status), err
 }
 
+// ReportContainerEvents records oom kills and unexpected exits of containers seen by agent
+func (v *Vibranium) ReportContainerEvents(ctx context.Context, opts *pb.ContainerEventsOptions) (*pb.Empty, error) {
+	v.taskAdd("ReportContainerEvents", false)
+	defer v.taskDone("ReportContainerEvents", false)
+
+	return &pb.Empty{}, v.cluster.RecordContainerEvents(ctx, toCoreContainerEvents(opts.Events))
+}
+
 // ContainerStatusStream watch and show deployed status
 func (v *Vibranium) ContainerStatusStream(opts *pb.ContainerStatusStreamOptions, stream pb.CoreRPC_ContainerStatusStreamServer) error {
 	log.Infof("[rpc] ContainerStatusStream start %s", opts.Appname)
@@ -330,67 +798,84 @@ func (v *Vibranium) Copy(opts *pb.CopyOptions, stream pb.CoreRPC_CopyServer) err
 		return err
 	}
 
-	//4K buffer
-	bsize := 4 * 1024
-
 	for m := range ch {
-		var copyError string
 		if m.Error != nil {
-			copyError = fmt.Sprintf("%v", m.Error)
 			if err := stream.Send(&pb.CopyMessage{
 				Id:     m.ID,
 				Status: m.Status,
 				Name:   m.Name,
 				Path:   m.Path,
-				Error:  copyError,
+				Error:  fmt.Sprintf("%v", m.Error),
 				Data:   []byte{},
 			}); err != nil {
 				v.logUnsentMessages("Copy", m)
 			}
 			continue
 		}
+		if err := v.copyFile(stream, m); err != nil {
+			log.Errorf("[Copy] Error during copying %s from %s: %v", m.Path, m.ID, err)
+			if err := stream.Send(&pb.CopyMessage{
+				Id:     m.ID,
+				Status: m.Status,
+				Name:   m.Name,
+				Path:   m.Path,
+				Error:  err.Error(),
+				Data:   []byte{},
+			}); err != nil {
+				v.logUnsentMessages("Copy", m)
+			}
+		}
+	}
+	return nil
+}
+
+// copyFile sends a tarball of the file in m by chunks from the pool,
+// the file is spooled to disk first as size of it may be unknown, so memory usage doesn't grow with file size
+func (v *Vibranium) copyFile(stream pb.CoreRPC_CopyServer, m *types.CopyMessage) error {
+	defer m.Data.Close()
+	if err := acquireSlot(stream.Context(), v.copySlots); err != nil {
+		return err
+	}
+	defer releaseSlot(v.copySlots)
 
-		r, w := io.Pipe()
-		go func() {
-			defer w.Close()
-			defer m.Data.Close()
+	buf := v.chunks.get()
+	defer v.chunks.put(buf)
+	f, err := spool(m.Data, buf, v.config.GRPCConfig.MaxCopySize)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
 
-			tw := tar.NewWriter(w)
-			bs, err := ioutil.ReadAll(m.Data)
-			if err != nil {
-				log.Errorf("[Copy] Error during extracting copy data: %v", err)
-			}
-			header := &tar.Header{Name: m.Name, Mode: 0644, Size: int64(len(bs))}
-			if err := tw.WriteHeader(header); err != nil {
-				log.Errorf("[Copy] Error during writing tarball header: %v", err)
-			}
-			if _, err := tw.Write(bs); err != nil {
-				log.Errorf("[Copy] Error during writing tarball content: %v", err)
-			}
-		}()
-
-		for {
-			p := make([]byte, bsize)
-			n, err := r.Read(p)
-			if err != nil {
-				if err != io.EOF {
-					log.Errorf("[Copy] Error during buffer resp: %v", err)
-				}
-				break
-			}
-			if err = stream.Send(&pb.CopyMessage{
+	r, w := io.Pipe()
+	go func() {
+		wbuf := v.chunks.get()
+		defer v.chunks.put(wbuf)
+		w.CloseWithError(writeTar(w, m.Name, f, wbuf))
+	}()
+	defer r.Close()
+
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			// grpc has serialized data before Send returns, buf is free to be reused then
+			if err := stream.Send(&pb.CopyMessage{
 				Id:     m.ID,
 				Status: m.Status,
 				Name:   m.Name,
 				Path:   m.Path,
-				Error:  copyError,
-				Data:   p[:n],
+				Data:   buf[:n],
 			}); err != nil {
 				v.logUnsentMessages("Copy", m)
+				return nil
 			}
 		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 	}
-	return nil
 }
 
 // Send send files to some contaienrs
@@ -450,6 +935,25 @@ func (v *Vibranium) BuildImage(opts *pb.BuildImageOptions, stream pb.CoreRPC_Bui
 	return err
 }
 
+// CommitContainer commit container to image
+func (v *Vibranium) CommitContainer(opts *pb.CommitContainerOptions, stream pb.CoreRPC_CommitContainerServer) error {
+	v.taskAdd("CommitContainer", true)
+	defer v.taskDone("CommitContainer", true)
+
+	ch, err := v.cluster.CommitContainer(stream.Context(), toCoreCommitOptions(opts))
+	if err != nil {
+		log.Errorf("[CommitContainer] commit container error %+v", err)
+		return err
+	}
+
+	for m := range ch {
+		if err = stream.Send(toRPCBuildImageMessage(m)); err != nil {
+			v.logUnsentMessages("CommitContainer", m)
+		}
+	}
+	return err
+}
+
 // CacheImage cache image
 func (v *Vibranium) CacheImage(opts *pb.CacheImageOptions, stream pb.CoreRPC_CacheImageServer) error {
 	v.taskAdd("CacheImage", true)
@@ -509,6 +1013,42 @@ func (v *Vibranium) CreateContainer(opts *pb.DeployOptions, stream pb.CoreRPC_Cr
 	return nil
 }
 
+// CloneContainer create a container with options of an existing one
+func (v *Vibranium) CloneContainer(opts *pb.CloneContainerOptions, stream pb.CoreRPC_CloneContainerServer) error {
+	v.taskAdd("CloneContainer", true)
+	defer v.taskDone("CloneContainer", true)
+
+	ch, err := v.cluster.CloneContainer(stream.Context(), toCoreCloneOptions(opts))
+	if err != nil {
+		return err
+	}
+
+	for m := range ch {
+		if err = stream.Send(toRPCCreateContainerMessage(m)); err != nil {
+			v.logUnsentMessages("CloneContainer", m)
+		}
+	}
+	return nil
+}
+
+// WaitContainer wait containers exited or healthy
+func (v *Vibranium) WaitContainer(opts *pb.WaitContainerOptions, stream pb.CoreRPC_WaitContainerServer) error {
+	v.taskAdd("WaitContainer", true)
+	defer v.taskDone("WaitContainer", true)
+
+	ch, err := v.cluster.WaitContainer(stream.Context(), toCoreWaitOptions(opts))
+	if err != nil {
+		return err
+	}
+
+	for m := range ch {
+		if err = stream.Send(toRPCWaitContainerMessage(m)); err != nil {
+			v.logUnsentMessages("WaitContainer", m)
+		}
+	}
+	return nil
+}
+
 // ReplaceContainer replace containers
 func (v *Vibranium) ReplaceContainer(opts *pb.ReplaceOptions, stream pb.CoreRPC_ReplaceContainerServer) error {
 	v.taskAdd("ReplaceContainer", true)
@@ -532,6 +1072,80 @@ func (v *Vibranium) ReplaceContainer(opts *pb.ReplaceOptions, stream pb.CoreRPC_
 	return nil
 }
 
+// Rebalance move containers between nodes
+func (v *Vibranium) Rebalance(opts *pb.RebalanceOptions, stream pb.CoreRPC_RebalanceServer) error {
+	v.taskAdd("Rebalance", true)
+	defer v.taskDone("Rebalance", true)
+
+	rebalanceOpts, err := toCoreRebalanceOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	ch, err := v.cluster.Rebalance(stream.Context(), rebalanceOpts)
+	if err != nil {
+		return err
+	}
+
+	for m := range ch {
+		if err = stream.Send(toRPCRebalanceMessage(m)); err != nil {
+			v.logUnsentMessages("Rebalance", m)
+		}
+	}
+	return nil
+}
+
+// MigrateContainer move containers to another pod
+func (v *Vibranium) MigrateContainer(opts *pb.MigrateContainerOptions, stream pb.CoreRPC_MigrateContainerServer) error {
+	v.taskAdd("MigrateContainer", true)
+	defer v.taskDone("MigrateContainer", true)
+
+	migrateOpts, err := toCoreMigrateOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	ch, err := v.cluster.MigrateContainer(stream.Context(), migrateOpts)
+	if err != nil {
+		return err
+	}
+
+	for m := range ch {
+		if err = stream.Send(toRPCMigrateMessage(m)); err != nil {
+			v.logUnsentMessages("MigrateContainer", m)
+		}
+	}
+	return nil
+}
+
+// MigrateContainerToNode recreate a container on another node
+func (v *Vibranium) MigrateContainerToNode(ctx context.Context, opts *pb.MigrateContainerToNodeOptions) (*pb.MigrateContainerMessage, error) {
+	v.taskAdd("MigrateContainerToNode", true)
+	defer v.taskDone("MigrateContainerToNode", true)
+
+	m, err := v.cluster.MigrateContainerToNode(ctx, opts.Id, opts.Nodename, time.Duration(opts.Timeout)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return toRPCMigrateMessage(m), nil
+}
+
+// CheckpointContainer checkpoints a running container
+func (v *Vibranium) CheckpointContainer(ctx context.Context, opts *pb.CheckpointContainerOptions) (*pb.Empty, error) {
+	v.taskAdd("CheckpointContainer", true)
+	defer v.taskDone("CheckpointContainer", true)
+
+	return &pb.Empty{}, v.cluster.CheckpointContainer(ctx, &types.CheckpointOptions{ID: opts.Id, Name: opts.Name, Exit: opts.Exit})
+}
+
+// RestoreContainer restores a stopped container from checkpoint
+func (v *Vibranium) RestoreContainer(ctx context.Context, opts *pb.RestoreContainerOptions) (*pb.Empty, error) {
+	v.taskAdd("RestoreContainer", true)
+	defer v.taskDone("RestoreContainer", true)
+
+	return &pb.Empty{}, v.cluster.RestoreContainer(ctx, &types.RestoreOptions{ID: opts.Id, Name: opts.Name, From: opts.From})
+}
+
 // RemoveContainer remove containers
 func (v *Vibranium) RemoveContainer(opts *pb.RemoveContainerOptions, stream pb.CoreRPC_RemoveContainerServer) error {
 	v.taskAdd("RemoveContainer", true)
@@ -544,7 +1158,8 @@ func (v *Vibranium) RemoveContainer(opts *pb.RemoveContainerOptions, stream pb.C
 	if len(ids) == 0 {
 		return types.ErrNoContainerIDs
 	}
-	ch, err := v.cluster.RemoveContainer(stream.Context(), ids, force, step)
+	stopTimeout := time.Duration(opts.GetStopTimeout()) * time.Second
+	ch, err := v.cluster.RemoveContainer(stream.Context(), ids, force, step, stopTimeout)
 	if err != nil {
 		return err
 	}
@@ -582,6 +1197,19 @@ func (v *Vibranium) DissociateContainer(opts *pb.DissociateContainerOptions, str
 	return err
 }
 
+// ValidateHook dry-run checks hooks against a running container
+func (v *Vibranium) ValidateHook(ctx context.Context, opts *pb.ValidateHookOptions) (*pb.HookResults, error) {
+	results, err := v.cluster.ValidateHook(ctx, &types.ValidateHookOptions{ID: opts.Id, Hook: toCoreHook(opts.Hook)})
+	if err != nil {
+		return nil, err
+	}
+	msg := &pb.HookResults{}
+	for _, result := range results {
+		msg.Results = append(msg.Results, toRPCHookResult(result))
+	}
+	return msg, nil
+}
+
 // ControlContainer control containers
 func (v *Vibranium) ControlContainer(opts *pb.ControlContainerOptions, stream pb.CoreRPC_ControlContainerServer) error {
 	v.taskAdd("ControlContainer", true)
@@ -595,7 +1223,8 @@ func (v *Vibranium) ControlContainer(opts *pb.ControlContainerOptions, stream pb
 		return types.ErrNoContainerIDs
 	}
 
-	ch, err := v.cluster.ControlContainer(stream.Context(), ids, t, force)
+	stopTimeout := time.Duration(opts.GetStopTimeout()) * time.Second
+	ch, err := v.cluster.ControlContainer(stream.Context(), ids, t, force, opts.GetStreamHook(), stopTimeout)
 	if err != nil {
 		return err
 	}
@@ -663,7 +1292,7 @@ func (v *Vibranium) ReallocResource(opts *pb.ReallocOptions, stream pb.CoreRPC_R
 	//这里不能让 client 打断 remove
 	ch, err := v.cluster.ReallocResource(
 		stream.Context(),
-		&types.ReallocOptions{IDs: ids, CPU: opts.Cpu, BindCPU: bindCPU, Memory: opts.Memory, MemoryLimit: memoryLimit, Volumes: vbs},
+		&types.ReallocOptions{IDs: ids, CPU: opts.Cpu, CPUShares: opts.CpuShares, BindCPU: bindCPU, Memory: opts.Memory, MemoryLimit: memoryLimit, Volumes: vbs},
 	)
 	if err != nil {
 		return err
@@ -793,11 +1422,45 @@ func (v *Vibranium) RunAndWait(stream pb.CoreRPC_RunAndWaitServer) error {
 	return nil
 }
 
+// RunJob runs containers to completion and streams their output and exit codes
+func (v *Vibranium) RunJob(opts *pb.JobOptions, stream pb.CoreRPC_RunJobServer) error {
+	v.taskAdd("RunJob", true)
+	defer v.taskDone("RunJob", true)
+
+	jobOpts, err := toCoreJobOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	ch, err := v.cluster.RunJob(stream.Context(), jobOpts)
+	if err != nil {
+		return err
+	}
+
+	for m := range ch {
+		if err = stream.Send(toRPCJobMessage(m)); err != nil {
+			v.logUnsentMessages("RunJob", m)
+		}
+	}
+	return nil
+}
+
 func (v *Vibranium) logUnsentMessages(msgType string, msg interface{}) {
 	log.Infof("[logUnsentMessages] Unsent %s streamed message: %v", msgType, msg)
 }
 
 // New will new a new cluster instance
 func New(cluster cluster.Cluster, config types.Config, rpcch chan struct{}) *Vibranium {
-	return &Vibranium{cluster: cluster, config: config, counter: sync.WaitGroup{}, rpcch: rpcch}
+	var copySlots chan struct{}
+	if config.GRPCConfig.MaxConcurrentCopies > 0 {
+		copySlots = make(chan struct{}, config.GRPCConfig.MaxConcurrentCopies)
+	}
+	return &Vibranium{
+		cluster:   cluster,
+		config:    config,
+		counter:   sync.WaitGroup{},
+		rpcch:     rpcch,
+		chunks:    newChunkPool(config.GRPCConfig.CopyChunkSize),
+		copySlots: copySlots,
+	}
 }
@@ -1,6 +1,10 @@
 package rpc
 
 import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"strings"
 	"testing"
 
 	"context"
@@ -12,11 +16,14 @@ import (
 	"github.com/projecteru2/core/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
 )
 
 func newVibranium() *Vibranium {
 	v := &Vibranium{
-		cluster: &clustermock.Cluster{},
+		cluster:   &clustermock.Cluster{},
+		chunks:    newChunkPool(8),
+		copySlots: make(chan struct{}, 1),
 	}
 	return v
 }
@@ -58,3 +65,71 @@ func TestAddNode(t *testing.T) {
 	_, err = v.AddNode(context.Background(), opts)
 	assert.NoError(t, err)
 }
+
+func TestDebugInfo(t *testing.T) {
+	v := newVibranium()
+	cluster := v.cluster.(*clustermock.Cluster)
+	cluster.On("DebugInfo", mock.Anything).Return(&types.DebugInfo{Goroutines: 10, StoreState: "READY"}, nil)
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		info, err := v.DebugInfo(context.Background(), &pb.Empty{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), info.Streams)
+		assert.Equal(t, int64(10), info.Goroutines)
+		assert.Equal(t, "READY", info.StoreState)
+		return nil
+	}
+	assert.NoError(t, v.StreamCounter(nil, nil, nil, handler))
+	info, err := v.DebugInfo(context.Background(), &pb.Empty{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), info.Streams)
+}
+
+type copyStream struct {
+	grpc.ServerStream
+	msgs []*pb.CopyMessage
+}
+
+func (s *copyStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *copyStream) Send(m *pb.CopyMessage) error {
+	s.msgs = append(s.msgs, &pb.CopyMessage{Id: m.Id, Name: m.Name, Error: m.Error, Data: append([]byte{}, m.Data...)})
+	return nil
+}
+
+func TestCopy(t *testing.T) {
+	v := newVibranium()
+	v.config.GRPCConfig.MaxCopySize = 20
+	cluster := v.cluster.(*clustermock.Cluster)
+	ch := make(chan *types.CopyMessage, 3)
+	ch <- &types.CopyMessage{ID: "c1", Name: "small", Data: ioutil.NopCloser(strings.NewReader("0123456789"))}
+	ch <- &types.CopyMessage{ID: "c1", Name: "large", Data: ioutil.NopCloser(strings.NewReader(strings.Repeat("a", 21)))}
+	ch <- &types.CopyMessage{ID: "c2", Name: "failed", Error: types.ErrNoETCD}
+	close(ch)
+	cluster.On("Copy", mock.Anything, mock.Anything).Return(ch, nil)
+
+	stream := &copyStream{}
+	assert.NoError(t, v.Copy(&pb.CopyOptions{}, stream))
+	data := &bytes.Buffer{}
+	errs := map[string]string{}
+	for _, m := range stream.msgs {
+		if m.Error != "" {
+			errs[m.Name] = m.Error
+			continue
+		}
+		assert.Equal(t, "small", m.Name)
+		assert.LessOrEqual(t, len(m.Data), 8)
+		data.Write(m.Data)
+	}
+	assert.Len(t, errs, 2)
+	assert.Contains(t, errs["large"], types.ErrCopyTooLarge.Error())
+
+	tr := tar.NewReader(data)
+	header, err := tr.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "small", header.Name)
+	content, err := ioutil.ReadAll(tr)
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789", string(content))
+}
@@ -0,0 +1,118 @@
+package rpc
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/projecteru2/core/types"
+)
+
+// chunkPool reuses fixed-size buffers for streaming file data,
+// so concurrent copies don't allocate a new buffer for every message
+type chunkPool struct {
+	size int
+	pool sync.Pool
+}
+
+func newChunkPool(size int) *chunkPool {
+	if size <= 0 {
+		size = 32 * 1024
+	}
+	p := &chunkPool{size: size}
+	p.pool.New = func() interface{} {
+		return make([]byte, p.size)
+	}
+	return p
+}
+
+func (p *chunkPool) get() []byte {
+	return p.pool.Get().([]byte)
+}
+
+func (p *chunkPool) put(b []byte) {
+	if cap(b) != p.size {
+		return
+	}
+	p.pool.Put(b[:p.size]) // nolint
+}
+
+// acquireSlot waits until there is a free slot in slots or ctx is done
+func acquireSlot(ctx context.Context, slots chan struct{}) error {
+	if slots == nil {
+		return nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func releaseSlot(slots chan struct{}) {
+	if slots != nil {
+		<-slots
+	}
+}
+
+// spool writes data to a temp file with buf, size of the tarball header is known only after that,
+// data larger than max (0 means no limit) is refused.
+// the file is removed when closed
+func spool(data io.Reader, buf []byte, max int64) (*spooledFile, error) {
+	f, err := ioutil.TempFile("", "core-copy-")
+	if err != nil {
+		return nil, err
+	}
+	sf := &spooledFile{File: f}
+	if max > 0 {
+		data = io.LimitReader(data, max+1)
+	}
+	if sf.size, err = io.CopyBuffer(onlyWriter{f}, data, buf); err != nil {
+		sf.Close()
+		return nil, err
+	}
+	if max > 0 && sf.size > max {
+		sf.Close()
+		return nil, types.NewDetailedErr(types.ErrCopyTooLarge, max)
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		sf.Close()
+		return nil, err
+	}
+	return sf, nil
+}
+
+// onlyWriter hides ReaderFrom of a writer, so io.CopyBuffer always uses the given buffer
+type onlyWriter struct {
+	io.Writer
+}
+
+type spooledFile struct {
+	*os.File
+	size int64
+}
+
+// Close closes and removes the file
+func (f *spooledFile) Close() error {
+	err := f.File.Close()
+	if e := os.Remove(f.Name()); err == nil {
+		err = e
+	}
+	return err
+}
+
+// writeTar writes a tarball of a single file named name to w
+func writeTar(w io.Writer, name string, f *spooledFile, buf []byte) error {
+	tw := tar.NewWriter(w)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: f.size}); err != nil {
+		return err
+	}
+	if _, err := io.CopyBuffer(onlyWriter{tw}, struct{ io.Reader }{f.File}, buf); err != nil {
+		return err
+	}
+	return tw.Close()
+}
@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkPool(t *testing.T) {
+	p := newChunkPool(16)
+	b := p.get()
+	assert.Len(t, b, 16)
+	p.put(b[:3])
+	assert.Len(t, p.get(), 16)
+	p.put(make([]byte, 8))
+	assert.Len(t, p.get(), 16)
+	assert.Len(t, newChunkPool(0).get(), 32*1024)
+}
+
+func TestCopySlots(t *testing.T) {
+	assert.NoError(t, acquireSlot(context.Background(), nil))
+	releaseSlot(nil)
+
+	slots := make(chan struct{}, 1)
+	assert.NoError(t, acquireSlot(context.Background(), slots))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, acquireSlot(ctx, slots))
+	releaseSlot(slots)
+	assert.NoError(t, acquireSlot(context.Background(), slots))
+}
+
+func TestSpool(t *testing.T) {
+	buf := make([]byte, 4)
+	data := strings.Repeat("abc", 10)
+
+	_, err := spool(strings.NewReader(data), buf, 29)
+	assert.True(t, errors.Is(err, types.ErrCopyTooLarge))
+
+	f, err := spool(strings.NewReader(data), buf, 30)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(30), f.size)
+	name := f.Name()
+
+	w := &bytes.Buffer{}
+	assert.NoError(t, writeTar(w, "test", f, buf))
+	assert.NoError(t, f.Close())
+	_, err = os.Stat(name)
+	assert.True(t, os.IsNotExist(err))
+
+	tr := tar.NewReader(w)
+	header, err := tr.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "test", header.Name)
+	assert.Equal(t, int64(30), header.Size)
+	content, err := ioutil.ReadAll(tr)
+	assert.NoError(t, err)
+	assert.Equal(t, data, string(content))
+}
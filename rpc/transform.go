@@ -13,6 +13,19 @@ import (
 	"golang.org/x/net/context"
 )
 
+func toRPCDebugInfo(info *types.DebugInfo) *pb.CoreDebugInfo {
+	return &pb.CoreDebugInfo{
+		Goroutines:   int64(info.Goroutines),
+		HeapAlloc:    info.HeapAlloc,
+		HeapSys:      info.HeapSys,
+		NumGc:        info.NumGC,
+		GcPauseTotal: int64(info.GCPauseTotal),
+		GcPauseLast:  int64(info.GCPauseLast),
+		Streams:      info.Streams,
+		StoreState:   info.StoreState,
+	}
+}
+
 func toRPCServiceStatus(status types.ServiceStatus) *pb.ServiceStatus {
 	return &pb.ServiceStatus{
 		Addresses:        status.Addresses,
@@ -29,7 +42,26 @@ func toRPCCPUMap(m types.CPUMap) map[string]int32 {
 }
 
 func toRPCPod(p *types.Pod) *pb.Pod {
-	return &pb.Pod{Name: p.Name, Desc: p.Desc}
+	return &pb.Pod{Name: p.Name, Desc: p.Desc, Placement: p.Placement, Hook: toRPCHook(p.Hook), Timezone: p.Timezone, Locale: p.Locale, SysctlAllowlist: p.SysctlAllowlist, DeviceAllowlist: p.DeviceAllowlist}
+}
+
+func toCoreQuotaUsage(u *pb.QuotaUsage) types.QuotaUsage {
+	if u == nil {
+		return types.QuotaUsage{}
+	}
+	return types.QuotaUsage{CPU: u.Cpu, Memory: u.Memory, Storage: u.Storage, Volume: u.Volume, Containers: int(u.Containers)}
+}
+
+func toRPCQuotaUsage(u types.QuotaUsage) *pb.QuotaUsage {
+	return &pb.QuotaUsage{Cpu: u.CPU, Memory: u.Memory, Storage: u.Storage, Volume: u.Volume, Containers: int64(u.Containers)}
+}
+
+func toCoreQuota(q *pb.Quota) *types.Quota {
+	return &types.Quota{Scope: q.Scope, Name: q.Name, Limit: toCoreQuotaUsage(q.Limit)}
+}
+
+func toRPCQuota(q *types.Quota) *pb.Quota {
+	return &pb.Quota{Scope: q.Scope, Name: q.Name, Limit: toRPCQuotaUsage(q.Limit), Used: toRPCQuotaUsage(q.Used)}
 }
 
 func toRPCPodResource(p *types.PodResource) *pb.PodResource {
@@ -59,27 +91,117 @@ func toRPCNode(ctx context.Context, n *types.Node) *pb.Node {
 	}
 
 	return &pb.Node{
-		Name:        n.Name,
-		Endpoint:    n.Endpoint,
-		Podname:     n.Podname,
-		Cpu:         toRPCCPUMap(n.CPU),
-		CpuUsed:     n.CPUUsed,
-		Memory:      n.MemCap,
-		MemoryUsed:  n.InitMemCap - n.MemCap,
-		Storage:     n.StorageCap,
-		StorageUsed: n.StorageUsed(),
-		Volume:      n.Volume,
-		VolumeUsed:  n.VolumeUsed,
-		Available:   n.Available,
-		Labels:      n.Labels,
-		InitCpu:     toRPCCPUMap(n.InitCPU),
-		InitMemory:  n.InitMemCap,
-		InitStorage: n.InitStorageCap,
-		InitVolume:  n.InitVolume,
-		Info:        nodeInfo,
-		Numa:        n.NUMA,
-		NumaMemory:  n.NUMAMemory,
+		Name:          n.Name,
+		Endpoint:      n.Endpoint,
+		Podname:       n.Podname,
+		Cpu:           toRPCCPUMap(n.CPU),
+		CpuUsed:       n.CPUUsed,
+		Memory:        n.MemCap,
+		MemoryUsed:    n.InitMemCap - n.MemCap,
+		Storage:       n.StorageCap,
+		StorageUsed:   n.StorageUsed(),
+		Volume:        n.Volume,
+		VolumeUsed:    n.VolumeUsed,
+		Available:     n.Available,
+		Unschedulable: n.Unschedulable,
+		Labels:        n.Labels,
+		Annotations:   n.Annotations,
+		InitCpu:       toRPCCPUMap(n.InitCPU),
+		InitMemory:    n.InitMemCap,
+		InitStorage:   n.InitStorageCap,
+		InitVolume:    n.InitVolume,
+		Hugepages:     n.Hugepages,
+		InitHugepages: n.InitHugepages,
+		Gpu:           n.GPU,
+		InitGpu:       n.InitGPU,
+		Info:          nodeInfo,
+		Numa:          n.NUMA,
+		NumaMemory:    n.NUMAMemory,
+	}
+}
+
+func toRPCCapacityMessage(msg *types.CapacityMessage) *pb.CapacityMessage {
+	r := &pb.CapacityMessage{
+		Total:          int64(msg.Total),
+		NodeCapacities: map[string]*pb.NodeCapacity{},
+	}
+	for nodename, nodeCapacity := range msg.NodeCapacities {
+		r.NodeCapacities[nodename] = &pb.NodeCapacity{
+			Nodename:  nodeCapacity.Nodename,
+			Capacity:  int64(nodeCapacity.Capacity),
+			LimitedBy: nodeCapacity.LimitedBy,
+		}
+	}
+	return r
+}
+
+func toRPCResourceCapacity(r types.ResourceCapacity) *pb.ResourceCapacity {
+	return &pb.ResourceCapacity{
+		Cpu:         r.CPU,
+		CpuUsed:     r.CPUUsed,
+		Memory:      r.Memory,
+		MemoryUsed:  r.MemoryUsed,
+		Storage:     r.Storage,
+		StorageUsed: r.StorageUsed,
+		Volume:      r.Volume,
+		VolumeUsed:  r.VolumeUsed,
+		Processing:  int64(r.Processing),
+	}
+}
+
+func toRPCCapacityReport(reports []*types.PodCapacityReport) *pb.PodCapacityReports {
+	r := &pb.PodCapacityReports{Pods: []*pb.PodCapacityReport{}}
+	for _, report := range reports {
+		pod := &pb.PodCapacityReport{
+			Name:     report.Name,
+			Resource: toRPCResourceCapacity(report.ResourceCapacity),
+			Nodes:    []*pb.NodeCapacityReport{},
+		}
+		for _, node := range report.Nodes {
+			pod.Nodes = append(pod.Nodes, &pb.NodeCapacityReport{
+				Name:     node.Name,
+				Resource: toRPCResourceCapacity(node.ResourceCapacity),
+			})
+		}
+		r.Pods = append(r.Pods, pod)
+	}
+	return r
+}
+
+func toRPCNodeFragmentations(fs []*types.NodeFragmentation) *pb.NodeFragmentations {
+	r := &pb.NodeFragmentations{Nodes: []*pb.NodeFragmentation{}}
+	for _, f := range fs {
+		r.Nodes = append(r.Nodes, &pb.NodeFragmentation{
+			Name:                f.Name,
+			FreeCores:           int64(f.FreeCores),
+			FragmentedCores:     int64(f.FragmentedCores),
+			FragmentedCpu:       f.FragmentedCPU,
+			VolumeFree:          f.VolumeFree,
+			VolumeDevices:       int64(f.VolumeDevices),
+			MaxVolumeDeviceFree: f.MaxVolumeDeviceFree,
+			Suggestions:         f.Suggestions,
+		})
+	}
+	return r
+}
+
+func toRPCCapacityForecast(f *types.CapacityForecast) *pb.CapacityForecast {
+	r := &pb.CapacityForecast{
+		Podname:        f.Podname,
+		Samples:        int64(f.Samples),
+		DeployFailures: int64(f.DeployFailures),
+		Resources:      []*pb.ResourceForecast{},
+	}
+	for _, rf := range f.Resources {
+		r.Resources = append(r.Resources, &pb.ResourceForecast{
+			Resource:     rf.Resource,
+			Total:        rf.Total,
+			Used:         rf.Used,
+			GrowthPerDay: rf.GrowthPerDay,
+			ExhaustIn:    int64(rf.ExhaustIn.Seconds()),
+		})
 	}
+	return r
 }
 
 func toRPCNodeResource(nr *types.NodeResource) *pb.NodeResource {
@@ -96,6 +218,7 @@ func toRPCNodeResource(nr *types.NodeResource) *pb.NodeResource {
 
 func toRPCBuildImageMessage(b *types.BuildImageMessage) *pb.BuildImageMessage {
 	return &pb.BuildImageMessage{
+		Name:     b.Name,
 		Id:       b.ID,
 		Status:   b.Status,
 		Progress: b.Progress,
@@ -123,20 +246,23 @@ func toCoreSendOptions(b *pb.SendOptions) (*types.SendOptions, error) { // nolin
 
 func toCoreAddNodeOptions(b *pb.AddNodeOptions) *types.AddNodeOptions {
 	r := &types.AddNodeOptions{
-		Nodename:   b.Nodename,
-		Endpoint:   b.Endpoint,
-		Podname:    b.Podname,
-		Ca:         b.Ca,
-		Cert:       b.Cert,
-		Key:        b.Key,
-		CPU:        int(b.Cpu),
-		Share:      int(b.Share),
-		Memory:     b.Memory,
-		Storage:    b.Storage,
-		Labels:     b.Labels,
-		Numa:       b.Numa,
-		NumaMemory: b.NumaMemory,
-		Volume:     types.VolumeMap{},
+		Nodename:    b.Nodename,
+		Endpoint:    b.Endpoint,
+		Podname:     b.Podname,
+		Ca:          b.Ca,
+		Cert:        b.Cert,
+		Key:         b.Key,
+		CPU:         int(b.Cpu),
+		Share:       int(b.Share),
+		Memory:      b.Memory,
+		Storage:     b.Storage,
+		Labels:      b.Labels,
+		Annotations: b.Annotations,
+		Numa:        b.Numa,
+		NumaMemory:  b.NumaMemory,
+		Volume:      types.VolumeMap{},
+		Hugepages:   b.Hugepages,
+		GPU:         b.Gpu,
 	}
 	return r
 }
@@ -151,8 +277,15 @@ func toCoreSetNodeOptions(b *pb.SetNodeOptions) (*types.SetNodeOptions, error) {
 		DeltaStorage:    b.DeltaStorage,
 		DeltaNUMAMemory: b.DeltaNumaMemory,
 		DeltaVolume:     b.DeltaVolume,
+		DeltaHugepages:  b.DeltaHugepages,
+		DeltaGPU:        b.DeltaGpu,
 		NUMA:            b.Numa,
 		Labels:          b.Labels,
+		Annotations:     b.Annotations,
+		Endpoint:        b.Endpoint,
+		Ca:              b.Ca,
+		Cert:            b.Cert,
+		Key:             b.Key,
 	}
 	for cpuID, cpuShare := range b.DeltaCpu {
 		r.DeltaCPU[cpuID] = int64(cpuShare)
@@ -160,6 +293,83 @@ func toCoreSetNodeOptions(b *pb.SetNodeOptions) (*types.SetNodeOptions, error) {
 	return r, nil
 }
 
+func toCoreSetContainerOptions(b *pb.SetContainerOptions) *types.SetContainerOptions {
+	return &types.SetContainerOptions{
+		ID:           b.Id,
+		Labels:       b.Labels,
+		RemoveLabels: b.RemoveLabels,
+
+		Annotations:       b.Annotations,
+		RemoveAnnotations: b.RemoveAnnotations,
+
+		Fields:      b.Fields,
+		Priority:    int(b.Priority),
+		StopTimeout: time.Duration(b.StopTimeout) * time.Second,
+	}
+}
+
+func toCoreAdoptOptions(opts *pb.AdoptContainerOptions) *types.AdoptOptions {
+	return &types.AdoptOptions{
+		Nodename: opts.Nodename,
+		ID:       opts.Id,
+		Name:     opts.Name,
+		Quota:    opts.Cpu,
+		Memory:   opts.Memory,
+		Storage:  opts.Storage,
+	}
+}
+
+func toCoreCommitOptions(opts *pb.CommitContainerOptions) *types.CommitOptions {
+	return &types.CommitOptions{
+		ID:      opts.Id,
+		Name:    opts.Name,
+		Tags:    opts.Tags,
+		Comment: opts.Comment,
+		Push:    opts.Push,
+	}
+}
+
+func toCoreSourceCredential(c *pb.SourceCredential) *types.SourceCredential {
+	if c == nil {
+		return nil
+	}
+	return &types.SourceCredential{
+		Username:   c.Username,
+		Token:      c.Token,
+		PrivateKey: c.PrivateKey,
+	}
+}
+
+func toCoreSourceVerify(v *pb.SourceVerify) *types.SourceVerify {
+	if v == nil {
+		return nil
+	}
+	return &types.SourceVerify{
+		Signature: v.Signature,
+		KeyRing:   v.Keyring,
+		Checksum:  v.Checksum,
+	}
+}
+
+func toCoreTarball(t *pb.Tarball) *types.Tarball {
+	if t == nil {
+		return nil
+	}
+	return &types.Tarball{
+		URL:      t.Url,
+		Checksum: t.Checksum,
+		Header:   t.Header,
+	}
+}
+
+func toCoreBuildTargets(ts []*pb.BuildTarget) []*types.BuildTarget {
+	targets := []*types.BuildTarget{}
+	for _, t := range ts {
+		targets = append(targets, &types.BuildTarget{Name: t.Name, Tags: t.Tags, Subpath: t.Subpath})
+	}
+	return targets
+}
+
 func toCoreBuildOptions(b *pb.BuildImageOptions) (*types.BuildOptions, error) {
 	var builds *types.Builds
 	if b.GetBuilds() != nil {
@@ -177,6 +387,7 @@ func toCoreBuildOptions(b *pb.BuildImageOptions) (*types.BuildOptions, error) {
 			builds.Builds[stage] = &types.Build{
 				Base:       p.Base,
 				Repo:       p.Repo,
+				Tarball:    toCoreTarball(p.Tarball),
 				Version:    p.Version,
 				Dir:        p.Dir,
 				Submodule:  p.Submodule || false,
@@ -188,6 +399,14 @@ func toCoreBuildOptions(b *pb.BuildImageOptions) (*types.BuildOptions, error) {
 				Artifacts:  p.Artifacts,
 				Cache:      p.Cache,
 				StopSignal: p.StopSignal,
+				Credential: toCoreSourceCredential(p.Credential),
+				Branch:     p.Branch,
+				Tag:        p.Tag,
+				Commit:     p.Commit,
+				Depth:      int(p.Depth),
+				Secrets:    p.Secrets,
+				Subpath:    p.Subpath,
+				Verify:     toCoreSourceVerify(p.Verify),
 			}
 		}
 	}
@@ -211,6 +430,9 @@ func toCoreBuildOptions(b *pb.BuildImageOptions) (*types.BuildOptions, error) {
 		Builds:      builds,
 		Tar:         bytes.NewReader(b.Tar),
 		ExistID:     b.GetExistId(),
+		Secrets:     b.GetSecrets(),
+		Targets:     toCoreBuildTargets(b.GetTargets()),
+		Nodename:    b.GetNodename(),
 	}, nil
 }
 
@@ -228,7 +450,252 @@ func toCoreReplaceOptions(r *pb.ReplaceOptions) (*types.ReplaceOptions, error) {
 	return replaceOpts, err
 }
 
+func toCoreRebalanceOptions(r *pb.RebalanceOptions) (*types.RebalanceOptions, error) {
+	if r.DeployOpt == nil {
+		return nil, types.ErrNoEntryInSpec
+	}
+	deployOpts, err := toCoreDeployOptions(r.DeployOpt)
+	if err != nil {
+		return nil, err
+	}
+	return &types.RebalanceOptions{
+		DeployOptions:  *deployOpts,
+		Strategy:       r.Strategy,
+		MaxUnavailable: int(r.MaxUnavailable),
+		Execute:        r.Execute,
+	}, nil
+}
+
+func toCoreCloneOptions(opts *pb.CloneContainerOptions) *types.CloneOptions {
+	return &types.CloneOptions{
+		ID:         opts.Id,
+		Nodename:   opts.Nodename,
+		Env:        opts.Env,
+		IgnoreHook: opts.IgnoreHook,
+	}
+}
+
+func toCoreWaitOptions(opts *pb.WaitContainerOptions) *types.WaitOptions {
+	return &types.WaitOptions{
+		IDs:       opts.Ids,
+		Condition: opts.Condition,
+		Timeout:   time.Duration(opts.Timeout) * time.Second,
+	}
+}
+
+func toCoreMigrateOptions(r *pb.MigrateContainerOptions) (*types.MigrateOptions, error) {
+	if r.DeployOpt == nil {
+		return nil, types.ErrNoEntryInSpec
+	}
+	deployOpts, err := toCoreDeployOptions(r.DeployOpt)
+	if err != nil {
+		return nil, err
+	}
+	return &types.MigrateOptions{
+		DeployOptions:     *deployOpts,
+		IDs:               r.Ids,
+		MaxUnavailable:    int(r.MaxUnavailable),
+		AllowLocalVolumes: r.AllowLocalVolumes,
+	}, nil
+}
+
+func toCoreDrainNodeOptions(r *pb.DrainNodeOptions) *types.DrainNodeOptions {
+	return &types.DrainNodeOptions{
+		Nodename:          r.Nodename,
+		Remove:            r.Remove,
+		MaxUnavailable:    int(r.MaxUnavailable),
+		AllowLocalVolumes: r.AllowLocalVolumes,
+	}
+}
+
+func toCoreReserveOptions(r *pb.ReserveOptions) (*types.ReserveOptions, error) {
+	if r.DeployOpt == nil {
+		return nil, types.ErrNoEntryInSpec
+	}
+	deployOpts, err := toCoreDeployOptions(r.DeployOpt)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ReserveOptions{
+		DeployOptions: *deployOpts,
+		TTL:           time.Duration(r.Ttl) * time.Second,
+	}, nil
+}
+
+func toRPCReservation(r *types.Reservation) *pb.Reservation {
+	nodes := map[string]int64{}
+	for _, nodeInfo := range r.NodesInfo {
+		nodes[nodeInfo.Name] = int64(nodeInfo.Deploy)
+	}
+	return &pb.Reservation{
+		Id:         r.ID,
+		Podname:    r.Podname,
+		Appname:    r.Appname,
+		Entrypoint: r.Entrypoint,
+		CpuQuota:   r.CPUQuota,
+		CpuBind:    r.CPUBind,
+		Memory:     r.Memory,
+		Storage:    r.Storage,
+		Volumes:    r.Volumes.ToStringSlice(false, false),
+		Nodes:      nodes,
+		Expire:     r.Expire.Unix(),
+	}
+}
+
+func toCoreScalePolicy(p *pb.ScalePolicy) *types.ScalePolicy {
+	return &types.ScalePolicy{
+		Appname:         p.Appname,
+		Entrypoint:      p.Entrypoint,
+		Podname:         p.Podname,
+		Min:             int(p.Min),
+		Max:             int(p.Max),
+		CPUThreshold:    p.CpuThreshold,
+		MemoryThreshold: p.MemoryThreshold,
+		Cooldown:        time.Duration(p.Cooldown) * time.Second,
+	}
+}
+
+func toRPCScalePolicy(p *types.ScalePolicy) *pb.ScalePolicy {
+	r := &pb.ScalePolicy{
+		Appname:         p.Appname,
+		Entrypoint:      p.Entrypoint,
+		Podname:         p.Podname,
+		Min:             int32(p.Min),
+		Max:             int32(p.Max),
+		CpuThreshold:    p.CPUThreshold,
+		MemoryThreshold: p.MemoryThreshold,
+		Cooldown:        int32(p.Cooldown / time.Second),
+	}
+	if !p.LastScaled.IsZero() {
+		r.LastScaled = p.LastScaled.Unix()
+	}
+	return r
+}
+
+func toCoreCron(c *pb.Cron) (*types.Cron, error) {
+	if c.DeployOptions == nil {
+		return nil, types.ErrNoDeployOpts
+	}
+	deployOpts, err := toCoreDeployOptions(c.DeployOptions)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Cron{
+		Name:          c.Name,
+		Spec:          c.Spec,
+		Kind:          c.Kind,
+		Retries:       int(c.Retries),
+		DeployOptions: *deployOpts,
+	}, nil
+}
+
+func toRPCCron(c *types.Cron) *pb.Cron {
+	r := &pb.Cron{
+		Name:    c.Name,
+		Spec:    c.Spec,
+		Kind:    c.Kind,
+		Retries: int32(c.Retries),
+		DeployOptions: &pb.DeployOptions{
+			Name:    c.DeployOptions.Name,
+			Podname: c.DeployOptions.Podname,
+			Image:   c.DeployOptions.Image,
+			Count:   int32(c.DeployOptions.Count),
+		},
+		Created: c.Created.Unix(),
+	}
+	if c.DeployOptions.Entrypoint != nil {
+		r.DeployOptions.Entrypoint = &pb.EntrypointOptions{Name: c.DeployOptions.Entrypoint.Name}
+	}
+	if !c.LastRun.IsZero() {
+		r.LastRun = c.LastRun.Unix()
+	}
+	return r
+}
+
+func toRPCCronRun(r *types.CronRun) *pb.CronRun {
+	run := &pb.CronRun{
+		Id:        r.ID,
+		Name:      r.Name,
+		Scheduled: r.Scheduled.Unix(),
+		Started:   r.Started.Unix(),
+		Succeeded: int32(r.Succeeded),
+		Failed:    int32(r.Failed),
+		Error:     r.Error,
+	}
+	if !r.Finished.IsZero() {
+		run.Finished = r.Finished.Unix()
+	}
+	return run
+}
+
+func toCoreEventFilter(opts *pb.WatchEventsOptions) *types.EventFilter {
+	return &types.EventFilter{Kinds: opts.Kinds, Podname: opts.Podname, Nodename: opts.Nodename, Appname: opts.Appname}
+}
+
+func toRPCEvent(e *types.Event) *pb.Event {
+	return &pb.Event{
+		Id:          e.ID,
+		Kind:        e.Kind,
+		Time:        e.Time.UnixNano(),
+		Source:      e.Source,
+		Podname:     e.Podname,
+		Nodename:    e.Nodename,
+		Appname:     e.Appname,
+		ContainerId: e.ContainerID,
+		Image:       e.Image,
+	}
+}
+
+func toCoreAuditFilter(opts *pb.ListAuditLogsOptions) *types.AuditFilter {
+	filter := &types.AuditFilter{User: opts.User, Appname: opts.Appname, Limit: int(opts.Limit)}
+	if opts.Since > 0 {
+		filter.Since = time.Unix(opts.Since, 0)
+	}
+	if opts.Until > 0 {
+		filter.Until = time.Unix(opts.Until, 0)
+	}
+	return filter
+}
+
+func toRPCAuditLog(l *types.AuditLog) *pb.AuditLog {
+	return &pb.AuditLog{
+		Id:       l.ID,
+		Method:   l.Method,
+		User:     l.User,
+		Peer:     l.Peer,
+		Appname:  l.Appname,
+		Options:  l.Options,
+		Started:  l.Started.UnixNano(),
+		Finished: l.Finished.UnixNano(),
+		Error:    l.Error,
+		Source:   l.Source,
+	}
+}
+
+func toCoreEventWebhook(w *pb.EventWebhook) *types.EventWebhook {
+	return &types.EventWebhook{Name: w.Name, URL: w.Url, Secret: w.Secret, Kinds: w.Kinds, Appname: w.Appname}
+}
+
+func toRPCEventWebhook(w *types.EventWebhook) *pb.EventWebhook {
+	return &pb.EventWebhook{Name: w.Name, Url: w.URL, Kinds: w.Kinds, Appname: w.Appname}
+}
+
+func toCoreRegistryCredentialOptions(o *pb.SetRegistryCredentialOptions) *types.RegistryCredentialOptions {
+	return &types.RegistryCredentialOptions{Registry: o.Registry, Podname: o.Podname, Username: o.Username, Password: o.Password}
+}
+
+func toRPCRegistryCredentials(credentials []*types.RegistryCredential) *pb.RegistryCredentials {
+	r := &pb.RegistryCredentials{}
+	for _, c := range credentials {
+		r.Credentials = append(r.Credentials, &pb.RegistryCredential{Registry: c.Registry, Podname: c.Podname, Username: c.Username})
+	}
+	return r
+}
+
 func toCoreDeployOptions(d *pb.DeployOptions) (*types.DeployOptions, error) {
+	if d.Specs != "" {
+		return toCoreDeployOptionsWithSpecs(d)
+	}
 	if d.Entrypoint == nil {
 		return nil, types.ErrNoEntryInSpec
 	}
@@ -236,13 +703,39 @@ func toCoreDeployOptions(d *pb.DeployOptions) (*types.DeployOptions, error) {
 	entrypoint := d.Entrypoint
 
 	entry := &types.Entrypoint{
-		Name:          entrypoint.Name,
-		Command:       entrypoint.Command,
-		Privileged:    entrypoint.Privileged,
-		Dir:           entrypoint.Dir,
-		Publish:       entrypoint.Publish,
-		RestartPolicy: entrypoint.RestartPolicy,
-		Sysctls:       entrypoint.Sysctls,
+		Name:        entrypoint.Name,
+		Command:     entrypoint.Command,
+		Commands:    entrypoint.Commands,
+		Privileged:  entrypoint.Privileged,
+		Dir:         entrypoint.Dir,
+		Publish:     entrypoint.Publish,
+		Sysctls:     entrypoint.Sysctls,
+		SkipPodHook: entrypoint.SkipPodHook,
+		StopTimeout: int(entrypoint.StopTimeout),
+	}
+	if len(entrypoint.Ulimits) > 0 {
+		entry.Ulimits = map[string]*types.Ulimit{}
+		for name, ulimit := range entrypoint.Ulimits {
+			if ulimit != nil {
+				entry.Ulimits[name] = &types.Ulimit{Soft: ulimit.Soft, Hard: ulimit.Hard}
+			}
+		}
+	}
+
+	switch {
+	case entrypoint.Restart != nil:
+		entry.RestartPolicy = &types.RestartPolicy{
+			Policy:     entrypoint.Restart.Policy,
+			MaxRetries: int(entrypoint.Restart.MaxRetries),
+			Backoff:    int(entrypoint.Restart.Backoff),
+			Window:     int(entrypoint.Restart.Window),
+		}
+	case entrypoint.RestartPolicy != "":
+		restart, err := types.ParseRestartPolicy(entrypoint.RestartPolicy)
+		if err != nil {
+			return nil, err
+		}
+		entry.RestartPolicy = restart
 	}
 
 	if entrypoint.Log != nil && entrypoint.Log.Type != "" {
@@ -257,15 +750,54 @@ func toCoreDeployOptions(d *pb.DeployOptions) (*types.DeployOptions, error) {
 		entry.HealthCheck.HTTPPort = entrypoint.Healthcheck.HttpPort
 		entry.HealthCheck.HTTPURL = entrypoint.Healthcheck.Url
 		entry.HealthCheck.HTTPCode = int(entrypoint.Healthcheck.Code)
+		entry.HealthCheck.Protocol = entrypoint.Healthcheck.Protocol
+		entry.HealthCheck.Cmd = entrypoint.Healthcheck.Cmd
+		entry.HealthCheck.Interval = int(entrypoint.Healthcheck.Interval)
+		entry.HealthCheck.Timeout = int(entrypoint.Healthcheck.Timeout)
+		entry.HealthCheck.HealthyThreshold = int(entrypoint.Healthcheck.HealthyThreshold)
+		entry.HealthCheck.UnhealthyThreshold = int(entrypoint.Healthcheck.UnhealthyThreshold)
+		entry.HealthCheck.InitialDelay = int(entrypoint.Healthcheck.InitialDelay)
 	}
 
-	if entrypoint.Hook != nil {
-		entry.Hook = &types.Hook{}
-		entry.Hook.AfterStart = entrypoint.Hook.AfterStart
-		entry.Hook.BeforeStop = entrypoint.Hook.BeforeStop
-		entry.Hook.Force = entrypoint.Hook.Force
+	entry.Hook = toCoreHook(entrypoint.Hook)
+
+	opts, err := toCoreBaseDeployOptions(d)
+	if err != nil {
+		return nil, err
+	}
+	opts.Entrypoint = entry
+	return opts, nil
+}
+
+// toCoreDeployOptionsWithSpecs picks entrypoint from app.yaml by entrypoint name
+// name can be omitted if there is only one entrypoint
+func toCoreDeployOptionsWithSpecs(d *pb.DeployOptions) (*types.DeployOptions, error) {
+	specs, err := types.LoadSpecs([]byte(d.Specs))
+	if err != nil {
+		return nil, err
+	}
+	base, err := toCoreBaseDeployOptions(d)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := specs.DeployOptions(base)
+	if err != nil {
+		return nil, err
 	}
+	name := d.GetEntrypoint().GetName()
+	if name == "" && len(opts) == 1 {
+		for _, o := range opts {
+			return o, nil
+		}
+	}
+	o, ok := opts[name]
+	if !ok {
+		return nil, types.NewDetailedErr(types.ErrNoEntryInSpec, name)
+	}
+	return o, nil
+}
 
+func toCoreBaseDeployOptions(d *pb.DeployOptions) (*types.DeployOptions, error) {
 	vbs, err := types.MakeVolumeBindings(d.Volumes)
 	if err != nil {
 		return nil, err
@@ -279,38 +811,81 @@ func toCoreDeployOptions(d *pb.DeployOptions) (*types.DeployOptions, error) {
 	}
 
 	return &types.DeployOptions{
-		Name:         d.Name,
-		Entrypoint:   entry,
-		Podname:      d.Podname,
-		Nodename:     d.Nodename,
-		Image:        d.Image,
-		ExtraArgs:    d.ExtraArgs,
-		CPUQuota:     d.CpuQuota,
-		CPUBind:      d.CpuBind,
-		Memory:       d.Memory,
-		Storage:      d.Storage,
-		Count:        int(d.Count),
-		Env:          d.Env,
-		DNS:          d.Dns,
-		ExtraHosts:   d.ExtraHosts,
-		Volumes:      vbs,
-		Networks:     d.Networks,
-		NetworkMode:  d.Networkmode,
-		User:         d.User,
-		Debug:        d.Debug,
-		OpenStdin:    d.OpenStdin,
-		Labels:       d.Labels,
-		NodeLabels:   d.Nodelabels,
-		DeployMethod: d.DeployMethod,
-		SoftLimit:    d.SoftLimit,
-		NodesLimit:   int(d.NodesLimit),
-		IgnoreHook:   d.IgnoreHook,
-		AfterCreate:  d.AfterCreate,
-		RawArgs:      d.RawArgs,
-		Data:         data,
+		Name:           d.Name,
+		Podname:        d.Podname,
+		Nodename:       d.Nodename,
+		Image:          d.Image,
+		ExtraArgs:      d.ExtraArgs,
+		CPUQuota:       d.CpuQuota,
+		CPUShares:      d.CpuShares,
+		Hugepages:      d.Hugepages,
+		CPUBind:        d.CpuBind,
+		Memory:         d.Memory,
+		Storage:        d.Storage,
+		Count:          int(d.Count),
+		Env:            d.Env,
+		DNS:            d.Dns,
+		ExtraHosts:     d.ExtraHosts,
+		Volumes:        vbs,
+		Networks:       d.Networks,
+		NetworkMode:    d.Networkmode,
+		User:           d.User,
+		Debug:          d.Debug,
+		OpenStdin:      d.OpenStdin,
+		Labels:         d.Labels,
+		Annotations:    d.Annotations,
+		NodeLabels:     d.Nodelabels,
+		DeployStrategy: d.DeployMethod,
+		SoftLimit:      d.SoftLimit,
+		NodesLimit:     int(d.NodesLimit),
+		IgnoreHook:     d.IgnoreHook,
+		AfterCreate:    d.AfterCreate,
+		RawArgs:        d.RawArgs,
+		Data:           data,
+		ReservationID:  d.ReservationId,
+		Timezone:       d.Timezone,
+		Locale:         d.Locale,
+		Devices:        toCoreDevices(d.Devices),
+		GPURequest:     toCoreGPURequest(d.GpuRequest),
+		Affinity:       d.Affinity,
+		AntiAffinity:   d.AntiAffinity,
+		Priority:       int(d.Priority),
+		SecretRefs:     toCoreSecretRefs(d.SecretRefs),
+		ConfigRefs:     toCoreConfigRefs(d.ConfigRefs),
 	}, nil
 }
 
+func toCoreGPURequest(r *pb.GPURequest) *types.GPURequest {
+	if r == nil {
+		return nil
+	}
+	return &types.GPURequest{Count: int(r.Count), Exclusive: r.Exclusive, Share: r.Share}
+}
+
+func toCoreSecretRefs(refs []*pb.SecretRef) []*types.SecretRef {
+	var r []*types.SecretRef
+	for _, ref := range refs {
+		r = append(r, &types.SecretRef{Name: ref.Name, Env: ref.Env, Path: ref.Path})
+	}
+	return r
+}
+
+func toCoreConfigRefs(refs []*pb.ConfigRef) []*types.ConfigRef {
+	var r []*types.ConfigRef
+	for _, ref := range refs {
+		r = append(r, &types.ConfigRef{Name: ref.Name, Path: ref.Path})
+	}
+	return r
+}
+
+func toCoreDevices(devices []*pb.Device) []*types.Device {
+	var r []*types.Device
+	for _, d := range devices {
+		r = append(r, &types.Device{PathOnHost: d.PathOnHost, PathInContainer: d.PathInContainer, Permissions: d.Permissions})
+	}
+	return r
+}
+
 func toRPCVolumePlan(v types.VolumePlan) map[string]*pb.Volume {
 	if v == nil {
 		return nil
@@ -340,13 +915,92 @@ func toRPCCreateContainerMessage(c *types.CreateContainerMessage) *pb.CreateCont
 		VolumePlan: toRPCVolumePlan(c.VolumePlan),
 		Publish:    utils.EncodePublishInfo(c.Publish),
 		Hook:       types.HookOutput(c.Hook),
+		Preempted:  c.Preempted,
+	}
+	for _, result := range c.HookResults {
+		msg.HookResults = append(msg.HookResults, toRPCHookResult(result))
 	}
 	if c.Error != nil {
 		msg.Error = c.Error.Error()
 	}
+	msg.SchemaVersion = messageSchemaVersion
+	msg.ErrorCode = toRPCErrorCode(c.Error)
 	return msg
 }
 
+func toCoreHook(h *pb.HookOptions) *types.Hook {
+	if h == nil {
+		return nil
+	}
+	hook := &types.Hook{}
+	hook.AfterStart = h.AfterStart
+	hook.BeforeStop = h.BeforeStop
+	hook.BeforeRemove = h.BeforeRemove
+	hook.Force = h.Force
+	hook.Timeout = int(h.Timeout)
+	hook.Retries = int(h.Retries)
+	hook.Backoff = int(h.Backoff)
+	hook.Type = h.Type
+	hook.HTTPCode = int(h.Code)
+	hook.User = h.User
+	hook.WorkingDir = h.WorkingDir
+	for _, stage := range h.Stages {
+		hook.Stages = append(hook.Stages, types.HookStage{
+			Name:       stage.Name,
+			On:         stage.On,
+			Commands:   stage.Commands,
+			Force:      stage.Force,
+			User:       stage.User,
+			WorkingDir: stage.WorkingDir,
+		})
+	}
+	return hook
+}
+
+func toRPCHook(h *types.Hook) *pb.HookOptions {
+	if h == nil {
+		return nil
+	}
+	hook := &pb.HookOptions{
+		AfterStart:   h.AfterStart,
+		BeforeStop:   h.BeforeStop,
+		BeforeRemove: h.BeforeRemove,
+		Force:        h.Force,
+		Timeout:      int32(h.Timeout),
+		Retries:      int32(h.Retries),
+		Backoff:      int32(h.Backoff),
+		Type:         h.Type,
+		Code:         int32(h.HTTPCode),
+		User:         h.User,
+		WorkingDir:   h.WorkingDir,
+	}
+	for _, stage := range h.Stages {
+		hook.Stages = append(hook.Stages, &pb.HookStage{
+			Name:       stage.Name,
+			On:         stage.On,
+			Commands:   stage.Commands,
+			Force:      stage.Force,
+			User:       stage.User,
+			WorkingDir: stage.WorkingDir,
+		})
+	}
+	return hook
+}
+
+func toRPCHookResult(r *types.HookResult) *pb.HookResult {
+	result := &pb.HookResult{
+		Stage:    r.Stage,
+		Cmd:      r.Cmd,
+		Stdout:   r.Stdout,
+		Stderr:   r.Stderr,
+		ExitCode: int32(r.ExitCode),
+	}
+	if r.Error != nil {
+		result.Error = r.Error.Error()
+	}
+	return result
+}
+
 func toRPCReplaceContainerMessage(r *types.ReplaceContainerMessage) *pb.ReplaceContainerMessage {
 	msg := &pb.ReplaceContainerMessage{
 		Create: toRPCCreateContainerMessage(r.Create),
@@ -358,6 +1012,45 @@ func toRPCReplaceContainerMessage(r *types.ReplaceContainerMessage) *pb.ReplaceC
 	return msg
 }
 
+func toRPCRebalanceMessage(r *types.RebalanceMessage) *pb.RebalanceMessage {
+	msg := &pb.RebalanceMessage{
+		ContainerId: r.Move.ContainerID,
+		From:        r.Move.From,
+		To:          r.Move.To,
+		Create:      toRPCCreateContainerMessage(r.Create),
+		Remove:      toRPCRemoveContainerMessage(r.Remove),
+	}
+	if r.Error != nil {
+		msg.Error = r.Error.Error()
+	}
+	return msg
+}
+
+func toRPCWaitContainerMessage(r *types.WaitContainerMessage) *pb.WaitContainerMessage {
+	msg := &pb.WaitContainerMessage{
+		Id:      r.ContainerID,
+		Code:    r.Code,
+		Message: r.Message,
+		Healthy: r.Healthy,
+	}
+	if r.Error != nil {
+		msg.Error = r.Error.Error()
+	}
+	return msg
+}
+
+func toRPCMigrateMessage(r *types.MigrateMessage) *pb.MigrateContainerMessage {
+	msg := &pb.MigrateContainerMessage{
+		ContainerId: r.ContainerID,
+		Create:      toRPCCreateContainerMessage(r.Create),
+		Remove:      toRPCRemoveContainerMessage(r.Remove),
+	}
+	if r.Error != nil {
+		msg.Error = r.Error.Error()
+	}
+	return msg
+}
+
 func toRPCCacheImageMessage(r *types.CacheImageMessage) *pb.CacheImageMessage {
 	return &pb.CacheImageMessage{
 		Image:    r.Image,
@@ -377,8 +1070,13 @@ func toRPCRemoveImageMessage(r *types.RemoveImageMessage) *pb.RemoveImageMessage
 
 func toRPCControlContainerMessage(c *types.ControlContainerMessage) *pb.ControlContainerMessage {
 	r := &pb.ControlContainerMessage{
-		Id:   c.ContainerID,
-		Hook: types.HookOutput(c.Hook),
+		Id:            c.ContainerID,
+		Hook:          types.HookOutput(c.Hook),
+		Partial:       c.Partial,
+		Done:          int32(c.Done),
+		Total:         int32(c.Total),
+		SchemaVersion: messageSchemaVersion,
+		ErrorCode:     toRPCErrorCode(c.Error),
 	}
 	if c.Error != nil {
 		r.Error = c.Error.Error()
@@ -400,11 +1098,19 @@ func toRPCRemoveContainerMessage(r *types.RemoveContainerMessage) *pb.RemoveCont
 	if r == nil {
 		return nil
 	}
-	return &pb.RemoveContainerMessage{
-		Id:      r.ContainerID,
-		Success: r.Success,
-		Hook:    string(types.HookOutput(r.Hook)),
+	msg := &pb.RemoveContainerMessage{
+		Id:            r.ContainerID,
+		Success:       r.Success,
+		Hook:          string(types.HookOutput(r.Hook)),
+		Done:          int32(r.Done),
+		Total:         int32(r.Total),
+		SchemaVersion: messageSchemaVersion,
+		ErrorCode:     toRPCErrorCode(r.Error),
 	}
+	if r.Error != nil {
+		msg.Error = r.Error.Error()
+	}
+	return msg
 }
 
 func toRPCDissociateContainerMessage(r *types.DissociateContainerMessage) *pb.DissociateContainerMessage {
@@ -424,6 +1130,36 @@ func toRPCAttachContainerMessage(msg *types.AttachContainerMessage) *pb.AttachCo
 	}
 }
 
+func toCoreJobOptions(r *pb.JobOptions) (*types.JobOptions, error) {
+	if r.DeployOptions == nil {
+		return nil, types.ErrNoDeployOpts
+	}
+	deployOpts, err := toCoreDeployOptions(r.DeployOptions)
+	if err != nil {
+		return nil, err
+	}
+	return &types.JobOptions{
+		DeployOptions: *deployOpts,
+		Retries:       int(r.Retries),
+	}, nil
+}
+
+func toRPCJobMessage(msg *types.JobMessage) *pb.JobMessage {
+	r := &pb.JobMessage{
+		ContainerId:   msg.ContainerID,
+		Attempt:       int32(msg.Attempt),
+		Data:          msg.Data,
+		Finished:      msg.Finished,
+		ExitCode:      int32(msg.ExitCode),
+		SchemaVersion: messageSchemaVersion,
+		ErrorCode:     toRPCErrorCode(msg.Error),
+	}
+	if msg.Error != nil {
+		r.Error = msg.Error.Error()
+	}
+	return r
+}
+
 func toRPCContainerStatus(containerStatus *types.StatusMeta) *pb.ContainerStatus {
 	r := &pb.ContainerStatus{}
 	if containerStatus != nil {
@@ -476,25 +1212,71 @@ func toRPCContainer(_ context.Context, c *types.Container) (*pb.Container, error
 		)
 	}
 	cpu := toRPCCPUMap(c.CPU)
+	var restart *pb.RestartPolicy
+	if c.Restart != nil {
+		restart = &pb.RestartPolicy{
+			Policy:     c.Restart.Policy,
+			MaxRetries: int32(c.Restart.MaxRetries),
+			Backoff:    int32(c.Restart.Backoff),
+			Window:     int32(c.Restart.Window),
+		}
+	}
 	return &pb.Container{
-		Id:         c.ID,
-		Podname:    c.Podname,
-		Nodename:   c.Nodename,
-		Name:       c.Name,
-		Cpu:        cpu,
-		Quota:      c.Quota,
-		Memory:     c.Memory,
-		Storage:    c.Storage,
-		Privileged: c.Privileged,
-		Publish:    publish,
-		Image:      c.Image,
-		Labels:     c.Labels,
-		Volumes:    c.Volumes.ToStringSlice(false, false),
-		VolumePlan: toRPCVolumePlan(c.VolumePlan),
-		Status:     toRPCContainerStatus(c.StatusMeta),
+		Id:            c.ID,
+		Podname:       c.Podname,
+		Nodename:      c.Nodename,
+		Name:          c.Name,
+		Cpu:           cpu,
+		Quota:         c.Quota,
+		CpuShares:     c.CPUShares,
+		Hugepages:     c.Hugepages,
+		Gpu:           c.GPU,
+		Memory:        c.Memory,
+		Storage:       c.Storage,
+		Privileged:    c.Privileged,
+		Publish:       publish,
+		Image:         c.Image,
+		Labels:        c.Labels,
+		Annotations:   c.Annotations,
+		Volumes:       c.Volumes.ToStringSlice(false, false),
+		VolumePlan:    toRPCVolumePlan(c.VolumePlan),
+		Status:        toRPCContainerStatus(c.StatusMeta),
+		RestartPolicy: restart,
+		Exits:         toRPCContainerExits(c.Exits),
+		Priority:      int32(c.Priority),
 	}, nil
 }
 
+func toRPCContainerExits(e *types.ContainerExits) *pb.ContainerExits {
+	if e == nil {
+		return nil
+	}
+	r := &pb.ContainerExits{
+		OomKills:     int64(e.OOMKills),
+		Exits:        int64(e.Exits),
+		LastExitCode: int32(e.LastExitCode),
+	}
+	if !e.LastOOMKill.IsZero() {
+		r.LastOomKill = e.LastOOMKill.Unix()
+	}
+	if !e.LastExit.IsZero() {
+		r.LastExit = e.LastExit.Unix()
+	}
+	return r
+}
+
+func toCoreContainerEvents(events []*pb.ContainerEvent) []*types.ContainerEvent {
+	r := []*types.ContainerEvent{}
+	for _, e := range events {
+		event := &types.ContainerEvent{ID: e.Id, Kind: e.Kind, ExitCode: int(e.ExitCode)}
+		if e.Time > 0 {
+			event.Time = time.Unix(e.Time, 0)
+		}
+		r = append(r, event)
+	}
+	return r
+}
+
 func toRPCLogStreamMessage(msg *types.LogStreamMessage) *pb.LogStreamMessage {
 	r := &pb.LogStreamMessage{
 		Id:   msg.ID,
@@ -19,7 +19,7 @@ func min(a, b int) int {
 	return b
 }
 
-func cpuPriorPlan(cpu float64, memory int64, nodesInfo []types.NodeInfo, maxShareCore, coreShare int) ([]types.NodeInfo, map[string][]types.CPUMap, int, error) {
+func cpuPriorPlan(cpu float64, memory int64, nodesInfo []types.NodeInfo, maxShareCore, coreShare int, performance bool) ([]types.NodeInfo, map[string][]types.CPUMap, int, error) {
 	var nodeContainer = map[string][]types.CPUMap{}
 	volTotal := 0
 
@@ -46,7 +46,7 @@ func cpuPriorPlan(cpu float64, memory int64, nodesInfo []types.NodeInfo, maxShar
 			if !ok {
 				continue
 			}
-			cap, plan := calculateCPUPlan(nodeCPUMap, nodeMemCap, cpu, memory, maxShareCore, coreShare)
+			cap, plan := calculateCPUPlan(nodeCPUMap, nodeMemCap, cpu, memory, maxShareCore, coreShare, performance)
 			if cap > 0 {
 				if _, ok := nodeContainer[nodeInfo.Name]; !ok {
 					nodeContainer[nodeInfo.Name] = []types.CPUMap{}
@@ -62,7 +62,7 @@ func cpuPriorPlan(cpu float64, memory int64, nodesInfo []types.NodeInfo, maxShar
 		}
 		// 非 numa
 		// 或者是扣掉 numa 分配后剩下的资源里面
-		cap, plan := calculateCPUPlan(globalCPUMap, globalMemCap, cpu, memory, maxShareCore, coreShare)
+		cap, plan := calculateCPUPlan(globalCPUMap, globalMemCap, cpu, memory, maxShareCore, coreShare, performance)
 		if cap > 0 {
 			if _, ok := nodeContainer[nodeInfo.Name]; !ok {
 				nodeContainer[nodeInfo.Name] = []types.CPUMap{}
@@ -84,8 +84,9 @@ func cpuPriorPlan(cpu float64, memory int64, nodesInfo []types.NodeInfo, maxShar
 	return nodesInfo[p:], nodeContainer, volTotal, nil
 }
 
-func calculateCPUPlan(CPUMap types.CPUMap, MemCap int64, cpu float64, memory int64, maxShareCore, coreShare int) (int, []types.CPUMap) {
+func calculateCPUPlan(CPUMap types.CPUMap, MemCap int64, cpu float64, memory int64, maxShareCore, coreShare int, performance bool) (int, []types.CPUMap) {
 	host := newHost(CPUMap, coreShare)
+	host.performance = performance
 	plan := host.distributeOneRation(cpu, maxShareCore)
 	memLimit := math.MaxInt16
 	if memory != 0 {
@@ -1,6 +1,9 @@
 package complexscheduler
 
 import (
+	"fmt"
+	"math/rand"
+	"strconv"
 	"testing"
 
 	"github.com/docker/go-units"
@@ -12,13 +15,13 @@ import (
 func TestCPUPriorPlan(t *testing.T) {
 	// normal 分配
 	nodesInfo := resetNodesInfo()
-	_, resultCPUPlan, total, err := cpuPriorPlan(3.0, int64(units.MiB), nodesInfo, -1, 100)
+	_, resultCPUPlan, total, err := cpuPriorPlan(3.0, int64(units.MiB), nodesInfo, -1, 100, false)
 	assert.NoError(t, err)
 	assert.Equal(t, len(resultCPUPlan), 1)
 	assert.Equal(t, total, 1)
 	// numa 分配
 	nodesInfo = resetNodesInfo()
-	_, resultCPUPlan, total, err = cpuPriorPlan(1.5, int64(units.MiB), nodesInfo, -1, 100)
+	_, resultCPUPlan, total, err = cpuPriorPlan(1.5, int64(units.MiB), nodesInfo, -1, 100, false)
 	assert.NoError(t, err)
 	assert.Equal(t, len(resultCPUPlan), 1)
 	assert.Equal(t, total, 2)
@@ -32,7 +35,7 @@ func TestCPUPriorPlan(t *testing.T) {
 	}
 	// numa and normal 分配
 	nodesInfo = resetNodesInfo()
-	_, resultCPUPlan, total, err = cpuPriorPlan(1, int64(units.GiB), nodesInfo, -1, 100)
+	_, resultCPUPlan, total, err = cpuPriorPlan(1, int64(units.GiB), nodesInfo, -1, 100, false)
 	assert.NoError(t, err)
 	assert.Equal(t, len(resultCPUPlan), 1)
 	assert.Equal(t, total, 3)
@@ -57,3 +60,44 @@ func resetNodesInfo() []types.NodeInfo {
 		},
 	}
 }
+
+func TestCPUPlanPerformance(t *testing.T) {
+	r := rand.New(rand.NewSource(1)) // nolint
+	for round := 0; round < 50; round++ {
+		cpuMap := types.CPUMap{}
+		for i := 0; i < 64; i++ {
+			// full cores and fractional shares
+			cpuMap[strconv.Itoa(i)] = []int64{100, 100, 100, 30, 50, 75}[r.Intn(6)]
+		}
+		for _, cpu := range []float64{0.3, 0.5, 1, 1.5, 2.7, 4} {
+			for _, maxShare := range []int{-1, 64} {
+				h := newHost(cpuMap, 100)
+				fast := &host{share: h.share, performance: true}
+				fast.full = append(fast.full, h.full...)
+				fast.fragment = append(fast.fragment, h.fragment...)
+				assert.Equal(t, h.distributeOneRation(cpu, maxShare), fast.distributeOneRation(cpu, maxShare), fmt.Sprintf("cpu %v maxshare %d", cpu, maxShare))
+			}
+		}
+	}
+}
+
+func benchmarkCPUPlan(b *testing.B, cores int, cpu float64, performance bool) {
+	cpuMap := types.CPUMap{}
+	for i := 0; i < cores; i++ {
+		cpuMap[strconv.Itoa(i)] = 100
+		if i%3 == 0 {
+			cpuMap[strconv.Itoa(i)] = 40
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		calculateCPUPlan(cpuMap, int64(units.TiB), cpu, int64(units.MiB), -1, 100, performance)
+	}
+}
+
+func BenchmarkCPUPlan128(b *testing.B)                    { benchmarkCPUPlan(b, 128, 1.5, false) }
+func BenchmarkCPUPlan128Performance(b *testing.B)         { benchmarkCPUPlan(b, 128, 1.5, true) }
+func BenchmarkCPUPlan256(b *testing.B)                    { benchmarkCPUPlan(b, 256, 1.5, false) }
+func BenchmarkCPUPlan256Performance(b *testing.B)         { benchmarkCPUPlan(b, 256, 1.5, true) }
+func BenchmarkCPUPlan256Fragment(b *testing.B)            { benchmarkCPUPlan(b, 256, 0.3, false) }
+func BenchmarkCPUPlan256FragmentPerformance(b *testing.B) { benchmarkCPUPlan(b, 256, 0.3, true) }
@@ -0,0 +1,39 @@
+package complexscheduler
+
+import (
+	"sort"
+
+	"github.com/projecteru2/core/types"
+)
+
+// calculateGPUPlan gives at most limit plans of count different devices with share each,
+// devices already shared are used first so whole devices are left for exclusive requests
+func calculateGPUPlan(gpu types.GPUMap, count int, share int64, limit int) []types.GPUMap {
+	free := types.GPUMap{}
+	free.Add(gpu)
+	plans := []types.GPUMap{}
+	for len(plans) < limit {
+		devices := []string{}
+		for device, left := range free {
+			if left >= share {
+				devices = append(devices, device)
+			}
+		}
+		if len(devices) < count {
+			break
+		}
+		sort.Slice(devices, func(i, j int) bool {
+			if free[devices[i]] != free[devices[j]] {
+				return free[devices[i]] < free[devices[j]]
+			}
+			return devices[i] < devices[j]
+		})
+		plan := types.GPUMap{}
+		for _, device := range devices[:count] {
+			plan[device] = share
+			free[device] -= share
+		}
+		plans = append(plans, plan)
+	}
+	return plans
+}
@@ -0,0 +1,34 @@
+package complexscheduler
+
+import (
+	"sort"
+
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// PackDivisionPlan 尽量少用节点
+// nodes already have containers go first, then the busiest ones, each node is filled up before next
+func PackDivisionPlan(nodesInfo []types.NodeInfo, need int, resourceType types.ResourceType) ([]types.NodeInfo, error) {
+	sort.SliceStable(nodesInfo, func(i, j int) bool {
+		if (nodesInfo[i].Count > 0) != (nodesInfo[j].Count > 0) {
+			return nodesInfo[i].Count > 0
+		}
+		return nodesInfo[i].GetResourceUsage(resourceType) > nodesInfo[j].GetResourceUsage(resourceType)
+	})
+	for i := range nodesInfo {
+		if need <= 0 {
+			break
+		}
+		deploy := nodesInfo[i].Capacity
+		if deploy > need {
+			deploy = need
+		}
+		nodesInfo[i].Deploy += deploy
+		nodesInfo[i].Capacity -= deploy
+		need -= deploy
+	}
+	// 同样 total 保证了 need 一定为 0
+	log.Debugf("[PackDivisionPlan] resource: %v, nodesInfo: %v", resourceType, nodesInfo)
+	return nodesInfo, nil
+}
@@ -0,0 +1,29 @@
+package complexscheduler
+
+import (
+	"testing"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackDivisionPlan(t *testing.T) {
+	nodes := []types.NodeInfo{
+		{Name: "n1", Capacity: 10, Usages: map[types.ResourceType]float64{types.ResourceMemory: 0.1}},
+		{Name: "n2", Capacity: 3, Usages: map[types.ResourceType]float64{types.ResourceMemory: 0.7}},
+		{Name: "n3", Capacity: 5, Count: 1, Usages: map[types.ResourceType]float64{types.ResourceMemory: 0.2}},
+	}
+	r, err := PackDivisionPlan(nodes, 7, types.ResourceMemory)
+	assert.NoError(t, err)
+	deploy := map[string]int{}
+	for _, nodeInfo := range r {
+		deploy[nodeInfo.Name] = nodeInfo.Deploy
+	}
+	// nodes with containers first, then the busiest
+	assert.Equal(t, deploy, map[string]int{"n1": 0, "n2": 2, "n3": 5})
+
+	s, err := New(types.Config{Scheduler: types.SchedConfig{MaxShare: -1, ShareBase: 100}})
+	assert.NoError(t, err)
+	_, err = s.PackDivision(nodes, 20, 11, types.ResourceMemory)
+	assert.Error(t, err)
+}
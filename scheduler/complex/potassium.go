@@ -14,11 +14,12 @@ import (
 // Potassium is a scheduler
 type Potassium struct {
 	maxshare, sharebase int
+	performance         bool
 }
 
 // New a potassium
 func New(config types.Config) (*Potassium, error) {
-	return &Potassium{config.Scheduler.MaxShare, config.Scheduler.ShareBase}, nil
+	return &Potassium{config.Scheduler.MaxShare, config.Scheduler.ShareBase, config.Scheduler.Performance}, nil
 }
 
 // MaxIdleNode use for build
@@ -70,6 +71,63 @@ func (m *Potassium) SelectStorageNodes(nodesInfo []types.NodeInfo, storage int64
 	return nodesInfo, total, nil
 }
 
+// SelectHugepageNodes filter nodes with enough hugepages of every requested size
+func (m *Potassium) SelectHugepageNodes(nodesInfo []types.NodeInfo, hugepages types.HugepageMap) ([]types.NodeInfo, int, error) {
+	if hugepages.Total() == 0 {
+		return nodesInfo, math.MaxInt32, nil
+	}
+	log.Infof("[SelectHugepageNodes] nodesInfo: %v, need: %v", nodesInfo, hugepages)
+
+	result := []types.NodeInfo{}
+	total := 0
+	for _, nodeInfo := range nodesInfo {
+		capacity := nodeInfo.Capacity
+		for size, count := range hugepages {
+			if count <= 0 {
+				continue
+			}
+			capacity = utils.Min(capacity, int(nodeInfo.Hugepages[size]/count))
+		}
+		if capacity <= 0 {
+			continue
+		}
+		nodeInfo.Capacity = capacity
+		total += capacity
+		result = append(result, nodeInfo)
+	}
+	if len(result) == 0 {
+		return nil, 0, types.ErrInsufficientHugepages
+	}
+	return result, total, nil
+}
+
+// SelectGPUNodes filter nodes with enough gpu devices, and plans devices of each container
+func (m *Potassium) SelectGPUNodes(nodesInfo []types.NodeInfo, request *types.GPURequest) ([]types.NodeInfo, map[string][]types.GPUMap, int, error) {
+	share := request.DeviceShare()
+	if share == 0 {
+		return nodesInfo, nil, math.MaxInt32, nil
+	}
+	log.Infof("[SelectGPUNodes] nodesInfo: %v, need: %v", nodesInfo, request)
+
+	result := []types.NodeInfo{}
+	nodeGPUPlans := map[string][]types.GPUMap{}
+	total := 0
+	for _, nodeInfo := range nodesInfo {
+		plans := calculateGPUPlan(nodeInfo.GPU, request.Count, share, nodeInfo.Capacity)
+		if len(plans) == 0 {
+			continue
+		}
+		nodeInfo.Capacity = len(plans)
+		nodeGPUPlans[nodeInfo.Name] = plans
+		total += nodeInfo.Capacity
+		result = append(result, nodeInfo)
+	}
+	if len(result) == 0 {
+		return nil, nil, 0, types.ErrInsufficientGPU
+	}
+	return result, nodeGPUPlans, total, nil
+}
+
 // SelectMemoryNodes filter nodes with enough memory
 func (m *Potassium) SelectMemoryNodes(nodesInfo []types.NodeInfo, quota float64, memory int64) ([]types.NodeInfo, int, error) {
 	log.Infof("[SelectMemoryNodes] nodesInfo: %v, need cpu: %f, memory: %d", nodesInfo, quota, memory)
@@ -123,7 +181,7 @@ func (m *Potassium) SelectCPUNodes(nodesInfo []types.NodeInfo, quota float64, me
 	if len(nodesInfo) == 0 {
 		return nil, nil, 0, types.ErrZeroNodes
 	}
-	return cpuPriorPlan(quota, memory, nodesInfo, m.maxshare, m.sharebase)
+	return cpuPriorPlan(quota, memory, nodesInfo, m.maxshare, m.sharebase, m.performance)
 }
 
 // SelectVolumeNodes calculates plans for volume request
@@ -242,3 +300,13 @@ func (m *Potassium) GlobalDivision(nodesInfo []types.NodeInfo, need, total int,
 	}
 	return GlobalDivisionPlan(nodesInfo, need, globalResource)
 }
+
+// PackDivision deploy containers on as few nodes as possible
+// need 是所需总量，total 是支持部署总量
+func (m *Potassium) PackDivision(nodesInfo []types.NodeInfo, need, total int, resourceType types.ResourceType) ([]types.NodeInfo, error) {
+	if total < need {
+		return nil, types.NewDetailedErr(types.ErrInsufficientRes,
+			fmt.Sprintf("need: %d, vol: %d", need, total))
+	}
+	return PackDivisionPlan(nodesInfo, need, resourceType)
+}
@@ -1609,3 +1609,65 @@ func TestSelectUnlimited(t *testing.T) {
 	assert.Equal(t, res["2"][1][types.MustToVolumeBinding("AUTO:/data4:rw:0")], types.VolumeMap{"/data4": 0})
 
 }
+
+func TestSelectHugepageNodes(t *testing.T) {
+	k, _ := newPotassium()
+	nodesInfo := generateNodes(2, 2, 4*int64(units.GiB), int64(units.GiB), 10)
+	_, total, err := k.SelectHugepageNodes(nodesInfo, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, math.MaxInt32, total)
+
+	nodesInfo, _, err = k.SelectMemoryNodes(nodesInfo, 1.0, int64(units.GiB))
+	assert.NoError(t, err)
+	_, _, err = k.SelectHugepageNodes(nodesInfo, types.HugepageMap{types.Hugepage2Mi: 1})
+	assert.Equal(t, types.ErrInsufficientHugepages, err)
+
+	nodesInfo[0].Hugepages = types.HugepageMap{types.Hugepage2Mi: 1024, types.Hugepage1Gi: 2}
+	res, total, err := k.SelectHugepageNodes(nodesInfo, types.HugepageMap{types.Hugepage2Mi: 512})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, 2, total)
+	res, total, err = k.SelectHugepageNodes(nodesInfo, types.HugepageMap{types.Hugepage2Mi: 100, types.Hugepage1Gi: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, 2, total)
+}
+
+func TestSelectGPUNodes(t *testing.T) {
+	k, _ := newPotassium()
+	nodesInfo := generateNodes(2, 2, 4*int64(units.GiB), int64(units.GiB), 10)
+	_, plans, total, err := k.SelectGPUNodes(nodesInfo, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, plans)
+	assert.Equal(t, math.MaxInt32, total)
+
+	nodesInfo, _, err = k.SelectMemoryNodes(nodesInfo, 1.0, int64(units.GiB))
+	assert.NoError(t, err)
+	_, _, _, err = k.SelectGPUNodes(nodesInfo, &types.GPURequest{Count: 1, Exclusive: true})
+	assert.Equal(t, types.ErrInsufficientGPU, err)
+
+	nodesInfo[0].GPU = types.GPUMap{"0": 100, "1": 100, "2": 40}
+	res, plans, total, err := k.SelectGPUNodes(nodesInfo, &types.GPURequest{Count: 2, Exclusive: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, 1, total)
+	assert.Equal(t, []types.GPUMap{{"0": 100, "1": 100}}, plans[res[0].Name])
+
+	// shared devices are used first, capacity is still limited by memory
+	res, plans, total, err = k.SelectGPUNodes(nodesInfo, &types.GPURequest{Count: 1, Share: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, total)
+	assert.Equal(t, types.GPUMap{"2": 20}, plans[res[0].Name][0])
+	assert.Equal(t, types.GPUMap{"2": 20}, plans[res[0].Name][1])
+	assert.Equal(t, types.GPUMap{"0": 20}, plans[res[0].Name][2])
+}
+
+func TestCalculateGPUPlan(t *testing.T) {
+	gpu := types.GPUMap{"0": 100, "1": 50}
+	assert.Len(t, calculateGPUPlan(gpu, 2, 50, 10), 1)
+	assert.Len(t, calculateGPUPlan(gpu, 1, 50, 10), 3)
+	assert.Len(t, calculateGPUPlan(gpu, 1, 50, 2), 2)
+	assert.Empty(t, calculateGPUPlan(gpu, 3, 10, 10))
+	// gpu is not changed
+	assert.Equal(t, types.GPUMap{"0": 100, "1": 50}, gpu)
+}
@@ -15,6 +15,8 @@ type host struct {
 	full     []resourceInfo
 	fragment []resourceInfo
 	share    int
+	// count candidates instead of building them
+	performance bool
 }
 
 func newHost(resourceMap types.ResourceMap, share int) *host {
@@ -41,6 +43,9 @@ func newHost(resourceMap types.ResourceMap, share int) *host {
 }
 
 func (h *host) getComplexResult(full int, fragment int64, maxShare int) []types.ResourceMap {
+	if h.performance {
+		return h.getComplexResultFast(full, fragment, maxShare)
+	}
 	if maxShare == -1 {
 		maxShare = len(h.full) - full // 减枝，M == N 的情况下预留至少一个 full 量的核数
 	} else {
@@ -85,6 +90,87 @@ func (h *host) getComplexResult(full int, fragment int64, maxShare int) []types.
 	return result
 }
 
+// getComplexResultFast picks the same split of full and fragment cores as getComplexResult,
+// but only counts plans of each split, plans are built once for the best one
+func (h *host) getComplexResultFast(full int, fragment int64, maxShare int) []types.ResourceMap {
+	if maxShare == -1 {
+		maxShare = len(h.full) - full
+	} else {
+		maxShare -= len(h.fragment)
+	}
+
+	fragmentCount := countFragments(fragment, h.fragment)
+	baseLine := min(fragmentCount, h.countFull(full, h.full))
+	best := 0
+	for i := 1; i < maxShare+1; i++ {
+		fragmentCount += int(h.full[i-1].pieces / fragment)
+		canDeployNum := min(fragmentCount, h.countFull(full, h.full[i:]))
+		if canDeployNum > baseLine {
+			baseLine = canDeployNum
+			best = i
+		}
+	}
+
+	fragments := make([]resourceInfo, 0, len(h.fragment)+best)
+	fragments = append(append(fragments, h.fragment...), h.full[:best]...)
+	fragmentResult := h.getSingleFragmentResult(fragment, fragments)
+	fullResult := h.getFullResult(full, h.full[best:])
+
+	result := []types.ResourceMap{}
+	for i := 0; i < baseLine; i++ {
+		r := types.ResourceMap{}
+		for id, pieces := range fullResult[i] {
+			r[id] += pieces
+		}
+		for id, pieces := range fragmentResult[i] {
+			r[id] = pieces
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+// countFragments counts plans of one fragment on resources, every resource takes pieces/fragment plans
+func countFragments(fragment int64, resources []resourceInfo) int {
+	count := 0
+	for _, resource := range resources {
+		count += int(resource.pieces / fragment)
+	}
+	return count
+}
+
+// countFull counts plans of getFullResult without building them
+func (h *host) countFull(full int, resources []resourceInfo) int {
+	pieces := make([]int64, len(resources))
+	for i, resource := range resources {
+		pieces[i] = resource.pieces
+	}
+	count := 0
+	for len(pieces)/full > 0 {
+		n := len(pieces) / full
+		count += n
+		left := []int64{}
+		for _, p := range pieces[:n*full] {
+			if p -= int64(h.share); p > 0 {
+				left = append(left, p)
+			}
+		}
+		pieces = left
+	}
+	return count
+}
+
+// getSingleFragmentResult is getFragmentResult of one fragment, plans are in order of resources
+func (h *host) getSingleFragmentResult(fragment int64, resources []resourceInfo) []types.ResourceMap {
+	result := []types.ResourceMap{}
+	for _, resource := range resources {
+		for i := int64(0); i < resource.pieces/fragment; i++ {
+			result = append(result, types.ResourceMap{resource.id: fragment})
+		}
+	}
+	return result
+}
+
 func (h *host) getFragmentResult(fragment int64, resources []resourceInfo) []types.ResourceMap {
 	resourceMaps := h.getFragmentsResult(resources, fragment)
 	result := make([]types.ResourceMap, len(resourceMaps))
@@ -217,6 +303,9 @@ func (h *host) distributeOneRation(ration float64, maxShare int) []types.Resourc
 		diff := maxShare - len(h.fragment)
 		h.fragment = append(h.fragment, h.full[:diff]...)
 
+		if h.performance {
+			return h.getSingleFragmentResult(fragmentRequire, h.fragment)
+		}
 		return h.getFragmentResult(fragmentRequire, h.fragment)
 	}
 
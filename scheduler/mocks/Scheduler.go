@@ -126,6 +126,29 @@ func (_m *Scheduler) MaxIdleNode(nodes []*types.Node) (*types.Node, error) {
 	return r0, r1
 }
 
+// PackDivision provides a mock function with given fields: nodesInfo, need, total, resourceType
+func (_m *Scheduler) PackDivision(nodesInfo []types.NodeInfo, need int, total int, resourceType types.ResourceType) ([]types.NodeInfo, error) {
+	ret := _m.Called(nodesInfo, need, total, resourceType)
+
+	var r0 []types.NodeInfo
+	if rf, ok := ret.Get(0).(func([]types.NodeInfo, int, int, types.ResourceType) []types.NodeInfo); ok {
+		r0 = rf(nodesInfo, need, total, resourceType)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.NodeInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]types.NodeInfo, int, int, types.ResourceType) error); ok {
+		r1 = rf(nodesInfo, need, total, resourceType)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SelectCPUNodes provides a mock function with given fields: nodesInfo, quota, memory
 func (_m *Scheduler) SelectCPUNodes(nodesInfo []types.NodeInfo, quota float64, memory int64) ([]types.NodeInfo, map[string][]types.ResourceMap, int, error) {
 	ret := _m.Called(nodesInfo, quota, memory)
@@ -165,6 +188,75 @@ func (_m *Scheduler) SelectCPUNodes(nodesInfo []types.NodeInfo, quota float64, m
 	return r0, r1, r2, r3
 }
 
+// SelectGPUNodes provides a mock function with given fields: nodesInfo, request
+func (_m *Scheduler) SelectGPUNodes(nodesInfo []types.NodeInfo, request *types.GPURequest) ([]types.NodeInfo, map[string][]types.GPUMap, int, error) {
+	ret := _m.Called(nodesInfo, request)
+
+	var r0 []types.NodeInfo
+	if rf, ok := ret.Get(0).(func([]types.NodeInfo, *types.GPURequest) []types.NodeInfo); ok {
+		r0 = rf(nodesInfo, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.NodeInfo)
+		}
+	}
+
+	var r1 map[string][]types.GPUMap
+	if rf, ok := ret.Get(1).(func([]types.NodeInfo, *types.GPURequest) map[string][]types.GPUMap); ok {
+		r1 = rf(nodesInfo, request)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(map[string][]types.GPUMap)
+		}
+	}
+
+	var r2 int
+	if rf, ok := ret.Get(2).(func([]types.NodeInfo, *types.GPURequest) int); ok {
+		r2 = rf(nodesInfo, request)
+	} else {
+		r2 = ret.Get(2).(int)
+	}
+
+	var r3 error
+	if rf, ok := ret.Get(3).(func([]types.NodeInfo, *types.GPURequest) error); ok {
+		r3 = rf(nodesInfo, request)
+	} else {
+		r3 = ret.Error(3)
+	}
+
+	return r0, r1, r2, r3
+}
+
+// SelectHugepageNodes provides a mock function with given fields: nodesInfo, hugepages
+func (_m *Scheduler) SelectHugepageNodes(nodesInfo []types.NodeInfo, hugepages types.ResourceMap) ([]types.NodeInfo, int, error) {
+	ret := _m.Called(nodesInfo, hugepages)
+
+	var r0 []types.NodeInfo
+	if rf, ok := ret.Get(0).(func([]types.NodeInfo, types.ResourceMap) []types.NodeInfo); ok {
+		r0 = rf(nodesInfo, hugepages)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.NodeInfo)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func([]types.NodeInfo, types.ResourceMap) int); ok {
+		r1 = rf(nodesInfo, hugepages)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func([]types.NodeInfo, types.ResourceMap) error); ok {
+		r2 = rf(nodesInfo, hugepages)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // SelectMemoryNodes provides a mock function with given fields: nodesInfo, quota, memory
 func (_m *Scheduler) SelectMemoryNodes(nodesInfo []types.NodeInfo, quota float64, memory int64) ([]types.NodeInfo, int, error) {
 	ret := _m.Called(nodesInfo, quota, memory)
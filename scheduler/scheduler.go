@@ -10,6 +10,10 @@ type Scheduler interface {
 	// typically used to build image
 	MaxIdleNode(nodes []*types.Node) (*types.Node, error)
 	SelectStorageNodes(nodesInfo []types.NodeInfo, storage int64) ([]types.NodeInfo, int, error)
+	// select nodes with enough hugepages of each requested page size
+	SelectHugepageNodes(nodesInfo []types.NodeInfo, hugepages types.HugepageMap) ([]types.NodeInfo, int, error)
+	// select nodes with enough gpu devices, return devices planned for each container
+	SelectGPUNodes(nodesInfo []types.NodeInfo, request *types.GPURequest) ([]types.NodeInfo, map[string][]types.GPUMap, int, error)
 	SelectMemoryNodes(nodesInfo []types.NodeInfo, quota float64, memory int64) ([]types.NodeInfo, int, error)
 	// select nodes from nodes, return a list of nodenames and the corresponding cpumap, and also the changed nodes with remaining cpumap
 	// quota and number must be given, typically used to determine where to deploy
@@ -20,6 +24,8 @@ type Scheduler interface {
 	GlobalDivision(nodesInfo []types.NodeInfo, need, total int, resourceType types.ResourceType) ([]types.NodeInfo, error)
 	// common division
 	CommonDivision(nodesInfo []types.NodeInfo, need, total int, resourceType types.ResourceType) ([]types.NodeInfo, error)
+	// pack division
+	PackDivision(nodesInfo []types.NodeInfo, need, total int, resourceType types.ResourceType) ([]types.NodeInfo, error)
 	// average division
 	EachDivision(nodesInfo []types.NodeInfo, need, limit int, resourceType types.ResourceType) ([]types.NodeInfo, error)
 	// fill division
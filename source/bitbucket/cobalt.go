@@ -0,0 +1,47 @@
+package bitbucket
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/projecteru2/core/source/common"
+	"github.com/projecteru2/core/types"
+)
+
+// New new a bitbucket obj
+// with username set token is treated as app password, otherwise as access token
+func New(config types.Config) (*common.GitScm, error) {
+	gitConfig := config.Git
+	token := fmt.Sprintf("Bearer %s", gitConfig.Token)
+	if gitConfig.Username != "" {
+		cred := base64.StdEncoding.EncodeToString([]byte(gitConfig.Username + ":" + gitConfig.Token))
+		token = fmt.Sprintf("Basic %s", cred)
+	}
+	authHeaders := map[string]string{"Authorization": token}
+	scm, err := common.NewGitScm(gitConfig, authHeaders)
+	if scm != nil {
+		scm.CloneUser = "x-token-auth"
+		scm.ArchiveURL = archiveURL
+		scm.ObjectStorage = config.ObjectStorage
+	}
+	return scm, err
+}
+
+// archiveURL works for bitbucket cloud and bitbucket server,
+// server repositories are cloned from /scm/{project}/{repo}.git
+func archiveURL(repository, revision string) (string, error) {
+	base, p, err := common.ParseRepository(repository)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Split(p, "/")
+	if len(parts) == 3 && parts[0] == "scm" {
+		// server archive has no top level directory unless prefix given
+		return fmt.Sprintf("%s/rest/api/latest/projects/%s/repos/%s/archive?at=%s&format=zip&prefix=%s/",
+			base, parts[1], parts[2], url.QueryEscape(revision), path.Base(p)), nil
+	}
+	return fmt.Sprintf("%s/%s/get/%s.zip", base, p, revision), nil
+}
@@ -0,0 +1,24 @@
+package bitbucket
+
+import (
+	"testing"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveURL(t *testing.T) {
+	u, err := archiveURL("https://bitbucket.org/owner/repo.git", "master")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://bitbucket.org/owner/repo/get/master.zip", u)
+	u, err = archiveURL("https://bitbucket.example.com/scm/prj/repo.git", "master")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://bitbucket.example.com/rest/api/latest/projects/prj/repos/repo/archive?at=master&format=zip&prefix=repo/", u)
+}
+
+func TestNew(t *testing.T) {
+	scm, _ := New(types.Config{Git: types.GitConfig{Token: "t"}})
+	assert.Equal(t, "Bearer t", scm.AuthHeaders["Authorization"])
+	scm, _ = New(types.Config{Git: types.GitConfig{Token: "t", Username: "u"}})
+	assert.Equal(t, "Basic dTp0", scm.AuthHeaders["Authorization"])
+}
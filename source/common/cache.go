@@ -0,0 +1,117 @@
+package common
+
+import (
+	"context"
+	"crypto/sha1" // nolint
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// mirror all branches and tags, HEAD of cache is always detached so local branches can be force updated
+var cacheRefSpecs = []config.RefSpec{"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"}
+
+var cacheLocks sync.Map
+
+// cachedSourceCode fetch repository into its cache incrementally, checkout revision there,
+// then copy the worktree into path
+func (g *GitScm) cachedSourceCode(ctx context.Context, repository, path, revision string, auth transport.AuthMethod, opts *enginetypes.SourceOptions) (string, error) {
+	dir := filepath.Join(g.Config.CacheDir, cacheKey(repository))
+	mutex, _ := cacheLocks.LoadOrStore(dir, &sync.Mutex{})
+	mutex.(*sync.Mutex).Lock()
+	defer mutex.(*sync.Mutex).Unlock()
+
+	hash, err := g.updateCache(ctx, dir, repository, revision, auth, opts)
+	if err != nil {
+		return "", err
+	}
+	return hash.String(), copyDir(dir, path)
+}
+
+func (g *GitScm) updateCache(ctx context.Context, dir, repository, revision string, auth transport.AuthMethod, opts *enginetypes.SourceOptions) (*plumbing.Hash, error) {
+	repo, err := gogit.PlainOpen(dir)
+	if err == gogit.ErrRepositoryNotExists {
+		log.Infof("[updateCache] Init cache of repo %s", repository)
+		if repo, err = gogit.PlainInit(dir, false); err != nil {
+			return nil, err
+		}
+		_, err = repo.CreateRemote(&config.RemoteConfig{Name: gogit.DefaultRemoteName, URLs: []string{repository}})
+	}
+	if err != nil {
+		// cache is broken, clone from scratch next time
+		log.Warnf("[updateCache] Open cache of %s failed %v, drop it", repository, err)
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	if err = repo.FetchContext(ctx, &gogit.FetchOptions{RefSpecs: cacheRefSpecs, Auth: auth, Force: true}); err != nil && err != gogit.NoErrAlreadyUpToDate {
+		return nil, err
+	}
+	log.Infof("[updateCache] Fetch repo %s", repository)
+
+	hash, err := resolveCacheRevision(repo, revision, auth)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Verify != nil && opts.Verify.Signature {
+		if err = g.verifySignature(repo, *hash, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	w, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	// force to reset changes
+	if err = w.Checkout(&gogit.CheckoutOptions{Hash: *hash, Force: true}); err != nil {
+		log.Warnf("[updateCache] Checkout cache of %s failed %v, drop it", repository, err)
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	log.Infof("[updateCache] Checkout to commit %s", hash)
+
+	if opts.Submodule {
+		err = g.updateSubmodules(ctx, w, opts.Credential)
+	}
+	return hash, err
+}
+
+// resolveCacheRevision resolves HEAD by default branch of remote, for HEAD of cache is detached
+func resolveCacheRevision(repo *gogit.Repository, revision string, auth transport.AuthMethod) (*plumbing.Hash, error) {
+	if revision != string(plumbing.HEAD) {
+		return repo.ResolveRevision(plumbing.Revision(revision))
+	}
+	remote, err := repo.Remote(gogit.DefaultRemoteName)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := remote.List(&gogit.ListOptions{Auth: auth})
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		if ref.Name() != plumbing.HEAD {
+			continue
+		}
+		if ref.Type() == plumbing.SymbolicReference {
+			return repo.ResolveRevision(plumbing.Revision(ref.Target()))
+		}
+		hash := ref.Hash()
+		return &hash, nil
+	}
+	return nil, plumbing.ErrReferenceNotFound
+}
+
+func cacheKey(repository string) string {
+	sum := sha1.Sum([]byte(repository)) // nolint
+	return hex.EncodeToString(sum[:])
+}
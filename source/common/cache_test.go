@@ -0,0 +1,99 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func TestCachedSourceCode(t *testing.T) {
+	originDir, err := ioutil.TempDir("", "origin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(originDir)
+	cacheDir, err := ioutil.TempDir("", "cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+
+	origin, err := gogit.PlainInit(originDir, false)
+	assert.NoError(t, err)
+	w, err := origin.Worktree()
+	assert.NoError(t, err)
+	commit := func(content string) {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(originDir, "version"), []byte(content), 0644))
+		_, err := w.Add("version")
+		assert.NoError(t, err)
+		_, err = w.Commit(content, &gogit.CommitOptions{Author: &object.Signature{Name: "eru", When: time.Now()}})
+		assert.NoError(t, err)
+	}
+
+	g := &GitScm{Config: types.GitConfig{CacheDir: cacheDir}}
+	ctx := context.Background()
+	fetch := func(revision string) string {
+		dst, err := ioutil.TempDir("", "source")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dst)
+		_, err = g.cachedSourceCode(ctx, originDir, dst, revision, nil, &enginetypes.SourceOptions{})
+		assert.NoError(t, err)
+		b, err := ioutil.ReadFile(filepath.Join(dst, "version"))
+		assert.NoError(t, err)
+		return string(b)
+	}
+
+	commit("v1")
+	assert.Equal(t, "v1", fetch("HEAD"))
+	_, err = os.Stat(filepath.Join(cacheDir, cacheKey(originDir), ".git"))
+	assert.NoError(t, err)
+
+	// fetch new commit into cache
+	commit("v2")
+	assert.Equal(t, "v2", fetch("HEAD"))
+	assert.Equal(t, "v2", fetch("master"))
+	assert.Equal(t, "v2", fetch("refs/heads/master"))
+
+	// bad revision keeps the cache
+	dst, err := ioutil.TempDir("", "source")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dst)
+	_, err = g.cachedSourceCode(ctx, originDir, dst, "nothing", nil, &enginetypes.SourceOptions{})
+	assert.Error(t, err)
+	_, err = os.Stat(filepath.Join(cacheDir, cacheKey(originDir)))
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", fetch("master"))
+
+	// verify signature of commit
+	entity, err := openpgp.NewEntity("eru", "", "eru@example.com", nil)
+	assert.NoError(t, err)
+	keyRing := &bytes.Buffer{}
+	aw, err := armor.Encode(keyRing, openpgp.PublicKeyType, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, entity.Serialize(aw))
+	assert.NoError(t, aw.Close())
+	verify := &enginetypes.SourceOptions{Verify: &enginetypes.SourceVerify{Signature: true, KeyRing: keyRing.String()}}
+
+	_, err = g.cachedSourceCode(ctx, originDir, dst, "master", nil, &enginetypes.SourceOptions{Verify: &enginetypes.SourceVerify{Signature: true}})
+	assert.True(t, errors.Is(err, types.ErrNoKeyRing))
+	_, err = g.cachedSourceCode(ctx, originDir, dst, "master", nil, verify)
+	assert.True(t, errors.Is(err, types.ErrBadSignature))
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(originDir, "version"), []byte("v3"), 0644))
+	_, err = w.Add("version")
+	assert.NoError(t, err)
+	hash, err := w.Commit("v3", &gogit.CommitOptions{Author: &object.Signature{Name: "eru", When: time.Now()}, SignKey: entity})
+	assert.NoError(t, err)
+	signed, err := g.cachedSourceCode(ctx, originDir, dst, "master", nil, verify)
+	assert.NoError(t, err)
+	assert.Equal(t, hash.String(), signed)
+}
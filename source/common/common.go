@@ -1,7 +1,10 @@
 package common
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -9,20 +12,34 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	enginetypes "github.com/projecteru2/core/engine/types"
 	"github.com/projecteru2/core/types"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/openpgp"
 	"golang.org/x/crypto/ssh"
 )
 
-// GitScm is gitlab or github source code manager
+// ArchiveURLFunc builds the archive download url of repository at revision
+type ArchiveURLFunc func(repository, revision string) (string, error)
+
+// GitScm is gitlab, github, gitea or bitbucket source code manager
 type GitScm struct {
 	http.Client
 	Config      types.GitConfig
 	AuthHeaders map[string]string
+	// CloneUser is the username paired with token when cloning over https
+	CloneUser string
+	// ArchiveURL is used to fetch source code by archive instead of cloning
+	ArchiveURL ArchiveURLFunc
+	// ObjectStorage holds credentials for artifacts like s3://bucket/key or oss://bucket/key
+	ObjectStorage types.ObjectStorageConfig
 
 	keyBytes []byte
 }
@@ -37,83 +54,241 @@ func NewGitScm(config types.GitConfig, authHeaders map[string]string) (*GitScm,
 	}, err
 }
 
-// SourceCode clone code from repository into path, by revision
-func (g *GitScm) SourceCode(ctx context.Context, repository, path, revision string, submodule bool) error {
-	var repo *gogit.Repository
-	var err error
+// SourceCode clone code from repository into path by branch, tag, commit or revision
+// credential overrides config for repository and submodules if given
+// returns the fetched commit, or sha256 of archive
+func (g *GitScm) SourceCode(ctx context.Context, repository, path string, opts *enginetypes.SourceOptions) (string, error) {
+	ref, revision, err := sourceReference(opts)
+	if err != nil {
+		return "", err
+	}
 	ctx, cancel := context.WithTimeout(ctx, g.Config.CloneTimeout)
 	defer cancel()
+	checksum, signature := "", false
+	if opts.Verify != nil {
+		checksum, signature = opts.Verify.Checksum, opts.Verify.Signature
+	}
+	archivable := strings.Contains(repository, "https://") && g.ArchiveURL != nil && !opts.Submodule && opts.Credential == nil && !signature
 	switch {
-	case strings.Contains(repository, "https://"):
-		repo, err = gogit.PlainCloneContext(ctx, path, false, &gogit.CloneOptions{
-			URL: repository,
-		})
-	case strings.Contains(repository, "git@") || strings.Contains(repository, "gitlab@"):
-		signer, signErr := ssh.ParsePrivateKey(g.keyBytes)
-		if signErr != nil {
-			return signErr
-		}
-		splitRepo := strings.Split(repository, "@")
-		user, parseErr := url.Parse(splitRepo[0])
-		if parseErr != nil {
-			return parseErr
-		}
-		auth := &gitssh.PublicKeys{
-			User:   user.Host + user.Path,
-			Signer: signer,
-			HostKeyCallbackHelper: gitssh.HostKeyCallbackHelper{
-				HostKeyCallback: ssh.InsecureIgnoreHostKey(), // nolint
-			},
-		}
-		repo, err = gogit.PlainCloneContext(ctx, path, false, &gogit.CloneOptions{
-			URL:      repository,
-			Progress: ioutil.Discard,
-			Auth:     auth,
-		})
-	default:
-		return types.ErrNotSupport
+	case checksum != "" && !archivable:
+		// checksum is only for archive
+		return "", types.NewDetailedErr(types.ErrNotSupport, "checksum needs archive of https repository without submodule, credential or signature")
+	case archivable && (g.Config.Archive || checksum != ""):
+		return g.sourceArchive(ctx, repository, path, archiveRevision(opts), checksum)
 	}
+
+	auth, err := g.auth(repository, opts.Credential)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if g.Config.CacheDir != "" {
+		// cache keeps full history, depth is useless
+		return g.cachedSourceCode(ctx, repository, path, revision, auth, opts)
+	}
+	cloneOpts := &gogit.CloneOptions{
+		URL:           repository,
+		Auth:          auth,
+		ReferenceName: ref,
+		SingleBranch:  ref != "",
+		Depth:         opts.Depth,
+	}
+	if !strings.Contains(repository, "https://") {
+		cloneOpts.Progress = ioutil.Discard
+	}
+	repo, err := gogit.PlainCloneContext(ctx, path, false, cloneOpts)
+	if err != nil {
+		return "", err
 	}
 
 	w, err := repo.Worktree()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	hash, err := repo.ResolveRevision(plumbing.Revision(revision))
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	if signature {
+		if err = g.verifySignature(repo, *hash, opts); err != nil {
+			return "", err
+		}
 	}
 
 	if err = w.Checkout(&gogit.CheckoutOptions{Hash: *hash}); err != nil {
-		return err
+		return "", err
 	}
 
 	log.Infof("[SourceCode] Fetch repo %s", repository)
 	log.Infof("[SourceCode] Checkout to commit %s", hash)
 
 	// Prepare submodules
-	if submodule {
-		s, err := w.Submodules()
+	if opts.Submodule {
+		err = g.updateSubmodules(ctx, w, opts.Credential)
+	}
+	return hash.String(), err
+}
+
+// verifySignature verifies gpg signature of tag if given, otherwise of commit
+func (g *GitScm) verifySignature(repo *gogit.Repository, hash plumbing.Hash, opts *enginetypes.SourceOptions) error {
+	keyRing := opts.Verify.KeyRing
+	if keyRing == "" && g.Config.KeyRing != "" {
+		b, err := ioutil.ReadFile(g.Config.KeyRing)
+		if err != nil {
+			return err
+		}
+		keyRing = string(b)
+	}
+	if keyRing == "" {
+		return types.ErrNoKeyRing
+	}
+
+	var entity *openpgp.Entity
+	if opts.Tag != "" {
+		ref, err := repo.Reference(plumbing.NewTagReferenceName(opts.Tag), true)
+		if err != nil {
+			return err
+		}
+		// lightweight tag can't be signed
+		tag, err := repo.TagObject(ref.Hash())
+		if err != nil {
+			return types.NewDetailedErr(types.ErrBadSignature, err.Error())
+		}
+		if entity, err = tag.Verify(keyRing); err != nil {
+			return types.NewDetailedErr(types.ErrBadSignature, err.Error())
+		}
+	} else {
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return err
+		}
+		if entity, err = commit.Verify(keyRing); err != nil {
+			return types.NewDetailedErr(types.ErrBadSignature, err.Error())
+		}
+	}
+	for name := range entity.Identities {
+		log.Infof("[verifySignature] Commit %s signed by %s", hash, name)
+	}
+	return nil
+}
+
+// sourceReference returns reference to clone and revision to checkout,
+// commit is checked out from the branch or tag if both given
+func sourceReference(opts *enginetypes.SourceOptions) (plumbing.ReferenceName, string, error) {
+	if opts.Branch != "" && opts.Tag != "" {
+		return "", "", types.ErrBranchTagExclusive
+	}
+	var ref plumbing.ReferenceName
+	revision := opts.Revision
+	switch {
+	case opts.Branch != "":
+		ref = plumbing.NewBranchReferenceName(opts.Branch)
+		revision = ref.String()
+	case opts.Tag != "":
+		ref = plumbing.NewTagReferenceName(opts.Tag)
+		revision = ref.String()
+	}
+	if opts.Commit != "" {
+		revision = opts.Commit
+	}
+	if revision == "" {
+		revision = string(plumbing.HEAD)
+	}
+	return ref, revision, nil
+}
+
+// archiveRevision returns revision known by archive endpoints
+func archiveRevision(opts *enginetypes.SourceOptions) string {
+	for _, revision := range []string{opts.Commit, opts.Tag, opts.Branch, opts.Revision} {
+		if revision != "" {
+			return revision
+		}
+	}
+	return string(plumbing.HEAD)
+}
+
+// updateSubmodules init and update submodules recursively,
+// auth is decided by each submodule url so private submodules over ssh or https both work
+func (g *GitScm) updateSubmodules(ctx context.Context, w *gogit.Worktree, credential *enginetypes.SourceCredential) error {
+	subs, err := w.Submodules()
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		auth, err := g.auth(sub.Config().URL, credential)
+		if err != nil {
+			return err
+		}
+		if err := sub.UpdateContext(ctx, &gogit.SubmoduleUpdateOptions{Init: true, Auth: auth}); err != nil {
+			return err
+		}
+		log.Infof("[updateSubmodules] Update submodule %s", sub.Config().Name)
+
+		repo, err := sub.Repository()
+		if err != nil {
+			return err
+		}
+		subw, err := repo.Worktree()
 		if err != nil {
 			return err
 		}
-		return s.Update(&gogit.SubmoduleUpdateOptions{Init: true})
+		if err := g.updateSubmodules(ctx, subw, credential); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auth returns auth method of repository by its protocol
+func (g *GitScm) auth(repository string, credential *enginetypes.SourceCredential) (transport.AuthMethod, error) {
+	switch {
+	case strings.Contains(repository, "https://"):
+		return g.httpAuth(credential), nil
+	case strings.Contains(repository, "git@") || strings.Contains(repository, "gitlab@"):
+		keyBytes := g.keyBytes
+		if credential != nil && credential.PrivateKey != "" {
+			keyBytes = []byte(credential.PrivateKey)
+		}
+		signer, err := ssh.ParsePrivateKey(keyBytes)
+		if err != nil {
+			return nil, err
+		}
+		splitRepo := strings.Split(repository, "@")
+		user, err := url.Parse(splitRepo[0])
+		if err != nil {
+			return nil, err
+		}
+		return &gitssh.PublicKeys{
+			User:   user.Host + user.Path,
+			Signer: signer,
+			HostKeyCallbackHelper: gitssh.HostKeyCallbackHelper{
+				HostKeyCallback: ssh.InsecureIgnoreHostKey(), // nolint
+			},
+		}, nil
+	default:
+		return nil, types.ErrNotSupport
 	}
-	return err
 }
 
 // Artifact download the artifact to the path, then unzip it
+// artifact in object storage is signed by object storage credentials instead of scm auth headers
 func (g *GitScm) Artifact(artifact, path string) error {
-	req, err := http.NewRequest(http.MethodGet, artifact, nil)
+	var req *http.Request
+	var err error
+	if isObjectURL(artifact) {
+		req, err = newObjectRequest(context.Background(), g.ObjectStorage, artifact, time.Now())
+	} else {
+		req, err = http.NewRequest(http.MethodGet, artifact, nil)
+	}
 	if err != nil {
 		return err
 	}
 
-	for k, v := range g.AuthHeaders {
-		req.Header.Add(k, v)
+	if !isObjectURL(artifact) {
+		for k, v := range g.AuthHeaders {
+			req.Header.Add(k, v)
+		}
 	}
 
 	log.Infof("[Artifact] Downloading artifacts from %q", artifact)
@@ -130,6 +305,124 @@ func (g *GitScm) Artifact(artifact, path string) error {
 	return unzipFile(resp.Body, path)
 }
 
+// Tarball download tarball with its header, then extract it into path
+// checksum is verified if given, returns sha256 of tarball
+func (g *GitScm) Tarball(ctx context.Context, tarball *enginetypes.Tarball, path string) (string, error) {
+	if !strings.HasPrefix(tarball.URL, "https://") {
+		return "", types.NewDetailedErr(types.ErrBadTarballURL, tarball.URL)
+	}
+	ctx, cancel := context.WithTimeout(ctx, g.Config.CloneTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tarball.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	// scm auth headers are not for vendors
+	for k, v := range tarball.Header {
+		req.Header.Add(k, v)
+	}
+
+	log.Infof("[Tarball] Downloading tarball %q", tarball.URL)
+	resp, err := g.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("Download tarball error %q, code %d", tarball.URL, resp.StatusCode)
+	}
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	digest, err := checkDigest(content, tarball.Checksum)
+	if err != nil {
+		return "", err
+	}
+	return digest, extractTarball(content, path)
+}
+
+// checkDigest returns sha256 of content, which must match checksum if given
+func checkDigest(content []byte, checksum string) (string, error) {
+	sum := sha256.Sum256(content)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if checksum != "" && checksum != digest {
+		return "", types.NewDetailedErr(types.ErrSourceChecksum, fmt.Sprintf("expected %s, got %s", checksum, digest))
+	}
+	return digest, nil
+}
+
+// sourceArchive download the archive of repository at revision, then unzip it into path
+// checksum is verified if given, returns sha256 of archive
+func (g *GitScm) sourceArchive(ctx context.Context, repository, path, revision, checksum string) (string, error) {
+	archive, err := g.ArchiveURL(repository, revision)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, archive, nil)
+	if err != nil {
+		return "", err
+	}
+
+	for k, v := range g.AuthHeaders {
+		req.Header.Add(k, v)
+	}
+
+	log.Infof("[sourceArchive] Downloading archive of %s at %s", repository, revision)
+	resp, err := g.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("Download archive error %q, code %d", archive, resp.StatusCode)
+	}
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	digest, err := checkDigest(content, checksum)
+	if err != nil {
+		return "", err
+	}
+	return digest, unzipArchive(bytes.NewReader(content), path)
+}
+
+// httpAuth returns basic auth for https clone if token set
+func (g *GitScm) httpAuth(credential *enginetypes.SourceCredential) transport.AuthMethod {
+	user, token := g.Config.Username, g.Config.Token
+	if credential != nil && credential.Token != "" {
+		user, token = credential.Username, credential.Token
+	}
+	if token == "" {
+		return nil
+	}
+	if user == "" {
+		user = g.CloneUser
+	}
+	return &githttp.BasicAuth{Username: user, Password: token}
+}
+
+// ParseRepository split https repository into base url (scheme and host) and path without .git suffix
+func ParseRepository(repository string) (string, string, error) {
+	u, err := url.Parse(repository)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", "", types.NewDetailedErr(types.ErrNotSupport, repository)
+	}
+	p := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if p == "" {
+		return "", "", types.NewDetailedErr(types.ErrNotSupport, repository)
+	}
+	return fmt.Sprintf("%s://%s", u.Scheme, u.Host), p, nil
+}
+
 // Security remove the .git folder
 func (g *GitScm) Security(path string) error {
 	return os.RemoveAll(filepath.Join(path, ".git"))
@@ -1,8 +1,14 @@
 package common
 
 import (
+	"archive/tar"
 	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -13,6 +19,9 @@ import (
 	"testing"
 	"time"
 
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	enginetypes "github.com/projecteru2/core/engine/types"
 	"github.com/projecteru2/core/types"
 	"github.com/stretchr/testify/assert"
 )
@@ -88,19 +97,19 @@ func TestSourceCode(t *testing.T) {
 
 	dname, err := ioutil.TempDir("", "source")
 	assert.NoError(t, err)
-	err = g.SourceCode(ctx, "file:///xxxx", dname, "MASTER", false)
+	_, err = g.SourceCode(ctx, "file:///xxxx", dname, &enginetypes.SourceOptions{Revision: "MASTER"})
 	assert.Error(t, err)
-	err = g.SourceCode(ctx, "git@xxxx", dname, "MASTER", false)
+	_, err = g.SourceCode(ctx, "git@xxxx", dname, &enginetypes.SourceOptions{Revision: "MASTER"})
 	assert.Error(t, err)
-	err = g.SourceCode(ctx, "gitlab@xxxx", dname, "MASTER", false)
+	_, err = g.SourceCode(ctx, "gitlab@xxxx", dname, &enginetypes.SourceOptions{Revision: "MASTER"})
 	assert.Error(t, err)
 
-	err = g.SourceCode(ctx, repo, dname, "NOTHING", false)
+	_, err = g.SourceCode(ctx, repo, dname, &enginetypes.SourceOptions{Revision: "NOTHING"})
 	assert.Error(t, err)
 
 	os.RemoveAll(dname)
 	os.Mkdir(dname, 0755)
-	err = g.SourceCode(ctx, repo, dname, rev, true)
+	_, err = g.SourceCode(ctx, repo, dname, &enginetypes.SourceOptions{Revision: rev, Submodule: true})
 	assert.NoError(t, err)
 	// auto clone submodule, so vendor can't remove by os.Remove
 	subrepo := filepath.Join(dname, subname)
@@ -168,6 +177,128 @@ func TestArtifact(t *testing.T) {
 	os.RemoveAll(savedDir)
 }
 
+func TestSourceArchive(t *testing.T) {
+	authValue := "test"
+	buf := &bytes.Buffer{}
+	zipWriter := zip.NewWriter(buf)
+	_, err := zipWriter.Create("repo-sha/")
+	assert.NoError(t, err)
+	w, err := zipWriter.Create("repo-sha/Dockerfile")
+	assert.NoError(t, err)
+	w.Write([]byte("FROM scratch"))
+	assert.NoError(t, zipWriter.Close())
+	data := buf.Bytes()
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("TEST") != authValue || req.URL.Path != "/archive/sha.zip" {
+			res.WriteHeader(404)
+			return
+		}
+		res.Write(data)
+	}))
+	defer testServer.Close()
+
+	savedDir, err := ioutil.TempDir("", "archive")
+	assert.NoError(t, err)
+	defer os.RemoveAll(savedDir)
+
+	g := &GitScm{
+		Config:      types.GitConfig{Archive: true, CloneTimeout: time.Minute},
+		AuthHeaders: map[string]string{"TEST": authValue},
+		ArchiveURL: func(repository, revision string) (string, error) {
+			return testServer.URL + "/archive/" + revision + ".zip", nil
+		},
+	}
+	ctx := context.Background()
+	// bad revision
+	_, err = g.SourceCode(ctx, "https://example.com/a/b.git", savedDir, &enginetypes.SourceOptions{Revision: "bad"})
+	assert.Error(t, err)
+	digest, err := g.SourceCode(ctx, "https://example.com/a/b.git", savedDir, &enginetypes.SourceOptions{Revision: "sha"})
+	assert.NoError(t, err)
+	sum := sha256.Sum256(data)
+	assert.Equal(t, "sha256:"+hex.EncodeToString(sum[:]), digest)
+	saved, err := ioutil.ReadFile(filepath.Join(savedDir, "Dockerfile"))
+	assert.NoError(t, err)
+	assert.Equal(t, "FROM scratch", string(saved))
+
+	// checksum forces archive
+	g.Config.Archive = false
+	_, err = g.SourceCode(ctx, "https://example.com/a/b.git", savedDir, &enginetypes.SourceOptions{Revision: "sha", Verify: &enginetypes.SourceVerify{Checksum: "sha256:bad"}})
+	assert.True(t, errors.Is(err, types.ErrSourceChecksum))
+	_, err = g.SourceCode(ctx, "https://example.com/a/b.git", savedDir, &enginetypes.SourceOptions{Revision: "sha", Verify: &enginetypes.SourceVerify{Checksum: digest}})
+	assert.NoError(t, err)
+	// signature needs clone
+	_, err = g.SourceCode(ctx, "https://example.com/a/b.git", savedDir, &enginetypes.SourceOptions{Revision: "sha", Verify: &enginetypes.SourceVerify{Checksum: digest, Signature: true}})
+	assert.True(t, errors.Is(err, types.ErrNotSupport))
+}
+
+func TestSourceReference(t *testing.T) {
+	_, _, err := sourceReference(&enginetypes.SourceOptions{Branch: "master", Tag: "v1"})
+	assert.Equal(t, types.ErrBranchTagExclusive, err)
+
+	ref, revision, err := sourceReference(&enginetypes.SourceOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, ref)
+	assert.Equal(t, "HEAD", revision)
+
+	ref, revision, err = sourceReference(&enginetypes.SourceOptions{Revision: "dev", Branch: "master"})
+	assert.NoError(t, err)
+	assert.Equal(t, "refs/heads/master", ref.String())
+	assert.Equal(t, "refs/heads/master", revision)
+
+	ref, revision, err = sourceReference(&enginetypes.SourceOptions{Tag: "v1", Commit: rev})
+	assert.NoError(t, err)
+	assert.Equal(t, "refs/tags/v1", ref.String())
+	assert.Equal(t, rev, revision)
+
+	assert.Equal(t, "HEAD", archiveRevision(&enginetypes.SourceOptions{}))
+	assert.Equal(t, "v1", archiveRevision(&enginetypes.SourceOptions{Tag: "v1", Revision: "dev"}))
+	assert.Equal(t, rev, archiveRevision(&enginetypes.SourceOptions{Branch: "master", Commit: rev}))
+}
+
+func TestParseRepository(t *testing.T) {
+	base, p, err := ParseRepository("https://gitlab.example.com/group/sub/project.git")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://gitlab.example.com", base)
+	assert.Equal(t, "group/sub/project", p)
+	_, _, err = ParseRepository("git@github.com:projecteru2/core.git")
+	assert.Error(t, err)
+	_, _, err = ParseRepository("https://github.com/")
+	assert.Error(t, err)
+}
+
+func TestHTTPAuth(t *testing.T) {
+	g := &GitScm{CloneUser: "oauth2"}
+	assert.Nil(t, g.httpAuth(nil))
+	g.Config.Token = "token"
+	auth := g.httpAuth(nil).(*githttp.BasicAuth)
+	assert.Equal(t, "oauth2", auth.Username)
+	assert.Equal(t, "token", auth.Password)
+	g.Config.Username = "user"
+	auth = g.httpAuth(nil).(*githttp.BasicAuth)
+	assert.Equal(t, "user", auth.Username)
+	// credential overrides config
+	auth = g.httpAuth(&enginetypes.SourceCredential{Token: "scoped"}).(*githttp.BasicAuth)
+	assert.Equal(t, "oauth2", auth.Username)
+	assert.Equal(t, "scoped", auth.Password)
+}
+
+func TestAuth(t *testing.T) {
+	g := &GitScm{}
+	_, err := g.auth("file:///xxxx", nil)
+	assert.Error(t, err)
+	// no key
+	_, err = g.auth("git@github.com:projecteru2/core.git", nil)
+	assert.Error(t, err)
+	// deploy key from credential
+	auth, err := g.auth("git@github.com:projecteru2/core.git", &enginetypes.SourceCredential{PrivateKey: privkey})
+	assert.NoError(t, err)
+	assert.Equal(t, "git", auth.(*gitssh.PublicKeys).User)
+	auth, err = g.auth("https://github.com/projecteru2/core.git", &enginetypes.SourceCredential{Token: "scoped"})
+	assert.NoError(t, err)
+	assert.Equal(t, "scoped", auth.(*githttp.BasicAuth).Password)
+}
+
 func zipFiles(newfile *os.File, files []string) error {
 	defer newfile.Close()
 
@@ -209,3 +340,53 @@ func zipFiles(newfile *os.File, files []string) error {
 	}
 	return nil
 }
+
+func TestTarball(t *testing.T) {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "vendor-1.0/", Typeflag: tar.TypeDir, Mode: 0755}))
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "vendor-1.0/src/main.c", Typeflag: tar.TypeReg, Mode: 0644, Size: 4}))
+	_, err := tw.Write([]byte("main"))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	data := buf.Bytes()
+
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer vendor" {
+			res.WriteHeader(401)
+			return
+		}
+		res.Write(data)
+	}))
+	defer testServer.Close()
+
+	savedDir, err := ioutil.TempDir("", "tarball")
+	assert.NoError(t, err)
+	defer os.RemoveAll(savedDir)
+
+	g := &GitScm{
+		Client:      *testServer.Client(),
+		Config:      types.GitConfig{CloneTimeout: time.Minute},
+		AuthHeaders: map[string]string{"Authorization": "Bearer scm"},
+	}
+	ctx := context.Background()
+	_, err = g.Tarball(ctx, &enginetypes.Tarball{URL: "http://example.com/vendor.tar.gz"}, savedDir)
+	assert.True(t, errors.Is(err, types.ErrBadTarballURL))
+	// scm auth headers are not sent
+	_, err = g.Tarball(ctx, &enginetypes.Tarball{URL: testServer.URL + "/vendor.tar.gz"}, savedDir)
+	assert.Error(t, err)
+
+	header := map[string]string{"Authorization": "Bearer vendor"}
+	_, err = g.Tarball(ctx, &enginetypes.Tarball{URL: testServer.URL + "/vendor.tar.gz", Header: header, Checksum: "sha256:bad"}, savedDir)
+	assert.True(t, errors.Is(err, types.ErrSourceChecksum))
+	sum := sha256.Sum256(data)
+	checksum := "sha256:" + hex.EncodeToString(sum[:])
+	digest, err := g.Tarball(ctx, &enginetypes.Tarball{URL: testServer.URL + "/vendor.tar.gz", Header: header, Checksum: checksum}, filepath.Join(savedDir, "vendor"))
+	assert.NoError(t, err)
+	assert.Equal(t, checksum, digest)
+	saved, err := ioutil.ReadFile(filepath.Join(savedDir, "vendor", "src", "main.c"))
+	assert.NoError(t, err)
+	assert.Equal(t, "main", string(saved))
+}
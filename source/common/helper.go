@@ -1,16 +1,30 @@
 package common
 
 import (
+	"archive/tar"
 	"archive/zip"
 	"bytes"
+	"compress/gzip"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // unzipFile unzip a file(from resp.Body) to the spec path
 func unzipFile(body io.Reader, path string) error {
+	return extractZip(body, path, false)
+}
+
+// unzipArchive unzip a repository archive to the spec path,
+// the top level directory added by SCM archive endpoints is stripped
+func unzipArchive(body io.Reader, path string) error {
+	return extractZip(body, path, true)
+}
+
+func extractZip(body io.Reader, path string, strip bool) error {
 	content, err := ioutil.ReadAll(body)
 	if err != nil {
 		return err
@@ -20,6 +34,9 @@ func unzipFile(body io.Reader, path string) error {
 	if err != nil {
 		return err
 	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
 
 	// extract files from zipfile
 	for _, f := range reader.File {
@@ -30,14 +47,26 @@ func unzipFile(body io.Reader, path string) error {
 
 		defer zipped.Close()
 
+		name := f.Name
+		if strip {
+			parts := strings.SplitN(name, "/", 2)
+			if len(parts) < 2 || parts[1] == "" {
+				continue
+			}
+			name = parts[1]
+		}
+
 		//  G305: File traversal when extracting zip archive
-		p := filepath.Join(path, f.Name) // nolint
+		p := filepath.Join(path, name) // nolint
 
 		if f.FileInfo().IsDir() {
 			_ = os.MkdirAll(p, f.Mode())
 			continue
 		}
 
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
 		writer, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE, f.Mode())
 		if err != nil {
 			return err
@@ -51,3 +80,161 @@ func unzipFile(body io.Reader, path string) error {
 	}
 	return nil
 }
+
+// extractTarball extract tar, tar.gz or zip content to the spec path,
+// the only top level directory is stripped like archives of SCM
+func extractTarball(content []byte, path string) error {
+	if bytes.HasPrefix(content, []byte("PK\x03\x04")) {
+		reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		if err != nil {
+			return err
+		}
+		names := []string{}
+		for _, f := range reader.File {
+			names = append(names, f.Name)
+		}
+		return extractZip(bytes.NewReader(content), path, topDir(names) != "")
+	}
+
+	var body io.Reader = bytes.NewReader(content)
+	if bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		if content, err = ioutil.ReadAll(gz); err != nil {
+			return err
+		}
+	}
+
+	// scan names first to decide stripping
+	names := []string{}
+	reader := tar.NewReader(bytes.NewReader(content))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		names = append(names, header.Name)
+	}
+	top := topDir(names)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+
+	reader = tar.NewReader(bytes.NewReader(content))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.Clean(header.Name)
+		if top != "" {
+			if name = strings.TrimPrefix(name, top+"/"); name == top {
+				continue
+			}
+		}
+		if filepath.IsAbs(name) || strings.HasPrefix(name, "..") {
+			return fmt.Errorf("Bad tarball entry %q", header.Name)
+		}
+		p := filepath.Join(path, name)
+		mode := os.FileMode(header.Mode)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(p, mode); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, p); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+				return err
+			}
+			writer, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(writer, reader) // nolint
+			writer.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// topDir returns the directory containing all names, empty if there are several
+func topDir(names []string) string {
+	top := ""
+	for _, name := range names {
+		name = strings.TrimPrefix(name, "./")
+		if name == "" {
+			continue
+		}
+		parts := strings.SplitN(name, "/", 2)
+		if len(parts) < 2 {
+			// file at top level
+			return ""
+		}
+		if top != "" && top != parts[0] {
+			return ""
+		}
+		top = parts[0]
+	}
+	return top
+}
+
+// copyDir copy files, dirs and symlinks under src into dst
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(p, target, info.Mode())
+		}
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	reader, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	writer, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+	_, err = io.Copy(writer, reader)
+	return err
+}
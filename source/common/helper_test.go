@@ -0,0 +1,14 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopDir(t *testing.T) {
+	assert.Equal(t, "a", topDir([]string{"a/", "a/b", "a/c/d"}))
+	assert.Equal(t, "a", topDir([]string{"./", "./a/", "./a/b"}))
+	assert.Equal(t, "", topDir([]string{"a/b", "c/d"}))
+	assert.Equal(t, "", topDir([]string{"a/b", "README"}))
+}
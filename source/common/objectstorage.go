@@ -0,0 +1,152 @@
+package common
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" // nolint
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/projecteru2/core/types"
+)
+
+const (
+	s3Scheme        = "s3"
+	ossScheme       = "oss"
+	s3DefaultRegion = "us-east-1"
+	unsignedS3Body  = "UNSIGNED-PAYLOAD"
+)
+
+// isObjectURL checks if artifact is in object storage, like s3://bucket/key or oss://bucket/key
+func isObjectURL(artifact string) bool {
+	return strings.HasPrefix(artifact, s3Scheme+"://") || strings.HasPrefix(artifact, ossScheme+"://")
+}
+
+// newObjectRequest makes a signed GET request of object storage artifact
+func newObjectRequest(ctx context.Context, config types.ObjectStorageConfig, artifact string, now time.Time) (*http.Request, error) {
+	u, err := url.Parse(artifact)
+	if err != nil {
+		return nil, err
+	}
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, types.NewDetailedErr(types.ErrNotSupport, artifact)
+	}
+	switch u.Scheme {
+	case s3Scheme:
+		return newS3Request(ctx, config.S3, bucket, key, now)
+	case ossScheme:
+		return newOSSRequest(ctx, config.OSS, bucket, key, now)
+	default:
+		return nil, types.NewDetailedErr(types.ErrNotSupport, artifact)
+	}
+}
+
+// newS3Request signs request with AWS signature v4, works for aws s3 and s3 compatible storage like minio
+func newS3Request(ctx context.Context, config types.S3Config, bucket, key string, now time.Time) (*http.Request, error) {
+	region := config.Region
+	if region == "" {
+		region = s3DefaultRegion
+	}
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	host, path := fmt.Sprintf("%s.%s", bucket, base.Host), "/"+s3Escape(key)
+	if config.PathStyle {
+		host, path = base.Host, fmt.Sprintf("/%s/%s", s3Escape(bucket), s3Escape(key))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s%s", base.Scheme, host, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	if config.AccessKey == "" {
+		// anonymous access for public buckets
+		return req, nil
+	}
+
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", unsignedS3Body)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		path,
+		"",
+		fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", host, unsignedS3Body, amzDate),
+		signedHeaders,
+		unsignedS3Body,
+	}, "\n")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, region)
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(hashed[:])}, "\n")
+
+	signingKey := []byte("AWS4" + config.SecretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		signingKey = hmacSum(sha256.New, signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSum(sha256.New, signingKey, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		config.AccessKey, scope, signedHeaders, signature))
+	return req, nil
+}
+
+// newOSSRequest signs request with aliyun oss header signature
+func newOSSRequest(ctx context.Context, config types.OSSConfig, bucket, key string, now time.Time) (*http.Request, error) {
+	base, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if base.Host == "" {
+		return nil, types.NewDetailedErr(types.ErrNotSupport, "oss endpoint not set")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s.%s/%s", base.Scheme, bucket, base.Host, s3Escape(key)), nil)
+	if err != nil {
+		return nil, err
+	}
+	if config.AccessKey == "" {
+		return req, nil
+	}
+
+	date := now.UTC().Format(http.TimeFormat)
+	req.Header.Set("Date", date)
+	stringToSign := fmt.Sprintf("%s\n\n\n%s\n/%s/%s", http.MethodGet, date, bucket, key)
+	signature := base64.StdEncoding.EncodeToString(hmacSum(sha1.New, []byte(config.SecretKey), stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("OSS %s:%s", config.AccessKey, signature))
+	return req, nil
+}
+
+// s3Escape escapes everything except unreserved characters, keeps "/" in key
+func s3Escape(s string) string {
+	b := strings.Builder{}
+	for _, c := range []byte(s) {
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hmacSum(h func() hash.Hash, key []byte, data string) []byte {
+	mac := hmac.New(h, key)
+	mac.Write([]byte(data)) // nolint
+	return mac.Sum(nil)
+}
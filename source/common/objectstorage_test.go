@@ -0,0 +1,95 @@
+package common
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewObjectRequest(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	config := types.ObjectStorageConfig{
+		S3:  types.S3Config{AccessKey: "AKID", SecretKey: "SECRET"},
+		OSS: types.OSSConfig{Endpoint: "https://oss-cn-hangzhou.aliyuncs.com", AccessKey: "AKID", SecretKey: "SECRET"},
+	}
+
+	assert.True(t, isObjectURL("s3://bucket/key"))
+	assert.True(t, isObjectURL("oss://bucket/key"))
+	assert.False(t, isObjectURL("https://bucket/key"))
+	_, err := newObjectRequest(ctx, config, "s3://bucket", now)
+	assert.Error(t, err)
+
+	req, err := newObjectRequest(ctx, config, "s3://bucket/dir/app v1.zip", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://bucket.s3.us-east-1.amazonaws.com/dir/app%20v1.zip", req.URL.String())
+	assert.Equal(t, "20200102T030405Z", req.Header.Get("x-amz-date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKID/20200102/us-east-1/s3/aws4_request, "+
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date, "+
+		"Signature=3616e9e82aafc7519c92067570a50fbe4b304beb3635ea10540c8beea40eaf4c", req.Header.Get("Authorization"))
+
+	req, err = newObjectRequest(ctx, config, "oss://bucket/dir/app v1.zip", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://bucket.oss-cn-hangzhou.aliyuncs.com/dir/app%20v1.zip", req.URL.String())
+	assert.Equal(t, "Thu, 02 Jan 2020 03:04:05 GMT", req.Header.Get("Date"))
+	assert.Equal(t, "OSS AKID:z4eTuiAjODP8g/BCo1GivL2zbHQ=", req.Header.Get("Authorization"))
+
+	// anonymous
+	req, err = newObjectRequest(ctx, types.ObjectStorageConfig{}, "s3://bucket/key", now)
+	assert.NoError(t, err)
+	assert.Empty(t, req.Header.Get("Authorization"))
+	// oss needs endpoint
+	_, err = newObjectRequest(ctx, types.ObjectStorageConfig{}, "oss://bucket/key", now)
+	assert.Error(t, err)
+}
+
+func TestObjectArtifact(t *testing.T) {
+	rawString := "test"
+	origFile, err := ioutil.TempFile("", "orig")
+	assert.NoError(t, err)
+	origFile.WriteString(rawString)
+	origFile.Close()
+	defer os.Remove(origFile.Name())
+	zipFile, err := ioutil.TempFile("", "zip")
+	assert.NoError(t, err)
+	defer os.Remove(zipFile.Name())
+	assert.NoError(t, zipFiles(zipFile, []string{origFile.Name()}))
+	data, err := ioutil.ReadFile(zipFile.Name())
+	assert.NoError(t, err)
+	savedDir, err := ioutil.TempDir("", "saved")
+	assert.NoError(t, err)
+	defer os.RemoveAll(savedDir)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		// path style like minio, scm auth headers must not be sent
+		if req.URL.Path != "/bucket/app.zip" || req.Header.Get("TEST") != "" ||
+			!strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			res.WriteHeader(403)
+			return
+		}
+		res.Write(data)
+	}))
+	defer testServer.Close()
+
+	g := &GitScm{
+		AuthHeaders: map[string]string{"TEST": "test"},
+		ObjectStorage: types.ObjectStorageConfig{
+			S3: types.S3Config{Endpoint: testServer.URL, AccessKey: "AKID", SecretKey: "SECRET", PathStyle: true},
+		},
+	}
+	assert.Error(t, g.Artifact("s3://bucket/other.zip", savedDir))
+	assert.NoError(t, g.Artifact("s3://bucket/app.zip", savedDir))
+	saved, err := ioutil.ReadFile(filepath.Join(savedDir, path.Base(origFile.Name())))
+	assert.NoError(t, err)
+	assert.Equal(t, rawString, string(saved))
+}
@@ -0,0 +1,30 @@
+package gitea
+
+import (
+	"fmt"
+
+	"github.com/projecteru2/core/source/common"
+	"github.com/projecteru2/core/types"
+)
+
+// New new a gitea obj
+func New(config types.Config) (*common.GitScm, error) {
+	gitConfig := config.Git
+	token := fmt.Sprintf("token %s", gitConfig.Token)
+	authHeaders := map[string]string{"Authorization": token}
+	scm, err := common.NewGitScm(gitConfig, authHeaders)
+	if scm != nil {
+		scm.CloneUser = "oauth2"
+		scm.ArchiveURL = archiveURL
+		scm.ObjectStorage = config.ObjectStorage
+	}
+	return scm, err
+}
+
+func archiveURL(repository, revision string) (string, error) {
+	base, path, err := common.ParseRepository(repository)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/api/v1/repos/%s/archive/%s.zip", base, path, revision), nil
+}
@@ -0,0 +1,23 @@
+package gitea
+
+import (
+	"testing"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveURL(t *testing.T) {
+	u, err := archiveURL("https://gitea.example.com/owner/repo.git", "master")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://gitea.example.com/api/v1/repos/owner/repo/archive/master.zip", u)
+	_, err = archiveURL("git@gitea.example.com:owner/repo.git", "master")
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	scm, err := New(types.Config{Git: types.GitConfig{Token: "t"}})
+	assert.Error(t, err) // no private key
+	assert.Equal(t, "token t", scm.AuthHeaders["Authorization"])
+	assert.NotNil(t, scm.ArchiveURL)
+}
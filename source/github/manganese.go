@@ -7,10 +7,31 @@ import (
 	"github.com/projecteru2/core/types"
 )
 
+const publicHost = "https://github.com"
+
 // New new a github obj
 func New(config types.Config) (*common.GitScm, error) {
 	gitConfig := config.Git
 	token := fmt.Sprintf("token %s", gitConfig.Token)
 	authHeaders := map[string]string{"Authorization": token}
-	return common.NewGitScm(gitConfig, authHeaders)
+	scm, err := common.NewGitScm(gitConfig, authHeaders)
+	if scm != nil {
+		scm.CloneUser = "x-access-token"
+		scm.ArchiveURL = archiveURL
+		scm.ObjectStorage = config.ObjectStorage
+	}
+	return scm, err
+}
+
+// archiveURL uses api.github.com for github.com, /api/v3 for github enterprise
+func archiveURL(repository, revision string) (string, error) {
+	base, path, err := common.ParseRepository(repository)
+	if err != nil {
+		return "", err
+	}
+	api := base + "/api/v3"
+	if base == publicHost {
+		api = "https://api.github.com"
+	}
+	return fmt.Sprintf("%s/repos/%s/zipball/%s", api, path, revision), nil
 }
@@ -0,0 +1,16 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveURL(t *testing.T) {
+	u, err := archiveURL("https://github.com/projecteru2/core.git", "master")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://api.github.com/repos/projecteru2/core/zipball/master", u)
+	u, err = archiveURL("https://git.example.com/projecteru2/core.git", "master")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://git.example.com/api/v3/repos/projecteru2/core/zipball/master", u)
+}
@@ -1,6 +1,9 @@
 package gitlab
 
 import (
+	"fmt"
+	"net/url"
+
 	"github.com/projecteru2/core/source/common"
 	"github.com/projecteru2/core/types"
 )
@@ -9,5 +12,20 @@ import (
 func New(config types.Config) (*common.GitScm, error) {
 	gitConfig := config.Git
 	authHeaders := map[string]string{"PRIVATE-TOKEN": gitConfig.Token}
-	return common.NewGitScm(gitConfig, authHeaders)
+	scm, err := common.NewGitScm(gitConfig, authHeaders)
+	if scm != nil {
+		scm.CloneUser = "oauth2"
+		scm.ArchiveURL = archiveURL
+		scm.ObjectStorage = config.ObjectStorage
+	}
+	return scm, err
+}
+
+// archiveURL works for both gitlab.com and self-hosted gitlab
+func archiveURL(repository, revision string) (string, error) {
+	base, path, err := common.ParseRepository(repository)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/api/v4/projects/%s/repository/archive.zip?sha=%s", base, url.PathEscape(path), url.QueryEscape(revision)), nil
 }
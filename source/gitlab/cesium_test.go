@@ -0,0 +1,13 @@
+package gitlab
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveURL(t *testing.T) {
+	u, err := archiveURL("https://gitlab.example.com/group/project.git", "v1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://gitlab.example.com/api/v4/projects/group%2Fproject/repository/archive.zip?sha=v1.0", u)
+}
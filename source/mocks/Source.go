@@ -6,6 +6,8 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	types "github.com/projecteru2/core/engine/types"
 )
 
 // Source is an autogenerated mock type for the Source type
@@ -27,6 +29,27 @@ func (_m *Source) Artifact(artifact string, path string) error {
 	return r0
 }
 
+// Tarball provides a mock function with given fields: ctx, tarball, path
+func (_m *Source) Tarball(ctx context.Context, tarball *types.Tarball, path string) (string, error) {
+	ret := _m.Called(ctx, tarball, path)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Tarball, string) string); ok {
+		r0 = rf(ctx, tarball, path)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.Tarball, string) error); ok {
+		r1 = rf(ctx, tarball, path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Security provides a mock function with given fields: path
 func (_m *Source) Security(path string) error {
 	ret := _m.Called(path)
@@ -41,16 +64,23 @@ func (_m *Source) Security(path string) error {
 	return r0
 }
 
-// SourceCode provides a mock function with given fields: ctx, repository, path, revision, submodule
-func (_m *Source) SourceCode(ctx context.Context, repository string, path string, revision string, submodule bool) error {
-	ret := _m.Called(ctx, repository, path, revision, submodule)
+// SourceCode provides a mock function with given fields: ctx, repository, path, opts
+func (_m *Source) SourceCode(ctx context.Context, repository string, path string, opts *types.SourceOptions) (string, error) {
+	ret := _m.Called(ctx, repository, path, opts)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, bool) error); ok {
-		r0 = rf(ctx, repository, path, revision, submodule)
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *types.SourceOptions) string); ok {
+		r0 = rf(ctx, repository, path, opts)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(string)
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *types.SourceOptions) error); ok {
+		r1 = rf(ctx, repository, path, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
@@ -1,11 +1,17 @@
 package source
 
-import "context"
+import (
+	"context"
+
+	enginetypes "github.com/projecteru2/core/engine/types"
+)
 
 // Source defines SCM funcions
 type Source interface {
-	// Get source code from repository into path by revision
-	SourceCode(ctx context.Context, repository, path, revision string, submodule bool) error
+	// Get source code from repository into path by options
+	SourceCode(ctx context.Context, repository, path string, opts *enginetypes.SourceOptions) (string, error)
+	// Get source code from https tarball into path, returns sha256 of tarball
+	Tarball(ctx context.Context, tarball *enginetypes.Tarball, path string) (string, error)
 	// Get related artifact by artifact into path
 	Artifact(artifact, path string) error
 	// Keep code security
@@ -0,0 +1,65 @@
+package etcdv3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	"go.etcd.io/etcd/v3/clientv3"
+)
+
+// AddAuditLog save an audit log, it's kept for ttl and never overwritten
+// storage path in etcd is `/audit/:unixnano-:ID`, so logs are sorted by start time
+func (m *Mercury) AddAuditLog(ctx context.Context, log *types.AuditLog, ttl time.Duration) error {
+	bytes, err := json.Marshal(log)
+	if err != nil {
+		return err
+	}
+	lease, err := m.grant(ctx, int64(ttl/time.Second))
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf(auditKey, log.Started.UnixNano(), log.ID)
+	resp, err := m.txn(ctx,
+		[]clientv3.Cmp{clientv3.Compare(clientv3.Version(key), "=", 0)},
+		[]clientv3.Op{clientv3.OpPut(key, string(bytes), clientv3.WithLease(lease.ID))},
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return types.NewDetailedErr(types.ErrKeyExists, key)
+	}
+	return nil
+}
+
+// ListAuditLogs list audit logs started in [since, until), zero until means now
+func (m *Mercury) ListAuditLogs(ctx context.Context, since, until time.Time) ([]*types.AuditLog, error) {
+	end := clientv3.GetPrefixRangeEnd(auditPrefix)
+	if !until.IsZero() {
+		end = auditRangeKey(until)
+	}
+	resp, err := m.Get(ctx, auditRangeKey(since), clientv3.WithRange(end))
+	if err != nil {
+		return nil, err
+	}
+	logs := []*types.AuditLog{}
+	for _, ev := range resp.Kvs {
+		log := &types.AuditLog{}
+		if err := json.Unmarshal(ev.Value, log); err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+	return logs, nil
+}
+
+func auditRangeKey(t time.Time) string {
+	if t.IsZero() {
+		return auditPrefix
+	}
+	return fmt.Sprintf(auditPrefix+"%020d", t.UnixNano())
+}
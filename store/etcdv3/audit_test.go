@@ -0,0 +1,36 @@
+package etcdv3
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLogs(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+
+	now := time.Now()
+	for i, method := range []string{"AddPod", "CreateContainer", "RemoveContainer"} {
+		log := &types.AuditLog{ID: "x", Method: method, Started: now.Add(time.Duration(i) * time.Minute)}
+		assert.NoError(t, m.AddAuditLog(ctx, log, time.Hour))
+	}
+	// never overwritten
+	err := m.AddAuditLog(ctx, &types.AuditLog{ID: "x", Method: "SetNode", Started: now}, time.Hour)
+	assert.True(t, errors.Is(err, types.ErrKeyExists))
+
+	logs, err := m.ListAuditLogs(ctx, time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 3)
+	assert.Equal(t, "AddPod", logs[0].Method)
+
+	logs, err = m.ListAuditLogs(ctx, now.Add(time.Second), now.Add(2*time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "CreateContainer", logs[0].Method)
+}
@@ -0,0 +1,61 @@
+package etcdv3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/projecteru2/core/types"
+	"go.etcd.io/etcd/v3/clientv3"
+)
+
+// SetScalePolicy save a scale policy, the old one of the app entrypoint is replaced
+// storage path in etcd is `/scalepolicies/:appname/:entrypoint`
+func (m *Mercury) SetScalePolicy(ctx context.Context, policy *types.ScalePolicy) error {
+	bytes, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf(scalePolicyKey, policy.Appname, policy.Entrypoint)
+	_, err = m.Put(ctx, key, string(bytes))
+	return err
+}
+
+// GetScalePolicy get scale policy of an app entrypoint
+func (m *Mercury) GetScalePolicy(ctx context.Context, appname, entrypoint string) (*types.ScalePolicy, error) {
+	ev, err := m.GetOne(ctx, fmt.Sprintf(scalePolicyKey, appname, entrypoint))
+	if err != nil {
+		return nil, err
+	}
+	policy := &types.ScalePolicy{}
+	return policy, json.Unmarshal(ev.Value, policy)
+}
+
+// ListScalePolicies list all scale policies
+func (m *Mercury) ListScalePolicies(ctx context.Context) ([]*types.ScalePolicy, error) {
+	resp, err := m.Get(ctx, scalePolicyPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	policies := []*types.ScalePolicy{}
+	for _, ev := range resp.Kvs {
+		policy := &types.ScalePolicy{}
+		if err := json.Unmarshal(ev.Value, policy); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// RemoveScalePolicy remove scale policy of an app entrypoint
+func (m *Mercury) RemoveScalePolicy(ctx context.Context, appname, entrypoint string) error {
+	resp, err := m.Delete(ctx, fmt.Sprintf(scalePolicyKey, appname, entrypoint))
+	if err != nil {
+		return err
+	}
+	if resp.Deleted == 0 {
+		return types.NewDetailedErr(types.ErrKeyNotExists, appname+"/"+entrypoint)
+	}
+	return nil
+}
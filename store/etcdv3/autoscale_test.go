@@ -0,0 +1,38 @@
+package etcdv3
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScalePolicy(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+
+	p := &types.ScalePolicy{Appname: "app", Entrypoint: "web", Podname: "pod", Min: 1, Max: 3, CPUThreshold: 0.6, Cooldown: time.Minute}
+	assert.NoError(t, m.SetScalePolicy(ctx, p))
+	_, err := m.GetScalePolicy(ctx, "app", "worker")
+	assert.Error(t, err)
+
+	// replaced
+	p.Max = 5
+	assert.NoError(t, m.SetScalePolicy(ctx, p))
+	p2, err := m.GetScalePolicy(ctx, "app", "web")
+	assert.NoError(t, err)
+	assert.Equal(t, p, p2)
+	assert.NoError(t, m.SetScalePolicy(ctx, &types.ScalePolicy{Appname: "app", Entrypoint: "worker", Podname: "pod"}))
+	ps, err := m.ListScalePolicies(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, ps, 2)
+
+	assert.NoError(t, m.RemoveScalePolicy(ctx, "app", "web"))
+	assert.Error(t, m.RemoveScalePolicy(ctx, "app", "web"))
+	ps, err = m.ListScalePolicies(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, ps, 1)
+}
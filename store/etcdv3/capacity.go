@@ -0,0 +1,51 @@
+package etcdv3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	"go.etcd.io/etcd/v3/clientv3"
+)
+
+// AddCapacitySample save a capacity sample of a pod
+// storage path in etcd is `/capacity/:podname/:unixnano`, so samples are sorted by time
+func (m *Mercury) AddCapacitySample(ctx context.Context, podname string, sample *types.CapacitySample) error {
+	bytes, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+	_, err = m.Put(ctx, capacitySampleKey(podname, sample.Time), string(bytes))
+	return err
+}
+
+// ListCapacitySamples list capacity samples of a pod since given time
+func (m *Mercury) ListCapacitySamples(ctx context.Context, podname string, since time.Time) ([]*types.CapacitySample, error) {
+	prefix := fmt.Sprintf(capacityKey, podname)
+	resp, err := m.Get(ctx, capacitySampleKey(podname, since), clientv3.WithRange(clientv3.GetPrefixRangeEnd(prefix)))
+	if err != nil {
+		return nil, err
+	}
+	samples := []*types.CapacitySample{}
+	for _, ev := range resp.Kvs {
+		sample := &types.CapacitySample{}
+		if err := json.Unmarshal(ev.Value, sample); err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+// RemoveCapacitySamples remove capacity samples of a pod before given time
+func (m *Mercury) RemoveCapacitySamples(ctx context.Context, podname string, before time.Time) error {
+	prefix := fmt.Sprintf(capacityKey, podname)
+	_, err := m.Delete(ctx, prefix, clientv3.WithRange(capacitySampleKey(podname, before)))
+	return err
+}
+
+func capacitySampleKey(podname string, t time.Time) string {
+	return fmt.Sprintf(capacityKey+"%020d", podname, t.UnixNano())
+}
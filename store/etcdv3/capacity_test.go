@@ -0,0 +1,45 @@
+package etcdv3
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapacitySamples(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		sample := &types.CapacitySample{
+			Time:             now.Add(time.Duration(i-4) * time.Hour),
+			ResourceCapacity: types.ResourceCapacity{CPU: 10, CPUUsed: float64(i)},
+			DeployFailures:   i,
+		}
+		assert.NoError(t, m.AddCapacitySample(ctx, "p1", sample))
+	}
+	assert.NoError(t, m.AddCapacitySample(ctx, "p10", &types.CapacitySample{Time: now}))
+
+	samples, err := m.ListCapacitySamples(ctx, "p1", now.Add(-24*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, samples, 5)
+	assert.Equal(t, samples[4].CPUUsed, float64(4))
+
+	samples, err = m.ListCapacitySamples(ctx, "p1", now.Add(-90*time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, samples, 2)
+	assert.Equal(t, samples[0].DeployFailures, 3)
+
+	assert.NoError(t, m.RemoveCapacitySamples(ctx, "p1", now.Add(-150*time.Minute)))
+	samples, err = m.ListCapacitySamples(ctx, "p1", now.Add(-24*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, samples, 3)
+	samples, err = m.ListCapacitySamples(ctx, "p10", now.Add(-24*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, samples, 1)
+}
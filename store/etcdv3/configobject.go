@@ -0,0 +1,60 @@
+package etcdv3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/projecteru2/core/types"
+	"go.etcd.io/etcd/v3/clientv3"
+)
+
+// SetConfigObject save a config object, the old one of the same name is replaced
+// storage path in etcd is `/configs/:name`
+func (m *Mercury) SetConfigObject(ctx context.Context, object *types.ConfigObject) error {
+	bytes, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	_, err = m.Put(ctx, fmt.Sprintf(configKey, object.Name), string(bytes))
+	return err
+}
+
+// GetConfigObject get a config object by name
+func (m *Mercury) GetConfigObject(ctx context.Context, name string) (*types.ConfigObject, error) {
+	ev, err := m.GetOne(ctx, fmt.Sprintf(configKey, name))
+	if err != nil {
+		return nil, err
+	}
+	object := &types.ConfigObject{}
+	return object, json.Unmarshal(ev.Value, object)
+}
+
+// ListConfigObjects list all config objects
+func (m *Mercury) ListConfigObjects(ctx context.Context) ([]*types.ConfigObject, error) {
+	resp, err := m.Get(ctx, configPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	objects := []*types.ConfigObject{}
+	for _, ev := range resp.Kvs {
+		object := &types.ConfigObject{}
+		if err := json.Unmarshal(ev.Value, object); err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// RemoveConfigObject remove a config object by name
+func (m *Mercury) RemoveConfigObject(ctx context.Context, name string) error {
+	resp, err := m.Delete(ctx, fmt.Sprintf(configKey, name))
+	if err != nil {
+		return err
+	}
+	if resp.Deleted == 0 {
+		return types.NewDetailedErr(types.ErrKeyNotExists, name)
+	}
+	return nil
+}
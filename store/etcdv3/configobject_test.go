@@ -0,0 +1,33 @@
+package etcdv3
+
+import (
+	"context"
+	"testing"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigObject(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+
+	object := &types.ConfigObject{Name: "nginx", Content: "listen {{.Env.PORT}}"}
+	assert.NoError(t, m.SetConfigObject(ctx, object))
+	assert.NoError(t, m.SetConfigObject(ctx, &types.ConfigObject{Name: "redis", Content: "port 6379"}))
+	o, err := m.GetConfigObject(ctx, "nginx")
+	assert.NoError(t, err)
+	assert.Equal(t, object, o)
+	_, err = m.GetConfigObject(ctx, "nope")
+	assert.Error(t, err)
+	objects, err := m.ListConfigObjects(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, objects, 2)
+
+	assert.NoError(t, m.RemoveConfigObject(ctx, "redis"))
+	assert.Error(t, m.RemoveConfigObject(ctx, "redis"))
+	objects, err = m.ListConfigObjects(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, objects, 1)
+}
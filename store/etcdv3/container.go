@@ -76,14 +76,14 @@ func (m *Mercury) SetContainerStatus(ctx context.Context, container *types.Conta
 	}
 	val := string(data)
 	statusKey := filepath.Join(containerStatusPrefix, appname, entrypoint, container.Nodename, container.ID)
-	lease, err := m.cliv3.Grant(ctx, ttl)
+	lease, err := m.grant(ctx, ttl)
 	if err != nil {
 		return err
 	}
 	updateStatus := []clientv3.Op{clientv3.OpPut(statusKey, val, clientv3.WithLease(lease.ID))}
-	tr, err := m.cliv3.Txn(ctx).
-		If(clientv3.Compare(clientv3.Version(fmt.Sprintf(containerInfoKey, container.ID)), "!=", 0)).
-		Then( // 保证有容器
+	tr, err := m.txn(ctx,
+		[]clientv3.Cmp{clientv3.Compare(clientv3.Version(fmt.Sprintf(containerInfoKey, container.ID)), "!=", 0)},
+		[]clientv3.Op{ // 保证有容器
 			clientv3.OpTxn(
 				[]clientv3.Cmp{clientv3.Compare(clientv3.Version(statusKey), "!=", 0)}, // 判断是否有 status key
 				[]clientv3.Op{clientv3.OpTxn( // 有 status key
@@ -93,7 +93,7 @@ func (m *Mercury) SetContainerStatus(ctx context.Context, container *types.Conta
 				)},
 				updateStatus, // 没有 status key
 			),
-		).Commit()
+		}, nil)
 	if err != nil {
 		return err
 	}
@@ -108,7 +108,7 @@ func (m *Mercury) SetContainerStatus(ctx context.Context, container *types.Conta
 	tr3 := tr2.Responses[0].GetResponseTxn()
 	if tr3.Succeeded {
 		oldLeaseID := clientv3.LeaseID(tr3.Responses[0].GetResponseRange().Kvs[0].Lease) // 拿到 status 绑定的 leaseID
-		_, err := m.cliv3.KeepAliveOnce(ctx, oldLeaseID)                                 // 刷新 lease
+		_, err := m.keepAliveOnce(ctx, oldLeaseID)                                       // 刷新 lease
 		return err
 	}
 	return nil
@@ -124,45 +124,39 @@ func (m *Mercury) ListContainers(ctx context.Context, appname, entrypoint, noden
 	}
 	// 这里显式加个 / 来保证 prefix 是唯一的
 	key := filepath.Join(containerDeployPrefix, appname, entrypoint, nodename) + "/"
-	resp, err := m.Get(ctx, key, clientv3.WithPrefix(), clientv3.WithLimit(limit))
+	containers, err := m.listContainers(ctx, key, limit, labels)
 	if err != nil {
 		return nil, err
 	}
-
-	containers := []*types.Container{}
-	for _, ev := range resp.Kvs {
-		container := &types.Container{VolumePlan: types.VolumePlan{}}
-		if err := json.Unmarshal(ev.Value, container); err != nil {
-			return nil, err
-		}
-		if utils.FilterContainer(container.Labels, labels) {
-			containers = append(containers, container)
-		}
-	}
-
 	return m.bindContainersAdditions(ctx, containers)
 }
 
 // ListNodeContainers list containers belong to one node
 func (m *Mercury) ListNodeContainers(ctx context.Context, nodename string, labels map[string]string) ([]*types.Container, error) {
 	key := fmt.Sprintf(nodeContainersKey, nodename, "")
-	resp, err := m.Get(ctx, key, clientv3.WithPrefix())
+	containers, err := m.listContainers(ctx, key, 0, labels)
 	if err != nil {
-		return nil, err
+		return []*types.Container{}, err
 	}
+	return m.bindContainersAdditions(ctx, containers)
+}
 
+// listContainers gets containers under prefix page by page, only matched ones are kept
+func (m *Mercury) listContainers(ctx context.Context, prefix string, limit int64, labels map[string]string) ([]*types.Container, error) {
 	containers := []*types.Container{}
-	for _, ev := range resp.Kvs {
-		container := &types.Container{VolumePlan: types.VolumePlan{}}
-		if err := json.Unmarshal(ev.Value, container); err != nil {
-			return []*types.Container{}, err
-		}
-		if utils.FilterContainer(container.Labels, labels) {
-			containers = append(containers, container)
+	err := m.getPrefix(ctx, prefix, limit, func(kvs []*mvccpb.KeyValue) error {
+		for _, ev := range kvs {
+			container := &types.Container{VolumePlan: types.VolumePlan{}}
+			if err := json.Unmarshal(ev.Value, container); err != nil {
+				return err
+			}
+			if utils.FilterContainer(container.Labels, labels) {
+				containers = append(containers, container)
+			}
 		}
-	}
-
-	return m.bindContainersAdditions(ctx, containers)
+		return nil
+	})
+	return containers, err
 }
 
 // ContainerStatusStream watch deployed status
@@ -210,6 +204,65 @@ func (m *Mercury) ContainerStatusStream(ctx context.Context, appname, entrypoint
 	return ch
 }
 
+// GetContainerMeta get a meta value bound to container
+func (m *Mercury) GetContainerMeta(ctx context.Context, ID, key string) ([]byte, error) {
+	kv, err := m.GetOne(ctx, fmt.Sprintf(containerMetaKey, ID, key))
+	if err != nil {
+		return nil, err
+	}
+	return kv.Value, nil
+}
+
+// SetContainerMeta set a meta value bound to container
+// total size of one container's meta is limited by types.MaxContainerMetaSize,
+// it's checked against meta unchanged since it's read, or read and checked again
+func (m *Mercury) SetContainerMeta(ctx context.Context, ID, key string, value []byte) error {
+	prefix := fmt.Sprintf(containerMetaKey, ID, "")
+	metaKey := prefix + key
+	infoKey := fmt.Sprintf(containerInfoKey, ID)
+	for {
+		resp, err := m.Get(ctx, prefix, clientv3.WithPrefix())
+		if err != nil {
+			return err
+		}
+		size := len(key) + len(value)
+		for _, kv := range resp.Kvs {
+			if string(kv.Key) == metaKey {
+				continue
+			}
+			size += len(kv.Key) - len(prefix) + len(kv.Value)
+		}
+		if size > types.MaxContainerMetaSize {
+			return types.NewDetailedErr(types.ErrContainerMetaQuota, size)
+		}
+
+		tr, err := m.txn(ctx,
+			[]clientv3.Cmp{
+				clientv3.Compare(clientv3.Version(infoKey), "!=", 0),
+				// no meta of container is put since it's read
+				clientv3.Compare(clientv3.ModRevision(prefix), "<", resp.Header.Revision+1).WithPrefix(),
+			},
+			[]clientv3.Op{clientv3.OpPut(metaKey, string(value))},
+			[]clientv3.Op{clientv3.OpGet(infoKey, clientv3.WithCountOnly())},
+		)
+		if err != nil {
+			return err
+		}
+		if tr.Succeeded {
+			return nil
+		}
+		if tr.Responses[0].GetResponseRange().Count == 0 {
+			return types.NewDetailedErr(types.ErrContainerNotExists, ID)
+		}
+	}
+}
+
+// DeleteContainerMeta delete a meta value bound to container
+func (m *Mercury) DeleteContainerMeta(ctx context.Context, ID, key string) error {
+	_, err := m.Delete(ctx, fmt.Sprintf(containerMetaKey, ID, key))
+	return err
+}
+
 func (m *Mercury) cleanContainerData(ctx context.Context, container *types.Container) error {
 	appname, entrypoint, _, err := utils.ParseContainerName(container.Name)
 	if err != nil {
@@ -222,7 +275,11 @@ func (m *Mercury) cleanContainerData(ctx context.Context, container *types.Conta
 		fmt.Sprintf(containerInfoKey, container.ID),                                                 // container info
 		fmt.Sprintf(nodeContainersKey, container.Nodename, container.ID),                            // node containers
 	}
-	_, err = m.batchDelete(ctx, keys)
+	if _, err = m.batchDelete(ctx, keys); err != nil {
+		return err
+	}
+	// container meta
+	_, err = m.Delete(ctx, fmt.Sprintf(containerMetaKey, container.ID, ""), clientv3.WithPrefix())
 	return err
 }
 
@@ -268,16 +325,23 @@ func (m *Mercury) bindContainersAdditions(ctx context.Context, containers []*typ
 		nodes[node.Name] = node
 	}
 
+	// status keys may be missing, get them in batch
+	keys := []string{}
+	for _, key := range statusKeys {
+		keys = append(keys, key)
+	}
+	kvs, err := m.getExisting(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
 	for index, container := range containers {
 		if _, ok := nodes[container.Nodename]; !ok {
 			return nil, types.ErrBadMeta
 		}
 		containers[index].Engine = nodes[container.Nodename].Engine
-		if _, ok := statusKeys[container.ID]; !ok {
-			continue
-		}
-		kv, err := m.GetOne(ctx, statusKeys[container.ID])
-		if err != nil {
+		kv, ok := kvs[statusKeys[container.ID]]
+		if !ok {
 			continue
 		}
 		status := &types.StatusMeta{}
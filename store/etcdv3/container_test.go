@@ -3,7 +3,9 @@ package etcdv3
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -67,6 +69,71 @@ func TestRemoveContainer(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestContainerMeta(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+	ID := "1234567812345678123456781234567812345678123456781234567812345678"
+	container := &types.Container{
+		ID:       ID,
+		Nodename: "n1",
+		Podname:  "test",
+		Name:     "test_app_1",
+	}
+	// failed by no container
+	err := m.SetContainerMeta(ctx, ID, "k", []byte("v"))
+	assert.Error(t, err)
+	assert.NoError(t, m.AddContainer(ctx, container))
+	// success
+	assert.NoError(t, m.SetContainerMeta(ctx, ID, "k", []byte("v")))
+	value, err := m.GetContainerMeta(ctx, ID, "k")
+	assert.NoError(t, err)
+	assert.Equal(t, value, []byte("v"))
+	// failed by quota
+	err = m.SetContainerMeta(ctx, ID, "big", make([]byte, types.MaxContainerMetaSize))
+	assert.Error(t, err)
+	// delete
+	assert.NoError(t, m.DeleteContainerMeta(ctx, ID, "k"))
+	_, err = m.GetContainerMeta(ctx, ID, "k")
+	assert.Error(t, err)
+	// cleaned with container
+	assert.NoError(t, m.SetContainerMeta(ctx, ID, "k", []byte("v")))
+	assert.NoError(t, m.RemoveContainer(ctx, container))
+	_, err = m.GetContainerMeta(ctx, ID, "k")
+	assert.Error(t, err)
+}
+
+func TestContainerMetaConcurrentQuota(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+	ID := "1234567812345678123456781234567812345678123456781234567812345678"
+	assert.NoError(t, m.AddContainer(ctx, &types.Container{ID: ID, Nodename: "n1", Podname: "test", Name: "test_app_1"}))
+
+	// each writer fits quota alone, only one of them fits along with others
+	value := make([]byte, types.MaxContainerMetaSize/2+1)
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- m.SetContainerMeta(ctx, ID, fmt.Sprintf("k%d", i), value)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.True(t, errors.Is(err, types.ErrContainerMetaQuota))
+	}
+	assert.Equal(t, 1, succeeded)
+}
+
 func TestGetContainer(t *testing.T) {
 	m := NewMercury(t)
 	defer m.TerminateEmbededStorage()
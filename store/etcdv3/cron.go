@@ -0,0 +1,142 @@
+package etcdv3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+	"go.etcd.io/etcd/v3/clientv3"
+	"go.etcd.io/etcd/v3/clientv3/concurrency"
+	"go.etcd.io/etcd/v3/mvcc/mvccpb"
+)
+
+// SetCron save a cron, the old one of the same name is replaced
+// storage path in etcd is `/crons/:name`
+func (m *Mercury) SetCron(ctx context.Context, cron *types.Cron) error {
+	bytes, err := json.Marshal(cron)
+	if err != nil {
+		return err
+	}
+	_, err = m.Put(ctx, fmt.Sprintf(cronKey, cron.Name), string(bytes))
+	return err
+}
+
+// GetCron get a cron by name
+func (m *Mercury) GetCron(ctx context.Context, name string) (*types.Cron, error) {
+	ev, err := m.GetOne(ctx, fmt.Sprintf(cronKey, name))
+	if err != nil {
+		return nil, err
+	}
+	cron := &types.Cron{}
+	return cron, json.Unmarshal(ev.Value, cron)
+}
+
+// ListCrons list all crons
+func (m *Mercury) ListCrons(ctx context.Context) ([]*types.Cron, error) {
+	resp, err := m.Get(ctx, cronPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	crons := []*types.Cron{}
+	for _, ev := range resp.Kvs {
+		cron := &types.Cron{}
+		if err := json.Unmarshal(ev.Value, cron); err != nil {
+			return nil, err
+		}
+		crons = append(crons, cron)
+	}
+	return crons, nil
+}
+
+// RemoveCron remove a cron by name
+func (m *Mercury) RemoveCron(ctx context.Context, name string) error {
+	resp, err := m.Delete(ctx, fmt.Sprintf(cronKey, name))
+	if err != nil {
+		return err
+	}
+	if resp.Deleted == 0 {
+		return types.NewDetailedErr(types.ErrKeyNotExists, name)
+	}
+	return nil
+}
+
+// SetCronRun save an execution of cron, it's kept for ttl
+// storage path in etcd is `/cronruns/:name/:runID`
+func (m *Mercury) SetCronRun(ctx context.Context, run *types.CronRun, ttl time.Duration) error {
+	bytes, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	lease, err := m.grant(ctx, int64(ttl/time.Second))
+	if err != nil {
+		return err
+	}
+	_, err = m.Put(ctx, fmt.Sprintf(cronRunKey, run.Name, run.ID), string(bytes), clientv3.WithLease(lease.ID))
+	return err
+}
+
+// WatchCronRuns watches executions of a cron, or all crons if name is empty
+func (m *Mercury) WatchCronRuns(ctx context.Context, name string) (<-chan *types.CronRun, error) {
+	key := cronRunPrefix
+	if name != "" {
+		key = fmt.Sprintf(cronRunKey, name, "")
+	}
+	ch := make(chan *types.CronRun)
+	go func() {
+		defer close(ch)
+		for resp := range m.watch(ctx, key, clientv3.WithPrefix()) {
+			if resp.Err() != nil {
+				if !resp.Canceled {
+					log.Errorf("[WatchCronRuns] watch failed %v", resp.Err())
+				}
+				return
+			}
+			for _, ev := range resp.Events {
+				if ev.Type != mvccpb.PUT {
+					continue
+				}
+				run := &types.CronRun{}
+				if err := json.Unmarshal(ev.Kv.Value, run); err != nil {
+					log.Errorf("[WatchCronRuns] Unmarshal %s failed %v", ev.Kv.Key, err)
+					continue
+				}
+				ch <- run
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// Campaign blocks until this core is elected as leader of name, or ctx is done,
+// the returned channel is closed once leadership is lost, it's resigned when ctx is done
+func (m *Mercury) Campaign(ctx context.Context, name string, ttl time.Duration) (<-chan struct{}, error) {
+	session, err := concurrency.NewSession(m.cliv3, concurrency.WithTTL(int(ttl.Seconds())))
+	if err != nil {
+		return nil, err
+	}
+	election := concurrency.NewElection(session, fmt.Sprintf(electionKey, name))
+	if err := election.Campaign(ctx, m.config.Bind); err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	lost := make(chan struct{})
+	go func() {
+		defer close(lost)
+		defer session.Close()
+		select {
+		case <-ctx.Done():
+			resignCtx, cancel := context.WithTimeout(context.Background(), ttl)
+			defer cancel()
+			if err := election.Resign(resignCtx); err != nil {
+				log.Errorf("[Campaign] Resign %s failed %v", name, err)
+			}
+		case <-session.Done():
+			log.Warnf("[Campaign] Leadership of %s lost", name)
+		}
+	}()
+	return lost, nil
+}
@@ -0,0 +1,76 @@
+package etcdv3
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCron(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+
+	cron := &types.Cron{Name: "backup", Spec: "@daily", Kind: types.CronKindJob, DeployOptions: types.DeployOptions{Name: "app", Count: 1, Entrypoint: &types.Entrypoint{Name: "backup"}}}
+	assert.NoError(t, m.SetCron(ctx, cron))
+	_, err := m.GetCron(ctx, "cleanup")
+	assert.Error(t, err)
+	c, err := m.GetCron(ctx, "backup")
+	assert.NoError(t, err)
+	assert.Equal(t, cron, c)
+	assert.NoError(t, m.SetCron(ctx, &types.Cron{Name: "cleanup", Spec: "@hourly"}))
+	crons, err := m.ListCrons(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, crons, 2)
+
+	assert.NoError(t, m.RemoveCron(ctx, "cleanup"))
+	assert.Error(t, m.RemoveCron(ctx, "cleanup"))
+	crons, err = m.ListCrons(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, crons, 1)
+}
+
+func TestCronRuns(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := m.WatchCronRuns(ctx, "backup")
+	assert.NoError(t, err)
+	// wait for watch
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, m.SetCronRun(ctx, &types.CronRun{ID: "1", Name: "cleanup"}, time.Minute))
+	assert.NoError(t, m.SetCronRun(ctx, &types.CronRun{ID: "1", Name: "backup"}, time.Minute))
+	assert.NoError(t, m.SetCronRun(ctx, &types.CronRun{ID: "1", Name: "backup", Succeeded: 1}, time.Minute))
+	run := <-ch
+	assert.Equal(t, "backup", run.Name)
+	assert.Equal(t, 0, run.Succeeded)
+	run = <-ch
+	assert.Equal(t, 1, run.Succeeded)
+}
+
+func TestCampaign(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	lost1, err := m.Campaign(ctx1, "cron", time.Second)
+	assert.NoError(t, err)
+
+	// another campaigner waits until leader resigns
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	_, err = m.Campaign(ctx2, "cron", time.Second)
+	cancel2()
+	assert.Error(t, err)
+
+	cancel1()
+	<-lost1
+	ctx2, cancel2 = context.WithCancel(context.Background())
+	defer cancel2()
+	_, err = m.Campaign(ctx2, "cron", time.Second)
+	assert.NoError(t, err)
+}
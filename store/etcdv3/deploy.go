@@ -8,29 +8,33 @@ import (
 	"github.com/projecteru2/core/types"
 	log "github.com/sirupsen/logrus"
 	"go.etcd.io/etcd/v3/clientv3"
+	"go.etcd.io/etcd/v3/mvcc/mvccpb"
 )
 
 // MakeDeployStatus get deploy status from store
 func (m *Mercury) MakeDeployStatus(ctx context.Context, opts *types.DeployOptions, nodesInfo []types.NodeInfo) ([]types.NodeInfo, error) {
 	// 手动加 / 防止不精确
 	key := filepath.Join(containerDeployPrefix, opts.Name, opts.Entrypoint.Name) + "/"
-	resp, err := m.Get(ctx, key, clientv3.WithPrefix(), clientv3.WithKeysOnly())
-	if err != nil {
+	kvs := []*mvccpb.KeyValue{}
+	if err := m.getPrefix(ctx, key, 0, func(page []*mvccpb.KeyValue) error {
+		kvs = append(kvs, page...)
+		return nil
+	}, clientv3.WithKeysOnly()); err != nil {
 		return nil, err
 	}
-	if resp.Count == 0 {
+	if len(kvs) == 0 {
 		log.Warnf("[MakeDeployStatus] Deploy status not found %s.%s", opts.Name, opts.Entrypoint.Name)
 	}
-	nodesInfo, err = m.doGetDeployStatus(ctx, resp, nodesInfo)
+	nodesInfo, err := m.doGetDeployStatus(ctx, kvs, nodesInfo)
 	if err != nil {
 		return nil, err
 	}
 	return m.doLoadProcessing(ctx, opts, nodesInfo)
 }
 
-func (m *Mercury) doGetDeployStatus(_ context.Context, resp *clientv3.GetResponse, nodesInfo []types.NodeInfo) ([]types.NodeInfo, error) { // nolint
+func (m *Mercury) doGetDeployStatus(_ context.Context, kvs []*mvccpb.KeyValue, nodesInfo []types.NodeInfo) ([]types.NodeInfo, error) { // nolint
 	nodesCount := map[string]int{}
-	for _, ev := range resp.Kvs {
+	for _, ev := range kvs {
 		key := string(ev.Key)
 		parts := strings.Split(key, "/")
 		nodename := parts[len(parts)-2]
@@ -0,0 +1,60 @@
+package etcdv3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+	"go.etcd.io/etcd/v3/clientv3"
+	"go.etcd.io/etcd/v3/mvcc/mvccpb"
+)
+
+// PublishEvent save a cluster event, it's kept for ttl
+// storage path in etcd is `/events/:eventID`
+func (m *Mercury) PublishEvent(ctx context.Context, event *types.Event, ttl time.Duration) error {
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	lease, err := m.grant(ctx, int64(ttl/time.Second))
+	if err != nil {
+		return err
+	}
+	_, err = m.Put(ctx, fmt.Sprintf(eventKey, event.ID), string(bytes), clientv3.WithLease(lease.ID))
+	return err
+}
+
+// WatchEvents watches cluster events published by all cores, in the order they are saved
+func (m *Mercury) WatchEvents(ctx context.Context) (<-chan *types.Event, error) {
+	ch := make(chan *types.Event)
+	go func() {
+		defer close(ch)
+		for resp := range m.watch(ctx, eventPrefix, clientv3.WithPrefix()) {
+			if resp.Err() != nil {
+				if !resp.Canceled {
+					log.Errorf("[WatchEvents] watch failed %v", resp.Err())
+				}
+				return
+			}
+			for _, ev := range resp.Events {
+				if ev.Type != mvccpb.PUT {
+					continue
+				}
+				event := &types.Event{}
+				if err := json.Unmarshal(ev.Kv.Value, event); err != nil {
+					log.Errorf("[WatchEvents] Unmarshal %s failed %v", ev.Kv.Key, err)
+					continue
+				}
+				select {
+				case ch <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
@@ -0,0 +1,31 @@
+package etcdv3
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvents(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch, err := m.WatchEvents(ctx)
+	assert.NoError(t, err)
+	// wait for watch
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, m.PublishEvent(ctx, &types.Event{ID: "1", Kind: types.EventNodeAdded, Nodename: "n1"}, time.Minute))
+	assert.NoError(t, m.PublishEvent(ctx, &types.Event{ID: "2", Kind: types.EventNodeDown, Nodename: "n1"}, time.Minute))
+	event := <-ch
+	assert.Equal(t, types.EventNodeAdded, event.Kind)
+	event = <-ch
+	assert.Equal(t, "2", event.ID)
+
+	cancel()
+	for range ch {
+	}
+}
@@ -0,0 +1,34 @@
+package etcdv3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/v3/clientv3"
+)
+
+// SetNodeAlive keeps heartbeat of a node, it expires after ttl unless set again
+// storage path in etcd is `/alive/:nodename`
+func (m *Mercury) SetNodeAlive(ctx context.Context, nodename string, ttl time.Duration) error {
+	lease, err := m.grant(ctx, int64(ttl/time.Second))
+	if err != nil {
+		return err
+	}
+	_, err = m.Put(ctx, fmt.Sprintf(aliveKey, nodename), time.Now().Format(time.RFC3339), clientv3.WithLease(lease.ID))
+	return err
+}
+
+// ListAliveNodes list names of nodes with unexpired heartbeats
+func (m *Mercury) ListAliveNodes(ctx context.Context) ([]string, error) {
+	resp, err := m.Get(ctx, alivePrefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return nil, err
+	}
+	nodenames := []string{}
+	for _, ev := range resp.Kvs {
+		nodenames = append(nodenames, strings.TrimPrefix(string(ev.Key), alivePrefix))
+	}
+	return nodenames, nil
+}
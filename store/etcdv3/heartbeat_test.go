@@ -0,0 +1,26 @@
+package etcdv3
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeAlive(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+
+	nodenames, err := m.ListAliveNodes(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, nodenames)
+
+	assert.NoError(t, m.SetNodeAlive(ctx, "n1", time.Minute))
+	assert.NoError(t, m.SetNodeAlive(ctx, "n2", time.Minute))
+	assert.NoError(t, m.SetNodeAlive(ctx, "n1", time.Minute))
+	nodenames, err = m.ListAliveNodes(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"n1", "n2"}, nodenames)
+}
@@ -13,17 +13,44 @@ import (
 
 	"github.com/projecteru2/core/lock"
 	"github.com/projecteru2/core/lock/etcdlock"
+	"github.com/projecteru2/core/metrics"
 	"github.com/projecteru2/core/store/etcdv3/embedded"
 	"github.com/projecteru2/core/types"
 	"github.com/projecteru2/core/utils"
 	"go.etcd.io/etcd/v3/clientv3"
 	"go.etcd.io/etcd/v3/clientv3/namespace"
+	"go.etcd.io/etcd/v3/etcdserver/api/v3rpc/rpctypes"
 	"go.etcd.io/etcd/v3/mvcc/mvccpb"
 )
 
 const (
-	podInfoKey       = "/pod/info/%s" // /pod/info/{podname}
-	serviceStatusKey = "/services/%s" // /service/{ipv4:port}
+	podInfoKey        = "/pod/info/%s"  // /pod/info/{podname}
+	serviceStatusKey  = "/services/%s"  // /service/{ipv4:port}
+	quotaKey          = "/quotas/%s/%s" // /quotas/{scope}/{name}
+	quotaPrefix       = "/quotas/"
+	reservationKey    = "/reservations/%s"     // /reservations/{reservationID}
+	capacityKey       = "/capacity/%s/"        // /capacity/{podname}/{unixnano}
+	scalePolicyKey    = "/scalepolicies/%s/%s" // /scalepolicies/{appname}/{entrypoint}
+	scalePolicyPrefix = "/scalepolicies/"
+	cronKey           = "/crons/%s" // /crons/{name}
+	cronPrefix        = "/crons/"
+	cronRunKey        = "/cronruns/%s/%s" // /cronruns/{name}/{runID}
+	cronRunPrefix     = "/cronruns/"
+	electionKey       = "/election/%s" // /election/{name}
+	eventKey          = "/events/%s"   // /events/{eventID}
+	eventPrefix       = "/events/"
+	auditKey          = "/audit/%020d-%s" // /audit/{unixnano}-{random}
+	auditPrefix       = "/audit/"
+	webhookKey        = "/webhooks/%s" // /webhooks/{name}
+	webhookPrefix     = "/webhooks/"
+	secretKey         = "/secrets/%s" // /secrets/{name}
+	secretPrefix      = "/secrets/"
+	configKey         = "/configs/%s" // /configs/{name}
+	configPrefix      = "/configs/"
+	aliveKey          = "/alive/%s" // /alive/{nodename}
+	alivePrefix       = "/alive/"
+	registryKey       = "/registries/%s/%s" // /registries/{registry}/{podname}, podname is empty for all pods
+	registryPrefix    = "/registries/"
 
 	nodeInfoKey       = "/node/%s"               // /node/{nodename}
 	nodePodKey        = "/node/%s:pod/%s"        // /node/{podname}:pod/{nodename}
@@ -32,19 +59,29 @@ const (
 	nodeKeyKey        = "/node/%s:key"           // /node/{nodename}:key
 	nodeContainersKey = "/node/%s:containers/%s" // /node/{nodename}:containers/{containerID}
 
-	containerInfoKey          = "/containers/%s" // /containers/{containerID}
-	containerDeployPrefix     = "/deploy"        // /deploy/{appname}/{entrypoint}/{nodename}/{containerID}
-	containerStatusPrefix     = "/status"        // /status/{appname}/{entrypoint}/{nodename}/{containerID} value -> something by agent
-	containerProcessingPrefix = "/processing"    // /processing/{appname}/{entrypoint}/{nodename}/{opsIdent} value -> count
+	containerInfoKey          = "/containers/%s"         // /containers/{containerID}
+	containerMetaKey          = "/containers/%s:meta/%s" // /containers/{containerID}:meta/{key}
+	containerDeployPrefix     = "/deploy"                // /deploy/{appname}/{entrypoint}/{nodename}/{containerID}
+	containerStatusPrefix     = "/status"                // /status/{appname}/{entrypoint}/{nodename}/{containerID} value -> something by agent
+	containerProcessingPrefix = "/processing"            // /processing/{appname}/{entrypoint}/{nodename}/{opsIdent} value -> count
 
 	cmpVersion = "version"
 	cmpValue   = "value"
+
+	opGet       = "get"
+	opPut       = "put"
+	opDelete    = "delete"
+	opTxn       = "txn"
+	opGrant     = "grant"
+	opKeepAlive = "keepalive"
+	opCompact   = "compact"
 )
 
 // Mercury means store with etcdv3
 type Mercury struct {
-	cliv3  *clientv3.Client
-	config types.Config
+	cliv3   *clientv3.Client
+	config  types.Config
+	engines *utils.EngineCache
 }
 
 // New for create a Mercury instance
@@ -81,7 +118,60 @@ func New(config types.Config, embeddedStorage bool) (*Mercury, error) {
 	cliv3.KV = namespace.NewKV(cliv3.KV, config.Etcd.Prefix)
 	cliv3.Watcher = namespace.NewWatcher(cliv3.Watcher, config.Etcd.Prefix)
 	cliv3.Lease = namespace.NewLease(cliv3.Lease, config.Etcd.Prefix)
-	return &Mercury{cliv3: cliv3, config: config}, nil
+	m := &Mercury{cliv3: cliv3, config: config, engines: utils.NewEngineCache(config.EngineCache.IdleTimeout, config.EngineCache.IdleTimeout/2)}
+	if config.EngineCache.CheckInterval > 0 {
+		go m.checkEngines(context.Background())
+	}
+	if config.Etcd.CompactInterval > 0 {
+		go m.compact(context.Background())
+	}
+	return m, nil
+}
+
+// compact compacts revisions older than interval periodically
+// revision of last round is compacted, so history of one interval is always kept
+func (m *Mercury) compact(ctx context.Context) {
+	ticker := time.NewTicker(m.config.Etcd.CompactInterval)
+	defer ticker.Stop()
+	var rev int64
+	for {
+		select {
+		case <-ticker.C:
+			if rev > 0 {
+				start := time.Now()
+				_, err := m.cliv3.Compact(ctx, rev)
+				// other cores may have compacted it
+				if err == rpctypes.ErrCompacted {
+					err = nil
+				}
+				if observe(opCompact, start, err) != nil {
+					log.Errorf("[compact] Compact revision %d failed %v", rev, err)
+				}
+			}
+			resp, err := m.Get(ctx, "/", clientv3.WithCountOnly())
+			if err != nil {
+				log.Errorf("[compact] Get revision failed %v", err)
+				continue
+			}
+			rev = resp.Header.Revision
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkEngines drops unhealthy engine clients periodically
+func (m *Mercury) checkEngines(ctx context.Context) {
+	ticker := time.NewTicker(m.config.EngineCache.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.engines.CheckHealth(ctx, m.config.EngineCache.CheckTimeout)
+		case <-ctx.Done():
+			return
+		}
+	}
 }
 
 // TerminateEmbededStorage terminate embedded storage
@@ -98,7 +188,9 @@ func (m *Mercury) CreateLock(key string, ttl time.Duration) (lock.DistributedLoc
 
 // Get get results or noting
 func (m *Mercury) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
-	return m.cliv3.Get(ctx, key, opts...)
+	start := time.Now()
+	resp, err := m.cliv3.Get(ctx, key, opts...)
+	return resp, observe(opGet, start, err)
 }
 
 // GetOne get one result or noting
@@ -135,14 +227,74 @@ func (m *Mercury) GetMulti(ctx context.Context, keys []string, opts ...clientv3.
 	return
 }
 
+// getPrefix gets keys of prefix page by page at the same revision, at most limit keys if limit > 0
+// each page is handled by f, so a huge prefix won't come in one response
+func (m *Mercury) getPrefix(ctx context.Context, prefix string, limit int64, f func([]*mvccpb.KeyValue) error, opts ...clientv3.OpOption) error {
+	key := prefix
+	end := clientv3.GetPrefixRangeEnd(prefix)
+	var rev int64
+	for {
+		pageSize := m.config.Etcd.PageSize
+		if limit > 0 && (pageSize <= 0 || limit < pageSize) {
+			pageSize = limit
+		}
+		pageOpts := append([]clientv3.OpOption{clientv3.WithRange(end), clientv3.WithLimit(pageSize)}, opts...)
+		if rev > 0 {
+			pageOpts = append(pageOpts, clientv3.WithRev(rev))
+		}
+		resp, err := m.Get(ctx, key, pageOpts...)
+		if err != nil {
+			return err
+		}
+		if len(resp.Kvs) == 0 {
+			return nil
+		}
+		if err := f(resp.Kvs); err != nil {
+			return err
+		}
+		if !resp.More {
+			return nil
+		}
+		if limit > 0 {
+			if limit -= int64(len(resp.Kvs)); limit <= 0 {
+				return nil
+			}
+		}
+		rev = resp.Header.Revision
+		key = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+}
+
+// getExisting gets keys in batch, missing keys are skipped
+func (m *Mercury) getExisting(ctx context.Context, keys []string) (map[string]*mvccpb.KeyValue, error) {
+	kvs := map[string]*mvccpb.KeyValue{}
+	if len(keys) == 0 {
+		return kvs, nil
+	}
+	txnResponse, err := m.batchGet(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	for _, responseOp := range txnResponse.Responses {
+		for _, kv := range responseOp.GetResponseRange().Kvs {
+			kvs[string(kv.Key)] = kv
+		}
+	}
+	return kvs, nil
+}
+
 // Delete delete key
 func (m *Mercury) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
-	return m.cliv3.Delete(ctx, key, opts...)
+	start := time.Now()
+	resp, err := m.cliv3.Delete(ctx, key, opts...)
+	return resp, observe(opDelete, start, err)
 }
 
 // Put save a key value
 func (m *Mercury) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
-	return m.cliv3.Put(ctx, key, val, opts...)
+	start := time.Now()
+	resp, err := m.cliv3.Put(ctx, key, val, opts...)
+	return resp, observe(opPut, start, err)
 }
 
 // Create create a key if not exists
@@ -155,18 +307,89 @@ func (m *Mercury) Update(ctx context.Context, key, val string, opts ...clientv3.
 	return m.batchUpdate(ctx, map[string]string{key: val}, opts...)
 }
 
-// Watch wath a key
+// Watch wath a key, events and errors are counted
 func (m *Mercury) watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
-	return m.cliv3.Watch(ctx, key, opts...)
+	ch := make(chan clientv3.WatchResponse)
+	go func() {
+		defer close(ch)
+		for resp := range m.cliv3.Watch(ctx, key, opts...) {
+			err := resp.Err()
+			if ctx.Err() != nil {
+				err = nil // canceled by caller
+			}
+			metrics.Client.SendStoreWatch(len(resp.Events), err)
+			select {
+			case ch <- resp:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// txn commits a txn, counts it as conflict if compares failed
+func (m *Mercury) txn(ctx context.Context, conds []clientv3.Cmp, ops, failOps []clientv3.Op) (*clientv3.TxnResponse, error) {
+	start := time.Now()
+	txn := m.cliv3.Txn(ctx)
+	if len(conds) != 0 {
+		txn = txn.If(conds...)
+	}
+	resp, err := txn.Then(ops...).Else(failOps...).Commit()
+	if err == nil && !resp.Succeeded {
+		metrics.Client.SendStoreConflict(opTxn)
+	}
+	return resp, observe(opTxn, start, err)
+}
+
+func (m *Mercury) grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	start := time.Now()
+	resp, err := m.cliv3.Grant(ctx, ttl)
+	return resp, observe(opGrant, start, err)
+}
+
+func (m *Mercury) keepAliveOnce(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseKeepAliveResponse, error) {
+	start := time.Now()
+	resp, err := m.cliv3.KeepAliveOnce(ctx, id)
+	return resp, observe(opKeepAlive, start, err)
+}
+
+// observe sends latency of a store operation to metrics, returns err as is
+func observe(op string, start time.Time, err error) error {
+	metrics.Client.SendStoreOp(op, time.Since(start), err)
+	return err
 }
 
+// batchGet gets keys in txns of at most MaxTxnOps ops, responses are merged in order
 func (m *Mercury) batchGet(ctx context.Context, keys []string, opt ...clientv3.OpOption) (txnResponse *clientv3.TxnResponse, err error) {
-	ops := []clientv3.Op{}
-	for _, key := range keys {
-		op := clientv3.OpGet(key, opt...)
-		ops = append(ops, op)
+	if len(keys) == 0 {
+		return m.doBatchOp(ctx, nil, nil, nil)
 	}
-	return m.doBatchOp(ctx, nil, ops, nil)
+	size := m.config.Etcd.MaxTxnOps
+	if size <= 0 {
+		size = len(keys)
+	}
+	for len(keys) > 0 {
+		if size > len(keys) {
+			size = len(keys)
+		}
+		ops := []clientv3.Op{}
+		for _, key := range keys[:size] {
+			op := clientv3.OpGet(key, opt...)
+			ops = append(ops, op)
+		}
+		keys = keys[size:]
+		resp, err := m.doBatchOp(ctx, nil, ops, nil)
+		if err != nil {
+			return nil, err
+		}
+		if txnResponse == nil {
+			txnResponse = resp
+			continue
+		}
+		txnResponse.Responses = append(txnResponse.Responses, resp.Responses...)
+	}
+	return txnResponse, nil
 }
 
 func (m *Mercury) batchDelete(ctx context.Context, keys []string, opts ...clientv3.OpOption) (*clientv3.TxnResponse, error) {
@@ -256,11 +479,7 @@ func (m *Mercury) doBatchOp(ctx context.Context, conds []clientv3.Cmp, ops, fail
 	wg := sync.WaitGroup{}
 	doOp := func(index int, ops []clientv3.Op) {
 		defer wg.Done()
-		txn := m.cliv3.Txn(ctx)
-		if len(conds) != 0 {
-			txn = txn.If(conds...)
-		}
-		resp, err := txn.Then(ops...).Else(failOps...).Commit()
+		resp, err := m.txn(ctx, conds, ops, failOps)
 		resps[index] = resp
 		errs[index] = err
 	}
@@ -293,5 +512,3 @@ func (m *Mercury) doBatchOp(ctx context.Context, conds []clientv3.Cmp, ops, fail
 	}
 	return resp, nil
 }
-
-var _cache = utils.NewEngineCache(12*time.Hour, 10*time.Minute)
@@ -9,6 +9,7 @@ import (
 	"github.com/projecteru2/core/types"
 	"github.com/stretchr/testify/assert"
 	"go.etcd.io/etcd/v3/clientv3"
+	"go.etcd.io/etcd/v3/mvcc/mvccpb"
 )
 
 func NewMercury(t *testing.T) *Mercury {
@@ -121,3 +122,60 @@ func TestMercury(t *testing.T) {
 	m.Create(ctx, "watchkey/1", "b")
 	cancel()
 }
+
+func TestGetPrefix(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+	m.config.Etcd.PageSize = 2
+	for _, key := range []string{"page/1", "page/2", "page/3", "page/4", "page/5", "pages"} {
+		_, err := m.Put(ctx, key, key)
+		assert.NoError(t, err)
+	}
+
+	get := func(limit int64) ([]string, int) {
+		keys := []string{}
+		pages := 0
+		assert.NoError(t, m.getPrefix(ctx, "page/", limit, func(kvs []*mvccpb.KeyValue) error {
+			pages++
+			for _, kv := range kvs {
+				keys = append(keys, string(kv.Key))
+			}
+			return nil
+		}))
+		return keys, pages
+	}
+	keys, pages := get(0)
+	assert.Equal(t, []string{"page/1", "page/2", "page/3", "page/4", "page/5"}, keys)
+	assert.Equal(t, 3, pages)
+	keys, pages = get(3)
+	assert.Equal(t, []string{"page/1", "page/2", "page/3"}, keys)
+	assert.Equal(t, 2, pages)
+	// all in one page
+	m.config.Etcd.PageSize = 0
+	keys, pages = get(0)
+	assert.Len(t, keys, 5)
+	assert.Equal(t, 1, pages)
+	// stopped by error
+	assert.Error(t, m.getPrefix(ctx, "page/", 0, func([]*mvccpb.KeyValue) error { return types.ErrBadCount }))
+}
+
+func TestBatchGetChunked(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+	m.config.Etcd.MaxTxnOps = 2
+	keys := []string{"chunk/1", "chunk/2", "chunk/3", "chunk/4", "chunk/5"}
+	for _, key := range keys {
+		_, err := m.Put(ctx, key, key)
+		assert.NoError(t, err)
+	}
+	kvs, err := m.GetMulti(ctx, keys)
+	assert.NoError(t, err)
+	assert.Len(t, kvs, 5)
+	for i, kv := range kvs {
+		assert.Equal(t, keys[i], string(kv.Value))
+	}
+	_, err = m.batchGet(ctx, nil)
+	assert.Error(t, err)
+}
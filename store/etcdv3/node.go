@@ -10,6 +10,7 @@ import (
 	"github.com/projecteru2/core/store"
 
 	enginefactory "github.com/projecteru2/core/engine/factory"
+	"github.com/projecteru2/core/engine/lazy"
 	"github.com/projecteru2/core/metrics"
 	"github.com/projecteru2/core/types"
 	"github.com/projecteru2/core/utils"
@@ -58,6 +59,9 @@ func (m *Mercury) AddNode(ctx context.Context, opts *types.AddNodeOptions) (*typ
 	if opts.Volume == nil {
 		opts.Volume = types.VolumeMap{}
 	}
+	if opts.Hugepages == nil {
+		opts.Hugepages = types.HugepageMap{}
+	}
 	// 设置 numa 的内存默认值，如果没有的话，按照 numa node 个数均分
 	if len(opts.Numa) > 0 {
 		nodeIDs := map[string]struct{}{}
@@ -75,7 +79,13 @@ func (m *Mercury) AddNode(ctx context.Context, opts *types.AddNodeOptions) (*typ
 		}
 	}
 
-	return m.doAddNode(ctx, opts.Nodename, opts.Endpoint, opts.Podname, opts.Ca, opts.Cert, opts.Key, opts.CPU, opts.Share, opts.Memory, opts.Storage, opts.Labels, opts.Numa, opts.NumaMemory, opts.Volume)
+	node, err := m.doAddNode(ctx, opts.Nodename, opts.Endpoint, opts.Podname, opts.Ca, opts.Cert, opts.Key, opts.CPU, opts.Share, opts.Memory, opts.Storage, opts.Labels, opts.Annotations, opts.Numa, opts.NumaMemory, opts.Volume, opts.Hugepages, opts.GPU)
+	if err != nil {
+		return nil, err
+	}
+	// reuse the client checked above
+	m.engines.Set(engineKey(node.Name, node.Endpoint), client)
+	return node, nil
 }
 
 // RemoveNode delete a node
@@ -139,8 +149,39 @@ func (m *Mercury) UpdateNode(ctx context.Context, node *types.Node) error {
 	return err
 }
 
+// UpdateNodeEngine changes endpoint or certs of node, empty one is kept
+// client of node is dropped and will be made again with new ones
+func (m *Mercury) UpdateNodeEngine(ctx context.Context, node *types.Node, endpoint, ca, cert, key string) error {
+	m.engines.Delete(engineKey(node.Name, node.Endpoint))
+	if endpoint != "" {
+		node.Endpoint = endpoint
+	}
+	bytes, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	d := string(bytes)
+	data := map[string]string{
+		fmt.Sprintf(nodeInfoKey, node.Name):              d,
+		fmt.Sprintf(nodePodKey, node.Podname, node.Name): d,
+	}
+	if ca != "" {
+		data[fmt.Sprintf(nodeCaKey, node.Name)] = ca
+	}
+	if cert != "" {
+		data[fmt.Sprintf(nodeCertKey, node.Name)] = cert
+	}
+	if key != "" {
+		data[fmt.Sprintf(nodeKeyKey, node.Name)] = key
+	}
+
+	log.Infof("[UpdateNodeEngine] pod %s node %s endpoint %s", node.Podname, node.Name, node.Endpoint)
+	_, err = m.batchPut(ctx, data, nil)
+	return err
+}
+
 // UpdateNodeResource update cpu and memory on a node, either add or subtract
-func (m *Mercury) UpdateNodeResource(ctx context.Context, node *types.Node, cpu types.CPUMap, quota float64, memory, storage int64, volume types.VolumeMap, action string) error {
+func (m *Mercury) UpdateNodeResource(ctx context.Context, node *types.Node, cpu types.CPUMap, quota float64, memory, storage int64, volume types.VolumeMap, hugepages types.HugepageMap, gpu types.GPUMap, action string) error {
 	switch action {
 	case store.ActionIncr:
 		node.CPU.Add(cpu)
@@ -149,6 +190,8 @@ func (m *Mercury) UpdateNodeResource(ctx context.Context, node *types.Node, cpu
 		node.SetVolumeUsed(volume.Total(), types.DecrUsage)
 		node.MemCap += memory
 		node.StorageCap += storage
+		node.Hugepages.Add(hugepages)
+		node.GPU.Add(gpu)
 		if nodeID := node.GetNUMANode(cpu); nodeID != "" {
 			node.IncrNUMANodeMemory(nodeID, memory)
 		}
@@ -159,6 +202,8 @@ func (m *Mercury) UpdateNodeResource(ctx context.Context, node *types.Node, cpu
 		node.SetVolumeUsed(volume.Total(), types.IncrUsage)
 		node.MemCap -= memory
 		node.StorageCap -= storage
+		node.Hugepages.Sub(hugepages)
+		node.GPU.Sub(gpu)
 		if nodeID := node.GetNUMANode(cpu); nodeID != "" {
 			node.DecrNUMANodeMemory(nodeID, memory)
 		}
@@ -170,19 +215,25 @@ func (m *Mercury) UpdateNodeResource(ctx context.Context, node *types.Node, cpu
 	return m.UpdateNode(ctx, node)
 }
 
+// engineKey identifies client of node, a client of the old endpoint won't be reused
+func engineKey(nodename, endpoint string) string {
+	return fmt.Sprintf("%s|%s", nodename, endpoint)
+}
+
 func (m *Mercury) makeClient(ctx context.Context, node *types.Node, force bool) (engine.API, error) {
 	// try get client, if nil, create a new one
 	var client engine.API
-	var err error
-	client = _cache.Get(node.Name)
+	client = m.engines.Get(engineKey(node.Name, node.Endpoint))
 	if client == nil || force {
-		keyFormats := []string{nodeCaKey, nodeCertKey, nodeKeyKey}
+		keys := []string{fmt.Sprintf(nodeCaKey, node.Name), fmt.Sprintf(nodeCertKey, node.Name), fmt.Sprintf(nodeKeyKey, node.Name)}
 		data := []string{"", "", ""}
-		for i := 0; i < 3; i++ {
-			if ev, err := m.GetOne(ctx, fmt.Sprintf(keyFormats[i], node.Name)); err != nil {
-				log.Warnf("[makeClient] Get key failed %v", err)
-			} else {
-				data[i] = string(ev.Value)
+		kvs, err := m.getExisting(ctx, keys)
+		if err != nil {
+			log.Warnf("[makeClient] Get keys failed %v", err)
+		}
+		for i, key := range keys {
+			if kv, ok := kvs[key]; ok {
+				data[i] = string(kv.Value)
 			}
 		}
 
@@ -190,12 +241,12 @@ func (m *Mercury) makeClient(ctx context.Context, node *types.Node, force bool)
 		if err != nil {
 			return nil, err
 		}
-		_cache.Set(node.Name, client)
+		m.engines.Set(engineKey(node.Name, node.Endpoint), client)
 	}
 	return client, nil
 }
 
-func (m *Mercury) doAddNode(ctx context.Context, name, endpoint, podname, ca, cert, key string, cpu, share int, memory, storage int64, labels map[string]string, numa types.NUMA, numaMemory types.NUMAMemory, volumemap types.VolumeMap) (*types.Node, error) {
+func (m *Mercury) doAddNode(ctx context.Context, name, endpoint, podname, ca, cert, key string, cpu, share int, memory, storage int64, labels, annotations map[string]string, numa types.NUMA, numaMemory types.NUMAMemory, volumemap types.VolumeMap, hugepages types.HugepageMap, gpus []string) (*types.Node, error) {
 	data := map[string]string{}
 	// 如果有tls的证书需要保存就保存一下
 	if ca != "" {
@@ -213,6 +264,16 @@ func (m *Mercury) doAddNode(ctx context.Context, name, endpoint, podname, ca, ce
 		cpumap[strconv.Itoa(i)] = int64(share)
 	}
 
+	initHugepages := types.HugepageMap{}
+	initHugepages.Add(hugepages)
+
+	gpumap := types.GPUMap{}
+	for _, device := range gpus {
+		gpumap[device] = types.GPUShareBase
+	}
+	initGPU := types.GPUMap{}
+	initGPU.Add(gpumap)
+
 	node := &types.Node{
 		Name:           name,
 		Endpoint:       endpoint,
@@ -226,8 +287,13 @@ func (m *Mercury) doAddNode(ctx context.Context, name, endpoint, podname, ca, ce
 		InitStorageCap: storage,
 		InitNUMAMemory: numaMemory,
 		InitVolume:     volumemap,
+		Hugepages:      hugepages,
+		InitHugepages:  initHugepages,
+		GPU:            gpumap,
+		InitGPU:        initGPU,
 		Available:      true,
 		Labels:         labels,
+		Annotations:    annotations,
 		NUMA:           numa,
 		NUMAMemory:     numaMemory,
 	}
@@ -263,7 +329,7 @@ func (m *Mercury) doRemoveNode(ctx context.Context, podname, nodename, endpoint
 		fmt.Sprintf(nodeKeyKey, nodename),
 	}
 
-	_cache.Delete(nodename)
+	m.engines.Delete(engineKey(nodename, endpoint))
 	_, err := m.batchDelete(ctx, keys)
 	log.Infof("[doRemoveNode] Node (%s, %s, %s) deleted", podname, nodename, endpoint)
 	return err
@@ -278,11 +344,10 @@ func (m *Mercury) doGetNodes(ctx context.Context, kvs []*mvccpb.KeyValue, labels
 		}
 		node.Init()
 		if (node.Available || all) && utils.FilterContainer(node.Labels, labels) {
-			engine, err := m.makeClient(ctx, node, false)
-			if err != nil {
-				return nil, err
-			}
-			node.Engine = engine
+			// client is made on first call, listing nodes won't dial them
+			node.Engine = lazy.New(func(ctx context.Context) (engine.API, error) {
+				return m.makeClient(ctx, node, false)
+			})
 			nodes = append(nodes, node)
 		}
 	}
@@ -131,7 +131,7 @@ RdCPRPt513WozkJZZAjUSP2U
 	nodename3 := "nodename3"
 	endpoint3 := "tcp://path"
 	m.config.CertPath = "/tmp"
-	node3, err := m.doAddNode(ctx, nodename3, endpoint3, podname, ca, cert, certkey, cpu, share, memory, storage, labels, nil, nil, nil)
+	node3, err := m.doAddNode(ctx, nodename3, endpoint3, podname, ca, cert, certkey, cpu, share, memory, storage, labels, nil, nil, nil, nil, nil, nil)
 	assert.NoError(t, err)
 	engine3, err := m.makeClient(ctx, node3, true)
 	assert.NoError(t, err)
@@ -147,7 +147,7 @@ func TestRemoveNode(t *testing.T) {
 	m := NewMercury(t)
 	defer m.TerminateEmbededStorage()
 	ctx := context.Background()
-	node, err := m.doAddNode(ctx, "test", "mock://", "testpod", "", "", "", 100, 100, 100000, 100000, nil, nil, nil, nil)
+	node, err := m.doAddNode(ctx, "test", "mock://", "testpod", "", "", "", 100, 100, 100000, 100000, nil, nil, nil, nil, nil, nil, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, node.Name, "test")
 	assert.NoError(t, m.RemoveNode(ctx, nil))
@@ -158,7 +158,7 @@ func TestGetNode(t *testing.T) {
 	m := NewMercury(t)
 	defer m.TerminateEmbededStorage()
 	ctx := context.Background()
-	node, err := m.doAddNode(ctx, "test", "mock://", "testpod", "", "", "", 100, 100, 100000, 100000, nil, nil, nil, nil)
+	node, err := m.doAddNode(ctx, "test", "mock://", "testpod", "", "", "", 100, 100, 100000, 100000, nil, nil, nil, nil, nil, nil, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, node.Name, "test")
 	_, err = m.GetNode(ctx, "wtf")
@@ -172,7 +172,7 @@ func TestGetNodesByPod(t *testing.T) {
 	m := NewMercury(t)
 	defer m.TerminateEmbededStorage()
 	ctx := context.Background()
-	node, err := m.doAddNode(ctx, "test", "mock://", "testpod", "", "", "", 100, 100, 100000, 100000, map[string]string{"x": "y"}, nil, nil, nil)
+	node, err := m.doAddNode(ctx, "test", "mock://", "testpod", "", "", "", 100, 100, 100000, 100000, map[string]string{"x": "y"}, nil, nil, nil, nil, nil, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, node.Name, "test")
 	ns, err := m.GetNodesByPod(ctx, "wtf", nil, false)
@@ -187,7 +187,7 @@ func TestUpdateNode(t *testing.T) {
 	m := NewMercury(t)
 	defer m.TerminateEmbededStorage()
 	ctx := context.Background()
-	node, err := m.doAddNode(ctx, "test", "mock://", "testpod", "", "", "", 100, 100, 100000, 100000, map[string]string{"x": "y"}, nil, nil, nil)
+	node, err := m.doAddNode(ctx, "test", "mock://", "testpod", "", "", "", 100, 100, 100000, 100000, map[string]string{"x": "y"}, nil, nil, nil, nil, nil, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, node.Name, "test")
 	fakeNode := &types.Node{
@@ -202,10 +202,64 @@ func TestUpdateNodeResource(t *testing.T) {
 	m := NewMercury(t)
 	defer m.TerminateEmbededStorage()
 	ctx := context.Background()
-	node, err := m.doAddNode(ctx, "test", "mock://", "testpod", "", "", "", 1, 100, 100000, 100000, map[string]string{"x": "y"}, map[string]string{"0": "0"}, map[string]int64{"0": 100}, nil)
+	node, err := m.doAddNode(ctx, "test", "mock://", "testpod", "", "", "", 1, 100, 100000, 100000, map[string]string{"x": "y"}, nil, map[string]string{"0": "0"}, map[string]int64{"0": 100}, nil, nil, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, node.Name, "test")
-	assert.Error(t, m.UpdateNodeResource(ctx, node, nil, 0, 0, 0, nil, "wtf"))
-	assert.NoError(t, m.UpdateNodeResource(ctx, node, map[string]int64{"0": 100}, 0, 0, 0, nil, store.ActionIncr))
-	assert.NoError(t, m.UpdateNodeResource(ctx, node, map[string]int64{"0": 100}, 0, 0, 0, nil, store.ActionDecr))
+	assert.Error(t, m.UpdateNodeResource(ctx, node, nil, 0, 0, 0, nil, nil, nil, "wtf"))
+	assert.NoError(t, m.UpdateNodeResource(ctx, node, map[string]int64{"0": 100}, 0, 0, 0, nil, nil, nil, store.ActionIncr))
+	assert.NoError(t, m.UpdateNodeResource(ctx, node, map[string]int64{"0": 100}, 0, 0, 0, nil, nil, nil, store.ActionDecr))
+
+	node, err = m.doAddNode(ctx, "test2", "mock://", "testpod", "", "", "", 1, 100, 100000, 100000, nil, nil, nil, nil, nil, types.HugepageMap{types.Hugepage2Mi: 10}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, m.UpdateNodeResource(ctx, node, nil, 0, 0, 0, nil, types.HugepageMap{types.Hugepage2Mi: 4}, nil, store.ActionDecr))
+	node, err = m.GetNode(ctx, "test2")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), node.Hugepages[types.Hugepage2Mi])
+	assert.Equal(t, int64(10), node.InitHugepages[types.Hugepage2Mi])
+
+	node, err = m.doAddNode(ctx, "test3", "mock://", "testpod", "", "", "", 1, 100, 100000, 100000, nil, nil, nil, nil, nil, nil, []string{"0", "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, types.GPUMap{"0": types.GPUShareBase, "1": types.GPUShareBase}, node.InitGPU)
+	assert.NoError(t, m.UpdateNodeResource(ctx, node, nil, 0, 0, 0, nil, nil, types.GPUMap{"0": 100, "1": 30}, store.ActionDecr))
+	node, err = m.GetNode(ctx, "test3")
+	assert.NoError(t, err)
+	assert.Equal(t, types.GPUMap{"0": 0, "1": 70}, node.GPU)
+}
+
+func TestUpdateNodeEngine(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+	_, err := m.doAddNode(ctx, "test", "mock://", "testpod", "", "", "", 100, 100, 100000, 100000, nil, nil, nil, nil, nil, nil, nil)
+	assert.NoError(t, err)
+	node, err := m.GetNode(ctx, "test")
+	assert.NoError(t, err)
+	_, err = node.Engine.Info(ctx)
+	assert.NoError(t, err)
+	assert.NotNil(t, m.engines.Get(engineKey("test", "mock://")))
+
+	assert.NoError(t, m.UpdateNodeEngine(ctx, node, "mock://new", "ca", "", ""))
+	assert.Nil(t, m.engines.Get(engineKey("test", "mock://")))
+	node, err = m.GetNode(ctx, "test")
+	assert.NoError(t, err)
+	assert.Equal(t, "mock://new", node.Endpoint)
+	kv, err := m.GetOne(ctx, fmt.Sprintf(nodeCaKey, "test"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ca", string(kv.Value))
+	_, err = m.GetOne(ctx, fmt.Sprintf(nodeCertKey, "test"))
+	assert.Error(t, err)
+}
+
+func TestGetNodesLazyEngine(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+	// client of it can't be made
+	_, err := m.doAddNode(ctx, "test", "unknown://", "testpod", "", "", "", 100, 100, 100000, 100000, nil, nil, nil, nil, nil, nil, nil)
+	assert.NoError(t, err)
+	ns, err := m.GetNodesByPod(ctx, "testpod", nil, false)
+	assert.NoError(t, err)
+	assert.Len(t, ns, 1)
+	_, err = ns[0].Engine.Info(ctx)
+	assert.Error(t, err)
 }
@@ -24,6 +24,18 @@ func (m *Mercury) AddPod(ctx context.Context, name, desc string) (*types.Pod, er
 	return pod, err
 }
 
+// UpdatePod update a pod
+// pod must exist
+func (m *Mercury) UpdatePod(ctx context.Context, pod *types.Pod) error {
+	key := fmt.Sprintf(podInfoKey, pod.Name)
+	bytes, err := json.Marshal(pod)
+	if err != nil {
+		return err
+	}
+	_, err = m.Update(ctx, key, string(bytes))
+	return err
+}
+
 // RemovePod if the pod has no nodes left, otherwise return an error
 func (m *Mercury) RemovePod(ctx context.Context, podname string) error {
 	key := fmt.Sprintf(podInfoKey, podname)
@@ -22,6 +22,13 @@ func TestPod(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, pod2.Name, podname)
 
+	pod2.Placement = types.PlacementPack
+	assert.NoError(t, m.UpdatePod(ctx, pod2))
+	pod3, err := m.GetPod(ctx, podname)
+	assert.NoError(t, err)
+	assert.Equal(t, pod3.Placement, types.PlacementPack)
+	assert.Error(t, m.UpdatePod(ctx, &types.Pod{Name: "notexists"}))
+
 	pods, err := m.GetAllPods(ctx)
 	assert.NoError(t, err)
 	assert.Equal(t, len(pods), 1)
@@ -28,6 +28,19 @@ func (m *Mercury) UpdateProcessing(ctx context.Context, opts *types.DeployOption
 	return err
 }
 
+// GetProcessing returns count of containers not created yet on node, 0 if all of them are done
+func (m *Mercury) GetProcessing(ctx context.Context, opts *types.DeployOptions, nodename string) (int, error) {
+	processingKey := filepath.Join(containerProcessingPrefix, opts.Name, opts.Entrypoint.Name, nodename, opts.ProcessIdent)
+	resp, err := m.Get(ctx, processingKey)
+	if err != nil {
+		return 0, err
+	}
+	if resp.Count == 0 {
+		return 0, nil
+	}
+	return strconv.Atoi(string(resp.Kvs[0].Value))
+}
+
 // DeleteProcessing delete processing status in etcd
 func (m *Mercury) DeleteProcessing(ctx context.Context, opts *types.DeployOptions, nodeInfo types.NodeInfo) error {
 	processingKey := filepath.Join(containerProcessingPrefix, opts.Name, opts.Entrypoint.Name, nodeInfo.Name, opts.ProcessIdent)
@@ -35,6 +48,54 @@ func (m *Mercury) DeleteProcessing(ctx context.Context, opts *types.DeployOption
 	return err
 }
 
+// ListProcessing returns processing containers count of each node
+func (m *Mercury) ListProcessing(ctx context.Context) (map[string]int, error) {
+	resp, err := m.Get(ctx, containerProcessingPrefix+"/", clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	nodesCount := map[string]int{}
+	for _, ev := range resp.Kvs {
+		parts := strings.Split(string(ev.Key), "/")
+		count, err := strconv.Atoi(string(ev.Value))
+		if err != nil {
+			log.Errorf("[ListProcessing] Load processing status failed %v", err)
+			continue
+		}
+		nodesCount[parts[len(parts)-2]] += count
+	}
+	return nodesCount, nil
+}
+
+// ListProcessingRecords returns all processing records
+func (m *Mercury) ListProcessingRecords(ctx context.Context) ([]*types.ProcessingRecord, error) {
+	resp, err := m.Get(ctx, containerProcessingPrefix+"/", clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	records := []*types.ProcessingRecord{}
+	for _, ev := range resp.Kvs {
+		parts := strings.Split(string(ev.Key), "/")
+		if len(parts) < 4 {
+			continue
+		}
+		count, err := strconv.Atoi(string(ev.Value))
+		if err != nil {
+			log.Errorf("[ListProcessingRecords] Load processing status failed %v", err)
+			continue
+		}
+		parts = parts[len(parts)-4:]
+		records = append(records, &types.ProcessingRecord{
+			Appname:    parts[0],
+			Entrypoint: parts[1],
+			Nodename:   parts[2],
+			Ident:      parts[3],
+			Count:      count,
+		})
+	}
+	return records, nil
+}
+
 func (m *Mercury) doLoadProcessing(ctx context.Context, opts *types.DeployOptions, nodesInfo []types.NodeInfo) ([]types.NodeInfo, error) {
 	// 显式的加 / 保证 prefix 一致性
 	processingKey := filepath.Join(containerProcessingPrefix, opts.Name, opts.Entrypoint.Name) + "/"
@@ -21,17 +21,32 @@ func TestProcessing(t *testing.T) {
 
 	// not exists
 	assert.Error(t, m.UpdateProcessing(ctx, opts, nodeInfo.Name, 8))
+	count, err := m.GetProcessing(ctx, opts, nodeInfo.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
 	// create
 	assert.NoError(t, m.SaveProcessing(ctx, opts, nodeInfo))
 	// create again
 	assert.Error(t, m.SaveProcessing(ctx, opts, nodeInfo))
 	// update
 	assert.NoError(t, m.UpdateProcessing(ctx, opts, nodeInfo.Name, 8))
+	count, err = m.GetProcessing(ctx, opts, nodeInfo.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, count)
 
 	nodesInfo, err := m.doLoadProcessing(ctx, opts, []types.NodeInfo{nodeInfo})
 	assert.NoError(t, err)
 	assert.Equal(t, len(nodesInfo), 1)
 	assert.Equal(t, nodesInfo[0].Count, 8)
+	nodesCount, err := m.ListProcessing(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, nodesCount["node"], 8)
+	records, err := m.ListProcessingRecords(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []*types.ProcessingRecord{{Appname: "app", Entrypoint: "entry", Nodename: "node", Ident: "abc", Count: 8}}, records)
 	// delete
 	assert.NoError(t, m.DeleteProcessing(ctx, opts, nodeInfo))
+	records, err = m.ListProcessingRecords(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, records)
 }
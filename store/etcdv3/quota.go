@@ -0,0 +1,121 @@
+package etcdv3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/projecteru2/core/types"
+	"go.etcd.io/etcd/v3/clientv3"
+)
+
+// SetQuota save a quota, limit of the old one of the same scope and name is replaced but its usage is kept,
+// it's compared and swapped like ChargeQuota so no charge is lost
+// storage path in etcd is `/quotas/:scope/:name`
+func (m *Mercury) SetQuota(ctx context.Context, quota *types.Quota) error {
+	key := fmt.Sprintf(quotaKey, quota.Scope, quota.Name)
+	for {
+		// a new quota is created only if no one else created it in the meantime
+		cond := clientv3.Compare(clientv3.CreateRevision(key), "=", 0)
+		ev, err := m.GetOne(ctx, key)
+		switch {
+		case err == nil:
+			old := &types.Quota{}
+			if err := json.Unmarshal(ev.Value, old); err != nil {
+				return err
+			}
+			quota.Used = old.Used
+			cond = clientv3.Compare(clientv3.ModRevision(key), "=", ev.ModRevision)
+		case !errors.Is(err, types.ErrBadCount):
+			return err
+		}
+		bytes, err := json.Marshal(quota)
+		if err != nil {
+			return err
+		}
+		resp, err := m.doBatchOp(ctx, []clientv3.Cmp{cond}, []clientv3.Op{clientv3.OpPut(key, string(bytes))}, nil)
+		if err != nil {
+			return err
+		}
+		if resp.Succeeded {
+			return nil
+		}
+	}
+}
+
+// GetQuota get a quota by scope and name
+func (m *Mercury) GetQuota(ctx context.Context, scope, name string) (*types.Quota, error) {
+	ev, err := m.GetOne(ctx, fmt.Sprintf(quotaKey, scope, name))
+	if err != nil {
+		return nil, err
+	}
+	quota := &types.Quota{}
+	return quota, json.Unmarshal(ev.Value, quota)
+}
+
+// ListQuotas list all quotas
+func (m *Mercury) ListQuotas(ctx context.Context) ([]*types.Quota, error) {
+	resp, err := m.Get(ctx, quotaPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	quotas := []*types.Quota{}
+	for _, ev := range resp.Kvs {
+		quota := &types.Quota{}
+		if err := json.Unmarshal(ev.Value, quota); err != nil {
+			return nil, err
+		}
+		quotas = append(quotas, quota)
+	}
+	return quotas, nil
+}
+
+// RemoveQuota remove a quota by scope and name
+func (m *Mercury) RemoveQuota(ctx context.Context, scope, name string) error {
+	resp, err := m.Delete(ctx, fmt.Sprintf(quotaKey, scope, name))
+	if err != nil {
+		return err
+	}
+	if resp.Deleted == 0 {
+		return types.NewDetailedErr(types.ErrKeyNotExists, scope+"/"+name)
+	}
+	return nil
+}
+
+// ChargeQuota adds delta to usage of a quota atomically, it's compared and swapped until no one else changed it,
+// nothing is charged if there is no such quota
+func (m *Mercury) ChargeQuota(ctx context.Context, scope, name string, delta types.QuotaUsage) error {
+	key := fmt.Sprintf(quotaKey, scope, name)
+	for {
+		ev, err := m.GetOne(ctx, key)
+		if errors.Is(err, types.ErrBadCount) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		quota := &types.Quota{}
+		if err := json.Unmarshal(ev.Value, quota); err != nil {
+			return err
+		}
+		if err := quota.Charge(delta); err != nil {
+			return err
+		}
+		bytes, err := json.Marshal(quota)
+		if err != nil {
+			return err
+		}
+		resp, err := m.doBatchOp(ctx,
+			[]clientv3.Cmp{clientv3.Compare(clientv3.ModRevision(key), "=", ev.ModRevision)},
+			[]clientv3.Op{clientv3.OpPut(key, string(bytes))},
+			nil,
+		)
+		if err != nil {
+			return err
+		}
+		if resp.Succeeded {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package etcdv3
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuota(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+
+	quota := &types.Quota{Scope: types.QuotaScopePod, Name: "p1", Limit: types.QuotaUsage{CPU: 10}}
+	assert.NoError(t, m.SetQuota(ctx, quota))
+	assert.NoError(t, m.SetQuota(ctx, &types.Quota{Scope: types.QuotaScopePod, Name: "p2"}))
+	_, err := m.GetQuota(ctx, types.QuotaScopePod, "p3")
+	assert.Error(t, err)
+	q, err := m.GetQuota(ctx, types.QuotaScopePod, "p1")
+	assert.NoError(t, err)
+	assert.Equal(t, quota, q)
+	quotas, err := m.ListQuotas(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, quotas, 2)
+
+	// replacing a quota keeps its usage
+	assert.NoError(t, m.ChargeQuota(ctx, types.QuotaScopePod, "p1", types.QuotaUsage{CPU: 1}))
+	assert.NoError(t, m.SetQuota(ctx, &types.Quota{Scope: types.QuotaScopePod, Name: "p1", Limit: types.QuotaUsage{CPU: 5}, Used: types.QuotaUsage{CPU: 100}}))
+	q, err = m.GetQuota(ctx, types.QuotaScopePod, "p1")
+	assert.NoError(t, err)
+	assert.Equal(t, types.QuotaUsage{CPU: 5}, q.Limit)
+	assert.Equal(t, types.QuotaUsage{CPU: 1}, q.Used)
+
+	assert.NoError(t, m.RemoveQuota(ctx, types.QuotaScopePod, "p2"))
+	assert.Error(t, m.RemoveQuota(ctx, types.QuotaScopePod, "p2"))
+	quotas, err = m.ListQuotas(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, quotas, 1)
+}
+
+func TestChargeQuota(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+
+	// no quota, nothing charged
+	assert.NoError(t, m.ChargeQuota(ctx, types.QuotaScopePod, "pod", types.QuotaUsage{Memory: 100}))
+
+	assert.NoError(t, m.SetQuota(ctx, &types.Quota{Scope: types.QuotaScopePod, Name: "pod", Limit: types.QuotaUsage{Memory: 10}}))
+	wg := sync.WaitGroup{}
+	mu := sync.Mutex{}
+	exceeded := 0
+	for i := 0; i < 15; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := m.ChargeQuota(ctx, types.QuotaScopePod, "pod", types.QuotaUsage{Memory: 1, Storage: 1})
+			if err != nil {
+				assert.True(t, errors.Is(err, types.ErrQuotaExceeded))
+				mu.Lock()
+				defer mu.Unlock()
+				exceeded++
+			}
+		}()
+	}
+	// concurrent limit changes lose no charge
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, m.SetQuota(ctx, &types.Quota{Scope: types.QuotaScopePod, Name: "pod", Limit: types.QuotaUsage{Memory: 10}}))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 5, exceeded)
+	q, err := m.GetQuota(ctx, types.QuotaScopePod, "pod")
+	assert.NoError(t, err)
+	assert.Equal(t, types.QuotaUsage{Memory: 10, Storage: 10}, q.Used)
+
+	assert.NoError(t, m.ChargeQuota(ctx, types.QuotaScopePod, "pod", types.QuotaUsage{Memory: -4, Storage: -4}))
+	q, err = m.GetQuota(ctx, types.QuotaScopePod, "pod")
+	assert.NoError(t, err)
+	assert.Equal(t, types.QuotaUsage{Memory: 6, Storage: 6}, q.Used)
+
+	// refunding more than used is rejected and nothing changes
+	err = m.ChargeQuota(ctx, types.QuotaScopePod, "pod", types.QuotaUsage{Storage: -7})
+	assert.True(t, errors.Is(err, types.ErrQuotaUnderflow))
+	q, err = m.GetQuota(ctx, types.QuotaScopePod, "pod")
+	assert.NoError(t, err)
+	assert.Equal(t, types.QuotaUsage{Memory: 6, Storage: 6}, q.Used)
+}
@@ -0,0 +1,54 @@
+package etcdv3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/projecteru2/core/types"
+	"go.etcd.io/etcd/v3/clientv3"
+)
+
+// SetRegistryCredential save a credential of registry, the old one of the same registry and pod is replaced
+// storage path in etcd is `/registries/:registry/:podname`
+func (m *Mercury) SetRegistryCredential(ctx context.Context, credential *types.RegistryCredential) error {
+	bytes, err := json.Marshal(credential)
+	if err != nil {
+		return err
+	}
+	_, err = m.Put(ctx, fmt.Sprintf(registryKey, credential.Registry, credential.Podname), string(bytes))
+	return err
+}
+
+// ListRegistryCredentials list credentials of registry for all pods, or credentials of all registries if registry is empty
+func (m *Mercury) ListRegistryCredentials(ctx context.Context, registry string) ([]*types.RegistryCredential, error) {
+	key := registryPrefix
+	if registry != "" {
+		key = fmt.Sprintf(registryKey, registry, "")
+	}
+	resp, err := m.Get(ctx, key, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	credentials := []*types.RegistryCredential{}
+	for _, ev := range resp.Kvs {
+		credential := &types.RegistryCredential{}
+		if err := json.Unmarshal(ev.Value, credential); err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, nil
+}
+
+// RemoveRegistryCredential remove a credential of registry by pod, empty podname for the one of all pods
+func (m *Mercury) RemoveRegistryCredential(ctx context.Context, registry, podname string) error {
+	resp, err := m.Delete(ctx, fmt.Sprintf(registryKey, registry, podname))
+	if err != nil {
+		return err
+	}
+	if resp.Deleted == 0 {
+		return types.NewDetailedErr(types.ErrKeyNotExists, registry+"/"+podname)
+	}
+	return nil
+}
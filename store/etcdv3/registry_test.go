@@ -0,0 +1,41 @@
+package etcdv3
+
+import (
+	"context"
+	"testing"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryCredential(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+
+	all := &types.RegistryCredential{Registry: "hub.example.com", Username: "u", Password: []byte{1, 2, 3}}
+	pod := &types.RegistryCredential{Registry: "hub.example.com", Podname: "p1", Username: "u1", Password: []byte{4}}
+	assert.NoError(t, m.SetRegistryCredential(ctx, all))
+	assert.NoError(t, m.SetRegistryCredential(ctx, pod))
+	assert.NoError(t, m.SetRegistryCredential(ctx, &types.RegistryCredential{Registry: "hub.example.com:5000", Username: "u2", Password: []byte{5}}))
+	credentials, err := m.ListRegistryCredentials(ctx, "hub.example.com")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []*types.RegistryCredential{all, pod}, credentials)
+	credentials, err = m.ListRegistryCredentials(ctx, "")
+	assert.NoError(t, err)
+	assert.Len(t, credentials, 3)
+
+	// rotated
+	all.Password = []byte{6}
+	assert.NoError(t, m.SetRegistryCredential(ctx, all))
+	credentials, err = m.ListRegistryCredentials(ctx, "hub.example.com")
+	assert.NoError(t, err)
+	assert.Len(t, credentials, 2)
+	assert.Contains(t, credentials, all)
+
+	assert.NoError(t, m.RemoveRegistryCredential(ctx, "hub.example.com", "p1"))
+	assert.Error(t, m.RemoveRegistryCredential(ctx, "hub.example.com", "p1"))
+	credentials, err = m.ListRegistryCredentials(ctx, "hub.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []*types.RegistryCredential{all}, credentials)
+}
@@ -0,0 +1,73 @@
+package etcdv3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/projecteru2/core/types"
+	"go.etcd.io/etcd/v3/clientv3"
+)
+
+// AddReservation save a reservation
+// storage path in etcd is `/reservations/:id`
+func (m *Mercury) AddReservation(ctx context.Context, reservation *types.Reservation) error {
+	bytes, err := json.Marshal(reservation)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf(reservationKey, reservation.ID)
+	_, err = m.Create(ctx, key, string(bytes))
+	return err
+}
+
+// UpdateReservation update a reservation, it fails if the reservation is claimed
+func (m *Mercury) UpdateReservation(ctx context.Context, reservation *types.Reservation) error {
+	bytes, err := json.Marshal(reservation)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf(reservationKey, reservation.ID)
+	_, err = m.Update(ctx, key, string(bytes))
+	return err
+}
+
+// GetReservation get a reservation by id
+func (m *Mercury) GetReservation(ctx context.Context, id string) (*types.Reservation, error) {
+	ev, err := m.GetOne(ctx, fmt.Sprintf(reservationKey, id))
+	if err != nil {
+		return nil, err
+	}
+	reservation := &types.Reservation{}
+	return reservation, json.Unmarshal(ev.Value, reservation)
+}
+
+// ListReservations list all reservations
+func (m *Mercury) ListReservations(ctx context.Context) ([]*types.Reservation, error) {
+	resp, err := m.Get(ctx, fmt.Sprintf(reservationKey, ""), clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	reservations := []*types.Reservation{}
+	for _, ev := range resp.Kvs {
+		reservation := &types.Reservation{}
+		if err := json.Unmarshal(ev.Value, reservation); err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, reservation)
+	}
+	return reservations, nil
+}
+
+// RemoveReservation remove a reservation
+// only one caller can remove it, so it's used to claim a reservation
+func (m *Mercury) RemoveReservation(ctx context.Context, id string) error {
+	resp, err := m.Delete(ctx, fmt.Sprintf(reservationKey, id))
+	if err != nil {
+		return err
+	}
+	if resp.Deleted == 0 {
+		return types.NewDetailedErr(types.ErrKeyNotExists, id)
+	}
+	return nil
+}
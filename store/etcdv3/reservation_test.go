@@ -0,0 +1,61 @@
+package etcdv3
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservation(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+
+	r := &types.Reservation{
+		ID:         "r1",
+		Podname:    "pod",
+		Appname:    "app",
+		Entrypoint: "web",
+		Memory:     100,
+		Volumes:    types.MustToVolumeBindings([]string{"AUTO:/data:rw:100"}),
+		NodesInfo: []types.NodeInfo{
+			{Name: "n1", Deploy: 2, VolumePlans: []types.VolumePlan{
+				{types.MustToVolumeBinding("AUTO:/data:rw:100"): types.VolumeMap{"/dir0": 100}},
+				{types.MustToVolumeBinding("AUTO:/data:rw:100"): types.VolumeMap{"/dir1": 100}},
+			}},
+		},
+		Expire: time.Now().Add(time.Minute),
+	}
+	assert.NoError(t, m.AddReservation(ctx, r))
+	// duplicated
+	assert.Error(t, m.AddReservation(ctx, r))
+
+	r2, err := m.GetReservation(ctx, "r1")
+	assert.NoError(t, err)
+	assert.Equal(t, r2.Count(), 2)
+	assert.True(t, r2.Volumes.IsEqual(r.Volumes))
+	assert.Equal(t, r2.NodesInfo[0].VolumePlans[1].IntoVolumeMap(), types.VolumeMap{"/dir1": 100})
+	_, err = m.GetReservation(ctx, "r2")
+	assert.Error(t, err)
+
+	rs, err := m.ListReservations(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, rs, 1)
+
+	r.Dispatching = true
+	assert.NoError(t, m.UpdateReservation(ctx, r))
+	r2, err = m.GetReservation(ctx, "r1")
+	assert.NoError(t, err)
+	assert.True(t, r2.Dispatching)
+
+	assert.NoError(t, m.RemoveReservation(ctx, "r1"))
+	assert.Error(t, m.RemoveReservation(ctx, "r1"))
+	// claimed
+	assert.Error(t, m.UpdateReservation(ctx, r))
+	rs, err = m.ListReservations(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, rs, 0)
+}
@@ -0,0 +1,60 @@
+package etcdv3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/projecteru2/core/types"
+	"go.etcd.io/etcd/v3/clientv3"
+)
+
+// SetSecret save an encrypted secret, the old one of the same name is replaced
+// storage path in etcd is `/secrets/:name`
+func (m *Mercury) SetSecret(ctx context.Context, secret *types.Secret) error {
+	bytes, err := json.Marshal(secret)
+	if err != nil {
+		return err
+	}
+	_, err = m.Put(ctx, fmt.Sprintf(secretKey, secret.Name), string(bytes))
+	return err
+}
+
+// GetSecret get an encrypted secret by name
+func (m *Mercury) GetSecret(ctx context.Context, name string) (*types.Secret, error) {
+	ev, err := m.GetOne(ctx, fmt.Sprintf(secretKey, name))
+	if err != nil {
+		return nil, err
+	}
+	secret := &types.Secret{}
+	return secret, json.Unmarshal(ev.Value, secret)
+}
+
+// ListSecrets list all encrypted secrets
+func (m *Mercury) ListSecrets(ctx context.Context) ([]*types.Secret, error) {
+	resp, err := m.Get(ctx, secretPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	secrets := []*types.Secret{}
+	for _, ev := range resp.Kvs {
+		secret := &types.Secret{}
+		if err := json.Unmarshal(ev.Value, secret); err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, nil
+}
+
+// RemoveSecret remove a secret by name
+func (m *Mercury) RemoveSecret(ctx context.Context, name string) error {
+	resp, err := m.Delete(ctx, fmt.Sprintf(secretKey, name))
+	if err != nil {
+		return err
+	}
+	if resp.Deleted == 0 {
+		return types.NewDetailedErr(types.ErrKeyNotExists, name)
+	}
+	return nil
+}
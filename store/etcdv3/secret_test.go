@@ -0,0 +1,33 @@
+package etcdv3
+
+import (
+	"context"
+	"testing"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecret(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+
+	secret := &types.Secret{Name: "db", Data: []byte{1, 2, 3}}
+	assert.NoError(t, m.SetSecret(ctx, secret))
+	assert.NoError(t, m.SetSecret(ctx, &types.Secret{Name: "tls", Data: []byte{4}}))
+	s, err := m.GetSecret(ctx, "db")
+	assert.NoError(t, err)
+	assert.Equal(t, secret, s)
+	_, err = m.GetSecret(ctx, "nope")
+	assert.Error(t, err)
+	secrets, err := m.ListSecrets(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, secrets, 2)
+
+	assert.NoError(t, m.RemoveSecret(ctx, "tls"))
+	assert.Error(t, m.RemoveSecret(ctx, "tls"))
+	secrets, err = m.ListSecrets(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, secrets, 1)
+}
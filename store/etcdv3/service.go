@@ -86,7 +86,7 @@ func (m *Mercury) ServiceStatusStream(ctx context.Context) (chan []string, error
 // RegisterService put /services/{address}
 func (m *Mercury) RegisterService(ctx context.Context, serviceAddress string, expire time.Duration) error {
 	key := fmt.Sprintf(serviceStatusKey, serviceAddress)
-	lease, err := m.cliv3.Grant(ctx, int64(expire/time.Second))
+	lease, err := m.grant(ctx, int64(expire/time.Second))
 	if err != nil {
 		return err
 	}
@@ -102,6 +102,15 @@ func (m *Mercury) UnregisterService(ctx context.Context, serviceAddress string)
 	return err
 }
 
+// ConnectionState shows state of connection to etcd, e.g. READY, TRANSIENT_FAILURE
+func (m *Mercury) ConnectionState() string {
+	conn := m.cliv3.ActiveConnection()
+	if conn == nil {
+		return "UNKNOWN"
+	}
+	return conn.GetState().String()
+}
+
 func parseServiceKey(key []byte) (endpoint string) {
 	parts := strings.Split(string(key), "/")
 	return parts[len(parts)-1]
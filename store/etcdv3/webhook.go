@@ -0,0 +1,50 @@
+package etcdv3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/projecteru2/core/types"
+	"go.etcd.io/etcd/v3/clientv3"
+)
+
+// SetEventWebhook save a webhook, the old one of the same name is replaced
+// storage path in etcd is `/webhooks/:name`
+func (m *Mercury) SetEventWebhook(ctx context.Context, webhook *types.EventWebhook) error {
+	bytes, err := json.Marshal(webhook)
+	if err != nil {
+		return err
+	}
+	_, err = m.Put(ctx, fmt.Sprintf(webhookKey, webhook.Name), string(bytes))
+	return err
+}
+
+// ListEventWebhooks list all webhooks
+func (m *Mercury) ListEventWebhooks(ctx context.Context) ([]*types.EventWebhook, error) {
+	resp, err := m.Get(ctx, webhookPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	webhooks := []*types.EventWebhook{}
+	for _, ev := range resp.Kvs {
+		webhook := &types.EventWebhook{}
+		if err := json.Unmarshal(ev.Value, webhook); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+// RemoveEventWebhook remove a webhook by name
+func (m *Mercury) RemoveEventWebhook(ctx context.Context, name string) error {
+	resp, err := m.Delete(ctx, fmt.Sprintf(webhookKey, name))
+	if err != nil {
+		return err
+	}
+	if resp.Deleted == 0 {
+		return types.NewDetailedErr(types.ErrKeyNotExists, name)
+	}
+	return nil
+}
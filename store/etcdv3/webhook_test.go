@@ -0,0 +1,29 @@
+package etcdv3
+
+import (
+	"context"
+	"testing"
+
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventWebhook(t *testing.T) {
+	m := NewMercury(t)
+	defer m.TerminateEmbededStorage()
+	ctx := context.Background()
+
+	webhook := &types.EventWebhook{Name: "w1", URL: "http://127.0.0.1/hook", Secret: "s"}
+	assert.NoError(t, m.SetEventWebhook(ctx, webhook))
+	assert.NoError(t, m.SetEventWebhook(ctx, &types.EventWebhook{Name: "w2", URL: "http://127.0.0.1/hook2"}))
+	webhooks, err := m.ListEventWebhooks(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, webhooks, 2)
+	assert.Equal(t, webhook, webhooks[0])
+
+	assert.NoError(t, m.RemoveEventWebhook(ctx, "w2"))
+	assert.Error(t, m.RemoveEventWebhook(ctx, "w2"))
+	webhooks, err = m.ListEventWebhooks(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, webhooks, 1)
+}
@@ -18,6 +18,34 @@ type Store struct {
 	mock.Mock
 }
 
+// AddAuditLog provides a mock function with given fields: ctx, log, ttl
+func (_m *Store) AddAuditLog(ctx context.Context, log *types.AuditLog, ttl time.Duration) error {
+	ret := _m.Called(ctx, log, ttl)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.AuditLog, time.Duration) error); ok {
+		r0 = rf(ctx, log, ttl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddCapacitySample provides a mock function with given fields: ctx, podname, sample
+func (_m *Store) AddCapacitySample(ctx context.Context, podname string, sample *types.CapacitySample) error {
+	ret := _m.Called(ctx, podname, sample)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *types.CapacitySample) error); ok {
+		r0 = rf(ctx, podname, sample)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // AddContainer provides a mock function with given fields: ctx, container
 func (_m *Store) AddContainer(ctx context.Context, container *types.Container) error {
 	ret := _m.Called(ctx, container)
@@ -78,6 +106,71 @@ func (_m *Store) AddPod(ctx context.Context, name string, desc string) (*types.P
 	return r0, r1
 }
 
+// AddReservation provides a mock function with given fields: ctx, reservation
+func (_m *Store) AddReservation(ctx context.Context, reservation *types.Reservation) error {
+	ret := _m.Called(ctx, reservation)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Reservation) error); ok {
+		r0 = rf(ctx, reservation)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Campaign provides a mock function with given fields: ctx, name, ttl
+func (_m *Store) Campaign(ctx context.Context, name string, ttl time.Duration) (<-chan struct{}, error) {
+	ret := _m.Called(ctx, name, ttl)
+
+	var r0 <-chan struct{}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) <-chan struct{}); ok {
+		r0 = rf(ctx, name, ttl)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan struct{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Duration) error); ok {
+		r1 = rf(ctx, name, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChargeQuota provides a mock function with given fields: ctx, scope, name, delta
+func (_m *Store) ChargeQuota(ctx context.Context, scope string, name string, delta types.QuotaUsage) error {
+	ret := _m.Called(ctx, scope, name, delta)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, types.QuotaUsage) error); ok {
+		r0 = rf(ctx, scope, name, delta)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConnectionState provides a mock function with given fields:
+func (_m *Store) ConnectionState() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
 // ContainerStatusStream provides a mock function with given fields: ctx, appname, entrypoint, nodename, labels
 func (_m *Store) ContainerStatusStream(ctx context.Context, appname string, entrypoint string, nodename string, labels map[string]string) chan *types.ContainerStatus {
 	ret := _m.Called(ctx, appname, entrypoint, nodename, labels)
@@ -117,6 +210,20 @@ func (_m *Store) CreateLock(key string, ttl time.Duration) (lock.DistributedLock
 	return r0, r1
 }
 
+// DeleteContainerMeta provides a mock function with given fields: ctx, ID, key
+func (_m *Store) DeleteContainerMeta(ctx context.Context, ID string, key string) error {
+	ret := _m.Called(ctx, ID, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, ID, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteProcessing provides a mock function with given fields: ctx, opts, nodeInfo
 func (_m *Store) DeleteProcessing(ctx context.Context, opts *types.DeployOptions, nodeInfo types.NodeInfo) error {
 	ret := _m.Called(ctx, opts, nodeInfo)
@@ -154,6 +261,29 @@ func (_m *Store) GetAllPods(ctx context.Context) ([]*types.Pod, error) {
 	return r0, r1
 }
 
+// GetConfigObject provides a mock function with given fields: ctx, name
+func (_m *Store) GetConfigObject(ctx context.Context, name string) (*types.ConfigObject, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *types.ConfigObject
+	if rf, ok := ret.Get(0).(func(context.Context, string) *types.ConfigObject); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.ConfigObject)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetContainer provides a mock function with given fields: ctx, ID
 func (_m *Store) GetContainer(ctx context.Context, ID string) (*types.Container, error) {
 	ret := _m.Called(ctx, ID)
@@ -177,6 +307,29 @@ func (_m *Store) GetContainer(ctx context.Context, ID string) (*types.Container,
 	return r0, r1
 }
 
+// GetContainerMeta provides a mock function with given fields: ctx, ID, key
+func (_m *Store) GetContainerMeta(ctx context.Context, ID string, key string) ([]byte, error) {
+	ret := _m.Called(ctx, ID, key)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []byte); ok {
+		r0 = rf(ctx, ID, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ID, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetContainerStatus provides a mock function with given fields: ctx, ID
 func (_m *Store) GetContainerStatus(ctx context.Context, ID string) (*types.StatusMeta, error) {
 	ret := _m.Called(ctx, ID)
@@ -223,6 +376,29 @@ func (_m *Store) GetContainers(ctx context.Context, IDs []string) ([]*types.Cont
 	return r0, r1
 }
 
+// GetCron provides a mock function with given fields: ctx, name
+func (_m *Store) GetCron(ctx context.Context, name string) (*types.Cron, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *types.Cron
+	if rf, ok := ret.Get(0).(func(context.Context, string) *types.Cron); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Cron)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetNode provides a mock function with given fields: ctx, nodename
 func (_m *Store) GetNode(ctx context.Context, nodename string) (*types.Node, error) {
 	ret := _m.Called(ctx, nodename)
@@ -315,22 +491,20 @@ func (_m *Store) GetPod(ctx context.Context, podname string) (*types.Pod, error)
 	return r0, r1
 }
 
-// ListContainers provides a mock function with given fields: ctx, appname, entrypoint, nodename, limit, labels
-func (_m *Store) ListContainers(ctx context.Context, appname string, entrypoint string, nodename string, limit int64, labels map[string]string) ([]*types.Container, error) {
-	ret := _m.Called(ctx, appname, entrypoint, nodename, limit, labels)
+// GetProcessing provides a mock function with given fields: ctx, opts, nodename
+func (_m *Store) GetProcessing(ctx context.Context, opts *types.DeployOptions, nodename string) (int, error) {
+	ret := _m.Called(ctx, opts, nodename)
 
-	var r0 []*types.Container
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, int64, map[string]string) []*types.Container); ok {
-		r0 = rf(ctx, appname, entrypoint, nodename, limit, labels)
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, *types.DeployOptions, string) int); ok {
+		r0 = rf(ctx, opts, nodename)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*types.Container)
-		}
+		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, int64, map[string]string) error); ok {
-		r1 = rf(ctx, appname, entrypoint, nodename, limit, labels)
+	if rf, ok := ret.Get(1).(func(context.Context, *types.DeployOptions, string) error); ok {
+		r1 = rf(ctx, opts, nodename)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -338,22 +512,22 @@ func (_m *Store) ListContainers(ctx context.Context, appname string, entrypoint
 	return r0, r1
 }
 
-// ListNodeContainers provides a mock function with given fields: ctx, nodename, labels
-func (_m *Store) ListNodeContainers(ctx context.Context, nodename string, labels map[string]string) ([]*types.Container, error) {
-	ret := _m.Called(ctx, nodename, labels)
+// GetQuota provides a mock function with given fields: ctx, scope, name
+func (_m *Store) GetQuota(ctx context.Context, scope string, name string) (*types.Quota, error) {
+	ret := _m.Called(ctx, scope, name)
 
-	var r0 []*types.Container
-	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]string) []*types.Container); ok {
-		r0 = rf(ctx, nodename, labels)
+	var r0 *types.Quota
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *types.Quota); ok {
+		r0 = rf(ctx, scope, name)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*types.Container)
+			r0 = ret.Get(0).(*types.Quota)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, map[string]string) error); ok {
-		r1 = rf(ctx, nodename, labels)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, scope, name)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -361,22 +535,22 @@ func (_m *Store) ListNodeContainers(ctx context.Context, nodename string, labels
 	return r0, r1
 }
 
-// MakeDeployStatus provides a mock function with given fields: ctx, opts, nodesInfo
-func (_m *Store) MakeDeployStatus(ctx context.Context, opts *types.DeployOptions, nodesInfo []types.NodeInfo) ([]types.NodeInfo, error) {
-	ret := _m.Called(ctx, opts, nodesInfo)
+// GetReservation provides a mock function with given fields: ctx, id
+func (_m *Store) GetReservation(ctx context.Context, id string) (*types.Reservation, error) {
+	ret := _m.Called(ctx, id)
 
-	var r0 []types.NodeInfo
-	if rf, ok := ret.Get(0).(func(context.Context, *types.DeployOptions, []types.NodeInfo) []types.NodeInfo); ok {
-		r0 = rf(ctx, opts, nodesInfo)
+	var r0 *types.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, string) *types.Reservation); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]types.NodeInfo)
+			r0 = ret.Get(0).(*types.Reservation)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *types.DeployOptions, []types.NodeInfo) error); ok {
-		r1 = rf(ctx, opts, nodesInfo)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -384,92 +558,91 @@ func (_m *Store) MakeDeployStatus(ctx context.Context, opts *types.DeployOptions
 	return r0, r1
 }
 
-// RegisterService provides a mock function with given fields: _a0, _a1, _a2
-func (_m *Store) RegisterService(_a0 context.Context, _a1 string, _a2 time.Duration) error {
-	ret := _m.Called(_a0, _a1, _a2)
+// GetScalePolicy provides a mock function with given fields: ctx, appname, entrypoint
+func (_m *Store) GetScalePolicy(ctx context.Context, appname string, entrypoint string) (*types.ScalePolicy, error) {
+	ret := _m.Called(ctx, appname, entrypoint)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) error); ok {
-		r0 = rf(_a0, _a1, _a2)
+	var r0 *types.ScalePolicy
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *types.ScalePolicy); ok {
+		r0 = rf(ctx, appname, entrypoint)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.ScalePolicy)
+		}
 	}
 
-	return r0
-}
-
-// RemoveContainer provides a mock function with given fields: ctx, container
-func (_m *Store) RemoveContainer(ctx context.Context, container *types.Container) error {
-	ret := _m.Called(ctx, container)
-
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *types.Container) error); ok {
-		r0 = rf(ctx, container)
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, appname, entrypoint)
 	} else {
-		r0 = ret.Error(0)
+		r1 = ret.Error(1)
 	}
 
-	return r0
+	return r0, r1
 }
 
-// RemoveNode provides a mock function with given fields: ctx, node
-func (_m *Store) RemoveNode(ctx context.Context, node *types.Node) error {
-	ret := _m.Called(ctx, node)
+// GetSecret provides a mock function with given fields: ctx, name
+func (_m *Store) GetSecret(ctx context.Context, name string) (*types.Secret, error) {
+	ret := _m.Called(ctx, name)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *types.Node) error); ok {
-		r0 = rf(ctx, node)
+	var r0 *types.Secret
+	if rf, ok := ret.Get(0).(func(context.Context, string) *types.Secret); ok {
+		r0 = rf(ctx, name)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Secret)
+		}
 	}
 
-	return r0
-}
-
-// RemovePod provides a mock function with given fields: ctx, podname
-func (_m *Store) RemovePod(ctx context.Context, podname string) error {
-	ret := _m.Called(ctx, podname)
-
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(ctx, podname)
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
 	} else {
-		r0 = ret.Error(0)
+		r1 = ret.Error(1)
 	}
 
-	return r0
+	return r0, r1
 }
 
-// SaveProcessing provides a mock function with given fields: ctx, opts, nodeInfo
-func (_m *Store) SaveProcessing(ctx context.Context, opts *types.DeployOptions, nodeInfo types.NodeInfo) error {
-	ret := _m.Called(ctx, opts, nodeInfo)
+// ListAliveNodes provides a mock function with given fields: ctx
+func (_m *Store) ListAliveNodes(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *types.DeployOptions, types.NodeInfo) error); ok {
-		r0 = rf(ctx, opts, nodeInfo)
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// ServiceStatusStream provides a mock function with given fields: _a0
-func (_m *Store) ServiceStatusStream(_a0 context.Context) (chan []string, error) {
-	ret := _m.Called(_a0)
+// ListAuditLogs provides a mock function with given fields: ctx, since, until
+func (_m *Store) ListAuditLogs(ctx context.Context, since time.Time, until time.Time) ([]*types.AuditLog, error) {
+	ret := _m.Called(ctx, since, until)
 
-	var r0 chan []string
-	if rf, ok := ret.Get(0).(func(context.Context) chan []string); ok {
-		r0 = rf(_a0)
+	var r0 []*types.AuditLog
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time) []*types.AuditLog); ok {
+		r0 = rf(ctx, since, until)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(chan []string)
+			r0 = ret.Get(0).([]*types.AuditLog)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, since, until)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -477,41 +650,736 @@ func (_m *Store) ServiceStatusStream(_a0 context.Context) (chan []string, error)
 	return r0, r1
 }
 
-// SetContainerStatus provides a mock function with given fields: ctx, container, ttl
-func (_m *Store) SetContainerStatus(ctx context.Context, container *types.Container, ttl int64) error {
-	ret := _m.Called(ctx, container, ttl)
+// ListCapacitySamples provides a mock function with given fields: ctx, podname, since
+func (_m *Store) ListCapacitySamples(ctx context.Context, podname string, since time.Time) ([]*types.CapacitySample, error) {
+	ret := _m.Called(ctx, podname, since)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *types.Container, int64) error); ok {
-		r0 = rf(ctx, container, ttl)
+	var r0 []*types.CapacitySample
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) []*types.CapacitySample); ok {
+		r0 = rf(ctx, podname, since)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.CapacitySample)
+		}
 	}
 
-	return r0
-}
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(ctx, podname, since)
+	} else {
+		r1 = ret.Error(1)
+	}
 
-// TerminateEmbededStorage provides a mock function with given fields:
-func (_m *Store) TerminateEmbededStorage() {
-	_m.Called()
+	return r0, r1
 }
 
-// UnregisterService provides a mock function with given fields: _a0, _a1
-func (_m *Store) UnregisterService(_a0 context.Context, _a1 string) error {
-	ret := _m.Called(_a0, _a1)
+// ListConfigObjects provides a mock function with given fields: ctx
+func (_m *Store) ListConfigObjects(ctx context.Context) ([]*types.ConfigObject, error) {
+	ret := _m.Called(ctx)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
-		r0 = rf(_a0, _a1)
+	var r0 []*types.ConfigObject
+	if rf, ok := ret.Get(0).(func(context.Context) []*types.ConfigObject); ok {
+		r0 = rf(ctx)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.ConfigObject)
+		}
 	}
 
-	return r0
-}
-
-// UpdateContainer provides a mock function with given fields: ctx, container
-func (_m *Store) UpdateContainer(ctx context.Context, container *types.Container) error {
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListContainers provides a mock function with given fields: ctx, appname, entrypoint, nodename, limit, labels
+func (_m *Store) ListContainers(ctx context.Context, appname string, entrypoint string, nodename string, limit int64, labels map[string]string) ([]*types.Container, error) {
+	ret := _m.Called(ctx, appname, entrypoint, nodename, limit, labels)
+
+	var r0 []*types.Container
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, int64, map[string]string) []*types.Container); ok {
+		r0 = rf(ctx, appname, entrypoint, nodename, limit, labels)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Container)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, int64, map[string]string) error); ok {
+		r1 = rf(ctx, appname, entrypoint, nodename, limit, labels)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListCrons provides a mock function with given fields: ctx
+func (_m *Store) ListCrons(ctx context.Context) ([]*types.Cron, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*types.Cron
+	if rf, ok := ret.Get(0).(func(context.Context) []*types.Cron); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Cron)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListEventWebhooks provides a mock function with given fields: ctx
+func (_m *Store) ListEventWebhooks(ctx context.Context) ([]*types.EventWebhook, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*types.EventWebhook
+	if rf, ok := ret.Get(0).(func(context.Context) []*types.EventWebhook); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.EventWebhook)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListNodeContainers provides a mock function with given fields: ctx, nodename, labels
+func (_m *Store) ListNodeContainers(ctx context.Context, nodename string, labels map[string]string) ([]*types.Container, error) {
+	ret := _m.Called(ctx, nodename, labels)
+
+	var r0 []*types.Container
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]string) []*types.Container); ok {
+		r0 = rf(ctx, nodename, labels)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Container)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, map[string]string) error); ok {
+		r1 = rf(ctx, nodename, labels)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListProcessing provides a mock function with given fields: ctx
+func (_m *Store) ListProcessing(ctx context.Context) (map[string]int, error) {
+	ret := _m.Called(ctx)
+
+	var r0 map[string]int
+	if rf, ok := ret.Get(0).(func(context.Context) map[string]int); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]int)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListProcessingRecords provides a mock function with given fields: ctx
+func (_m *Store) ListProcessingRecords(ctx context.Context) ([]*types.ProcessingRecord, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*types.ProcessingRecord
+	if rf, ok := ret.Get(0).(func(context.Context) []*types.ProcessingRecord); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.ProcessingRecord)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListQuotas provides a mock function with given fields: ctx
+func (_m *Store) ListQuotas(ctx context.Context) ([]*types.Quota, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*types.Quota
+	if rf, ok := ret.Get(0).(func(context.Context) []*types.Quota); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Quota)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListRegistryCredentials provides a mock function with given fields: ctx, registry
+func (_m *Store) ListRegistryCredentials(ctx context.Context, registry string) ([]*types.RegistryCredential, error) {
+	ret := _m.Called(ctx, registry)
+
+	var r0 []*types.RegistryCredential
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*types.RegistryCredential); ok {
+		r0 = rf(ctx, registry)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.RegistryCredential)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, registry)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListReservations provides a mock function with given fields: ctx
+func (_m *Store) ListReservations(ctx context.Context) ([]*types.Reservation, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*types.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context) []*types.Reservation); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListScalePolicies provides a mock function with given fields: ctx
+func (_m *Store) ListScalePolicies(ctx context.Context) ([]*types.ScalePolicy, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*types.ScalePolicy
+	if rf, ok := ret.Get(0).(func(context.Context) []*types.ScalePolicy); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.ScalePolicy)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSecrets provides a mock function with given fields: ctx
+func (_m *Store) ListSecrets(ctx context.Context) ([]*types.Secret, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*types.Secret
+	if rf, ok := ret.Get(0).(func(context.Context) []*types.Secret); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Secret)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MakeDeployStatus provides a mock function with given fields: ctx, opts, nodesInfo
+func (_m *Store) MakeDeployStatus(ctx context.Context, opts *types.DeployOptions, nodesInfo []types.NodeInfo) ([]types.NodeInfo, error) {
+	ret := _m.Called(ctx, opts, nodesInfo)
+
+	var r0 []types.NodeInfo
+	if rf, ok := ret.Get(0).(func(context.Context, *types.DeployOptions, []types.NodeInfo) []types.NodeInfo); ok {
+		r0 = rf(ctx, opts, nodesInfo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.NodeInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.DeployOptions, []types.NodeInfo) error); ok {
+		r1 = rf(ctx, opts, nodesInfo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PublishEvent provides a mock function with given fields: ctx, event, ttl
+func (_m *Store) PublishEvent(ctx context.Context, event *types.Event, ttl time.Duration) error {
+	ret := _m.Called(ctx, event, ttl)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Event, time.Duration) error); ok {
+		r0 = rf(ctx, event, ttl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RegisterService provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Store) RegisterService(_a0 context.Context, _a1 string, _a2 time.Duration) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveCapacitySamples provides a mock function with given fields: ctx, podname, before
+func (_m *Store) RemoveCapacitySamples(ctx context.Context, podname string, before time.Time) error {
+	ret := _m.Called(ctx, podname, before)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, podname, before)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveConfigObject provides a mock function with given fields: ctx, name
+func (_m *Store) RemoveConfigObject(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveContainer provides a mock function with given fields: ctx, container
+func (_m *Store) RemoveContainer(ctx context.Context, container *types.Container) error {
+	ret := _m.Called(ctx, container)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Container) error); ok {
+		r0 = rf(ctx, container)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveCron provides a mock function with given fields: ctx, name
+func (_m *Store) RemoveCron(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveEventWebhook provides a mock function with given fields: ctx, name
+func (_m *Store) RemoveEventWebhook(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveNode provides a mock function with given fields: ctx, node
+func (_m *Store) RemoveNode(ctx context.Context, node *types.Node) error {
+	ret := _m.Called(ctx, node)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Node) error); ok {
+		r0 = rf(ctx, node)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemovePod provides a mock function with given fields: ctx, podname
+func (_m *Store) RemovePod(ctx context.Context, podname string) error {
+	ret := _m.Called(ctx, podname)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, podname)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveQuota provides a mock function with given fields: ctx, scope, name
+func (_m *Store) RemoveQuota(ctx context.Context, scope string, name string) error {
+	ret := _m.Called(ctx, scope, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, scope, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveRegistryCredential provides a mock function with given fields: ctx, registry, podname
+func (_m *Store) RemoveRegistryCredential(ctx context.Context, registry string, podname string) error {
+	ret := _m.Called(ctx, registry, podname)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, registry, podname)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveReservation provides a mock function with given fields: ctx, id
+func (_m *Store) RemoveReservation(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveScalePolicy provides a mock function with given fields: ctx, appname, entrypoint
+func (_m *Store) RemoveScalePolicy(ctx context.Context, appname string, entrypoint string) error {
+	ret := _m.Called(ctx, appname, entrypoint)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, appname, entrypoint)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveSecret provides a mock function with given fields: ctx, name
+func (_m *Store) RemoveSecret(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveProcessing provides a mock function with given fields: ctx, opts, nodeInfo
+func (_m *Store) SaveProcessing(ctx context.Context, opts *types.DeployOptions, nodeInfo types.NodeInfo) error {
+	ret := _m.Called(ctx, opts, nodeInfo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.DeployOptions, types.NodeInfo) error); ok {
+		r0 = rf(ctx, opts, nodeInfo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ServiceStatusStream provides a mock function with given fields: _a0
+func (_m *Store) ServiceStatusStream(_a0 context.Context) (chan []string, error) {
+	ret := _m.Called(_a0)
+
+	var r0 chan []string
+	if rf, ok := ret.Get(0).(func(context.Context) chan []string); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(chan []string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetConfigObject provides a mock function with given fields: ctx, object
+func (_m *Store) SetConfigObject(ctx context.Context, object *types.ConfigObject) error {
+	ret := _m.Called(ctx, object)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.ConfigObject) error); ok {
+		r0 = rf(ctx, object)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetContainerMeta provides a mock function with given fields: ctx, ID, key, value
+func (_m *Store) SetContainerMeta(ctx context.Context, ID string, key string, value []byte) error {
+	ret := _m.Called(ctx, ID, key, value)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []byte) error); ok {
+		r0 = rf(ctx, ID, key, value)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetContainerStatus provides a mock function with given fields: ctx, container, ttl
+func (_m *Store) SetContainerStatus(ctx context.Context, container *types.Container, ttl int64) error {
+	ret := _m.Called(ctx, container, ttl)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Container, int64) error); ok {
+		r0 = rf(ctx, container, ttl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetCron provides a mock function with given fields: ctx, cron
+func (_m *Store) SetCron(ctx context.Context, cron *types.Cron) error {
+	ret := _m.Called(ctx, cron)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Cron) error); ok {
+		r0 = rf(ctx, cron)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetCronRun provides a mock function with given fields: ctx, run, ttl
+func (_m *Store) SetCronRun(ctx context.Context, run *types.CronRun, ttl time.Duration) error {
+	ret := _m.Called(ctx, run, ttl)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.CronRun, time.Duration) error); ok {
+		r0 = rf(ctx, run, ttl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetEventWebhook provides a mock function with given fields: ctx, webhook
+func (_m *Store) SetEventWebhook(ctx context.Context, webhook *types.EventWebhook) error {
+	ret := _m.Called(ctx, webhook)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.EventWebhook) error); ok {
+		r0 = rf(ctx, webhook)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetNodeAlive provides a mock function with given fields: ctx, nodename, ttl
+func (_m *Store) SetNodeAlive(ctx context.Context, nodename string, ttl time.Duration) error {
+	ret := _m.Called(ctx, nodename, ttl)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) error); ok {
+		r0 = rf(ctx, nodename, ttl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetQuota provides a mock function with given fields: ctx, quota
+func (_m *Store) SetQuota(ctx context.Context, quota *types.Quota) error {
+	ret := _m.Called(ctx, quota)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Quota) error); ok {
+		r0 = rf(ctx, quota)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetRegistryCredential provides a mock function with given fields: ctx, credential
+func (_m *Store) SetRegistryCredential(ctx context.Context, credential *types.RegistryCredential) error {
+	ret := _m.Called(ctx, credential)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.RegistryCredential) error); ok {
+		r0 = rf(ctx, credential)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetScalePolicy provides a mock function with given fields: ctx, policy
+func (_m *Store) SetScalePolicy(ctx context.Context, policy *types.ScalePolicy) error {
+	ret := _m.Called(ctx, policy)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.ScalePolicy) error); ok {
+		r0 = rf(ctx, policy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetSecret provides a mock function with given fields: ctx, secret
+func (_m *Store) SetSecret(ctx context.Context, secret *types.Secret) error {
+	ret := _m.Called(ctx, secret)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Secret) error); ok {
+		r0 = rf(ctx, secret)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TerminateEmbededStorage provides a mock function with given fields:
+func (_m *Store) TerminateEmbededStorage() {
+	_m.Called()
+}
+
+// UnregisterService provides a mock function with given fields: _a0, _a1
+func (_m *Store) UnregisterService(_a0 context.Context, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateContainer provides a mock function with given fields: ctx, container
+func (_m *Store) UpdateContainer(ctx context.Context, container *types.Container) error {
 	ret := _m.Called(ctx, container)
 
 	var r0 error
@@ -538,13 +1406,41 @@ func (_m *Store) UpdateNode(ctx context.Context, node *types.Node) error {
 	return r0
 }
 
-// UpdateNodeResource provides a mock function with given fields: ctx, node, cpu, quota, memory, storage, volume, action
-func (_m *Store) UpdateNodeResource(ctx context.Context, node *types.Node, cpu types.ResourceMap, quota float64, memory int64, storage int64, volume types.ResourceMap, action string) error {
-	ret := _m.Called(ctx, node, cpu, quota, memory, storage, volume, action)
+// UpdateNodeEngine provides a mock function with given fields: ctx, node, endpoint, ca, cert, key
+func (_m *Store) UpdateNodeEngine(ctx context.Context, node *types.Node, endpoint string, ca string, cert string, key string) error {
+	ret := _m.Called(ctx, node, endpoint, ca, cert, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Node, string, string, string, string) error); ok {
+		r0 = rf(ctx, node, endpoint, ca, cert, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateNodeResource provides a mock function with given fields: ctx, node, cpu, quota, memory, storage, volume, hugepages, gpu, action
+func (_m *Store) UpdateNodeResource(ctx context.Context, node *types.Node, cpu types.CPUMap, quota float64, memory int64, storage int64, volume types.VolumeMap, hugepages types.HugepageMap, gpu types.GPUMap, action string) error {
+	ret := _m.Called(ctx, node, cpu, quota, memory, storage, volume, hugepages, gpu, action)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Node, types.CPUMap, float64, int64, int64, types.VolumeMap, types.HugepageMap, types.GPUMap, string) error); ok {
+		r0 = rf(ctx, node, cpu, quota, memory, storage, volume, hugepages, gpu, action)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePod provides a mock function with given fields: ctx, pod
+func (_m *Store) UpdatePod(ctx context.Context, pod *types.Pod) error {
+	ret := _m.Called(ctx, pod)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *types.Node, types.ResourceMap, float64, int64, int64, types.ResourceMap, string) error); ok {
-		r0 = rf(ctx, node, cpu, quota, memory, storage, volume, action)
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Pod) error); ok {
+		r0 = rf(ctx, pod)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -565,3 +1461,63 @@ func (_m *Store) UpdateProcessing(ctx context.Context, opts *types.DeployOptions
 
 	return r0
 }
+
+// UpdateReservation provides a mock function with given fields: ctx, reservation
+func (_m *Store) UpdateReservation(ctx context.Context, reservation *types.Reservation) error {
+	ret := _m.Called(ctx, reservation)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *types.Reservation) error); ok {
+		r0 = rf(ctx, reservation)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WatchCronRuns provides a mock function with given fields: ctx, name
+func (_m *Store) WatchCronRuns(ctx context.Context, name string) (<-chan *types.CronRun, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 <-chan *types.CronRun
+	if rf, ok := ret.Get(0).(func(context.Context, string) <-chan *types.CronRun); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan *types.CronRun)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WatchEvents provides a mock function with given fields: ctx
+func (_m *Store) WatchEvents(ctx context.Context) (<-chan *types.Event, error) {
+	ret := _m.Called(ctx)
+
+	var r0 <-chan *types.Event
+	if rf, ok := ret.Get(0).(func(context.Context) <-chan *types.Event); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan *types.Event)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
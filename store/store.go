@@ -21,13 +21,22 @@ type Store interface {
 	ServiceStatusStream(context.Context) (chan []string, error)
 	RegisterService(context.Context, string, time.Duration) error
 	UnregisterService(context.Context, string) error
+	ConnectionState() string
 
 	// pod
 	AddPod(ctx context.Context, name, desc string) (*types.Pod, error)
 	GetPod(ctx context.Context, podname string) (*types.Pod, error)
+	UpdatePod(ctx context.Context, pod *types.Pod) error
 	RemovePod(ctx context.Context, podname string) error
 	GetAllPods(ctx context.Context) ([]*types.Pod, error)
 
+	// quota
+	SetQuota(ctx context.Context, quota *types.Quota) error
+	GetQuota(ctx context.Context, scope, name string) (*types.Quota, error)
+	ListQuotas(ctx context.Context) ([]*types.Quota, error)
+	RemoveQuota(ctx context.Context, scope, name string) error
+	ChargeQuota(ctx context.Context, scope, name string, delta types.QuotaUsage) error
+
 	// node
 	AddNode(context.Context, *types.AddNodeOptions) (*types.Node, error)
 	RemoveNode(ctx context.Context, node *types.Node) error
@@ -35,7 +44,8 @@ type Store interface {
 	GetNodes(ctx context.Context, nodenames []string) ([]*types.Node, error)
 	GetNodesByPod(ctx context.Context, podname string, labels map[string]string, all bool) ([]*types.Node, error)
 	UpdateNode(ctx context.Context, node *types.Node) error
-	UpdateNodeResource(ctx context.Context, node *types.Node, cpu types.CPUMap, quota float64, memory, storage int64, volume types.VolumeMap, action string) error
+	UpdateNodeEngine(ctx context.Context, node *types.Node, endpoint, ca, cert, key string) error
+	UpdateNodeResource(ctx context.Context, node *types.Node, cpu types.CPUMap, quota float64, memory, storage int64, volume types.VolumeMap, hugepages types.HugepageMap, gpu types.GPUMap, action string) error
 
 	// container
 	AddContainer(ctx context.Context, container *types.Container) error
@@ -48,6 +58,9 @@ type Store interface {
 	ListContainers(ctx context.Context, appname, entrypoint, nodename string, limit int64, labels map[string]string) ([]*types.Container, error)
 	ListNodeContainers(ctx context.Context, nodename string, labels map[string]string) ([]*types.Container, error)
 	ContainerStatusStream(ctx context.Context, appname, entrypoint, nodename string, labels map[string]string) chan *types.ContainerStatus
+	GetContainerMeta(ctx context.Context, ID, key string) ([]byte, error)
+	SetContainerMeta(ctx context.Context, ID, key string, value []byte) error
+	DeleteContainerMeta(ctx context.Context, ID, key string) error
 
 	// deploy status
 	MakeDeployStatus(ctx context.Context, opts *types.DeployOptions, nodesInfo []types.NodeInfo) ([]types.NodeInfo, error)
@@ -55,7 +68,71 @@ type Store interface {
 	// processing status
 	SaveProcessing(ctx context.Context, opts *types.DeployOptions, nodeInfo types.NodeInfo) error
 	UpdateProcessing(ctx context.Context, opts *types.DeployOptions, nodename string, count int) error
+	GetProcessing(ctx context.Context, opts *types.DeployOptions, nodename string) (int, error)
 	DeleteProcessing(ctx context.Context, opts *types.DeployOptions, nodeInfo types.NodeInfo) error
+	ListProcessing(ctx context.Context) (map[string]int, error)
+	ListProcessingRecords(ctx context.Context) ([]*types.ProcessingRecord, error)
+
+	// reservation
+	AddReservation(ctx context.Context, reservation *types.Reservation) error
+	UpdateReservation(ctx context.Context, reservation *types.Reservation) error
+	GetReservation(ctx context.Context, id string) (*types.Reservation, error)
+	ListReservations(ctx context.Context) ([]*types.Reservation, error)
+	RemoveReservation(ctx context.Context, id string) error
+
+	// capacity history
+	AddCapacitySample(ctx context.Context, podname string, sample *types.CapacitySample) error
+	ListCapacitySamples(ctx context.Context, podname string, since time.Time) ([]*types.CapacitySample, error)
+	RemoveCapacitySamples(ctx context.Context, podname string, before time.Time) error
+
+	// scale policy
+	SetScalePolicy(ctx context.Context, policy *types.ScalePolicy) error
+	GetScalePolicy(ctx context.Context, appname, entrypoint string) (*types.ScalePolicy, error)
+	ListScalePolicies(ctx context.Context) ([]*types.ScalePolicy, error)
+	RemoveScalePolicy(ctx context.Context, appname, entrypoint string) error
+
+	// cron
+	SetCron(ctx context.Context, cron *types.Cron) error
+	GetCron(ctx context.Context, name string) (*types.Cron, error)
+	ListCrons(ctx context.Context) ([]*types.Cron, error)
+	RemoveCron(ctx context.Context, name string) error
+	SetCronRun(ctx context.Context, run *types.CronRun, ttl time.Duration) error
+	WatchCronRuns(ctx context.Context, name string) (<-chan *types.CronRun, error)
+	Campaign(ctx context.Context, name string, ttl time.Duration) (<-chan struct{}, error)
+
+	// cluster event
+	PublishEvent(ctx context.Context, event *types.Event, ttl time.Duration) error
+	WatchEvents(ctx context.Context) (<-chan *types.Event, error)
+
+	// audit log
+	AddAuditLog(ctx context.Context, log *types.AuditLog, ttl time.Duration) error
+	ListAuditLogs(ctx context.Context, since, until time.Time) ([]*types.AuditLog, error)
+
+	// event webhook
+	SetEventWebhook(ctx context.Context, webhook *types.EventWebhook) error
+	ListEventWebhooks(ctx context.Context) ([]*types.EventWebhook, error)
+	RemoveEventWebhook(ctx context.Context, name string) error
+
+	// secret
+	SetSecret(ctx context.Context, secret *types.Secret) error
+	GetSecret(ctx context.Context, name string) (*types.Secret, error)
+	ListSecrets(ctx context.Context) ([]*types.Secret, error)
+	RemoveSecret(ctx context.Context, name string) error
+
+	// registry credential
+	SetRegistryCredential(ctx context.Context, credential *types.RegistryCredential) error
+	ListRegistryCredentials(ctx context.Context, registry string) ([]*types.RegistryCredential, error)
+	RemoveRegistryCredential(ctx context.Context, registry, podname string) error
+
+	// config object
+	SetConfigObject(ctx context.Context, object *types.ConfigObject) error
+	GetConfigObject(ctx context.Context, name string) (*types.ConfigObject, error)
+	ListConfigObjects(ctx context.Context) ([]*types.ConfigObject, error)
+	RemoveConfigObject(ctx context.Context, name string) error
+
+	// node heartbeat
+	SetNodeAlive(ctx context.Context, nodename string, ttl time.Duration) error
+	ListAliveNodes(ctx context.Context) ([]string, error)
 
 	// distributed lock
 	CreateLock(key string, ttl time.Duration) (lock.DistributedLock, error)
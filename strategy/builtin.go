@@ -0,0 +1,47 @@
+package strategy
+
+import (
+	"github.com/projecteru2/core/cluster"
+	"github.com/projecteru2/core/scheduler"
+	"github.com/projecteru2/core/types"
+)
+
+func init() {
+	for name, strategy := range map[string]Strategy{
+		cluster.DeployAuto:   auto,
+		cluster.DeployEach:   each,
+		cluster.DeployFill:   fill,
+		cluster.DeployGlobal: global,
+		SpreadByZone:         spreadByZone,
+	} {
+		if err := Register(name, strategy); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// auto deploys evenly, nodes with fewer containers of the app first, or packs them if pod says so
+func auto(s scheduler.Scheduler, nodesInfo []types.NodeInfo, opts *Options) ([]types.NodeInfo, error) {
+	if opts.Placement == types.PlacementPack {
+		return s.PackDivision(nodesInfo, opts.Count, opts.Total, opts.ResourceType)
+	}
+	return s.CommonDivision(nodesInfo, opts.Count, opts.Total, opts.ResourceType)
+}
+
+// each deploys Count containers on each node
+func each(s scheduler.Scheduler, nodesInfo []types.NodeInfo, opts *Options) ([]types.NodeInfo, error) {
+	return s.EachDivision(nodesInfo, opts.Count, opts.NodesLimit, opts.ResourceType)
+}
+
+// fill deploys containers on each node until it has Count
+func fill(s scheduler.Scheduler, nodesInfo []types.NodeInfo, opts *Options) ([]types.NodeInfo, error) {
+	return s.FillDivision(nodesInfo, opts.Count, opts.NodesLimit, opts.ResourceType)
+}
+
+// global deploys to keep resource usages of nodes even, or packs them if pod says so
+func global(s scheduler.Scheduler, nodesInfo []types.NodeInfo, opts *Options) ([]types.NodeInfo, error) {
+	if opts.Placement == types.PlacementPack {
+		return s.PackDivision(nodesInfo, opts.Count, opts.Total, opts.ResourceType)
+	}
+	return s.GlobalDivision(nodesInfo, opts.Count, opts.Total, opts.ResourceType)
+}
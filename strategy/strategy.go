@@ -0,0 +1,65 @@
+package strategy
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/projecteru2/core/scheduler"
+	"github.com/projecteru2/core/types"
+)
+
+// Options holds what a strategy needs besides nodes info
+type Options struct {
+	Count        int                    // containers to deploy
+	Total        int                    // containers nodes can take
+	NodesLimit   int                    // nodes limit of each and fill
+	ResourceType types.ResourceType     // resources deciding the plan
+	Placement    string                 // placement policy of pod
+	Nodes        map[string]*types.Node // nodes of nodes info, for strategies reading labels of nodes
+}
+
+// Strategy plans how many containers each node deploys by Deploy of nodes info,
+// Capacity of nodes info is how many more a node can take, Count is how many of the app it has
+type Strategy func(s scheduler.Scheduler, nodesInfo []types.NodeInfo, opts *Options) ([]types.NodeInfo, error)
+
+var (
+	mutex      sync.RWMutex
+	strategies = map[string]Strategy{}
+)
+
+// Register adds a strategy by name, it's usually called in init of the package providing it
+func Register(name string, strategy Strategy) error {
+	if name == "" || strategy == nil {
+		return types.NewDetailedErr(types.ErrBadDeployMethod, "empty name or strategy")
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if _, ok := strategies[name]; ok {
+		return types.NewDetailedErr(types.ErrBadDeployMethod, name+" registered")
+	}
+	strategies[name] = strategy
+	return nil
+}
+
+// Get returns strategy of name
+func Get(name string) (Strategy, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	strategy, ok := strategies[name]
+	if !ok {
+		return nil, types.NewDetailedErr(types.ErrBadDeployMethod, name)
+	}
+	return strategy, nil
+}
+
+// Names lists names of registered strategies
+func Names() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	names := []string{}
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
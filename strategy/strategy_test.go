@@ -0,0 +1,84 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/projecteru2/core/cluster"
+	"github.com/projecteru2/core/scheduler"
+	schedulermocks "github.com/projecteru2/core/scheduler/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	assert.Equal(t, []string{cluster.DeployAuto, cluster.DeployEach, cluster.DeployFill, cluster.DeployGlobal, SpreadByZone}, Names())
+	_, err := Get("custom")
+	assert.Error(t, err)
+
+	custom := func(_ scheduler.Scheduler, nodesInfo []types.NodeInfo, _ *Options) ([]types.NodeInfo, error) {
+		return nodesInfo, nil
+	}
+	assert.Error(t, Register("", custom))
+	assert.Error(t, Register("custom", nil))
+	assert.NoError(t, Register("custom", custom))
+	assert.Error(t, Register("custom", custom))
+	s, err := Get("custom")
+	assert.NoError(t, err)
+	nodesInfo, err := s(nil, []types.NodeInfo{{Name: "n1"}}, &Options{})
+	assert.NoError(t, err)
+	assert.Len(t, nodesInfo, 1)
+}
+
+func TestBuiltin(t *testing.T) {
+	s := &schedulermocks.Scheduler{}
+	nodesInfo := []types.NodeInfo{{Name: "n1"}}
+	opts := &Options{Count: 2, Total: 3, NodesLimit: 1, ResourceType: types.ResourceAll}
+	s.On("CommonDivision", nodesInfo, 2, 3, types.ResourceAll).Return(nodesInfo, nil).Once()
+	s.On("GlobalDivision", nodesInfo, 2, 3, types.ResourceAll).Return(nodesInfo, nil).Once()
+	s.On("EachDivision", nodesInfo, 2, 1, types.ResourceAll).Return(nodesInfo, nil).Once()
+	s.On("FillDivision", nodesInfo, 2, 1, types.ResourceAll).Return(nodesInfo, nil).Once()
+	for _, name := range []string{cluster.DeployAuto, cluster.DeployGlobal, cluster.DeployEach, cluster.DeployFill} {
+		deploy, err := Get(name)
+		assert.NoError(t, err)
+		_, err = deploy(s, nodesInfo, opts)
+		assert.NoError(t, err)
+	}
+	// pod packs containers
+	opts.Placement = types.PlacementPack
+	s.On("PackDivision", nodesInfo, 2, 3, types.ResourceAll).Return(nodesInfo, nil).Twice()
+	_, err := auto(s, nodesInfo, opts)
+	assert.NoError(t, err)
+	_, err = global(s, nodesInfo, opts)
+	assert.NoError(t, err)
+	s.AssertExpectations(t)
+}
+
+func TestSpreadByZone(t *testing.T) {
+	nodes := map[string]*types.Node{
+		"a1": {Name: "a1", Labels: map[string]string{ZoneLabel: "a"}},
+		"a2": {Name: "a2", Labels: map[string]string{ZoneLabel: "a"}},
+		"b1": {Name: "b1", Labels: map[string]string{ZoneLabel: "b"}},
+		"c1": {Name: "c1"},
+	}
+	nodesInfo := []types.NodeInfo{
+		{Name: "a1", Capacity: 10, Count: 1},
+		{Name: "a2", Capacity: 10},
+		{Name: "b1", Capacity: 1},
+		{Name: "c1", Capacity: 10, Count: 2},
+	}
+	// zone a has 1, b has 0, no zone has 2
+	nodesInfo, err := spreadByZone(nil, nodesInfo, &Options{Count: 4, Total: 31, Nodes: nodes})
+	assert.NoError(t, err)
+	deploy := map[string]int{}
+	for _, nodeInfo := range nodesInfo {
+		deploy[nodeInfo.Name] = nodeInfo.Deploy
+	}
+	// b1 is full after one, so a and no zone end up with 3 each, a2 catches up with a1 first
+	assert.Equal(t, map[string]int{"a1": 1, "a2": 1, "b1": 1, "c1": 1}, deploy)
+
+	// insufficient
+	_, err = spreadByZone(nil, []types.NodeInfo{{Name: "a1", Capacity: 1}}, &Options{Count: 2, Total: 2, Nodes: nodes})
+	assert.Error(t, err)
+	_, err = spreadByZone(nil, nil, &Options{Count: 2, Total: 1})
+	assert.Error(t, err)
+}
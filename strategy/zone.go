@@ -0,0 +1,66 @@
+package strategy
+
+import (
+	"fmt"
+
+	"github.com/projecteru2/core/scheduler"
+	"github.com/projecteru2/core/types"
+)
+
+const (
+	// SpreadByZone for spreading containers between zones of nodes
+	SpreadByZone = "spread-by-zone"
+	// ZoneLabel is label of nodes telling zones, nodes without it are in the same zone
+	ZoneLabel = "zone"
+)
+
+// spreadByZone deploys one by one to the zone with fewest containers of the app,
+// then to the node with fewest of the zone, so losing a zone loses as few as possible
+func spreadByZone(_ scheduler.Scheduler, nodesInfo []types.NodeInfo, opts *Options) ([]types.NodeInfo, error) {
+	if opts.Total < opts.Count {
+		return nil, types.NewDetailedErr(types.ErrInsufficientRes, fmt.Sprintf("need: %d, vol: %d", opts.Count, opts.Total))
+	}
+	zones := map[string]int{}
+	for _, nodeInfo := range nodesInfo {
+		zones[zoneOf(opts.Nodes, nodeInfo.Name)] += nodeInfo.Count
+	}
+	for i := 0; i < opts.Count; i++ {
+		picked := -1
+		for j := range nodesInfo {
+			if nodesInfo[j].Deploy >= nodesInfo[j].Capacity {
+				continue
+			}
+			if picked < 0 || spreadBefore(opts.Nodes, zones, nodesInfo[j], nodesInfo[picked]) {
+				picked = j
+			}
+		}
+		if picked < 0 {
+			return nil, types.NewDetailedErr(types.ErrInsufficientRes, fmt.Sprintf("need: %d, deployed: %d", opts.Count, i))
+		}
+		nodesInfo[picked].Deploy++
+		zones[zoneOf(opts.Nodes, nodesInfo[picked].Name)]++
+	}
+	return nodesInfo, nil
+}
+
+// spreadBefore tells if a should take the next container before b
+func spreadBefore(nodes map[string]*types.Node, zones map[string]int, a, b types.NodeInfo) bool {
+	zoneA, zoneB := zoneOf(nodes, a.Name), zoneOf(nodes, b.Name)
+	if zones[zoneA] != zones[zoneB] {
+		return zones[zoneA] < zones[zoneB]
+	}
+	if zoneA != zoneB {
+		return zoneA < zoneB
+	}
+	if a.Count+a.Deploy != b.Count+b.Deploy {
+		return a.Count+a.Deploy < b.Count+b.Deploy
+	}
+	return a.Name < b.Name
+}
+
+func zoneOf(nodes map[string]*types.Node, nodename string) string {
+	if node, ok := nodes[nodename]; ok && node != nil {
+		return node.Labels[ZoneLabel]
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package types
+
+import "time"
+
+const (
+	// AlertNodeAllocation fires when allocated resource of node exceeds threshold
+	AlertNodeAllocation = "node_allocation"
+	// AlertDeployFailureRate fires when deploy failure rate of app exceeds threshold
+	AlertDeployFailureRate = "deploy_failure_rate"
+	// AlertProcessingStale fires when processing record lives longer than threshold
+	AlertProcessingStale = "processing_stale"
+	// AlertContainerOOM is sent when container is killed by oom killer, it's never resolved
+	AlertContainerOOM = "container_oom"
+	// AlertContainerExit is sent when container exits without being stopped, it's never resolved
+	AlertContainerExit = "container_exit"
+)
+
+// Alert is sent to webhooks when a threshold is crossed, and again with Resolved when it's back
+// Target is nodename for node allocation, appname for deploy failure rate, processing key for processing stale
+// and container ID for container oom and exit
+type Alert struct {
+	Kind      string    `json:"kind"`
+	Target    string    `json:"target"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Message   string    `json:"message"`
+	Resolved  bool      `json:"resolved"`
+	Time      time.Time `json:"time"`
+}
+
+// ProcessingRecord is a deploy in progress on node
+type ProcessingRecord struct {
+	Appname    string
+	Entrypoint string
+	Nodename   string
+	Ident      string
+	Count      int
+}
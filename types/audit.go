@@ -0,0 +1,31 @@
+package types
+
+import "time"
+
+// AuditLog is a mutating call to core, it's never changed once recorded
+type AuditLog struct {
+	ID       string    `json:"id"`
+	Method   string    `json:"method"`
+	User     string    `json:"user,omitempty"` // authenticated username, empty if auth is disabled
+	Peer     string    `json:"peer,omitempty"` // address of caller
+	Appname  string    `json:"appname,omitempty"`
+	Options  string    `json:"options,omitempty"` // request in json, secrets are redacted
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Error    string    `json:"error,omitempty"`
+	Source   string    `json:"source"` // address of the core serving it
+}
+
+// AuditFilter selects audit logs, empty fields match all
+type AuditFilter struct {
+	User    string
+	Appname string
+	Since   time.Time
+	Until   time.Time
+	Limit   int // latest ones are kept if limited
+}
+
+// Match tells whether audit log is selected, ignoring time range
+func (f *AuditFilter) Match(log *AuditLog) bool {
+	return (f.User == "" || f.User == log.User) && (f.Appname == "" || f.Appname == log.Appname)
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"math"
+	"time"
+)
+
+// ScalePolicy keeps replicas of an app entrypoint in a pod between Min and Max,
+// replicas are changed to bring average cpu and memory usage to thresholds
+type ScalePolicy struct {
+	Appname    string `json:"appname"`
+	Entrypoint string `json:"entrypoint"`
+	Podname    string `json:"podname"` // containers are counted and deployed in this pod only
+	Min        int    `json:"min"`
+	Max        int    `json:"max"`
+	// usage ratio of cpu quota and memory limit to keep, 0 ignores the metric
+	CPUThreshold    float64       `json:"cpu_threshold"`
+	MemoryThreshold float64       `json:"memory_threshold"`
+	Cooldown        time.Duration `json:"cooldown"` // no scaling within cooldown after the last one
+	LastScaled      time.Time     `json:"last_scaled,omitempty"`
+}
+
+// Validate checks policy
+func (p *ScalePolicy) Validate() error {
+	switch {
+	case p.Appname == "" || p.Entrypoint == "" || p.Podname == "":
+		return NewDetailedErr(ErrBadScalePolicy, "appname, entrypoint and podname are required")
+	case p.Min < 0 || p.Max < p.Min:
+		return NewDetailedErr(ErrBadScalePolicy, "min must not be negative or more than max")
+	case p.CPUThreshold < 0 || p.MemoryThreshold < 0:
+		return NewDetailedErr(ErrBadScalePolicy, "negative threshold")
+	case p.Cooldown < 0:
+		return NewDetailedErr(ErrBadScalePolicy, "negative cooldown")
+	}
+	return nil
+}
+
+// CoolingDown checks if now is within cooldown after the last scaling
+func (p *ScalePolicy) CoolingDown(now time.Time) bool {
+	return !p.LastScaled.IsZero() && now.Sub(p.LastScaled) < p.Cooldown
+}
+
+// DesiredReplicas tells how many replicas there should be by average usage ratios of current ones,
+// a negative usage means it's unknown. replicas are kept if usages are within tolerance of thresholds
+func (p *ScalePolicy) DesiredReplicas(current int, cpuUsage, memoryUsage, tolerance float64) int {
+	desired := current
+	ratio := 0.0
+	if p.CPUThreshold > 0 && cpuUsage >= 0 {
+		ratio = math.Max(ratio, cpuUsage/p.CPUThreshold)
+	}
+	if p.MemoryThreshold > 0 && memoryUsage >= 0 {
+		ratio = math.Max(ratio, memoryUsage/p.MemoryThreshold)
+	}
+	if ratio > 0 && math.Abs(ratio-1) > tolerance {
+		desired = int(math.Ceil(float64(current) * ratio))
+	}
+	if desired < p.Min {
+		return p.Min
+	}
+	if desired > p.Max {
+		return p.Max
+	}
+	return desired
+}
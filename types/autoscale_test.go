@@ -0,0 +1,56 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScalePolicyValidate(t *testing.T) {
+	p := &ScalePolicy{Appname: "app", Entrypoint: "web", Podname: "pod", Min: 1, Max: 3, CPUThreshold: 0.5}
+	assert.NoError(t, p.Validate())
+	p.Podname = ""
+	assert.Error(t, p.Validate())
+	p.Podname = "pod"
+	p.Max = 0
+	assert.Error(t, p.Validate())
+	p.Max = 3
+	p.MemoryThreshold = -1
+	assert.Error(t, p.Validate())
+	p.MemoryThreshold = 0
+	p.Cooldown = -time.Second
+	assert.Error(t, p.Validate())
+}
+
+func TestScalePolicyCoolingDown(t *testing.T) {
+	now := time.Now()
+	p := &ScalePolicy{Cooldown: time.Minute}
+	assert.False(t, p.CoolingDown(now))
+	p.LastScaled = now.Add(-time.Second)
+	assert.True(t, p.CoolingDown(now))
+	p.LastScaled = now.Add(-time.Hour)
+	assert.False(t, p.CoolingDown(now))
+}
+
+func TestDesiredReplicas(t *testing.T) {
+	p := &ScalePolicy{Min: 1, Max: 10, CPUThreshold: 0.5, MemoryThreshold: 0.8}
+	// within tolerance
+	assert.Equal(t, 4, p.DesiredReplicas(4, 0.52, 0.1, 0.1))
+	// cpu
+	assert.Equal(t, 8, p.DesiredReplicas(4, 1, 0.1, 0.1))
+	assert.Equal(t, 2, p.DesiredReplicas(4, 0.2, 0.1, 0.1))
+	// memory is higher
+	assert.Equal(t, 5, p.DesiredReplicas(4, 0.2, 0.9, 0.1))
+	// bounded
+	assert.Equal(t, 10, p.DesiredReplicas(4, 5, 0.1, 0.1))
+	assert.Equal(t, 1, p.DesiredReplicas(4, 0.01, 0.01, 0.1))
+	// unknown usage only keeps bounds
+	assert.Equal(t, 4, p.DesiredReplicas(4, -1, -1, 0.1))
+	assert.Equal(t, 1, p.DesiredReplicas(0, -1, -1, 0.1))
+	p.Max = 3
+	assert.Equal(t, 3, p.DesiredReplicas(4, -1, -1, 0.1))
+	// metric ignored
+	p.CPUThreshold = 0
+	assert.Equal(t, 3, p.DesiredReplicas(3, 5, 0.8, 0.1))
+}
@@ -0,0 +1,42 @@
+package types
+
+import "strings"
+
+// CheckpointOptions for checkpointing a running container by CRIU
+// container keeps running unless Exit is set
+type CheckpointOptions struct {
+	ID   string
+	Name string
+	Exit bool
+}
+
+// Validate checks checkpoint options
+func (o *CheckpointOptions) Validate() error {
+	if o.ID == "" {
+		return ErrNoContainerIDs
+	}
+	return validateCheckpointName(o.Name)
+}
+
+// RestoreOptions for restoring a stopped container from checkpoint
+// checkpoint of container From is restored if it's set, e.g. to move a container to a clone on another node
+type RestoreOptions struct {
+	ID   string
+	Name string
+	From string
+}
+
+// Validate checks restore options
+func (o *RestoreOptions) Validate() error {
+	if o.ID == "" {
+		return ErrNoContainerIDs
+	}
+	return validateCheckpointName(o.Name)
+}
+
+func validateCheckpointName(name string) error {
+	if name == "" || strings.ContainsAny(name, "/\\") || name == "." || name == ".." {
+		return NewDetailedErr(ErrBadCheckpoint, "bad name "+name)
+	}
+	return nil
+}
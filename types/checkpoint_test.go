@@ -0,0 +1,19 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointOptions(t *testing.T) {
+	assert.Error(t, (&CheckpointOptions{Name: "c1"}).Validate())
+	assert.Error(t, (&CheckpointOptions{ID: "id"}).Validate())
+	assert.Error(t, (&CheckpointOptions{ID: "id", Name: "../c1"}).Validate())
+	assert.Error(t, (&CheckpointOptions{ID: "id", Name: ".."}).Validate())
+	assert.NoError(t, (&CheckpointOptions{ID: "id", Name: "c1"}).Validate())
+
+	assert.Error(t, (&RestoreOptions{Name: "c1"}).Validate())
+	assert.Error(t, (&RestoreOptions{ID: "id", Name: "a/b"}).Validate())
+	assert.NoError(t, (&RestoreOptions{ID: "id", Name: "c1", From: "other"}).Validate())
+}
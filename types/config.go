@@ -6,22 +6,49 @@ import (
 
 // Config holds eru-core config
 type Config struct {
-	LogLevel      string        `yaml:"log_level" required:"true" default:"INFO"`
-	Bind          string        `yaml:"bind" required:"true" default:"5001"`           // HTTP API address
-	LockTimeout   time.Duration `yaml:"lock_timeout" required:"true" default:"30s"`    // timeout for lock (ttl)
-	GlobalTimeout time.Duration `yaml:"global_timeout" required:"true" default:"300s"` // timeout for remove, run_and_wait and build, in second
-	Statsd        string        `yaml:"statsd"`                                        // statsd host and port
-	Profile       string        `yaml:"profile"`                                       // profile ip:port
-	CertPath      string        `yaml:"cert_path"`                                     // docker cert files path
-	Auth          AuthConfig    `yaml:"auth"`                                          // grpc auth
-	GRPCConfig    GRPCConfig    `yaml:"grpc"`                                          // grpc config
-
-	Git       GitConfig     `yaml:"git"`
-	Etcd      EtcdConfig    `yaml:"etcd"`
-	Docker    DockerConfig  `yaml:"docker"`
-	Scheduler SchedConfig   `yaml:"scheduler"`
-	Virt      VirtConfig    `yaml:"virt"`
-	Systemd   SystemdConfig `yaml:"systemd"`
+	LogLevel       string         `yaml:"log_level" required:"true" default:"INFO"`
+	Bind           string         `yaml:"bind" required:"true" default:"5001"`           // HTTP API address
+	LockTimeout    time.Duration  `yaml:"lock_timeout" required:"true" default:"30s"`    // timeout for lock (ttl)
+	GlobalTimeout  time.Duration  `yaml:"global_timeout" required:"true" default:"300s"` // timeout for remove, run_and_wait and build, in second
+	MaxConcurrency int            `yaml:"max_concurrency" required:"true" default:"20"`  // max containers removed or controlled at the same time by a request
+	Statsd         string         `yaml:"statsd"`                                        // statsd host and port
+	Profile        string         `yaml:"profile"`                                       // profile ip:port
+	ProfileAuth    HTTPAuthConfig `yaml:"profile_auth"`                                  // auth of profile and metrics listener
+	CertPath       string         `yaml:"cert_path"`                                     // docker cert files path
+	Auth           AuthConfig     `yaml:"auth"`                                          // grpc auth
+	GRPCConfig     GRPCConfig     `yaml:"grpc"`                                          // grpc config
+	DispatchExpire time.Duration  `yaml:"dispatch_expire"`                               // resources held by a deploy not renewed for it are released, 0 disables it
+	WALFile        string         `yaml:"wal_file" default:"core.wal"`                   // write-ahead log of containers being created, replayed on start, empty disables it
+	WALOpenTimeout time.Duration  `yaml:"wal_open_timeout" default:"8s"`                 // wait for another core holding wal file
+
+	Git           GitConfig           `yaml:"git"`
+	ObjectStorage ObjectStorageConfig `yaml:"object_storage"`
+	Etcd          EtcdConfig          `yaml:"etcd"`
+	Docker        DockerConfig        `yaml:"docker"`
+	Scheduler     SchedConfig         `yaml:"scheduler"`
+	Virt          VirtConfig          `yaml:"virt"`
+	Podman        PodmanConfig        `yaml:"podman"`
+	Containerd    ContainerdConfig    `yaml:"containerd"`
+	Systemd       SystemdConfig       `yaml:"systemd"`
+	Metrics       MetricsConfig       `yaml:"metrics"`
+	Alert         AlertConfig         `yaml:"alert"`
+	Orphan        OrphanConfig        `yaml:"orphan"`
+	Retention     RetentionConfig     `yaml:"retention"`
+	Autoscale     AutoscaleConfig     `yaml:"autoscale"`
+	Cron          CronConfig          `yaml:"cron"`
+	Events        EventsConfig        `yaml:"events"`
+	Audit         AuditConfig         `yaml:"audit"`
+	Notify        NotifyConfig        `yaml:"notify"`
+	Secrets       SecretsConfig       `yaml:"secrets"`
+	HealthCheck   HealthCheckConfig   `yaml:"health_check"`
+	Supervisor    SupervisorConfig    `yaml:"supervisor"`
+	Liveness      LivenessConfig      `yaml:"liveness"`
+	Checkpoint    CheckpointConfig    `yaml:"checkpoint"`
+	Webhook       WebhookConfig       `yaml:"webhook"`
+	ImagePull     ImagePullConfig     `yaml:"image_pull"`
+	Stream        StreamConfig        `yaml:"stream"`
+	EngineCache   EngineCacheConfig   `yaml:"engine_cache"`
+	Mutators      []MutatorConfig     `yaml:"mutators"` // mutators of deploy options, applied in order before scheduling
 }
 
 // EtcdConfig holds eru-core etcd config
@@ -33,16 +60,46 @@ type EtcdConfig struct {
 	Key        string     `yaml:"key"`                                                // etcd key
 	Cert       string     `yaml:"cert"`                                               // etcd trusted_ca
 	Auth       AuthConfig `yaml:"auth"`                                               // etcd auth
+
+	PageSize        int64         `yaml:"page_size" default:"1000"`  // keys of a page when listing a prefix, 0 gets all of them at once
+	MaxTxnOps       int           `yaml:"max_txn_ops" default:"128"` // ops of a read txn, keep it no more than max-txn-ops of etcd, 0 means no limit
+	CompactInterval time.Duration `yaml:"compact_interval"`          // compact revisions older than it, 0 disables it
 }
 
 // GitConfig holds eru-core git config
 type GitConfig struct {
-	SCMType      string        `yaml:"scm_type"`                                     // source code manager type [gitlab/github]
+	SCMType      string        `yaml:"scm_type"`                                     // source code manager type [gitlab/github/gitea/bitbucket]
 	PrivateKey   string        `yaml:"private_key"`                                  // private key to clone code
 	Token        string        `yaml:"token"`                                        // token to call SCM API
+	Username     string        `yaml:"username"`                                     // username paired with token when cloning over https
+	Archive      bool          `yaml:"archive"`                                      // fetch https source code by archive instead of cloning
+	CacheDir     string        `yaml:"cache_dir"`                                    // keep cloned repositories here and fetch incrementally, empty disables it
+	KeyRing      string        `yaml:"keyring"`                                      // path of armored public keys trusted to verify source signatures
 	CloneTimeout time.Duration `yaml:"clone_timeout" required:"true" default:"300s"` // clone timeout
 }
 
+// ObjectStorageConfig holds object storage credentials for build artifacts
+type ObjectStorageConfig struct {
+	S3  S3Config  `yaml:"s3"`
+	OSS OSSConfig `yaml:"oss"`
+}
+
+// S3Config holds aws s3 or s3 compatible storage (e.g. minio) config
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint"`                   // empty for aws s3
+	Region    string `yaml:"region" default:"us-east-1"` // signing region
+	AccessKey string `yaml:"access_key"`                 // empty for anonymous access
+	SecretKey string `yaml:"secret_key"`
+	PathStyle bool   `yaml:"path_style"` // request endpoint/bucket/key instead of bucket.endpoint/key, minio needs it
+}
+
+// OSSConfig holds aliyun oss config
+type OSSConfig struct {
+	Endpoint  string `yaml:"endpoint"`   // e.g. https://oss-cn-hangzhou.aliyuncs.com
+	AccessKey string `yaml:"access_key"` // empty for anonymous access
+	SecretKey string `yaml:"secret_key"`
+}
+
 // DockerConfig holds eru-core docker config
 type DockerConfig struct {
 	APIVersion  string                `yaml:"version" required:"true" default:"1.32"`      // docker API version
@@ -53,6 +110,7 @@ type DockerConfig struct {
 	UseLocalDNS bool                  `yaml:"local_dns"`                                   // use node IP as dns
 	Log         LogConfig             `yaml:"log"`                                         // docker log driver
 	AuthConfigs map[string]AuthConfig `yaml:"auths"`                                       // docker registry credentials
+	GPURuntime  string                `yaml:"gpu_runtime" default:"nvidia"`                // runtime of containers with gpu, devices are given in NVIDIA_VISIBLE_DEVICES
 }
 
 // VirtConfig holds yavirtd config
@@ -60,9 +118,208 @@ type VirtConfig struct {
 	APIVersion string `yaml:"version"` // Yavirtd API version
 }
 
+// PodmanConfig holds podman engine config, registry credentials are shared with docker
+type PodmanConfig struct {
+	APIVersion  string `yaml:"version" default:"v4.0.0"`      // libpod API version
+	NetworkMode string `yaml:"network_mode" default:"bridge"` // network mode of containers without networks given
+}
+
+// ContainerdConfig holds containerd engine config, registry credentials are shared with docker
+type ContainerdConfig struct {
+	Namespace   string `yaml:"namespace" default:"eru"`                   // containerd namespace of containers and images
+	Snapshotter string `yaml:"snapshotter" default:"overlayfs"`           // snapshotter of container rootfs
+	Runtime     string `yaml:"runtime" default:"io.containerd.runc.v2"`   // runtime of tasks
+	NetworkMode string `yaml:"network_mode" default:"host"`               // host or none, containerd has no networks
+	LogDir      string `yaml:"log_dir" default:"/var/log/eru/containerd"` // stdout and stderr of containers are written here
+}
+
+// MetricsConfig holds metrics backends config
+type MetricsConfig struct {
+	Backends     []string           `yaml:"backends"`                                    // statsd, prometheus, pushgateway or datadog, statsd (if statsd set) and prometheus if empty
+	Pushgateway  string             `yaml:"pushgateway"`                                 // prometheus pushgateway url
+	PushInterval time.Duration      `yaml:"push_interval" required:"true" default:"15s"` // push interval of pushgateway
+	Datadog      string             `yaml:"datadog"`                                     // datadog agent dogstatsd host and port
+	StatsdTags   bool               `yaml:"statsd_tags"`                                 // send labels to statsd as tags in dogstatsd format, instead of in bucket names
+	SampleRates  map[string]float32 `yaml:"sample_rates"`                                // sample rates of counters and timings by metric name, 1 if not set
+	NodeInterval time.Duration      `yaml:"node_interval" required:"true" default:"1m"`  // interval of exporting node resource gauges
+}
+
+// AlertConfig holds alert thresholds, zero threshold disables its check
+type AlertConfig struct {
+	Webhooks          []string      `yaml:"webhooks"`                              // alerts are posted to webhooks in json, no check runs if empty
+	Interval          time.Duration `yaml:"interval" required:"true" default:"1m"` // interval of checking thresholds
+	NodeAllocation    float64       `yaml:"node_allocation"`                       // ratio of allocated cpu, memory or storage of node, e.g. 0.9
+	DeployFailureRate float64       `yaml:"deploy_failure_rate"`                   // ratio of failed containers of app deployed in an interval
+	ProcessingAge     time.Duration `yaml:"processing_age"`                        // age of processing records
+	ContainerEvents   bool          `yaml:"container_events"`                      // send oom kills and unexpected exits of containers reported by agents
+}
+
+// MutatorConfig changes deploy options of every deploy before scheduling, for conventions of the platform
+// static changes are applied before changes returned by webhook, neither overrides what the deploy sets
+type MutatorConfig struct {
+	Name     string            `yaml:"name"`     // shown in logs and errors
+	Pods     []string          `yaml:"pods"`     // pods it applies to, all pods if empty
+	Env      []string          `yaml:"env"`      // KEY=VALUE appended unless the deploy sets KEY
+	Labels   map[string]string `yaml:"labels"`   // labels set unless the deploy sets them
+	DNS      []string          `yaml:"dns"`      // used if the deploy sets no dns
+	Volumes  []string          `yaml:"volumes"`  // mounted unless the deploy mounts the same destination
+	Webhook  string            `yaml:"webhook"`  // external mutator, DeployMutationRequest is posted to it in json and DeployMutation is returned
+	Timeout  time.Duration     `yaml:"timeout"`  // timeout of webhook, 5s if not set
+	Optional bool              `yaml:"optional"` // deploy goes on without changes of webhook if it fails
+}
+
+// OrphanConfig holds sweeping of containers labeled by eru on nodes but unknown to store
+type OrphanConfig struct {
+	Interval time.Duration `yaml:"interval"`                          // interval of sweeping, 0 disables it
+	Age      time.Duration `yaml:"age" required:"true" default:"10m"` // containers younger than age are skipped, they may be in creating
+	Remove   bool          `yaml:"remove"`                            // remove orphans, only report them if false
+}
+
+// RetentionConfig holds cleaning of exited, failed and replaced containers
+type RetentionConfig struct {
+	Interval time.Duration   `yaml:"interval"` // interval of cleaning, 0 disables it
+	DryRun   bool            `yaml:"dry_run"`  // only report containers to remove if true
+	Rules    []RetentionRule `yaml:"rules"`    // the first rule matching pod and app of a container applies, containers matching none are kept
+}
+
+// RetentionRule keeps the latest containers of an app for debugging and removes the rest after grace
+type RetentionRule struct {
+	Pod    string        `yaml:"pod"`    // all pods if empty
+	App    string        `yaml:"app"`    // all apps if empty
+	States []string      `yaml:"states"` // exited, failed or replaced, all of them if empty
+	Keep   int           `yaml:"keep"`   // latest containers of each app kept
+	Grace  time.Duration `yaml:"grace"`  // containers are removed after being found in states longer than grace
+}
+
+// AutoscaleConfig holds the controller of scale policies
+type AutoscaleConfig struct {
+	Interval  time.Duration `yaml:"interval"`                                // interval of checking policies, 0 disables it
+	Tolerance float64       `yaml:"tolerance" required:"true" default:"0.1"` // replicas are kept if usage ratio to threshold is within 1±tolerance
+}
+
+// CronConfig holds the trigger of crons, only the leader core fires them
+type CronConfig struct {
+	Interval  time.Duration `yaml:"interval" default:"10s"`   // interval of checking crons, 0 disables it
+	LeaderTTL time.Duration `yaml:"leader_ttl" default:"10s"` // leadership is lost if core is unreachable for it
+	RunTTL    time.Duration `yaml:"run_ttl" default:"24h"`    // executions are kept for it
+}
+
+// EventsConfig holds cluster events published to store
+type EventsConfig struct {
+	TTL time.Duration `yaml:"ttl" default:"1h"` // events are kept for it, 0 disables publishing
+}
+
+// AuditConfig holds audit logs of mutating calls
+type AuditConfig struct {
+	TTL            time.Duration `yaml:"ttl" default:"720h"`              // audit logs are kept for it, 0 disables auditing
+	MaxOptionsSize int           `yaml:"max_options_size" default:"4096"` // recorded options are truncated to it
+}
+
+// NotifyConfig holds posting lifecycle events of containers to registered webhooks
+type NotifyConfig struct {
+	Timeout time.Duration `yaml:"timeout" default:"5s"` // of each post, 0 disables notifying
+	Retries int           `yaml:"retries" default:"3"`  // failed posts are retried
+	Backoff time.Duration `yaml:"backoff" default:"1s"` // waited before the first retry, doubled after each retry
+}
+
+// SecretsConfig holds encryption of secrets saved in store
+type SecretsConfig struct {
+	MasterKey string `yaml:"master_key"` // base64 of 32 bytes aes key, empty disables secrets and registry credentials
+}
+
+// HealthCheckConfig holds tcp and http health checks of containers run by core instead of agents,
+// only the leader core runs them, health reported by agents is ignored for these containers
+type HealthCheckConfig struct {
+	Interval         time.Duration `yaml:"interval"`                       // due checks are run every interval, 0 disables it
+	LeaderTTL        time.Duration `yaml:"leader_ttl" default:"10s"`       // leadership is lost if core is unreachable for it
+	Concurrency      int           `yaml:"concurrency" default:"20"`       // checks at the same time
+	DefaultInterval  time.Duration `yaml:"default_interval" default:"10s"` // of health checks without interval
+	DefaultTimeout   time.Duration `yaml:"default_timeout" default:"3s"`   // of health checks without timeout
+	StatusTTL        time.Duration `yaml:"status_ttl" default:"5m"`        // status updated by core expires after it unless reported again
+	RestartThreshold int           `yaml:"restart_threshold"`              // restart containers failing so many checks in a row unless their restart policy is no, 0 never
+}
+
+// SupervisorConfig holds restarting and redeploying containers by their restart policies,
+// only the leader core supervises them, as engines don't restart containers after e.g. node reboots
+type SupervisorConfig struct {
+	Interval    time.Duration `yaml:"interval"`                  // containers are checked every interval, 0 disables it
+	LeaderTTL   time.Duration `yaml:"leader_ttl" default:"10s"`  // leadership is lost if core is unreachable for it
+	Backoff     time.Duration `yaml:"backoff" default:"10s"`     // waited before restarting again of policies without backoff, doubled after each restart
+	MaxBackoff  time.Duration `yaml:"max_backoff" default:"5m"`  // backoff stops doubling at it, and restarts are reset after running for it
+	LostTimeout time.Duration `yaml:"lost_timeout" default:"5m"` // containers missing in engine or on unavailable nodes for it are deployed again
+}
+
+// LivenessConfig holds heartbeats of nodes, nodes are alive if their agents keep heartbeats or engines reply to pings,
+// the leader core declares nodes dead after dead timeout and fails their containers over to other nodes of the pod
+type LivenessConfig struct {
+	Interval     time.Duration `yaml:"interval"`                    // liveness of nodes is checked every interval, 0 disables it
+	LeaderTTL    time.Duration `yaml:"leader_ttl" default:"10s"`    // leadership is lost if core is unreachable for it
+	HeartbeatTTL time.Duration `yaml:"heartbeat_ttl" default:"30s"` // of heartbeats without ttl
+	PingTimeout  time.Duration `yaml:"ping_timeout" default:"5s"`   // of pinging engines of nodes without heartbeats
+	DeadTimeout  time.Duration `yaml:"dead_timeout" default:"1m"`   // nodes neither keeping heartbeats nor replying to pings for it are dead
+}
+
+// CheckpointConfig holds checkpoints of containers by CRIU
+type CheckpointConfig struct {
+	Dir string `yaml:"dir"` // on storage shared by nodes, checkpoints of a container are kept in its sub dir, empty disables checkpoints
+}
+
+// ImagePullConfig limits concurrent image pulls, identical pulls on a node share one
+type ImagePullConfig struct {
+	MaxConcurrency  int `yaml:"max_concurrency" required:"true" default:"20"` // pulls at the same time of core
+	NodeConcurrency int `yaml:"node_concurrency" required:"true" default:"2"` // pulls at the same time on a node
+}
+
+const (
+	// SlowConsumerBlock waits until client reads messages
+	SlowConsumerBlock = "block"
+	// SlowConsumerDrop drops messages and sends a summary of dropped messages at last
+	SlowConsumerDrop = "drop"
+	// SlowConsumerDisconnect ends the stream, following messages are dropped
+	SlowConsumerDisconnect = "disconnect"
+)
+
+// StreamConfig holds buffering of result channels and what to do with clients reading slowly
+type StreamConfig struct {
+	Buffer       int           `yaml:"buffer"`                                        // buffered messages of a result channel
+	SlowConsumer string        `yaml:"slow_consumer" required:"true" default:"block"` // block, drop or disconnect when channel is full for timeout
+	Timeout      time.Duration `yaml:"timeout" required:"true" default:"10s"`         // wait of a full channel before applying slow consumer policy
+}
+
+// EngineCacheConfig holds reuse of engine clients of nodes
+type EngineCacheConfig struct {
+	IdleTimeout   time.Duration `yaml:"idle_timeout" required:"true" default:"30m"` // clients unused for it are closed
+	CheckInterval time.Duration `yaml:"check_interval"`                             // interval of health checks of clients, 0 disables it
+	CheckTimeout  time.Duration `yaml:"check_timeout" required:"true" default:"5s"` // timeout of a health check
+}
+
+// WebhookConfig holds inbound webhook which builds and rolls out apps on push events
+type WebhookConfig struct {
+	Bind string                `yaml:"bind"` // listen address, empty disables webhook
+	Apps map[string]WebhookApp `yaml:"apps"` // app is triggered by POST /webhook/:appname
+}
+
+// WebhookApp is build and rollout of an app triggered by push
+type WebhookApp struct {
+	Secret  string          `yaml:"secret"`  // github hmac secret or gitlab token
+	Branch  string          `yaml:"branch"`  // only pushes to this branch trigger, empty for all branches
+	Image   string          `yaml:"image"`   // name of image, tagged by pushed commit
+	Builds  Builds          `yaml:"builds"`  // stages with repo are built at pushed commit
+	Replace *WebhookReplace `yaml:"replace"` // roll out the new image if set
+}
+
+// WebhookReplace replaces containers of entrypoint with the new image
+type WebhookReplace struct {
+	Podname        string     `yaml:"podname"`
+	Entrypoint     Entrypoint `yaml:"entrypoint"`
+	Env            []string   `yaml:"env"`
+	NetworkInherit bool       `yaml:"network_inherit"`
+}
+
 // SystemdConfig is systemd config
 type SystemdConfig struct {
 	Username string `yaml:"username" default:"root"`
+	Slice    string `yaml:"slice" default:"eru.slice"` // slice of services, resource limits of services are under it
 }
 
 // LogConfig define log type
@@ -75,6 +332,9 @@ type LogConfig struct {
 type SchedConfig struct {
 	MaxShare  int `yaml:"maxshare" required:"true" default:"-1"`   // comlpex scheduler use maxshare
 	ShareBase int `yaml:"sharebase" required:"true" default:"100"` // how many pieces for one core
+	// counts candidates of cpu plans instead of building all of them, for nodes with many cores
+	// plans are the same as the default algorithm
+	Performance bool `yaml:"performance"`
 }
 
 // AuthConfig contains authorization information for connecting to a Registry
@@ -86,10 +346,23 @@ type AuthConfig struct {
 	Password string `yaml:"password,omitempty" json:"password,omitempty"`
 }
 
+// HTTPAuthConfig guards a http listener, all checks are optional
+// https is served if Cert and Key set, and clients must present certs signed by CA if CA set
+type HTTPAuthConfig struct {
+	Auth     AuthConfig `yaml:"auth"`      // basic auth
+	AllowIPs []string   `yaml:"allow_ips"` // ips or cidrs allowed
+	CA       string     `yaml:"ca"`        // ca of client certs
+	Cert     string     `yaml:"cert"`      // server cert
+	Key      string     `yaml:"key"`       // server key
+}
+
 // GRPCConfig indicate grpc config
 type GRPCConfig struct {
 	MaxConcurrentStreams         int           `yaml:"max_concurrent_streams,omitempty" json:"max_concurrent_streams,omitempty" required:"true" default:"100"`
 	MaxRecvMsgSize               int           `yaml:"max_recv_msg_size,omitempty" json:"max_recv_msg_size,omitempty" required:"true" default:"20971520"`
 	ServiceDiscoveryPushInterval time.Duration `yaml:"service_discovery_interval" required:"true" default:"15s"`
 	ServiceHeartbeatInterval     time.Duration `yaml:"service_heartbeat_interval" required:"true" default:"15s"`
+	CopyChunkSize                int           `yaml:"copy_chunk_size" default:"32768"`   // bytes of data in a message of Copy
+	MaxCopySize                  int64         `yaml:"max_copy_size"`                     // bytes, larger files are refused by Copy, 0 means no limit
+	MaxConcurrentCopies          int           `yaml:"max_concurrent_copies" default:"4"` // files being transferred at once, others wait, 0 means no limit
 }
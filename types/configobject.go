@@ -0,0 +1,84 @@
+package types
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// ConfigObject is a named file template shared by containers, rendered for each of them by ConfigData
+type ConfigObject struct {
+	Name    string `json:"name"`
+	Content string `json:"content"` // go template
+}
+
+// ConfigRef mounts a config object into containers
+type ConfigRef struct {
+	Name string `json:"name"` // name of config object
+	Path string `json:"path"` // absolute path in container
+}
+
+// ConfigData is what config objects are rendered with, e.g. {{.Nodename}} or {{.Env.APP_PORT}}
+type ConfigData struct {
+	Appname     string
+	Podname     string
+	Nodename    string
+	ContainerNo int
+	Env         map[string]string // env of deploy, secrets not included
+}
+
+// NewConfigData makes data of a container to render config objects
+func NewConfigData(appname, podname, nodename string, no int, env []string) *ConfigData {
+	data := &ConfigData{Appname: appname, Podname: podname, Nodename: nodename, ContainerNo: no, Env: map[string]string{}}
+	for _, e := range env {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) == 2 {
+			data.Env[kv[0]] = kv[1]
+		}
+	}
+	return data
+}
+
+// Validate checks config object, content must be a valid template
+func (o *ConfigObject) Validate() error {
+	if o.Name == "" || strings.Contains(o.Name, "/") {
+		return NewDetailedErr(ErrBadConfigObject, "bad name "+o.Name)
+	}
+	_, err := o.template()
+	return err
+}
+
+// Render renders content by data, missing keys fail it
+func (o *ConfigObject) Render(data *ConfigData) ([]byte, error) {
+	tmpl, err := o.template()
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return nil, NewDetailedErr(ErrBadConfigObject, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (o *ConfigObject) template() (*template.Template, error) {
+	tmpl, err := template.New(o.Name).Option("missingkey=error").Parse(o.Content)
+	if err != nil {
+		return nil, NewDetailedErr(ErrBadConfigObject, err)
+	}
+	return tmpl, nil
+}
+
+// ValidateConfigRefs checks config objects referred by deploy
+func ValidateConfigRefs(refs []*ConfigRef) error {
+	for _, ref := range refs {
+		if ref.Name == "" {
+			return NewDetailedErr(ErrBadConfigObject, "name of config object is required")
+		}
+		if !filepath.IsAbs(ref.Path) {
+			return NewDetailedErr(ErrBadConfigObject, "path of config object must be absolute "+ref.Path)
+		}
+	}
+	return nil
+}
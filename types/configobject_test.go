@@ -0,0 +1,27 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigObjectRender(t *testing.T) {
+	assert.True(t, errors.Is((&ConfigObject{Content: "x"}).Validate(), ErrBadConfigObject))
+	assert.True(t, errors.Is((&ConfigObject{Name: "nginx", Content: "{{.Nodename"}).Validate(), ErrBadConfigObject))
+
+	o := &ConfigObject{Name: "nginx", Content: "server {{.Nodename}}-{{.ContainerNo}}:{{.Env.PORT}}"}
+	assert.NoError(t, o.Validate())
+	b, err := o.Render(NewConfigData("app", "pod", "node1", 2, []string{"PORT=80", "BAD"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "server node1-2:80", string(b))
+	_, err = o.Render(NewConfigData("app", "pod", "node1", 2, nil))
+	assert.True(t, errors.Is(err, ErrBadConfigObject))
+}
+
+func TestValidateConfigRefs(t *testing.T) {
+	assert.NoError(t, ValidateConfigRefs([]*ConfigRef{{Name: "nginx", Path: "/etc/nginx.conf"}}))
+	assert.True(t, errors.Is(ValidateConfigRefs([]*ConfigRef{{Path: "/etc/nginx.conf"}}), ErrBadConfigObject))
+	assert.True(t, errors.Is(ValidateConfigRefs([]*ConfigRef{{Name: "nginx", Path: "nginx.conf"}}), ErrBadConfigObject))
+}
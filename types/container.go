@@ -2,6 +2,7 @@ package types
 
 import (
 	"context"
+	"time"
 
 	engine "github.com/projecteru2/core/engine"
 	enginetypes "github.com/projecteru2/core/engine/types"
@@ -23,29 +24,146 @@ type LabelMeta struct {
 	HealthCheck *HealthCheck
 }
 
+// kinds of container events reported by agent
+const (
+	// ContainerEventOOM means container is killed by oom killer
+	ContainerEventOOM = "oom"
+	// ContainerEventExit means container exits without being stopped
+	ContainerEventExit = "exit"
+)
+
+// ContainerEvent is an oom kill or unexpected exit of container seen by agent
+type ContainerEvent struct {
+	ID       string
+	Kind     string
+	ExitCode int
+	Time     time.Time
+}
+
+// Validate checks kind of event
+func (e *ContainerEvent) Validate() error {
+	switch e.Kind {
+	case ContainerEventOOM, ContainerEventExit:
+		return nil
+	default:
+		return NewDetailedErr(ErrBadContainerEvent, e.Kind)
+	}
+}
+
+// ContainerExits counts oom kills and unexpected exits of container
+type ContainerExits struct {
+	OOMKills     int       `json:"oom_kills"`
+	LastOOMKill  time.Time `json:"last_oom_kill"`
+	Exits        int       `json:"exits"`
+	LastExit     time.Time `json:"last_exit"`
+	LastExitCode int       `json:"last_exit_code"`
+}
+
+// Record counts event, last time and exit code are kept from the latest event
+func (e *ContainerExits) Record(event *ContainerEvent) {
+	switch event.Kind {
+	case ContainerEventOOM:
+		e.OOMKills++
+		if event.Time.After(e.LastOOMKill) {
+			e.LastOOMKill = event.Time
+		}
+	case ContainerEventExit:
+		e.Exits++
+		if event.Time.After(e.LastExit) {
+			e.LastExit = event.Time
+			e.LastExitCode = event.ExitCode
+		}
+	}
+}
+
+// retention states of container
+const (
+	// ContainerStateExited means container is not running
+	ContainerStateExited = "exited"
+	// ContainerStateFailed means container is not running after a non zero exit or oom kill
+	ContainerStateFailed = "failed"
+	// ContainerStateReplaced means container is left by replace as the new one started
+	ContainerStateReplaced = "replaced"
+	// ContainerStateLost means container is left on a dead node as it's deployed again elsewhere
+	ContainerStateLost = "lost"
+)
+
+// ContainerReplacedAnnotation marks container left by replace, value is ID of the new one
+const ContainerReplacedAnnotation = "eru.replaced_by"
+
+// ContainerLostAnnotation marks container left on a dead node by failover, value is ID of the new one
+const ContainerLostAnnotation = "eru.lost_to"
+
+// ContainerStoppedAnnotation marks container stopped by user, which isn't restarted by supervisor
+const ContainerStoppedAnnotation = "eru.stopped_at"
+
+// ValidateContainerStates checks retention states
+func ValidateContainerStates(states []string) error {
+	for _, state := range states {
+		switch state {
+		case ContainerStateExited, ContainerStateFailed, ContainerStateReplaced, ContainerStateLost:
+		default:
+			return NewDetailedErr(ErrBadContainerState, state)
+		}
+	}
+	return nil
+}
+
 // Container store container info
 // only relationship with pod and node is stored
 // if you wanna get realtime information, use Inspect method
 type Container struct {
-	ID         string            `json:"id"`
-	Name       string            `json:"name"`
-	Podname    string            `json:"podname"`
-	Nodename   string            `json:"nodename"`
-	CPU        CPUMap            `json:"cpu"`
-	Quota      float64           `json:"quota"`
-	Memory     int64             `json:"memory"`
-	Storage    int64             `json:"storage"`
-	Hook       *Hook             `json:"hook"`
-	Privileged bool              `json:"privileged"`
-	SoftLimit  bool              `json:"softlimit"`
-	User       string            `json:"user"`
-	Env        []string          `json:"env"`
-	Image      string            `json:"image"`
-	Volumes    VolumeBindings    `json:"volumes"`
-	VolumePlan VolumePlan        `json:"volume_plan"`
-	Labels     map[string]string `json:"labels"`
-	StatusMeta *StatusMeta       `json:"-"`
-	Engine     engine.API        `json:"-"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Podname     string            `json:"podname"`
+	Nodename    string            `json:"nodename"`
+	CPU         CPUMap            `json:"cpu"`
+	Quota       float64           `json:"quota"`
+	CPUShares   int64             `json:"cpu_shares,omitempty"`
+	Memory      int64             `json:"memory"`
+	Storage     int64             `json:"storage"`
+	Hook        *Hook             `json:"hook"`
+	PodHook     *Hook             `json:"pod_hook,omitempty"`
+	Privileged  bool              `json:"privileged"`
+	SoftLimit   bool              `json:"softlimit"`
+	User        string            `json:"user"`
+	Env         []string          `json:"env"`
+	Image       string            `json:"image"`
+	Volumes     VolumeBindings    `json:"volumes"`
+	VolumePlan  VolumePlan        `json:"volume_plan"`
+	Hugepages   HugepageMap       `json:"hugepages,omitempty"`
+	GPU         GPUMap            `json:"gpu,omitempty"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	Restart     *RestartPolicy    `json:"restart_policy,omitempty"`
+	StopTimeout time.Duration     `json:"stop_timeout,omitempty"`
+	Exits       *ContainerExits   `json:"exits,omitempty"`
+	Priority    int               `json:"priority,omitempty"`
+	Seq         int               `json:"seq,omitempty"`     // no of container in its deploy
+	Configs     []*ConfigRef      `json:"configs,omitempty"` // config objects mounted, rendered again by reload
+	Cmd         []string          `json:"cmd,omitempty"`     // command, dir and networks are kept to deploy it again once lost
+	Dir         string            `json:"dir,omitempty"`
+	Networks    []string          `json:"networks,omitempty"`
+	StatusMeta  *StatusMeta       `json:"-"`
+	Engine      engine.API        `json:"-"`
+}
+
+// RetentionState returns retention state of container,
+// empty if it is running or its status is unknown
+func (c *Container) RetentionState() string {
+	if _, ok := c.Annotations[ContainerReplacedAnnotation]; ok {
+		return ContainerStateReplaced
+	}
+	if _, ok := c.Annotations[ContainerLostAnnotation]; ok {
+		return ContainerStateLost
+	}
+	if c.StatusMeta == nil || c.StatusMeta.Running {
+		return ""
+	}
+	if e := c.Exits; e != nil && (e.LastExitCode != 0 || (e.OOMKills > 0 && !e.LastOOMKill.Before(e.LastExit))) {
+		return ContainerStateFailed
+	}
+	return ContainerStateExited
 }
 
 // Inspect a container
@@ -64,12 +182,15 @@ func (c *Container) Start(ctx context.Context) error {
 	return c.Engine.VirtualizationStart(ctx, c.ID)
 }
 
-// Stop a container
-func (c *Container) Stop(ctx context.Context) error {
+// Stop a container, it's killed after timeout, or StopTimeout of container if zero
+func (c *Container) Stop(ctx context.Context, timeout time.Duration) error {
 	if c.Engine == nil {
 		return ErrNilEngine
 	}
-	return c.Engine.VirtualizationStop(ctx, c.ID)
+	if timeout <= 0 {
+		timeout = c.StopTimeout
+	}
+	return c.Engine.VirtualizationStop(ctx, c.ID, timeout)
 }
 
 // Remove a container
@@ -3,6 +3,7 @@ package types
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -28,14 +29,16 @@ func TestContainerInspect(t *testing.T) {
 func TestContainerControl(t *testing.T) {
 	mockEngine := &mocks.API{}
 	mockEngine.On("VirtualizationStart", mock.Anything, mock.Anything).Return(nil)
-	mockEngine.On("VirtualizationStop", mock.Anything, mock.Anything).Return(nil)
+	mockEngine.On("VirtualizationStop", mock.Anything, mock.Anything, time.Duration(0)).Return(nil).Once()
+	mockEngine.On("VirtualizationStop", mock.Anything, mock.Anything, 10*time.Second).Return(nil).Once()
+	mockEngine.On("VirtualizationStop", mock.Anything, mock.Anything, 5*time.Second).Return(nil).Once()
 	mockEngine.On("VirtualizationRemove", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	ctx := context.Background()
 	c := Container{}
 	err := c.Start(ctx)
 	assert.Error(t, err)
-	err = c.Stop(ctx)
+	err = c.Stop(ctx, 0)
 	assert.Error(t, err)
 	err = c.Remove(ctx, true)
 	assert.Error(t, err)
@@ -43,8 +46,50 @@ func TestContainerControl(t *testing.T) {
 	c.Engine = mockEngine
 	err = c.Start(ctx)
 	assert.NoError(t, err)
-	err = c.Stop(ctx)
+	err = c.Stop(ctx, 0)
 	assert.NoError(t, err)
+	// default of container
+	c.StopTimeout = 10 * time.Second
+	assert.NoError(t, c.Stop(ctx, 0))
+	// given by call
+	assert.NoError(t, c.Stop(ctx, 5*time.Second))
+	mockEngine.AssertNumberOfCalls(t, "VirtualizationStop", 3)
 	err = c.Remove(ctx, true)
 	assert.NoError(t, err)
 }
+
+func TestContainerExits(t *testing.T) {
+	assert.NoError(t, (&ContainerEvent{Kind: ContainerEventOOM}).Validate())
+	assert.Error(t, (&ContainerEvent{Kind: "die"}).Validate())
+
+	exits := &ContainerExits{}
+	t1, t2 := time.Unix(100, 0), time.Unix(200, 0)
+	exits.Record(&ContainerEvent{Kind: ContainerEventExit, ExitCode: 2, Time: t2})
+	// late event only counts
+	exits.Record(&ContainerEvent{Kind: ContainerEventExit, ExitCode: 1, Time: t1})
+	exits.Record(&ContainerEvent{Kind: ContainerEventOOM, Time: t1})
+	assert.Equal(t, &ContainerExits{OOMKills: 1, LastOOMKill: t1, Exits: 2, LastExit: t2, LastExitCode: 2}, exits)
+}
+
+func TestContainerRetentionState(t *testing.T) {
+	c := &Container{}
+	assert.Equal(t, "", c.RetentionState())
+	c.StatusMeta = &StatusMeta{Running: true}
+	assert.Equal(t, "", c.RetentionState())
+	c.StatusMeta.Running = false
+	assert.Equal(t, ContainerStateExited, c.RetentionState())
+	now := time.Now()
+	c.Exits = &ContainerExits{Exits: 1, LastExit: now}
+	assert.Equal(t, ContainerStateExited, c.RetentionState())
+	c.Exits.Record(&ContainerEvent{Kind: ContainerEventOOM, Time: now})
+	assert.Equal(t, ContainerStateFailed, c.RetentionState())
+	c.Exits = &ContainerExits{LastExitCode: 137}
+	assert.Equal(t, ContainerStateFailed, c.RetentionState())
+	c.Annotations = map[string]string{ContainerReplacedAnnotation: "new"}
+	assert.Equal(t, ContainerStateReplaced, c.RetentionState())
+	c.Annotations = map[string]string{ContainerLostAnnotation: "new"}
+	assert.Equal(t, ContainerStateLost, c.RetentionState())
+
+	assert.NoError(t, ValidateContainerStates([]string{ContainerStateExited, ContainerStateFailed, ContainerStateReplaced, ContainerStateLost}))
+	assert.Error(t, ValidateContainerStates([]string{"running"}))
+}
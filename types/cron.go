@@ -0,0 +1,187 @@
+package types
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// CronKindJob runs containers to completion by RunJob, they are removed once exited
+	CronKindJob = "job"
+	// CronKindDeploy deploys containers by CreateContainer, they are kept as services
+	CronKindDeploy = "deploy"
+)
+
+// Cron triggers a deploy at times matched by Spec, only the leader core fires it
+type Cron struct {
+	Name          string        `json:"name"`
+	Spec          string        `json:"spec"` // minute hour day-of-month month day-of-week, or @hourly @daily @weekly @monthly @yearly
+	Kind          string        `json:"kind"`
+	Retries       int           `json:"retries,omitempty"` // failed containers are deployed again if kind is job
+	DeployOptions DeployOptions `json:"deploy_options"`
+	Created       time.Time     `json:"created"`
+	LastRun       time.Time     `json:"last_run,omitempty"`
+}
+
+// Validate checks cron
+func (c *Cron) Validate() error {
+	if c.Name == "" || strings.Contains(c.Name, "/") {
+		return NewDetailedErr(ErrBadCron, "name is required without /")
+	}
+	if _, err := ParseCronSpec(c.Spec); err != nil {
+		return err
+	}
+	switch {
+	case c.Kind != CronKindJob && c.Kind != CronKindDeploy:
+		return NewDetailedErr(ErrBadCron, "unknown kind "+c.Kind)
+	case c.Retries < 0:
+		return NewDetailedErr(ErrBadCron, "negative retries")
+	case c.DeployOptions.Count <= 0:
+		return NewDetailedErr(ErrBadCron, "count must be positive")
+	case len(c.DeployOptions.Data) > 0:
+		return NewDetailedErr(ErrBadCron, "files are not kept for cron")
+	case c.DeployOptions.OpenStdin:
+		return NewDetailedErr(ErrBadCron, "stdin is not supported by cron")
+	}
+	return nil
+}
+
+// Next tells when cron should fire after its last run
+func (c *Cron) Next() (time.Time, error) {
+	schedule, err := ParseCronSpec(c.Spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	last := c.LastRun
+	if last.IsZero() {
+		last = c.Created
+	}
+	return schedule.Next(last), nil
+}
+
+// CronRun is an execution of a cron, saved once started and again once finished
+type CronRun struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Scheduled time.Time `json:"scheduled"`
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished,omitempty"`
+	Succeeded int       `json:"succeeded"`
+	Failed    int       `json:"failed"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// CronSchedule is parsed cron spec, a field matches values by bits
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// day matches either day-of-month or day-of-week if both are restricted
+	domStar, dowStar bool
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCronSpec parses standard cron spec of 5 fields,
+// a field is `*`, a value, a range `a-b`, a step `*/n` or `a-b/n`, or list of them by `,`
+func ParseCronSpec(spec string) (*CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if expanded, ok := cronDescriptors[spec]; ok {
+		spec = expanded
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, NewDetailedErr(ErrBadCron, "spec needs 5 fields: "+spec)
+	}
+	s := &CronSchedule{domStar: fields[2] == "*", dowStar: fields[4] == "*"}
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	bits := [5]*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, field := range fields {
+		b, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, err
+		}
+		*bits[i] = b
+	}
+	// 7 is sunday too
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, NewDetailedErr(ErrBadCron, "bad step "+part)
+			}
+			rng, step = part[:i], n
+		}
+		start, end := min, max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if start, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, NewDetailedErr(ErrBadCron, "bad value "+part)
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, NewDetailedErr(ErrBadCron, "bad range "+part)
+				}
+			} else if step > 1 {
+				// a/n runs from a to max
+				end = max
+			}
+		}
+		if start < min || end > max || start > end {
+			return 0, NewDetailedErr(ErrBadCron, "out of range "+part)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first time after t matched by schedule, in location of t,
+// zero if nothing matches within 5 years, e.g. Feb 30
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(5, 0, 0)
+	for t.Before(end) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *CronSchedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
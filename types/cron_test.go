@@ -0,0 +1,66 @@
+package types
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCronSpec(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@often"} {
+		_, err := ParseCronSpec(spec)
+		assert.True(t, errors.Is(err, ErrBadCron), spec)
+	}
+
+	at := func(s string) time.Time {
+		r, err := time.Parse("2006-01-02 15:04", s)
+		assert.NoError(t, err)
+		return r
+	}
+	cases := []struct {
+		spec, from, next string
+	}{
+		{"* * * * *", "2021-01-01 10:00", "2021-01-01 10:01"},
+		{"*/15 * * * *", "2021-01-01 10:16", "2021-01-01 10:30"},
+		{"30 2 * * *", "2021-01-01 10:00", "2021-01-02 02:30"},
+		{"@hourly", "2021-01-01 10:00", "2021-01-01 11:00"},
+		{"0 9-17/4 * * 1-5", "2021-01-01 17:00", "2021-01-04 09:00"}, // friday to monday
+		{"0 0 * * 7", "2021-01-01 00:00", "2021-01-03 00:00"},        // sunday
+		{"0 0 13 * 5", "2021-01-01 00:00", "2021-01-08 00:00"},       // 13th or friday
+		{"0 0 29 2 *", "2021-01-01 00:00", "2024-02-29 00:00"},
+		{"5,10 0 1 */6 *", "2021-01-01 00:05", "2021-01-01 00:10"},
+		{"0 0 30 2 *", "2021-01-01 00:00", "0001-01-01 00:00"},
+	}
+	for _, c := range cases {
+		schedule, err := ParseCronSpec(c.spec)
+		assert.NoError(t, err, c.spec)
+		assert.Equal(t, at(c.next), schedule.Next(at(c.from)), c.spec)
+	}
+}
+
+func TestCronValidate(t *testing.T) {
+	cron := &Cron{Name: "backup", Spec: "@daily", Kind: CronKindJob, DeployOptions: DeployOptions{Count: 1}}
+	assert.NoError(t, cron.Validate())
+	created := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+	cron.Created = created
+	next, err := cron.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, created.Add(14*time.Hour), next)
+	cron.LastRun = next
+	next, err = cron.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, created.Add(38*time.Hour), next)
+
+	for _, bad := range []*Cron{
+		{Name: "a/b", Spec: "@daily", Kind: CronKindJob, DeployOptions: DeployOptions{Count: 1}},
+		{Name: "a", Spec: "@daily", Kind: "service", DeployOptions: DeployOptions{Count: 1}},
+		{Name: "a", Spec: "@daily", Kind: CronKindJob, Retries: -1, DeployOptions: DeployOptions{Count: 1}},
+		{Name: "a", Spec: "@daily", Kind: CronKindDeploy},
+		{Name: "a", Spec: "@daily", Kind: CronKindDeploy, DeployOptions: DeployOptions{Count: 1, OpenStdin: true}},
+		{Name: "a", Spec: "1 2 3", Kind: CronKindDeploy, DeployOptions: DeployOptions{Count: 1}},
+	} {
+		assert.True(t, errors.Is(bad.Validate(), ErrBadCron))
+	}
+}
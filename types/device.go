@@ -0,0 +1,75 @@
+package types
+
+import (
+	"path"
+	"strings"
+)
+
+// Device is a host device granted to container
+type Device struct {
+	PathOnHost      string `json:"path_on_host"`
+	PathInContainer string `json:"path_in_container"` // same as PathOnHost if empty
+	Permissions     string `json:"permissions"`       // combination of r, w and m, rwm if empty
+}
+
+// Normalized returns a copy of device with default path in container and permissions filled
+func (d Device) Normalized() Device {
+	if d.PathInContainer == "" {
+		d.PathInContainer = d.PathOnHost
+	}
+	if d.Permissions == "" {
+		d.Permissions = "rwm"
+	}
+	return d
+}
+
+// Validate checks paths are absolute and clean, and permissions are known
+func (d Device) Validate() error {
+	d = d.Normalized()
+	for _, p := range []string{d.PathOnHost, d.PathInContainer} {
+		if !path.IsAbs(p) || path.Clean(p) != p {
+			return NewDetailedErr(ErrBadDevice, p)
+		}
+	}
+	if strings.Trim(d.Permissions, "rwm") != "" || len(d.Permissions) > 3 {
+		return NewDetailedErr(ErrBadDevice, d.Permissions)
+	}
+	return nil
+}
+
+// ValidateDeviceAllowlist checks patterns of a device allowlist,
+// a pattern is an absolute host path or a glob like /dev/nvidia*
+func ValidateDeviceAllowlist(allowlist []string) error {
+	for _, pattern := range allowlist {
+		if !path.IsAbs(pattern) {
+			return NewDetailedErr(ErrBadDevice, pattern)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return NewDetailedErr(ErrBadDevice, pattern)
+		}
+	}
+	return nil
+}
+
+// ValidateDevices checks devices, and that host paths of them are allowed by allowlist
+// no device is allowed if allowlist is empty
+func ValidateDevices(devices []*Device, allowlist []string) error {
+	for _, device := range devices {
+		if err := device.Validate(); err != nil {
+			return err
+		}
+		if !deviceAllowed(device.PathOnHost, allowlist) {
+			return NewDetailedErr(ErrForbiddenDevice, device.PathOnHost)
+		}
+	}
+	return nil
+}
+
+func deviceAllowed(hostPath string, allowlist []string) bool {
+	for _, pattern := range allowlist {
+		if matched, _ := path.Match(pattern, hostPath); matched {
+			return true
+		}
+	}
+	return false
+}
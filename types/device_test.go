@@ -0,0 +1,43 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevice(t *testing.T) {
+	d := Device{PathOnHost: "/dev/fuse"}
+	assert.Equal(t, Device{PathOnHost: "/dev/fuse", PathInContainer: "/dev/fuse", Permissions: "rwm"}, d.Normalized())
+	assert.Equal(t, "", d.PathInContainer)
+	assert.NoError(t, d.Validate())
+	assert.NoError(t, Device{PathOnHost: "/dev/nvidia0", PathInContainer: "/dev/gpu", Permissions: "rw"}.Validate())
+
+	for _, bad := range []Device{
+		{},
+		{PathOnHost: "dev/fuse"},
+		{PathOnHost: "/dev/../etc/passwd"},
+		{PathOnHost: "/dev/fuse", PathInContainer: "fuse"},
+		{PathOnHost: "/dev/fuse", Permissions: "rx"},
+		{PathOnHost: "/dev/fuse", Permissions: "rwmr"},
+	} {
+		assert.True(t, errors.Is(bad.Validate(), ErrBadDevice), bad)
+	}
+}
+
+func TestValidateDevices(t *testing.T) {
+	assert.NoError(t, ValidateDeviceAllowlist([]string{"/dev/fuse", "/dev/nvidia*"}))
+	assert.True(t, errors.Is(ValidateDeviceAllowlist([]string{"dev/fuse"}), ErrBadDevice))
+	assert.True(t, errors.Is(ValidateDeviceAllowlist([]string{"/dev/[nvidia"}), ErrBadDevice))
+
+	devices := []*Device{{PathOnHost: "/dev/fuse"}, {PathOnHost: "/dev/nvidia0"}}
+	assert.NoError(t, ValidateDevices(nil, nil))
+	assert.NoError(t, ValidateDevices(devices, []string{"/dev/fuse", "/dev/nvidia*"}))
+	// nothing is allowed by empty allowlist
+	assert.True(t, errors.Is(ValidateDevices(devices, nil), ErrForbiddenDevice))
+	err := ValidateDevices(devices, []string{"/dev/fuse"})
+	assert.True(t, errors.Is(err, ErrForbiddenDevice))
+	assert.Contains(t, err.Error(), "/dev/nvidia0")
+	assert.True(t, errors.Is(ValidateDevices([]*Device{{PathOnHost: "/dev/fuse", Permissions: "x"}}, []string{"*"}), ErrBadDevice))
+}
@@ -7,15 +7,17 @@ import (
 
 // errors
 var (
-	ErrInsufficientCPU     = errors.New("cannot alloc a plan, not enough cpu")
-	ErrInsufficientMEM     = errors.New("cannot alloc a plan, not enough memory")
-	ErrInsufficientStorage = errors.New("cannot alloc a plan, not enough storage")
-	ErrInsufficientVolume  = errors.New("cannot alloc a plan, not enough volume")
-	ErrInsufficientCap     = errors.New("cannot alloc a each node plan, not enough capacity")
-	ErrInsufficientRes     = errors.New("not enough resource")
-	ErrInvalidRes          = errors.New("invalid resource")
-	ErrInsufficientNodes   = errors.New("not enough nodes")
-	ErrAlreadyFilled       = errors.New("Cannot alloc a fill node plan, each node has enough containers")
+	ErrInsufficientCPU       = errors.New("cannot alloc a plan, not enough cpu")
+	ErrInsufficientMEM       = errors.New("cannot alloc a plan, not enough memory")
+	ErrInsufficientStorage   = errors.New("cannot alloc a plan, not enough storage")
+	ErrInsufficientVolume    = errors.New("cannot alloc a plan, not enough volume")
+	ErrInsufficientHugepages = errors.New("cannot alloc a plan, not enough hugepages")
+	ErrInsufficientGPU       = errors.New("cannot alloc a plan, not enough gpu")
+	ErrInsufficientCap       = errors.New("cannot alloc a each node plan, not enough capacity")
+	ErrInsufficientRes       = errors.New("not enough resource")
+	ErrInvalidRes            = errors.New("invalid resource")
+	ErrInsufficientNodes     = errors.New("not enough nodes")
+	ErrAlreadyFilled         = errors.New("Cannot alloc a fill node plan, each node has enough containers")
 
 	ErrNegativeMemory  = errors.New("memory must be positive")
 	ErrNegativeStorage = errors.New("storage must be positive")
@@ -36,12 +38,20 @@ var (
 	ErrBadSCMType      = errors.New("unknown SCM type")
 	ErrBadMemory       = errors.New("bad `Memory` value")
 	ErrBadCPU          = errors.New("bad `CPU` value")
+	ErrBadCPUShares    = errors.New("bad `CPUShares` value")
 	ErrBadStorage      = errors.New("bad `Storage` value")
 	ErrBadVolume       = errors.New("bad `Volume` value")
+	ErrBadHugepages    = errors.New("bad `Hugepages` value")
+	ErrBadGPU          = errors.New("bad `GPU` value")
 	ErrBadCount        = errors.New("bad `Count` value")
+	ErrBadEntrypoint   = errors.New("bad `Entrypoint` value")
 
-	ErrPodHasNodes = errors.New("pod has nodes")
-	ErrPodNoNodes  = errors.New("pod has no nodes")
+	ErrPodHasNodes    = errors.New("pod has nodes")
+	ErrPodNoNodes     = errors.New("pod has no nodes")
+	ErrBadQuota       = errors.New("bad quota")
+	ErrQuotaExceeded  = errors.New("quota exceeded")
+	ErrQuotaUnderflow = errors.New("quota usage underflow")
+	ErrBadPlacement   = errors.New("bad placement policy")
 
 	ErrCannotGetEngine = errors.New("cannot get engine")
 	ErrNilEngine       = errors.New("engine is nil")
@@ -59,6 +69,7 @@ var (
 	ErrNoDeployOpts                = errors.New("No deploy options")
 	ErrNoContainerIDs              = errors.New("No container ids given")
 	ErrRunAndWaitCountOneWithStdin = errors.New("Count must be 1 if OpenStdin is true")
+	ErrBadJobRetries               = errors.New("Job retries must not be negative")
 	ErrUnknownControlType          = errors.New("Unknown control type")
 
 	ErrNoETCD       = errors.New("ETCD must be set")
@@ -73,14 +84,90 @@ var (
 	ErrIgnoreContainer = errors.New("ignore this container")
 
 	ErrInvalidGitURL        = errors.New("invalid git url format")
+	ErrBranchTagExclusive   = errors.New("branch and tag are exclusive")
+	ErrNoBuildSecret        = errors.New("build secret not given")
+	ErrBadSubpath           = errors.New("subpath must be a dir inside repository")
+	ErrNoKeyRing            = errors.New("no keyring to verify source signature")
+	ErrBadSignature         = errors.New("bad source signature")
+	ErrSourceChecksum       = errors.New("source checksum mismatch")
+	ErrRepoTarballExclusive = errors.New("repo and tarball are exclusive")
+	ErrBadTarballURL        = errors.New("tarball must be https url")
+	ErrMessagesDropped      = errors.New("messages dropped for slow consumer")
 	ErrInvalidContainerName = errors.New("invalid container name")
 
 	ErrEngineNotImplemented = errors.New("not implemented")
 
+	ErrReservedLabel       = errors.New("label is reserved by eru")
+	ErrAnnotationsTooLarge = errors.New("annotations too large")
+	ErrContainerMetaQuota  = errors.New("container meta exceeds quota")
+	ErrBadContainerField   = errors.New("bad container field")
+
+	ErrInvalidHealthCheck   = errors.New("invalid healthcheck")
+	ErrInvalidRestartPolicy = errors.New("invalid restart policy")
+	ErrInvalidUlimit        = errors.New("invalid ulimit")
+	ErrInvalidHook          = errors.New("invalid hook")
+	ErrBadMetricsBackend    = errors.New("bad metrics backend")
+	ErrBadStopTimeout       = errors.New("bad stop timeout")
+	ErrBadAllowIP           = errors.New("bad allow ip")
+	ErrBadCA                = errors.New("bad ca")
+	ErrInvalidSpecs         = errors.New("invalid specs")
+	ErrBadRebalanceStrategy = errors.New("unknown rebalance strategy")
+	ErrNodeLocalVolume      = errors.New("container has node local volumes")
+	ErrSameNode             = errors.New("container is already on the node")
+	ErrNodeUnavailable      = errors.New("node is unavailable")
+	ErrBadWaitCondition     = errors.New("unknown wait condition")
+	ErrWatchClosed          = errors.New("watch closed")
+	ErrCopyTooLarge         = errors.New("file to copy is too large")
+	ErrBadTimezone          = errors.New("bad timezone")
+	ErrBadLocale            = errors.New("bad locale")
+	ErrBadSysctl            = errors.New("bad sysctl")
+	ErrForbiddenSysctl      = errors.New("sysctl not allowed by pod")
+	ErrBadDevice            = errors.New("bad device")
+	ErrForbiddenDevice      = errors.New("device not allowed by pod")
+	ErrBadContainerEvent    = errors.New("unknown container event")
+	ErrBadContainerState    = errors.New("unknown container state")
+
+	ErrBadReservationTTL   = errors.New("bad reservation ttl")
+	ErrReservationExpired  = errors.New("reservation expired")
+	ErrReservationMismatch = errors.New("deploy options mismatch reservation")
+	ErrReservationInUse    = errors.New("reservation is in use by a deploy")
+
+	ErrHookTimeout = errors.New("hook timeout")
+
+	ErrMutatorFailed = errors.New("deploy mutator failed")
+
+	ErrBadRetentionRule = errors.New("bad retention rule")
+
+	ErrBadScalePolicy  = errors.New("bad scale policy")
+	ErrBadCron         = errors.New("bad cron")
+	ErrBadWebhook      = errors.New("bad webhook")
+	ErrBadSecret       = errors.New("bad secret")
+	ErrNoMasterKey     = errors.New("master key of secrets not set")
+	ErrBadConfigObject = errors.New("bad config object")
+	ErrBadCheckpoint   = errors.New("bad checkpoint")
+	ErrNoCheckpointDir = errors.New("dir of checkpoints not set")
+
+	ErrBadRegistryCredential = errors.New("bad registry credential")
+
 	ErrNodeNotExists      = errors.New("node not exists")
 	ErrContainerNotExists = errors.New("container not exists")
 )
 
+// HookError is returned when a forced hook stage fails, it unwraps to error of the failed cmd
+type HookError struct {
+	Stage string
+	Err   error
+}
+
+func (e *HookError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns error of the failed cmd
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
 // NewDetailedErr returns an error with details
 func NewDetailedErr(err error, details interface{}) error {
 	return fmt.Errorf("%w: %v", err, details)
@@ -0,0 +1,64 @@
+package types
+
+import "time"
+
+const (
+	// EventContainerCreated means container is created and started by a deploy
+	EventContainerCreated = "container_created"
+	// EventContainerRemoved means container is removed or dissociated
+	EventContainerRemoved = "container_removed"
+	// EventContainerStarted means container is started by control
+	EventContainerStarted = "container_started"
+	// EventContainerStopped means container is stopped by control
+	EventContainerStopped = "container_stopped"
+	// EventContainerUnhealthy means a running container turns unhealthy by status reported by agent
+	EventContainerUnhealthy = "container_unhealthy"
+	// EventContainerHealthy means an unhealthy container turns healthy again by status reported by agent
+	EventContainerHealthy = "container_healthy"
+	// EventNodeAdded means node is added to a pod
+	EventNodeAdded = "node_added"
+	// EventNodeDown means node is set unavailable
+	EventNodeDown = "node_down"
+	// EventImagePulled means image is pulled on node, not counting cached ones
+	EventImagePulled = "image_pulled"
+)
+
+// Event is something happened in cluster, published to store by the core making it
+type Event struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind"`
+	Time        time.Time `json:"time"`
+	Source      string    `json:"source"` // address of the core publishing it
+	Podname     string    `json:"podname,omitempty"`
+	Nodename    string    `json:"nodename,omitempty"`
+	Appname     string    `json:"appname,omitempty"`
+	ContainerID string    `json:"container_id,omitempty"`
+	Image       string    `json:"image,omitempty"`
+}
+
+// EventFilter selects events, empty fields match all
+type EventFilter struct {
+	Kinds    []string
+	Podname  string
+	Nodename string
+	Appname  string
+}
+
+// Match tells whether event is selected
+func (f *EventFilter) Match(event *Event) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Kinds) > 0 {
+		matched := false
+		for _, kind := range f.Kinds {
+			matched = matched || kind == event.Kind
+		}
+		if !matched {
+			return false
+		}
+	}
+	return (f.Podname == "" || f.Podname == event.Podname) &&
+		(f.Nodename == "" || f.Nodename == event.Nodename) &&
+		(f.Appname == "" || f.Appname == event.Appname)
+}
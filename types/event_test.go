@@ -0,0 +1,18 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventFilterMatch(t *testing.T) {
+	event := &Event{Kind: EventContainerCreated, Podname: "p1", Nodename: "n1", Appname: "app"}
+	var f *EventFilter
+	assert.True(t, f.Match(event))
+	assert.True(t, (&EventFilter{}).Match(event))
+	assert.True(t, (&EventFilter{Kinds: []string{EventNodeDown, EventContainerCreated}, Podname: "p1", Appname: "app"}).Match(event))
+	assert.False(t, (&EventFilter{Kinds: []string{EventNodeDown}}).Match(event))
+	assert.False(t, (&EventFilter{Nodename: "n2"}).Match(event))
+	assert.False(t, (&EventFilter{Appname: "other"}).Match(event))
+}
@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+// CapacitySample is a snapshot of resources of a pod
+// DeployFailures counts deploys failed by insufficient resources since last sample
+type CapacitySample struct {
+	Time time.Time `json:"time"`
+	ResourceCapacity
+	DeployFailures int `json:"deploy_failures"`
+}
+
+// ResourceForecast predicts exhaustion of a resource by its growth
+// ExhaustIn is zero if resource isn't growing
+type ResourceForecast struct {
+	Resource     string
+	Total        float64
+	Used         float64
+	GrowthPerDay float64
+	ExhaustIn    time.Duration
+}
+
+// CapacityForecast predicts resources exhaustion of a pod
+type CapacityForecast struct {
+	Podname        string
+	Samples        int
+	DeployFailures int
+	Resources      []*ResourceForecast
+}
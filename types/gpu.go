@@ -0,0 +1,74 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+)
+
+// GPUShareBase is share of a whole gpu device, exclusive allocation takes all of it
+const GPUShareBase = 100
+
+// GPUMap is gpu device map, device id to share
+// GPUMap {["0"]100, ["1"]50}
+type GPUMap = ResourceMap
+
+// GPURequest asks gpu devices for each container,
+// devices of a container are different, shared devices may also be used by other containers
+type GPURequest struct {
+	Count     int   // devices of each container
+	Exclusive bool  // take whole devices
+	Share     int64 // share of each device if not exclusive, 1 to GPUShareBase
+}
+
+// Validate checks count and share
+func (r *GPURequest) Validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.Count < 0 || (!r.Exclusive && r.Count > 0 && (r.Share <= 0 || r.Share > GPUShareBase)) {
+		return NewDetailedErr(ErrBadGPU, fmt.Sprintf("count %d share %d", r.Count, r.Share))
+	}
+	return nil
+}
+
+// DeviceShare returns share taken from each device, 0 if no gpu needed
+func (r *GPURequest) DeviceShare() int64 {
+	switch {
+	case r == nil || r.Count == 0:
+		return 0
+	case r.Exclusive:
+		return GPUShareBase
+	default:
+		return r.Share
+	}
+}
+
+// Equal checks if two requests take the same devices, nil equals to zero count
+func (r *GPURequest) Equal(q *GPURequest) bool {
+	var rc, qc int
+	if r != nil {
+		rc = r.Count
+	}
+	if q != nil {
+		qc = q.Count
+	}
+	return rc == qc && r.DeviceShare() == q.DeviceShare()
+}
+
+// GPURequestOf returns request planned as gpu, nil if gpu is empty
+func GPURequestOf(gpu GPUMap) *GPURequest {
+	for _, share := range gpu {
+		return &GPURequest{Count: len(gpu), Exclusive: share == GPUShareBase, Share: share}
+	}
+	return nil
+}
+
+// GPUDevices returns sorted device ids of gpu map
+func GPUDevices(gpu GPUMap) []string {
+	devices := []string{}
+	for device := range gpu {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+	return devices
+}
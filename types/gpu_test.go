@@ -0,0 +1,33 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGPURequest(t *testing.T) {
+	var r *GPURequest
+	assert.NoError(t, r.Validate())
+	assert.Equal(t, int64(0), r.DeviceShare())
+	assert.True(t, r.Equal(&GPURequest{}))
+
+	r = &GPURequest{Count: 2, Exclusive: true}
+	assert.NoError(t, r.Validate())
+	assert.Equal(t, int64(GPUShareBase), r.DeviceShare())
+	assert.True(t, r.Equal(&GPURequest{Count: 2, Share: GPUShareBase}))
+	assert.False(t, r.Equal(&GPURequest{Count: 2, Share: 50}))
+
+	assert.Error(t, (&GPURequest{Count: -1}).Validate())
+	assert.Error(t, (&GPURequest{Count: 1}).Validate())
+	assert.Error(t, (&GPURequest{Count: 1, Share: GPUShareBase + 1}).Validate())
+	assert.NoError(t, (&GPURequest{Count: 1, Share: 30}).Validate())
+}
+
+func TestGPURequestOf(t *testing.T) {
+	assert.Nil(t, GPURequestOf(nil))
+	assert.Equal(t, &GPURequest{Count: 2, Exclusive: true, Share: GPUShareBase}, GPURequestOf(GPUMap{"0": 100, "1": 100}))
+	r := GPURequestOf(GPUMap{"1": 30})
+	assert.Equal(t, int64(30), r.DeviceShare())
+	assert.Equal(t, []string{"0", "1", "3"}, GPUDevices(GPUMap{"3": 100, "0": 50, "1": 100}))
+}
@@ -2,14 +2,90 @@ package types
 
 import (
 	"bytes"
+	"fmt"
 	"math"
 )
 
+// MaxAnnotationsSize limits total size of annotations keys and values in bytes
+const MaxAnnotationsSize = 64 * 1024
+
+// MaxContainerMetaSize limits total size of meta keys and values stored for one container in bytes
+const MaxContainerMetaSize = 256 * 1024
+
+// ValidateAnnotations checks annotations size
+func ValidateAnnotations(annotations map[string]string) error {
+	size := 0
+	for key, value := range annotations {
+		size += len(key) + len(value)
+	}
+	if size > MaxAnnotationsSize {
+		return NewDetailedErr(ErrAnnotationsTooLarge, size)
+	}
+	return nil
+}
+
+// MinCPUShares and MaxCPUShares are bounds of cpu.shares
+const (
+	MinCPUShares = 2
+	MaxCPUShares = 262144
+)
+
+// ValidateCPUShares checks cpu shares, 0 means default
+func ValidateCPUShares(shares int64) error {
+	if shares != 0 && (shares < MinCPUShares || shares > MaxCPUShares) {
+		return NewDetailedErr(ErrBadCPUShares, shares)
+	}
+	return nil
+}
+
+// Hugepage sizes supported
+const (
+	Hugepage2Mi = "2Mi"
+	Hugepage1Gi = "1Gi"
+)
+
+// HugepageSize returns page size in bytes, 0 for unsupported size
+func HugepageSize(size string) int64 {
+	switch size {
+	case Hugepage2Mi:
+		return 2 << 20
+	case Hugepage1Gi:
+		return 1 << 30
+	default:
+		return 0
+	}
+}
+
+// ValidateHugepages checks page sizes and counts
+func ValidateHugepages(hugepages HugepageMap) error {
+	for size, count := range hugepages {
+		if HugepageSize(size) == 0 || count < 0 {
+			return NewDetailedErr(ErrBadHugepages, fmt.Sprintf("%s: %d", size, count))
+		}
+	}
+	return nil
+}
+
 // Round for float64 to int
 func Round(f float64) float64 {
 	return math.Round(f*1000000) / 1000000
 }
 
+// HookResultsOutput flattens hook results into one buffer per command
+// failed command shows its error, others show stdout followed by stderr
+func HookResultsOutput(results []*HookResult) []*bytes.Buffer {
+	outputs := []*bytes.Buffer{}
+	for _, result := range results {
+		if result.Error != nil {
+			outputs = append(outputs, bytes.NewBufferString(result.Error.Error()))
+			continue
+		}
+		output := append(append([]byte{}, result.Stdout...), result.Stderr...)
+		outputs = append(outputs, bytes.NewBuffer(output))
+	}
+	return outputs
+}
+
 // HookOutput output hooks output
 func HookOutput(outputs []*bytes.Buffer) []byte {
 	r := []byte{}
@@ -3,6 +3,7 @@ package types
 import (
 	"bytes"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,3 +29,26 @@ func TestHookOutput(t *testing.T) {
 	assert.NotEmpty(t, r)
 	assert.Equal(t, string(r), "ab")
 }
+
+func TestValidateAnnotations(t *testing.T) {
+	assert.NoError(t, ValidateAnnotations(nil))
+	assert.NoError(t, ValidateAnnotations(map[string]string{"git": "abcdef"}))
+	large := map[string]string{"a": strings.Repeat("x", MaxAnnotationsSize)}
+	assert.Error(t, ValidateAnnotations(large))
+}
+
+func TestValidateCPUShares(t *testing.T) {
+	assert.NoError(t, ValidateCPUShares(0))
+	assert.NoError(t, ValidateCPUShares(512))
+	assert.Error(t, ValidateCPUShares(1))
+	assert.Error(t, ValidateCPUShares(-1))
+	assert.Error(t, ValidateCPUShares(MaxCPUShares+1))
+}
+
+func TestValidateHugepages(t *testing.T) {
+	assert.NoError(t, ValidateHugepages(nil))
+	assert.NoError(t, ValidateHugepages(HugepageMap{Hugepage2Mi: 512, Hugepage1Gi: 0}))
+	assert.Error(t, ValidateHugepages(HugepageMap{"4Mi": 1}))
+	assert.Error(t, ValidateHugepages(HugepageMap{Hugepage2Mi: -1}))
+	assert.Equal(t, int64(2<<20), HugepageSize(Hugepage2Mi))
+}
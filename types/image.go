@@ -26,6 +26,15 @@ type Builds = enginetypes.Builds
 // Build is identical to enginetype.Build
 type Build = enginetypes.Build
 
+// SourceCredential is identical to enginetype.SourceCredential
+type SourceCredential = enginetypes.SourceCredential
+
+// SourceVerify is identical to enginetype.SourceVerify
+type SourceVerify = enginetypes.SourceVerify
+
+// Tarball is identical to enginetype.Tarball
+type Tarball = enginetypes.Tarball
+
 // BuildOptions is options for building image
 type BuildOptions struct {
 	Name string
@@ -36,4 +45,26 @@ type BuildOptions struct {
 	*Builds
 	Tar     io.Reader
 	ExistID string
+	// Secrets are values of build secrets by ID, kept out of builds spec
+	Secrets map[string][]byte
+	// Targets build several images from subpaths of one repository, Name and Tags are ignored if set
+	Targets []*BuildTarget
+	// Nodename is the builder node, the most idle node of build pod if empty
+	Nodename string
+}
+
+// BuildTarget is an image built from a subpath of repository
+type BuildTarget struct {
+	Name    string
+	Tags    []string
+	Subpath string
+}
+
+// CommitOptions is options for committing a container to image
+type CommitOptions struct {
+	ID      string
+	Name    string
+	Tags    []string
+	Comment string
+	Push    bool
 }
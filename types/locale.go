@@ -0,0 +1,49 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// zoneinfoDir holds timezone files on nodes
+const zoneinfoDir = "/usr/share/zoneinfo"
+
+var localeRegexp = regexp.MustCompile(`^([a-z]{2,3}(_[A-Z]{2})?|C|POSIX)(\.[A-Za-z0-9-]+)?(@[a-z]+)?$`)
+
+// ValidateTimezone checks timezone is an IANA name like Asia/Shanghai, empty means not set
+func ValidateTimezone(timezone string) error {
+	if timezone == "" {
+		return nil
+	}
+	if timezone == "Local" || strings.HasPrefix(timezone, "/") || strings.Contains(timezone, "..") {
+		return NewDetailedErr(ErrBadTimezone, timezone)
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return NewDetailedErr(ErrBadTimezone, timezone)
+	}
+	return nil
+}
+
+// ValidateLocale checks locale is like en_US.UTF-8, empty means not set
+func ValidateLocale(locale string) error {
+	if locale != "" && !localeRegexp.MatchString(locale) {
+		return NewDetailedErr(ErrBadLocale, locale)
+	}
+	return nil
+}
+
+// LocaleMutation is changes to deploy options for timezone and locale:
+// TZ env and /etc/localtime from zoneinfo of node for timezone, LANG and LC_ALL env for locale
+func LocaleMutation(timezone, locale string) *DeployMutation {
+	mutation := &DeployMutation{}
+	if timezone != "" {
+		mutation.Env = append(mutation.Env, fmt.Sprintf("TZ=%s", timezone))
+		mutation.Volumes = append(mutation.Volumes, fmt.Sprintf("%s/%s:/etc/localtime:ro", zoneinfoDir, timezone))
+	}
+	if locale != "" {
+		mutation.Env = append(mutation.Env, fmt.Sprintf("LANG=%s", locale), fmt.Sprintf("LC_ALL=%s", locale))
+	}
+	return mutation
+}
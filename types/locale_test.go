@@ -0,0 +1,33 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTimezone(t *testing.T) {
+	assert.NoError(t, ValidateTimezone(""))
+	assert.NoError(t, ValidateTimezone("UTC"))
+	assert.NoError(t, ValidateTimezone("Asia/Shanghai"))
+	for _, tz := range []string{"Local", "Mars/Olympus", "/etc/passwd", "../../etc/passwd"} {
+		assert.True(t, errors.Is(ValidateTimezone(tz), ErrBadTimezone), tz)
+	}
+}
+
+func TestValidateLocale(t *testing.T) {
+	for _, locale := range []string{"", "C", "POSIX", "C.UTF-8", "en_US.UTF-8", "zh_CN.utf8", "de_DE@euro"} {
+		assert.NoError(t, ValidateLocale(locale), locale)
+	}
+	for _, locale := range []string{"english", "en_US UTF-8", "en_us"} {
+		assert.True(t, errors.Is(ValidateLocale(locale), ErrBadLocale), locale)
+	}
+}
+
+func TestLocaleMutation(t *testing.T) {
+	assert.Equal(t, &DeployMutation{}, LocaleMutation("", ""))
+	m := LocaleMutation("UTC", "C.UTF-8")
+	assert.Equal(t, []string{"TZ=UTC", "LANG=C.UTF-8", "LC_ALL=C.UTF-8"}, m.Env)
+	assert.Equal(t, []string{"/usr/share/zoneinfo/UTC:/etc/localtime:ro"}, m.Volumes)
+}
@@ -6,10 +6,14 @@ import (
 )
 
 // RemoveContainerMessage for remove message
+// Done and Total are progress of the request
 type RemoveContainerMessage struct {
 	ContainerID string
 	Success     bool
+	Error       error
 	Hook        []*bytes.Buffer
+	Done        int
+	Total       int
 }
 
 // DissociateContainerMessage for dissociate container message
@@ -25,6 +29,7 @@ type errorDetail struct {
 
 // BuildImageMessage for build image ops message
 type BuildImageMessage struct {
+	Name        string      `json:"name,omitempty"` // name of build target
 	ID          string      `json:"id,omitempty"`
 	Status      string      `json:"status,omitempty"`
 	Progress    string      `json:"progress,omitempty"`
@@ -66,13 +71,44 @@ type RemoveImageMessage struct {
 }
 
 // ControlContainerMessage for container control message
+// Partial message carries a piece of streamed hook output only
+// Done and Total are progress of the request, not set in partial messages
 type ControlContainerMessage struct {
 	ContainerID string
 	Error       error
 	Hook        []*bytes.Buffer
+	Partial     bool
+	Done        int
+	Total       int
+}
+
+// HookResult is the output of one hook command
+type HookResult struct {
+	Stage    string
+	Cmd      string
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+	Error    error
 }
 
+const (
+	// DeployFailureScheduling means no resource for containers
+	DeployFailureScheduling = "scheduling"
+	// DeployFailureNode means node can't be got
+	DeployFailureNode = "node"
+	// DeployFailureImage means image can't be pulled
+	DeployFailureImage = "image"
+	// DeployFailureCreate means engine failed to create or start container
+	DeployFailureCreate = "create"
+	// DeployFailureHook means after start hook failed
+	DeployFailureHook = "hook"
+	// DeployFailureStore means container can't be saved
+	DeployFailureStore = "store"
+)
+
 // CreateContainerMessage for create message
+// FailureReason tells which step of deploy Error comes from
 type CreateContainerMessage struct {
 	Podname       string
 	Nodename      string
@@ -84,8 +120,13 @@ type CreateContainerMessage struct {
 	Memory        int64
 	VolumePlan    VolumePlan
 	Storage       int64
+	Hugepages     HugepageMap
+	GPU           GPUMap
 	Publish       map[string][]string
 	Hook          []*bytes.Buffer
+	HookResults   []*HookResult
+	FailureReason string
+	Preempted     []string // IDs of containers evicted for this deploy, carried by the first message
 }
 
 // ReplaceContainerMessage for replace method
@@ -95,12 +136,57 @@ type ReplaceContainerMessage struct {
 	Error  error
 }
 
+// RebalanceMove move a container from one node to another
+type RebalanceMove struct {
+	ContainerID string
+	From        string
+	To          string
+}
+
+// RebalanceMessage for rebalance method
+// Create and Remove are nil if moves are not executed
+type RebalanceMessage struct {
+	Move   *RebalanceMove
+	Create *CreateContainerMessage
+	Remove *RemoveContainerMessage
+	Error  error
+}
+
+// MigrateMessage for migrate method
+type MigrateMessage struct {
+	ContainerID string
+	Create      *CreateContainerMessage
+	Remove      *RemoveContainerMessage
+	Error       error
+}
+
+// WaitContainerMessage for wait method
+// Code and Message are exit code and error of container if waiting for exit
+type WaitContainerMessage struct {
+	ContainerID string
+	Code        int64
+	Message     string
+	Healthy     bool
+	Error       error
+}
+
 // AttachContainerMessage for run and wait
 type AttachContainerMessage struct {
 	ContainerID string
 	Data        []byte
 }
 
+// JobMessage for run job, streams output of a job container,
+// ExitCode is valid once Finished, Error is set if attempt failed before container exited
+type JobMessage struct {
+	ContainerID string
+	Attempt     int
+	Data        []byte
+	Finished    bool
+	ExitCode    int
+	Error       error
+}
+
 // PullImageMessage for cache image
 type PullImageMessage struct {
 	BuildImageMessage
@@ -54,6 +54,21 @@ func (c ResourceMap) Sub(q ResourceMap) {
 	}
 }
 
+// Equal checks if two maps have same non-zero values
+func (c ResourceMap) Equal(q ResourceMap) bool {
+	for label, value := range c {
+		if q[label] != value {
+			return false
+		}
+	}
+	for label, value := range q {
+		if c[label] != value {
+			return false
+		}
+	}
+	return true
+}
+
 // CPUMap is cpu core map
 // CPUMap {["0"]10000, ["1"]10000}
 type CPUMap = ResourceMap
@@ -115,6 +130,9 @@ func (p *VolumePlan) UnmarshalJSON(b []byte) (err error) {
 	if err = json.Unmarshal(b, &plan); err != nil {
 		return err
 	}
+	if *p == nil {
+		*p = VolumePlan{}
+	}
 	for volume, vmap := range plan {
 		vb, err := NewVolumeBinding(volume)
 		if err != nil {
@@ -186,6 +204,10 @@ func (p VolumePlan) Merge(p2 VolumePlan) {
 	}
 }
 
+// HugepageMap is hugepage pool map, page size to page count
+// HugepageMap {["2Mi"]1024, ["1Gi"]4}
+type HugepageMap = ResourceMap
+
 // NUMA define NUMA cpuID->nodeID
 type NUMA map[string]string
 
@@ -207,12 +229,18 @@ type Node struct {
 	MemCap         int64             `json:"memcap"`
 	StorageCap     int64             `json:"storage_cap"`
 	Available      bool              `json:"available"`
+	Unschedulable  bool              `json:"unschedulable,omitempty"`
 	Labels         map[string]string `json:"labels"`
+	Annotations    map[string]string `json:"annotations"`
 	InitCPU        CPUMap            `json:"init_cpu"`
 	InitMemCap     int64             `json:"init_memcap"`
 	InitStorageCap int64             `json:"init_storage_cap"`
 	InitNUMAMemory NUMAMemory        `json:"init_numa_memory"`
 	InitVolume     VolumeMap         `json:"init_volume"`
+	Hugepages      HugepageMap       `json:"hugepages,omitempty"`
+	InitHugepages  HugepageMap       `json:"init_hugepages,omitempty"`
+	GPU            GPUMap            `json:"gpu,omitempty"`
+	InitGPU        GPUMap            `json:"init_gpu,omitempty"`
 	Engine         engine.API        `json:"-"`
 }
 
@@ -224,6 +252,18 @@ func (n *Node) Init() {
 	if n.InitVolume == nil {
 		n.InitVolume = VolumeMap{}
 	}
+	if n.Hugepages == nil {
+		n.Hugepages = HugepageMap{}
+	}
+	if n.InitHugepages == nil {
+		n.InitHugepages = HugepageMap{}
+	}
+	if n.GPU == nil {
+		n.GPU = GPUMap{}
+	}
+	if n.InitGPU == nil {
+		n.InitGPU = GPUMap{}
+	}
 }
 
 // Info show node info
@@ -325,11 +365,14 @@ type NodeInfo struct {
 	NUMAMemory    NUMAMemory
 	MemCap        int64
 	StorageCap    int64
+	Hugepages     HugepageMap
+	GPU           GPUMap
 
 	Usages map[ResourceType]float64
 	Rates  map[ResourceType]float64
 
 	CPUPlan     []CPUMap
+	GPUPlan     []GPUMap
 	VolumePlans []VolumePlan // {{"AUTO:/data:rw:1024": "/mnt0:/data:rw:1024"}}
 	Capacity    int          // 可以部署几个
 	Count       int          // 上面有几个了
@@ -357,6 +400,33 @@ func (n *NodeInfo) GetResourceRate(resource ResourceType) (rate float64) {
 	return
 }
 
+// NodeCapacity show how many containers can be deployed on a node
+// LimitedBy is the resource which limits capacity
+type NodeCapacity struct {
+	Nodename  string
+	Capacity  int
+	LimitedBy string
+}
+
+// CapacityMessage show capacity of candidate nodes
+type CapacityMessage struct {
+	Total          int
+	NodeCapacities map[string]*NodeCapacity
+}
+
+// NodeFragmentation show how free cpu and volume of a node are fragmented
+// FragmentedCPU is the sum of free pieces in partly used cores, in cores
+type NodeFragmentation struct {
+	Name                string
+	FreeCores           int
+	FragmentedCores     int
+	FragmentedCPU       float64
+	VolumeFree          int64
+	VolumeDevices       int
+	MaxVolumeDeviceFree int64
+	Suggestions         []string
+}
+
 // NodeResource for node check
 type NodeResource struct {
 	Name              string
@@ -5,41 +5,76 @@ import (
 	"io"
 	"io/ioutil"
 	"sync"
+	"time"
 )
 
 // DeployOptions is options for deploying
 type DeployOptions struct {
-	Name         string                   // Name of application
-	Entrypoint   *Entrypoint              // entrypoint
-	Podname      string                   // Name of pod to deploy
-	Nodename     string                   // Specific nodes to deploy, if given, must belong to pod
-	Image        string                   // Name of image to deploy
-	ExtraArgs    string                   // Extra arguments to append to command
-	CPUQuota     float64                  // How many cores needed, e.g. 1.5
-	CPUBind      bool                     // Bind CPU or not ( old CPU piror )
-	Memory       int64                    // Memory for container, in bytes
-	Storage      int64                    // Storage for container, in bytes
-	Count        int                      // How many containers needed, e.g. 4
-	Env          []string                 // Env for container
-	DNS          []string                 // DNS for container
-	ExtraHosts   []string                 // Extra hosts for container
-	Volumes      VolumeBindings           // Volumes for container
-	Networks     map[string]string        // Network names and specified IPs
-	NetworkMode  string                   // Network mode
-	User         string                   // User for container
-	Debug        bool                     // debug mode, use syslog as log driver
-	OpenStdin    bool                     // OpenStdin for container
-	Labels       map[string]string        // Labels for containers
-	NodeLabels   map[string]string        // NodeLabels for filter node
-	DeployMethod string                   // Deploy method
-	Data         map[string]ReaderManager // For additional file data
-	SoftLimit    bool                     // Soft limit memory
-	NodesLimit   int                      // Limit nodes count
-	ProcessIdent string                   // ProcessIdent ident this deploy
-	IgnoreHook   bool                     // IgnoreHook ignore hook process
-	AfterCreate  []string                 // AfterCreate support run cmds after create
-	RawArgs      []byte                   // RawArgs for raw args processing
-	Lambda       bool                     // indicate is lambda container or not
+	Name           string                   // Name of application
+	Entrypoint     *Entrypoint              // entrypoint
+	Podname        string                   // Name of pod to deploy
+	Nodename       string                   // Specific nodes to deploy, if given, must belong to pod
+	Image          string                   // Name of image to deploy
+	ExtraArgs      string                   // Extra arguments to append to command
+	CPUQuota       float64                  // How many cores needed, e.g. 1.5
+	CPUBind        bool                     // Bind CPU or not ( old CPU piror )
+	CPUShares      int64                    // CPUShares relative weight under contention, 0 means default
+	Memory         int64                    // Memory for container, in bytes
+	Storage        int64                    // Storage for container, in bytes
+	Count          int                      // How many containers needed, e.g. 4
+	Env            []string                 // Env for container
+	DNS            []string                 // DNS for container
+	ExtraHosts     []string                 // Extra hosts for container
+	Volumes        VolumeBindings           // Volumes for container
+	Networks       map[string]string        // Network names and specified IPs
+	NetworkMode    string                   // Network mode
+	User           string                   // User for container
+	Debug          bool                     // debug mode, use syslog as log driver
+	OpenStdin      bool                     // OpenStdin for container
+	Labels         map[string]string        // Labels for containers
+	Annotations    map[string]string        // Annotations for containers, not used by scheduler
+	NodeLabels     map[string]string        // NodeLabels for filter node
+	DeployStrategy string                   // DeployStrategy name of registered deploy strategy
+	Data           map[string]ReaderManager // For additional file data
+	SoftLimit      bool                     // Soft limit memory
+	NodesLimit     int                      // Limit nodes count
+	ProcessIdent   string                   // ProcessIdent ident this deploy
+	IgnoreHook     bool                     // IgnoreHook ignore hook process
+	PodHook        *Hook                    // PodHook default hooks of pod, filled by core unless entrypoint skips it
+	AfterCreate    []string                 // AfterCreate support run cmds after create
+	RawArgs        []byte                   // RawArgs for raw args processing
+	Lambda         bool                     // indicate is lambda container or not
+	ReservationID  string                   // ReservationID consume resources held by this reservation
+	Hugepages      HugepageMap              // Hugepages needed, page size to page count, e.g. {"2Mi": 512}
+	GPURequest     *GPURequest              // GPURequest pins gpu devices to each container
+	Timezone       string                   // Timezone of container, e.g. Asia/Shanghai, pod default if empty
+	Locale         string                   // Locale of container, e.g. en_US.UTF-8, pod default if empty
+	Devices        []*Device                // Devices of host granted to container, must be allowed by pod
+	Affinity       map[string]string        // Affinity deploys only to nodes running containers with these labels
+	AntiAffinity   map[string]string        // AntiAffinity skips nodes running containers with these labels, one per node if they match Labels
+	Priority       int                      // Priority may preempt containers of lower priority if resources are insufficient
+	SecretRefs     []*SecretRef             // SecretRefs are injected into containers at creation, never saved with them
+	ConfigRefs     []*ConfigRef             // ConfigRefs are rendered and sent into containers at creation, again by reload
+}
+
+// DeployMutation is changes made to deploy options by a mutator
+type DeployMutation struct {
+	Env     []string          `json:"env,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	DNS     []string          `json:"dns,omitempty"`
+	Volumes []string          `json:"volumes,omitempty"`
+}
+
+// DeployMutationRequest is posted to mutator webhooks, it tells what is being deployed
+type DeployMutationRequest struct {
+	Appname    string            `json:"appname"`
+	Entrypoint string            `json:"entrypoint"`
+	Podname    string            `json:"podname"`
+	Image      string            `json:"image"`
+	Env        []string          `json:"env"`
+	Labels     map[string]string `json:"labels"`
+	DNS        []string          `json:"dns"`
+	Volumes    []string          `json:"volumes"`
 }
 
 // ReaderManager return Reader under concurrency
@@ -83,6 +118,13 @@ type RunAndWaitOptions struct {
 	Cmd     string
 }
 
+// JobOptions is options for running containers to completion,
+// failed ones are deployed again up to Retries times
+type JobOptions struct {
+	DeployOptions
+	Retries int
+}
+
 // CopyOptions for multiple container files copy
 type CopyOptions struct {
 	Targets map[string][]string
@@ -112,22 +154,74 @@ type ReplaceOptions struct {
 	IDs            []string
 }
 
+// RebalanceOptions for rebalancing containers of an entrypoint between nodes of a pod
+// NodeLabels in DeployOptions limit target nodes, DeployOptions is used to recreate moved containers
+type RebalanceOptions struct {
+	DeployOptions
+	Strategy       string
+	MaxUnavailable int
+	Execute        bool
+}
+
+// DrainNodeOptions for moving all containers off a node, they are removed instead if Remove is set
+type DrainNodeOptions struct {
+	Nodename          string
+	Remove            bool
+	MaxUnavailable    int
+	AllowLocalVolumes bool // data of host path and AUTO volumes is not carried to new node
+}
+
+// MigrateOptions for moving containers to pod of DeployOptions
+// containers of the app entrypoint in other pods are moved if IDs is empty
+type MigrateOptions struct {
+	DeployOptions
+	IDs               []string
+	MaxUnavailable    int
+	AllowLocalVolumes bool // data of host path and AUTO volumes is not carried to new node
+}
+
+// CloneOptions for creating a container with options of an existing one
+// clone is created on node of the original one if Nodename is empty
+type CloneOptions struct {
+	ID         string
+	Nodename   string
+	Env        []string // appended to env of the original one
+	IgnoreHook bool
+}
+
+// WaitOptions for waiting containers exited or healthy
+type WaitOptions struct {
+	IDs       []string
+	Condition string
+	Timeout   time.Duration // no timeout if zero
+}
+
+// ReserveOptions for holding resources of a deploy
+// resources will be released if not consumed before TTL expired
+type ReserveOptions struct {
+	DeployOptions
+	TTL time.Duration
+}
+
 // AddNodeOptions for adding node
 type AddNodeOptions struct {
-	Nodename   string
-	Endpoint   string
-	Podname    string
-	Ca         string
-	Cert       string
-	Key        string
-	CPU        int
-	Share      int
-	Memory     int64
-	Storage    int64
-	Labels     map[string]string
-	Numa       NUMA
-	NumaMemory NUMAMemory
-	Volume     VolumeMap
+	Nodename    string
+	Endpoint    string
+	Podname     string
+	Ca          string
+	Cert        string
+	Key         string
+	CPU         int
+	Share       int
+	Memory      int64
+	Storage     int64
+	Labels      map[string]string
+	Annotations map[string]string
+	Numa        NUMA
+	NumaMemory  NUMAMemory
+	Volume      VolumeMap
+	Hugepages   HugepageMap
+	GPU         []string // ids of gpu devices, each has GPUShareBase share
 }
 
 // Normalize keeps options consistent
@@ -145,8 +239,20 @@ type SetNodeOptions struct {
 	DeltaStorage    int64
 	DeltaNUMAMemory map[string]int64
 	DeltaVolume     VolumeMap
+	DeltaHugepages  HugepageMap
+	DeltaGPU        GPUMap
 	NUMA            map[string]string
 	Labels          map[string]string
+	Annotations     map[string]string
+	Endpoint        string
+	Ca              string
+	Cert            string
+	Key             string
+}
+
+// ChangeEngine tells whether endpoint or certs of node change
+func (o *SetNodeOptions) ChangeEngine() bool {
+	return o.Endpoint != "" || o.Ca != "" || o.Cert != "" || o.Key != ""
 }
 
 // Normalize keeps options consistent
@@ -159,6 +265,38 @@ func (o *SetNodeOptions) Normalize(node *Node) {
 	}
 }
 
+// metadata of container updated by SetContainer, they are only used by core so engines needn't change
+const (
+	ContainerFieldPriority    = "priority"
+	ContainerFieldStopTimeout = "stop_timeout"
+)
+
+// SetContainerOptions for updating container labels and metadata after creation
+// only metadata named in Fields is updated, as zero values of them are meaningful
+type SetContainerOptions struct {
+	ID           string
+	Labels       map[string]string // labels to add or overwrite
+	RemoveLabels []string          // label keys to remove
+
+	Annotations       map[string]string // annotations to add or overwrite
+	RemoveAnnotations []string          // annotation keys to remove
+
+	Fields      []string // selected metadata to update
+	Priority    int
+	StopTimeout time.Duration
+}
+
+// AdoptOptions for registering an existing engine container into eru
+// Quota and Memory are inspected from engine if zero
+type AdoptOptions struct {
+	Nodename string
+	ID       string
+	Name     string // name in appname_entrypoint_ident, engine container name if empty
+	Quota    float64
+	Memory   int64
+	Storage  int64
+}
+
 // ExecuteContainerOptions for executing commands in running container
 type ExecuteContainerOptions struct {
 	ContainerID string
@@ -173,6 +311,7 @@ type ExecuteContainerOptions struct {
 type ReallocOptions struct {
 	IDs         []string
 	CPU         float64
+	CPUShares   int64 // set cpu shares if positive, otherwise keep
 	Memory      int64
 	Volumes     VolumeBindings
 	BindCPU     TriOptions
@@ -190,3 +329,10 @@ const (
 	// TriFalse .
 	TriFalse
 )
+
+// ValidateHookOptions for dry-run validating hooks against a running container
+// container's own hook is validated if Hook is nil
+type ValidateHookOptions struct {
+	ID   string
+	Hook *Hook
+}
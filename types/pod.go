@@ -1,9 +1,34 @@
 package types
 
+const (
+	// PlacementSpread spread containers to minimize blast radius, default placement
+	PlacementSpread = "spread"
+	// PlacementPack pack containers to minimize nodes count
+	PlacementPack = "pack"
+)
+
 // Pod define pod
 type Pod struct {
-	Name string `json:"name"`
-	Desc string `json:"desc"`
+	Name      string `json:"name"`
+	Desc      string `json:"desc"`
+	Placement string `json:"placement,omitempty"`
+	Hook      *Hook  `json:"hook,omitempty"`
+	Timezone  string `json:"timezone,omitempty"` // default timezone of containers
+	Locale    string `json:"locale,omitempty"`   // default locale of containers
+	// sysctls containers may set, all are allowed if empty, see ValidateSysctlAllowlist for patterns
+	SysctlAllowlist []string `json:"sysctl_allowlist,omitempty"`
+	// host devices containers may be granted, none if empty, see ValidateDeviceAllowlist for patterns
+	DeviceAllowlist []string `json:"device_allowlist,omitempty"`
+}
+
+// ValidatePlacement checks placement policy of pod
+func ValidatePlacement(placement string) error {
+	switch placement {
+	case "", PlacementSpread, PlacementPack:
+		return nil
+	default:
+		return NewDetailedErr(ErrBadPlacement, placement)
+	}
 }
 
 // PodResource define pod resource
@@ -16,3 +41,43 @@ type PodResource struct {
 	Verifications   map[string]bool
 	Details         map[string]string
 }
+
+// ResourceCapacity show total and allocated resources
+// allocated resources include what processing containers reserved
+type ResourceCapacity struct {
+	CPU         float64
+	CPUUsed     float64
+	Memory      int64
+	MemoryUsed  int64
+	Storage     int64
+	StorageUsed int64
+	Volume      int64
+	VolumeUsed  int64
+	Processing  int
+}
+
+// Add adds another resource capacity
+func (r *ResourceCapacity) Add(o ResourceCapacity) {
+	r.CPU += o.CPU
+	r.CPUUsed += o.CPUUsed
+	r.Memory += o.Memory
+	r.MemoryUsed += o.MemoryUsed
+	r.Storage += o.Storage
+	r.StorageUsed += o.StorageUsed
+	r.Volume += o.Volume
+	r.VolumeUsed += o.VolumeUsed
+	r.Processing += o.Processing
+}
+
+// NodeCapacityReport show resource capacity of a node
+type NodeCapacityReport struct {
+	Name string
+	ResourceCapacity
+}
+
+// PodCapacityReport show resource capacity of a pod and its nodes
+type PodCapacityReport struct {
+	Name string
+	ResourceCapacity
+	Nodes []*NodeCapacityReport
+}
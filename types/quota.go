@@ -0,0 +1,103 @@
+package types
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// QuotaScopePod limits containers in a pod
+	QuotaScopePod = "pod"
+	// QuotaScopeApp limits containers of an app, by appname
+	QuotaScopeApp = "app"
+)
+
+// QuotaUsage is resources counted against a quota
+type QuotaUsage struct {
+	CPU        float64 `json:"cpu"`
+	Memory     int64   `json:"memory"`
+	Storage    int64   `json:"storage"`
+	Volume     int64   `json:"volume"`
+	Containers int     `json:"containers"`
+}
+
+// Add returns usage added by delta
+func (u QuotaUsage) Add(delta QuotaUsage) QuotaUsage {
+	r := QuotaUsage{
+		CPU:        u.CPU + delta.CPU,
+		Memory:     u.Memory + delta.Memory,
+		Storage:    u.Storage + delta.Storage,
+		Volume:     u.Volume + delta.Volume,
+		Containers: u.Containers + delta.Containers,
+	}
+	// float dust left by refunds is cleaned
+	if math.Abs(r.CPU) < 1e-6 {
+		r.CPU = 0
+	}
+	return r
+}
+
+// Sub returns difference of usages, which may be negative
+func (u QuotaUsage) Sub(o QuotaUsage) QuotaUsage {
+	return u.Add(o.Neg())
+}
+
+// Neg returns negative of usage, for refunding it
+func (u QuotaUsage) Neg() QuotaUsage {
+	return QuotaUsage{CPU: -u.CPU, Memory: -u.Memory, Storage: -u.Storage, Volume: -u.Volume, Containers: -u.Containers}
+}
+
+// Times returns usage of n containers
+func (u QuotaUsage) Times(n int) QuotaUsage {
+	return QuotaUsage{CPU: u.CPU * float64(n), Memory: u.Memory * int64(n), Storage: u.Storage * int64(n), Volume: u.Volume * int64(n), Containers: u.Containers * n}
+}
+
+// Quota limits resources of containers in a pod or of an app, 0 limit means unlimited
+type Quota struct {
+	Scope string     `json:"scope"`
+	Name  string     `json:"name"` // podname or appname
+	Limit QuotaUsage `json:"limit"`
+	Used  QuotaUsage `json:"used"`
+}
+
+// Validate checks quota
+func (q *Quota) Validate() error {
+	switch {
+	case q.Scope != QuotaScopePod && q.Scope != QuotaScopeApp:
+		return NewDetailedErr(ErrBadQuota, "unknown scope "+q.Scope)
+	case q.Name == "":
+		return NewDetailedErr(ErrBadQuota, "name is required")
+	case q.Limit.CPU < 0 || q.Limit.Memory < 0 || q.Limit.Storage < 0 || q.Limit.Volume < 0 || q.Limit.Containers < 0:
+		return NewDetailedErr(ErrBadQuota, "negative limit")
+	}
+	return nil
+}
+
+// Charge adds delta to usage, it's rejected if a resource is increased beyond limit,
+// decreasing succeeds so an over limit quota can be brought back, unless usage drops below zero,
+// which means usage was lost somewhere, the quota should be removed and set again to recount it
+func (q *Quota) Charge(delta QuotaUsage) error {
+	used := q.Used.Add(delta)
+	if used.CPU < 0 || used.Memory < 0 || used.Storage < 0 || used.Volume < 0 || used.Containers < 0 {
+		return NewDetailedErr(ErrQuotaUnderflow, fmt.Sprintf("%s %s: %+v", q.Scope, q.Name, used))
+	}
+	exceeded := func(resource string, increased bool, used, limit float64) error {
+		if !increased || limit <= 0 || used <= limit+1e-6 {
+			return nil
+		}
+		return NewDetailedErr(ErrQuotaExceeded, fmt.Sprintf("%s of %s %s: %v > %v", resource, q.Scope, q.Name, used, limit))
+	}
+	for _, err := range []error{
+		exceeded("cpu", delta.CPU > 0, used.CPU, q.Limit.CPU),
+		exceeded("memory", delta.Memory > 0, float64(used.Memory), float64(q.Limit.Memory)),
+		exceeded("storage", delta.Storage > 0, float64(used.Storage), float64(q.Limit.Storage)),
+		exceeded("volume", delta.Volume > 0, float64(used.Volume), float64(q.Limit.Volume)),
+		exceeded("containers", delta.Containers > 0, float64(used.Containers), float64(q.Limit.Containers)),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	q.Used = used
+	return nil
+}
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotaValidate(t *testing.T) {
+	assert.NoError(t, (&Quota{Scope: QuotaScopePod, Name: "p1"}).Validate())
+	assert.NoError(t, (&Quota{Scope: QuotaScopeApp, Name: "app"}).Validate())
+	for _, q := range []*Quota{
+		{Scope: "node", Name: "n1"},
+		{Scope: QuotaScopePod},
+		{Scope: QuotaScopePod, Name: "p1", Limit: QuotaUsage{Memory: -1}},
+		{Scope: QuotaScopePod, Name: "p1", Limit: QuotaUsage{Volume: -1}},
+		{Scope: QuotaScopeApp, Name: "app", Limit: QuotaUsage{Containers: -1}},
+	} {
+		assert.True(t, errors.Is(q.Validate(), ErrBadQuota))
+	}
+}
+
+func TestQuotaCharge(t *testing.T) {
+	q := &Quota{Scope: QuotaScopePod, Name: "p1", Limit: QuotaUsage{CPU: 2}}
+	one := QuotaUsage{CPU: 0.7, Memory: 1 << 30, Storage: 1 << 30}
+	assert.NoError(t, q.Charge(one.Times(2)))
+	assert.Equal(t, QuotaUsage{CPU: 1.4, Memory: 2 << 30, Storage: 2 << 30}, q.Used)
+
+	// cpu exceeded, unlimited memory is fine
+	err := q.Charge(one)
+	assert.True(t, errors.Is(err, ErrQuotaExceeded))
+	assert.Contains(t, err.Error(), "cpu of pod p1")
+	assert.Equal(t, 2<<30, int(q.Used.Memory))
+
+	// decreasing is always fine
+	q.Limit.CPU = 1
+	assert.NoError(t, q.Charge(QuotaUsage{Memory: -(1 << 30)}))
+	assert.NoError(t, q.Charge(one.Times(2).Neg().Add(QuotaUsage{Memory: 1 << 30})))
+	assert.Equal(t, QuotaUsage{}, q.Used)
+	// but never below zero
+	err = q.Charge(one.Neg())
+	assert.True(t, errors.Is(err, ErrQuotaUnderflow))
+	assert.Equal(t, QuotaUsage{}, q.Used)
+	assert.Equal(t, QuotaUsage{CPU: -0.7, Memory: 1}, QuotaUsage{Memory: 1}.Sub(QuotaUsage{CPU: 0.7}))
+
+	// volume exceeded
+	q.Limit = QuotaUsage{Volume: 100}
+	assert.NoError(t, q.Charge(QuotaUsage{Volume: 100}))
+	err = q.Charge(QuotaUsage{Volume: 1})
+	assert.True(t, errors.Is(err, ErrQuotaExceeded))
+	assert.Contains(t, err.Error(), "volume of pod p1")
+
+	// containers of app exceeded
+	q = &Quota{Scope: QuotaScopeApp, Name: "app", Limit: QuotaUsage{Containers: 2}}
+	assert.NoError(t, q.Charge(QuotaUsage{Memory: 1, Containers: 1}.Times(2)))
+	err = q.Charge(QuotaUsage{Memory: 1, Containers: 1})
+	assert.True(t, errors.Is(err, ErrQuotaExceeded))
+	assert.Contains(t, err.Error(), "containers of app app")
+}
@@ -0,0 +1,39 @@
+package types
+
+import "strings"
+
+// RegistryCredential authenticates pulls of images from a registry, password is encrypted by master key of core
+type RegistryCredential struct {
+	Registry string `json:"registry"` // host of registry, e.g. docker.io or hub.example.com:5000
+	Podname  string `json:"podname"`  // nodes of this pod only, or nodes of all pods if empty
+	Username string `json:"username"`
+	Password []byte `json:"password"` // encrypted value, never shown
+}
+
+// RegistryCredentialOptions for adding or rotating a credential of registry
+// credential of the same registry and pod is replaced
+type RegistryCredentialOptions struct {
+	Registry string
+	Podname  string
+	Username string
+	Password string
+}
+
+// Validate checks registry credential options
+func (o *RegistryCredentialOptions) Validate() error {
+	if err := ValidateRegistry(o.Registry); err != nil {
+		return err
+	}
+	if o.Username == "" || o.Password == "" {
+		return NewDetailedErr(ErrBadRegistryCredential, "username and password are required")
+	}
+	return nil
+}
+
+// ValidateRegistry checks host of registry
+func ValidateRegistry(registry string) error {
+	if registry == "" || strings.ContainsAny(registry, "/ ") {
+		return NewDetailedErr(ErrBadRegistryCredential, "bad registry "+registry)
+	}
+	return nil
+}
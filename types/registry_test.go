@@ -0,0 +1,20 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryCredentialOptions(t *testing.T) {
+	opts := &RegistryCredentialOptions{Registry: "hub.example.com:5000", Username: "u", Password: "p"}
+	assert.NoError(t, opts.Validate())
+	opts.Password = ""
+	assert.True(t, errors.Is(opts.Validate(), ErrBadRegistryCredential))
+	opts.Password = "p"
+	opts.Registry = "hub.example.com/app"
+	assert.True(t, errors.Is(opts.Validate(), ErrBadRegistryCredential))
+	assert.Error(t, ValidateRegistry(""))
+	assert.NoError(t, ValidateRegistry("docker.io"))
+}
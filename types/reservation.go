@@ -0,0 +1,53 @@
+package types
+
+import "time"
+
+// Reservation holds resources on nodes for a future deploy
+// NodesInfo only keeps name, deploy count and plans of each node
+type Reservation struct {
+	ID         string         `json:"id"`
+	Podname    string         `json:"podname"`
+	Appname    string         `json:"appname"`
+	Entrypoint string         `json:"entrypoint"`
+	CPUQuota   float64        `json:"cpu_quota"`
+	CPUBind    bool           `json:"cpu_bind"`
+	Memory     int64          `json:"memory"`
+	Storage    int64          `json:"storage"`
+	Volumes    VolumeBindings `json:"volumes"`
+	Hugepages  HugepageMap    `json:"hugepages,omitempty"`
+	GPURequest *GPURequest    `json:"gpu_request,omitempty"`
+	NodesInfo  []NodeInfo     `json:"nodes_info"`
+	Expire     time.Time      `json:"expire"`
+	// Dispatching marks resources held by a running deploy, it's renewed by the deploy
+	// and released if the deploy stops renewing it, e.g. core crashed
+	Dispatching bool `json:"dispatching,omitempty"`
+}
+
+// Expired checks if reservation is expired
+func (r *Reservation) Expired() bool {
+	return time.Now().After(r.Expire)
+}
+
+// Count returns how many containers reserved
+func (r *Reservation) Count() (count int) {
+	for _, nodeInfo := range r.NodesInfo {
+		count += nodeInfo.Deploy
+	}
+	return
+}
+
+// Match checks if deploy options fit this reservation
+// deploy options must be normalized
+func (r *Reservation) Match(opts *DeployOptions) bool {
+	return opts.Podname == r.Podname &&
+		opts.Name == r.Appname &&
+		opts.Entrypoint != nil && opts.Entrypoint.Name == r.Entrypoint &&
+		opts.CPUQuota == r.CPUQuota &&
+		opts.CPUBind == r.CPUBind &&
+		opts.Memory == r.Memory &&
+		opts.Storage == r.Storage &&
+		opts.Volumes.IsEqual(r.Volumes) &&
+		opts.Hugepages.Equal(r.Hugepages) &&
+		opts.GPURequest.Equal(r.GPURequest) &&
+		opts.Count == r.Count()
+}
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Secret is a named value encrypted by master key of core, plaintext is never saved
+type Secret struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"` // encrypted value
+}
+
+// SecretRef injects a secret into containers, as env var or file
+type SecretRef struct {
+	Name string // name of secret
+	Env  string // value is set to this env var
+	Path string // or written to this absolute path in container
+}
+
+// ValidateSecretName checks name of secret
+func ValidateSecretName(name string) error {
+	if name == "" || strings.Contains(name, "/") {
+		return NewDetailedErr(ErrBadSecret, "bad name "+name)
+	}
+	return nil
+}
+
+// ValidateSecretRefs checks secrets referred by deploy
+func ValidateSecretRefs(refs []*SecretRef) error {
+	for _, ref := range refs {
+		if err := ValidateSecretName(ref.Name); err != nil {
+			return err
+		}
+		switch {
+		case (ref.Env == "") == (ref.Path == ""):
+			return NewDetailedErr(ErrBadSecret, "either env or path of secret "+ref.Name+" is required")
+		case strings.Contains(ref.Env, "="):
+			return NewDetailedErr(ErrBadSecret, "bad env "+ref.Env)
+		case ref.Path != "" && !filepath.IsAbs(ref.Path):
+			return NewDetailedErr(ErrBadSecret, "path of secret must be absolute "+ref.Path)
+		}
+	}
+	return nil
+}
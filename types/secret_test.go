@@ -0,0 +1,23 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSecretRefs(t *testing.T) {
+	assert.NoError(t, ValidateSecretRefs(nil))
+	assert.NoError(t, ValidateSecretRefs([]*SecretRef{{Name: "db", Env: "DB_PASSWORD"}, {Name: "tls", Path: "/etc/tls.key"}}))
+	for _, ref := range []*SecretRef{
+		{Env: "DB_PASSWORD"},
+		{Name: "a/b", Env: "DB_PASSWORD"},
+		{Name: "db"},
+		{Name: "db", Env: "DB_PASSWORD", Path: "/etc/db"},
+		{Name: "db", Env: "A=B"},
+		{Name: "db", Path: "etc/db"},
+	} {
+		assert.True(t, errors.Is(ValidateSecretRefs([]*SecretRef{ref}), ErrBadSecret))
+	}
+}
@@ -7,3 +7,15 @@ type ServiceStatus struct {
 	Addresses []string
 	Interval  time.Duration
 }
+
+// DebugInfo shows resource usage of core process itself
+type DebugInfo struct {
+	Goroutines   int
+	HeapAlloc    uint64
+	HeapSys      uint64
+	NumGC        uint32
+	GCPauseTotal time.Duration
+	GCPauseLast  time.Duration
+	Streams      int64
+	StoreState   string
+}
@@ -1,32 +1,329 @@
 package types
 
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
 // Hook define hooks
+// Timeout limits each hook command in seconds, zero means no limit other than the request's own
+// failed command is retried Retries times, waiting Backoff seconds first and doubling it after each retry
+// with http type, each command is an url which receives container context by POST, HTTPCode is the expected status
+// Stages run in order after the plain list of the same event, each with its own force flag
+// User and WorkingDir apply to exec sessions of hook commands, stages may override them
 type Hook struct {
-	AfterStart []string `yaml:"after_start,omitempty"`
-	BeforeStop []string `yaml:"before_stop,omitempty"`
+	AfterStart   []string    `yaml:"after_start,omitempty"`
+	BeforeStop   []string    `yaml:"before_stop,omitempty"`
+	BeforeRemove []string    `yaml:"before_remove,omitempty"`
+	Force        bool        `yaml:"force,omitempty"`
+	Timeout      int         `yaml:"timeout,omitempty"`
+	Retries      int         `yaml:"retries,omitempty"`
+	Backoff      int         `yaml:"backoff,omitempty"`
+	Type         string      `yaml:"type,omitempty"`
+	HTTPCode     int         `yaml:"code,omitempty"`
+	Stages       []HookStage `yaml:"stages,omitempty"`
+	User         string      `yaml:"user,omitempty"`
+	WorkingDir   string      `yaml:"working_dir,omitempty"`
+}
+
+// HookStage is a named group of hook commands run on event, defaults to after_start
+type HookStage struct {
+	Name       string   `yaml:"name"`
+	On         string   `yaml:"on,omitempty"`
+	Commands   []string `yaml:"commands"`
 	Force      bool     `yaml:"force,omitempty"`
+	User       string   `yaml:"user,omitempty"`
+	WorkingDir string   `yaml:"working_dir,omitempty"`
+}
+
+// StagesOf returns stages to run on event in order
+// plain list of event comes first as a stage named after event
+// user and working dir of hook are filled into stages without their own
+func (h *Hook) StagesOf(event string) []HookStage {
+	stages := []HookStage{}
+	var cmds []string
+	switch event {
+	case HookAfterStart:
+		cmds = h.AfterStart
+	case HookBeforeStop:
+		cmds = h.BeforeStop
+	case HookBeforeRemove:
+		cmds = h.BeforeRemove
+	}
+	if len(cmds) > 0 {
+		stages = append(stages, HookStage{Name: event, On: event, Commands: cmds, Force: h.Force, User: h.User, WorkingDir: h.WorkingDir})
+	}
+	for _, stage := range h.Stages {
+		on := stage.On
+		if on == "" {
+			on = HookAfterStart
+		}
+		if on != event {
+			continue
+		}
+		if stage.User == "" {
+			stage.User = h.User
+		}
+		if stage.WorkingDir == "" {
+			stage.WorkingDir = h.WorkingDir
+		}
+		stages = append(stages, stage)
+	}
+	return stages
+}
+
+const (
+	// HookTypeExec runs hook commands inside container
+	HookTypeExec = "exec"
+	// HookTypeHTTP posts container context to hook urls
+	HookTypeHTTP = "http"
+
+	// HookAfterStart runs after container started
+	HookAfterStart = "after_start"
+	// HookBeforeStop runs before container stopped
+	HookBeforeStop = "before_stop"
+	// HookBeforeRemove runs before container removed
+	HookBeforeRemove = "before_remove"
+)
+
+// Validate checks hook and fills type and http code if not set
+func (h *Hook) Validate() error {
+	if h.Type == "" {
+		h.Type = HookTypeExec
+	}
+	switch h.Type {
+	case HookTypeExec:
+	case HookTypeHTTP:
+		if h.HTTPCode == 0 {
+			h.HTTPCode = http.StatusOK
+		}
+		cmds := [][]string{h.AfterStart, h.BeforeStop, h.BeforeRemove}
+		for _, stage := range h.Stages {
+			cmds = append(cmds, stage.Commands)
+		}
+		for _, stageCmds := range cmds {
+			for _, cmd := range stageCmds {
+				if u, err := url.Parse(cmd); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+					return NewDetailedErr(ErrInvalidHook, fmt.Sprintf("invalid url %q", cmd))
+				}
+			}
+		}
+	default:
+		return NewDetailedErr(ErrInvalidHook, fmt.Sprintf("unknown type %q", h.Type))
+	}
+	if h.Timeout < 0 || h.Retries < 0 || h.Backoff < 0 {
+		return NewDetailedErr(ErrInvalidHook, "timeout, retries and backoff must not be negative")
+	}
+	names := map[string]bool{HookAfterStart: true, HookBeforeStop: true, HookBeforeRemove: true}
+	for _, stage := range h.Stages {
+		if stage.Name == "" || names[stage.Name] {
+			return NewDetailedErr(ErrInvalidHook, fmt.Sprintf("stage name %q empty or duplicated", stage.Name))
+		}
+		names[stage.Name] = true
+		switch stage.On {
+		case "", HookAfterStart, HookBeforeStop, HookBeforeRemove:
+		default:
+			return NewDetailedErr(ErrInvalidHook, fmt.Sprintf("unknown event %q of stage %s", stage.On, stage.Name))
+		}
+	}
+	return nil
 }
 
+const (
+	// HealthCheckTCP checks tcp ports
+	HealthCheckTCP = "tcp"
+	// HealthCheckHTTP checks http url
+	HealthCheckHTTP = "http"
+	// HealthCheckCmd runs command inside container
+	HealthCheckCmd = "cmd"
+)
+
 // HealthCheck define healthcheck
+// Interval, Timeout and InitialDelay are in seconds, zero means agent default
 type HealthCheck struct {
-	TCPPorts []string `yaml:"tcp_ports,omitempty,flow"`
-	HTTPPort string   `yaml:"http_port"`
-	HTTPURL  string   `yaml:"url,omitempty"`
-	HTTPCode int      `yaml:"code,omitempty"`
+	Protocol           string   `yaml:"protocol,omitempty"`
+	TCPPorts           []string `yaml:"tcp_ports,omitempty,flow"`
+	HTTPPort           string   `yaml:"http_port"`
+	HTTPURL            string   `yaml:"url,omitempty"`
+	HTTPCode           int      `yaml:"code,omitempty"`
+	Cmd                []string `yaml:"cmd,omitempty,flow"`
+	Interval           int      `yaml:"interval,omitempty"`
+	Timeout            int      `yaml:"timeout,omitempty"`
+	HealthyThreshold   int      `yaml:"healthy_threshold,omitempty"`
+	UnhealthyThreshold int      `yaml:"unhealthy_threshold,omitempty"`
+	InitialDelay       int      `yaml:"initial_delay,omitempty"`
+}
+
+// Validate checks healthcheck and fills protocol if not set
+// an empty healthcheck of legacy clients is kept without protocol, agents take it as tcp check of no ports
+func (h *HealthCheck) Validate() error {
+	if h.Protocol == "" {
+		switch {
+		case h.HTTPPort != "":
+			h.Protocol = HealthCheckHTTP
+		case len(h.TCPPorts) > 0:
+			h.Protocol = HealthCheckTCP
+		case len(h.Cmd) > 0:
+			h.Protocol = HealthCheckCmd
+		}
+	}
+	switch h.Protocol {
+	case "":
+	case HealthCheckTCP:
+		if len(h.TCPPorts) == 0 {
+			return NewDetailedErr(ErrInvalidHealthCheck, "tcp ports must be provided")
+		}
+	case HealthCheckHTTP:
+		if h.HTTPPort == "" {
+			return NewDetailedErr(ErrInvalidHealthCheck, "http port must be provided")
+		}
+	case HealthCheckCmd:
+		if len(h.Cmd) == 0 {
+			return NewDetailedErr(ErrInvalidHealthCheck, "cmd must be provided")
+		}
+	default:
+		return NewDetailedErr(ErrInvalidHealthCheck, fmt.Sprintf("unknown protocol %q", h.Protocol))
+	}
+	if h.Interval < 0 || h.Timeout < 0 || h.InitialDelay < 0 || h.HealthyThreshold < 0 || h.UnhealthyThreshold < 0 {
+		return NewDetailedErr(ErrInvalidHealthCheck, "intervals and thresholds must not be negative")
+	}
+	if h.Interval > 0 && h.Timeout > h.Interval {
+		return NewDetailedErr(ErrInvalidHealthCheck, "timeout must not exceed interval")
+	}
+	return nil
+}
+
+const (
+	// RestartNo never restart
+	RestartNo = "no"
+	// RestartAlways always restart
+	RestartAlways = "always"
+	// RestartOnFailure restart when exit code is not zero
+	RestartOnFailure = "on-failure"
+	// RestartUnlessStopped restart unless stopped by user
+	RestartUnlessStopped = "unless-stopped"
+)
+
+// RestartPolicy define restart policy
+// Backoff is delay between restarts in seconds, zero means engine default
+// Window is seconds MaxRetries are counted in, zero means the whole lifetime of container
+type RestartPolicy struct {
+	Policy     string `yaml:"policy,omitempty"`
+	MaxRetries int    `yaml:"max_retries,omitempty"`
+	Backoff    int    `yaml:"backoff,omitempty"`
+	Window     int    `yaml:"window,omitempty"`
+}
+
+// ParseRestartPolicy parses legacy restart policy string like on-failure:5
+func ParseRestartPolicy(s string) (*RestartPolicy, error) {
+	parts := strings.SplitN(s, ":", 2)
+	r := &RestartPolicy{Policy: parts[0]}
+	if len(parts) == 2 {
+		retries, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, NewDetailedErr(ErrInvalidRestartPolicy, s)
+		}
+		r.MaxRetries = retries
+	}
+	return r, r.Validate()
+}
+
+// UnmarshalYAML accepts both legacy string and typed restart policy
+func (r *RestartPolicy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		p, err := ParseRestartPolicy(s)
+		if err != nil {
+			return err
+		}
+		*r = *p
+		return nil
+	}
+	type plain RestartPolicy
+	return unmarshal((*plain)(r))
+}
+
+// Validate checks restart policy
+func (r *RestartPolicy) Validate() error {
+	switch r.Policy {
+	case RestartNo, RestartAlways, RestartUnlessStopped:
+		if r.MaxRetries != 0 {
+			return NewDetailedErr(ErrInvalidRestartPolicy, fmt.Sprintf("max retries only works with %s", RestartOnFailure))
+		}
+	case RestartOnFailure:
+	default:
+		return NewDetailedErr(ErrInvalidRestartPolicy, fmt.Sprintf("unknown policy %q", r.Policy))
+	}
+	if r.MaxRetries < 0 || r.Backoff < 0 || r.Window < 0 {
+		return NewDetailedErr(ErrInvalidRestartPolicy, "max retries, backoff and window must not be negative")
+	}
+	if r.Window > 0 && r.MaxRetries == 0 {
+		return NewDetailedErr(ErrInvalidRestartPolicy, "window only works with max retries")
+	}
+	return nil
+}
+
+// String returns restart policy in legacy format
+func (r *RestartPolicy) String() string {
+	if r.MaxRetries > 0 {
+		return fmt.Sprintf("%s:%d", r.Policy, r.MaxRetries)
+	}
+	return r.Policy
+}
+
+// ulimit names supported by entrypoint
+const (
+	UlimitNofile = "nofile"
+	UlimitNproc  = "nproc"
+	UlimitCore   = "core"
+)
+
+// Ulimit is soft and hard limits of a resource, -1 means unlimited
+type Ulimit struct {
+	Soft int64 `yaml:"soft"`
+	Hard int64 `yaml:"hard"`
+}
+
+// ValidateUlimits checks names of ulimits are supported and soft limits don't exceed hard ones
+func ValidateUlimits(ulimits map[string]*Ulimit) error {
+	for name, ulimit := range ulimits {
+		switch name {
+		case UlimitNofile, UlimitNproc, UlimitCore:
+		default:
+			return NewDetailedErr(ErrInvalidUlimit, fmt.Sprintf("unknown ulimit %q", name))
+		}
+		if ulimit == nil || ulimit.Soft < -1 || ulimit.Hard < -1 {
+			return NewDetailedErr(ErrInvalidUlimit, fmt.Sprintf("%s must be -1 or not negative", name))
+		}
+		if ulimit.Hard != -1 && (ulimit.Soft == -1 || ulimit.Soft > ulimit.Hard) {
+			return NewDetailedErr(ErrInvalidUlimit, fmt.Sprintf("soft limit of %s exceeds hard limit", name))
+		}
+	}
+	return nil
 }
 
 // Entrypoint is a single entrypoint
+// Commands is exec form of Command, it won't be split by shell rules
 type Entrypoint struct {
-	Name          string            `yaml:"name,omitempty"`
-	Command       string            `yaml:"cmd,omitempty"`
-	Privileged    bool              `yaml:"privileged,omitempty"`
-	Dir           string            `yaml:"dir,omitempty"`
-	Log           *LogConfig        `yaml:"log,omitempty"`
-	Publish       []string          `yaml:"publish,omitempty,flow"`
-	HealthCheck   *HealthCheck      `yaml:"healthcheck,omitempty,flow"`
-	Hook          *Hook             `yaml:"hook,omitempty,flow"`
-	RestartPolicy string            `yaml:"restart,omitempty"`
-	Sysctls       map[string]string `yaml:"sysctls,omitempty,flow"`
+	Name          string             `yaml:"name,omitempty"`
+	Command       string             `yaml:"cmd,omitempty"`
+	Commands      []string           `yaml:"cmds,omitempty,flow"`
+	Privileged    bool               `yaml:"privileged,omitempty"`
+	Dir           string             `yaml:"dir,omitempty"`
+	Log           *LogConfig         `yaml:"log,omitempty"`
+	Publish       []string           `yaml:"publish,omitempty,flow"`
+	HealthCheck   *HealthCheck       `yaml:"healthcheck,omitempty,flow"`
+	Hook          *Hook              `yaml:"hook,omitempty,flow"`
+	RestartPolicy *RestartPolicy     `yaml:"restart,omitempty"`
+	Sysctls       map[string]string  `yaml:"sysctls,omitempty,flow"`
+	SkipPodHook   bool               `yaml:"skip_pod_hook,omitempty"`
+	StopTimeout   int                `yaml:"stop_timeout,omitempty"` // seconds before killed when stopped, zero means engine default
+	Ulimits       map[string]*Ulimit `yaml:"ulimits,omitempty"`      // nofile, nproc or core, daemon defaults if not set
 }
 
 // Bind define a single bind
@@ -34,3 +331,90 @@ type Bind struct {
 	InContainerPath string `yaml:"bind,omitempty"`
 	ReadOnly        bool   `yaml:"ro,omitempty"`
 }
+
+// Specs define app.yaml of an app
+type Specs struct {
+	Appname     string                 `yaml:"appname,omitempty"`
+	Entrypoints map[string]*Entrypoint `yaml:"entrypoints,omitempty,flow"`
+	Volumes     []string               `yaml:"volumes,omitempty,flow"`
+	DNS         []string               `yaml:"dns,omitempty,flow"`
+	ExtraHosts  []string               `yaml:"hosts,omitempty,flow"`
+	Labels      map[string]string      `yaml:"labels,omitempty,flow"`
+}
+
+// LoadSpecs parses app.yaml content
+func LoadSpecs(content []byte) (*Specs, error) {
+	specs := &Specs{}
+	if err := yaml.Unmarshal(content, specs); err != nil {
+		return nil, err
+	}
+	return specs, specs.Validate()
+}
+
+// Validate checks specs
+// appname and entrypoint names are part of container name, so they can't contain `_`
+func (s *Specs) Validate() error {
+	if s.Appname == "" || strings.Contains(s.Appname, "_") {
+		return NewDetailedErr(ErrInvalidSpecs, fmt.Sprintf("invalid appname %q", s.Appname))
+	}
+	if len(s.Entrypoints) == 0 {
+		return ErrNoEntryInSpec
+	}
+	for name, entry := range s.Entrypoints {
+		if name == "" || strings.Contains(name, "_") {
+			return NewDetailedErr(ErrInvalidSpecs, fmt.Sprintf("invalid entrypoint name %q", name))
+		}
+		if entry == nil || (entry.Command == "" && len(entry.Commands) == 0) {
+			return NewDetailedErr(ErrInvalidSpecs, fmt.Sprintf("entrypoint %s has no command", name))
+		}
+		if entry.HealthCheck != nil {
+			if err := entry.HealthCheck.Validate(); err != nil {
+				return err
+			}
+		}
+		if entry.RestartPolicy != nil {
+			if err := entry.RestartPolicy.Validate(); err != nil {
+				return err
+			}
+		}
+		if entry.Hook != nil {
+			if err := entry.Hook.Validate(); err != nil {
+				return err
+			}
+		}
+		if entry.StopTimeout < 0 {
+			return NewDetailedErr(ErrBadStopTimeout, entry.StopTimeout)
+		}
+	}
+	_, err := MakeVolumeBindings(s.Volumes)
+	return err
+}
+
+// DeployOptions makes deploy options for each entrypoint
+// base carries what specs don't have, like pod, image, count and resources
+func (s *Specs) DeployOptions(base *DeployOptions) (map[string]*DeployOptions, error) {
+	vbs, err := MakeVolumeBindings(s.Volumes)
+	if err != nil {
+		return nil, err
+	}
+	r := map[string]*DeployOptions{}
+	for name, entry := range s.Entrypoints {
+		opts := *base
+		e := *entry
+		e.Name = name
+		opts.Name = s.Appname
+		opts.Entrypoint = &e
+		opts.Volumes = append(append(VolumeBindings{}, vbs...), base.Volumes...)
+		opts.DNS = append(append([]string{}, s.DNS...), base.DNS...)
+		opts.ExtraHosts = append(append([]string{}, s.ExtraHosts...), base.ExtraHosts...)
+		opts.Labels = map[string]string{}
+		for k, v := range s.Labels {
+			opts.Labels[k] = v
+		}
+		for k, v := range base.Labels {
+			opts.Labels[k] = v
+		}
+		r[name] = &opts
+	}
+	return r, nil
+}
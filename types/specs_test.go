@@ -0,0 +1,184 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthCheckValidate(t *testing.T) {
+	// protocol inferred from legacy fields
+	h := &HealthCheck{TCPPorts: []string{"80"}}
+	assert.NoError(t, h.Validate())
+	assert.Equal(t, h.Protocol, HealthCheckTCP)
+	h = &HealthCheck{HTTPPort: "80", HTTPURL: "/healthz", HTTPCode: 200}
+	assert.NoError(t, h.Validate())
+	assert.Equal(t, h.Protocol, HealthCheckHTTP)
+	// empty healthcheck of legacy clients
+	h = &HealthCheck{}
+	assert.NoError(t, h.Validate())
+	assert.Empty(t, h.Protocol)
+	h = &HealthCheck{Interval: -1}
+	assert.Error(t, h.Validate())
+	// missing fields
+	h = &HealthCheck{Protocol: HealthCheckCmd}
+	assert.Error(t, h.Validate())
+	h = &HealthCheck{Protocol: "udp", TCPPorts: []string{"80"}}
+	assert.Error(t, h.Validate())
+	// intervals and thresholds
+	h = &HealthCheck{Cmd: []string{"true"}, Interval: 5, Timeout: 10}
+	assert.Error(t, h.Validate())
+	h = &HealthCheck{Cmd: []string{"true"}, HealthyThreshold: -1}
+	assert.Error(t, h.Validate())
+	h = &HealthCheck{Cmd: []string{"true"}, Interval: 10, Timeout: 5, HealthyThreshold: 1, UnhealthyThreshold: 3, InitialDelay: 30}
+	assert.NoError(t, h.Validate())
+	assert.Equal(t, h.Protocol, HealthCheckCmd)
+}
+
+func TestHookValidate(t *testing.T) {
+	h := &Hook{AfterStart: []string{"echo"}}
+	assert.NoError(t, h.Validate())
+	assert.Equal(t, h.Type, HookTypeExec)
+	h = &Hook{Type: HookTypeHTTP, AfterStart: []string{"http://sd/register"}}
+	assert.NoError(t, h.Validate())
+	assert.Equal(t, h.HTTPCode, 200)
+	h = &Hook{Type: HookTypeHTTP, BeforeStop: []string{"deregister"}}
+	assert.Error(t, h.Validate())
+	h = &Hook{Type: "grpc"}
+	assert.Error(t, h.Validate())
+	h = &Hook{Retries: -1}
+	assert.Error(t, h.Validate())
+	h = &Hook{Stages: []HookStage{{Name: "warmup"}, {Name: "warmup"}}}
+	assert.Error(t, h.Validate())
+	h = &Hook{Stages: []HookStage{{Name: "warmup", On: "after_build"}}}
+	assert.Error(t, h.Validate())
+	h = &Hook{Type: HookTypeHTTP, Stages: []HookStage{{Name: "register", Commands: []string{"register"}}}}
+	assert.Error(t, h.Validate())
+}
+
+func TestHookStagesOf(t *testing.T) {
+	h := &Hook{
+		AfterStart: []string{"a"},
+		Force:      true,
+		Stages: []HookStage{
+			{Name: "warmup", Commands: []string{"w"}},
+			{Name: "deregister", On: HookBeforeStop, Commands: []string{"d"}, Force: true},
+			{Name: "register", On: HookAfterStart, Commands: []string{"r"}},
+		},
+	}
+	stages := h.StagesOf(HookAfterStart)
+	assert.Len(t, stages, 3)
+	assert.Equal(t, HookAfterStart, stages[0].Name)
+	assert.True(t, stages[0].Force)
+	assert.Equal(t, "warmup", stages[1].Name)
+	assert.Equal(t, "register", stages[2].Name)
+	stages = h.StagesOf(HookBeforeStop)
+	assert.Len(t, stages, 1)
+	assert.Equal(t, "deregister", stages[0].Name)
+	assert.Len(t, h.StagesOf(HookBeforeRemove), 0)
+
+	h.User = "root"
+	h.WorkingDir = "/app"
+	h.Stages[0].User = "app"
+	stages = h.StagesOf(HookAfterStart)
+	assert.Equal(t, "root", stages[0].User)
+	assert.Equal(t, "app", stages[1].User)
+	assert.Equal(t, "/app", stages[1].WorkingDir)
+	assert.Equal(t, "root", stages[2].User)
+}
+
+func TestRestartPolicy(t *testing.T) {
+	r, err := ParseRestartPolicy("on-failure:5")
+	assert.NoError(t, err)
+	assert.Equal(t, r.Policy, RestartOnFailure)
+	assert.Equal(t, r.MaxRetries, 5)
+	assert.Equal(t, r.String(), "on-failure:5")
+	r, err = ParseRestartPolicy("always")
+	assert.NoError(t, err)
+	assert.Equal(t, r.String(), RestartAlways)
+	_, err = ParseRestartPolicy("on-failure:x")
+	assert.Error(t, err)
+	_, err = ParseRestartPolicy("always:3")
+	assert.Error(t, err)
+	_, err = ParseRestartPolicy("sometimes")
+	assert.Error(t, err)
+	r = &RestartPolicy{Policy: RestartOnFailure, MaxRetries: 3, Backoff: -1}
+	assert.Error(t, r.Validate())
+	r.Backoff = 10
+	assert.NoError(t, r.Validate())
+	r.Window = 60
+	assert.NoError(t, r.Validate())
+	r = &RestartPolicy{Policy: RestartOnFailure, Window: 60}
+	assert.Error(t, r.Validate())
+}
+
+func TestValidateUlimits(t *testing.T) {
+	assert.NoError(t, ValidateUlimits(nil))
+	assert.NoError(t, ValidateUlimits(map[string]*Ulimit{
+		UlimitNofile: {Soft: 1024, Hard: 65535},
+		UlimitNproc:  {Soft: 4096, Hard: -1},
+		UlimitCore:   {Soft: -1, Hard: -1},
+	}))
+	for _, ulimits := range []map[string]*Ulimit{
+		{"memlock": {Soft: 1, Hard: 1}},
+		{UlimitNofile: nil},
+		{UlimitNofile: {Soft: -2, Hard: 1}},
+		{UlimitNofile: {Soft: 2, Hard: 1}},
+		{UlimitCore: {Soft: -1, Hard: 1}},
+	} {
+		assert.True(t, errors.Is(ValidateUlimits(ulimits), ErrInvalidUlimit), ulimits)
+	}
+}
+
+func TestLoadSpecs(t *testing.T) {
+	content := `
+appname: test
+entrypoints:
+  web:
+    cmd: "python -m http.server"
+    restart: "on-failure:3"
+    healthcheck:
+      http_port: "8000"
+      url: /
+  worker:
+    cmds: ["sh", "-c", "echo 'a b'"]
+    restart:
+      policy: always
+      backoff: 5
+    ulimits:
+      nofile:
+        soft: 1024
+        hard: 4096
+volumes:
+  - /tmp:/tmp
+labels:
+  a: "1"
+`
+	specs, err := LoadSpecs([]byte(content))
+	assert.NoError(t, err)
+	assert.Equal(t, specs.Entrypoints["web"].RestartPolicy.MaxRetries, 3)
+	assert.Equal(t, specs.Entrypoints["worker"].RestartPolicy.Backoff, 5)
+	assert.Equal(t, &Ulimit{Soft: 1024, Hard: 4096}, specs.Entrypoints["worker"].Ulimits[UlimitNofile])
+	assert.Equal(t, specs.Entrypoints["web"].HealthCheck.Protocol, HealthCheckHTTP)
+
+	base := &DeployOptions{Podname: "p", Count: 2, Labels: map[string]string{"b": "2"}}
+	opts, err := specs.DeployOptions(base)
+	assert.NoError(t, err)
+	assert.Len(t, opts, 2)
+	assert.Equal(t, opts["worker"].Name, "test")
+	assert.Equal(t, opts["worker"].Entrypoint.Name, "worker")
+	assert.Equal(t, opts["worker"].Entrypoint.Commands[2], "echo 'a b'")
+	assert.Equal(t, opts["web"].Count, 2)
+	assert.Len(t, opts["web"].Volumes, 1)
+	assert.Len(t, opts["web"].Labels, 2)
+	assert.Nil(t, base.Entrypoint)
+
+	// invalid
+	_, err = LoadSpecs([]byte("appname: a_b\nentrypoints:\n  web:\n    cmd: ls\n"))
+	assert.Error(t, err)
+	_, err = LoadSpecs([]byte("appname: test\n"))
+	assert.Error(t, err)
+	_, err = LoadSpecs([]byte("appname: test\nentrypoints:\n  web:\n    restart: sometimes\n    cmd: ls\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	sysctlRegexp       = regexp.MustCompile(`^[a-z0-9_]+(\.[a-z0-9_-]+)+$`)
+	sysctlPrefixRegexp = regexp.MustCompile(`^[a-z0-9_]+(\.[a-z0-9_-]+)*$`)
+)
+
+// ValidateSysctlAllowlist checks patterns of a sysctl allowlist,
+// a pattern is a sysctl name like net.core.somaxconn, or a prefix ends with .* like net.*, or * for all
+func ValidateSysctlAllowlist(allowlist []string) error {
+	for _, pattern := range allowlist {
+		if pattern == "*" {
+			continue
+		}
+		if prefix := strings.TrimSuffix(pattern, ".*"); prefix != pattern {
+			if !sysctlPrefixRegexp.MatchString(prefix) {
+				return NewDetailedErr(ErrBadSysctl, pattern)
+			}
+			continue
+		}
+		if !sysctlRegexp.MatchString(pattern) {
+			return NewDetailedErr(ErrBadSysctl, pattern)
+		}
+	}
+	return nil
+}
+
+// ValidateSysctls checks names of sysctls, and that they are allowed by allowlist
+// all sysctls are allowed if allowlist is empty
+func ValidateSysctls(sysctls map[string]string, allowlist []string) error {
+	for name := range sysctls {
+		if !sysctlRegexp.MatchString(name) {
+			return NewDetailedErr(ErrBadSysctl, name)
+		}
+		if len(allowlist) > 0 && !sysctlAllowed(name, allowlist) {
+			return NewDetailedErr(ErrForbiddenSysctl, name)
+		}
+	}
+	return nil
+}
+
+func sysctlAllowed(name string, allowlist []string) bool {
+	for _, pattern := range allowlist {
+		if pattern == "*" || pattern == name {
+			return true
+		}
+		if prefix := strings.TrimSuffix(pattern, "*"); prefix != pattern && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSysctlAllowlist(t *testing.T) {
+	assert.NoError(t, ValidateSysctlAllowlist(nil))
+	assert.NoError(t, ValidateSysctlAllowlist([]string{"*", "net.*", "net.ipv4.*", "kernel.shm_rmid_forced"}))
+	for _, pattern := range []string{"", "net", "net*", "*.net", "net.*.tcp", "Net.*"} {
+		assert.True(t, errors.Is(ValidateSysctlAllowlist([]string{pattern}), ErrBadSysctl), pattern)
+	}
+}
+
+func TestValidateSysctls(t *testing.T) {
+	sysctls := map[string]string{"net.core.somaxconn": "1024", "net.ipv4.tcp_syncookies": "0"}
+	assert.NoError(t, ValidateSysctls(sysctls, nil))
+	assert.NoError(t, ValidateSysctls(sysctls, []string{"net.*"}))
+	assert.NoError(t, ValidateSysctls(sysctls, []string{"net.core.somaxconn", "net.ipv4.*"}))
+	assert.NoError(t, ValidateSysctls(sysctls, []string{"*"}))
+
+	err := ValidateSysctls(sysctls, []string{"net.core.*"})
+	assert.True(t, errors.Is(err, ErrForbiddenSysctl))
+	assert.Contains(t, err.Error(), "net.ipv4.tcp_syncookies")
+	// prefix matches whole parts only
+	assert.True(t, errors.Is(ValidateSysctls(map[string]string{"network.x": "1"}, []string{"net.*"}), ErrForbiddenSysctl))
+	assert.True(t, errors.Is(ValidateSysctls(map[string]string{"net..x": "1"}, nil), ErrBadSysctl))
+}
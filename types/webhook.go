@@ -0,0 +1,57 @@
+package types
+
+import "net/url"
+
+// webhookEvents are kinds of events webhooks can be registered for, lifecycle of containers
+var webhookEvents = []string{
+	EventContainerCreated, EventContainerRemoved, EventContainerStarted, EventContainerStopped,
+	EventContainerHealthy, EventContainerUnhealthy,
+}
+
+// EventWebhook is an endpoint events of containers are posted to in json
+// body is signed by hmac sha256 of secret if it's set
+type EventWebhook struct {
+	Name    string   `json:"name"`
+	URL     string   `json:"url"`
+	Secret  string   `json:"secret,omitempty"`
+	Kinds   []string `json:"kinds,omitempty"`   // all lifecycle events if empty
+	Appname string   `json:"appname,omitempty"` // containers of all apps if empty
+}
+
+// Validate checks webhook
+func (w *EventWebhook) Validate() error {
+	if w.Name == "" {
+		return NewDetailedErr(ErrBadWebhook, "name is required")
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return NewDetailedErr(ErrBadWebhook, "bad url "+w.URL)
+	}
+	for _, kind := range w.Kinds {
+		known := false
+		for _, k := range webhookEvents {
+			known = known || k == kind
+		}
+		if !known {
+			return NewDetailedErr(ErrBadWebhook, "unknown event "+kind)
+		}
+	}
+	return nil
+}
+
+// Match tells whether event is posted to webhook
+func (w *EventWebhook) Match(event *Event) bool {
+	if w.Appname != "" && w.Appname != event.Appname {
+		return false
+	}
+	kinds := w.Kinds
+	if len(kinds) == 0 {
+		kinds = webhookEvents
+	}
+	for _, kind := range kinds {
+		if kind == event.Kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventWebhookValidate(t *testing.T) {
+	w := &EventWebhook{URL: "http://127.0.0.1/hook"}
+	assert.True(t, errors.Is(w.Validate(), ErrBadWebhook))
+	w.Name = "hook"
+	assert.NoError(t, w.Validate())
+	w.URL = "ftp://127.0.0.1/hook"
+	assert.True(t, errors.Is(w.Validate(), ErrBadWebhook))
+	w.URL = "https://127.0.0.1/hook"
+	w.Kinds = []string{EventNodeAdded}
+	assert.True(t, errors.Is(w.Validate(), ErrBadWebhook))
+	w.Kinds = []string{EventContainerHealthy}
+	assert.NoError(t, w.Validate())
+}
+
+func TestEventWebhookMatch(t *testing.T) {
+	w := &EventWebhook{}
+	assert.True(t, w.Match(&Event{Kind: EventContainerCreated, Appname: "app"}))
+	assert.False(t, w.Match(&Event{Kind: EventImagePulled}))
+	w.Appname = "app"
+	w.Kinds = []string{EventContainerUnhealthy}
+	assert.False(t, w.Match(&Event{Kind: EventContainerCreated, Appname: "app"}))
+	assert.False(t, w.Match(&Event{Kind: EventContainerUnhealthy, Appname: "other"}))
+	assert.True(t, w.Match(&Event{Kind: EventContainerUnhealthy, Appname: "app"}))
+}
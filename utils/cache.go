@@ -1,34 +1,50 @@
 package utils
 
 import (
+	"context"
+	"io"
+	"sync"
 	"time"
 
 	"github.com/patrickmn/go-cache"
 	"github.com/projecteru2/core/engine"
+	log "github.com/sirupsen/logrus"
 )
 
 // EngineCache connections
 // otherwise they'll leak
+// clients idle longer than expire are closed, so are replaced or deleted ones
 type EngineCache struct {
+	sync.Mutex
 	cache *cache.Cache
 }
 
 // NewEngineCache creates Cache instance
 func NewEngineCache(expire time.Duration, cleanupInterval time.Duration) *EngineCache {
-	return &EngineCache{
-		cache: cache.New(expire, cleanupInterval),
-	}
+	c := cache.New(expire, cleanupInterval)
+	c.OnEvicted(func(host string, e interface{}) {
+		closeEngine(host, e.(engine.API))
+	})
+	return &EngineCache{cache: c}
 }
 
 // Set connection with host
 func (c *EngineCache) Set(host string, client engine.API) {
+	c.Lock()
+	defer c.Unlock()
+	if e, found := c.cache.Get(host); found && e.(engine.API) != client {
+		closeEngine(host, e.(engine.API))
+	}
 	c.cache.Set(host, client, cache.DefaultExpiration)
 }
 
-// Get connection by host
+// Get connection by host, expiration of it is renewed
 func (c *EngineCache) Get(host string) engine.API {
+	c.Lock()
+	defer c.Unlock()
 	e, found := c.cache.Get(host)
 	if found {
+		c.cache.Set(host, e, cache.DefaultExpiration)
 		return e.(engine.API)
 	}
 	return nil
@@ -36,5 +52,38 @@ func (c *EngineCache) Get(host string) engine.API {
 
 // Delete connection by host
 func (c *EngineCache) Delete(host string) {
+	c.Lock()
+	defer c.Unlock()
 	c.cache.Delete(host)
 }
+
+// CheckHealth calls Info of every client, clients failed are deleted and will be created again when used
+func (c *EngineCache) CheckHealth(ctx context.Context, timeout time.Duration) {
+	wg := sync.WaitGroup{}
+	for host, item := range c.cache.Items() {
+		wg.Add(1)
+		go func(host string, client engine.API) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			if _, err := client.Info(ctx); err != nil {
+				log.Warnf("[EngineCache] Client of %s is unhealthy %v, drop it", host, err)
+				c.Lock()
+				defer c.Unlock()
+				// it may be replaced while checking
+				if e, found := c.cache.Get(host); found && e.(engine.API) == client {
+					c.cache.Delete(host)
+				}
+			}
+		}(host, item.Object.(engine.API))
+	}
+	wg.Wait()
+}
+
+func closeEngine(host string, client engine.API) {
+	if closer, ok := client.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Warnf("[EngineCache] Close client of %s failed %v", host, err)
+		}
+	}
+}
@@ -1,11 +1,15 @@
 package utils
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	enginemocks "github.com/projecteru2/core/engine/mocks"
+	enginetypes "github.com/projecteru2/core/engine/types"
+	"github.com/projecteru2/core/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestCache(t *testing.T) {
@@ -20,3 +24,56 @@ func TestCache(t *testing.T) {
 	time.Sleep(3 * time.Second)
 	assert.Nil(t, c.Get(host))
 }
+
+type closableEngine struct {
+	enginemocks.API
+	closed int
+}
+
+func (e *closableEngine) Close() error {
+	e.closed++
+	return nil
+}
+
+func TestCacheClose(t *testing.T) {
+	c := NewEngineCache(time.Second, 100*time.Millisecond)
+
+	host := "1.1.1.1"
+	cli1 := &closableEngine{}
+	cli2 := &closableEngine{}
+	c.Set(host, cli1)
+	c.Set(host, cli1)
+	assert.Equal(t, cli1.closed, 0)
+	// replaced one is closed
+	c.Set(host, cli2)
+	assert.Equal(t, cli1.closed, 1)
+	// expiration is renewed by Get
+	for i := 0; i < 4; i++ {
+		time.Sleep(400 * time.Millisecond)
+		assert.Equal(t, c.Get(host), cli2)
+	}
+	assert.Equal(t, cli2.closed, 0)
+	// idle one is closed
+	time.Sleep(1500 * time.Millisecond)
+	assert.Nil(t, c.Get(host))
+	assert.Equal(t, cli2.closed, 1)
+	// deleted one is closed
+	c.Set(host, cli1)
+	c.Delete(host)
+	assert.Equal(t, cli1.closed, 2)
+}
+
+func TestCacheCheckHealth(t *testing.T) {
+	c := NewEngineCache(time.Minute, time.Minute)
+
+	healthy := &enginemocks.API{}
+	healthy.On("Info", mock.Anything).Return(&enginetypes.Info{}, nil)
+	unhealthy := &closableEngine{}
+	unhealthy.On("Info", mock.Anything).Return(nil, types.ErrCannotGetEngine)
+	c.Set("healthy", healthy)
+	c.Set("unhealthy", unhealthy)
+	c.CheckHealth(context.Background(), time.Second)
+	assert.Equal(t, c.Get("healthy"), healthy)
+	assert.Nil(t, c.Get("unhealthy"))
+	assert.Equal(t, unhealthy.closed, 1)
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncrypt(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 32)
+	ciphertext, err := Encrypt(key, []byte("password"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), "password")
+	plaintext, err := Decrypt(key, ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, "password", string(plaintext))
+
+	// nonce is random
+	another, err := Encrypt(key, []byte("password"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, ciphertext, another)
+
+	_, err = Decrypt(bytes.Repeat([]byte("x"), 32), ciphertext)
+	assert.Error(t, err)
+	_, err = Decrypt(key, ciphertext[:4])
+	assert.Error(t, err)
+	_, err = Encrypt([]byte("short"), []byte("password"))
+	assert.Error(t, err)
+}
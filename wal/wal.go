@@ -0,0 +1,158 @@
+package wal
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.etcd.io/bbolt"
+)
+
+var bucket = []byte("intents")
+
+// WAL is write-ahead log of core kept in a local file,
+// an intent is logged before side effects on engines and committed once they are saved in store,
+// intents left by a crashed core are replayed when it starts again.
+// a nil WAL logs nothing
+type WAL struct {
+	db *bbolt.DB
+}
+
+// Entry is an uncommitted intent
+type Entry struct {
+	ID      uint64          `json:"-"`
+	Kind    string          `json:"kind"`
+	Data    json.RawMessage `json:"data"`
+	Created int64           `json:"created"`
+}
+
+// Handler handles data of an intent left by a crash, the intent is committed once it succeeds
+type Handler func(ctx context.Context, data []byte) error
+
+// Open opens WAL in file, timeout is how long to wait for another core holding it
+func Open(path string, timeout time.Duration) (*WAL, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &WAL{db: db}, nil
+}
+
+// Close closes file of WAL
+func (w *WAL) Close() error {
+	if w == nil {
+		return nil
+	}
+	return w.db.Close()
+}
+
+// Log logs an intent of kind, data is saved as json, returns ID of the intent
+func (w *WAL) Log(kind string, data interface{}) (uint64, error) {
+	if w == nil {
+		return 0, nil
+	}
+	var ID uint64
+	return ID, w.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucket)
+		var err error
+		if ID, err = b.NextSequence(); err != nil {
+			return err
+		}
+		value, err := encode(kind, data)
+		if err != nil {
+			return err
+		}
+		return b.Put(key(ID), value)
+	})
+}
+
+// Update replaces data of an intent, e.g. with ID of container once it's created
+func (w *WAL) Update(ID uint64, kind string, data interface{}) error {
+	if w == nil {
+		return nil
+	}
+	value, err := encode(kind, data)
+	if err != nil {
+		return err
+	}
+	return w.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucket).Put(key(ID), value)
+	})
+}
+
+// Commit removes an intent, its side effects are either saved or undone
+func (w *WAL) Commit(ID uint64) error {
+	if w == nil {
+		return nil
+	}
+	return w.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucket).Delete(key(ID))
+	})
+}
+
+// Entries lists uncommitted intents in order of logging
+func (w *WAL) Entries() ([]*Entry, error) {
+	entries := []*Entry{}
+	if w == nil {
+		return entries, nil
+	}
+	return entries, w.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(k, v []byte) error {
+			entry := &Entry{ID: binary.BigEndian.Uint64(k)}
+			if err := json.Unmarshal(v, entry); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+}
+
+// Recover replays uncommitted intents by handlers of their kinds,
+// intents failed or without handler are kept for next time
+func (w *WAL) Recover(ctx context.Context, handlers map[string]Handler) {
+	entries, err := w.Entries()
+	if err != nil {
+		log.Errorf("[WAL] List intents failed %v", err)
+		return
+	}
+	for _, entry := range entries {
+		handler, ok := handlers[entry.Kind]
+		if !ok {
+			log.Warnf("[WAL] No handler of intent %d %s", entry.ID, entry.Kind)
+			continue
+		}
+		if err := handler(ctx, entry.Data); err != nil {
+			log.Errorf("[WAL] Recover intent %d %s %s failed %v", entry.ID, entry.Kind, entry.Data, err)
+			continue
+		}
+		if err := w.Commit(entry.ID); err != nil {
+			log.Errorf("[WAL] Commit intent %d %s failed %v", entry.ID, entry.Kind, err)
+			continue
+		}
+		log.Infof("[WAL] Intent %d %s %s recovered", entry.ID, entry.Kind, entry.Data)
+	}
+}
+
+func encode(kind string, data interface{}) ([]byte, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&Entry{Kind: kind, Data: raw, Created: time.Now().Unix()})
+}
+
+func key(ID uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, ID)
+	return k
+}
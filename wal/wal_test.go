@@ -0,0 +1,84 @@
+package wal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type intent struct {
+	Name string `json:"name"`
+	ID   string `json:"id,omitempty"`
+}
+
+func TestWAL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wal")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "core.wal")
+
+	w, err := Open(path, time.Second)
+	assert.NoError(t, err)
+	ID1, err := w.Log("create", &intent{Name: "a"})
+	assert.NoError(t, err)
+	ID2, err := w.Log("create", &intent{Name: "b"})
+	assert.NoError(t, err)
+	ID3, err := w.Log("unknown", &intent{Name: "c"})
+	assert.NoError(t, err)
+	assert.NoError(t, w.Update(ID2, "create", &intent{Name: "b", ID: "ID-b"}))
+	assert.NoError(t, w.Commit(ID1))
+	// another core can't open it
+	_, err = Open(path, 10*time.Millisecond)
+	assert.Error(t, err)
+	assert.NoError(t, w.Close())
+
+	// replayed after restart
+	w, err = Open(path, time.Second)
+	assert.NoError(t, err)
+	defer w.Close()
+	entries, err := w.Entries()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, ID2, entries[0].ID)
+	assert.Equal(t, ID3, entries[1].ID)
+
+	fail := true
+	handled := []*intent{}
+	handlers := map[string]Handler{"create": func(ctx context.Context, data []byte) error {
+		i := &intent{}
+		if err := json.Unmarshal(data, i); err != nil {
+			return err
+		}
+		handled = append(handled, i)
+		if fail {
+			return errors.New("engine down")
+		}
+		return nil
+	}}
+	w.Recover(context.Background(), handlers)
+	entries, err = w.Entries()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	fail = false
+	w.Recover(context.Background(), handlers)
+	entries, err = w.Entries()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "unknown", entries[0].Kind)
+	assert.Equal(t, []*intent{{Name: "b", ID: "ID-b"}, {Name: "b", ID: "ID-b"}}, handled)
+
+	// nil WAL does nothing
+	var nilWAL *WAL
+	ID, err := nilWAL.Log("create", &intent{})
+	assert.NoError(t, err)
+	assert.NoError(t, nilWAL.Commit(ID))
+	nilWAL.Recover(context.Background(), handlers)
+}
@@ -0,0 +1,230 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" // nolint
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/projecteru2/core/cluster"
+	"github.com/projecteru2/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	pathPrefix       = "/webhook/"
+	zeroCommit       = "0000000000000000000000000000000000000000"
+	githubEvent      = "X-GitHub-Event"
+	githubSignature  = "X-Hub-Signature-256"
+	githubSignature1 = "X-Hub-Signature"
+	gitlabEvent      = "X-Gitlab-Event"
+	gitlabToken      = "X-Gitlab-Token"
+)
+
+var (
+	errBadSignature = errors.New("bad signature")
+	errNoEvent      = errors.New("not a github or gitlab event")
+)
+
+// Webhook builds and rolls out apps on github and gitlab push events
+type Webhook struct {
+	cluster cluster.Cluster
+	config  types.WebhookConfig
+	locks   sync.Map
+	// deploy runs in background, replaceable for testing
+	deploy func(ctx context.Context, appname string, app types.WebhookApp, commit string)
+}
+
+type pushEvent struct {
+	Ref         string `json:"ref"`
+	After       string `json:"after"`
+	CheckoutSHA string `json:"checkout_sha"` // gitlab only
+}
+
+// New .
+func New(cluster cluster.Cluster, config types.WebhookConfig) *Webhook {
+	w := &Webhook{cluster: cluster, config: config}
+	w.deploy = func(ctx context.Context, appname string, app types.WebhookApp, commit string) {
+		go w.doDeploy(ctx, appname, app, commit)
+	}
+	return w
+}
+
+// ServeHTTP handles POST /webhook/:appname
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.URL.Path, pathPrefix) {
+		http.NotFound(rw, r)
+		return
+	}
+	appname := strings.TrimPrefix(r.URL.Path, pathPrefix)
+	app, ok := w.config.Apps[appname]
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	push, err := verify(r.Header, body, app.Secret)
+	switch {
+	case err == errBadSignature:
+		log.Warnf("[Webhook] Bad signature of app %s from %s", appname, r.RemoteAddr)
+		http.Error(rw, err.Error(), http.StatusUnauthorized)
+		return
+	case err != nil:
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	case !push:
+		// ping or other events
+		fmt.Fprintln(rw, "ignored")
+		return
+	}
+
+	event := &pushEvent{}
+	if err := json.Unmarshal(body, event); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	commit := event.CheckoutSHA
+	if commit == "" {
+		commit = event.After
+	}
+	branch := strings.TrimPrefix(event.Ref, "refs/heads/")
+	if commit == "" || commit == zeroCommit || !strings.HasPrefix(event.Ref, "refs/heads/") || (app.Branch != "" && app.Branch != branch) {
+		// branch deleted, tag pushed or other branches
+		fmt.Fprintln(rw, "ignored")
+		return
+	}
+
+	log.Infof("[Webhook] Push of app %s to %s at %s", appname, branch, commit)
+	w.deploy(context.Background(), appname, app, commit)
+	rw.WriteHeader(http.StatusAccepted)
+	fmt.Fprintln(rw, commit)
+}
+
+// verify checks signature or token of event, returns if it is a push event
+func verify(header http.Header, body []byte, secret string) (bool, error) {
+	switch {
+	case header.Get(githubEvent) != "":
+		if secret != "" && !verifyGithub(header, body, secret) {
+			return false, errBadSignature
+		}
+		return header.Get(githubEvent) == "push", nil
+	case header.Get(gitlabEvent) != "":
+		if secret != "" && subtle.ConstantTimeCompare([]byte(header.Get(gitlabToken)), []byte(secret)) != 1 {
+			return false, errBadSignature
+		}
+		return header.Get(gitlabEvent) == "Push Hook", nil
+	default:
+		return false, errNoEvent
+	}
+}
+
+func verifyGithub(header http.Header, body []byte, secret string) bool {
+	var h func() hash.Hash
+	var signature string
+	switch {
+	case header.Get(githubSignature) != "":
+		h, signature = sha256.New, strings.TrimPrefix(header.Get(githubSignature), "sha256=")
+	case header.Get(githubSignature1) != "":
+		h, signature = sha1.New, strings.TrimPrefix(header.Get(githubSignature1), "sha1=")
+	default:
+		return false
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body) // nolint
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// doDeploy builds image of commit and replaces containers with it,
+// deploys of the same app are serialized
+func (w *Webhook) doDeploy(ctx context.Context, appname string, app types.WebhookApp, commit string) {
+	mutex, _ := w.locks.LoadOrStore(appname, &sync.Mutex{})
+	mutex.(*sync.Mutex).Lock()
+	defer mutex.(*sync.Mutex).Unlock()
+
+	image, err := w.build(ctx, app, commit)
+	if err != nil {
+		log.Errorf("[Webhook] Build app %s at %s failed %v", appname, commit, err)
+		return
+	}
+	log.Infof("[Webhook] Built app %s image %s", appname, image)
+	if app.Replace == nil {
+		return
+	}
+
+	entrypoint := app.Replace.Entrypoint
+	ch, err := w.cluster.ReplaceContainer(ctx, &types.ReplaceOptions{
+		DeployOptions: types.DeployOptions{
+			Name:       appname,
+			Entrypoint: &entrypoint,
+			Podname:    app.Replace.Podname,
+			Image:      image,
+			Env:        app.Replace.Env,
+		},
+		NetworkInherit: app.Replace.NetworkInherit,
+	})
+	if err != nil {
+		log.Errorf("[Webhook] Replace app %s failed %v", appname, err)
+		return
+	}
+	for m := range ch {
+		if m.Error != nil {
+			log.Errorf("[Webhook] Replace container of app %s failed %v", appname, m.Error)
+			continue
+		}
+		log.Infof("[Webhook] Replaced container %s of app %s", m.Remove.ContainerID, appname)
+	}
+}
+
+// build returns the first pushed ref of image
+func (w *Webhook) build(ctx context.Context, app types.WebhookApp, commit string) (string, error) {
+	builds := &types.Builds{Stages: app.Builds.Stages, Builds: map[string]*types.Build{}}
+	for stage, build := range app.Builds.Builds {
+		b := *build
+		if b.Repo != "" {
+			b.Commit = commit
+		}
+		builds.Builds[stage] = &b
+	}
+	ch, err := w.cluster.BuildImage(ctx, &types.BuildOptions{
+		Name:        app.Image,
+		Tags:        []string{commit},
+		BuildMethod: types.BuildFromSCM,
+		Builds:      builds,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	image := ""
+	for m := range ch {
+		if m.Error != "" {
+			err = errors.New(m.Error)
+		}
+		if m.Status == "finished" && image == "" {
+			image = m.Progress
+		}
+	}
+	if err == nil && image == "" {
+		err = types.ErrNoImage
+	}
+	return image, err
+}
@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	clustermocks "github.com/projecteru2/core/cluster/mocks"
+	"github.com/projecteru2/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const commit = "3b18e512dba79e4c8300dd08aeb37f8e728b8dad"
+
+func newTestWebhook() (*Webhook, *[]string) {
+	w := New(&clustermocks.Cluster{}, types.WebhookConfig{
+		Apps: map[string]types.WebhookApp{
+			"app": {Secret: "secret", Branch: "master"},
+		},
+	})
+	deployed := []string{}
+	w.deploy = func(_ context.Context, appname string, _ types.WebhookApp, commit string) {
+		deployed = append(deployed, appname+"@"+commit)
+	}
+	return w, &deployed
+}
+
+func serve(w *Webhook, path string, body string, header map[string]string) int {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func sign(body string) string {
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestServeHTTP(t *testing.T) {
+	w, deployed := newTestWebhook()
+	push := `{"ref": "refs/heads/master", "after": "` + commit + `"}`
+
+	assert.Equal(t, http.StatusNotFound, serve(w, "/webhook/nothing", push, nil))
+	// not github or gitlab
+	assert.Equal(t, http.StatusBadRequest, serve(w, "/webhook/app", push, nil))
+	// bad signature
+	assert.Equal(t, http.StatusUnauthorized, serve(w, "/webhook/app", push, map[string]string{githubEvent: "push", githubSignature: sign("other")}))
+	assert.Equal(t, http.StatusUnauthorized, serve(w, "/webhook/app", push, map[string]string{gitlabEvent: "Push Hook", gitlabToken: "bad"}))
+	// ping
+	assert.Equal(t, http.StatusOK, serve(w, "/webhook/app", "{}", map[string]string{githubEvent: "ping", githubSignature: sign("{}")}))
+	// other branch
+	other := `{"ref": "refs/heads/dev", "after": "` + commit + `"}`
+	assert.Equal(t, http.StatusOK, serve(w, "/webhook/app", other, map[string]string{githubEvent: "push", githubSignature: sign(other)}))
+	// branch deleted
+	deleted := `{"ref": "refs/heads/master", "after": "` + zeroCommit + `"}`
+	assert.Equal(t, http.StatusOK, serve(w, "/webhook/app", deleted, map[string]string{githubEvent: "push", githubSignature: sign(deleted)}))
+	assert.Empty(t, *deployed)
+
+	assert.Equal(t, http.StatusAccepted, serve(w, "/webhook/app", push, map[string]string{githubEvent: "push", githubSignature: sign(push)}))
+	gitlab := `{"ref": "refs/heads/master", "after": "x", "checkout_sha": "` + commit + `"}`
+	assert.Equal(t, http.StatusAccepted, serve(w, "/webhook/app", gitlab, map[string]string{gitlabEvent: "Push Hook", gitlabToken: "secret"}))
+	assert.Equal(t, []string{"app@" + commit, "app@" + commit}, *deployed)
+}
+
+func TestDoDeploy(t *testing.T) {
+	cluster := &clustermocks.Cluster{}
+	w := New(cluster, types.WebhookConfig{})
+	ctx := context.Background()
+	app := types.WebhookApp{
+		Image: "app",
+		Builds: types.Builds{
+			Stages: []string{"build"},
+			Builds: map[string]*types.Build{"build": {Repo: "git@github.com:projecteru2/core.git"}},
+		},
+	}
+
+	// build failed
+	ch := make(chan *types.BuildImageMessage, 1)
+	ch <- &types.BuildImageMessage{Error: "failed"}
+	close(ch)
+	cluster.On("BuildImage", mock.Anything, mock.Anything).Return(ch, nil).Once()
+	w.doDeploy(ctx, "app", app, commit)
+	cluster.AssertNotCalled(t, "ReplaceContainer", mock.Anything, mock.Anything)
+
+	ch = make(chan *types.BuildImageMessage, 1)
+	ch <- &types.BuildImageMessage{Status: "finished", Progress: "hub/app:" + commit}
+	close(ch)
+	cluster.On("BuildImage", mock.Anything, mock.MatchedBy(func(opts *types.BuildOptions) bool {
+		return opts.Builds.Builds["build"].Commit == commit && opts.Tags[0] == commit
+	})).Return(ch, nil).Once()
+	replaceCh := make(chan *types.ReplaceContainerMessage, 1)
+	replaceCh <- &types.ReplaceContainerMessage{Create: &types.CreateContainerMessage{ContainerID: "new"}, Remove: &types.RemoveContainerMessage{ContainerID: "old"}}
+	close(replaceCh)
+	cluster.On("ReplaceContainer", mock.Anything, mock.MatchedBy(func(opts *types.ReplaceOptions) bool {
+		return opts.Name == "app" && opts.Image == "hub/app:"+commit && opts.Entrypoint.Name == "web" && opts.NetworkInherit
+	})).Return(replaceCh, nil).Once()
+	app.Replace = &types.WebhookReplace{Entrypoint: types.Entrypoint{Name: "web"}, NetworkInherit: true}
+	w.doDeploy(ctx, "app", app, commit)
+	cluster.AssertExpectations(t)
+	// spec in config untouched
+	assert.Empty(t, app.Builds.Builds["build"].Commit)
+}